@@ -5,10 +5,14 @@
 package cmd
 
 import (
+	"archive/zip"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/urfave/cli"
@@ -32,6 +36,9 @@ to make automatic initialization process more smoothly`,
 			subcmdRewriteAuthorizedKeys,
 			subcmdSyncRepositoryHooks,
 			subcmdReinitMissingRepositories,
+			subcmdExportRepository,
+			subcmdImportRepository,
+			subcmdImportIssues,
 		},
 	}
 
@@ -131,6 +138,44 @@ to make automatic initialization process more smoothly`,
 			stringFlag("config, c", "", "Custom configuration file path"),
 		},
 	}
+
+	subcmdExportRepository = cli.Command{
+		Name:   "export-repo",
+		Usage:  "Export a repository with its data into a portable bundle",
+		Action: runExportRepository,
+		Flags: []cli.Flag{
+			stringFlag("repo", "", "Repository to export in the form of <owner>/<name>"),
+			stringFlag("target", "", "Path of the bundle, defaults to <owner>-<name>.zip in the current directory"),
+			stringFlag("config, c", "", "Custom configuration file path"),
+		},
+	}
+
+	subcmdImportRepository = cli.Command{
+		Name:   "import-repo",
+		Usage:  "Import a repository from a bundle exported by another Gogs instance",
+		Action: runImportRepository,
+		Flags: []cli.Flag{
+			stringFlag("source", "", "Path of the bundle"),
+			stringFlag("owner", "", "Username of the user or organization to own the repository"),
+			stringFlag("name", "", "Name of the repository, defaults to the original name"),
+			stringFlag("doer", "", "Username of the user to perform the import, defaults to the owner"),
+			stringFlag("config, c", "", "Custom configuration file path"),
+		},
+	}
+
+	subcmdImportIssues = cli.Command{
+		Name:   "import-issues",
+		Usage:  "Create issues in a repository from a CSV or JSON file",
+		Action: runImportIssues,
+		Flags: []cli.Flag{
+			stringFlag("repo", "", "Repository to create issues in, in the form of <owner>/<name>"),
+			stringFlag("source", "", "Path of the CSV or JSON file"),
+			stringFlag("format", "", "Format of the file, either csv or json, defaults to the file extension"),
+			stringFlag("doer", "", "Username of the user to create issues as"),
+			boolFlag("dry-run", "Only validate the file without creating any issue"),
+			stringFlag("config, c", "", "Custom configuration file path"),
+		},
+	}
 )
 
 func runCreateUser(c *cli.Context) error {
@@ -191,3 +236,162 @@ func adminDashboardOperation(operation func() error, successMessage string) func
 		return nil
 	}
 }
+
+func runExportRepository(c *cli.Context) (err error) {
+	if !c.IsSet("repo") {
+		return errors.New("Repository is not specified")
+	}
+
+	err = conf.Init(c.String("config"))
+	if err != nil {
+		return errors.Wrap(err, "init configuration")
+	}
+	conf.InitLogging(true)
+
+	if _, err = database.SetEngine(); err != nil {
+		return errors.Wrap(err, "set engine")
+	}
+
+	repo, err := database.GetRepositoryByRef(c.String("repo"))
+	if err != nil {
+		return errors.Wrap(err, "get repository")
+	}
+
+	target := c.String("target")
+	if target == "" {
+		target = strings.ReplaceAll(c.String("repo"), "/", "-") + ".zip"
+	}
+	f, err := os.Create(target)
+	if err != nil {
+		return errors.Wrap(err, "create bundle")
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(target)
+		}
+	}()
+
+	if err = database.ExportRepository(context.Background(), repo, f); err != nil {
+		return errors.Wrap(err, "export repository")
+	}
+
+	fmt.Printf("Repository %q has been successfully exported to %q!\n", c.String("repo"), target)
+	return nil
+}
+
+func runImportRepository(c *cli.Context) error {
+	if !c.IsSet("source") {
+		return errors.New("Source is not specified")
+	} else if !c.IsSet("owner") {
+		return errors.New("Owner is not specified")
+	}
+
+	err := conf.Init(c.String("config"))
+	if err != nil {
+		return errors.Wrap(err, "init configuration")
+	}
+	conf.InitLogging(true)
+
+	if _, err = database.SetEngine(); err != nil {
+		return errors.Wrap(err, "set engine")
+	}
+
+	ctx := context.Background()
+	owner, err := database.Handle.Users().GetByUsername(ctx, c.String("owner"))
+	if err != nil {
+		return errors.Wrap(err, "get owner")
+	}
+	doer := owner
+	if c.IsSet("doer") {
+		doer, err = database.Handle.Users().GetByUsername(ctx, c.String("doer"))
+		if err != nil {
+			return errors.Wrap(err, "get doer")
+		}
+	} else if owner.IsOrganization() {
+		return errors.New("Doer must be specified when the owner is an organization")
+	}
+
+	zr, err := zip.OpenReader(c.String("source"))
+	if err != nil {
+		return errors.Wrap(err, "open bundle")
+	}
+	defer func() { _ = zr.Close() }()
+
+	repo, err := database.ImportRepository(ctx, doer, owner, &zr.Reader, database.ImportRepoOptions{Name: c.String("name")})
+	if err != nil {
+		return errors.Wrap(err, "import repository")
+	}
+
+	fmt.Printf("Repository %q has been successfully imported!\n", repo.FullName())
+	return nil
+}
+
+func runImportIssues(c *cli.Context) error {
+	if !c.IsSet("repo") {
+		return errors.New("Repository is not specified")
+	} else if !c.IsSet("source") {
+		return errors.New("Source is not specified")
+	} else if !c.IsSet("doer") {
+		return errors.New("Doer is not specified")
+	}
+
+	format := c.String("format")
+	if format == "" {
+		format = strings.ToLower(strings.TrimPrefix(filepath.Ext(c.String("source")), "."))
+	}
+
+	err := conf.Init(c.String("config"))
+	if err != nil {
+		return errors.Wrap(err, "init configuration")
+	}
+	conf.InitLogging(true)
+
+	if _, err = database.SetEngine(); err != nil {
+		return errors.Wrap(err, "set engine")
+	}
+
+	repo, err := database.GetRepositoryByRef(c.String("repo"))
+	if err != nil {
+		return errors.Wrap(err, "get repository")
+	}
+	doer, err := database.Handle.Users().GetByUsername(context.Background(), c.String("doer"))
+	if err != nil {
+		return errors.Wrap(err, "get doer")
+	}
+
+	f, err := os.Open(c.String("source"))
+	if err != nil {
+		return errors.Wrap(err, "open source")
+	}
+	defer func() { _ = f.Close() }()
+
+	records, err := database.ReadIssueRecords(f, format)
+	if err != nil {
+		return errors.Wrap(err, "read records")
+	}
+
+	report, err := database.ImportIssues(doer, repo, records, c.Bool("dry-run"))
+	if err != nil {
+		return errors.Wrap(err, "import issues")
+	}
+	if len(report.Errors) > 0 {
+		for _, e := range report.Errors {
+			if e.Row > 0 {
+				fmt.Printf("Record %d: %s\n", e.Row, e.Message)
+			} else {
+				fmt.Println(e.Message)
+			}
+		}
+		return errors.Errorf("found %d problems in %d records, no issue has been created", len(report.Errors), report.Total)
+	}
+
+	if report.DryRun {
+		fmt.Printf("All %d records are valid, no issue has been created.\n", report.Total)
+	} else {
+		fmt.Printf("%d issues have been successfully created in %q!\n", len(report.Created), repo.FullName())
+	}
+	return nil
+}
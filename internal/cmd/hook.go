@@ -7,6 +7,7 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/url"
@@ -25,6 +26,9 @@ import (
 	"gogs.io/gogs/internal/database"
 	"gogs.io/gogs/internal/email"
 	"gogs.io/gogs/internal/httplib"
+	"gogs.io/gogs/internal/osutil"
+	"gogs.io/gogs/internal/packcache"
+	"gogs.io/gogs/internal/tool"
 )
 
 var (
@@ -39,6 +43,7 @@ var (
 			subcmdHookPreReceive,
 			subcmdHookUpadte,
 			subcmdHookPostReceive,
+			subcmdHookPackObjects,
 		},
 	}
 
@@ -60,6 +65,13 @@ var (
 		Description: "This command should only be called by Git",
 		Action:      runHookPostReceive,
 	}
+	subcmdHookPackObjects = cli.Command{
+		Name:            "pack-objects",
+		Usage:           "Delegate uploadpack.packObjectsHook of Git",
+		Description:     "This command should only be called by Git",
+		Action:          runHookPackObjects,
+		SkipFlagParsing: true,
+	}
 )
 
 func runHookPreReceive(c *cli.Context) error {
@@ -70,6 +82,9 @@ func runHookPreReceive(c *cli.Context) error {
 
 	isWiki := strings.Contains(os.Getenv(database.ENV_REPO_CUSTOM_HOOKS_PATH), ".wiki.git/")
 
+	// Whether any of the references is being created or updated, i.e. not deleted.
+	hasNewObjects := false
+
 	buf := bytes.NewBuffer(nil)
 	scanner := bufio.NewScanner(os.Stdin)
 	for scanner.Scan() {
@@ -87,6 +102,9 @@ func runHookPreReceive(c *cli.Context) error {
 		oldCommitID := string(fields[0])
 		newCommitID := string(fields[1])
 		branchName := git.RefShortName(string(fields[2]))
+		if newCommitID != git.EmptyID {
+			hasNewObjects = true
+		}
 
 		// Branch protection
 		repoID := com.StrTo(os.Getenv(database.ENV_REPO_ID)).MustInt64()
@@ -134,25 +152,52 @@ func runHookPreReceive(c *cli.Context) error {
 		}
 	}
 
-	customHooksPath := filepath.Join(os.Getenv(database.ENV_REPO_CUSTOM_HOOKS_PATH), "pre-receive")
-	if !com.IsFile(customHooksPath) {
-		return nil
+	// Storage quota, pushes that only delete references are always allowed so that
+	// users are able to free up space.
+	if hasNewObjects && conf.Quota.Enabled {
+		checkRepositoryQuota()
 	}
 
-	var hookCmd *exec.Cmd
-	if conf.IsWindowsRuntime() {
-		hookCmd = exec.Command("bash.exe", "custom_hooks/pre-receive")
-	} else {
-		hookCmd = exec.Command(customHooksPath)
+	runCustomHooks("pre-receive", nil, buf.Bytes())
+	return nil
+}
+
+// checkRepositoryQuota fails the push when objects received would exceed the
+// repository storage quota of the repository owner.
+func checkRepositoryQuota() {
+	ctx := context.Background()
+	ownerName := os.Getenv(database.ENV_REPO_OWNER_NAME)
+	owner, err := database.Handle.Users().GetByUsername(ctx, ownerName)
+	if err != nil {
+		fail("Internal error", "Failed to get repository owner %q: %v", ownerName, err)
 	}
-	hookCmd.Dir = database.RepoPath(os.Getenv(database.ENV_REPO_OWNER_NAME), os.Getenv(database.ENV_REPO_NAME))
-	hookCmd.Stdout = os.Stdout
-	hookCmd.Stdin = buf
-	hookCmd.Stderr = os.Stderr
-	if err := hookCmd.Run(); err != nil {
-		fail("Internal error", "Failed to execute custom pre-receive hook: %v", err)
+
+	repoID := com.StrTo(os.Getenv(database.ENV_REPO_ID)).MustInt64()
+	repo, err := database.Handle.Repositories().GetByID(ctx, repoID)
+	if err != nil {
+		fail("Internal error", "Failed to get repository %d: %v", repoID, err)
+	}
+
+	// The stored size of the repository is only refreshed after pushes, thus
+	// could be stale, use the actual size of objects on disk instead. Objects
+	// received are kept in the quarantine directory under the objects directory
+	// until all hooks are passed, so they are counted as well, see
+	// https://git-scm.com/docs/git-receive-pack#_quarantine_environment.
+	objectsPath := filepath.Join(database.RepoPath(ownerName, os.Getenv(database.ENV_REPO_NAME)), "objects")
+	size, err := osutil.DirSize(objectsPath)
+	if err != nil {
+		fail("Internal error", "Failed to get size of objects directory %q: %v", objectsPath, err)
+	}
+
+	err = database.Handle.Quotas().Check(ctx, owner, database.QuotaTypeRepository, size-repo.Size)
+	if err != nil {
+		if database.IsErrQuotaExceeded(err) {
+			quotaErr := err.(database.ErrQuotaExceeded)
+			fail(fmt.Sprintf("Repository storage quota of %q exceeded (%s used of %s)",
+				owner.Name, tool.FileSize(quotaErr.Usage), tool.FileSize(quotaErr.Limit)), "")
+		}
+		fail("Internal error", "Failed to check repository storage quota: %v", err)
 	}
-	return nil
 }
 
 func runHookUpdate(c *cli.Context) error {
@@ -168,24 +213,7 @@ func runHookUpdate(c *cli.Context) error {
 		fail("First argument 'refName' is empty", "First argument 'refName' is empty")
 	}
 
-	customHooksPath := filepath.Join(os.Getenv(database.ENV_REPO_CUSTOM_HOOKS_PATH), "update")
-	if !com.IsFile(customHooksPath) {
-		return nil
-	}
-
-	var hookCmd *exec.Cmd
-	if conf.IsWindowsRuntime() {
-		hookCmd = exec.Command("bash.exe", append([]string{"custom_hooks/update"}, args...)...)
-	} else {
-		hookCmd = exec.Command(customHooksPath, args...)
-	}
-	hookCmd.Dir = database.RepoPath(os.Getenv(database.ENV_REPO_OWNER_NAME), os.Getenv(database.ENV_REPO_NAME))
-	hookCmd.Stdout = os.Stdout
-	hookCmd.Stdin = os.Stdin
-	hookCmd.Stderr = os.Stderr
-	if err := hookCmd.Run(); err != nil {
-		fail("Internal error", "Failed to execute custom pre-receive hook: %v", err)
-	}
+	runCustomHooks("update", args, nil)
 	return nil
 }
 
@@ -252,23 +280,107 @@ func runHookPostReceive(c *cli.Context) error {
 		}
 	}
 
-	customHooksPath := filepath.Join(os.Getenv(database.ENV_REPO_CUSTOM_HOOKS_PATH), "post-receive")
-	if !com.IsFile(customHooksPath) {
-		return nil
+	runCustomHooks("post-receive", nil, buf.Bytes())
+	return nil
+}
+
+// runCustomHooks runs the hook with given name in the global hooks directory (if
+// configured) and then the custom hook of the repository, both with given
+// arguments and input. It fails the command when any of them fails.
+func runCustomHooks(name string, args []string, input []byte) {
+	// The path of the repository being pushed to, which could be a wiki.
+	repoPath := os.Getenv(database.ENV_REPO_PATH)
+	if repoPath == "" {
+		repoPath = database.RepoPath(os.Getenv(database.ENV_REPO_OWNER_NAME), os.Getenv(database.ENV_REPO_NAME))
+	}
+
+	hooks := customHooks(name, os.Getenv(database.ENV_REPO_CUSTOM_HOOKS_PATH))
+	failed, err := runHookScripts(hooks, repoPath, args, input)
+	if err == nil {
+		return
+	}
+
+	if failed.global {
+		fail(fmt.Sprintf("Global %s hook declined", name), "Failed to execute global %s hook: %v", name, err)
 	}
+	fail("Internal error", "Failed to execute custom %s hook: %v", name, err)
+}
 
+// customHook is a hook script to be run for a push.
+type customHook struct {
+	path string
+	// Whether the hook is from the global hooks directory.
+	global bool
+}
+
+// customHooks returns existing hooks with given name in the order to be run,
+// i.e. the one in the global hooks directory (if configured) and then the one
+// in the custom hooks directory of the repository.
+func customHooks(name, customHooksPath string) []customHook {
+	var hooks []customHook
+	if conf.Git.GlobalHooksPath != "" {
+		hookPath := filepath.Join(conf.Git.GlobalHooksPath, name)
+		if com.IsFile(hookPath) {
+			hooks = append(hooks, customHook{path: hookPath, global: true})
+		}
+	}
+
+	hookPath := filepath.Join(customHooksPath, name)
+	if customHooksPath != "" && com.IsFile(hookPath) {
+		hooks = append(hooks, customHook{path: hookPath})
+	}
+	return hooks
+}
+
+// runHookScripts executes hooks in order in the given directory. It stops at
+// the first hook that fails and returns it with the error.
+func runHookScripts(hooks []customHook, dir string, args []string, input []byte) (*customHook, error) {
+	for i := range hooks {
+		err := runHookScript(hooks[i].path, dir, args, input)
+		if err != nil {
+			return &hooks[i], err
+		}
+	}
+	return nil, nil
+}
+
+// runHookScript executes the hook script in the given directory.
+func runHookScript(hookPath, dir string, args []string, input []byte) error {
 	var hookCmd *exec.Cmd
 	if conf.IsWindowsRuntime() {
-		hookCmd = exec.Command("bash.exe", "custom_hooks/post-receive")
+		hookCmd = exec.Command("bash.exe", append([]string{filepath.ToSlash(hookPath)}, args...)...)
 	} else {
-		hookCmd = exec.Command(customHooksPath)
+		hookCmd = exec.Command(hookPath, args...)
 	}
-	hookCmd.Dir = database.RepoPath(os.Getenv(database.ENV_REPO_OWNER_NAME), os.Getenv(database.ENV_REPO_NAME))
+	hookCmd.Dir = dir
 	hookCmd.Stdout = os.Stdout
-	hookCmd.Stdin = buf
+	hookCmd.Stdin = bytes.NewReader(input)
 	hookCmd.Stderr = os.Stderr
-	if err := hookCmd.Run(); err != nil {
-		fail("Internal error", "Failed to execute custom post-receive hook: %v", err)
+	return hookCmd.Run()
+}
+
+// runHookPackObjects serves "git pack-objects" requests of "git upload-pack"
+// through the pack-objects cache. Git appends the command to run (including the
+// leading "git pack-objects") as arguments.
+func runHookPackObjects(c *cli.Context) error {
+	setup(c, "pack-objects.log", false)
+
+	args := c.Args()
+	if len(args) < 2 {
+		fail("Not enough arguments", "Not enough arguments")
+	}
+
+	// The "git upload-pack" always runs hooks in the repository directory.
+	repoPath, err := os.Getwd()
+	if err != nil {
+		fail("Internal error", "Failed to get working directory: %v", err)
+	}
+
+	opts := conf.Git.PackObjectsCache
+	cache := packcache.New(opts.Path, opts.MaxSize*1024*1024, opts.MaxAge)
+	err = cache.Run(repoPath, args, os.Stdin, os.Stdout, os.Stderr)
+	if err != nil {
+		fail("Internal error", "Failed to run pack-objects for %q: %v", repoPath, err)
 	}
 	return nil
 }
@@ -0,0 +1,110 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/database"
+)
+
+func TestRunHookScripts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping testing on Windows")
+	}
+
+	before := conf.Git
+	defer func() {
+		conf.Git = before
+	}()
+
+	root := t.TempDir()
+	conf.Git.GlobalHooksPath = filepath.Join(root, "global")
+	repoPath := filepath.Join(root, "alice", "repo.wiki.git")
+	customHooksPath := filepath.Join(repoPath, "custom_hooks")
+	logPath := filepath.Join(root, "hooks.log")
+
+	writeHook := func(t *testing.T, dir, name, script string) {
+		require.NoError(t, os.MkdirAll(dir, os.ModePerm))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script), 0755))
+	}
+	readLog := func(t *testing.T) string {
+		p, err := os.ReadFile(logPath)
+		require.NoError(t, err)
+		return string(p)
+	}
+
+	t.Run("global before per-repository", func(t *testing.T) {
+		t.Cleanup(func() { _ = os.Remove(logPath) })
+		writeHook(t, conf.Git.GlobalHooksPath, "pre-receive", `echo "global $(cat)" >> `+logPath+"\n")
+		writeHook(t, customHooksPath, "pre-receive", `echo "repository $(cat)" >> `+logPath+"\n")
+
+		hooks := customHooks("pre-receive", customHooksPath)
+		require.Len(t, hooks, 2)
+		assert.True(t, hooks[0].global)
+		assert.False(t, hooks[1].global)
+
+		failed, err := runHookScripts(hooks, repoPath, nil, []byte("input"))
+		require.NoError(t, err)
+		assert.Nil(t, failed)
+		assert.Equal(t, "global input\nrepository input\n", readLog(t))
+	})
+
+	t.Run("failing global hook declines", func(t *testing.T) {
+		t.Cleanup(func() { _ = os.Remove(logPath) })
+		writeHook(t, conf.Git.GlobalHooksPath, "pre-receive", `echo global >> `+logPath+"\nexit 1\n")
+		writeHook(t, customHooksPath, "pre-receive", `echo repository >> `+logPath+"\n")
+
+		failed, err := runHookScripts(customHooks("pre-receive", customHooksPath), repoPath, nil, nil)
+		require.Error(t, err)
+		require.NotNil(t, failed)
+		assert.True(t, failed.global)
+
+		// The per-repository hook is never run
+		assert.Equal(t, "global\n", readLog(t))
+	})
+
+	t.Run("environment variables", func(t *testing.T) {
+		t.Cleanup(func() { _ = os.Remove(logPath) })
+		for _, env := range database.ComposeHookEnvs(database.ComposeHookEnvsOptions{
+			AuthUser:  &database.User{ID: 1, Name: "alice", Email: "alice@example.com"},
+			OwnerName: "alice",
+			RepoID:    2,
+			RepoName:  "repo",
+			RepoPath:  repoPath,
+		}) {
+			k, v, _ := strings.Cut(env, "=")
+			t.Setenv(k, v)
+		}
+		writeHook(t, conf.Git.GlobalHooksPath, "update",
+			`echo "$1 $GOGS_REPO_OWNER_NAME $GOGS_REPO_NAME $GOGS_REPO_ID $GOGS_REPO_PATH $GOGS_AUTH_USER_ID $GOGS_AUTH_USER_NAME $GOGS_AUTH_USER_EMAIL $(pwd -P)" >> `+logPath+"\n")
+		require.NoError(t, os.RemoveAll(customHooksPath))
+
+		failed, err := runHookScripts(customHooks("update", customHooksPath), repoPath, []string{"refs/heads/main"}, nil)
+		require.NoError(t, err)
+		assert.Nil(t, failed)
+
+		realRepoPath, err := filepath.EvalSymlinks(repoPath)
+		require.NoError(t, err)
+		assert.Equal(t, "refs/heads/main alice repo 2 "+repoPath+" 1 alice alice@example.com "+realRepoPath+"\n", readLog(t))
+	})
+
+	t.Run("no global hooks directory", func(t *testing.T) {
+		conf.Git.GlobalHooksPath = ""
+		writeHook(t, customHooksPath, "post-receive", "")
+
+		hooks := customHooks("post-receive", customHooksPath)
+		require.Len(t, hooks, 1)
+		assert.False(t, hooks[0].global)
+	})
+}
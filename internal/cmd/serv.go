@@ -19,6 +19,7 @@ import (
 
 	"gogs.io/gogs/internal/conf"
 	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/gitutil"
 )
 
 const (
@@ -160,6 +161,7 @@ func runServ(c *cli.Context) error {
 	}
 	ownerName := strings.ToLower(repoFields[0])
 	repoName := strings.TrimSuffix(strings.ToLower(repoFields[1]), ".git")
+	isWiki := strings.HasSuffix(repoName, ".wiki")
 	repoName = strings.TrimSuffix(repoName, ".wiki")
 
 	owner, err := database.Handle.Users().GetByUsername(ctx, ownerName)
@@ -249,26 +251,19 @@ func runServ(c *cli.Context) error {
 		}
 	}
 
-	// Special handle for Windows.
-	if conf.IsWindowsRuntime() {
-		verb = strings.Replace(verb, "-", " ", 1)
-	}
-
-	var gitCmd *exec.Cmd
-	verbs := strings.Split(verb, " ")
-	if len(verbs) == 2 {
-		gitCmd = exec.Command(verbs[0], verbs[1], repoFullName)
-	} else {
-		gitCmd = exec.Command(verb, repoFullName)
-	}
+	gitCmd := gitServeCommand(verb, repoFullName)
 	if requestMode == database.AccessModeWrite {
+		repoPath := repo.RepoPath()
+		if isWiki {
+			repoPath = repo.WikiPath()
+		}
 		gitCmd.Env = append(os.Environ(), database.ComposeHookEnvs(database.ComposeHookEnvsOptions{
 			AuthUser:  user,
 			OwnerName: owner.Name,
 			OwnerSalt: owner.Salt,
 			RepoID:    repo.ID,
 			RepoName:  repo.Name,
-			RepoPath:  repo.RepoPath(),
+			RepoPath:  repoPath,
 		})...)
 	}
 	gitCmd.Dir = conf.Repository.Root
@@ -281,3 +276,22 @@ func runServ(c *cli.Context) error {
 
 	return nil
 }
+
+// gitServeCommand returns the command to serve the Git verb for the repository.
+func gitServeCommand(verb, repoFullName string) *exec.Cmd {
+	if verb == "git-upload-pack" {
+		// Run as a subcommand of "git" for being able to pass configuration options.
+		return exec.Command("git", append(gitutil.UploadPackConfigs(), "upload-pack", repoFullName)...)
+	}
+
+	// Special handle for Windows.
+	if conf.IsWindowsRuntime() {
+		verb = strings.Replace(verb, "-", " ", 1)
+	}
+
+	verbs := strings.Split(verb, " ")
+	if len(verbs) == 2 {
+		return exec.Command(verbs[0], verbs[1], repoFullName)
+	}
+	return exec.Command(verb, repoFullName)
+}
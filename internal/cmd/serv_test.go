@@ -0,0 +1,98 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+func TestGitServeCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping testing on Windows")
+	}
+
+	before := conf.Git
+	defer func() {
+		conf.Git = before
+	}()
+	conf.Git.UploadPackAllowFilter = true
+
+	assert.Equal(t,
+		[]string{
+			"git",
+			"-c", "uploadpack.allowFilter=true",
+			"-c", "uploadpack.allowReachableSHA1InWant=true",
+			"upload-pack", "alice/repo.git",
+		},
+		gitServeCommand("git-upload-pack", "alice/repo.git").Args,
+	)
+	assert.Equal(t,
+		[]string{"git-receive-pack", "alice/repo.git"},
+		gitServeCommand("git-receive-pack", "alice/repo.git").Args,
+	)
+}
+
+func TestGitServeCommand_PartialClone(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping testing with Git operations in short mode")
+	} else if runtime.GOOS == "windows" {
+		t.Skip("Skipping testing on Windows")
+	}
+
+	before := conf.Git
+	defer func() {
+		conf.Git = before
+	}()
+	conf.Git.UploadPackAllowFilter = true
+
+	root := t.TempDir()
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=alice", "GIT_AUTHOR_EMAIL=alice@example.com",
+			"GIT_COMMITTER_NAME=alice", "GIT_COMMITTER_EMAIL=alice@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+
+	bare := filepath.Join(root, "alice", "repo.git")
+	git(root, "init", "--bare", bare)
+	work := filepath.Join(root, "work")
+	git(root, "clone", bare, work)
+	require.NoError(t, os.WriteFile(filepath.Join(work, "README.md"), []byte("# Partial clone"), 0644))
+	git(work, "add", "README.md")
+	git(work, "commit", "-m", "Initial commit")
+	git(work, "push", "origin", "HEAD")
+
+	// Use the same upload-pack command as the SSH server does, and the original
+	// protocol which checks what objects are allowed to be requested.
+	args := gitServeCommand("git-upload-pack", bare).Args
+	uploadPack := strings.Join(args[:len(args)-1], " ")
+	clone := filepath.Join(root, "clone")
+	git(root, "-c", "protocol.version=0", "clone", "--no-checkout", "--filter=blob:none", "--upload-pack="+uploadPack, "file://"+bare, clone)
+	git(clone, "config", "protocol.version", "0")
+	git(clone, "config", "remote.origin.uploadpack", uploadPack)
+
+	missing := git(clone, "rev-list", "--objects", "--missing=print", "HEAD")
+	assert.Contains(t, missing, "?", "blobs should not be fetched during clone")
+
+	git(clone, "checkout", "HEAD", "--", "README.md")
+	got, err := os.ReadFile(filepath.Join(clone, "README.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Partial clone", string(got))
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/go-macaron/i18n"
 	"github.com/go-macaron/session"
 	"github.com/go-macaron/toolbox"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/unknwon/com"
 	"github.com/urfave/cli"
@@ -36,6 +37,7 @@ import (
 	"gogs.io/gogs/internal/database"
 	"gogs.io/gogs/internal/form"
 	"gogs.io/gogs/internal/osutil"
+	"gogs.io/gogs/internal/packcache"
 	"gogs.io/gogs/internal/route"
 	"gogs.io/gogs/internal/route/admin"
 	apiv1 "gogs.io/gogs/internal/route/api/v1"
@@ -168,6 +170,11 @@ func runWeb(c *cli.Context) error {
 		log.Fatal("Failed to initialize application: %v", err)
 	}
 
+	if conf.Git.PackObjectsCache.Enabled {
+		opts := conf.Git.PackObjectsCache
+		prometheus.MustRegister(packcache.NewCollector(packcache.New(opts.Path, opts.MaxSize*1024*1024, opts.MaxAge)))
+	}
+
 	m := newMacaron()
 
 	reqSignIn := context.Toggle(&context.ToggleOptions{SignInRequired: true})
@@ -191,6 +198,11 @@ func runWeb(c *cli.Context) error {
 		m.Combo("/install", route.InstallInit).Get(route.Install).
 			Post(bindIgnErr(form.Install{}), route.InstallPost)
 		m.Get("/^:type(issues|pulls)$", reqSignIn, user.Issues)
+		m.Group("/notifications", func() {
+			m.Get("", user.Notifications)
+			m.Post("/status", user.NotificationStatusPost)
+			m.Post("/mark_all_read", user.NotificationsMarkAllReadPost)
+		}, reqSignIn)
 
 		// ***** START: User *****
 		m.Group("/user", func() {
@@ -216,6 +228,8 @@ func runWeb(c *cli.Context) error {
 			m.Combo("/email").Get(user.SettingsEmails).
 				Post(bindIgnErr(form.AddEmail{}), user.SettingsEmailPost)
 			m.Post("/email/delete", user.DeleteEmail)
+			m.Combo("/notifications").Get(user.SettingsNotifications).
+				Post(bindIgnErr(form.NotificationPreference{}), user.SettingsNotificationsPost)
 			m.Get("/password", user.SettingsPassword)
 			m.Post("/password", bindIgnErr(form.ChangePassword{}), user.SettingsPasswordPost)
 			m.Combo("/ssh").Get(user.SettingsSSHKeys).
@@ -251,6 +265,7 @@ func runWeb(c *cli.Context) error {
 			m.Any("/activate", user.Activate)
 			m.Any("/activate_email", user.ActivateEmail)
 			m.Get("/email2user", user.Email2User)
+			m.Combo("/unsubscribe/:token").Get(user.Unsubscribe).Post(user.UnsubscribePost)
 			m.Get("/forget_password", user.ForgotPasswd)
 			m.Post("/forget_password", user.ForgotPasswdPost)
 			m.Post("/logout", user.SignOut)
@@ -298,6 +313,7 @@ func runWeb(c *cli.Context) error {
 		}, reqAdmin)
 		// ***** END: Admin *****
 
+		m.Get("/:username.:type(atom|rss)", context.FeedAuth(), ignSignIn, context.InjectParamsUser(), user.ActivityFeed)
 		m.Group("", func() {
 			m.Group("/:username", func() {
 				m.Get("", user.Profile)
@@ -364,6 +380,24 @@ func runWeb(c *cli.Context) error {
 			}, repo.InjectOrgRepoContext())
 		}
 
+		projectWriterRoutes := func() {
+			m.Combo("/new").Get(repo.NewProject).
+				Post(bindIgnErr(form.CreateProject{}), repo.NewProjectPost)
+			m.Post("/delete", repo.DeleteProject)
+			m.Group("/:id", func() {
+				m.Combo("/edit").Get(repo.EditProject).
+					Post(bindIgnErr(form.CreateProject{}), repo.EditProjectPost)
+				m.Get("/:action", repo.ChangeProjectStatus)
+				m.Post("/columns/new", bindIgnErr(form.ProjectColumn{}), repo.NewProjectColumnPost)
+				m.Post("/columns/:column_id/edit", bindIgnErr(form.ProjectColumn{}), repo.EditProjectColumnPost)
+				m.Post("/columns/:column_id/delete", repo.DeleteProjectColumn)
+				m.Post("/cards/new", bindIgnErr(form.ProjectCard{}), repo.NewProjectCardPost)
+				m.Post("/cards/:card_id/edit", bindIgnErr(form.ProjectCard{}), repo.EditProjectCardPost)
+				m.Post("/cards/:card_id/delete", repo.DeleteProjectCard)
+				m.Post("/cards/:card_id/move", repo.MoveProjectCard)
+			})
+		}
+
 		// ***** START: Organization *****
 		m.Group("/org", func() {
 			m.Group("", func() {
@@ -382,6 +416,16 @@ func runWeb(c *cli.Context) error {
 				m.Get("/members/action/:action", org.MembersAction)
 
 				m.Get("/teams", org.Teams)
+
+				m.Group("/projects", func() {
+					m.Get("", repo.Projects)
+					m.Get("/:id", repo.ViewProject)
+					projectWriterRoutes()
+				})
+
+				m.Get("/labels", org.Labels)
+				m.Get("/milestones", org.Milestones)
+				m.Get("/milestones/:id", org.ViewMilestone)
 			}, context.OrgAssignment(true))
 
 			m.Group("/:org", func() {
@@ -408,6 +452,20 @@ func runWeb(c *cli.Context) error {
 				})
 
 				m.Route("/invitations/new", "GET,POST", org.Invitation)
+
+				m.Group("/labels", func() {
+					m.Post("/new", bindIgnErr(form.CreateLabel{}), org.NewLabel)
+					m.Post("/edit", bindIgnErr(form.CreateLabel{}), org.UpdateLabel)
+					m.Post("/delete", org.DeleteLabel)
+				})
+				m.Group("/milestones", func() {
+					m.Combo("/new").Get(org.NewMilestone).
+						Post(bindIgnErr(form.CreateMilestone{}), org.NewMilestonePost)
+					m.Get("/:id/edit", org.EditMilestone)
+					m.Post("/:id/edit", bindIgnErr(form.CreateMilestone{}), org.EditMilestonePost)
+					m.Get("/:id/:action", org.ChangeMilestoneStatus)
+					m.Post("/delete", org.DeleteMilestone)
+				})
 			}, context.OrgAssignment(true, true))
 		}, reqSignIn)
 		// ***** END: Organization *****
@@ -474,9 +532,13 @@ func runWeb(c *cli.Context) error {
 		m.Post("/:username/:reponame/action/:action", reqSignIn, context.RepoAssignment(), repo.Action)
 		m.Group("/:username/:reponame", func() {
 			m.Get("/issues", repo.RetrieveLabels, repo.Issues)
+			m.Get("/issues/export", repo.MustEnableIssues, repo.ExportIssues)
 			m.Get("/issues/:index", repo.ViewIssue)
+			m.Get("/issues/:index/history", repo.ContentHistory)
 			m.Get("/labels/", repo.RetrieveLabels, repo.Labels)
 			m.Get("/milestones", repo.Milestones)
+			m.Get("/projects", repo.Projects)
+			m.Get("/projects/:id", repo.ViewProject)
 		}, ignSignIn, context.RepoAssignment(true))
 		m.Group("/:username/:reponame", func() {
 			// FIXME: should use different URLs but mostly same logic for comments of issue and pull reuqest.
@@ -484,16 +546,20 @@ func runWeb(c *cli.Context) error {
 			m.Group("/issues", func() {
 				m.Combo("/new", repo.MustEnableIssues).Get(context.RepoRef(), repo.NewIssue).
 					Post(bindIgnErr(form.NewIssue{}), repo.NewIssuePost)
+				m.Get("/mention_teams", repo.MentionTeams)
 
 				m.Group("/:index", func() {
 					m.Post("/title", repo.UpdateIssueTitle)
 					m.Post("/content", repo.UpdateIssueContent)
 					m.Combo("/comments").Post(bindIgnErr(form.CreateComment{}), repo.NewComment)
+					m.Post("/reactions", repo.ToggleIssueReaction)
+					m.Post("/history/delete", reqRepoAdmin, repo.DeleteContentRevision)
 				})
 			})
 			m.Group("/comments/:id", func() {
 				m.Post("", repo.UpdateCommentContent)
 				m.Post("/delete", repo.DeleteComment)
+				m.Post("/reactions", repo.ToggleCommentReaction)
 			})
 		}, reqSignIn, context.RepoAssignment(true))
 		m.Group("/:username/:reponame", func() {
@@ -511,8 +577,26 @@ func runWeb(c *cli.Context) error {
 					m.Post("/label", repo.UpdateIssueLabel)
 					m.Post("/milestone", repo.UpdateIssueMilestone)
 					m.Post("/assignee", repo.UpdateIssueAssignee)
+					m.Post("/deadline", repo.UpdateIssueDeadline)
+					m.Post("/transfer", repo.TransferIssue)
+					m.Post("/lock", repo.LockIssue)
+					m.Post("/unlock", repo.UnlockIssue)
+					m.Post("/pin", repo.PinIssue)
+					m.Post("/unpin", repo.UnpinIssue)
+					m.Post("/dependencies", repo.AddIssueDependency)
+					m.Post("/dependencies/delete", repo.RemoveIssueDependency)
+					m.Group("/times", func() {
+						m.Post("/add", repo.AddIssueTrackedTime)
+						m.Post("/stopwatch/start", repo.StartIssueStopwatch)
+						m.Post("/stopwatch/stop", repo.StopIssueStopwatch)
+						m.Post("/stopwatch/cancel", repo.CancelIssueStopwatch)
+					})
 				}, reqRepoWriter)
 			})
+			m.Group("/times", func() {
+				m.Get("", repo.TrackedTimes)
+				m.Get("/export", repo.ExportTrackedTimes)
+			}, reqRepoWriter)
 			m.Group("/labels", func() {
 				m.Post("/new", bindIgnErr(form.CreateLabel{}), repo.NewLabel)
 				m.Post("/edit", bindIgnErr(form.CreateLabel{}), repo.UpdateLabel)
@@ -527,6 +611,7 @@ func runWeb(c *cli.Context) error {
 				m.Get("/:id/:action", repo.ChangeMilestonStatus)
 				m.Post("/delete", repo.DeleteMilestone)
 			}, reqRepoWriter, context.RepoRef())
+			m.Group("/projects", projectWriterRoutes, reqRepoWriter)
 
 			m.Group("/releases", func() {
 				m.Get("/new", repo.NewRelease)
@@ -619,6 +704,10 @@ func runWeb(c *cli.Context) error {
 
 			m.Get("/compare/:before([a-z0-9]{40})\\.\\.\\.:after([a-z0-9]{40})", repo.MustBeNotBare, context.RepoRef(), repo.CompareDiff)
 		}, ignSignIn, context.RepoAssignment())
+		m.Group("/:username/:reponame", func() {
+			m.Get("/commits.:type(atom|rss)", repo.CommitsFeed)
+			m.Get("/releases.:type(atom|rss)", repo.ReleasesFeed)
+		}, context.FeedAuth(), ignSignIn, context.RepoAssignment(), repo.MustBeNotBare)
 		m.Group("/:username/:reponame", func() {
 			m.Get("", repo.Home)
 			m.Get("/stars", repo.Stars)
@@ -216,6 +216,24 @@ func Init(customConf string) error {
 		Email.FromEmail = parsed.Address
 	}
 
+	if err = File.Section("incoming_email").MapTo(&IncomingEmail); err != nil {
+		return errors.Wrap(err, "mapping [incoming_email] section")
+	}
+
+	if IncomingEmail.Enabled {
+		if !strings.Contains(IncomingEmail.ReplyToAddress, "%{token}") {
+			return errors.Errorf("reply-to address %q of incoming emails does not contain the %%{token} placeholder", IncomingEmail.ReplyToAddress)
+		}
+
+		switch IncomingEmail.Mailbox {
+		case "maildir":
+			IncomingEmail.MaildirPath = ensureAbs(IncomingEmail.MaildirPath)
+		case "imap":
+		default:
+			return errors.Errorf("unsupported mailbox type %q of incoming emails", IncomingEmail.Mailbox)
+		}
+	}
+
 	// ***********************************
 	// ----- Authentication settings -----
 	// ***********************************
@@ -357,6 +375,8 @@ func Init(customConf string) error {
 		return errors.Wrap(err, "mapping [http] section")
 	} else if err = File.Section("release").MapTo(&Release); err != nil {
 		return errors.Wrap(err, "mapping [release] section")
+	} else if err = File.Section("quota").MapTo(&Quota); err != nil {
+		return errors.Wrap(err, "mapping [quota] section")
 	} else if err = File.Section("webhook").MapTo(&Webhook); err != nil {
 		return errors.Wrap(err, "mapping [webhook] section")
 	} else if err = File.Section("markdown").MapTo(&Markdown); err != nil {
@@ -379,6 +399,11 @@ func Init(customConf string) error {
 		return errors.Wrap(err, "mapping [other] section")
 	}
 
+	Git.PackObjectsCache.Path = ensureAbs(Git.PackObjectsCache.Path)
+	if Git.GlobalHooksPath != "" {
+		Git.GlobalHooksPath = ensureAbs(Git.GlobalHooksPath)
+	}
+
 	HasRobotsTxt = osutil.IsFile(filepath.Join(CustomDir(), "robots.txt"))
 	return nil
 }
@@ -38,6 +38,7 @@ func TestInit(t *testing.T) {
 		{"database", &Database},
 		{"security", &Security},
 		{"email", &Email},
+		{"incoming_email", &IncomingEmail},
 		{"auth", &Auth},
 		{"user", &User},
 		{"session", &Session},
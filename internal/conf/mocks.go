@@ -80,3 +80,15 @@ func SetMockPicture(t *testing.T, opts PictureOpts) {
 		mockPicture.Unlock()
 	})
 }
+
+var mockQuota sync.Mutex
+
+func SetMockQuota(t *testing.T, opts QuotaOpts) {
+	mockQuota.Lock()
+	before := Quota
+	Quota = opts
+	t.Cleanup(func() {
+		Quota = before
+		mockQuota.Unlock()
+	})
+}
@@ -71,6 +71,20 @@ var (
 		FromEmail string `ini:"-"` // Parsed email address of From without person's name.
 	}
 
+	// Incoming email settings
+	IncomingEmail struct {
+		Enabled        bool
+		ReplyToAddress string
+		Schedule       string
+		Mailbox        string
+		MaildirPath    string
+		IMAPHost       string `ini:"IMAP_HOST"`
+		IMAPUser       string `ini:"IMAP_USER"`
+		IMAPPassword   string `ini:"IMAP_PASSWORD"`
+		IMAPMailbox    string `ini:"IMAP_MAILBOX"`
+		IMAPSkipVerify bool   `ini:"IMAP_SKIP_VERIFY"`
+	}
+
 	// User settings
 	User struct {
 		EnableEmailNotification bool
@@ -185,6 +199,27 @@ var (
 			Schedule   string
 			OlderThan  time.Duration
 		} `ini:"cron.repo_archive_cleanup"`
+		RepoMaintenance struct {
+			Enabled               bool
+			RunAtStart            bool
+			Schedule              string
+			Timeout               time.Duration
+			MaxConcurrency        int
+			LooseObjectsThreshold int64
+			PacksThreshold        int64
+			GCInterval            time.Duration `ini:"GC_INTERVAL"`
+		} `ini:"cron.repo_maintenance"`
+		IssueDueReminder struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+			DueWithin  time.Duration
+		} `ini:"cron.issue_due_reminder"`
+		EmailDigest struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		} `ini:"cron.email_digest"`
 	}
 
 	// Git settings
@@ -197,7 +232,12 @@ var (
 		MaxDiffLines         int      `ini:"MAX_GIT_DIFF_LINES"`
 		MaxDiffLineChars     int      `ini:"MAX_GIT_DIFF_LINE_CHARACTERS"`
 		GCArgs               []string `ini:"GC_ARGS" delim:" "`
-		Timeout              struct {
+
+		UploadPackAllowFilter        bool
+		UploadPackAllowAnySHA1InWant bool `ini:"UPLOAD_PACK_ALLOW_ANY_SHA1_IN_WANT"`
+		GlobalHooksPath              string
+
+		Timeout struct {
 			Migrate int
 			Mirror  int
 			Clone   int
@@ -205,6 +245,13 @@ var (
 			Diff    int
 			GC      int `ini:"GC"`
 		} `ini:"git.timeout"`
+
+		PackObjectsCache struct {
+			Enabled bool
+			Path    string
+			MaxSize int64
+			MaxAge  time.Duration
+		} `ini:"git.pack_objects_cache"`
 	}
 
 	// API settings
@@ -406,6 +453,17 @@ type PictureOpts struct {
 // Picture settings
 var Picture PictureOpts
 
+type QuotaOpts struct {
+	Enabled bool
+	// Maximum total size (in MB) of each kind of storage, -1 means unlimited.
+	MaxRepoSize       int64
+	MaxLFSSize        int64 `ini:"MAX_LFS_SIZE"`
+	MaxAttachmentSize int64
+}
+
+// Quota settings
+var Quota QuotaOpts
+
 type i18nConf struct {
 	Langs     []string          `delim:","`
 	Names     []string          `delim:","`
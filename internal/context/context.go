@@ -269,6 +269,14 @@ func Contexter(store Store) macaron.Handler {
 			c.Data["LoggedUserID"] = c.User.ID
 			c.Data["LoggedUserName"] = c.User.Name
 			c.Data["IsAdmin"] = c.User.IsAdmin
+
+			if !isAPIPath(c.Req.URL.Path) {
+				count, err := store.CountUnreadNotifications(c.Req.Context(), c.User.ID)
+				if err != nil {
+					log.Error("Failed to count unread notifications of user [%d]: %v", c.User.ID, err)
+				}
+				c.Data["UnreadNotificationCount"] = count
+			}
 		} else {
 			c.Data["LoggedUserID"] = 0
 			c.Data["LoggedUserName"] = ""
@@ -0,0 +1,85 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package context
+
+import (
+	"net/http"
+	"net/url"
+
+	"gopkg.in/macaron.v1"
+
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/feed"
+	"gogs.io/gogs/internal/userutil"
+)
+
+// FeedAuth returns a handler that signs in the user by the feed token in the
+// "token" query parameter for the current request, which allows feed readers to
+// subscribe to private feeds. It must be used before any toggle so that a feed
+// is also available when signing in is required to view the site.
+func FeedAuth() macaron.Handler {
+	return func(c *Context) {
+		token := c.Query("token")
+		if c.IsLogged || token == "" {
+			return
+		}
+
+		userID, ok := userutil.FeedTokenUserID(token)
+		if !ok {
+			c.NotFound()
+			return
+		}
+		user, err := database.Handle.Users().GetByID(c.Req.Context(), userID)
+		if err != nil {
+			c.NotFoundOrError(err, "get user by ID")
+			return
+		} else if !userutil.ValidateFeedToken(token, user.ID, user.Rands) ||
+			!user.IsActive || user.ProhibitLogin {
+			c.NotFound()
+			return
+		}
+
+		c.User = user
+		c.IsLogged = true
+		c.Data["IsLogged"] = c.IsLogged
+		c.Data["LoggedUser"] = c.User
+		c.Data["LoggedUserID"] = c.User.ID
+		c.Data["LoggedUserName"] = c.User.Name
+		c.Data["IsAdmin"] = c.User.IsAdmin
+	}
+}
+
+// Feed writes the feed in the format given by the URL parameter ":type".
+func (c *Context) Feed(f *feed.Feed) {
+	typ := feed.Type(c.Params(":type"))
+	data, err := f.Encode(typ)
+	if err != nil {
+		c.Error(err, "encode feed")
+		return
+	}
+
+	c.Resp.Header().Set("Content-Type", typ.ContentType())
+	c.Resp.WriteHeader(http.StatusOK)
+	_, _ = c.Resp.Write(data)
+}
+
+// SetFeedLinks sets links of the feed at the link path, which is without the
+// extension of the format, for the page to advertise. Links carry the feed token
+// of the signed-in user to access private feeds.
+func (c *Context) SetFeedLinks(link string, query url.Values) {
+	if query == nil {
+		query = make(url.Values)
+	}
+	if c.IsLogged {
+		query.Set("token", userutil.FeedToken(c.User.ID, c.User.Rands))
+	}
+
+	rawQuery := ""
+	if len(query) > 0 {
+		rawQuery = "?" + query.Encode()
+	}
+	c.Data["FeedAtomLink"] = link + "." + string(feed.TypeAtom) + rawQuery
+	c.Data["FeedRSSLink"] = link + "." + string(feed.TypeRSS) + rawQuery
+}
@@ -42,6 +42,10 @@ type Store interface {
 	// When the "loginSourceID" is positive, it tries to authenticate via given
 	// login source and creates a new user when not yet exists in the database.
 	AuthenticateUser(ctx context.Context, login, password string, loginSourceID int64) (*database.User, error)
+
+	// CountUnreadNotifications returns the number of unread notifications of the
+	// user.
+	CountUnreadNotifications(ctx context.Context, userID int64) (int64, error)
 }
 
 type store struct{}
@@ -74,3 +78,7 @@ func (*store) CreateUser(ctx context.Context, username, email string, opts datab
 func (*store) AuthenticateUser(ctx context.Context, login, password string, loginSourceID int64) (*database.User, error) {
 	return database.Handle.Users().Authenticate(ctx, login, password, loginSourceID)
 }
+
+func (*store) CountUnreadNotifications(ctx context.Context, userID int64) (int64, error) {
+	return database.Handle.Notifications().CountUnread(ctx, userID)
+}
@@ -66,6 +66,45 @@ func NewContext() {
 			go database.DeleteOldRepositoryArchives()
 		}
 	}
+	if conf.Cron.RepoMaintenance.Enabled {
+		entry, err = c.AddFunc("Repository maintenance", conf.Cron.RepoMaintenance.Schedule, database.PerformRepoMaintenance)
+		if err != nil {
+			log.Fatal("Cron.(repository maintenance): %v", err)
+		}
+		if conf.Cron.RepoMaintenance.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go database.PerformRepoMaintenance()
+		}
+	}
+	if conf.Cron.IssueDueReminder.Enabled {
+		entry, err = c.AddFunc("Remind due issues", conf.Cron.IssueDueReminder.Schedule, database.RemindDueIssues)
+		if err != nil {
+			log.Fatal("Cron.(remind due issues): %v", err)
+		}
+		if conf.Cron.IssueDueReminder.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go database.RemindDueIssues()
+		}
+	}
+	if conf.Cron.EmailDigest.Enabled {
+		entry, err = c.AddFunc("Send email digests", conf.Cron.EmailDigest.Schedule, database.SendEmailDigests)
+		if err != nil {
+			log.Fatal("Cron.(send email digests): %v", err)
+		}
+		if conf.Cron.EmailDigest.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go database.SendEmailDigests()
+		}
+	}
+	if conf.IncomingEmail.Enabled {
+		_, err = c.AddFunc("Process incoming emails", conf.IncomingEmail.Schedule, database.ProcessIncomingEmails)
+		if err != nil {
+			log.Fatal("Cron.(process incoming emails): %v", err)
+		}
+	}
 	c.Start()
 }
 
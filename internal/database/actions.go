@@ -402,6 +402,10 @@ func updateCommitReferencesToIssues(doer *User, repo *Repository, commits []*Pus
 			}
 
 			if err = issue.ChangeStatus(doer, repo, true); err != nil {
+				if IsErrIssueBlocked(err) {
+					log.Trace("Skip closing blocked issue [%d] by commit: %s", issue.ID, c.Sha1)
+					continue
+				}
 				return err
 			}
 		}
@@ -710,6 +714,7 @@ const (
 	ActionMirrorSyncPush                          // 20
 	ActionMirrorSyncCreate                        // 21
 	ActionMirrorSyncDelete                        // 22
+	ActionTransferIssue                           // 23
 )
 
 // Action is a user operation to a repository. It implements template.Actioner
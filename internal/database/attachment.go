@@ -5,6 +5,7 @@
 package database
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -21,12 +22,14 @@ import (
 
 // Attachment represent a attachment of issue/comment/release.
 type Attachment struct {
-	ID        int64
-	UUID      string `xorm:"uuid UNIQUE"`
-	IssueID   int64  `xorm:"INDEX"`
-	CommentID int64
-	ReleaseID int64 `xorm:"INDEX"`
-	Name      string
+	ID         int64
+	UUID       string `xorm:"uuid UNIQUE"`
+	IssueID    int64  `xorm:"INDEX"`
+	CommentID  int64
+	ReleaseID  int64 `xorm:"INDEX"`
+	UploaderID int64 `xorm:"INDEX"`
+	Name       string
+	Size       int64 `xorm:"NOT NULL DEFAULT 0"`
 
 	Created     time.Time `xorm:"-" json:"-" gorm:"-"`
 	CreatedUnix int64
@@ -53,11 +56,18 @@ func (attach *Attachment) LocalPath() string {
 	return AttachmentLocalPath(attach.UUID)
 }
 
-// NewAttachment creates a new attachment object.
-func NewAttachment(name string, buf []byte, file multipart.File) (_ *Attachment, err error) {
+// NewAttachment creates a new attachment object uploaded by the given user.
+func NewAttachment(uploaderID int64, name string, buf []byte, file multipart.File) (_ *Attachment, err error) {
+	return newAttachment(uploaderID, name, io.MultiReader(bytes.NewReader(buf), file))
+}
+
+// newAttachment creates a new attachment object uploaded by the given user with
+// the content read from r.
+func newAttachment(uploaderID int64, name string, r io.Reader) (_ *Attachment, err error) {
 	attach := &Attachment{
-		UUID: gouuid.NewV4().String(),
-		Name: name,
+		UUID:       gouuid.NewV4().String(),
+		UploaderID: uploaderID,
+		Name:       name,
 	}
 
 	localPath := attach.LocalPath()
@@ -71,9 +81,8 @@ func NewAttachment(name string, buf []byte, file multipart.File) (_ *Attachment,
 	}
 	defer fw.Close()
 
-	if _, err = fw.Write(buf); err != nil {
-		return nil, fmt.Errorf("Write: %v", err)
-	} else if _, err = io.Copy(fw, file); err != nil {
+	attach.Size, err = io.Copy(fw, r)
+	if err != nil {
 		return nil, fmt.Errorf("Copy: %v", err)
 	}
 
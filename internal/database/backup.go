@@ -77,6 +77,12 @@ func dumpTable(ctx context.Context, db *gorm.DB, table any, w io.Writer) error {
 	switch table.(type) {
 	case *LFSObject:
 		query = query.Order("repo_id, oid ASC")
+	case *RepoMaintenance, *RepoMigration:
+		query = query.Order("repo_id ASC")
+	case *RepoMigrationIssue:
+		query = query.Order("repo_id, is_pull, number ASC")
+	case *NotificationPreference:
+		query = query.Order("user_id ASC")
 	default:
 		query = query.Order("id ASC")
 	}
@@ -198,7 +204,11 @@ func importTable(ctx context.Context, db *gorm.DB, table any, r io.Reader) error
 	}
 	rawTableName := s.Table
 	skipResetIDSeq := map[string]bool{
-		"lfs_object": true,
+		"lfs_object":              true,
+		"notification_preference": true,
+		"repo_maintenance":        true,
+		"repo_migration":          true,
+		"repo_migration_issue":    true,
 	}
 
 	scanner := bufio.NewScanner(r)
@@ -31,7 +31,7 @@ func TestDumpAndImport(t *testing.T) {
 	}
 	t.Parallel()
 
-	const wantTables = 8
+	const wantTables = 24
 	if len(Tables) != wantTables {
 		t.Fatalf("New table has added (want %d got %d), please add new tests for the table and update this check", wantTables, len(Tables))
 	}
@@ -132,6 +132,29 @@ func setupDBToDump(t *testing.T, db *gorm.DB) {
 			CreatedUnix:  1588568886,
 		},
 
+		&ContentRevision{
+			ID:          1,
+			IssueID:     1,
+			Content:     "Original description",
+			EditorID:    1,
+			CreatedUnix: 1588568886,
+		},
+		&ContentRevision{
+			ID:          2,
+			IssueID:     1,
+			Content:     "Edited description",
+			EditorID:    2,
+			CreatedUnix: 1588572486,
+		},
+		&ContentRevision{
+			ID:          3,
+			IssueID:     1,
+			CommentID:   1,
+			Content:     "Original comment",
+			EditorID:    1,
+			CreatedUnix: 1588568886,
+		},
+
 		&EmailAddress{
 			ID:          1,
 			UserID:      1,
@@ -145,6 +168,18 @@ func setupDBToDump(t *testing.T, db *gorm.DB) {
 			IsActivated: true,
 		},
 
+		&EmailDigestItem{
+			ID:          1,
+			UserID:      1,
+			Delivery:    EmailDeliveryDaily,
+			IssueID:     1,
+			CommentID:   2,
+			DoerID:      2,
+			Category:    NotificationCategoryMention,
+			Content:     "Hi @alice, please take a look",
+			CreatedUnix: 1588568886,
+		},
+
 		&Follow{
 			ID:       1,
 			UserID:   1,
@@ -156,6 +191,37 @@ func setupDBToDump(t *testing.T, db *gorm.DB) {
 			FollowID: 1,
 		},
 
+		&IssueAssignee{
+			ID:         1,
+			IssueID:    1,
+			AssigneeID: 1,
+		},
+		&IssueAssignee{
+			ID:         2,
+			IssueID:    1,
+			AssigneeID: 2,
+		},
+
+		&IssueDependency{
+			ID:           1,
+			IssueID:      2,
+			DependencyID: 1,
+			CreatedUnix:  1588568886,
+		},
+		&IssueDependency{
+			ID:           2,
+			IssueID:      3,
+			DependencyID: 1,
+			CreatedUnix:  1588568886,
+		},
+
+		&IssueRedirect{
+			ID:      1,
+			RepoID:  1,
+			Index:   4,
+			IssueID: 5,
+		},
+
 		&LFSObject{
 			RepoID:    1,
 			OID:       "ef797c8118f02dfb649607dd5d3f8c7623048c9c063d532cc95c5ed7a898a64f",
@@ -197,6 +263,186 @@ func setupDBToDump(t *testing.T, db *gorm.DB) {
 			Description: "This is a notice",
 			CreatedUnix: 1588568886,
 		},
+
+		&Notification{
+			ID:          1,
+			UserID:      1,
+			RepoID:      1,
+			IssueID:     1,
+			CommentID:   2,
+			Status:      NotificationStatusUnread,
+			Reason:      NotificationReasonMention,
+			UpdatedByID: 2,
+			CreatedUnix: 1588568886,
+			UpdatedUnix: 1588572486,
+		},
+		&Notification{
+			ID:          2,
+			UserID:      2,
+			RepoID:      1,
+			IssueID:     1,
+			Status:      NotificationStatusPinned,
+			Reason:      NotificationReasonWatching,
+			UpdatedByID: 1,
+			CreatedUnix: 1588568886,
+			UpdatedUnix: 1588568886,
+		},
+
+		&NotificationPreference{
+			UserID:        1,
+			OwnActivity:   EmailDeliveryNone,
+			Mention:       EmailDeliveryImmediate,
+			Participating: EmailDeliveryHourly,
+			Watching:      EmailDeliveryDaily,
+		},
+
+		&Project{
+			ID:          1,
+			RepoID:      1,
+			Name:        "Roadmap",
+			Description: "Plans for the next release",
+			CreatorID:   1,
+			CreatedUnix: 1588568886,
+			UpdatedUnix: 1588572486, // 1 hour later
+		},
+		&Project{
+			ID:          2,
+			OwnerID:     3,
+			Name:        "Organization roadmap",
+			IsClosed:    true,
+			CreatorID:   1,
+			CreatedUnix: 1588568886,
+			UpdatedUnix: 1588568886,
+		},
+		&ProjectCard{
+			ID:          1,
+			ProjectID:   1,
+			ColumnID:    1,
+			IssueID:     1,
+			Position:    0,
+			CreatorID:   1,
+			CreatedUnix: 1588568886,
+			UpdatedUnix: 1588568886,
+		},
+		&ProjectCard{
+			ID:          2,
+			ProjectID:   1,
+			ColumnID:    2,
+			Note:        "Write release notes",
+			Position:    0,
+			CreatorID:   1,
+			CreatedUnix: 1588568886,
+			UpdatedUnix: 1588572486, // 1 hour later
+		},
+		&ProjectColumn{
+			ID:          1,
+			ProjectID:   1,
+			Name:        "To do",
+			Position:    0,
+			CreatedUnix: 1588568886,
+		},
+		&ProjectColumn{
+			ID:          2,
+			ProjectID:   1,
+			Name:        "Done",
+			Position:    1,
+			Automation:  ProjectColumnAutomationClosed,
+			CreatedUnix: 1588568886,
+		},
+
+		&Reaction{
+			ID:          1,
+			UserID:      1,
+			IssueID:     1,
+			Type:        "+1",
+			CreatedUnix: 1588568886,
+		},
+		&Reaction{
+			ID:          2,
+			UserID:      2,
+			IssueID:     1,
+			CommentID:   1,
+			Type:        "heart",
+			CreatedUnix: 1588568886,
+		},
+
+		&RepoMaintenance{
+			RepoID:         1,
+			Pushes:         2,
+			LooseObjects:   12,
+			Packs:          1,
+			Tasks:          "repack,commit-graph",
+			Duration:       1500,
+			MaintainedUnix: 1588568886,
+			LastGCUnix:     1588568886,
+		},
+		&RepoMaintenance{
+			RepoID:         2,
+			Tasks:          "gc",
+			Error:          "gc: exit status 128",
+			MaintainedUnix: 1588568886,
+		},
+
+		&RepoMigration{
+			RepoID:      1,
+			DoerID:      1,
+			Service:     "github",
+			CloneAddr:   "https://github.com/gogs/gogs.git",
+			Stages:      "labels,milestones,issues,pull_requests,releases",
+			Stage:       "issues",
+			Page:        3,
+			LastNumber:  120,
+			LastIndex:   100,
+			CreatedUnix: 1588568886,
+			UpdatedUnix: 1588568886,
+		},
+		&RepoMigration{
+			RepoID:      2,
+			DoerID:      1,
+			Service:     "gitlab",
+			CloneAddr:   "https://gitlab.com/gitlab-org/gitlab.git",
+			Stages:      "issues",
+			Stage:       "done",
+			Page:        1,
+			LastIndex:   20,
+			CreatedUnix: 1588568886,
+			UpdatedUnix: 1588568886,
+		},
+
+		&RepoMigrationIssue{
+			RepoID: 1,
+			Number: 1,
+			Index:  1,
+		},
+		&RepoMigrationIssue{
+			RepoID:      1,
+			IsPull:      true,
+			Number:      2,
+			Index:       2,
+			IsRewritten: true,
+		},
+
+		&Stopwatch{
+			ID:          1,
+			IssueID:     1,
+			UserID:      1,
+			CreatedUnix: 1588568886,
+		},
+
+		&TrackedTime{
+			ID:          1,
+			IssueID:     1,
+			UserID:      1,
+			Time:        3600,
+			CreatedUnix: 1588568886,
+		},
+		&TrackedTime{
+			ID:          2,
+			IssueID:     2,
+			UserID:      2,
+			Time:        90,
+			CreatedUnix: 1588568886,
+		},
 	}
 	for _, val := range vals {
 		err := db.Create(val).Error
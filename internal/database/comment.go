@@ -37,6 +37,27 @@ const (
 	COMMENT_TYPE_COMMENT_REF
 	// Reference from a pull request
 	COMMENT_TYPE_PULL_REF
+
+	// Time tracking, the content is the tracked time in seconds if any.
+	COMMENT_TYPE_START_TRACKING
+	COMMENT_TYPE_STOP_TRACKING
+	COMMENT_TYPE_ADD_TIME_MANUAL
+	COMMENT_TYPE_CANCEL_TRACKING
+
+	// Timeline events of changes made to the issue or pull request.
+	COMMENT_TYPE_ADD_LABEL     // LabelID is the label added
+	COMMENT_TYPE_REMOVE_LABEL  // LabelID is the label removed
+	COMMENT_TYPE_ASSIGN        // AssigneeID is the user assigned
+	COMMENT_TYPE_UNASSIGN      // AssigneeID is the user unassigned
+	COMMENT_TYPE_MILESTONE     // Changed from OldMilestoneID to MilestoneID, either can be 0
+	COMMENT_TYPE_CHANGE_TITLE  // Changed from OldTitle to NewTitle
+	COMMENT_TYPE_FORCE_PUSH    // Head of the pull request is force-pushed from OldCommitSHA to CommitSHA
+	COMMENT_TYPE_DELETE_BRANCH // Head branch of the pull request is deleted, the content is the branch name
+	COMMENT_TYPE_LOCK          // The content is the lock reason if any
+	COMMENT_TYPE_UNLOCK
+	COMMENT_TYPE_PIN
+	COMMENT_TYPE_UNPIN
+	COMMENT_TYPE_TRANSFER // The content is the former reference of the issue, e.g. "owner/repo#1"
 )
 
 type CommentTag int
@@ -69,10 +90,25 @@ type Comment struct {
 	// Reference issue in commit message
 	CommitSHA string `xorm:"VARCHAR(40)"`
 
+	// For timeline events.
+	LabelID        int64
+	Label          *Label `xorm:"-" json:"-" gorm:"-"`
+	AssigneeID     int64
+	Assignee       *User `xorm:"-" json:"-" gorm:"-"`
+	OldMilestoneID int64
+	OldMilestone   *Milestone `xorm:"-" json:"-" gorm:"-"`
+	MilestoneID    int64
+	Milestone      *Milestone `xorm:"-" json:"-" gorm:"-"`
+	OldTitle       string
+	NewTitle       string
+	OldCommitSHA   string `xorm:"VARCHAR(40)"`
+
 	Attachments []*Attachment `xorm:"-" json:"-" gorm:"-"`
 
 	// For view issue page.
-	ShowTag CommentTag `xorm:"-" json:"-" gorm:"-"`
+	ShowTag   CommentTag         `xorm:"-" json:"-" gorm:"-"`
+	Reactions []*ReactionSummary `xorm:"-" json:"-" gorm:"-"`
+	IsEdited  bool               `xorm:"-" json:"-" gorm:"-"`
 }
 
 func (c *Comment) BeforeInsert() {
@@ -126,6 +162,45 @@ func (c *Comment) loadAttributes(e Engine) (err error) {
 		}
 	}
 
+	return c.loadEventAttributes(e)
+}
+
+// loadEventAttributes loads subjects of the timeline event. Subjects that have
+// been deleted since are left as nil, except the assignee is the ghost user.
+func (c *Comment) loadEventAttributes(e Engine) (err error) {
+	if c.Label == nil && c.LabelID > 0 {
+		c.Label, err = getLabelOfRepoByID(e, 0, c.LabelID)
+		if err != nil && !IsErrLabelNotExist(err) {
+			return fmt.Errorf("getLabelOfRepoByID [%d]: %v", c.LabelID, err)
+		}
+	}
+
+	if c.Assignee == nil && c.AssigneeID > 0 {
+		c.Assignee, err = getUserByID(e, c.AssigneeID)
+		if err != nil {
+			if !IsErrUserNotExist(err) {
+				return fmt.Errorf("getUserByID.(Assignee) [%d]: %v", c.AssigneeID, err)
+			}
+			c.Assignee = NewGhostUser()
+		}
+	}
+
+	for _, m := range []struct {
+		id   int64
+		dest **Milestone
+	}{
+		{c.OldMilestoneID, &c.OldMilestone},
+		{c.MilestoneID, &c.Milestone},
+	} {
+		if *m.dest != nil || m.id <= 0 {
+			continue
+		}
+
+		*m.dest, err = getAvailableMilestoneByRepoID(e, c.Issue.RepoID, m.id)
+		if err != nil && !IsErrMilestoneNotExist(err) {
+			return fmt.Errorf("getAvailableMilestoneByRepoID [%d]: %v", m.id, err)
+		}
+	}
 	return nil
 }
 
@@ -164,11 +239,17 @@ func (c *Comment) EventTag() string {
 	return "event-" + com.ToStr(c.ID)
 }
 
-// mailParticipants sends new comment emails to repository watchers
-// and mentioned people.
+// TrackedTime returns the tracked time in seconds of the time tracking comment.
+func (c *Comment) TrackedTime() int64 {
+	return com.StrTo(c.Content).MustInt64()
+}
+
+// mailParticipants notifies repository watchers, participants and mentioned
+// people of the new comment, by notifications and emails.
 func (cmt *Comment) mailParticipants(e Engine, opType ActionType, issue *Issue) (err error) {
 	mentions := markup.FindAllMentions(cmt.Content)
-	if err = updateIssueMentions(e, cmt.IssueID, mentions); err != nil {
+	teamMentions := markup.FindAllTeamMentions(cmt.Content)
+	if err = updateIssueMentions(e, issue, mentions, teamMentions); err != nil {
 		return fmt.Errorf("UpdateIssueMentions [%d]: %v", cmt.IssueID, err)
 	}
 
@@ -180,8 +261,8 @@ func (cmt *Comment) mailParticipants(e Engine, opType ActionType, issue *Issue)
 	case ActionReopenIssue:
 		issue.Content = fmt.Sprintf("Reopened #%d", issue.Index)
 	}
-	if err = mailIssueCommentToParticipants(issue, cmt.Poster, mentions); err != nil {
-		log.Error("mailIssueCommentToParticipants: %v", err)
+	if err = notifyIssueParticipants(e, issue, cmt.Poster, mentions, teamMentions, cmt.ID); err != nil {
+		log.Error("notifyIssueParticipants: %v", err)
 	}
 
 	return nil
@@ -197,6 +278,14 @@ func createComment(e *xorm.Session, opts *CreateCommentOptions) (_ *Comment, err
 		CommitSHA: opts.CommitSHA,
 		Line:      opts.LineNum,
 		Content:   opts.Content,
+
+		LabelID:        opts.LabelID,
+		AssigneeID:     opts.AssigneeID,
+		OldMilestoneID: opts.OldMilestoneID,
+		MilestoneID:    opts.MilestoneID,
+		OldTitle:       opts.OldTitle,
+		NewTitle:       opts.NewTitle,
+		OldCommitSHA:   opts.OldCommitSHA,
 	}
 	if _, err = e.Insert(comment); err != nil {
 		return nil, err
@@ -209,7 +298,7 @@ func createComment(e *xorm.Session, opts *CreateCommentOptions) (_ *Comment, err
 		ActUserName:  opts.Doer.Name,
 		Content:      fmt.Sprintf("%d|%s", opts.Issue.Index, strings.Split(opts.Content, "\n")[0]),
 		RepoID:       opts.Repo.ID,
-		RepoUserName: opts.Repo.Owner.Name,
+		RepoUserName: opts.Repo.mustOwner(e).Name,
 		RepoName:     opts.Repo.Name,
 		IsPrivate:    opts.Repo.IsPrivate,
 	}
@@ -317,6 +406,15 @@ type CreateCommentOptions struct {
 	LineNum     int64
 	Content     string
 	Attachments []string // UUIDs of attachments
+
+	// For timeline events.
+	LabelID        int64
+	AssigneeID     int64
+	OldMilestoneID int64
+	MilestoneID    int64
+	OldTitle       string
+	NewTitle       string
+	OldCommitSHA   string
 }
 
 // CreateComment creates comment of issue or commit.
@@ -363,6 +461,32 @@ func CreateIssueComment(doer *User, repo *Repository, issue *Issue, content stri
 	return comment, nil
 }
 
+// CreateTrackingComment creates a time tracking comment with the tracked time
+// in seconds, which is omitted when not positive.
+func CreateTrackingComment(doer *User, repo *Repository, issue *Issue, typ CommentType, seconds int64) error {
+	opts := &CreateCommentOptions{
+		Type:  typ,
+		Doer:  doer,
+		Repo:  repo,
+		Issue: issue,
+	}
+	if seconds > 0 {
+		opts.Content = com.ToStr(seconds)
+	}
+	_, err := CreateComment(opts)
+	return err
+}
+
+// createEventComment creates a timeline event comment of the issue, the
+// repository of the issue must be loaded.
+func createEventComment(e *xorm.Session, doer *User, issue *Issue, opts CreateCommentOptions) error {
+	opts.Doer = doer
+	opts.Repo = issue.Repo
+	opts.Issue = issue
+	_, err := createComment(e, &opts)
+	return err
+}
+
 // CreateRefComment creates a commit reference comment to issue.
 func CreateRefComment(doer *User, repo *Repository, issue *Issue, content, commitSHA string) error {
 	if commitSHA == "" {
@@ -484,6 +608,19 @@ func UpdateComment(doer *User, c *Comment, oldContent string) (err error) {
 		return err
 	}
 
+	err = Handle.ContentRevisions().Create(context.TODO(), CreateContentRevisionOptions{
+		IssueID:     c.IssueID,
+		CommentID:   c.ID,
+		EditorID:    doer.ID,
+		Content:     c.Content,
+		OldContent:  oldContent,
+		AuthorID:    c.PosterID,
+		CreatedUnix: c.CreatedUnix,
+	})
+	if err != nil {
+		log.Error("Failed to create content revision [comment_id: %d]: %v", c.ID, err)
+	}
+
 	if err = c.Issue.LoadAttributes(); err != nil {
 		log.Error("Issue.LoadAttributes [issue_id: %d]: %v", c.IssueID, err)
 	} else if err = PrepareWebhooks(c.Issue.Repo, HOOK_EVENT_ISSUE_COMMENT, &api.IssueCommentPayload{
@@ -524,6 +661,13 @@ func DeleteCommentByID(doer *User, id int64) error {
 		return err
 	}
 
+	if _, err = sess.Exec("DELETE FROM `reaction` WHERE comment_id = ?", comment.ID); err != nil {
+		return fmt.Errorf("delete reactions: %v", err)
+	}
+	if _, err = sess.Exec("DELETE FROM `content_revision` WHERE comment_id = ?", comment.ID); err != nil {
+		return fmt.Errorf("delete content revisions: %v", err)
+	}
+
 	if comment.Type == COMMENT_TYPE_COMMENT {
 		if _, err = sess.Exec("UPDATE `issue` SET num_comments = num_comments - 1 WHERE id = ?", comment.IssueID); err != nil {
 			return err
@@ -0,0 +1,185 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+// ContentRevision is a revision of the body of an issue or of a comment. The
+// first revision of an edited content is its original version.
+type ContentRevision struct {
+	ID          int64  `gorm:"primaryKey"`
+	IssueID     int64  `gorm:"index;not null"`
+	CommentID   int64  `gorm:"index;not null"` // Zero for the body of the issue
+	Content     string `gorm:"type:TEXT"`
+	EditorID    int64  `gorm:"not null"`
+	CreatedUnix int64
+
+	Editor *User `gorm:"-" json:"-"`
+}
+
+// BeforeCreate implements the GORM create hook.
+func (r *ContentRevision) BeforeCreate(tx *gorm.DB) error {
+	if r.CreatedUnix == 0 {
+		r.CreatedUnix = tx.NowFunc().Unix()
+	}
+	return nil
+}
+
+// Created returns the time when the revision was made.
+func (r *ContentRevision) Created() time.Time {
+	return time.Unix(r.CreatedUnix, 0)
+}
+
+// ContentRevisionsStore is the storage layer for revisions of issue bodies and
+// comments.
+type ContentRevisionsStore struct {
+	db *gorm.DB
+}
+
+func newContentRevisionsStore(db *gorm.DB) *ContentRevisionsStore {
+	return &ContentRevisionsStore{db: db}
+}
+
+var _ errutil.NotFound = (*ErrContentRevisionNotExist)(nil)
+
+type ErrContentRevisionNotExist struct {
+	args errutil.Args
+}
+
+func IsErrContentRevisionNotExist(err error) bool {
+	return errors.As(err, &ErrContentRevisionNotExist{})
+}
+
+func (err ErrContentRevisionNotExist) Error() string {
+	return fmt.Sprintf("content revision does not exist: %v", err.args)
+}
+
+func (ErrContentRevisionNotExist) NotFound() bool {
+	return true
+}
+
+type CreateContentRevisionOptions struct {
+	IssueID   int64
+	CommentID int64 // Zero for the body of the issue
+	EditorID  int64
+	Content   string
+
+	// The content before the edit, along with the author and the creation time
+	// of the issue or the comment. They are recorded as the original version
+	// when the content has not been edited before.
+	OldContent  string
+	AuthorID    int64
+	CreatedUnix int64
+}
+
+// Create records an edit of the body of the issue, or of the comment when
+// CommentID is not zero. The original version is recorded as well when the
+// content has not been edited before. It is a noop when the content does not
+// change.
+func (s *ContentRevisionsStore) Create(ctx context.Context, opts CreateContentRevisionOptions) error {
+	if opts.Content == opts.OldContent {
+		return nil
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&ContentRevision{}).
+			Where("issue_id = ? AND comment_id = ?", opts.IssueID, opts.CommentID).
+			Count(&count).
+			Error
+		if err != nil {
+			return errors.Wrap(err, "count")
+		}
+
+		if count == 0 {
+			err = tx.Create(
+				&ContentRevision{
+					IssueID:     opts.IssueID,
+					CommentID:   opts.CommentID,
+					Content:     opts.OldContent,
+					EditorID:    opts.AuthorID,
+					CreatedUnix: opts.CreatedUnix,
+				},
+			).Error
+			if err != nil {
+				return errors.Wrap(err, "create original")
+			}
+		}
+
+		return tx.Create(
+			&ContentRevision{
+				IssueID:   opts.IssueID,
+				CommentID: opts.CommentID,
+				Content:   opts.Content,
+				EditorID:  opts.EditorID,
+			},
+		).Error
+	})
+}
+
+// GetByID returns the revision with given ID of the body of the issue, or of
+// the comment when commentID is not zero. It returns
+// ErrContentRevisionNotExist when not found.
+func (s *ContentRevisionsStore) GetByID(ctx context.Context, issueID, commentID, id int64) (*ContentRevision, error) {
+	r := new(ContentRevision)
+	err := s.db.WithContext(ctx).
+		Where("id = ? AND issue_id = ? AND comment_id = ?", id, issueID, commentID).
+		First(r).
+		Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrContentRevisionNotExist{args: errutil.Args{"revisionID": id}}
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// List returns all revisions of the body of the issue, or of the comment when
+// commentID is not zero, from the oldest to the latest.
+func (s *ContentRevisionsStore) List(ctx context.Context, issueID, commentID int64) ([]*ContentRevision, error) {
+	var revisions []*ContentRevision
+	return revisions, s.db.WithContext(ctx).
+		Where("issue_id = ? AND comment_id = ?", issueID, commentID).
+		Order("id ASC").
+		Find(&revisions).
+		Error
+}
+
+// ListEdited returns IDs of comments of the issue that have been edited, with
+// zero for the body of the issue.
+func (s *ContentRevisionsStore) ListEdited(ctx context.Context, issueID int64) (map[int64]bool, error) {
+	var commentIDs []int64
+	err := s.db.WithContext(ctx).
+		Model(&ContentRevision{}).
+		Where("issue_id = ?", issueID).
+		Distinct("comment_id").
+		Pluck("comment_id", &commentIDs).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	edited := make(map[int64]bool, len(commentIDs))
+	for _, id := range commentIDs {
+		edited[id] = true
+	}
+	return edited, nil
+}
+
+// Delete deletes the revision, which is used to remove sensitive information
+// from the history. It does not change the current content.
+func (s *ContentRevisionsStore) Delete(ctx context.Context, id int64) error {
+	return s.db.WithContext(ctx).Where("id = ?", id).Delete(&ContentRevision{}).Error
+}
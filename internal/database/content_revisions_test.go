@@ -0,0 +1,145 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+func TestContentRevisions(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	ctx := context.Background()
+	s := &ContentRevisionsStore{
+		db: newTestDB(t, "ContentRevisionsStore"),
+	}
+
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, s *ContentRevisionsStore)
+	}{
+		{"Create", contentRevisionsCreate},
+		{"ListEdited", contentRevisionsListEdited},
+		{"Delete", contentRevisionsDelete},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				err := clearTables(t, s.db)
+				require.NoError(t, err)
+			})
+			tc.test(t, ctx, s)
+		})
+		if t.Failed() {
+			break
+		}
+	}
+}
+
+func contentRevisionsCreate(t *testing.T, ctx context.Context, s *ContentRevisionsStore) {
+	// Unchanged content is not recorded
+	err := s.Create(ctx, CreateContentRevisionOptions{
+		IssueID:    1,
+		EditorID:   2,
+		Content:    "Hello",
+		OldContent: "Hello",
+		AuthorID:   1,
+	})
+	require.NoError(t, err)
+	revisions, err := s.List(ctx, 1, 0)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+
+	// The first edit records the original version as well
+	err = s.Create(ctx, CreateContentRevisionOptions{
+		IssueID:     1,
+		EditorID:    2,
+		Content:     "Hello, world",
+		OldContent:  "Hello",
+		AuthorID:    1,
+		CreatedUnix: 1588568886,
+	})
+	require.NoError(t, err)
+	err = s.Create(ctx, CreateContentRevisionOptions{
+		IssueID:     1,
+		EditorID:    1,
+		Content:     "Hello, world!",
+		OldContent:  "Hello, world",
+		AuthorID:    1,
+		CreatedUnix: 1588568886,
+	})
+	require.NoError(t, err)
+
+	revisions, err = s.List(ctx, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3)
+
+	assert.Equal(t, "Hello", revisions[0].Content)
+	assert.Equal(t, int64(1), revisions[0].EditorID)
+	assert.Equal(t, int64(1588568886), revisions[0].CreatedUnix)
+	assert.Equal(t, "Hello, world", revisions[1].Content)
+	assert.Equal(t, int64(2), revisions[1].EditorID)
+	assert.NotZero(t, revisions[1].CreatedUnix)
+	assert.Equal(t, "Hello, world!", revisions[2].Content)
+
+	// Revisions of comments are separate from the body of the issue
+	revisions, err = s.List(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+}
+
+func contentRevisionsListEdited(t *testing.T, ctx context.Context, s *ContentRevisionsStore) {
+	for _, opts := range []CreateContentRevisionOptions{
+		{IssueID: 1, EditorID: 1, Content: "b", OldContent: "a"},
+		{IssueID: 1, CommentID: 2, EditorID: 1, Content: "b", OldContent: "a"},
+		{IssueID: 1, CommentID: 2, EditorID: 1, Content: "c", OldContent: "b"},
+		{IssueID: 2, CommentID: 3, EditorID: 1, Content: "b", OldContent: "a"},
+	} {
+		err := s.Create(ctx, opts)
+		require.NoError(t, err)
+	}
+
+	edited, err := s.ListEdited(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]bool{0: true, 2: true}, edited)
+}
+
+func contentRevisionsDelete(t *testing.T, ctx context.Context, s *ContentRevisionsStore) {
+	err := s.Create(ctx, CreateContentRevisionOptions{
+		IssueID:    1,
+		CommentID:  1,
+		EditorID:   1,
+		Content:    "Redacted",
+		OldContent: "My password is 123456",
+		AuthorID:   1,
+	})
+	require.NoError(t, err)
+
+	revisions, err := s.List(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+
+	_, err = s.GetByID(ctx, 1, 0, revisions[0].ID)
+	wantErr := ErrContentRevisionNotExist{args: errutil.Args{"revisionID": revisions[0].ID}}
+	assert.Equal(t, wantErr, err)
+
+	err = s.Delete(ctx, revisions[0].ID)
+	require.NoError(t, err)
+
+	_, err = s.GetByID(ctx, 1, 1, revisions[0].ID)
+	assert.True(t, IsErrContentRevisionNotExist(err))
+	revisions, err = s.List(ctx, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "Redacted", revisions[0].Content)
+}
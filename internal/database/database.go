@@ -44,10 +44,16 @@ func newLogWriter() (logger.Writer, error) {
 // ⚠️ WARNING: This list is meant to be read-only.
 var Tables = []any{
 	new(Access), new(AccessToken), new(Action),
-	new(EmailAddress),
+	new(ContentRevision),
+	new(EmailAddress), new(EmailDigestItem),
 	new(Follow),
+	new(IssueAssignee), new(IssueDependency), new(IssueRedirect),
 	new(LFSObject), new(LoginSource),
-	new(Notice),
+	new(Notice), new(Notification), new(NotificationPreference),
+	new(Project), new(ProjectCard), new(ProjectColumn),
+	new(Reaction), new(RepoMaintenance), new(RepoMigration), new(RepoMigrationIssue),
+	new(Stopwatch),
+	new(TrackedTime),
 }
 
 // NewConnection returns a new database connection with the given logger.
@@ -152,6 +158,14 @@ func (db *DB) Actions() *ActionsStore {
 	return newActionsStore(db.db)
 }
 
+func (db *DB) ContentRevisions() *ContentRevisionsStore {
+	return newContentRevisionsStore(db.db)
+}
+
+func (db *DB) IssueDependencies() *IssueDependenciesStore {
+	return newIssueDependenciesStore(db.db)
+}
+
 func (db *DB) LFS() *LFSStore {
 	return newLFSStore(db.db)
 }
@@ -168,6 +182,14 @@ func (db *DB) Notices() *NoticesStore {
 	return newNoticesStore(db.db)
 }
 
+func (db *DB) Notifications() *NotificationsStore {
+	return newNotificationsStore(db.db)
+}
+
+func (db *DB) NotificationPreferences() *NotificationPreferencesStore {
+	return newNotificationPreferencesStore(db.db)
+}
+
 func (db *DB) Organizations() *OrganizationsStore {
 	return newOrganizationsStoreStore(db.db)
 }
@@ -180,10 +202,34 @@ func (db *DB) PublicKey() *PublicKeysStore {
 	return newPublicKeysStore(db.db)
 }
 
+func (db *DB) Projects() *ProjectsStore {
+	return newProjectsStore(db.db)
+}
+
+func (db *DB) Quotas() *QuotasStore {
+	return newQuotasStore(db.db)
+}
+
+func (db *DB) RepoMaintenance() *RepoMaintenanceStore {
+	return newRepoMaintenanceStore(db.db)
+}
+
+func (db *DB) Reactions() *ReactionsStore {
+	return newReactionsStore(db.db)
+}
+
+func (db *DB) RepoMigrations() *RepoMigrationsStore {
+	return newRepoMigrationsStore(db.db)
+}
+
 func (db *DB) Repositories() *RepositoriesStore {
 	return newReposStore(db.db)
 }
 
+func (db *DB) TrackedTimes() *TrackedTimesStore {
+	return newTrackedTimesStore(db.db)
+}
+
 func (db *DB) TwoFactors() *TwoFactorsStore {
 	return newTwoFactorsStore(db.db)
 }
@@ -40,23 +40,28 @@ type Issue struct {
 	MilestoneID     int64       `gorm:"index"`
 	Milestone       *Milestone  `xorm:"-" json:"-" gorm:"-"`
 	Priority        int
-	AssigneeID      int64 `gorm:"index"`
-	Assignee        *User `xorm:"-" json:"-" gorm:"-"`
+	Assignees       []*User `xorm:"-" json:"-" gorm:"-"`
 	IsClosed        bool
 	IsRead          bool         `xorm:"-" json:"-" gorm:"-"`
 	IsPull          bool         // Indicates whether is a pull request or not.
 	PullRequest     *PullRequest `xorm:"-" json:"-" gorm:"-"`
 	NumComments     int
+	NumReactions    int    `xorm:"NOT NULL DEFAULT 0" gorm:"not null;default:0"`
+	IsLocked        bool   `xorm:"NOT NULL DEFAULT false" gorm:"not null;default:FALSE"` // Only repository writers can comment on locked issues.
+	LockReason      string // One of IssueLockReasons, can be empty.
+	PinOrder        int    `xorm:"NOT NULL DEFAULT 0" gorm:"not null;default:0"` // Position among pinned issues of the repository, 0 means not pinned.
 
 	Deadline     time.Time `xorm:"-" json:"-" gorm:"-"`
-	DeadlineUnix int64
+	DeadlineUnix int64     `xorm:"NOT NULL DEFAULT 0" gorm:"not null;default:0"`
 	Created      time.Time `xorm:"-" json:"-" gorm:"-"`
 	CreatedUnix  int64
 	Updated      time.Time `xorm:"-" json:"-" gorm:"-"`
 	UpdatedUnix  int64
 
-	Attachments []*Attachment `xorm:"-" json:"-" gorm:"-"`
-	Comments    []*Comment    `xorm:"-" json:"-" gorm:"-"`
+	Attachments []*Attachment      `xorm:"-" json:"-" gorm:"-"`
+	Comments    []*Comment         `xorm:"-" json:"-" gorm:"-"`
+	Reactions   []*ReactionSummary `xorm:"-" json:"-" gorm:"-"`
+	IsEdited    bool               `xorm:"-" json:"-" gorm:"-"`
 }
 
 func (issue *Issue) BeforeInsert() {
@@ -66,13 +71,17 @@ func (issue *Issue) BeforeInsert() {
 
 func (issue *Issue) BeforeUpdate() {
 	issue.UpdatedUnix = time.Now().Unix()
-	issue.DeadlineUnix = issue.Deadline.Unix()
+	if !issue.Deadline.IsZero() {
+		issue.DeadlineUnix = issue.Deadline.Unix()
+	}
 }
 
 func (issue *Issue) AfterSet(colName string, _ xorm.Cell) {
 	switch colName {
 	case "deadline_unix":
-		issue.Deadline = time.Unix(issue.DeadlineUnix, 0).Local()
+		if issue.DeadlineUnix > 0 {
+			issue.Deadline = time.Unix(issue.DeadlineUnix, 0).Local()
+		}
 	case "created_unix":
 		issue.Created = time.Unix(issue.CreatedUnix, 0).Local()
 	case "updated_unix":
@@ -123,16 +132,16 @@ func (issue *Issue) loadAttributes(e Engine) (err error) {
 	}
 
 	if issue.Milestone == nil && issue.MilestoneID > 0 {
-		issue.Milestone, err = getMilestoneByRepoID(e, issue.RepoID, issue.MilestoneID)
+		issue.Milestone, err = getAvailableMilestoneByRepoID(e, issue.RepoID, issue.MilestoneID)
 		if err != nil {
-			return fmt.Errorf("getMilestoneByRepoID [repo_id: %d, milestone_id: %d]: %v", issue.RepoID, issue.MilestoneID, err)
+			return fmt.Errorf("getAvailableMilestoneByRepoID [repo_id: %d, milestone_id: %d]: %v", issue.RepoID, issue.MilestoneID, err)
 		}
 	}
 
-	if issue.Assignee == nil && issue.AssigneeID > 0 {
-		issue.Assignee, err = getUserByID(e, issue.AssigneeID)
+	if issue.Assignees == nil {
+		issue.Assignees, err = getAssigneesByIssueID(e, issue.ID)
 		if err != nil {
-			return fmt.Errorf("getUserByID.(assignee) [%d]: %v", issue.AssigneeID, err)
+			return fmt.Errorf("getAssigneesByIssueID [%d]: %v", issue.ID, err)
 		}
 	}
 
@@ -185,7 +194,7 @@ func (issue *Issue) State() api.StateType {
 
 // This method assumes some fields assigned with values:
 // Required - Poster, Labels,
-// Optional - Milestone, Assignee, PullRequest
+// Optional - Milestone, Assignees, PullRequest
 func (issue *Issue) APIFormat() *api.Issue {
 	apiLabels := make([]*api.Label, len(issue.Labels))
 	for i := range issue.Labels {
@@ -208,8 +217,9 @@ func (issue *Issue) APIFormat() *api.Issue {
 	if issue.Milestone != nil {
 		apiIssue.Milestone = issue.Milestone.APIFormat()
 	}
-	if issue.Assignee != nil {
-		apiIssue.Assignee = issue.Assignee.APIFormat()
+	// For backward compatibility, the first assignee is presented as the assignee.
+	if len(issue.Assignees) > 0 {
+		apiIssue.Assignee = issue.Assignees[0].APIFormat()
 	}
 	if issue.IsPull {
 		apiIssue.PullRequest = &api.PullRequestMeta{
@@ -277,24 +287,46 @@ func (issue *Issue) addLabel(e *xorm.Session, label *Label) error {
 
 // AddLabel adds a new label to the issue.
 func (issue *Issue) AddLabel(doer *User, label *Label) error {
-	if err := NewIssueLabel(issue, label); err != nil {
-		return err
-	}
-
-	issue.sendLabelUpdatedWebhook(doer)
-	return nil
+	return issue.AddLabels(doer, []*Label{label})
 }
 
-func (issue *Issue) addLabels(e *xorm.Session, labels []*Label) error {
-	return newIssueLabels(e, issue, labels)
+// addLabelsWithEvents adds labels that the issue does not have yet, and creates
+// a timeline event for each of them.
+func (issue *Issue) addLabelsWithEvents(e *xorm.Session, doer *User, labels []*Label) (err error) {
+	for _, label := range labels {
+		if hasIssueLabel(e, issue.ID, label.ID) {
+			continue
+		}
+
+		if err = newIssueLabel(e, issue, label); err != nil {
+			return fmt.Errorf("newIssueLabel: %v", err)
+		}
+		if err = createEventComment(e, doer, issue, CreateCommentOptions{
+			Type:    COMMENT_TYPE_ADD_LABEL,
+			LabelID: label.ID,
+		}); err != nil {
+			return fmt.Errorf("createEventComment: %v", err)
+		}
+	}
+	return nil
 }
 
 // AddLabels adds a list of new labels to the issue.
-func (issue *Issue) AddLabels(doer *User, labels []*Label) error {
-	if err := NewIssueLabels(issue, labels); err != nil {
+func (issue *Issue) AddLabels(doer *User, labels []*Label) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = issue.addLabelsWithEvents(sess, doer, labels); err != nil {
 		return err
 	}
 
+	if err = sess.Commit(); err != nil {
+		return fmt.Errorf("Commit: %v", err)
+	}
+
 	issue.sendLabelUpdatedWebhook(doer)
 	return nil
 }
@@ -311,35 +343,56 @@ func (issue *Issue) getLabels(e Engine) (err error) {
 	return nil
 }
 
-func (issue *Issue) removeLabel(e *xorm.Session, label *Label) error {
-	return deleteIssueLabel(e, issue, label)
+// removeLabelsWithEvents removes labels that the issue has, and creates a
+// timeline event for each of them.
+func (issue *Issue) removeLabelsWithEvents(e *xorm.Session, doer *User, labels []*Label) (err error) {
+	for _, label := range labels {
+		if !hasIssueLabel(e, issue.ID, label.ID) {
+			continue
+		}
+
+		if err = deleteIssueLabel(e, issue, label); err != nil {
+			return fmt.Errorf("deleteIssueLabel: %v", err)
+		}
+		if err = createEventComment(e, doer, issue, CreateCommentOptions{
+			Type:    COMMENT_TYPE_REMOVE_LABEL,
+			LabelID: label.ID,
+		}); err != nil {
+			return fmt.Errorf("createEventComment: %v", err)
+		}
+	}
+	return nil
 }
 
 // RemoveLabel removes a label from issue by given ID.
-func (issue *Issue) RemoveLabel(doer *User, label *Label) error {
-	if err := DeleteIssueLabel(issue, label); err != nil {
+func (issue *Issue) RemoveLabel(doer *User, label *Label) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = issue.removeLabelsWithEvents(sess, doer, []*Label{label}); err != nil {
 		return err
 	}
 
+	if err = sess.Commit(); err != nil {
+		return fmt.Errorf("Commit: %v", err)
+	}
+
 	issue.sendLabelUpdatedWebhook(doer)
 	return nil
 }
 
-func (issue *Issue) clearLabels(e *xorm.Session) (err error) {
+func (issue *Issue) clearLabels(e *xorm.Session, doer *User) (err error) {
 	if err = issue.getLabels(e); err != nil {
 		return fmt.Errorf("getLabels: %v", err)
 	}
 
-	// NOTE: issue.removeLabel slices issue.Labels, so we need to create another slice to be unaffected.
+	// NOTE: deleteIssueLabel slices issue.Labels, so we need to create another slice to be unaffected.
 	labels := make([]*Label, len(issue.Labels))
 	copy(labels, issue.Labels)
-	for i := range labels {
-		if err = issue.removeLabel(e, labels[i]); err != nil {
-			return fmt.Errorf("removeLabel: %v", err)
-		}
-	}
-
-	return nil
+	return issue.removeLabelsWithEvents(e, doer, labels)
 }
 
 func (issue *Issue) ClearLabels(doer *User) (err error) {
@@ -349,7 +402,7 @@ func (issue *Issue) ClearLabels(doer *User) (err error) {
 		return err
 	}
 
-	if err = issue.clearLabels(sess); err != nil {
+	if err = issue.clearLabels(sess, doer); err != nil {
 		return err
 	}
 
@@ -387,32 +440,36 @@ func (issue *Issue) ClearLabels(doer *User) (err error) {
 }
 
 // ReplaceLabels removes all current labels and add new labels to the issue.
-func (issue *Issue) ReplaceLabels(labels []*Label) (err error) {
+// Timeline events are only created for labels that are actually changed.
+func (issue *Issue) ReplaceLabels(doer *User, labels []*Label) (err error) {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
 		return err
 	}
 
-	if err = issue.clearLabels(sess); err != nil {
-		return fmt.Errorf("clearLabels: %v", err)
-	} else if err = issue.addLabels(sess, labels); err != nil {
-		return fmt.Errorf("addLabels: %v", err)
+	if err = issue.getLabels(sess); err != nil {
+		return fmt.Errorf("getLabels: %v", err)
 	}
 
-	return sess.Commit()
-}
-
-func (issue *Issue) GetAssignee() (err error) {
-	if issue.AssigneeID == 0 || issue.Assignee != nil {
-		return nil
+	keeps := make(map[int64]bool, len(labels))
+	for _, label := range labels {
+		keeps[label.ID] = true
+	}
+	removes := make([]*Label, 0, len(issue.Labels))
+	for _, label := range issue.Labels {
+		if !keeps[label.ID] {
+			removes = append(removes, label)
+		}
 	}
 
-	issue.Assignee, err = Handle.Users().GetByID(context.TODO(), issue.AssigneeID)
-	if IsErrUserNotExist(err) {
-		return nil
+	if err = issue.removeLabelsWithEvents(sess, doer, removes); err != nil {
+		return fmt.Errorf("removeLabelsWithEvents: %v", err)
+	} else if err = issue.addLabelsWithEvents(sess, doer, labels); err != nil {
+		return fmt.Errorf("addLabelsWithEvents: %v", err)
 	}
-	return err
+
+	return sess.Commit()
 }
 
 // ReadBy sets issue to be read by given user.
@@ -472,8 +529,37 @@ func (issue *Issue) changeStatus(e *xorm.Session, doer *User, repo *Repository,
 	return nil
 }
 
-// ChangeStatus changes issue status to open or closed.
+// CheckClosable returns ErrIssueBlocked when the issue is still blocked by
+// open dependencies, unless the repository allows closing blocked issues.
+func (issue *Issue) CheckClosable(repo *Repository) error {
+	if issue.IsPull || repo.AllowClosingBlocked {
+		return nil
+	}
+
+	count, err := Handle.IssueDependencies().CountOpenDependencies(context.TODO(), issue.ID)
+	if err != nil {
+		return fmt.Errorf("count open dependencies: %v", err)
+	} else if count > 0 {
+		return ErrIssueBlocked{IssueID: issue.ID, NumDependencies: count}
+	}
+	return nil
+}
+
+// ChangeStatus changes issue status to open or closed. It returns
+// ErrIssueBlocked when closing an issue that is still blocked by open
+// dependencies, unless the repository allows so.
 func (issue *Issue) ChangeStatus(doer *User, repo *Repository, isClosed bool) (err error) {
+	// Nothing should be performed if current status is same as target status
+	if issue.IsClosed == isClosed {
+		return nil
+	}
+
+	if isClosed {
+		if err = issue.CheckClosable(repo); err != nil {
+			return err
+		}
+	}
+
 	sess := x.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
@@ -488,6 +574,14 @@ func (issue *Issue) ChangeStatus(doer *User, repo *Repository, isClosed bool) (e
 		return fmt.Errorf("Commit: %v", err)
 	}
 
+	automation := ProjectColumnAutomationReopened
+	if isClosed {
+		automation = ProjectColumnAutomationClosed
+	}
+	if err = Handle.Projects().MoveIssueCards(context.TODO(), issue.ID, automation); err != nil {
+		log.Error("Failed to move project cards [issue_id: %d]: %v", issue.ID, err)
+	}
+
 	if issue.IsPull {
 		// Merge pull request calls issue.changeStatus so we need to handle separately.
 		issue.PullRequest.Issue = issue
@@ -527,8 +621,28 @@ func (issue *Issue) ChangeStatus(doer *User, repo *Repository, isClosed bool) (e
 func (issue *Issue) ChangeTitle(doer *User, title string) (err error) {
 	oldTitle := issue.Title
 	issue.Title = title
-	if err = UpdateIssueCols(issue, "name"); err != nil {
-		return fmt.Errorf("UpdateIssueCols: %v", err)
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = updateIssueCols(sess, issue, "name"); err != nil {
+		return fmt.Errorf("updateIssueCols: %v", err)
+	}
+	if oldTitle != title {
+		if err = createEventComment(sess, doer, issue, CreateCommentOptions{
+			Type:     COMMENT_TYPE_CHANGE_TITLE,
+			OldTitle: oldTitle,
+			NewTitle: title,
+		}); err != nil {
+			return fmt.Errorf("createEventComment: %v", err)
+		}
+	}
+
+	if err = sess.Commit(); err != nil {
+		return fmt.Errorf("Commit: %v", err)
 	}
 
 	if issue.IsPull {
@@ -566,6 +680,176 @@ func (issue *Issue) ChangeTitle(doer *User, title string) (err error) {
 	return nil
 }
 
+// HasDeadline returns true if the issue has a due date.
+func (issue *Issue) HasDeadline() bool {
+	return issue.DeadlineUnix > 0
+}
+
+// IsOverdue returns true if the issue is still open after its due date.
+func (issue *Issue) IsOverdue() bool {
+	return !issue.IsClosed && issue.HasDeadline() && time.Now().Unix() > issue.DeadlineUnix
+}
+
+// ChangeDeadline changes the due date of the issue, the zero time removes the
+// due date.
+func (issue *Issue) ChangeDeadline(deadline time.Time) error {
+	issue.Deadline = deadline
+	issue.DeadlineUnix = 0
+	if !deadline.IsZero() {
+		issue.DeadlineUnix = deadline.Unix()
+	}
+	if err := UpdateIssueCols(issue, "deadline_unix"); err != nil {
+		return fmt.Errorf("UpdateIssueCols: %v", err)
+	}
+	return nil
+}
+
+// IssueLockReasons are the valid reasons of locking issues.
+var IssueLockReasons = []string{"off_topic", "too_heated", "resolved", "spam"}
+
+// IsValidIssueLockReason returns true if the reason is empty or one of
+// IssueLockReasons.
+func IsValidIssueLockReason(reason string) bool {
+	return reason == "" || com.IsSliceContainsStr(IssueLockReasons, reason)
+}
+
+func (issue *Issue) changeLock(e *xorm.Session, doer *User, lock bool, reason string) error {
+	if issue.IsLocked == lock {
+		return nil
+	}
+
+	typ := COMMENT_TYPE_UNLOCK
+	issue.IsLocked = lock
+	issue.LockReason = ""
+	if lock {
+		typ = COMMENT_TYPE_LOCK
+		issue.LockReason = reason
+	}
+
+	if err := updateIssueCols(e, issue, "is_locked", "lock_reason"); err != nil {
+		return fmt.Errorf("updateIssueCols: %v", err)
+	}
+	if err := createEventComment(e, doer, issue, CreateCommentOptions{
+		Type:    typ,
+		Content: issue.LockReason,
+	}); err != nil {
+		return fmt.Errorf("createEventComment: %v", err)
+	}
+	return nil
+}
+
+// ChangeLock locks the conversation of the issue with the reason, or unlocks
+// it. It does nothing when the issue is already in the state.
+func (issue *Issue) ChangeLock(doer *User, lock bool, reason string) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = issue.changeLock(sess, doer, lock, reason); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// MaxPinnedIssues is the maximum number of pinned issues, and separately the
+// maximum number of pinned pull requests, of a repository.
+const MaxPinnedIssues = 3
+
+type ErrPinnedIssuesLimit struct {
+	RepoID int64
+	IsPull bool
+}
+
+// IsErrPinnedIssuesLimit returns true if the underlying error has the type
+// ErrPinnedIssuesLimit.
+func IsErrPinnedIssuesLimit(err error) bool {
+	_, ok := err.(ErrPinnedIssuesLimit)
+	return ok
+}
+
+func (err ErrPinnedIssuesLimit) Error() string {
+	return fmt.Sprintf("maximum number of %d pinned issues reached: [repo_id: %d, is_pull: %v]", MaxPinnedIssues, err.RepoID, err.IsPull)
+}
+
+// IsPinned returns true if the issue is pinned.
+func (issue *Issue) IsPinned() bool {
+	return issue.PinOrder > 0
+}
+
+func (issue *Issue) changePin(e *xorm.Session, doer *User, pin bool) error {
+	if issue.IsPinned() == pin {
+		return nil
+	}
+
+	typ := COMMENT_TYPE_UNPIN
+	pinOrder := 0
+	if pin {
+		typ = COMMENT_TYPE_PIN
+
+		count, err := e.Where("repo_id = ? AND is_pull = ? AND pin_order > 0", issue.RepoID, issue.IsPull).Count(new(Issue))
+		if err != nil {
+			return fmt.Errorf("count pinned issues: %v", err)
+		} else if count >= MaxPinnedIssues {
+			return ErrPinnedIssuesLimit{RepoID: issue.RepoID, IsPull: issue.IsPull}
+		}
+
+		var maxOrder int
+		if _, err = e.Table("issue").Select("COALESCE(MAX(pin_order), 0)").
+			Where("repo_id = ? AND is_pull = ?", issue.RepoID, issue.IsPull).
+			Get(&maxOrder); err != nil {
+			return fmt.Errorf("get max pin order: %v", err)
+		}
+		pinOrder = maxOrder + 1
+	}
+
+	issue.PinOrder = pinOrder
+	if err := updateIssueCols(e, issue, "pin_order"); err != nil {
+		return fmt.Errorf("updateIssueCols: %v", err)
+	}
+	if err := createEventComment(e, doer, issue, CreateCommentOptions{
+		Type: typ,
+	}); err != nil {
+		return fmt.Errorf("createEventComment: %v", err)
+	}
+	return nil
+}
+
+// ChangePin pins the issue after the already pinned ones, or unpins it. It
+// returns ErrPinnedIssuesLimit when the repository has reached MaxPinnedIssues.
+// It does nothing when the issue is already in the state.
+func (issue *Issue) ChangePin(doer *User, pin bool) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = issue.changePin(sess, doer, pin); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetPinnedIssues returns pinned issues or pull requests of the repository in
+// the order of pinning.
+func GetPinnedIssues(repoID int64, isPull bool) ([]*Issue, error) {
+	issues := make([]*Issue, 0, MaxPinnedIssues)
+	if err := x.Where("repo_id = ? AND is_pull = ? AND pin_order > 0", repoID, isPull).
+		Asc("pin_order").
+		Find(&issues); err != nil {
+		return nil, err
+	}
+
+	for i := range issues {
+		if err := issues[i].LoadAttributes(); err != nil {
+			return nil, fmt.Errorf("LoadAttributes [%d]: %v", issues[i].ID, err)
+		}
+	}
+	return issues, nil
+}
+
 func (issue *Issue) ChangeContent(doer *User, content string) (err error) {
 	oldContent := issue.Content
 	issue.Content = content
@@ -573,6 +857,8 @@ func (issue *Issue) ChangeContent(doer *User, content string) (err error) {
 		return fmt.Errorf("UpdateIssueCols: %v", err)
 	}
 
+	issue.createContentRevision(doer, oldContent)
+
 	if issue.IsPull {
 		issue.PullRequest.Issue = issue
 		err = PrepareWebhooks(issue.Repo, HOOK_EVENT_PULL_REQUEST, &api.PullRequestPayload{
@@ -608,69 +894,24 @@ func (issue *Issue) ChangeContent(doer *User, content string) (err error) {
 	return nil
 }
 
-func (issue *Issue) ChangeAssignee(doer *User, assigneeID int64) (err error) {
-	issue.AssigneeID = assigneeID
-	if err = UpdateIssueUserByAssignee(issue); err != nil {
-		return fmt.Errorf("UpdateIssueUserByAssignee: %v", err)
-	}
-
-	issue.Assignee, err = Handle.Users().GetByID(context.TODO(), issue.AssigneeID)
-	if err != nil && !IsErrUserNotExist(err) {
-		log.Error("Failed to get user by ID: %v", err)
-		return nil
-	}
-
-	// Error not nil here means user does not exist, which is remove assignee.
-	isRemoveAssignee := err != nil
-	if issue.IsPull {
-		issue.PullRequest.Issue = issue
-		apiPullRequest := &api.PullRequestPayload{
-			Index:       issue.Index,
-			PullRequest: issue.PullRequest.APIFormat(),
-			Repository:  issue.Repo.APIFormatLegacy(nil),
-			Sender:      doer.APIFormat(),
-		}
-		if isRemoveAssignee {
-			apiPullRequest.Action = api.HOOK_ISSUE_UNASSIGNED
-		} else {
-			apiPullRequest.Action = api.HOOK_ISSUE_ASSIGNED
-		}
-		err = PrepareWebhooks(issue.Repo, HOOK_EVENT_PULL_REQUEST, apiPullRequest)
-	} else {
-		apiIssues := &api.IssuesPayload{
-			Index:      issue.Index,
-			Issue:      issue.APIFormat(),
-			Repository: issue.Repo.APIFormatLegacy(nil),
-			Sender:     doer.APIFormat(),
-		}
-		if isRemoveAssignee {
-			apiIssues.Action = api.HOOK_ISSUE_UNASSIGNED
-		} else {
-			apiIssues.Action = api.HOOK_ISSUE_ASSIGNED
-		}
-		err = PrepareWebhooks(issue.Repo, HOOK_EVENT_ISSUES, apiIssues)
-	}
-	if err != nil {
-		log.Error("PrepareWebhooks [is_pull: %v, remove_assignee: %v]: %v", issue.IsPull, isRemoveAssignee, err)
-	}
-
-	return nil
-}
-
 type NewIssueOptions struct {
 	Repo        *Repository
 	Issue       *Issue
 	LableIDs    []int64
+	AssigneeIDs []int64
 	Attachments []string // In UUID format.
 	IsPull      bool
 }
 
 func newIssue(e *xorm.Session, opts NewIssueOptions) (err error) {
 	opts.Issue.Title = strings.TrimSpace(opts.Issue.Title)
-	opts.Issue.Index = opts.Repo.NextIssueIndex()
+	opts.Issue.Index, err = opts.Repo.nextIssueIndex(e)
+	if err != nil {
+		return err
+	}
 
 	if opts.Issue.MilestoneID > 0 {
-		milestone, err := getMilestoneByRepoID(e, opts.Issue.RepoID, opts.Issue.MilestoneID)
+		milestone, err := getAvailableMilestoneByRepoID(e, opts.Issue.RepoID, opts.Issue.MilestoneID)
 		if err != nil && !IsErrMilestoneNotExist(err) {
 			return fmt.Errorf("getMilestoneByID: %v", err)
 		}
@@ -686,24 +927,31 @@ func newIssue(e *xorm.Session, opts NewIssueOptions) (err error) {
 		}
 	}
 
-	if opts.Issue.AssigneeID > 0 {
-		assignee, err := getUserByID(e, opts.Issue.AssigneeID)
-		if err != nil && !IsErrUserNotExist(err) {
-			return fmt.Errorf("get user by ID: %v", err)
+	// Milestone validation should happen before insert actual object.
+	if _, err = e.Insert(opts.Issue); err != nil {
+		return err
+	}
+
+	// Assignees are saved before issue users to set the assigned status.
+	opts.Issue.Assignees = make([]*User, 0, len(opts.AssigneeIDs))
+	for _, assigneeID := range opts.AssigneeIDs {
+		if opts.Issue.IsAssignee(assigneeID) {
+			continue
 		}
 
-		if assignee != nil {
-			opts.Issue.AssigneeID = assignee.ID
-			opts.Issue.Assignee = assignee
-		} else {
+		assignee, err := getUserByID(e, assigneeID)
+		if err != nil {
 			// The assignee does not exist, drop it
-			opts.Issue.AssigneeID = 0
+			if IsErrUserNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("get user by ID: %v", err)
 		}
-	}
 
-	// Milestone and assignee validation should happen before insert actual object.
-	if _, err = e.Insert(opts.Issue); err != nil {
-		return err
+		if _, err = e.Insert(&IssueAssignee{IssueID: opts.Issue.ID, AssigneeID: assignee.ID}); err != nil {
+			return fmt.Errorf("insert issue assignee: %v", err)
+		}
+		opts.Issue.Assignees = append(opts.Issue.Assignees, assignee)
 	}
 
 	if opts.IsPull {
@@ -725,7 +973,8 @@ func newIssue(e *xorm.Session, opts NewIssueOptions) (err error) {
 
 		for _, label := range labels {
 			// Silently drop invalid labels.
-			if label.RepoID != opts.Repo.ID {
+			if label.RepoID != opts.Repo.ID &&
+				(!label.IsOrgLabel() || label.OrgID != opts.Repo.OwnerID) {
 				continue
 			}
 
@@ -756,8 +1005,9 @@ func newIssue(e *xorm.Session, opts NewIssueOptions) (err error) {
 	return opts.Issue.loadAttributes(e)
 }
 
-// NewIssue creates new issue with labels and attachments for repository.
-func NewIssue(repo *Repository, issue *Issue, labelIDs []int64, uuids []string) (err error) {
+// NewIssue creates new issue with labels, assignees and attachments for
+// repository.
+func NewIssue(repo *Repository, issue *Issue, labelIDs, assigneeIDs []int64, uuids []string) (err error) {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
@@ -768,6 +1018,7 @@ func NewIssue(repo *Repository, issue *Issue, labelIDs []int64, uuids []string)
 		Repo:        repo,
 		Issue:       issue,
 		LableIDs:    labelIDs,
+		AssigneeIDs: assigneeIDs,
 		Attachments: uuids,
 	}); err != nil {
 		return fmt.Errorf("newIssue: %v", err)
@@ -911,6 +1162,9 @@ type IssuesOptions struct {
 	IsPull      bool
 	Labels      string
 	SortType    string
+	// Query overrides IsClosed, IsPull and SortType when it has the
+	// corresponding qualifiers.
+	Query *IssueQuery
 }
 
 // buildIssuesQuery returns nil if it foresees there won't be any value returned.
@@ -921,20 +1175,33 @@ func buildIssuesQuery(opts *IssuesOptions) *xorm.Session {
 		opts.Page = 1
 	}
 
+	isClosed, isPull, sortType := opts.IsClosed, opts.IsPull, opts.SortType
+	if opts.Query != nil {
+		if opts.Query.IsClosed != nil {
+			isClosed = *opts.Query.IsClosed
+		}
+		if opts.Query.IsPull != nil {
+			isPull = *opts.Query.IsPull
+		}
+		if opts.Query.SortType != "" {
+			sortType = opts.Query.SortType
+		}
+	}
+
 	if opts.RepoID > 0 {
-		sess.Where("issue.repo_id=?", opts.RepoID).And("issue.is_closed=?", opts.IsClosed)
+		sess.Where("issue.repo_id=?", opts.RepoID).And("issue.is_closed=?", isClosed)
 	} else if opts.RepoIDs != nil {
 		// In case repository IDs are provided but actually no repository has issue.
 		if len(opts.RepoIDs) == 0 {
 			return nil
 		}
-		sess.In("issue.repo_id", opts.RepoIDs).And("issue.is_closed=?", opts.IsClosed)
+		sess.In("issue.repo_id", opts.RepoIDs).And("issue.is_closed=?", isClosed)
 	} else {
-		sess.Where("issue.is_closed=?", opts.IsClosed)
+		sess.Where("issue.is_closed=?", isClosed)
 	}
 
 	if opts.AssigneeID > 0 {
-		sess.And("issue.assignee_id=?", opts.AssigneeID)
+		sess.And(issueAssignedCond, opts.AssigneeID)
 	} else if opts.PosterID > 0 {
 		sess.And("issue.poster_id=?", opts.PosterID)
 	}
@@ -943,9 +1210,13 @@ func buildIssuesQuery(opts *IssuesOptions) *xorm.Session {
 		sess.And("issue.milestone_id=?", opts.MilestoneID)
 	}
 
-	sess.And("issue.is_pull=?", opts.IsPull)
+	sess.And("issue.is_pull=?", isPull)
 
-	switch opts.SortType {
+	if opts.Query != nil {
+		opts.Query.buildCond(sess)
+	}
+
+	switch sortType {
 	case "oldest":
 		sess.Asc("issue.created_unix")
 	case "recentupdate":
@@ -956,6 +1227,15 @@ func buildIssuesQuery(opts *IssuesOptions) *xorm.Session {
 		sess.Desc("issue.num_comments")
 	case "leastcomment":
 		sess.Asc("issue.num_comments")
+	case "mostreaction":
+		sess.Desc("issue.num_reactions")
+	case "leastreaction":
+		sess.Asc("issue.num_reactions")
+	case "nearduedate":
+		// Issues without due dates come last
+		sess.OrderBy("CASE WHEN issue.deadline_unix > 0 THEN 0 ELSE 1 END").Asc("issue.deadline_unix")
+	case "farduedate":
+		sess.Desc("issue.deadline_unix")
 	case "priority":
 		sess.Desc("issue.priority")
 	default:
@@ -1050,6 +1330,9 @@ type IssueUser struct {
 	IsMentioned bool
 	IsPoster    bool
 	IsClosed    bool
+	// Whether the user has unsubscribed from email notifications of the issue,
+	// which are still sent when the user is mentioned.
+	IsUnsubscribed bool
 }
 
 func newIssueUsers(e *xorm.Session, repo *Repository, issue *Issue) error {
@@ -1071,7 +1354,7 @@ func newIssueUsers(e *xorm.Session, repo *Repository, issue *Issue) error {
 			RepoID:     repo.ID,
 			UserID:     assignee.ID,
 			IsPoster:   isPoster,
-			IsAssigned: assignee.ID == issue.AssigneeID,
+			IsAssigned: issue.IsAssignee(assignee.ID),
 		})
 		if !isPosterAssignee && isPoster {
 			isPosterAssignee = true
@@ -1157,10 +1440,22 @@ func GetIssueUserPairsByMode(userID, repoID int64, filterMode FilterMode, isClos
 	return ius, err
 }
 
+// canReadIssues returns true if the user can read issues of the repository.
+func canReadIssues(ctx context.Context, perms *PermissionsStore, u *User, repo *Repository) bool {
+	return !repo.IsPrivate || u.IsAdmin ||
+		perms.Authorize(ctx, u.ID, repo.ID, AccessModeRead,
+			AccessModeOptions{
+				OwnerID: repo.OwnerID,
+				Private: repo.IsPrivate,
+			},
+		)
+}
+
 // updateIssueMentions extracts mentioned people from content and
-// updates issue-user relations for them.
-func updateIssueMentions(e Engine, issueID int64, mentions []string) error {
-	if len(mentions) == 0 {
+// updates issue-user relations for them. People who cannot access the
+// repository of the issue are skipped.
+func updateIssueMentions(e Engine, issue *Issue, mentions, teamMentions []string) error {
+	if len(mentions) == 0 && len(teamMentions) == 0 {
 		return nil
 	}
 
@@ -1169,31 +1464,47 @@ func updateIssueMentions(e Engine, issueID int64, mentions []string) error {
 	}
 	users := make([]*User, 0, len(mentions))
 
-	if err := e.In("lower_name", mentions).Asc("lower_name").Find(&users); err != nil {
-		return fmt.Errorf("find mentioned users: %v", err)
+	if len(mentions) > 0 {
+		if err := e.In("lower_name", mentions).Asc("lower_name").Find(&users); err != nil {
+			return fmt.Errorf("find mentioned users: %v", err)
+		}
 	}
 
-	ids := make([]int64, 0, len(mentions))
+	mentioned := make([]*User, 0, len(users))
 	for _, user := range users {
-		ids = append(ids, user.ID)
+		mentioned = append(mentioned, user)
 		if !user.IsOrganization() || user.NumMembers == 0 {
 			continue
 		}
 
-		memberIDs := make([]int64, 0, user.NumMembers)
 		orgUsers, err := getOrgUsersByOrgID(e, user.ID, 0)
 		if err != nil {
 			return fmt.Errorf("getOrgUsersByOrgID [%d]: %v", user.ID, err)
 		}
-
 		for _, orgUser := range orgUsers {
-			memberIDs = append(memberIDs, orgUser.ID)
+			member, err := getUserByID(e, orgUser.Uid)
+			if err != nil {
+				return fmt.Errorf("getUserByID [%d]: %v", orgUser.Uid, err)
+			}
+			mentioned = append(mentioned, member)
 		}
+	}
 
-		ids = append(ids, memberIDs...)
+	teamMembers, err := getMentionedTeamMembers(e, teamMentions)
+	if err != nil {
+		return fmt.Errorf("getMentionedTeamMembers: %v", err)
+	}
+	mentioned = append(mentioned, teamMembers...)
+
+	ctx := context.TODO()
+	ids := make([]int64, 0, len(mentioned))
+	for _, u := range mentioned {
+		if canReadIssues(ctx, Handle.Permissions(), u, issue.Repo) {
+			ids = append(ids, u.ID)
+		}
 	}
 
-	if err := updateIssueUsersByMentions(e, issueID, ids); err != nil {
+	if err := updateIssueUsersByMentions(e, issue.ID, ids); err != nil {
 		return fmt.Errorf("UpdateIssueUsersByMentions: %v", err)
 	}
 
@@ -1257,7 +1568,7 @@ func GetIssueStats(opts *IssueStatsOptions) *IssueStats {
 		}
 
 		if opts.AssigneeID > 0 {
-			sess.And("assignee_id = ?", opts.AssigneeID)
+			sess.And(issueAssignedCond, opts.AssigneeID)
 		}
 
 		return sess
@@ -1317,7 +1628,7 @@ func GetUserIssueStats(repoID, userID int64, repoIDs []int64, filterMode FilterM
 	}
 
 	stats.AssignCount, _ = countSession(false, isPull, repoID, nil).
-		And("assignee_id = ?", userID).
+		And(issueAssignedCond, userID).
 		Count(new(Issue))
 
 	stats.CreateCount, _ = countSession(false, isPull, repoID, nil).
@@ -1341,10 +1652,10 @@ func GetUserIssueStats(repoID, userID int64, repoIDs []int64, filterMode FilterM
 			Count(new(Issue))
 	case FILTER_MODE_ASSIGN:
 		stats.OpenCount, _ = countSession(false, isPull, repoID, nil).
-			And("assignee_id = ?", userID).
+			And(issueAssignedCond, userID).
 			Count(new(Issue))
 		stats.ClosedCount, _ = countSession(true, isPull, repoID, nil).
-			And("assignee_id = ?", userID).
+			And(issueAssignedCond, userID).
 			Count(new(Issue))
 	case FILTER_MODE_CREATE:
 		stats.OpenCount, _ = countSession(false, isPull, repoID, nil).
@@ -1373,8 +1684,8 @@ func GetRepoIssueStats(repoID, userID int64, filterMode FilterMode, isPull bool)
 
 	switch filterMode {
 	case FILTER_MODE_ASSIGN:
-		openCountSession.And("assignee_id = ?", userID)
-		closedCountSession.And("assignee_id = ?", userID)
+		openCountSession.And(issueAssignedCond, userID)
+		closedCountSession.And(issueAssignedCond, userID)
 	case FILTER_MODE_CREATE:
 		openCountSession.And("poster_id = ?", userID)
 		closedCountSession.And("poster_id = ?", userID)
@@ -1391,9 +1702,30 @@ func updateIssue(e Engine, issue *Issue) error {
 	return err
 }
 
-// UpdateIssue updates all fields of given issue.
-func UpdateIssue(issue *Issue) error {
-	return updateIssue(x, issue)
+// createContentRevision records the edit of the issue body by the doer. Failure
+// is only logged because the edit itself has already been saved.
+func (issue *Issue) createContentRevision(doer *User, oldContent string) {
+	err := Handle.ContentRevisions().Create(context.TODO(), CreateContentRevisionOptions{
+		IssueID:     issue.ID,
+		EditorID:    doer.ID,
+		Content:     issue.Content,
+		OldContent:  oldContent,
+		AuthorID:    issue.PosterID,
+		CreatedUnix: issue.CreatedUnix,
+	})
+	if err != nil {
+		log.Error("Failed to create content revision [issue_id: %d]: %v", issue.ID, err)
+	}
+}
+
+// UpdateIssue updates all fields of given issue, and records the revision of
+// the body when it differs from oldContent.
+func UpdateIssue(doer *User, issue *Issue, oldContent string) error {
+	if err := updateIssue(x, issue); err != nil {
+		return err
+	}
+	issue.createContentRevision(doer, oldContent)
+	return nil
 }
 
 func updateIssueUsersByStatus(e Engine, issueID int64, isClosed bool) error {
@@ -1406,36 +1738,6 @@ func UpdateIssueUsersByStatus(issueID int64, isClosed bool) error {
 	return updateIssueUsersByStatus(x, issueID, isClosed)
 }
 
-func updateIssueUserByAssignee(e *xorm.Session, issue *Issue) (err error) {
-	if _, err = e.Exec("UPDATE `issue_user` SET is_assigned = ? WHERE issue_id = ?", false, issue.ID); err != nil {
-		return err
-	}
-
-	// Assignee ID equals to 0 means clear assignee.
-	if issue.AssigneeID > 0 {
-		if _, err = e.Exec("UPDATE `issue_user` SET is_assigned = ? WHERE uid = ? AND issue_id = ?", true, issue.AssigneeID, issue.ID); err != nil {
-			return err
-		}
-	}
-
-	return updateIssue(e, issue)
-}
-
-// UpdateIssueUserByAssignee updates issue-user relation for assignee.
-func UpdateIssueUserByAssignee(issue *Issue) (err error) {
-	sess := x.NewSession()
-	defer sess.Close()
-	if err = sess.Begin(); err != nil {
-		return err
-	}
-
-	if err = updateIssueUserByAssignee(sess, issue); err != nil {
-		return err
-	}
-
-	return sess.Commit()
-}
-
 // UpdateIssueUserByRead updates issue-user relation for reading.
 func UpdateIssueUserByRead(uid, issueID int64) error {
 	_, err := x.Exec("UPDATE `issue_user` SET is_read=? WHERE uid=? AND issue_id=?", true, uid, issueID)
@@ -1466,3 +1768,42 @@ func updateIssueUsersByMentions(e Engine, issueID int64, uids []int64) error {
 	}
 	return nil
 }
+
+// UnsubscribeIssue stops email notifications of the issue to the user, except
+// for those that mention the user.
+func UnsubscribeIssue(userID int64, issue *Issue) error {
+	iu := &IssueUser{
+		UserID:  userID,
+		IssueID: issue.ID,
+	}
+	has, err := x.Get(iu)
+	if err != nil {
+		return err
+	}
+
+	iu.IsUnsubscribed = true
+	if has {
+		_, err = x.ID(iu.ID).AllCols().Update(iu)
+	} else {
+		iu.RepoID = issue.RepoID
+		iu.MilestoneID = issue.MilestoneID
+		iu.IsClosed = issue.IsClosed
+		_, err = x.Insert(iu)
+	}
+	return err
+}
+
+// getIssueUnsubscribers returns IDs of users who have unsubscribed from email
+// notifications of the issue.
+func getIssueUnsubscribers(e Engine, issueID int64) (map[int64]bool, error) {
+	ius := make([]*IssueUser, 0, 5)
+	if err := e.Where("issue_id = ? AND is_unsubscribed = ?", issueID, true).Find(&ius); err != nil {
+		return nil, err
+	}
+
+	userIDs := make(map[int64]bool, len(ius))
+	for _, iu := range ius {
+		userIDs[iu.UserID] = true
+	}
+	return userIDs, nil
+}
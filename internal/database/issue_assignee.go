@@ -0,0 +1,251 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"fmt"
+
+	api "github.com/gogs/go-gogs-client"
+	log "unknwon.dev/clog/v2"
+	"xorm.io/xorm"
+
+	"gogs.io/gogs/internal/markup"
+)
+
+// IssueAssignee represents an issue-assignee relation.
+type IssueAssignee struct {
+	ID         int64 `gorm:"primaryKey"`
+	IssueID    int64 `xorm:"UNIQUE(s)" gorm:"uniqueIndex:issue_assignee_unique;not null"`
+	AssigneeID int64 `xorm:"UNIQUE(s) INDEX" gorm:"uniqueIndex:issue_assignee_unique;index;not null"`
+}
+
+// issueAssignedCond is the condition of issues that are assigned to the given
+// user.
+const issueAssignedCond = "issue.id IN (SELECT issue_id FROM `issue_assignee` WHERE assignee_id = ?)"
+
+func getAssigneeIDsByIssueID(e Engine, issueID int64) ([]int64, error) {
+	assigneeIDs := make([]int64, 0, 2)
+	return assigneeIDs, e.Table("issue_assignee").Cols("assignee_id").
+		Where("issue_id = ?", issueID).
+		Asc("id").
+		Find(&assigneeIDs)
+}
+
+func getAssigneesByIssueID(e Engine, issueID int64) ([]*User, error) {
+	assigneeIDs, err := getAssigneeIDsByIssueID(e, issueID)
+	if err != nil {
+		return nil, fmt.Errorf("get assignee IDs: %v", err)
+	} else if len(assigneeIDs) == 0 {
+		return []*User{}, nil
+	}
+
+	users := make([]*User, 0, len(assigneeIDs))
+	if err = e.In("id", assigneeIDs).Asc("lower_name").Find(&users); err != nil {
+		return nil, err
+	}
+
+	// TODO(unknwon): Rely on AfterFind hook to sanitize user full name.
+	for _, u := range users {
+		u.FullName = markup.Sanitize(u.FullName)
+	}
+	return users, nil
+}
+
+// GetAssigneesByIssueID returns all users that are assigned to given issue by
+// ID.
+func GetAssigneesByIssueID(issueID int64) ([]*User, error) {
+	return getAssigneesByIssueID(x, issueID)
+}
+
+// deleteIssueAssigneesByRepoID deletes issue-assignee relations of all issues in
+// the repository.
+func deleteIssueAssigneesByRepoID(e Engine, repoID int64) error {
+	_, err := e.Exec("DELETE FROM `issue_assignee` WHERE issue_id IN (SELECT id FROM `issue` WHERE repo_id = ?)", repoID)
+	return err
+}
+
+// IsAssignee returns true if the user is one of assignees of the issue. It
+// assumes assignees of the issue have been loaded.
+func (issue *Issue) IsAssignee(userID int64) bool {
+	for _, assignee := range issue.Assignees {
+		if assignee.ID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// AssigneeIDs returns IDs of assignees of the issue. It assumes assignees of the
+// issue have been loaded.
+func (issue *Issue) AssigneeIDs() []int64 {
+	ids := make([]int64, len(issue.Assignees))
+	for i := range issue.Assignees {
+		ids[i] = issue.Assignees[i].ID
+	}
+	return ids
+}
+
+// changeIssueAssignees replaces assignees of the issue with users of given IDs,
+// non-existent users are dropped silently. It returns users that are newly
+// assigned and users that are unassigned.
+func changeIssueAssignees(e *xorm.Session, issue *Issue, assigneeIDs []int64) (assigned, unassigned []*User, err error) {
+	current, err := getAssigneesByIssueID(e, issue.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get assignees: %v", err)
+	}
+
+	wanted := make(map[int64]bool, len(assigneeIDs))
+	for _, id := range assigneeIDs {
+		wanted[id] = true
+	}
+
+	for _, u := range current {
+		if wanted[u.ID] {
+			delete(wanted, u.ID)
+			continue
+		}
+
+		if _, err = e.Delete(&IssueAssignee{IssueID: issue.ID, AssigneeID: u.ID}); err != nil {
+			return nil, nil, fmt.Errorf("delete issue assignee: %v", err)
+		}
+		unassigned = append(unassigned, u)
+	}
+
+	for _, id := range assigneeIDs {
+		if !wanted[id] {
+			continue
+		}
+		delete(wanted, id) // Guard against duplicated IDs
+
+		u, err := getUserByID(e, id)
+		if err != nil {
+			if IsErrUserNotExist(err) {
+				continue
+			}
+			return nil, nil, fmt.Errorf("get user by ID: %v", err)
+		}
+
+		if _, err = e.Insert(&IssueAssignee{IssueID: issue.ID, AssigneeID: u.ID}); err != nil {
+			return nil, nil, fmt.Errorf("insert issue assignee: %v", err)
+		}
+		assigned = append(assigned, u)
+	}
+
+	issue.Assignees, err = getAssigneesByIssueID(e, issue.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get assignees: %v", err)
+	}
+
+	if err = updateIssueUserByAssignees(e, issue); err != nil {
+		return nil, nil, fmt.Errorf("update issue users: %v", err)
+	}
+	return assigned, unassigned, nil
+}
+
+// ChangeAssignees replaces assignees of the issue with users of given IDs, and
+// triggers webhooks for every user that is newly assigned or unassigned.
+func (issue *Issue) ChangeAssignees(doer *User, assigneeIDs []int64) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	assigned, unassigned, err := changeIssueAssignees(sess, issue, assigneeIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range []struct {
+		typ   CommentType
+		users []*User
+	}{
+		{COMMENT_TYPE_ASSIGN, assigned},
+		{COMMENT_TYPE_UNASSIGN, unassigned},
+	} {
+		for _, u := range event.users {
+			if err = createEventComment(sess, doer, issue, CreateCommentOptions{
+				Type:       event.typ,
+				AssigneeID: u.ID,
+			}); err != nil {
+				return fmt.Errorf("create event comment: %v", err)
+			}
+		}
+	}
+
+	if err = sess.Commit(); err != nil {
+		return fmt.Errorf("commit: %v", err)
+	}
+
+	for _, u := range assigned {
+		issue.prepareAssigneeWebhooks(doer, u, api.HOOK_ISSUE_ASSIGNED)
+	}
+	for _, u := range unassigned {
+		issue.prepareAssigneeWebhooks(doer, u, api.HOOK_ISSUE_UNASSIGNED)
+	}
+	return nil
+}
+
+// prepareAssigneeWebhooks triggers webhooks of the action to the assignee,
+// which is presented as the assignee in the payload.
+func (issue *Issue) prepareAssigneeWebhooks(doer, assignee *User, action api.HookIssueAction) {
+	var err error
+	if issue.IsPull {
+		issue.PullRequest.Issue = issue
+		apiPullRequest := issue.PullRequest.APIFormat()
+		apiPullRequest.Assignee = assignee.APIFormat()
+		err = PrepareWebhooks(issue.Repo, HOOK_EVENT_PULL_REQUEST, &api.PullRequestPayload{
+			Action:      action,
+			Index:       issue.Index,
+			PullRequest: apiPullRequest,
+			Repository:  issue.Repo.APIFormatLegacy(nil),
+			Sender:      doer.APIFormat(),
+		})
+	} else {
+		apiIssue := issue.APIFormat()
+		apiIssue.Assignee = assignee.APIFormat()
+		err = PrepareWebhooks(issue.Repo, HOOK_EVENT_ISSUES, &api.IssuesPayload{
+			Action:     action,
+			Index:      issue.Index,
+			Issue:      apiIssue,
+			Repository: issue.Repo.APIFormatLegacy(nil),
+			Sender:     doer.APIFormat(),
+		})
+	}
+	if err != nil {
+		log.Error("PrepareWebhooks [is_pull: %v, action: %v]: %v", issue.IsPull, action, err)
+	}
+}
+
+func updateIssueUserByAssignees(e *xorm.Session, issue *Issue) (err error) {
+	if _, err = e.Exec("UPDATE `issue_user` SET is_assigned = ? WHERE issue_id = ?", false, issue.ID); err != nil {
+		return err
+	}
+
+	for _, assignee := range issue.Assignees {
+		iu := &IssueUser{
+			UserID:  assignee.ID,
+			IssueID: issue.ID,
+		}
+		has, err := e.Get(iu)
+		if err != nil {
+			return err
+		}
+
+		iu.IsAssigned = true
+		if has {
+			_, err = e.ID(iu.ID).AllCols().Update(iu)
+		} else {
+			iu.RepoID = issue.RepoID
+			iu.MilestoneID = issue.MilestoneID
+			iu.IsClosed = issue.IsClosed
+			_, err = e.Insert(iu)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteIssueAssigneesByRepoID(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	e := newTestLegacyEngine(t, new(Issue), new(IssueAssignee))
+
+	issue1 := &Issue{RepoID: 1, Index: 1}
+	issue2 := &Issue{RepoID: 2, Index: 1}
+	_, err := e.Insert(issue1, issue2)
+	require.NoError(t, err)
+	_, err = e.Insert(
+		&IssueAssignee{IssueID: issue1.ID, AssigneeID: 1},
+		&IssueAssignee{IssueID: issue1.ID, AssigneeID: 2},
+		&IssueAssignee{IssueID: issue2.ID, AssigneeID: 1},
+	)
+	require.NoError(t, err)
+
+	err = deleteIssueAssigneesByRepoID(e, 1)
+	require.NoError(t, err)
+
+	got, err := getAssigneeIDsByIssueID(e, issue1.ID)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	// Assignees of issues in other repositories are kept
+	got, err = getAssigneeIDsByIssueID(e, issue2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, got)
+}
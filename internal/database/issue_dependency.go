@@ -0,0 +1,286 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	dberrors "gogs.io/gogs/internal/database/errors"
+	"gogs.io/gogs/internal/errutil"
+)
+
+// IssueDependency is a relation that an issue is blocked by another issue (the
+// dependency), which may belong to a different repository.
+type IssueDependency struct {
+	ID           int64 `gorm:"primaryKey"`
+	IssueID      int64 `gorm:"uniqueIndex:issue_dependency_unique;not null"`
+	DependencyID int64 `gorm:"uniqueIndex:issue_dependency_unique;index;not null"`
+	CreatedUnix  int64
+}
+
+// BeforeCreate implements the GORM create hook.
+func (d *IssueDependency) BeforeCreate(tx *gorm.DB) error {
+	if d.CreatedUnix == 0 {
+		d.CreatedUnix = tx.NowFunc().Unix()
+	}
+	return nil
+}
+
+// IssueDependenciesStore is the storage layer for issue dependencies.
+type IssueDependenciesStore struct {
+	db *gorm.DB
+}
+
+func newIssueDependenciesStore(db *gorm.DB) *IssueDependenciesStore {
+	return &IssueDependenciesStore{db: db}
+}
+
+var _ errutil.NotFound = (*ErrIssueDependencyNotExist)(nil)
+
+type ErrIssueDependencyNotExist struct {
+	args errutil.Args
+}
+
+func IsErrIssueDependencyNotExist(err error) bool {
+	return errors.As(err, &ErrIssueDependencyNotExist{})
+}
+
+func (err ErrIssueDependencyNotExist) Error() string {
+	return fmt.Sprintf("issue dependency does not exist: %v", err.args)
+}
+
+func (ErrIssueDependencyNotExist) NotFound() bool {
+	return true
+}
+
+// ErrIssueDependencyInvalid is returned when the dependency would make an
+// issue depend on itself, either directly or through other issues.
+type ErrIssueDependencyInvalid struct {
+	args errutil.Args
+}
+
+func IsErrIssueDependencyInvalid(err error) bool {
+	return errors.As(err, &ErrIssueDependencyInvalid{})
+}
+
+func (err ErrIssueDependencyInvalid) Error() string {
+	return fmt.Sprintf("issue dependency would create a cycle: %v", err.args)
+}
+
+type ErrIssueDependencyAlreadyExist struct {
+	args errutil.Args
+}
+
+func IsErrIssueDependencyAlreadyExist(err error) bool {
+	return errors.As(err, &ErrIssueDependencyAlreadyExist{})
+}
+
+func (err ErrIssueDependencyAlreadyExist) Error() string {
+	return fmt.Sprintf("issue dependency already exists: %v", err.args)
+}
+
+// Add makes the issue blocked by the dependency. It returns
+// ErrIssueDependencyAlreadyExist when the relation already exists, or
+// ErrIssueDependencyInvalid when the relation would create a cycle.
+func (s *IssueDependenciesStore) Add(ctx context.Context, issueID, dependencyID int64) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		args := errutil.Args{"issueID": issueID, "dependencyID": dependencyID}
+
+		err := tx.Where("issue_id = ? AND dependency_id = ?", issueID, dependencyID).First(&IssueDependency{}).Error
+		if err == nil {
+			return ErrIssueDependencyAlreadyExist{args: args}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Wrap(err, "check existence")
+		}
+
+		// The relation creates a cycle if the issue is the dependency itself or
+		// one of transitive dependencies of the dependency.
+		visited := map[int64]bool{dependencyID: true}
+		queue := []int64{dependencyID}
+		for len(queue) > 0 && !visited[issueID] {
+			var next []int64
+			err = tx.Model(&IssueDependency{}).Where("issue_id IN (?)", queue).Pluck("dependency_id", &next).Error
+			if err != nil {
+				return errors.Wrap(err, "list transitive dependencies")
+			}
+
+			queue = queue[:0]
+			for _, id := range next {
+				if !visited[id] {
+					visited[id] = true
+					queue = append(queue, id)
+				}
+			}
+		}
+		if visited[issueID] {
+			return ErrIssueDependencyInvalid{args: args}
+		}
+
+		return tx.Create(
+			&IssueDependency{
+				IssueID:      issueID,
+				DependencyID: dependencyID,
+			},
+		).Error
+	})
+}
+
+// Remove removes the relation that the issue is blocked by the dependency. It
+// returns ErrIssueDependencyNotExist when not found.
+func (s *IssueDependenciesStore) Remove(ctx context.Context, issueID, dependencyID int64) error {
+	result := s.db.WithContext(ctx).
+		Where("issue_id = ? AND dependency_id = ?", issueID, dependencyID).
+		Delete(&IssueDependency{})
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return ErrIssueDependencyNotExist{args: errutil.Args{"issueID": issueID, "dependencyID": dependencyID}}
+	}
+	return nil
+}
+
+// ListDependencyIDs returns IDs of issues that block the given issue.
+func (s *IssueDependenciesStore) ListDependencyIDs(ctx context.Context, issueID int64) ([]int64, error) {
+	var ids []int64
+	return ids, s.db.WithContext(ctx).
+		Model(&IssueDependency{}).
+		Where("issue_id = ?", issueID).
+		Order("id ASC").
+		Pluck("dependency_id", &ids).
+		Error
+}
+
+// ListDependentIDs returns IDs of issues that are blocked by the given issue.
+func (s *IssueDependenciesStore) ListDependentIDs(ctx context.Context, dependencyID int64) ([]int64, error) {
+	var ids []int64
+	return ids, s.db.WithContext(ctx).
+		Model(&IssueDependency{}).
+		Where("dependency_id = ?", dependencyID).
+		Order("id ASC").
+		Pluck("issue_id", &ids).
+		Error
+}
+
+// CountOpenDependencies returns the number of open issues that block the given
+// issue.
+func (s *IssueDependenciesStore) CountOpenDependencies(ctx context.Context, issueID int64) (int64, error) {
+	var count int64
+	return count, s.db.WithContext(ctx).
+		Model(&IssueDependency{}).
+		Joins("JOIN issue ON issue.id = issue_dependency.dependency_id").
+		Where("issue_dependency.issue_id = ? AND issue.is_closed = ?", issueID, false).
+		Count(&count).
+		Error
+}
+
+// ErrIssueBlocked is returned when closing an issue or merging a pull request
+// while it is still blocked by open dependencies.
+type ErrIssueBlocked struct {
+	IssueID         int64
+	NumDependencies int64
+}
+
+// IsErrIssueBlocked returns true if the underlying error has the type
+// ErrIssueBlocked.
+func IsErrIssueBlocked(err error) bool {
+	return errors.As(err, &ErrIssueBlocked{})
+}
+
+func (err ErrIssueBlocked) Error() string {
+	return fmt.Sprintf("issue is blocked by %d open dependencies: [issue_id: %d]", err.NumDependencies, err.IssueID)
+}
+
+// CanReadIssues returns true if the user (nil for anonymous) can read issues of
+// the repository.
+func CanReadIssues(ctx context.Context, user *User, repo *Repository) bool {
+	if !repo.EnableIssues || repo.EnableExternalTracker {
+		return false
+	} else if repo.CanGuestViewIssues() || (user != nil && user.IsAdmin) {
+		return true
+	}
+
+	var userID int64
+	if user != nil {
+		userID = user.ID
+	}
+	return Handle.Permissions().Authorize(ctx, userID, repo.ID, AccessModeRead,
+		AccessModeOptions{
+			OwnerID: repo.OwnerID,
+			Private: repo.IsPrivate,
+		},
+	)
+}
+
+// GetVisibleIssueByRef returns the issue by given reference that is visible to
+// the user (nil for anonymous). The reference is either "#<index>" within the
+// given repository, or "<owner>/<repo>#<index>" of any repository. It returns
+// ErrIssueNotExist when the issue does not exist or is not visible to the
+// user.
+func GetVisibleIssueByRef(ctx context.Context, user *User, repo *Repository, ref string) (*Issue, error) {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "#") {
+		ref = repo.FullName() + ref
+	}
+
+	issue, err := GetIssueByRef(ref)
+	if err != nil {
+		if errutil.IsNotFound(err) || dberrors.IsInvalidRepoReference(err) {
+			return nil, ErrIssueNotExist{args: errutil.Args{"ref": ref}}
+		}
+		return nil, err
+	}
+
+	if !CanReadIssues(ctx, user, issue.Repo) {
+		return nil, ErrIssueNotExist{args: errutil.Args{"ref": ref}}
+	}
+	return issue, nil
+}
+
+// ListVisibleIssueDependencies returns issues that block the given issue and
+// issues that are blocked by the given issue, filtering out those that are not
+// visible to the user (nil for anonymous).
+func ListVisibleIssueDependencies(ctx context.Context, user *User, issueID int64) (blockedBy, blocks []*Issue, err error) {
+	load := func(ids []int64) ([]*Issue, error) {
+		issues := make([]*Issue, 0, len(ids))
+		for _, id := range ids {
+			issue, err := GetIssueByID(id)
+			if err != nil {
+				if IsErrIssueNotExist(err) {
+					continue
+				}
+				return nil, errors.Wrapf(err, "get issue by ID %d", id)
+			}
+
+			if CanReadIssues(ctx, user, issue.Repo) {
+				issues = append(issues, issue)
+			}
+		}
+		return issues, nil
+	}
+
+	dependencyIDs, err := Handle.IssueDependencies().ListDependencyIDs(ctx, issueID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "list dependency IDs")
+	}
+	blockedBy, err = load(dependencyIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dependentIDs, err := Handle.IssueDependencies().ListDependentIDs(ctx, issueID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "list dependent IDs")
+	}
+	blocks, err = load(dependentIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return blockedBy, blocks, nil
+}
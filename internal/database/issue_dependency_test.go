@@ -0,0 +1,113 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+func TestIssueDependencies(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	ctx := context.Background()
+	s := &IssueDependenciesStore{
+		db: newTestDB(t, "IssueDependenciesStore"),
+	}
+
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, s *IssueDependenciesStore)
+	}{
+		{"Add", issueDependenciesAdd},
+		{"Remove", issueDependenciesRemove},
+		{"CountOpenDependencies", issueDependenciesCountOpenDependencies},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				err := clearTables(t, s.db)
+				require.NoError(t, err)
+			})
+			tc.test(t, ctx, s)
+		})
+		if t.Failed() {
+			break
+		}
+	}
+}
+
+func issueDependenciesAdd(t *testing.T, ctx context.Context, s *IssueDependenciesStore) {
+	// 1 <- 2 <- 3
+	err := s.Add(ctx, 2, 1)
+	require.NoError(t, err)
+	err = s.Add(ctx, 3, 2)
+	require.NoError(t, err)
+
+	err = s.Add(ctx, 2, 1)
+	wantErr := ErrIssueDependencyAlreadyExist{args: errutil.Args{"issueID": int64(2), "dependencyID": int64(1)}}
+	assert.Equal(t, wantErr, err)
+
+	// Both direct and transitive cycles are rejected
+	err = s.Add(ctx, 1, 1)
+	assert.True(t, IsErrIssueDependencyInvalid(err))
+	err = s.Add(ctx, 1, 3)
+	assert.True(t, IsErrIssueDependencyInvalid(err))
+
+	ids, err := s.ListDependencyIDs(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, ids)
+
+	ids, err = s.ListDependentIDs(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3}, ids)
+}
+
+func issueDependenciesRemove(t *testing.T, ctx context.Context, s *IssueDependenciesStore) {
+	err := s.Remove(ctx, 2, 1)
+	wantErr := ErrIssueDependencyNotExist{args: errutil.Args{"issueID": int64(2), "dependencyID": int64(1)}}
+	assert.Equal(t, wantErr, err)
+
+	err = s.Add(ctx, 2, 1)
+	require.NoError(t, err)
+	err = s.Remove(ctx, 2, 1)
+	require.NoError(t, err)
+
+	ids, err := s.ListDependencyIDs(ctx, 2)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func issueDependenciesCountOpenDependencies(t *testing.T, ctx context.Context, s *IssueDependenciesStore) {
+	issues := []*Issue{
+		{RepoID: 1, Index: 1, Title: "open"},
+		{RepoID: 2, Index: 1, Title: "closed", IsClosed: true},
+		{RepoID: 1, Index: 2, Title: "blocked"},
+	}
+	for _, issue := range issues {
+		err := s.db.Create(issue).Error
+		require.NoError(t, err)
+	}
+
+	for _, dependency := range issues[:2] {
+		err := s.Add(ctx, issues[2].ID, dependency.ID)
+		require.NoError(t, err)
+	}
+
+	count, err := s.CountOpenDependencies(ctx, issues[2].ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = s.CountOpenDependencies(ctx, issues[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
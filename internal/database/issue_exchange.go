@@ -0,0 +1,426 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/strutil"
+)
+
+const (
+	IssueExchangeFormatCSV  = "csv"
+	IssueExchangeFormatJSON = "json"
+
+	// MaxIssueImportRecords is the maximum number of records that can be
+	// imported at once.
+	MaxIssueImportRecords = 1000
+
+	issueRecordDateLayout = "2006-01-02"
+)
+
+// IssueRecord is the representation of an issue in exported and imported
+// spreadsheets. Index, author and timestamps are ignored on import, where
+// issues are always created by the doer.
+type IssueRecord struct {
+	Index     int64      `json:"index,omitempty"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"`
+	Labels    []string   `json:"labels"`
+	Milestone string     `json:"milestone"`
+	Assignees []string   `json:"assignees"`
+	Author    string     `json:"author,omitempty"`
+	Created   *time.Time `json:"created_at,omitempty"`
+	Updated   *time.Time `json:"updated_at,omitempty"`
+	DueDate   string     `json:"due_date"`
+}
+
+var issueRecordColumns = []string{
+	"index", "title", "body", "state", "labels", "milestone", "assignees",
+	"author", "created_at", "updated_at", "due_date",
+}
+
+// newIssueRecord returns the record of the issue, whose attributes must have
+// been loaded.
+func newIssueRecord(issue *Issue) *IssueRecord {
+	r := &IssueRecord{
+		Index:     issue.Index,
+		Title:     issue.Title,
+		Body:      issue.Content,
+		State:     "open",
+		Labels:    make([]string, 0, len(issue.Labels)),
+		Assignees: make([]string, 0, len(issue.Assignees)),
+		Created:   &issue.Created,
+		Updated:   &issue.Updated,
+	}
+	if issue.IsClosed {
+		r.State = "closed"
+	}
+	for _, label := range issue.Labels {
+		r.Labels = append(r.Labels, label.Name)
+	}
+	if issue.Milestone != nil {
+		r.Milestone = issue.Milestone.Name
+	}
+	for _, assignee := range issue.Assignees {
+		r.Assignees = append(r.Assignees, assignee.Name)
+	}
+	if issue.Poster != nil {
+		r.Author = issue.Poster.Name
+	}
+	if issue.HasDeadline() {
+		r.DueDate = issue.Deadline.Format(issueRecordDateLayout)
+	}
+	return r
+}
+
+// ListIssueRecords returns records of all issues that match the options,
+// regardless of the page.
+func ListIssueRecords(opts *IssuesOptions) ([]*IssueRecord, error) {
+	sess := buildIssuesQuery(opts)
+	if sess == nil {
+		return []*IssueRecord{}, nil
+	}
+
+	issues := make([]*Issue, 0, conf.UI.IssuePagingNum)
+	if err := sess.Find(&issues); err != nil {
+		return nil, fmt.Errorf("find issues: %v", err)
+	}
+
+	records := make([]*IssueRecord, 0, len(issues))
+	for _, issue := range issues {
+		if err := issue.LoadAttributes(); err != nil {
+			return nil, fmt.Errorf("load attributes [issue_id: %d]: %v", issue.ID, err)
+		}
+		records = append(records, newIssueRecord(issue))
+	}
+	return records, nil
+}
+
+// WriteIssueRecords writes records to w in the given format. Cells of CSV that
+// would be treated as formulas by spreadsheet applications are escaped.
+func WriteIssueRecords(w io.Writer, format string, records []*IssueRecord) error {
+	switch format {
+	case IssueExchangeFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case IssueExchangeFormatCSV:
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(issueRecordColumns); err != nil {
+		return err
+	}
+	for _, r := range records {
+		if err := cw.Write([]string{
+			strconv.FormatInt(r.Index, 10),
+			strutil.EscapeCSVCell(r.Title),
+			strutil.EscapeCSVCell(r.Body),
+			r.State,
+			strutil.EscapeCSVCell(strings.Join(r.Labels, ", ")),
+			strutil.EscapeCSVCell(r.Milestone),
+			strutil.EscapeCSVCell(strings.Join(r.Assignees, ", ")),
+			strutil.EscapeCSVCell(r.Author),
+			formatTime(r.Created),
+			formatTime(r.Updated),
+			r.DueDate,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// splitIssueRecordList splits a comma-separated list of names in a CSV cell.
+func splitIssueRecordList(s string) []string {
+	names := make([]string, 0, 3)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// ReadIssueRecords reads records from r in the given format. CSV input must
+// start with a header row that has at least the "title" column, other known
+// columns are optional and unknown columns are ignored.
+func ReadIssueRecords(r io.Reader, format string) ([]*IssueRecord, error) {
+	switch format {
+	case IssueExchangeFormatJSON:
+		records := make([]*IssueRecord, 0, 10)
+		if err := json.NewDecoder(r).Decode(&records); err != nil {
+			return nil, fmt.Errorf("decode JSON: %v", err)
+		}
+		return records, nil
+	case IssueExchangeFormatCSV:
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return []*IssueRecord{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read header: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil, fmt.Errorf(`missing column "title"`)
+	}
+
+	records := make([]*IssueRecord, 0, 10)
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("read row: %v", err)
+		}
+
+		cell := func(column string) string {
+			i, ok := columns[column]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strutil.UnescapeCSVCell(row[i])
+		}
+		records = append(records, &IssueRecord{
+			Title:     cell("title"),
+			Body:      cell("body"),
+			State:     cell("state"),
+			Labels:    splitIssueRecordList(cell("labels")),
+			Milestone: cell("milestone"),
+			Assignees: splitIssueRecordList(cell("assignees")),
+			DueDate:   cell("due_date"),
+		})
+	}
+	return records, nil
+}
+
+// IssueImportError is a validation error of an imported record.
+type IssueImportError struct {
+	// Row is the 1-based position of the record, not counting the CSV header,
+	// or 0 for errors about the whole input.
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// IssueImportReport describes the outcome of an issue import.
+type IssueImportReport struct {
+	DryRun bool `json:"dry_run"`
+	Total  int  `json:"total"`
+	// Created contains indexes of created issues.
+	Created []int64            `json:"created"`
+	Errors  []IssueImportError `json:"errors"`
+}
+
+// validatedIssueRecord is an imported record resolved against the repository.
+type validatedIssueRecord struct {
+	*IssueRecord
+	isClosed    bool
+	labelIDs    []int64
+	milestoneID int64
+	assigneeIDs []int64
+	deadline    time.Time
+}
+
+// validateIssueRecords resolves names in the records and returns the
+// validation errors, if any.
+func validateIssueRecords(repo *Repository, records []*IssueRecord) ([]*validatedIssueRecord, []IssueImportError, error) {
+	labels, err := GetLabelsByRepoID(repo.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get labels: %v", err)
+	}
+	labelsByName := make(map[string]int64, len(labels))
+	for _, label := range labels {
+		labelsByName[strings.ToLower(label.Name)] = label.ID
+	}
+
+	milestones, err := GetMilestonesByRepoID(repo.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get milestones: %v", err)
+	}
+	milestonesByName := make(map[string]int64, len(milestones))
+	for _, milestone := range milestones {
+		milestonesByName[strings.ToLower(milestone.Name)] = milestone.ID
+	}
+
+	assignees, err := repo.GetAssignees()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get assignees: %v", err)
+	}
+	assigneesByName := make(map[string]int64, len(assignees))
+	for _, assignee := range assignees {
+		assigneesByName[strings.ToLower(assignee.Name)] = assignee.ID
+	}
+
+	validated := make([]*validatedIssueRecord, 0, len(records))
+	var errs []IssueImportError
+	for i, r := range records {
+		addError := func(format string, args ...any) {
+			errs = append(errs, IssueImportError{
+				Row:     i + 1,
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+
+		if r == nil {
+			addError("record is empty")
+			continue
+		}
+
+		v := &validatedIssueRecord{IssueRecord: r}
+		r.Title = strings.TrimSpace(r.Title)
+		if r.Title == "" {
+			addError("title is required")
+		} else if len(r.Title) > 255 {
+			addError("title is longer than 255 characters")
+		}
+
+		switch strings.ToLower(strings.TrimSpace(r.State)) {
+		case "", "open":
+		case "closed":
+			v.isClosed = true
+		default:
+			addError("unknown state %q", r.State)
+		}
+
+		for _, name := range r.Labels {
+			id, ok := labelsByName[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				addError("label %q does not exist", name)
+				continue
+			}
+			v.labelIDs = append(v.labelIDs, id)
+		}
+
+		if name := strings.TrimSpace(r.Milestone); name != "" {
+			id, ok := milestonesByName[strings.ToLower(name)]
+			if !ok {
+				addError("milestone %q does not exist", name)
+			}
+			v.milestoneID = id
+		}
+
+		for _, name := range r.Assignees {
+			id, ok := assigneesByName[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "@"))]
+			if !ok {
+				addError("user %q cannot be assigned", name)
+				continue
+			}
+			v.assigneeIDs = append(v.assigneeIDs, id)
+		}
+
+		if date := strings.TrimSpace(r.DueDate); date != "" {
+			v.deadline, err = time.ParseInLocation(issueRecordDateLayout, date, time.Local)
+			if err != nil {
+				addError("due date %q is not in the format YYYY-MM-DD", r.DueDate)
+			}
+		}
+
+		validated = append(validated, v)
+	}
+	return validated, errs, nil
+}
+
+// ImportIssues creates issues from the records in the repository with the doer
+// as the poster. Records are all validated first, and nothing is created when
+// any of them is invalid or in a dry run. Issues are created in a single
+// transaction without notifying watchers, participants or webhooks.
+func ImportIssues(doer *User, repo *Repository, records []*IssueRecord, dryRun bool) (*IssueImportReport, error) {
+	report := &IssueImportReport{
+		DryRun:  dryRun,
+		Total:   len(records),
+		Created: []int64{},
+		Errors:  []IssueImportError{},
+	}
+	if len(records) > MaxIssueImportRecords {
+		report.Errors = append(report.Errors, IssueImportError{
+			Message: fmt.Sprintf("cannot import more than %d records at once", MaxIssueImportRecords),
+		})
+		return report, nil
+	}
+
+	validated, errs, err := validateIssueRecords(repo, records)
+	if err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		report.Errors = errs
+		return report, nil
+	} else if dryRun {
+		return report, nil
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	created := make([]int64, 0, len(validated))
+	for _, v := range validated {
+		issue := &Issue{
+			RepoID:      repo.ID,
+			Repo:        repo,
+			Title:       v.Title,
+			PosterID:    doer.ID,
+			Poster:      doer,
+			MilestoneID: v.milestoneID,
+			Content:     v.Body,
+		}
+		if !v.deadline.IsZero() {
+			issue.DeadlineUnix = v.deadline.Unix()
+		}
+		if err = newIssue(sess, NewIssueOptions{
+			Repo:        repo,
+			Issue:       issue,
+			LableIDs:    v.labelIDs,
+			AssigneeIDs: v.assigneeIDs,
+		}); err != nil {
+			return nil, fmt.Errorf("new issue %q: %v", v.Title, err)
+		}
+		created = append(created, issue.Index)
+
+		if v.isClosed {
+			if err = issue.changeStatus(sess, doer, repo, true); err != nil {
+				return nil, fmt.Errorf("change status [issue_id: %d]: %v", issue.ID, err)
+			}
+		}
+	}
+
+	if err = sess.Commit(); err != nil {
+		return nil, err
+	}
+	report.Created = created
+	return report, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteIssueRecords_CSVFormula(t *testing.T) {
+	records := []*IssueRecord{
+		{
+			Index:     1,
+			Title:     `=HYPERLINK("http://example.com", "Click")`,
+			Body:      "+1",
+			State:     "open",
+			Labels:    []string{"-wontfix"},
+			Milestone: "@v1.0",
+			Assignees: []string{},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteIssueRecords(&buf, IssueExchangeFormatCSV, records))
+	rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, rows, 2)
+	assert.Equal(t, `1,"'=HYPERLINK(""http://example.com"", ""Click"")",'+1,open,'-wontfix,'@v1.0,,,,,`, rows[1])
+
+	// Escaped cells are read back as they were
+	got, err := ReadIssueRecords(&buf, IssueExchangeFormatCSV)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, records[0].Title, got[0].Title)
+	assert.Equal(t, records[0].Body, got[0].Body)
+	assert.Equal(t, records[0].Labels, got[0].Labels)
+	assert.Equal(t, records[0].Milestone, got[0].Milestone)
+}
+
+func TestIssueRecords(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []*IssueRecord{
+		{
+			Index:     1,
+			Title:     "Crash on start",
+			Body:      "Line one,\nline \"two\"",
+			State:     "open",
+			Labels:    []string{"bug", "help wanted"},
+			Milestone: "v1.0",
+			Assignees: []string{"alice", "bob"},
+			Author:    "carol",
+			Created:   &created,
+			Updated:   &created,
+			DueDate:   "2024-02-01",
+		},
+		{
+			Index:     2,
+			Title:     "Docs",
+			State:     "closed",
+			Labels:    []string{},
+			Assignees: []string{},
+		},
+	}
+
+	for _, format := range []string{IssueExchangeFormatCSV, IssueExchangeFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteIssueRecords(&buf, format, records))
+
+			got, err := ReadIssueRecords(&buf, format)
+			require.NoError(t, err)
+			require.Len(t, got, len(records))
+			for i := range records {
+				assert.Equal(t, records[i].Title, got[i].Title)
+				assert.Equal(t, records[i].Body, got[i].Body)
+				assert.Equal(t, records[i].State, got[i].State)
+				assert.Equal(t, records[i].Labels, got[i].Labels)
+				assert.Equal(t, records[i].Milestone, got[i].Milestone)
+				assert.Equal(t, records[i].Assignees, got[i].Assignees)
+				assert.Equal(t, records[i].DueDate, got[i].DueDate)
+			}
+		})
+	}
+}
+
+func TestReadIssueRecords_CSV(t *testing.T) {
+	got, err := ReadIssueRecords(strings.NewReader("Title,Labels,Owner\nFirst,\"bug, ,ui\",alice\nSecond\n"), IssueExchangeFormatCSV)
+	require.NoError(t, err)
+	want := []*IssueRecord{
+		{Title: "First", Labels: []string{"bug", "ui"}, Assignees: []string{}},
+		{Title: "Second", Labels: []string{}, Assignees: []string{}},
+	}
+	assert.Equal(t, want, got)
+
+	_, err = ReadIssueRecords(strings.NewReader("name,body\nFirst,\n"), IssueExchangeFormatCSV)
+	assert.Error(t, err)
+
+	_, err = ReadIssueRecords(strings.NewReader(""), "xlsx")
+	assert.Error(t, err)
+}
@@ -53,10 +53,17 @@ func GetLabelTemplateFile(name string) ([][2]string, error) {
 	return list, nil
 }
 
-// Label represents a label of repository for issues.
+// availableToRepoCond is the condition of labels and milestones that are
+// available to a repository, which are either of the repository itself or of the
+// organization that owns the repository. It takes the repository ID twice.
+const availableToRepoCond = "(repo_id = ? OR (repo_id = 0 AND org_id = (SELECT owner_id FROM `repository` WHERE id = ?)))"
+
+// Label represents a label of repository or organization for issues. Labels of
+// an organization have zero RepoID and are available to all its repositories.
 type Label struct {
 	ID              int64
 	RepoID          int64 `xorm:"INDEX"`
+	OrgID           int64 `xorm:"INDEX"`
 	Name            string
 	Color           string `xorm:"VARCHAR(7)"`
 	NumIssues       int
@@ -73,11 +80,25 @@ func (label *Label) APIFormat() *api.Label {
 	}
 }
 
+// IsOrgLabel returns true if the label belongs to an organization.
+func (label *Label) IsOrgLabel() bool {
+	return label.OrgID > 0
+}
+
 // CalOpenIssues calculates the open issues of label.
 func (label *Label) CalOpenIssues() {
 	label.NumOpenIssues = label.NumIssues - label.NumClosedIssues
 }
 
+// CalOpenIssuesInRepo calculates the open issues of label in given repository,
+// which differs from the total for labels of organizations.
+func (label *Label) CalOpenIssuesInRepo(repoID int64) {
+	count, _ := x.Join("INNER", "issue_label", "issue.id = issue_label.issue_id").
+		Where("issue_label.label_id = ? AND issue.repo_id = ? AND issue.is_closed = ?", label.ID, repoID, false).
+		Count(new(Issue))
+	label.NumOpenIssues = int(count)
+}
+
 // ForegroundColor calculates the text color for labels based
 // on their background color.
 func (l *Label) ForegroundColor() template.CSS {
@@ -180,6 +201,38 @@ func GetLabelOfRepoByName(repoID int64, labelName string) (*Label, error) {
 	return getLabelOfRepoByName(x, repoID, labelName)
 }
 
+// GetLabelOfOrgByID returns a label by ID in given organization.
+func GetLabelOfOrgByID(orgID, labelID int64) (*Label, error) {
+	if orgID <= 0 || labelID <= 0 {
+		return nil, ErrLabelNotExist{args: map[string]any{"orgID": orgID, "labelID": labelID}}
+	}
+
+	l := &Label{
+		ID:    labelID,
+		OrgID: orgID,
+	}
+	has, err := x.Get(l)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrLabelNotExist{args: map[string]any{"orgID": orgID, "labelID": labelID}}
+	}
+	return l, nil
+}
+
+// GetAvailableLabelOfRepoByID returns a label by ID that is available to given
+// repository, which is either of the repository or of its owner organization.
+func GetAvailableLabelOfRepoByID(repoID, labelID int64) (*Label, error) {
+	l := new(Label)
+	has, err := x.Where("id = ?", labelID).And(availableToRepoCond, repoID, repoID).Get(l)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrLabelNotExist{args: map[string]any{"repoID": repoID, "labelID": labelID}}
+	}
+	return l, nil
+}
+
 // GetLabelsInRepoByIDs returns a list of labels by IDs in given repository,
 // it silently ignores label IDs that are not belong to the repository.
 func GetLabelsInRepoByIDs(repoID int64, labelIDs []int64) ([]*Label, error) {
@@ -187,12 +240,33 @@ func GetLabelsInRepoByIDs(repoID int64, labelIDs []int64) ([]*Label, error) {
 	return labels, x.Where("repo_id = ?", repoID).In("id", tool.Int64sToStrings(labelIDs)).Asc("name").Find(&labels)
 }
 
+// GetAvailableLabelsInRepoByIDs returns a list of labels by IDs that are
+// available to given repository, it silently ignores label IDs that are not.
+func GetAvailableLabelsInRepoByIDs(repoID int64, labelIDs []int64) ([]*Label, error) {
+	labels := make([]*Label, 0, len(labelIDs))
+	return labels, x.Where(availableToRepoCond, repoID, repoID).In("id", tool.Int64sToStrings(labelIDs)).Asc("name").Find(&labels)
+}
+
 // GetLabelsByRepoID returns all labels that belong to given repository by ID.
 func GetLabelsByRepoID(repoID int64) ([]*Label, error) {
 	labels := make([]*Label, 0, 10)
 	return labels, x.Where("repo_id = ?", repoID).Asc("name").Find(&labels)
 }
 
+// GetAvailableLabelsByRepoID returns all labels that are available to given
+// repository by ID, labels of the repository come before labels of its owner
+// organization.
+func GetAvailableLabelsByRepoID(repoID int64) ([]*Label, error) {
+	labels := make([]*Label, 0, 10)
+	return labels, x.Where(availableToRepoCond, repoID, repoID).Desc("repo_id").Asc("name").Find(&labels)
+}
+
+// GetLabelsByOrgID returns all labels that belong to given organization by ID.
+func GetLabelsByOrgID(orgID int64) ([]*Label, error) {
+	labels := make([]*Label, 0, 10)
+	return labels, x.Where("repo_id = 0 AND org_id = ?", orgID).Asc("name").Find(&labels)
+}
+
 func getLabelsByIssueID(e Engine, issueID int64) ([]*Label, error) {
 	issueLabels, err := getIssueLabels(e, issueID)
 	if err != nil {
@@ -234,7 +308,23 @@ func DeleteLabel(repoID, labelID int64) error {
 		}
 		return err
 	}
+	return deleteLabel(labelID)
+}
+
+// DeleteOrgLabel deletes a label of given organization, which is also removed
+// from issues of all repositories of the organization.
+func DeleteOrgLabel(orgID, labelID int64) error {
+	_, err := GetLabelOfOrgByID(orgID, labelID)
+	if err != nil {
+		if IsErrLabelNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return deleteLabel(labelID)
+}
 
+func deleteLabel(labelID int64) (err error) {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
@@ -7,9 +7,11 @@ package database
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
-	"github.com/unknwon/com"
 	log "unknwon.dev/clog/v2"
 
 	"gogs.io/gogs/internal/conf"
@@ -39,6 +41,10 @@ func (this mailerUser) Email() string {
 	return this.user.Email
 }
 
+func (this mailerUser) Rands() string {
+	return this.user.Rands
+}
+
 func (this mailerUser) GenerateEmailActivateCode(email string) string {
 	return userutil.GenerateActivateCode(
 		this.user.ID,
@@ -79,6 +85,10 @@ type mailerIssue struct {
 	issue *Issue
 }
 
+func (this mailerIssue) ID() int64 {
+	return this.issue.ID
+}
+
 func (this mailerIssue) MailSubject() string {
 	return this.issue.MailSubject()
 }
@@ -91,38 +101,53 @@ func (this mailerIssue) HTMLURL() string {
 	return this.issue.HTMLURL()
 }
 
+func (this mailerIssue) Deadline() time.Time {
+	return this.issue.Deadline
+}
+
+func (this mailerIssue) IsOverdue() bool {
+	return this.issue.IsOverdue()
+}
+
 func NewMailerIssue(issue *Issue) email.Issue {
 	return mailerIssue{issue}
 }
 
-// mailIssueCommentToParticipants can be used for both new issue creation and comment.
-// This functions sends two list of emails:
-// 1. Repository watchers, users who participated in comments and the assignee.
-// 2. Users who are not in 1. but get mentioned in current issue/comment.
-func mailIssueCommentToParticipants(issue *Issue, doer *User, mentions []string) error {
+type issueRecipient struct {
+	user   *User
+	reason NotificationReason
+}
+
+// getIssueRecipients returns users to be notified of the activity of the doer
+// on the issue, which are:
+// 1. Repository watchers, users who participated in comments, the poster and
+// assignees.
+// 2. Users who get mentioned in the current issue/comment, including members of
+// mentioned organizations and teams.
+//
+// Users who have unsubscribed from the issue are excluded unless mentioned, and
+// so are users who cannot access the private repository.
+func getIssueRecipients(issue *Issue, doer *User, mentions, teamMentions []string) (map[int64]*issueRecipient, error) {
 	ctx := context.TODO()
 
-	if !conf.User.EnableEmailNotification {
-		return nil
+	recipients := make(map[int64]*issueRecipient)
+	add := func(u *User, reason NotificationReason) {
+		if u == nil || u.ID == doer.ID || u.IsOrganization() || !u.IsActive {
+			return
+		}
+		if r, ok := recipients[u.ID]; ok {
+			if reason > r.reason {
+				r.reason = reason
+			}
+			return
+		}
+		recipients[u.ID] = &issueRecipient{user: u, reason: reason}
 	}
 
 	watchers, err := GetWatchers(issue.RepoID)
 	if err != nil {
-		return fmt.Errorf("GetWatchers [repo_id: %d]: %v", issue.RepoID, err)
-	}
-	participants, err := GetParticipantsByIssueID(issue.ID)
-	if err != nil {
-		return fmt.Errorf("GetParticipantsByIssueID [issue_id: %d]: %v", issue.ID, err)
+		return nil, fmt.Errorf("GetWatchers [repo_id: %d]: %v", issue.RepoID, err)
 	}
-
-	// In case the issue poster is not watching the repository,
-	// even if we have duplicated in watchers, can be safely filtered out.
-	if issue.PosterID != doer.ID {
-		participants = append(participants, issue.Poster)
-	}
-
-	tos := make([]string, 0, len(watchers)) // List of email addresses
-	names := make([]string, 0, len(watchers))
 	for i := range watchers {
 		if watchers[i].UserID == doer.ID {
 			continue
@@ -130,63 +155,498 @@ func mailIssueCommentToParticipants(issue *Issue, doer *User, mentions []string)
 
 		to, err := Handle.Users().GetByID(ctx, watchers[i].UserID)
 		if err != nil {
-			return fmt.Errorf("GetUserByID [%d]: %v", watchers[i].UserID, err)
-		}
-		if to.IsOrganization() || !to.IsActive {
-			continue
+			return nil, fmt.Errorf("GetUserByID [%d]: %v", watchers[i].UserID, err)
 		}
+		add(to, NotificationReasonWatching)
+	}
 
-		tos = append(tos, to.Email)
-		names = append(names, to.Name)
+	participants, err := GetParticipantsByIssueID(issue.ID)
+	if err != nil {
+		return nil, fmt.Errorf("GetParticipantsByIssueID [issue_id: %d]: %v", issue.ID, err)
 	}
 	for i := range participants {
-		if participants[i].ID == doer.ID {
-			continue
-		} else if com.IsSliceContainsStr(names, participants[i].Name) {
+		add(participants[i], NotificationReasonParticipating)
+	}
+	// In case the issue poster is not watching the repository
+	add(issue.Poster, NotificationReasonParticipating)
+	for _, assignee := range issue.Assignees {
+		add(assignee, NotificationReasonAssigned)
+	}
+
+	usernames := make([]string, len(mentions))
+	for i := range mentions {
+		usernames[i] = strings.ToLower(mentions[i])
+	}
+	mentioned, err := Handle.Users().GetMailableByUsernames(ctx, usernames)
+	if err != nil {
+		return nil, errors.Wrap(err, "get mailable users by usernames")
+	}
+	for _, u := range mentioned {
+		if !u.IsOrganization() {
+			add(u, NotificationReasonMention)
 			continue
 		}
 
-		tos = append(tos, participants[i].Email)
-		names = append(names, participants[i].Name)
-	}
-	if issue.Assignee != nil && issue.Assignee.ID != doer.ID {
-		if !com.IsSliceContainsStr(names, issue.Assignee.Name) {
-			tos = append(tos, issue.Assignee.Email)
-			names = append(names, issue.Assignee.Name)
+		if err = u.GetMembers(0); err != nil {
+			return nil, fmt.Errorf("GetMembers [org_id: %d]: %v", u.ID, err)
+		}
+		for _, member := range u.Members {
+			add(member, NotificationReasonMention)
 		}
 	}
-	email.SendIssueCommentMail(NewMailerIssue(issue), NewMailerRepo(issue.Repo), NewMailerUser(doer), tos)
 
-	// Mail mentioned people and exclude watchers.
-	names = append(names, doer.Name)
-	toUsernames := make([]string, 0, len(mentions)) // list of user names.
-	for i := range mentions {
-		if com.IsSliceContainsStr(names, mentions[i]) {
+	teamMembers, err := getMentionedTeamMembers(x, teamMentions)
+	if err != nil {
+		return nil, fmt.Errorf("getMentionedTeamMembers: %v", err)
+	}
+	for _, member := range teamMembers {
+		add(member, NotificationReasonMention)
+	}
+
+	unsubscribers, err := getIssueUnsubscribers(x, issue.ID)
+	if err != nil {
+		return nil, fmt.Errorf("getIssueUnsubscribers [issue_id: %d]: %v", issue.ID, err)
+	}
+	for id, r := range recipients {
+		if unsubscribers[id] && r.reason != NotificationReasonMention {
+			delete(recipients, id)
 			continue
 		}
 
-		toUsernames = append(toUsernames, mentions[i])
+		if !canReadIssues(ctx, Handle.Permissions(), r.user, issue.Repo) {
+			delete(recipients, id)
+		}
 	}
+	return recipients, nil
+}
 
-	tos, err = Handle.Users().GetMailableEmailsByUsernames(ctx, toUsernames)
+// notifyIssueParticipants creates notifications of the activity of the doer on
+// the issue, with commentID being zero for the issue itself, and emails
+// recipients as well as the doer when email notifications are enabled. Emails
+// are sent immediately, queued for digests or skipped according to the
+// notification preference of each user for the category of the activity.
+// Mentioned users receive the mention email instead of the comment email.
+func notifyIssueParticipants(e Engine, issue *Issue, doer *User, mentions, teamMentions []string, commentID int64) error {
+	recipients, err := getIssueRecipients(issue, doer, mentions, teamMentions)
 	if err != nil {
-		return errors.Wrap(err, "get mailable emails by usernames")
+		return fmt.Errorf("getIssueRecipients [issue_id: %d]: %v", issue.ID, err)
 	}
-	email.SendIssueMentionMail(NewMailerIssue(issue), NewMailerRepo(issue.Repo), NewMailerUser(doer), tos)
+
+	reasons := make(map[int64]NotificationReason, len(recipients))
+	for id, r := range recipients {
+		reasons[id] = r.reason
+	}
+	err = createNotifications(e, notifyOptions{
+		RepoID:      issue.RepoID,
+		IssueID:     issue.ID,
+		CommentID:   commentID,
+		UpdatedByID: doer.ID,
+		Recipients:  reasons,
+	})
+	if err != nil {
+		return fmt.Errorf("createNotifications [issue_id: %d]: %v", issue.ID, err)
+	}
+
+	if !conf.User.EnableEmailNotification {
+		return nil
+	}
+
+	userIDs := make([]int64, 0, len(recipients)+1)
+	for id := range recipients {
+		userIDs = append(userIDs, id)
+	}
+	userIDs = append(userIDs, doer.ID)
+	prefs, err := getNotificationPreferences(e, userIDs)
+	if err != nil {
+		return fmt.Errorf("getNotificationPreferences: %v", err)
+	}
+
+	now := time.Now().Unix()
+	tos := make([]email.User, 0, len(recipients))
+	mentionTos := make([]email.User, 0, len(mentions))
+	deliver := func(u *User, category NotificationCategory) error {
+		delivery := prefs[u.ID].Delivery(category)
+		switch {
+		case delivery == EmailDeliveryImmediate && category == NotificationCategoryMention:
+			mentionTos = append(mentionTos, NewMailerUser(u))
+		case delivery == EmailDeliveryImmediate:
+			tos = append(tos, NewMailerUser(u))
+		case delivery.IsDigest():
+			_, err := e.Insert(&EmailDigestItem{
+				UserID:      u.ID,
+				Delivery:    delivery,
+				IssueID:     issue.ID,
+				CommentID:   commentID,
+				DoerID:      doer.ID,
+				Category:    category,
+				Content:     issue.Content,
+				CreatedUnix: now,
+			})
+			if err != nil {
+				return fmt.Errorf("insert email digest item: %v", err)
+			}
+		}
+		return nil
+	}
+	for _, r := range recipients {
+		if err = deliver(r.user, notificationCategoryOf(r.reason)); err != nil {
+			return err
+		}
+	}
+	if doer.IsActive {
+		if err = deliver(doer, NotificationCategoryOwnActivity); err != nil {
+			return err
+		}
+	}
+
+	email.SendIssueCommentMail(NewMailerIssue(issue), NewMailerRepo(issue.Repo), NewMailerUser(doer), tos)
+	email.SendIssueMentionMail(NewMailerIssue(issue), NewMailerRepo(issue.Repo), NewMailerUser(doer), mentionTos)
 	return nil
 }
 
-// MailParticipants sends new issue thread created emails to repository watchers
-// and mentioned people.
+// MailParticipants notifies repository watchers, participants and mentioned
+// people of the new issue thread, by notifications and emails.
 func (issue *Issue) MailParticipants() (err error) {
 	mentions := markup.FindAllMentions(issue.Content)
-	if err = updateIssueMentions(x, issue.ID, mentions); err != nil {
+	teamMentions := markup.FindAllTeamMentions(issue.Content)
+	if err = updateIssueMentions(x, issue, mentions, teamMentions); err != nil {
 		return fmt.Errorf("UpdateIssueMentions [%d]: %v", issue.ID, err)
 	}
 
-	if err = mailIssueCommentToParticipants(issue, issue.Poster, mentions); err != nil {
-		log.Error("mailIssueCommentToParticipants: %v", err)
+	if err = notifyIssueParticipants(x, issue, issue.Poster, mentions, teamMentions, 0); err != nil {
+		log.Error("notifyIssueParticipants: %v", err)
 	}
 
 	return nil
 }
+
+// getDueIssues returns open issues with a deadline no later than dueBefore,
+// ordered by the deadline.
+func getDueIssues(e Engine, dueBefore int64) ([]*Issue, error) {
+	issues := make([]*Issue, 0, 10)
+	return issues, e.Where("is_closed = ?", false).
+		And("deadline_unix > 0").
+		And("deadline_unix <= ?", dueBefore).
+		Asc("deadline_unix").
+		Find(&issues)
+}
+
+// RemindDueIssues sends a reminder email to each assignee of open issues that
+// are due within the configured duration or overdue.
+func RemindDueIssues() {
+	if taskStatusTable.IsRunning(_REMIND_DUE_ISSUES) {
+		return
+	}
+	taskStatusTable.Start(_REMIND_DUE_ISSUES)
+	defer taskStatusTable.Stop(_REMIND_DUE_ISSUES)
+
+	if !conf.User.EnableEmailNotification {
+		return
+	}
+
+	log.Trace("Doing: RemindDueIssues")
+
+	issues, err := getDueIssues(x, time.Now().Add(conf.Cron.IssueDueReminder.DueWithin).Unix())
+	if err != nil {
+		log.Error("RemindDueIssues: find issues: %v", err)
+		return
+	}
+
+	assignees := make(map[int64]*User)
+	dueIssues := make(map[int64][]email.DueIssue)
+	for _, issue := range issues {
+		if err = issue.LoadAttributes(); err != nil {
+			log.Error("RemindDueIssues: load attributes [issue_id: %d]: %v", issue.ID, err)
+			continue
+		}
+
+		for _, assignee := range issue.Assignees {
+			if !assignee.IsActive || assignee.IsOrganization() {
+				continue
+			}
+			assignees[assignee.ID] = assignee
+			dueIssues[assignee.ID] = append(dueIssues[assignee.ID], mailerIssue{issue})
+		}
+	}
+
+	for id, issues := range dueIssues {
+		email.SendIssueDueMail(NewMailerUser(assignees[id]), issues)
+	}
+}
+
+// SendEmailDigests sends queued activities to users in hourly and daily email
+// digests. A digest is sent once its earliest activity has been queued for the
+// period, so that each user receives at most one digest per period.
+func SendEmailDigests() {
+	if taskStatusTable.IsRunning(_EMAIL_DIGESTS) {
+		return
+	}
+	taskStatusTable.Start(_EMAIL_DIGESTS)
+	defer taskStatusTable.Stop(_EMAIL_DIGESTS)
+
+	log.Trace("Doing: SendEmailDigests")
+
+	ctx := context.TODO()
+	for _, digest := range []struct {
+		delivery EmailDelivery
+		period   time.Duration
+	}{
+		{EmailDeliveryHourly, time.Hour},
+		{EmailDeliveryDaily, 24 * time.Hour},
+	} {
+		var userIDs []int64
+		err := Handle.db.WithContext(ctx).
+			Model(&EmailDigestItem{}).
+			Where("delivery = ?", digest.delivery).
+			Group("user_id").
+			Having("MIN(created_unix) <= ?", time.Now().Add(-digest.period).Unix()).
+			Pluck("user_id", &userIDs).
+			Error
+		if err != nil {
+			log.Error("SendEmailDigests: find users of %s digests: %v", digest.delivery, err)
+			continue
+		}
+
+		for _, userID := range userIDs {
+			if err = sendEmailDigest(ctx, userID, digest.delivery); err != nil {
+				log.Error("SendEmailDigests: send %s digest [user_id: %d]: %v", digest.delivery, userID, err)
+			}
+		}
+	}
+}
+
+// sendEmailDigest sends all queued activities of the delivery to the user in
+// one email, and removes them from the queue. Activities on issues that the user
+// can no longer access are skipped.
+func sendEmailDigest(ctx context.Context, userID int64, delivery EmailDelivery) error {
+	var items []*EmailDigestItem
+	err := Handle.db.WithContext(ctx).
+		Where("user_id = ? AND delivery = ?", userID, delivery).
+		Order("id ASC").
+		Find(&items).
+		Error
+	if err != nil {
+		return errors.Wrap(err, "list items")
+	} else if len(items) == 0 {
+		return nil
+	}
+
+	// Remove items before sending, so that a failure in between never results in
+	// the same activity being sent again.
+	itemIDs := make([]int64, len(items))
+	for i := range items {
+		itemIDs[i] = items[i].ID
+	}
+	err = Handle.db.WithContext(ctx).Where("id IN (?)", itemIDs).Delete(&EmailDigestItem{}).Error
+	if err != nil {
+		return errors.Wrap(err, "delete items")
+	}
+
+	user, err := Handle.Users().GetByID(ctx, userID)
+	if err != nil {
+		if IsErrUserNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, "get user")
+	} else if !user.IsActive {
+		return nil
+	}
+
+	// Activities are grouped by issues in the order they are first queued, with
+	// nil values for issues that are skipped.
+	issues := make(map[int64]*Issue)
+	digestIssues := make(map[int64]*email.DigestIssue)
+	var digest []*email.DigestIssue
+	doers := make(map[int64]*User)
+	for _, item := range items {
+		issue, ok := issues[item.IssueID]
+		if !ok {
+			issue, err = GetIssueByID(item.IssueID)
+			if err != nil && !IsErrIssueNotExist(err) {
+				return errors.Wrap(err, "get issue")
+			}
+			if issue != nil && issue.Repo.IsPrivate && !user.IsAdmin &&
+				!Handle.Permissions().Authorize(ctx, user.ID, issue.RepoID, AccessModeRead,
+					AccessModeOptions{
+						OwnerID: issue.Repo.OwnerID,
+						Private: issue.Repo.IsPrivate,
+					},
+				) {
+				issue = nil
+			}
+			issues[item.IssueID] = issue
+
+			if issue != nil {
+				digestIssues[issue.ID] = &email.DigestIssue{
+					Subject: issue.MailSubject(),
+					HTMLURL: issue.HTMLURL(),
+				}
+				digest = append(digest, digestIssues[issue.ID])
+			}
+		}
+		if issue == nil {
+			continue
+		}
+
+		doer, ok := doers[item.DoerID]
+		if !ok {
+			doer, err = Handle.Users().GetByID(ctx, item.DoerID)
+			if err != nil {
+				if !IsErrUserNotExist(err) {
+					return errors.Wrap(err, "get doer")
+				}
+				doer = NewGhostUser()
+			}
+			doers[item.DoerID] = doer
+		}
+
+		activity := &email.DigestActivity{
+			Doer:    doer.DisplayName(),
+			Body:    string(markup.Markdown([]byte(item.Content), issue.Repo.HTMLURL(), issue.Repo.ComposeMetas())),
+			HTMLURL: issue.HTMLURL(),
+			Created: time.Unix(item.CreatedUnix, 0),
+		}
+		if item.CommentID > 0 {
+			activity.HTMLURL = fmt.Sprintf("%s#issuecomment-%d", activity.HTMLURL, item.CommentID)
+		}
+		digestIssue := digestIssues[issue.ID]
+		digestIssue.Activities = append(digestIssue.Activities, activity)
+	}
+
+	// All activities are on issues that no longer exist or are no longer
+	// accessible to the user.
+	if len(digest) == 0 {
+		return nil
+	}
+
+	email.SendIssueDigestMail(NewMailerUser(user), string(delivery), digest)
+	return nil
+}
+
+// ProcessIncomingEmails fetches unread emails from the mailbox for incoming
+// emails and handles them.
+func ProcessIncomingEmails() {
+	if taskStatusTable.IsRunning(_INCOMING_EMAILS) {
+		return
+	}
+	taskStatusTable.Start(_INCOMING_EMAILS)
+	defer taskStatusTable.Stop(_INCOMING_EMAILS)
+
+	log.Trace("Doing: ProcessIncomingEmails")
+
+	mailbox, err := email.NewMailbox()
+	if err != nil {
+		log.Error("ProcessIncomingEmails: new mailbox: %v", err)
+		return
+	}
+
+	err = mailbox.Fetch(func(r io.Reader) error {
+		in, err := email.ParseIncomingMessage(r)
+		if err != nil {
+			log.Warn("ProcessIncomingEmails: parse message: %v", err)
+			return nil
+		}
+		return HandleIncomingEmail(in)
+	})
+	if err != nil {
+		log.Error("ProcessIncomingEmails: %v", err)
+	}
+}
+
+// HandleIncomingEmail posts the reply as a comment on the issue on behalf of
+// the user, or unsubscribes the user from the issue, as identified by the token
+// in the address that the email is sent to. Emails that cannot be handled are
+// ignored, and the returned error is only for unexpected failures.
+func HandleIncomingEmail(in *email.IncomingMessage) error {
+	if in.IsAutoReply {
+		log.Trace("Ignored automatic reply from %q", in.From)
+		return nil
+	}
+
+	var token *email.Token
+	for _, to := range in.Recipients {
+		s := email.TokenFromAddress(to)
+		if s == "" {
+			continue
+		}
+
+		var err error
+		token, err = email.ParseToken(s)
+		if err != nil {
+			log.Warn("Ignored incoming email from %q with invalid token: %v", in.From, err)
+			return nil
+		}
+		break
+	}
+	if token == nil {
+		log.Trace("Ignored incoming email from %q without a token", in.From)
+		return nil
+	}
+
+	ctx := context.TODO()
+	user, err := Handle.Users().GetByID(ctx, token.UserID)
+	if err != nil {
+		if IsErrUserNotExist(err) {
+			log.Warn("Ignored incoming email from %q of non-existent user %d", in.From, token.UserID)
+			return nil
+		}
+		return errors.Wrap(err, "get user")
+	} else if !token.Verify(user.Rands) {
+		log.Warn("Ignored incoming email from %q with invalid token signature", in.From)
+		return nil
+	} else if !user.IsActive || user.ProhibitLogin {
+		log.Warn("Ignored incoming email from %q of inactive or prohibited user %q", in.From, user.Name)
+		return nil
+	}
+
+	issue, err := GetIssueByID(token.IssueID)
+	if err != nil {
+		if IsErrIssueNotExist(err) {
+			log.Warn("Ignored incoming email from %q for non-existent issue %d", in.From, token.IssueID)
+			return nil
+		}
+		return errors.Wrap(err, "get issue")
+	}
+
+	if token.Action == email.TokenActionUnsubscribe {
+		if err = UnsubscribeIssue(user.ID, issue); err != nil {
+			return errors.Wrap(err, "unsubscribe issue")
+		}
+		log.Trace("User %q unsubscribed from issue %d by email", user.Name, issue.ID)
+		return nil
+	}
+
+	// The user may have lost access since the notification was sent.
+	repo := issue.Repo
+	mode := Handle.Permissions().AccessMode(ctx, user.ID, repo.ID,
+		AccessModeOptions{
+			OwnerID: repo.OwnerID,
+			Private: repo.IsPrivate,
+		},
+	)
+	if user.IsAdmin {
+		mode = AccessModeOwner
+	}
+	if mode < AccessModeRead ||
+		(!issue.IsPull && !repo.EnableIssues) ||
+		(issue.IsPull && !repo.AllowsPulls()) {
+		log.Warn("Ignored reply by email from user %q without access to issue %d", user.Name, issue.ID)
+		return nil
+	} else if issue.IsLocked && mode < AccessModeWrite {
+		log.Warn("Ignored reply by email from user %q to locked issue %d", user.Name, issue.ID)
+		return nil
+	}
+
+	content := email.StripReply(in.Body)
+	if content == "" {
+		log.Trace("Ignored empty reply by email from user %q to issue %d", user.Name, issue.ID)
+		return nil
+	}
+
+	comment, err := CreateIssueComment(user, repo, issue, content, nil)
+	if err != nil {
+		return errors.Wrap(err, "create issue comment")
+	}
+	log.Trace("Comment created by email [issue_id: %d, comment_id: %d]", issue.ID, comment.ID)
+	return nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDueIssues(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	e := newTestLegacyEngine(t, new(Issue))
+	const dueBefore = 1000
+	for i, issue := range []*Issue{
+		{Title: "due later", DeadlineUnix: dueBefore + 1},
+		{Title: "due", DeadlineUnix: dueBefore},
+		{Title: "no deadline"},
+		{Title: "closed", DeadlineUnix: 500, IsClosed: true},
+		{Title: "overdue", DeadlineUnix: 500},
+	} {
+		issue.RepoID = 1
+		issue.Index = int64(i + 1)
+		_, err := e.Insert(issue)
+		require.NoError(t, err)
+	}
+
+	issues, err := getDueIssues(e, dueBefore)
+	require.NoError(t, err)
+
+	got := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		got = append(got, issue.Title)
+	}
+	assert.Equal(t, []string{"overdue", "due"}, got)
+}
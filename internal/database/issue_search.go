@@ -0,0 +1,478 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"xorm.io/xorm"
+
+	"gogs.io/gogs/internal/conf"
+	dberrors "gogs.io/gogs/internal/database/errors"
+)
+
+// issueQueryTerm is a term of an issue search query. It is a keyword when the
+// key is empty, or a "key:value" qualifier otherwise. A qualifier with a
+// leading "-" is negated.
+type issueQueryTerm struct {
+	Key     string
+	Value   string
+	Negated bool
+	Raw     string
+}
+
+// issueQueryKeys is the set of supported qualifier keys, terms with other keys
+// are treated as keywords.
+var issueQueryKeys = map[string]bool{
+	"is":        true,
+	"state":     true,
+	"type":      true,
+	"label":     true,
+	"no":        true,
+	"author":    true,
+	"assignee":  true,
+	"mentions":  true,
+	"milestone": true,
+	"created":   true,
+	"updated":   true,
+	"sort":      true,
+	"repo":      true,
+}
+
+// splitIssueQuery splits the query by whitespace, except for those enclosed by
+// double quotes. Quotes are removed from the returned fields.
+func splitIssueQuery(q string) []string {
+	var (
+		fields  []string
+		field   strings.Builder
+		inQuote bool
+	)
+	flush := func() {
+		if field.Len() > 0 {
+			fields = append(fields, field.String())
+			field.Reset()
+		}
+	}
+	for _, r := range q {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case unicode.IsSpace(r) && !inQuote:
+			flush()
+		default:
+			field.WriteRune(r)
+		}
+	}
+	flush()
+	return fields
+}
+
+func parseIssueQueryTerms(q string) []issueQueryTerm {
+	fields := splitIssueQuery(q)
+	terms := make([]issueQueryTerm, 0, len(fields))
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, ":")
+		negated := strings.HasPrefix(key, "-")
+		key = strings.ToLower(strings.TrimPrefix(key, "-"))
+		if !ok || value == "" || !issueQueryKeys[key] {
+			terms = append(terms, issueQueryTerm{Value: field, Raw: field})
+			continue
+		}
+		terms = append(terms, issueQueryTerm{Key: key, Value: value, Negated: negated, Raw: field})
+	}
+	return terms
+}
+
+// parseIssueQueryDate parses a date in the form of "2006-01-02" in the local
+// time zone.
+func parseIssueQueryDate(s string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", s, time.Local)
+}
+
+// parseIssueQueryDateRange parses the value of "created" and "updated"
+// qualifiers into the half-open interval [since, before) of Unix timestamps,
+// where zero means unbounded. Supported forms are "2006-01-02", ">2006-01-02",
+// ">=2006-01-02", "<2006-01-02", "<=2006-01-02" and "2006-01-02..2006-01-31"
+// with "*" for an open end.
+func parseIssueQueryDateRange(s string) (since, before int64, err error) {
+	nextDay := func(t time.Time) int64 {
+		return t.AddDate(0, 0, 1).Unix()
+	}
+
+	var t time.Time
+	switch {
+	case strings.HasPrefix(s, ">="):
+		t, err = parseIssueQueryDate(s[2:])
+		return t.Unix(), 0, err
+	case strings.HasPrefix(s, ">"):
+		t, err = parseIssueQueryDate(s[1:])
+		return nextDay(t), 0, err
+	case strings.HasPrefix(s, "<="):
+		t, err = parseIssueQueryDate(s[2:])
+		return 0, nextDay(t), err
+	case strings.HasPrefix(s, "<"):
+		t, err = parseIssueQueryDate(s[1:])
+		return 0, t.Unix(), err
+	}
+
+	if from, to, ok := strings.Cut(s, ".."); ok {
+		if from != "*" {
+			if t, err = parseIssueQueryDate(from); err != nil {
+				return 0, 0, err
+			}
+			since = t.Unix()
+		}
+		if to != "*" {
+			if t, err = parseIssueQueryDate(to); err != nil {
+				return 0, 0, err
+			}
+			before = nextDay(t)
+		}
+		return since, before, nil
+	}
+
+	t, err = parseIssueQueryDate(s)
+	return t.Unix(), nextDay(t), err
+}
+
+// issueQuerySortTypes maps values of the "sort" qualifier to sort types of
+// IssuesOptions.
+var issueQuerySortTypes = map[string]string{
+	"created":        "latest",
+	"created-desc":   "latest",
+	"created-asc":    "oldest",
+	"updated":        "recentupdate",
+	"updated-desc":   "recentupdate",
+	"updated-asc":    "leastupdate",
+	"comments":       "mostcomment",
+	"comments-desc":  "mostcomment",
+	"comments-asc":   "leastcomment",
+	"reactions":      "mostreaction",
+	"reactions-desc": "mostreaction",
+	"reactions-asc":  "leastreaction",
+	"due":            "nearduedate",
+	"due-asc":        "nearduedate",
+	"due-desc":       "farduedate",
+	"priority":       "priority",
+}
+
+// IssueQuery is a parsed issue search query, e.g.
+//
+//	is:open label:bug -label:wontfix author:alice assignee:@me milestone:"v1.2" created:>2024-01-01 sort:updated
+//
+// Keywords are matched against titles, contents and comments of issues. Users
+// that do not exist are recorded with the ID -1 so that nothing matches them.
+type IssueQuery struct {
+	Keywords []string
+
+	IsClosed *bool
+	IsPull   *bool
+	IsLocked *bool
+	IsPinned *bool
+	IsMerged *bool
+
+	Labels              []string
+	ExcludedLabels      []string
+	NoLabel             bool
+	Milestones          []string
+	ExcludedMilestones  []string
+	NoMilestone         bool
+	AuthorIDs           []int64
+	ExcludedAuthorIDs   []int64
+	AssigneeIDs         []int64
+	ExcludedAssigneeIDs []int64
+	NoAssignee          bool
+	MentionedIDs        []int64
+	RepoIDs             []int64
+
+	// Half-open intervals of Unix timestamps, zero means unbounded.
+	CreatedSince  int64
+	CreatedBefore int64
+	UpdatedSince  int64
+	UpdatedBefore int64
+
+	SortType string
+}
+
+// ParseIssueQuery parses the issue search query and resolves usernames and
+// repository names in qualifiers. The doer is the user "@me" refers to, which
+// can be nil. Qualifiers with invalid values are treated as keywords.
+func ParseIssueQuery(q string, doer *User) (*IssueQuery, error) {
+	query := &IssueQuery{}
+	setBool := func(b **bool, v bool) {
+		*b = &v
+	}
+	for _, term := range parseIssueQueryTerms(q) {
+		value := strings.ToLower(term.Value)
+		valid := true
+		switch term.Key {
+		case "":
+			query.Keywords = append(query.Keywords, term.Value)
+			continue
+
+		case "is", "state", "type":
+			switch value {
+			case "open":
+				setBool(&query.IsClosed, term.Negated)
+			case "closed":
+				setBool(&query.IsClosed, !term.Negated)
+			case "issue":
+				setBool(&query.IsPull, term.Negated)
+			case "pr", "pull":
+				setBool(&query.IsPull, !term.Negated)
+			case "locked":
+				setBool(&query.IsLocked, !term.Negated)
+			case "unlocked":
+				setBool(&query.IsLocked, term.Negated)
+			case "pinned":
+				setBool(&query.IsPinned, !term.Negated)
+			case "merged":
+				setBool(&query.IsMerged, !term.Negated)
+				if !term.Negated {
+					setBool(&query.IsPull, true)
+				}
+			default:
+				valid = false
+			}
+
+		case "no":
+			switch value {
+			case "label":
+				query.NoLabel = true
+			case "milestone":
+				query.NoMilestone = true
+			case "assignee":
+				query.NoAssignee = true
+			default:
+				valid = false
+			}
+
+		case "label":
+			if term.Negated {
+				query.ExcludedLabels = append(query.ExcludedLabels, value)
+			} else {
+				query.Labels = append(query.Labels, value)
+			}
+
+		case "milestone":
+			if term.Negated {
+				query.ExcludedMilestones = append(query.ExcludedMilestones, value)
+			} else {
+				query.Milestones = append(query.Milestones, value)
+			}
+
+		case "author", "assignee", "mentions":
+			userID, err := resolveIssueQueryUser(value, doer)
+			if err != nil {
+				return nil, err
+			}
+
+			switch {
+			case term.Negated && userID == -1:
+				// Nothing to exclude
+			case term.Key == "author" && term.Negated:
+				query.ExcludedAuthorIDs = append(query.ExcludedAuthorIDs, userID)
+			case term.Key == "author":
+				query.AuthorIDs = append(query.AuthorIDs, userID)
+			case term.Key == "assignee" && term.Negated:
+				query.ExcludedAssigneeIDs = append(query.ExcludedAssigneeIDs, userID)
+			case term.Key == "assignee":
+				query.AssigneeIDs = append(query.AssigneeIDs, userID)
+			case term.Key == "mentions" && !term.Negated:
+				query.MentionedIDs = append(query.MentionedIDs, userID)
+			default:
+				valid = false
+			}
+
+		case "created", "updated":
+			since, before, err := parseIssueQueryDateRange(value)
+			if err != nil {
+				valid = false
+				break
+			}
+			if term.Key == "created" {
+				query.CreatedSince, query.CreatedBefore = since, before
+			} else {
+				query.UpdatedSince, query.UpdatedBefore = since, before
+			}
+
+		case "sort":
+			sortType, ok := issueQuerySortTypes[value]
+			if !ok {
+				valid = false
+				break
+			}
+			query.SortType = sortType
+
+		case "repo":
+			repoID, err := resolveIssueQueryRepo(value)
+			if err != nil {
+				return nil, err
+			}
+			query.RepoIDs = append(query.RepoIDs, repoID)
+		}
+
+		if !valid {
+			query.Keywords = append(query.Keywords, term.Raw)
+		}
+	}
+	return query, nil
+}
+
+// resolveIssueQueryUser returns the ID of the user with the given name, or -1
+// if the user does not exist.
+func resolveIssueQueryUser(name string, doer *User) (int64, error) {
+	if name == "@me" {
+		if doer == nil {
+			return -1, nil
+		}
+		return doer.ID, nil
+	}
+
+	u, err := Handle.Users().GetByUsername(context.TODO(), strings.TrimPrefix(name, "@"))
+	if err != nil {
+		if IsErrUserNotExist(err) {
+			return -1, nil
+		}
+		return 0, fmt.Errorf("get user by name: %v", err)
+	}
+	return u.ID, nil
+}
+
+// resolveIssueQueryRepo returns the ID of the repository with the given full
+// name, or -1 if the repository does not exist.
+func resolveIssueQueryRepo(fullName string) (int64, error) {
+	repo, err := GetRepositoryByRef(fullName)
+	if err != nil {
+		if IsErrUserNotExist(err) || IsErrRepoNotExist(err) || dberrors.IsInvalidRepoReference(err) {
+			return -1, nil
+		}
+		return 0, fmt.Errorf("get repository by reference: %v", err)
+	}
+	return repo.ID, nil
+}
+
+const (
+	issueLabelNameCond = "issue.id IN (SELECT issue_label.issue_id FROM issue_label INNER JOIN label ON label.id = issue_label.label_id WHERE LOWER(label.name) = ?)"
+	issueMilestoneCond = "issue.milestone_id IN (SELECT id FROM milestone WHERE LOWER(name) = ?)"
+	issueMentionedCond = "issue.id IN (SELECT issue_id FROM issue_user WHERE uid = ? AND is_mentioned = ?)"
+	issueMergedCond    = "issue.id IN (SELECT issue_id FROM pull_request WHERE has_merged = ?)"
+	issueKeywordCond   = "(LOWER(issue.name) LIKE ? %[1]s OR LOWER(issue.content) LIKE ? %[1]s OR issue.id IN (SELECT issue_id FROM comment WHERE type = ? AND LOWER(content) LIKE ? %[1]s))"
+)
+
+var likePatternEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLikePattern escapes wildcards in the string to be matched literally by
+// LIKE conditions with the backslash as the escape character.
+func escapeLikePattern(s string) string {
+	return likePatternEscaper.Replace(s)
+}
+
+// likeEscapeClause returns the ESCAPE clause for patterns escaped by
+// escapeLikePattern, MySQL treats the backslash as an escape character in
+// string literals as well.
+func likeEscapeClause() string {
+	if conf.UseMySQL {
+		return `ESCAPE '\\'`
+	}
+	return `ESCAPE '\'`
+}
+
+// buildCond adds conditions of the query to the session, except the state and
+// the type of issues which are handled by buildIssuesQuery.
+func (q *IssueQuery) buildCond(sess *xorm.Session) {
+	keywordCond := fmt.Sprintf(issueKeywordCond, likeEscapeClause())
+	for _, keyword := range q.Keywords {
+		pattern := "%" + escapeLikePattern(strings.ToLower(keyword)) + "%"
+		sess.And(keywordCond, pattern, pattern, COMMENT_TYPE_COMMENT, pattern)
+	}
+
+	if q.IsLocked != nil {
+		sess.And("issue.is_locked = ?", *q.IsLocked)
+	}
+	if q.IsPinned != nil {
+		if *q.IsPinned {
+			sess.And("issue.pin_order > 0")
+		} else {
+			sess.And("issue.pin_order = 0")
+		}
+	}
+	if q.IsMerged != nil {
+		sess.And(issueMergedCond, *q.IsMerged)
+	}
+
+	for _, name := range q.Labels {
+		sess.And(issueLabelNameCond, name)
+	}
+	for _, name := range q.ExcludedLabels {
+		sess.And("NOT "+issueLabelNameCond, name)
+	}
+	if q.NoLabel {
+		sess.And("issue.id NOT IN (SELECT issue_id FROM issue_label)")
+	}
+
+	for _, name := range q.Milestones {
+		sess.And(issueMilestoneCond, name)
+	}
+	for _, name := range q.ExcludedMilestones {
+		sess.And("NOT "+issueMilestoneCond, name)
+	}
+	if q.NoMilestone {
+		sess.And("issue.milestone_id = 0")
+	}
+
+	for _, id := range q.AuthorIDs {
+		sess.And("issue.poster_id = ?", id)
+	}
+	for _, id := range q.ExcludedAuthorIDs {
+		sess.And("issue.poster_id != ?", id)
+	}
+	for _, id := range q.AssigneeIDs {
+		sess.And(issueAssignedCond, id)
+	}
+	for _, id := range q.ExcludedAssigneeIDs {
+		sess.And("NOT "+issueAssignedCond, id)
+	}
+	if q.NoAssignee {
+		sess.And("issue.id NOT IN (SELECT issue_id FROM `issue_assignee`)")
+	}
+	for _, id := range q.MentionedIDs {
+		sess.And(issueMentionedCond, id, true)
+	}
+
+	if len(q.RepoIDs) > 0 {
+		sess.In("issue.repo_id", q.RepoIDs)
+	}
+
+	if q.CreatedSince > 0 {
+		sess.And("issue.created_unix >= ?", q.CreatedSince)
+	}
+	if q.CreatedBefore > 0 {
+		sess.And("issue.created_unix < ?", q.CreatedBefore)
+	}
+	if q.UpdatedSince > 0 {
+		sess.And("issue.updated_unix >= ?", q.UpdatedSince)
+	}
+	if q.UpdatedBefore > 0 {
+		sess.And("issue.updated_unix < ?", q.UpdatedBefore)
+	}
+}
+
+// GetAccessibleRepoIDs returns IDs of repositories the user owns or has access
+// to as a collaborator or a team member.
+func GetAccessibleRepoIDs(userID int64) ([]int64, error) {
+	repoIDs := make([]int64, 0, 10)
+	if err := x.Table("repository").Cols("id").
+		Where("owner_id = ?", userID).
+		Or("id IN (SELECT repo_id FROM `access` WHERE user_id = ? AND mode >= ?)", userID, AccessModeRead).
+		Find(&repoIDs); err != nil {
+		return nil, fmt.Errorf("find repository IDs: %v", err)
+	}
+	return repoIDs, nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIssueQueryTerms(t *testing.T) {
+	got := parseIssueQueryTerms(`is:open  label:bug -label:wontfix milestone:"v1.2 beta" "exact phrase" foo:bar http://example.com -typo`)
+	want := []issueQueryTerm{
+		{Key: "is", Value: "open", Raw: "is:open"},
+		{Key: "label", Value: "bug", Raw: "label:bug"},
+		{Key: "label", Value: "wontfix", Negated: true, Raw: "-label:wontfix"},
+		{Key: "milestone", Value: "v1.2 beta", Raw: "milestone:v1.2 beta"},
+		{Value: "exact phrase", Raw: "exact phrase"},
+		{Value: "foo:bar", Raw: "foo:bar"},
+		{Value: "http://example.com", Raw: "http://example.com"},
+		{Value: "-typo", Raw: "-typo"},
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestParseIssueQueryDateRange(t *testing.T) {
+	day := func(d int) int64 {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.Local).Unix()
+	}
+	for _, tc := range []struct {
+		value      string
+		wantSince  int64
+		wantBefore int64
+	}{
+		{"2024-01-02", day(2), day(3)},
+		{">2024-01-02", day(3), 0},
+		{">=2024-01-02", day(2), 0},
+		{"<2024-01-02", 0, day(2)},
+		{"<=2024-01-02", 0, day(3)},
+		{"2024-01-02..2024-01-05", day(2), day(6)},
+		{"2024-01-02..*", day(2), 0},
+		{"*..2024-01-05", 0, day(6)},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			since, before, err := parseIssueQueryDateRange(tc.value)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantSince, since)
+			assert.Equal(t, tc.wantBefore, before)
+		})
+	}
+
+	_, _, err := parseIssueQueryDateRange(">yesterday")
+	assert.Error(t, err)
+}
+
+func TestParseIssueQuery(t *testing.T) {
+	doer := &User{ID: 7}
+	got, err := ParseIssueQuery(`is:open -is:pr is:locked label:Bug -label:wontfix no:milestone assignee:@me created:>2024-01-01 sort:updated-asc is:unknown fix crash`, doer)
+	require.NoError(t, err)
+
+	no, yes := false, true
+	want := &IssueQuery{
+		Keywords:       []string{"is:unknown", "fix", "crash"},
+		IsClosed:       &no,
+		IsPull:         &no,
+		IsLocked:       &yes,
+		Labels:         []string{"bug"},
+		ExcludedLabels: []string{"wontfix"},
+		NoMilestone:    true,
+		AssigneeIDs:    []int64{7},
+		CreatedSince:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local).Unix(),
+		SortType:       "leastupdate",
+	}
+	assert.Equal(t, want, got)
+
+	got, err = ParseIssueQuery(`author:@me -assignee:@me`, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &IssueQuery{AuthorIDs: []int64{-1}}, got)
+}
+
+func TestIssueQuery_buildCond_Keywords(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	e := newTestLegacyEngine(t, new(Issue), new(Comment))
+	for i, title := range []string{"100% done", "1000 done", "snake_case", "snakescase", `back\slash`} {
+		_, err := e.Insert(&Issue{RepoID: 1, Index: int64(i + 1), Title: title})
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		keyword string
+		want    []string
+	}{
+		{keyword: "100%", want: []string{"100% done"}},
+		{keyword: "e_c", want: []string{"snake_case"}},
+		{keyword: `k\s`, want: []string{`back\slash`}},
+		{keyword: "done", want: []string{"100% done", "1000 done"}},
+	}
+	for _, test := range tests {
+		t.Run(test.keyword, func(t *testing.T) {
+			sess := e.NewSession()
+			defer sess.Close()
+			(&IssueQuery{Keywords: []string{test.keyword}}).buildCond(sess)
+
+			var issues []*Issue
+			require.NoError(t, sess.Asc("id").Find(&issues))
+			got := make([]string, 0, len(issues))
+			for _, issue := range issues {
+				got = append(got, issue.Title)
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
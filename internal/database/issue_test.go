@@ -0,0 +1,184 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+func TestCanReadIssues(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	ctx := context.Background()
+	perms := &PermissionsStore{
+		db: newTestDB(t, "canReadIssues"),
+	}
+	err := perms.SetRepoPerms(ctx, 1, map[int64]AccessMode{2: AccessModeRead})
+	require.NoError(t, err)
+
+	public := &Repository{ID: 1, OwnerID: 1}
+	private := &Repository{ID: 1, OwnerID: 1, IsPrivate: true}
+	tests := []struct {
+		name string
+		user *User
+		repo *Repository
+		want bool
+	}{
+		{name: "public repository", user: &User{ID: 3}, repo: public, want: true},
+		{name: "owner", user: &User{ID: 1}, repo: private, want: true},
+		{name: "collaborator", user: &User{ID: 2}, repo: private, want: true},
+		{name: "admin", user: &User{ID: 3, IsAdmin: true}, repo: private, want: true},
+		{name: "no access", user: &User{ID: 3}, repo: private, want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, canReadIssues(ctx, perms, test.user, test.repo))
+		})
+	}
+}
+
+func newTestIssueEngine(t *testing.T) (*xorm.Engine, *Repository) {
+	e := newTestLegacyEngine(t, new(Issue), new(Comment), new(Repository), new(User), new(Attachment))
+	owner := &User{ID: 1, LowerName: "alice", Name: "alice"}
+	repo := &Repository{
+		ID:        1,
+		OwnerID:   owner.ID,
+		Owner:     owner,
+		LowerName: "repo",
+		Name:      "repo",
+	}
+	_, err := e.Insert(owner, repo)
+	require.NoError(t, err)
+	return e, repo
+}
+
+func listEventTypes(t *testing.T, e *xorm.Engine, issueID int64) []CommentType {
+	var comments []*Comment
+	require.NoError(t, e.Where("issue_id = ?", issueID).Asc("id").Find(&comments))
+	types := make([]CommentType, 0, len(comments))
+	for _, c := range comments {
+		types = append(types, c.Type)
+	}
+	return types
+}
+
+func TestIssue_changeLock(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	e, repo := newTestIssueEngine(t)
+	doer := repo.Owner
+	issue := &Issue{RepoID: repo.ID, Repo: repo, Index: 1, Title: "issue"}
+	_, err := e.Insert(issue)
+	require.NoError(t, err)
+
+	changeLock := func(lock bool, reason string) {
+		sess := e.NewSession()
+		defer sess.Close()
+		require.NoError(t, sess.Begin())
+		require.NoError(t, issue.changeLock(sess, doer, lock, reason))
+		require.NoError(t, sess.Commit())
+	}
+
+	changeLock(true, "spam")
+	changeLock(true, "too heated") // No-op when already locked
+
+	got := new(Issue)
+	_, err = e.ID(issue.ID).Get(got)
+	require.NoError(t, err)
+	assert.True(t, got.IsLocked)
+	assert.Equal(t, "spam", got.LockReason)
+
+	var comment Comment
+	_, err = e.Where("issue_id = ?", issue.ID).Get(&comment)
+	require.NoError(t, err)
+	assert.Equal(t, COMMENT_TYPE_LOCK, comment.Type)
+	assert.Equal(t, "spam", comment.Content)
+
+	changeLock(false, "")
+
+	got = new(Issue)
+	_, err = e.ID(issue.ID).Get(got)
+	require.NoError(t, err)
+	assert.False(t, got.IsLocked)
+	assert.Empty(t, got.LockReason)
+	assert.Equal(t, []CommentType{COMMENT_TYPE_LOCK, COMMENT_TYPE_UNLOCK}, listEventTypes(t, e, issue.ID))
+}
+
+func TestIsValidIssueLockReason(t *testing.T) {
+	for _, reason := range IssueLockReasons {
+		assert.True(t, IsValidIssueLockReason(reason), reason)
+	}
+	assert.True(t, IsValidIssueLockReason(""))
+	assert.False(t, IsValidIssueLockReason("bored"))
+}
+
+func TestIssue_changePin(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	e, repo := newTestIssueEngine(t)
+	doer := repo.Owner
+
+	var issues, pulls []*Issue
+	for i := 1; i <= MaxPinnedIssues+1; i++ {
+		issue := &Issue{RepoID: repo.ID, Repo: repo, Index: int64(2*i - 1), Title: "issue"}
+		pull := &Issue{RepoID: repo.ID, Repo: repo, Index: int64(2 * i), Title: "pull", IsPull: true}
+		_, err := e.Insert(issue, pull)
+		require.NoError(t, err)
+		issues = append(issues, issue)
+		pulls = append(pulls, pull)
+	}
+
+	changePin := func(issue *Issue, pin bool) error {
+		sess := e.NewSession()
+		defer sess.Close()
+		require.NoError(t, sess.Begin())
+		if err := issue.changePin(sess, doer, pin); err != nil {
+			return err
+		}
+		return sess.Commit()
+	}
+
+	for i, issue := range issues[:MaxPinnedIssues] {
+		require.NoError(t, changePin(issue, true))
+		assert.Equal(t, i+1, issue.PinOrder)
+	}
+	require.NoError(t, changePin(issues[0], true)) // No-op when already pinned
+	assert.Equal(t, 1, issues[0].PinOrder)
+
+	err := changePin(issues[MaxPinnedIssues], true)
+	assert.Equal(t, ErrPinnedIssuesLimit{RepoID: repo.ID, IsPull: false}, err)
+	assert.Zero(t, issues[MaxPinnedIssues].PinOrder)
+
+	// Pull requests are counted separately from issues
+	require.NoError(t, changePin(pulls[0], true))
+	assert.Equal(t, 1, pulls[0].PinOrder)
+
+	require.NoError(t, changePin(issues[1], false))
+	assert.Zero(t, issues[1].PinOrder)
+	require.NoError(t, changePin(issues[MaxPinnedIssues], true))
+	assert.Equal(t, MaxPinnedIssues+1, issues[MaxPinnedIssues].PinOrder)
+
+	got := new(Issue)
+	_, err = e.ID(issues[1].ID).Get(got)
+	require.NoError(t, err)
+	assert.Zero(t, got.PinOrder)
+
+	assert.Equal(t, []CommentType{COMMENT_TYPE_PIN, COMMENT_TYPE_UNPIN}, listEventTypes(t, e, issues[1].ID))
+	assert.Equal(t, []CommentType{COMMENT_TYPE_PIN}, listEventTypes(t, e, pulls[0].ID))
+}
@@ -0,0 +1,303 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"xorm.io/xorm"
+
+	dberrors "gogs.io/gogs/internal/database/errors"
+	"gogs.io/gogs/internal/errutil"
+)
+
+// IssueRedirect records the former location of an issue that has been
+// transferred to another repository.
+type IssueRedirect struct {
+	ID      int64 `gorm:"primaryKey"`
+	RepoID  int64 `xorm:"UNIQUE(s)" gorm:"uniqueIndex:issue_redirect_unique;not null"`
+	Index   int64 `xorm:"UNIQUE(s)" gorm:"uniqueIndex:issue_redirect_unique;not null"`
+	IssueID int64 `xorm:"INDEX" gorm:"index;not null"`
+}
+
+// GetIssueByRedirect returns the issue that used to have the index in the
+// repository before being transferred.
+func GetIssueByRedirect(repoID, index int64) (*Issue, error) {
+	redirect := &IssueRedirect{
+		RepoID: repoID,
+		Index:  index,
+	}
+	has, err := x.Get(redirect)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrIssueNotExist{args: map[string]any{"repoID": repoID, "index": index}}
+	}
+	return GetIssueByID(redirect.IssueID)
+}
+
+// GetIssueTransferTarget returns the repository by given full name that the
+// user can transfer issues to, which requires write access and an enabled
+// issue tracker. It returns ErrRepoNotExist otherwise.
+func GetIssueTransferTarget(ctx context.Context, user *User, fullName string) (*Repository, error) {
+	fullName = strings.TrimSpace(fullName)
+	repo, err := GetRepositoryByRef(fullName)
+	if err != nil {
+		if errutil.IsNotFound(err) || dberrors.IsInvalidRepoReference(err) {
+			return nil, ErrRepoNotExist{args: errutil.Args{"fullName": fullName}}
+		}
+		return nil, err
+	}
+
+	if !repo.EnableIssues || repo.EnableExternalTracker ||
+		!Handle.Permissions().Authorize(ctx, user.ID, repo.ID, AccessModeWrite,
+			AccessModeOptions{
+				OwnerID: repo.OwnerID,
+				Private: repo.IsPrivate,
+			},
+		) {
+		return nil, ErrRepoNotExist{args: errutil.Args{"fullName": fullName}}
+	}
+	return repo, nil
+}
+
+// nextIssueIndex returns the index for the next issue or pull request of the
+// repository, which never reuses indexes of issues that have been transferred
+// to other repositories.
+func (repo *Repository) nextIssueIndex(e Engine) (int64, error) {
+	var maxIndex, maxRedirectIndex int64
+	if _, err := e.Sql("SELECT COALESCE(MAX(`index`), 0) FROM `issue` WHERE repo_id = ?", repo.ID).Get(&maxIndex); err != nil {
+		return 0, fmt.Errorf("get max issue index: %v", err)
+	}
+	if _, err := e.Sql("SELECT COALESCE(MAX(`index`), 0) FROM `issue_redirect` WHERE repo_id = ?", repo.ID).Get(&maxRedirectIndex); err != nil {
+		return 0, fmt.Errorf("get max redirect index: %v", err)
+	}
+
+	if maxRedirectIndex > maxIndex {
+		maxIndex = maxRedirectIndex
+	}
+	return maxIndex + 1, nil
+}
+
+// transferLabels replaces labels of the issue with labels of the same names
+// available to the new repository, labels without a match are dropped.
+func (issue *Issue) transferLabels(e *xorm.Session, newRepoID int64) error {
+	if err := issue.getLabels(e); err != nil {
+		return fmt.Errorf("get labels: %v", err)
+	}
+	if len(issue.Labels) == 0 {
+		return nil
+	}
+
+	newLabels := make([]*Label, 0, 10)
+	if err := e.Where(availableToRepoCond, newRepoID, newRepoID).Desc("repo_id").Find(&newLabels); err != nil {
+		return fmt.Errorf("find labels of new repository: %v", err)
+	}
+	labelsByName := make(map[string]*Label, len(newLabels))
+	for _, label := range newLabels {
+		// Labels of the repository take precedence over labels of the organization
+		name := strings.ToLower(label.Name)
+		if _, ok := labelsByName[name]; !ok {
+			labelsByName[name] = label
+		}
+	}
+
+	// NOTE: deleteIssueLabel slices issue.Labels, so we need to create another slice to be unaffected.
+	oldLabels := make([]*Label, len(issue.Labels))
+	copy(oldLabels, issue.Labels)
+	for _, label := range oldLabels {
+		if err := deleteIssueLabel(e, issue, label); err != nil {
+			return fmt.Errorf("delete issue label: %v", err)
+		}
+
+		newLabel, ok := labelsByName[strings.ToLower(label.Name)]
+		if !ok {
+			continue
+		}
+		if err := newIssueLabel(e, issue, newLabel); err != nil {
+			return fmt.Errorf("new issue label: %v", err)
+		}
+	}
+	return nil
+}
+
+// transferMilestone replaces the milestone of the issue with the milestone of
+// the same name available to the new repository, or removes it when there is no
+// match.
+// It must be called before the issue is moved to the new repository.
+func (issue *Issue) transferMilestone(e *xorm.Session, newRepoID int64) error {
+	if issue.MilestoneID == 0 {
+		return nil
+	}
+
+	oldMilestone, err := getAvailableMilestoneByRepoID(e, issue.RepoID, issue.MilestoneID)
+	if err != nil {
+		if IsErrMilestoneNotExist(err) {
+			issue.MilestoneID = 0
+			return nil
+		}
+		return fmt.Errorf("get milestone: %v", err)
+	}
+
+	oldMilestone.NumIssues--
+	if issue.IsClosed {
+		oldMilestone.NumClosedIssues--
+	}
+	if err = updateMilestone(e, oldMilestone); err != nil {
+		return fmt.Errorf("update old milestone: %v", err)
+	}
+
+	newMilestone := new(Milestone)
+	has, err := e.Where(availableToRepoCond, newRepoID, newRepoID).And("LOWER(name) = ?", strings.ToLower(oldMilestone.Name)).
+		Desc("repo_id").Get(newMilestone)
+	if err != nil {
+		return fmt.Errorf("get milestone of new repository: %v", err)
+	} else if !has {
+		issue.MilestoneID = 0
+		issue.Milestone = nil
+		return nil
+	}
+
+	newMilestone.NumIssues++
+	if issue.IsClosed {
+		newMilestone.NumClosedIssues++
+	}
+	if err = updateMilestone(e, newMilestone); err != nil {
+		return fmt.Errorf("update new milestone: %v", err)
+	}
+	issue.MilestoneID = newMilestone.ID
+	issue.Milestone = newMilestone
+	return nil
+}
+
+// Transfer moves the issue along with its comments, attachments and mentions
+// to the new repository, where it gets a new index. Labels and the milestone
+// are matched by name, and assignees who cannot be assigned in the new
+// repository are dropped, so are cards in projects the new repository cannot
+// add issues to. The old index keeps redirecting to the issue, and the transfer
+// is recorded in the timeline of the issue and the activity of the old
+// repository.
+func (issue *Issue) Transfer(doer *User, newRepo *Repository) (err error) {
+	if issue.IsPull {
+		return fmt.Errorf("pull requests cannot be transferred")
+	} else if issue.RepoID == newRepo.ID {
+		return fmt.Errorf("issue is already in the repository")
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	oldRepo, err := getRepositoryByID(sess, issue.RepoID)
+	if err != nil {
+		return fmt.Errorf("get old repository: %v", err)
+	}
+	oldRef := oldRepo.mustOwner(sess).Name + "/" + oldRepo.Name + "#" + fmt.Sprint(issue.Index)
+
+	if err = issue.transferLabels(sess, newRepo.ID); err != nil {
+		return err
+	}
+	if err = issue.transferMilestone(sess, newRepo.ID); err != nil {
+		return err
+	}
+
+	assignees, err := newRepo.getAssignees(sess)
+	if err != nil {
+		return fmt.Errorf("get assignees of new repository: %v", err)
+	}
+	assignable := make(map[int64]bool, len(assignees))
+	for _, u := range assignees {
+		assignable[u.ID] = true
+	}
+	assigneeIDs, err := getAssigneeIDsByIssueID(sess, issue.ID)
+	if err != nil {
+		return fmt.Errorf("get assignee IDs: %v", err)
+	}
+	keptAssigneeIDs := make([]int64, 0, len(assigneeIDs))
+	for _, id := range assigneeIDs {
+		if assignable[id] {
+			keptAssigneeIDs = append(keptAssigneeIDs, id)
+		}
+	}
+	if _, _, err = changeIssueAssignees(sess, issue, keptAssigneeIDs); err != nil {
+		return fmt.Errorf("change assignees: %v", err)
+	}
+
+	if _, err = sess.Insert(&IssueRedirect{
+		RepoID:  issue.RepoID,
+		Index:   issue.Index,
+		IssueID: issue.ID,
+	}); err != nil {
+		return fmt.Errorf("insert redirect: %v", err)
+	}
+
+	oldIndex := issue.Index
+	issue.Index, err = newRepo.nextIssueIndex(sess)
+	if err != nil {
+		return err
+	}
+	issue.RepoID = newRepo.ID
+	issue.Repo = newRepo
+	issue.PinOrder = 0
+	if err = updateIssueCols(sess, issue, "repo_id", "index", "milestone_id", "pin_order"); err != nil {
+		return fmt.Errorf("update issue: %v", err)
+	}
+
+	if _, err = sess.Exec("UPDATE `issue_user` SET repo_id = ?, milestone_id = ? WHERE issue_id = ?", newRepo.ID, issue.MilestoneID, issue.ID); err != nil {
+		return fmt.Errorf("update issue users: %v", err)
+	}
+	if _, err = sess.Exec("UPDATE `notification` SET repo_id = ? WHERE issue_id = ?", newRepo.ID, issue.ID); err != nil {
+		return fmt.Errorf("update notifications: %v", err)
+	}
+
+	closedDelta := 0
+	if issue.IsClosed {
+		closedDelta = 1
+	}
+	if _, err = sess.Exec("UPDATE `repository` SET num_issues = num_issues - 1, num_closed_issues = num_closed_issues - ? WHERE id = ?", closedDelta, oldRepo.ID); err != nil {
+		return fmt.Errorf("update old repository: %v", err)
+	}
+	if _, err = sess.Exec("UPDATE `repository` SET num_issues = num_issues + 1, num_closed_issues = num_closed_issues + ? WHERE id = ?", closedDelta, newRepo.ID); err != nil {
+		return fmt.Errorf("update new repository: %v", err)
+	}
+
+	// Cards can only stay in organization projects that the new repository can
+	// add issues to.
+	if _, err = sess.Exec("DELETE FROM `project_card` WHERE issue_id = ? AND project_id NOT IN (SELECT id FROM `project` WHERE repo_id = 0 AND owner_id = ?)", issue.ID, newRepo.OwnerID); err != nil {
+		return fmt.Errorf("delete project cards: %v", err)
+	}
+
+	if err = createEventComment(sess, doer, issue, CreateCommentOptions{
+		Type:    COMMENT_TYPE_TRANSFER,
+		Content: oldRef,
+	}); err != nil {
+		return fmt.Errorf("create event comment: %v", err)
+	}
+
+	// The old index keeps redirecting to the issue, and the new location is only
+	// revealed to watchers of the old repository when it is public.
+	content := fmt.Sprint(oldIndex)
+	if !newRepo.IsPrivate {
+		content += "|" + newRepo.mustOwner(sess).Name + "/" + newRepo.Name + "#" + fmt.Sprint(issue.Index)
+	}
+	if err = notifyWatchers(sess, &Action{
+		ActUserID:    doer.ID,
+		ActUserName:  doer.Name,
+		OpType:       ActionTransferIssue,
+		Content:      content,
+		RepoID:       oldRepo.ID,
+		RepoUserName: oldRepo.mustOwner(sess).Name,
+		RepoName:     oldRepo.Name,
+		IsPrivate:    oldRepo.IsPrivate,
+	}); err != nil {
+		return fmt.Errorf("notify watchers: %v", err)
+	}
+
+	return sess.Commit()
+}
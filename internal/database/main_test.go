@@ -8,12 +8,16 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	_ "modernc.org/sqlite"
 	log "unknwon.dev/clog/v2"
+	"xorm.io/core"
+	"xorm.io/xorm"
 
 	"gogs.io/gogs/internal/conf"
 	"gogs.io/gogs/internal/dbtest"
@@ -55,6 +59,18 @@ func newTestDB(t *testing.T, suite string) *gorm.DB {
 	return dbtest.NewDB(t, suite, append(Tables, legacyTables...)...)
 }
 
+// newTestLegacyEngine returns a legacy XORM engine backed by a temporary SQLite
+// database with given tables, for testing functions that still rely on XORM.
+func newTestLegacyEngine(t *testing.T, tables ...any) *xorm.Engine {
+	engine, err := xorm.NewEngine("sqlite3", "file:"+filepath.Join(t.TempDir(), "gogs.db")+"?mode=rwc")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = engine.Close() })
+
+	engine.SetMapper(core.GonicMapper{})
+	require.NoError(t, engine.Sync2(tables...))
+	return engine
+}
+
 func clearTables(t *testing.T, db *gorm.DB) error {
 	if t.Failed() {
 		return nil
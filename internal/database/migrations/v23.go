@@ -0,0 +1,54 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func migrateIssueAssigneesToIssueAssigneeTable(db *gorm.DB) error {
+	type issue struct {
+		ID         int64
+		AssigneeID int64
+	}
+	type issueAssignee struct {
+		ID         int64 `gorm:"primaryKey"`
+		IssueID    int64 `gorm:"uniqueIndex:issue_assignee_unique;not null"`
+		AssigneeID int64 `gorm:"uniqueIndex:issue_assignee_unique;index;not null"`
+	}
+
+	if !db.Migrator().HasColumn(&issue{}, "AssigneeID") {
+		return errMigrationSkipped
+	}
+
+	var count int64
+	err := db.Model(&issue{}).Where("assignee_id > 0").Count(&count).Error
+	if err != nil {
+		return errors.Wrap(err, "count assigned issues")
+	} else if count == 0 {
+		return errMigrationSkipped
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.AutoMigrate(&issueAssignee{})
+		if err != nil {
+			return errors.Wrap(err, "auto migrate")
+		}
+
+		err = tx.Exec("INSERT INTO issue_assignee (issue_id, assignee_id) SELECT id, assignee_id FROM issue WHERE assignee_id > 0").Error
+		if err != nil {
+			return errors.Wrap(err, "copy assignees")
+		}
+
+		// The column is kept but reset to avoid rebuilding the table on SQLite, it
+		// is no longer read by the application.
+		err = tx.Model(&issue{}).Where("assignee_id > 0").Update("assignee_id", 0).Error
+		if err != nil {
+			return errors.Wrap(err, "reset assignees")
+		}
+		return nil
+	})
+}
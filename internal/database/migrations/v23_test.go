@@ -0,0 +1,74 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/dbtest"
+)
+
+type issuePreV23 struct {
+	ID         int64 `gorm:"primaryKey"`
+	RepoID     int64 `gorm:"index"`
+	Index      int64
+	Title      string
+	AssigneeID int64 `gorm:"index"`
+}
+
+func (*issuePreV23) TableName() string {
+	return "issue"
+}
+
+type issueAssigneeV23 struct {
+	ID         int64 `gorm:"primaryKey"`
+	IssueID    int64 `gorm:"uniqueIndex:issue_assignee_unique;not null"`
+	AssigneeID int64 `gorm:"uniqueIndex:issue_assignee_unique;index;not null"`
+}
+
+func (*issueAssigneeV23) TableName() string {
+	return "issue_assignee"
+}
+
+func TestMigrateIssueAssigneesToIssueAssigneeTable(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	db := dbtest.NewDB(t, "migrateIssueAssigneesToIssueAssigneeTable", new(issuePreV23))
+	err := db.Create(
+		[]*issuePreV23{
+			{ID: 1, RepoID: 1, Index: 1, Title: "assigned", AssigneeID: 2},
+			{ID: 2, RepoID: 1, Index: 2, Title: "unassigned"},
+			{ID: 3, RepoID: 1, Index: 3, Title: "assigned", AssigneeID: 3},
+		},
+	).Error
+	require.NoError(t, err)
+
+	err = migrateIssueAssigneesToIssueAssigneeTable(db)
+	require.NoError(t, err)
+
+	var assignees []*issueAssigneeV23
+	err = db.Order("issue_id ASC").Find(&assignees).Error
+	require.NoError(t, err)
+	require.Len(t, assignees, 2)
+	assert.Equal(t, int64(1), assignees[0].IssueID)
+	assert.Equal(t, int64(2), assignees[0].AssigneeID)
+	assert.Equal(t, int64(3), assignees[1].IssueID)
+	assert.Equal(t, int64(3), assignees[1].AssigneeID)
+
+	var count int64
+	err = db.Model(&issuePreV23{}).Where("assignee_id > 0").Count(&count).Error
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+
+	// Re-run should be skipped
+	err = migrateIssueAssigneesToIssueAssigneeTable(db)
+	require.Equal(t, errMigrationSkipped, err)
+}
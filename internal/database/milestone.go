@@ -17,10 +17,12 @@ import (
 	"gogs.io/gogs/internal/errutil"
 )
 
-// Milestone represents a milestone of repository.
+// Milestone represents a milestone of repository or organization. Milestones of
+// an organization have zero RepoID and are available to all its repositories.
 type Milestone struct {
 	ID              int64
 	RepoID          int64 `xorm:"INDEX"`
+	OrgID           int64 `xorm:"INDEX"`
 	Name            string
 	Content         string `xorm:"TEXT"`
 	RenderedContent string `xorm:"-" json:"-" gorm:"-"`
@@ -36,6 +38,8 @@ type Milestone struct {
 	DeadlineUnix   int64
 	ClosedDate     time.Time `xorm:"-" json:"-" gorm:"-"`
 	ClosedDateUnix int64
+
+	TrackedTime int64 `xorm:"-" json:"-" gorm:"-"` // In seconds
 }
 
 func (m *Milestone) BeforeInsert() {
@@ -74,6 +78,11 @@ func (m *Milestone) AfterSet(colName string, _ xorm.Cell) {
 	}
 }
 
+// IsOrgMilestone returns true if the milestone belongs to an organization.
+func (m *Milestone) IsOrgMilestone() bool {
+	return m.OrgID > 0
+}
+
 // State returns string representation of milestone status.
 func (m *Milestone) State() api.StateType {
 	if m.IsClosed {
@@ -113,7 +122,7 @@ func (m *Milestone) CountIssues(isClosed, includePulls bool) int64 {
 	return count
 }
 
-// NewMilestone creates new milestone of repository.
+// NewMilestone creates new milestone of repository or organization.
 func NewMilestone(m *Milestone) (err error) {
 	sess := x.NewSession()
 	defer sess.Close()
@@ -125,8 +134,10 @@ func NewMilestone(m *Milestone) (err error) {
 		return err
 	}
 
-	if _, err = sess.Exec("UPDATE `repository` SET num_milestones = num_milestones + 1 WHERE id = ?", m.RepoID); err != nil {
-		return err
+	if m.RepoID > 0 {
+		if _, err = sess.Exec("UPDATE `repository` SET num_milestones = num_milestones + 1 WHERE id = ?", m.RepoID); err != nil {
+			return err
+		}
 	}
 	return sess.Commit()
 }
@@ -169,12 +180,72 @@ func GetMilestoneByRepoID(repoID, id int64) (*Milestone, error) {
 	return getMilestoneByRepoID(x, repoID, id)
 }
 
+// getAvailableMilestoneByRepoID returns the milestone by ID that is available to
+// the repository, which is either of the repository or of its owner organization.
+func getAvailableMilestoneByRepoID(e Engine, repoID, id int64) (*Milestone, error) {
+	m := new(Milestone)
+	has, err := e.Where("id = ?", id).And(availableToRepoCond, repoID, repoID).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrMilestoneNotExist{args: map[string]any{"repoID": repoID, "milestoneID": id}}
+	}
+	return m, nil
+}
+
+// GetAvailableMilestoneByRepoID returns the milestone by ID that is available to
+// the repository, which is either of the repository or of its owner organization.
+func GetAvailableMilestoneByRepoID(repoID, id int64) (*Milestone, error) {
+	return getAvailableMilestoneByRepoID(x, repoID, id)
+}
+
+// GetMilestoneByOrgID returns the milestone in an organization.
+func GetMilestoneByOrgID(orgID, id int64) (*Milestone, error) {
+	if orgID <= 0 {
+		return nil, ErrMilestoneNotExist{args: map[string]any{"orgID": orgID, "milestoneID": id}}
+	}
+
+	m := &Milestone{
+		ID:    id,
+		OrgID: orgID,
+	}
+	has, err := x.Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrMilestoneNotExist{args: map[string]any{"orgID": orgID, "milestoneID": id}}
+	}
+	return m, nil
+}
+
 // GetMilestonesByRepoID returns all milestones of a repository.
 func GetMilestonesByRepoID(repoID int64) ([]*Milestone, error) {
 	miles := make([]*Milestone, 0, 10)
 	return miles, x.Where("repo_id = ?", repoID).Find(&miles)
 }
 
+// GetAvailableMilestonesByRepoID returns all milestones that are available to a
+// repository, milestones of the repository come before milestones of its owner
+// organization.
+func GetAvailableMilestonesByRepoID(repoID int64) ([]*Milestone, error) {
+	miles := make([]*Milestone, 0, 10)
+	return miles, x.Where(availableToRepoCond, repoID, repoID).Desc("repo_id").Asc("id").Find(&miles)
+}
+
+// GetAvailableMilestones returns a list of milestones of given status that are
+// available to a repository.
+func GetAvailableMilestones(repoID int64, isClosed bool) ([]*Milestone, error) {
+	miles := make([]*Milestone, 0, 10)
+	return miles, x.Where(availableToRepoCond, repoID, repoID).And("is_closed = ?", isClosed).
+		Desc("repo_id").Asc("id").Find(&miles)
+}
+
+// GetMilestonesByOrgID returns all milestones of an organization.
+func GetMilestonesByOrgID(orgID int64) ([]*Milestone, error) {
+	miles := make([]*Milestone, 0, 10)
+	return miles, x.Where("repo_id = 0 AND org_id = ?", orgID).Find(&miles)
+}
+
 // GetMilestones returns a list of milestones of given repository and status.
 func GetMilestones(repoID int64, page int, isClosed bool) ([]*Milestone, error) {
 	miles := make([]*Milestone, 0, conf.UI.IssuePagingNum)
@@ -185,6 +256,16 @@ func GetMilestones(repoID int64, page int, isClosed bool) ([]*Milestone, error)
 	return miles, sess.Find(&miles)
 }
 
+// GetOrgMilestones returns a list of milestones of given organization and status.
+func GetOrgMilestones(orgID int64, page int, isClosed bool) ([]*Milestone, error) {
+	miles := make([]*Milestone, 0, conf.UI.IssuePagingNum)
+	sess := x.Where("repo_id = 0 AND org_id = ? AND is_closed = ?", orgID, isClosed)
+	if page > 0 {
+		sess = sess.Limit(conf.UI.IssuePagingNum, (page-1)*conf.UI.IssuePagingNum)
+	}
+	return miles, sess.Find(&miles)
+}
+
 func updateMilestone(e Engine, m *Milestone) error {
 	_, err := e.ID(m.ID).AllCols().Update(m)
 	return err
@@ -221,10 +302,23 @@ func MilestoneStats(repoID int64) (open, closed int64) {
 	return open, CountRepoClosedMilestones(repoID)
 }
 
+// OrgMilestoneStats returns number of open and closed milestones of given
+// organization.
+func OrgMilestoneStats(orgID int64) (open, closed int64) {
+	open, _ = x.Where("repo_id = 0 AND org_id = ? AND is_closed = ?", orgID, false).Count(new(Milestone))
+	closed, _ = x.Where("repo_id = 0 AND org_id = ? AND is_closed = ?", orgID, true).Count(new(Milestone))
+	return open, closed
+}
+
 // ChangeMilestoneStatus changes the milestone open/closed status.
 // If milestone passes with changed values, those values will be
 // updated to database as well.
 func ChangeMilestoneStatus(m *Milestone, isClosed bool) (err error) {
+	if m.RepoID == 0 {
+		m.IsClosed = isClosed
+		return UpdateMilestone(m)
+	}
+
 	repo, err := GetRepositoryByID(m.RepoID)
 	if err != nil {
 		return err
@@ -254,7 +348,7 @@ func changeMilestoneIssueStats(e *xorm.Session, issue *Issue) error {
 		return nil
 	}
 
-	m, err := getMilestoneByRepoID(e, issue.RepoID, issue.MilestoneID)
+	m, err := getAvailableMilestoneByRepoID(e, issue.RepoID, issue.MilestoneID)
 	if err != nil {
 		return err
 	}
@@ -288,7 +382,7 @@ func ChangeMilestoneIssueStats(issue *Issue) (err error) {
 
 func changeMilestoneAssign(e *xorm.Session, issue *Issue, oldMilestoneID int64) error {
 	if oldMilestoneID > 0 {
-		m, err := getMilestoneByRepoID(e, issue.RepoID, oldMilestoneID)
+		m, err := getAvailableMilestoneByRepoID(e, issue.RepoID, oldMilestoneID)
 		if err != nil {
 			return err
 		}
@@ -308,7 +402,7 @@ func changeMilestoneAssign(e *xorm.Session, issue *Issue, oldMilestoneID int64)
 	}
 
 	if issue.MilestoneID > 0 {
-		m, err := getMilestoneByRepoID(e, issue.RepoID, issue.MilestoneID)
+		m, err := getAvailableMilestoneByRepoID(e, issue.RepoID, issue.MilestoneID)
 		if err != nil {
 			return err
 		}
@@ -341,6 +435,13 @@ func ChangeMilestoneAssign(doer *User, issue *Issue, oldMilestoneID int64) (err
 	if err = changeMilestoneAssign(sess, issue, oldMilestoneID); err != nil {
 		return err
 	}
+	if err = createEventComment(sess, doer, issue, CreateCommentOptions{
+		Type:           COMMENT_TYPE_MILESTONE,
+		OldMilestoneID: oldMilestoneID,
+		MilestoneID:    issue.MilestoneID,
+	}); err != nil {
+		return fmt.Errorf("createEventComment: %v", err)
+	}
 
 	if err = sess.Commit(); err != nil {
 		return fmt.Errorf("Commit: %v", err)
@@ -420,3 +521,32 @@ func DeleteMilestoneOfRepoByID(repoID, id int64) error {
 	}
 	return sess.Commit()
 }
+
+// DeleteMilestoneOfOrgByID deletes a milestone from an organization, which is
+// also removed from issues of all repositories of the organization.
+func DeleteMilestoneOfOrgByID(orgID, id int64) error {
+	m, err := GetMilestoneByOrgID(orgID, id)
+	if err != nil {
+		if IsErrMilestoneNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.ID(m.ID).Delete(new(Milestone)); err != nil {
+		return err
+	}
+
+	if _, err = sess.Exec("UPDATE `issue` SET milestone_id = 0 WHERE milestone_id = ?", m.ID); err != nil {
+		return err
+	} else if _, err = sess.Exec("UPDATE `issue_user` SET milestone_id = 0 WHERE milestone_id = ?", m.ID); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
@@ -0,0 +1,200 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// NotificationCategory is the category of activities that users choose how to
+// be notified of by email.
+type NotificationCategory string
+
+const (
+	NotificationCategoryOwnActivity   NotificationCategory = "own_activity"
+	NotificationCategoryMention       NotificationCategory = "mention"
+	NotificationCategoryParticipating NotificationCategory = "participating"
+	NotificationCategoryWatching      NotificationCategory = "watching"
+)
+
+// NotificationCategories is the list of all notification categories.
+var NotificationCategories = []NotificationCategory{
+	NotificationCategoryOwnActivity,
+	NotificationCategoryMention,
+	NotificationCategoryParticipating,
+	NotificationCategoryWatching,
+}
+
+// notificationCategoryOf returns the category of activities that the user is
+// notified of for the reason.
+func notificationCategoryOf(reason NotificationReason) NotificationCategory {
+	switch reason {
+	case NotificationReasonMention:
+		return NotificationCategoryMention
+	case NotificationReasonParticipating, NotificationReasonAssigned:
+		return NotificationCategoryParticipating
+	default:
+		return NotificationCategoryWatching
+	}
+}
+
+// EmailDelivery is how emails of notifications are delivered.
+type EmailDelivery string
+
+const (
+	EmailDeliveryImmediate EmailDelivery = "immediate"
+	EmailDeliveryHourly    EmailDelivery = "hourly"
+	EmailDeliveryDaily     EmailDelivery = "daily"
+	EmailDeliveryNone      EmailDelivery = "none"
+)
+
+// EmailDeliveries is the list of all email deliveries.
+var EmailDeliveries = []EmailDelivery{
+	EmailDeliveryImmediate,
+	EmailDeliveryHourly,
+	EmailDeliveryDaily,
+	EmailDeliveryNone,
+}
+
+// IsDigest returns true if emails are queued and sent in digests.
+func (d EmailDelivery) IsDigest() bool {
+	return d == EmailDeliveryHourly || d == EmailDeliveryDaily
+}
+
+// NotificationPreference is how a user chooses to receive emails of each
+// category of activities on issues and pull requests.
+type NotificationPreference struct {
+	UserID        int64         `gorm:"primaryKey;autoIncrement:false"`
+	OwnActivity   EmailDelivery `gorm:"not null"`
+	Mention       EmailDelivery `gorm:"not null"`
+	Participating EmailDelivery `gorm:"not null"`
+	Watching      EmailDelivery `gorm:"not null"`
+}
+
+// DefaultNotificationPreference returns the preference of users who have not
+// made a choice, which sends emails immediately except for their own
+// activities.
+func DefaultNotificationPreference(userID int64) *NotificationPreference {
+	return &NotificationPreference{
+		UserID:        userID,
+		OwnActivity:   EmailDeliveryNone,
+		Mention:       EmailDeliveryImmediate,
+		Participating: EmailDeliveryImmediate,
+		Watching:      EmailDeliveryImmediate,
+	}
+}
+
+// Delivery returns how emails of the category are delivered.
+func (p *NotificationPreference) Delivery(category NotificationCategory) EmailDelivery {
+	switch category {
+	case NotificationCategoryOwnActivity:
+		return p.OwnActivity
+	case NotificationCategoryMention:
+		return p.Mention
+	case NotificationCategoryParticipating:
+		return p.Participating
+	case NotificationCategoryWatching:
+		return p.Watching
+	default:
+		return EmailDeliveryNone
+	}
+}
+
+// SetDelivery sets how emails of the category are delivered.
+func (p *NotificationPreference) SetDelivery(category NotificationCategory, delivery EmailDelivery) {
+	switch category {
+	case NotificationCategoryOwnActivity:
+		p.OwnActivity = delivery
+	case NotificationCategoryMention:
+		p.Mention = delivery
+	case NotificationCategoryParticipating:
+		p.Participating = delivery
+	case NotificationCategoryWatching:
+		p.Watching = delivery
+	}
+}
+
+// NotificationPreferencesStore is the storage layer for notification
+// preferences.
+type NotificationPreferencesStore struct {
+	db *gorm.DB
+}
+
+func newNotificationPreferencesStore(db *gorm.DB) *NotificationPreferencesStore {
+	return &NotificationPreferencesStore{db: db}
+}
+
+// Get returns the notification preference of the user, which is the default
+// one when the user has not made a choice.
+func (s *NotificationPreferencesStore) Get(ctx context.Context, userID int64) (*NotificationPreference, error) {
+	p := new(NotificationPreference)
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(p).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return DefaultNotificationPreference(userID), nil
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// Save creates or updates the notification preference of the user.
+func (s *NotificationPreferencesStore) Save(ctx context.Context, p *NotificationPreference) error {
+	for _, category := range NotificationCategories {
+		valid := false
+		for _, delivery := range EmailDeliveries {
+			if p.Delivery(category) == delivery {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return errors.Errorf("invalid email delivery %q of category %q", p.Delivery(category), category)
+		}
+	}
+	return s.db.WithContext(ctx).Save(p).Error
+}
+
+// getNotificationPreferences returns notification preferences of given users,
+// with default ones for users who have not made a choice. It uses the legacy
+// engine because it may be called within a transaction of creating comments.
+func getNotificationPreferences(e Engine, userIDs []int64) (map[int64]*NotificationPreference, error) {
+	prefs := make([]*NotificationPreference, 0, len(userIDs))
+	if len(userIDs) > 0 {
+		if err := e.In("user_id", userIDs).Find(&prefs); err != nil {
+			return nil, fmt.Errorf("find notification preferences: %v", err)
+		}
+	}
+
+	m := make(map[int64]*NotificationPreference, len(userIDs))
+	for _, p := range prefs {
+		m[p.UserID] = p
+	}
+	for _, id := range userIDs {
+		if m[id] == nil {
+			m[id] = DefaultNotificationPreference(id)
+		}
+	}
+	return m, nil
+}
+
+// EmailDigestItem is an activity on an issue or a pull request that is queued
+// for the next email digest of a user.
+type EmailDigestItem struct {
+	ID        int64                `gorm:"primaryKey"`
+	UserID    int64                `gorm:"index;not null"`
+	Delivery  EmailDelivery        `gorm:"index;not null"`
+	IssueID   int64                `gorm:"index;not null"`
+	CommentID int64                // Zero for the issue itself
+	DoerID    int64                `gorm:"not null"`
+	Category  NotificationCategory `gorm:"not null"`
+	// The content of the activity in Markdown at the time it happened.
+	Content     string `gorm:"type:TEXT"`
+	CreatedUnix int64  `gorm:"index"`
+}
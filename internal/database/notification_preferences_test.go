@@ -0,0 +1,80 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotificationPreferences(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	ctx := context.Background()
+	s := &NotificationPreferencesStore{
+		db: newTestDB(t, "NotificationPreferencesStore"),
+	}
+
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, s *NotificationPreferencesStore)
+	}{
+		{"Get", notificationPreferencesGet},
+		{"Save", notificationPreferencesSave},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				err := clearTables(t, s.db)
+				require.NoError(t, err)
+			})
+			tc.test(t, ctx, s)
+		})
+		if t.Failed() {
+			break
+		}
+	}
+}
+
+func notificationPreferencesGet(t *testing.T, ctx context.Context, s *NotificationPreferencesStore) {
+	// Users who have not made a choice get the default preference
+	got, err := s.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultNotificationPreference(1), got)
+	assert.Equal(t, EmailDeliveryNone, got.Delivery(NotificationCategoryOwnActivity))
+	assert.Equal(t, EmailDeliveryImmediate, got.Delivery(notificationCategoryOf(NotificationReasonAssigned)))
+}
+
+func notificationPreferencesSave(t *testing.T, ctx context.Context, s *NotificationPreferencesStore) {
+	want := DefaultNotificationPreference(1)
+	want.SetDelivery(NotificationCategoryOwnActivity, EmailDeliveryImmediate)
+	want.SetDelivery(NotificationCategoryWatching, EmailDeliveryDaily)
+	err := s.Save(ctx, want)
+	require.NoError(t, err)
+
+	got, err := s.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	// Saving again updates the existing preference
+	want.SetDelivery(NotificationCategoryParticipating, EmailDeliveryHourly)
+	err = s.Save(ctx, want)
+	require.NoError(t, err)
+
+	got, err = s.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+	assert.True(t, got.Delivery(NotificationCategoryParticipating).IsDigest())
+
+	// Unknown deliveries are rejected
+	want.SetDelivery(NotificationCategoryMention, "weekly")
+	err = s.Save(ctx, want)
+	assert.Error(t, err)
+}
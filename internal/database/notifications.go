@@ -0,0 +1,341 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+// NotificationStatus is the status of a notification thread.
+type NotificationStatus int
+
+const (
+	NotificationStatusUnread NotificationStatus = iota + 1
+	NotificationStatusRead
+	NotificationStatusPinned
+)
+
+// String returns the name of the status that is used in URLs and the API.
+func (s NotificationStatus) String() string {
+	switch s {
+	case NotificationStatusUnread:
+		return "unread"
+	case NotificationStatusRead:
+		return "read"
+	case NotificationStatusPinned:
+		return "pinned"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseNotificationStatus returns the status with given name, or false if the
+// name is not recognized.
+func ParseNotificationStatus(name string) (NotificationStatus, bool) {
+	for _, s := range []NotificationStatus{NotificationStatusUnread, NotificationStatusRead, NotificationStatusPinned} {
+		if s.String() == name {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// NotificationReason is the reason of a user being notified of an issue, where
+// a greater value takes precedence.
+type NotificationReason int
+
+const (
+	NotificationReasonWatching NotificationReason = iota + 1
+	NotificationReasonParticipating
+	NotificationReasonAssigned
+	NotificationReasonMention
+)
+
+// String returns the name of the reason that is used in the API.
+func (r NotificationReason) String() string {
+	switch r {
+	case NotificationReasonWatching:
+		return "subscribed"
+	case NotificationReasonParticipating:
+		return "comment"
+	case NotificationReasonAssigned:
+		return "assign"
+	case NotificationReasonMention:
+		return "mention"
+	default:
+		return "unknown"
+	}
+}
+
+// Notification is the notification thread of an issue or a pull request for a
+// user, which is updated with each new activity.
+type Notification struct {
+	ID          int64              `gorm:"primaryKey"`
+	UserID      int64              `gorm:"uniqueIndex:notification_user_issue_unique;not null"`
+	RepoID      int64              `gorm:"index;not null"`
+	IssueID     int64              `gorm:"uniqueIndex:notification_user_issue_unique;not null"`
+	CommentID   int64              // The latest comment, zero for the issue itself
+	Status      NotificationStatus `gorm:"index;not null"`
+	Reason      NotificationReason `gorm:"not null"`
+	UpdatedByID int64              // The user who made the latest activity
+	CreatedUnix int64
+	UpdatedUnix int64 `gorm:"index"`
+
+	Issue *Issue `xorm:"-" gorm:"-" json:"-"`
+}
+
+// BeforeCreate implements the GORM create hook.
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.CreatedUnix == 0 {
+		n.CreatedUnix = tx.NowFunc().Unix()
+		n.UpdatedUnix = n.CreatedUnix
+	}
+	return nil
+}
+
+// Updated returns the time of the latest activity.
+func (n *Notification) Updated() time.Time {
+	return time.Unix(n.UpdatedUnix, 0)
+}
+
+// IsUnread returns true if the notification has not been read.
+func (n *Notification) IsUnread() bool {
+	return n.Status == NotificationStatusUnread
+}
+
+// IsPinned returns true if the notification has been pinned.
+func (n *Notification) IsPinned() bool {
+	return n.Status == NotificationStatusPinned
+}
+
+// NotificationsStore is the storage layer for notifications.
+type NotificationsStore struct {
+	db *gorm.DB
+}
+
+func newNotificationsStore(db *gorm.DB) *NotificationsStore {
+	return &NotificationsStore{db: db}
+}
+
+var _ errutil.NotFound = (*ErrNotificationNotExist)(nil)
+
+type ErrNotificationNotExist struct {
+	args errutil.Args
+}
+
+func IsErrNotificationNotExist(err error) bool {
+	return errors.As(err, &ErrNotificationNotExist{})
+}
+
+func (err ErrNotificationNotExist) Error() string {
+	return fmt.Sprintf("notification does not exist: %v", err.args)
+}
+
+func (ErrNotificationNotExist) NotFound() bool {
+	return true
+}
+
+// GetByID returns the notification with given ID of the user. It returns
+// ErrNotificationNotExist when not found.
+func (s *NotificationsStore) GetByID(ctx context.Context, userID, id int64) (*Notification, error) {
+	n := new(Notification)
+	err := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(n).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotificationNotExist{args: errutil.Args{"notificationID": id}}
+		}
+		return nil, err
+	}
+	return n, nil
+}
+
+type ListNotificationsOptions struct {
+	// The statuses to include, all notifications are included when empty.
+	Statuses []NotificationStatus
+	// The repository to limit to, all repositories are included when zero.
+	RepoID int64
+	// Only notifications updated after this time are included when not zero.
+	SinceUnix int64
+	Page      int
+	PageSize  int
+}
+
+func (opts ListNotificationsOptions) query(db *gorm.DB, userID int64) *gorm.DB {
+	query := db.Model(&Notification{}).Where("user_id = ?", userID)
+	if len(opts.Statuses) > 0 {
+		query = query.Where("status IN (?)", opts.Statuses)
+	}
+	if opts.RepoID > 0 {
+		query = query.Where("repo_id = ?", opts.RepoID)
+	}
+	if opts.SinceUnix > 0 {
+		query = query.Where("updated_unix > ?", opts.SinceUnix)
+	}
+	return query
+}
+
+// List returns notifications of the user that match the options, from the
+// latest updated to the earliest.
+func (s *NotificationsStore) List(ctx context.Context, userID int64, opts ListNotificationsOptions) ([]*Notification, error) {
+	query := opts.query(s.db.WithContext(ctx), userID).Order("updated_unix DESC, id DESC")
+	if opts.PageSize > 0 {
+		if opts.Page < 1 {
+			opts.Page = 1
+		}
+		query = query.Limit(opts.PageSize).Offset((opts.Page - 1) * opts.PageSize)
+	}
+
+	var notifications []*Notification
+	return notifications, query.Find(&notifications).Error
+}
+
+// Count returns the number of notifications of the user that match the
+// options, with pagination ignored.
+func (s *NotificationsStore) Count(ctx context.Context, userID int64, opts ListNotificationsOptions) (int64, error) {
+	var count int64
+	return count, opts.query(s.db.WithContext(ctx), userID).Count(&count).Error
+}
+
+// CountUnread returns the number of unread notifications of the user.
+func (s *NotificationsStore) CountUnread(ctx context.Context, userID int64) (int64, error) {
+	return s.Count(ctx, userID, ListNotificationsOptions{Statuses: []NotificationStatus{NotificationStatusUnread}})
+}
+
+// SetStatus sets the status of the notification with given ID of the user. It
+// returns ErrNotificationNotExist when not found.
+func (s *NotificationsStore) SetStatus(ctx context.Context, userID, id int64, status NotificationStatus) error {
+	result := s.db.WithContext(ctx).
+		Model(&Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		// The status may be unchanged, which is not an error
+		_, err := s.GetByID(ctx, userID, id)
+		return err
+	}
+	return nil
+}
+
+// MarkAllRead marks all unread notifications of the user as read, limited to
+// the repository when repoID is not zero, and to those updated no later than
+// the given time when beforeUnix is not zero.
+func (s *NotificationsStore) MarkAllRead(ctx context.Context, userID, repoID, beforeUnix int64) error {
+	query := s.db.WithContext(ctx).
+		Model(&Notification{}).
+		Where("user_id = ? AND status = ?", userID, NotificationStatusUnread)
+	if repoID > 0 {
+		query = query.Where("repo_id = ?", repoID)
+	}
+	if beforeUnix > 0 {
+		query = query.Where("updated_unix <= ?", beforeUnix)
+	}
+	return query.Update("status", NotificationStatusRead).Error
+}
+
+// MarkIssueRead marks the unread notification of the issue for the user as
+// read, which is done when the user views the issue.
+func (s *NotificationsStore) MarkIssueRead(ctx context.Context, userID, issueID int64) error {
+	return s.db.WithContext(ctx).
+		Model(&Notification{}).
+		Where("user_id = ? AND issue_id = ? AND status = ?", userID, issueID, NotificationStatusUnread).
+		Update("status", NotificationStatusRead).
+		Error
+}
+
+// LoadNotificationIssues loads issues of notifications along with their
+// repositories, and returns notifications whose issues are still accessible by
+// the user.
+func LoadNotificationIssues(ctx context.Context, user *User, notifications []*Notification) ([]*Notification, error) {
+	accessible := make([]*Notification, 0, len(notifications))
+	for _, n := range notifications {
+		issue, err := GetIssueByID(n.IssueID)
+		if err != nil {
+			if IsErrIssueNotExist(err) {
+				continue
+			}
+			return nil, errors.Wrap(err, "get issue")
+		}
+
+		if issue.Repo.IsPrivate && !user.IsAdmin &&
+			!Handle.Permissions().Authorize(ctx, user.ID, issue.RepoID, AccessModeRead,
+				AccessModeOptions{
+					OwnerID: issue.Repo.OwnerID,
+					Private: issue.Repo.IsPrivate,
+				},
+			) {
+			continue
+		}
+
+		n.Issue = issue
+		accessible = append(accessible, n)
+	}
+	return accessible, nil
+}
+
+type notifyOptions struct {
+	RepoID      int64
+	IssueID     int64
+	CommentID   int64
+	UpdatedByID int64
+	// The users to be notified, along with their reasons.
+	Recipients map[int64]NotificationReason
+}
+
+// createNotifications creates or updates notification threads of the issue for
+// recipients, which are marked as unread unless pinned. It uses the legacy
+// engine because it may be called within a transaction of creating comments.
+func createNotifications(e Engine, opts notifyOptions) error {
+	now := time.Now().Unix()
+	for userID, reason := range opts.Recipients {
+		n := new(Notification)
+		has, err := e.Where("user_id = ? AND issue_id = ?", userID, opts.IssueID).Get(n)
+		if err != nil {
+			return fmt.Errorf("get notification: %v", err)
+		}
+
+		if !has {
+			_, err = e.Insert(&Notification{
+				UserID:      userID,
+				RepoID:      opts.RepoID,
+				IssueID:     opts.IssueID,
+				CommentID:   opts.CommentID,
+				Status:      NotificationStatusUnread,
+				Reason:      reason,
+				UpdatedByID: opts.UpdatedByID,
+				CreatedUnix: now,
+				UpdatedUnix: now,
+			})
+			if err != nil {
+				return fmt.Errorf("insert notification: %v", err)
+			}
+			continue
+		}
+
+		updates := map[string]any{
+			"comment_id":    opts.CommentID,
+			"updated_by_id": opts.UpdatedByID,
+			"updated_unix":  now,
+		}
+		if n.Status != NotificationStatusPinned {
+			updates["status"] = NotificationStatusUnread
+		}
+		if reason > n.Reason {
+			updates["reason"] = reason
+		}
+		if _, err = e.Table("notification").Where("id = ?", n.ID).Update(updates); err != nil {
+			return fmt.Errorf("update notification: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+func TestNotifications(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	ctx := context.Background()
+	s := &NotificationsStore{
+		db: newTestDB(t, "NotificationsStore"),
+	}
+
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, s *NotificationsStore)
+	}{
+		{"List", notificationsList},
+		{"SetStatus", notificationsSetStatus},
+		{"MarkAllRead", notificationsMarkAllRead},
+		{"MarkIssueRead", notificationsMarkIssueRead},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				err := clearTables(t, s.db)
+				require.NoError(t, err)
+			})
+			tc.test(t, ctx, s)
+		})
+		if t.Failed() {
+			break
+		}
+	}
+}
+
+func createTestNotifications(t *testing.T, s *NotificationsStore) {
+	t.Helper()
+
+	for _, n := range []*Notification{
+		{UserID: 1, RepoID: 1, IssueID: 1, Status: NotificationStatusUnread, Reason: NotificationReasonMention, UpdatedUnix: 100},
+		{UserID: 1, RepoID: 1, IssueID: 2, Status: NotificationStatusRead, Reason: NotificationReasonWatching, UpdatedUnix: 200},
+		{UserID: 1, RepoID: 2, IssueID: 3, Status: NotificationStatusUnread, Reason: NotificationReasonAssigned, UpdatedUnix: 300},
+		{UserID: 1, RepoID: 2, IssueID: 4, Status: NotificationStatusPinned, Reason: NotificationReasonParticipating, UpdatedUnix: 400},
+		{UserID: 2, RepoID: 1, IssueID: 1, Status: NotificationStatusUnread, Reason: NotificationReasonWatching, UpdatedUnix: 100},
+	} {
+		n.CreatedUnix = n.UpdatedUnix
+		err := s.db.Create(n).Error
+		require.NoError(t, err)
+	}
+}
+
+func issueIDsOf(notifications []*Notification) []int64 {
+	ids := make([]int64, len(notifications))
+	for i, n := range notifications {
+		ids[i] = n.IssueID
+	}
+	return ids
+}
+
+func notificationsList(t *testing.T, ctx context.Context, s *NotificationsStore) {
+	createTestNotifications(t, s)
+
+	tests := []struct {
+		name string
+		opts ListNotificationsOptions
+		want []int64
+	}{
+		{
+			name: "all",
+			want: []int64{4, 3, 2, 1},
+		},
+		{
+			name: "unread",
+			opts: ListNotificationsOptions{Statuses: []NotificationStatus{NotificationStatusUnread}},
+			want: []int64{3, 1},
+		},
+		{
+			name: "unread and pinned",
+			opts: ListNotificationsOptions{Statuses: []NotificationStatus{NotificationStatusUnread, NotificationStatusPinned}},
+			want: []int64{4, 3, 1},
+		},
+		{
+			name: "repository",
+			opts: ListNotificationsOptions{RepoID: 1},
+			want: []int64{2, 1},
+		},
+		{
+			name: "since",
+			opts: ListNotificationsOptions{SinceUnix: 200},
+			want: []int64{4, 3},
+		},
+		{
+			name: "page",
+			opts: ListNotificationsOptions{Page: 2, PageSize: 3},
+			want: []int64{1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := s.List(ctx, 1, test.opts)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, issueIDsOf(got))
+		})
+	}
+
+	count, err := s.Count(ctx, 1, ListNotificationsOptions{Page: 2, PageSize: 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+
+	count, err = s.CountUnread(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func notificationsSetStatus(t *testing.T, ctx context.Context, s *NotificationsStore) {
+	createTestNotifications(t, s)
+
+	got, err := s.List(ctx, 2, ListNotificationsOptions{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	id := got[0].ID
+
+	// Notifications of other users are not accessible
+	err = s.SetStatus(ctx, 1, id, NotificationStatusPinned)
+	wantErr := ErrNotificationNotExist{args: errutil.Args{"notificationID": id}}
+	assert.Equal(t, wantErr, err)
+
+	err = s.SetStatus(ctx, 2, id, NotificationStatusPinned)
+	require.NoError(t, err)
+	// Setting the same status again is not an error
+	err = s.SetStatus(ctx, 2, id, NotificationStatusPinned)
+	require.NoError(t, err)
+
+	n, err := s.GetByID(ctx, 2, id)
+	require.NoError(t, err)
+	assert.True(t, n.IsPinned())
+}
+
+func notificationsMarkAllRead(t *testing.T, ctx context.Context, s *NotificationsStore) {
+	createTestNotifications(t, s)
+
+	err := s.MarkAllRead(ctx, 1, 2, 0)
+	require.NoError(t, err)
+	got, err := s.List(ctx, 1, ListNotificationsOptions{Statuses: []NotificationStatus{NotificationStatusUnread}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, issueIDsOf(got))
+
+	err = s.MarkAllRead(ctx, 1, 0, 50)
+	require.NoError(t, err)
+	count, err := s.CountUnread(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	err = s.MarkAllRead(ctx, 1, 0, 0)
+	require.NoError(t, err)
+	count, err = s.CountUnread(ctx, 1)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	// Pinned notifications and those of other users are untouched
+	got, err = s.List(ctx, 1, ListNotificationsOptions{Statuses: []NotificationStatus{NotificationStatusPinned}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{4}, issueIDsOf(got))
+	count, err = s.CountUnread(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func notificationsMarkIssueRead(t *testing.T, ctx context.Context, s *NotificationsStore) {
+	createTestNotifications(t, s)
+
+	for _, issueID := range []int64{1, 4} {
+		err := s.MarkIssueRead(ctx, 1, issueID)
+		require.NoError(t, err)
+	}
+
+	got, err := s.List(ctx, 1, ListNotificationsOptions{Statuses: []NotificationStatus{NotificationStatusUnread}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3}, issueIDsOf(got))
+	got, err = s.List(ctx, 1, ListNotificationsOptions{Statuses: []NotificationStatus{NotificationStatusPinned}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{4}, issueIDsOf(got))
+}
@@ -100,6 +100,54 @@ func (org *User) RemoveOrgRepo(repoID int64) error {
 	return org.removeOrgRepo(x, repoID)
 }
 
+// removeOrgIssueMetas removes labels and milestones of the organization from
+// issues of the repository, which must be done before the repository is no
+// longer owned by the organization.
+func (org *User) removeOrgIssueMetas(e *xorm.Session, repoID int64) error {
+	issues := make([]*Issue, 0, 10)
+	if err := e.Where("repo_id = ?", repoID).
+		And("(milestone_id IN (SELECT id FROM `milestone` WHERE org_id = ?) OR id IN (SELECT issue_id FROM `issue_label` WHERE label_id IN (SELECT id FROM `label` WHERE org_id = ?)))", org.ID, org.ID).
+		Find(&issues); err != nil {
+		return fmt.Errorf("find issues: %v", err)
+	}
+
+	for _, issue := range issues {
+		if err := issue.getLabels(e); err != nil {
+			return fmt.Errorf("get labels: %v", err)
+		}
+		// NOTE: deleteIssueLabel slices issue.Labels, so we need to create another slice to be unaffected.
+		labels := make([]*Label, len(issue.Labels))
+		copy(labels, issue.Labels)
+		for _, label := range labels {
+			if label.OrgID != org.ID {
+				continue
+			}
+			if err := deleteIssueLabel(e, issue, label); err != nil {
+				return fmt.Errorf("delete issue label: %v", err)
+			}
+		}
+
+		if issue.MilestoneID == 0 {
+			continue
+		}
+		m, err := getAvailableMilestoneByRepoID(e, repoID, issue.MilestoneID)
+		if err != nil {
+			if IsErrMilestoneNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("get milestone: %v", err)
+		} else if m.OrgID != org.ID {
+			continue
+		}
+		oldMilestoneID := issue.MilestoneID
+		issue.MilestoneID = 0
+		if err = changeMilestoneAssign(e, issue, oldMilestoneID); err != nil {
+			return fmt.Errorf("change milestone assign: %v", err)
+		}
+	}
+	return nil
+}
+
 // CreateOrganization creates record of a new organization.
 func CreateOrganization(org, owner *User) (err error) {
 	if err = isUsernameAllowed(org.Name); err != nil {
@@ -123,6 +171,9 @@ func CreateOrganization(org, owner *User) (err error) {
 	}
 	org.UseCustomAvatar = true
 	org.MaxRepoCreation = -1
+	org.MaxRepoSize = -1
+	org.MaxLFSSize = -1
+	org.MaxAttachmentSize = -1
 	org.NumTeams = 1
 	org.NumMembers = 1
 
@@ -231,9 +282,14 @@ func DeleteOrganization(org *User) error {
 		&Team{OrgID: org.ID},
 		&OrgUser{OrgID: org.ID},
 		&TeamUser{OrgID: org.ID},
+		&Label{OrgID: org.ID},
+		&Milestone{OrgID: org.ID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
+	if err = deleteProjects(sess, org.ID, 0); err != nil {
+		return err
+	}
 	return sess.Commit()
 }
 
@@ -339,6 +339,41 @@ func GetTeamOfOrgByName(orgID int64, name string) (*Team, error) {
 	return getTeamOfOrgByName(x, orgID, name)
 }
 
+// getMentionedTeamMembers returns members of teams mentioned in the form of
+// "<org>/<team>", where mentions of teams that do not exist are ignored.
+func getMentionedTeamMembers(e Engine, teamMentions []string) ([]*User, error) {
+	var members []*User
+	for _, mention := range teamMentions {
+		orgName, teamName, ok := strings.Cut(mention, "/")
+		if !ok {
+			continue
+		}
+
+		org := new(User)
+		has, err := e.Where("lower_name = ? AND type = ?", strings.ToLower(orgName), UserTypeOrganization).Get(org)
+		if err != nil {
+			return nil, fmt.Errorf("get organization %q: %v", orgName, err)
+		} else if !has {
+			continue
+		}
+
+		t, err := getTeamOfOrgByName(e, org.ID, teamName)
+		if err != nil {
+			if IsErrTeamNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("getTeamOfOrgByName [org_id: %d, name: %s]: %v", org.ID, teamName, err)
+		}
+
+		teamMembers, err := getTeamMembers(e, t.ID)
+		if err != nil {
+			return nil, fmt.Errorf("getTeamMembers [team_id: %d]: %v", t.ID, err)
+		}
+		members = append(members, teamMembers...)
+	}
+	return members, nil
+}
+
 func getTeamByID(e Engine, teamID int64) (*Team, error) {
 	t := new(Team)
 	has, err := e.ID(teamID).Get(t)
@@ -0,0 +1,821 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	dberrors "gogs.io/gogs/internal/database/errors"
+	"gogs.io/gogs/internal/errutil"
+)
+
+// Automations of project columns, cards of issues and pull requests are moved
+// to the column with the automation of the event that happened to them.
+const (
+	ProjectColumnAutomationNone     = ""
+	ProjectColumnAutomationClosed   = "closed"
+	ProjectColumnAutomationReopened = "reopened"
+	ProjectColumnAutomationMerged   = "merged"
+)
+
+// IsValidProjectColumnAutomation returns true if the given automation is one
+// of ProjectColumnAutomation*.
+func IsValidProjectColumnAutomation(automation string) bool {
+	switch automation {
+	case ProjectColumnAutomationNone,
+		ProjectColumnAutomationClosed,
+		ProjectColumnAutomationReopened,
+		ProjectColumnAutomationMerged:
+		return true
+	}
+	return false
+}
+
+// Project is a kanban board of a repository, or of an organization when the
+// repository ID is zero.
+type Project struct {
+	ID          int64  `gorm:"primaryKey"`
+	OwnerID     int64  `gorm:"index;not null"` // Zero for repository projects
+	RepoID      int64  `gorm:"index;not null"` // Zero for organization projects
+	Name        string `gorm:"type:VARCHAR(255);not null"`
+	Description string `gorm:"type:TEXT"`
+	IsClosed    bool   `gorm:"not null"`
+	CreatorID   int64  `gorm:"not null"`
+	CreatedUnix int64
+	UpdatedUnix int64
+}
+
+// BeforeCreate implements the GORM create hook.
+func (p *Project) BeforeCreate(tx *gorm.DB) error {
+	if p.CreatedUnix == 0 {
+		p.CreatedUnix = tx.NowFunc().Unix()
+	}
+	if p.UpdatedUnix == 0 {
+		p.UpdatedUnix = p.CreatedUnix
+	}
+	return nil
+}
+
+// IsOrgProject returns true if the project belongs to an organization.
+func (p *Project) IsOrgProject() bool {
+	return p.RepoID == 0
+}
+
+// Created returns the time when the project is created.
+func (p *Project) Created() time.Time {
+	return time.Unix(p.CreatedUnix, 0).Local()
+}
+
+// Updated returns the time when the project is last updated.
+func (p *Project) Updated() time.Time {
+	return time.Unix(p.UpdatedUnix, 0).Local()
+}
+
+// ProjectColumn is a column of a project.
+type ProjectColumn struct {
+	ID          int64  `gorm:"primaryKey"`
+	ProjectID   int64  `gorm:"index;not null"`
+	Name        string `gorm:"type:VARCHAR(255);not null"`
+	Position    int    `gorm:"not null"`
+	Automation  string `gorm:"type:VARCHAR(16);not null"` // One of ProjectColumnAutomation*
+	CreatedUnix int64
+
+	Cards []*ProjectCard `gorm:"-" json:"-"`
+}
+
+// BeforeCreate implements the GORM create hook.
+func (c *ProjectColumn) BeforeCreate(tx *gorm.DB) error {
+	if c.CreatedUnix == 0 {
+		c.CreatedUnix = tx.NowFunc().Unix()
+	}
+	return nil
+}
+
+// ProjectCard is a card in a column of a project, which is either linked to an
+// issue or a pull request, or a free-text note.
+type ProjectCard struct {
+	ID          int64  `gorm:"primaryKey"`
+	ProjectID   int64  `gorm:"index;not null"`
+	ColumnID    int64  `gorm:"index;not null"`
+	IssueID     int64  `gorm:"index;not null"` // Zero for notes
+	Note        string `gorm:"type:TEXT"`
+	Position    int    `gorm:"not null"`
+	CreatorID   int64  `gorm:"not null"`
+	CreatedUnix int64
+	UpdatedUnix int64
+
+	Issue *Issue `gorm:"-" json:"-"`
+}
+
+// BeforeCreate implements the GORM create hook.
+func (c *ProjectCard) BeforeCreate(tx *gorm.DB) error {
+	if c.CreatedUnix == 0 {
+		c.CreatedUnix = tx.NowFunc().Unix()
+	}
+	if c.UpdatedUnix == 0 {
+		c.UpdatedUnix = c.CreatedUnix
+	}
+	return nil
+}
+
+// IsNote returns true if the card is a free-text note.
+func (c *ProjectCard) IsNote() bool {
+	return c.IssueID == 0
+}
+
+// ProjectsStore is the storage layer for projects, columns and cards.
+type ProjectsStore struct {
+	db *gorm.DB
+}
+
+func newProjectsStore(db *gorm.DB) *ProjectsStore {
+	return &ProjectsStore{db: db}
+}
+
+var _ errutil.NotFound = (*ErrProjectNotExist)(nil)
+
+type ErrProjectNotExist struct {
+	args errutil.Args
+}
+
+func IsErrProjectNotExist(err error) bool {
+	return errors.As(err, &ErrProjectNotExist{})
+}
+
+func (err ErrProjectNotExist) Error() string {
+	return fmt.Sprintf("project does not exist: %v", err.args)
+}
+
+func (ErrProjectNotExist) NotFound() bool {
+	return true
+}
+
+var _ errutil.NotFound = (*ErrProjectColumnNotExist)(nil)
+
+type ErrProjectColumnNotExist struct {
+	args errutil.Args
+}
+
+func IsErrProjectColumnNotExist(err error) bool {
+	return errors.As(err, &ErrProjectColumnNotExist{})
+}
+
+func (err ErrProjectColumnNotExist) Error() string {
+	return fmt.Sprintf("project column does not exist: %v", err.args)
+}
+
+func (ErrProjectColumnNotExist) NotFound() bool {
+	return true
+}
+
+var _ errutil.NotFound = (*ErrProjectCardNotExist)(nil)
+
+type ErrProjectCardNotExist struct {
+	args errutil.Args
+}
+
+func IsErrProjectCardNotExist(err error) bool {
+	return errors.As(err, &ErrProjectCardNotExist{})
+}
+
+func (err ErrProjectCardNotExist) Error() string {
+	return fmt.Sprintf("project card does not exist: %v", err.args)
+}
+
+func (ErrProjectCardNotExist) NotFound() bool {
+	return true
+}
+
+type ErrProjectCardAlreadyExist struct {
+	args errutil.Args
+}
+
+func IsErrProjectCardAlreadyExist(err error) bool {
+	return errors.As(err, &ErrProjectCardAlreadyExist{})
+}
+
+func (err ErrProjectCardAlreadyExist) Error() string {
+	return fmt.Sprintf("issue is already in the project: %v", err.args)
+}
+
+type ErrProjectColumnInvalidAutomation struct {
+	args errutil.Args
+}
+
+func IsErrProjectColumnInvalidAutomation(err error) bool {
+	return errors.As(err, &ErrProjectColumnInvalidAutomation{})
+}
+
+func (err ErrProjectColumnInvalidAutomation) Error() string {
+	return fmt.Sprintf("invalid project column automation: %v", err.args)
+}
+
+// CreateProjectOptions contains options for creating a project.
+type CreateProjectOptions struct {
+	// Exactly one of OwnerID and RepoID must be set.
+	OwnerID     int64
+	RepoID      int64
+	Name        string
+	Description string
+	CreatorID   int64
+	// DefaultColumns indicates whether to create "To do", "In progress" and
+	// "Done" columns, where closed issues and merged pull requests are moved to
+	// "Done".
+	DefaultColumns bool
+}
+
+// Create creates a new project with given options.
+func (s *ProjectsStore) Create(ctx context.Context, opts CreateProjectOptions) (*Project, error) {
+	p := &Project{
+		OwnerID:     opts.OwnerID,
+		RepoID:      opts.RepoID,
+		Name:        opts.Name,
+		Description: opts.Description,
+		CreatorID:   opts.CreatorID,
+	}
+	if opts.RepoID > 0 {
+		p.OwnerID = 0
+	}
+
+	return p, s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(p).Error
+		if err != nil {
+			return errors.Wrap(err, "create project")
+		}
+		if !opts.DefaultColumns {
+			return nil
+		}
+
+		columns := []*ProjectColumn{
+			{ProjectID: p.ID, Name: "To do", Position: 0},
+			{ProjectID: p.ID, Name: "In progress", Position: 1},
+			{ProjectID: p.ID, Name: "Done", Position: 2, Automation: ProjectColumnAutomationClosed},
+		}
+		return errors.Wrap(tx.Create(&columns).Error, "create columns")
+	})
+}
+
+// GetByID returns the project with given ID. It returns ErrProjectNotExist
+// when not found.
+func (s *ProjectsStore) GetByID(ctx context.Context, id int64) (*Project, error) {
+	p := new(Project)
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(p).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectNotExist{args: errutil.Args{"projectID": id}}
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// ListProjectsOptions contains options for listing projects.
+type ListProjectsOptions struct {
+	// Projects of the organization are listed when RepoID is zero.
+	OwnerID  int64
+	RepoID   int64
+	IsClosed bool
+}
+
+func (s *ProjectsStore) scopedQuery(ctx context.Context, opts ListProjectsOptions) *gorm.DB {
+	tx := s.db.WithContext(ctx).Model(&Project{}).Where("is_closed = ?", opts.IsClosed)
+	if opts.RepoID > 0 {
+		return tx.Where("repo_id = ?", opts.RepoID)
+	}
+	return tx.Where("owner_id = ? AND repo_id = 0", opts.OwnerID)
+}
+
+// List returns projects with given options, the most recently created first.
+func (s *ProjectsStore) List(ctx context.Context, opts ListProjectsOptions) ([]*Project, error) {
+	var projects []*Project
+	return projects, s.scopedQuery(ctx, opts).Order("id DESC").Find(&projects).Error
+}
+
+// Count returns the number of projects with given options.
+func (s *ProjectsStore) Count(ctx context.Context, opts ListProjectsOptions) (int64, error) {
+	var count int64
+	return count, s.scopedQuery(ctx, opts).Count(&count).Error
+}
+
+// UpdateProjectOptions contains optional options for updating a project.
+type UpdateProjectOptions struct {
+	Name        *string
+	Description *string
+	IsClosed    *bool
+}
+
+// Update updates the project with given options.
+func (s *ProjectsStore) Update(ctx context.Context, id int64, opts UpdateProjectOptions) error {
+	updates := map[string]any{
+		"updated_unix": s.db.NowFunc().Unix(),
+	}
+	if opts.Name != nil {
+		updates["name"] = *opts.Name
+	}
+	if opts.Description != nil {
+		updates["description"] = *opts.Description
+	}
+	if opts.IsClosed != nil {
+		updates["is_closed"] = *opts.IsClosed
+	}
+	return s.db.WithContext(ctx).Model(&Project{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Delete deletes the project along with its columns and cards.
+func (s *ProjectsStore) Delete(ctx context.Context, id int64) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, table := range []any{&ProjectCard{}, &ProjectColumn{}} {
+			err := tx.Where("project_id = ?", id).Delete(table).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Where("id = ?", id).Delete(&Project{}).Error
+	})
+}
+
+// touch updates the updated time of the project.
+func (s *ProjectsStore) touch(tx *gorm.DB, projectID int64) error {
+	return tx.Model(&Project{}).Where("id = ?", projectID).Update("updated_unix", tx.NowFunc().Unix()).Error
+}
+
+// reorder moves the item of the model to the position among other items in
+// the scope, and renumbers positions of all items in the scope.
+func reorder(tx *gorm.DB, model any, scope string, scopeArg, id int64, position int) error {
+	var ids []int64
+	err := tx.Model(model).
+		Where(scope+" = ? AND id != ?", scopeArg, id).
+		Order("position ASC, id ASC").
+		Pluck("id", &ids).
+		Error
+	if err != nil {
+		return errors.Wrap(err, "list items")
+	}
+
+	if position < 0 {
+		position = 0
+	} else if position > len(ids) {
+		position = len(ids)
+	}
+	ids = append(ids[:position], append([]int64{id}, ids[position:]...)...)
+	for i, id := range ids {
+		err = tx.Model(model).Where("id = ?", id).Update("position", i).Error
+		if err != nil {
+			return errors.Wrap(err, "update position")
+		}
+	}
+	return nil
+}
+
+// CreateColumn creates a new column at the end of the project. It returns
+// ErrProjectColumnInvalidAutomation when the automation is not valid.
+func (s *ProjectsStore) CreateColumn(ctx context.Context, projectID int64, name, automation string) (*ProjectColumn, error) {
+	if !IsValidProjectColumnAutomation(automation) {
+		return nil, ErrProjectColumnInvalidAutomation{args: errutil.Args{"automation": automation}}
+	}
+
+	column := &ProjectColumn{
+		ProjectID:  projectID,
+		Name:       name,
+		Automation: automation,
+	}
+	return column, s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		err := tx.Model(&ProjectColumn{}).Where("project_id = ?", projectID).Count(&count).Error
+		if err != nil {
+			return errors.Wrap(err, "count columns")
+		}
+
+		column.Position = int(count)
+		err = tx.Create(column).Error
+		if err != nil {
+			return errors.Wrap(err, "create")
+		}
+		return s.touch(tx, projectID)
+	})
+}
+
+// GetColumnByID returns the column with given ID in the project. It returns
+// ErrProjectColumnNotExist when not found.
+func (s *ProjectsStore) GetColumnByID(ctx context.Context, projectID, id int64) (*ProjectColumn, error) {
+	column := new(ProjectColumn)
+	err := s.db.WithContext(ctx).Where("id = ? AND project_id = ?", id, projectID).First(column).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectColumnNotExist{args: errutil.Args{"projectID": projectID, "columnID": id}}
+		}
+		return nil, err
+	}
+	return column, nil
+}
+
+// ListColumns returns all columns of the project in the order of positions.
+func (s *ProjectsStore) ListColumns(ctx context.Context, projectID int64) ([]*ProjectColumn, error) {
+	var columns []*ProjectColumn
+	return columns, s.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Order("position ASC, id ASC").
+		Find(&columns).
+		Error
+}
+
+// UpdateProjectColumnOptions contains optional options for updating a column.
+type UpdateProjectColumnOptions struct {
+	Name       *string
+	Automation *string
+	// Position moves the column to the 0-based position among all columns of
+	// the project.
+	Position *int
+}
+
+// UpdateColumn updates the column with given options. It returns
+// ErrProjectColumnInvalidAutomation when the automation is not valid.
+func (s *ProjectsStore) UpdateColumn(ctx context.Context, column *ProjectColumn, opts UpdateProjectColumnOptions) error {
+	updates := make(map[string]any)
+	if opts.Name != nil {
+		updates["name"] = *opts.Name
+	}
+	if opts.Automation != nil {
+		if !IsValidProjectColumnAutomation(*opts.Automation) {
+			return ErrProjectColumnInvalidAutomation{args: errutil.Args{"automation": *opts.Automation}}
+		}
+		updates["automation"] = *opts.Automation
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			err := tx.Model(&ProjectColumn{}).Where("id = ?", column.ID).Updates(updates).Error
+			if err != nil {
+				return errors.Wrap(err, "update")
+			}
+		}
+		if opts.Position != nil {
+			err := reorder(tx, &ProjectColumn{}, "project_id", column.ProjectID, column.ID, *opts.Position)
+			if err != nil {
+				return errors.Wrap(err, "reorder")
+			}
+		}
+		return s.touch(tx, column.ProjectID)
+	})
+}
+
+// DeleteColumn deletes the column along with its cards.
+func (s *ProjectsStore) DeleteColumn(ctx context.Context, column *ProjectColumn) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("column_id = ?", column.ID).Delete(&ProjectCard{}).Error
+		if err != nil {
+			return errors.Wrap(err, "delete cards")
+		}
+		err = tx.Where("id = ?", column.ID).Delete(&ProjectColumn{}).Error
+		if err != nil {
+			return errors.Wrap(err, "delete")
+		}
+		return s.touch(tx, column.ProjectID)
+	})
+}
+
+// CreateProjectCardOptions contains options for creating a card.
+type CreateProjectCardOptions struct {
+	ColumnID int64
+	// Exactly one of IssueID and Note must be set.
+	IssueID   int64
+	Note      string
+	CreatorID int64
+}
+
+// CreateCard creates a new card at the end of the column. It returns
+// ErrProjectCardAlreadyExist when the issue is already in the project.
+func (s *ProjectsStore) CreateCard(ctx context.Context, column *ProjectColumn, opts CreateProjectCardOptions) (*ProjectCard, error) {
+	card := &ProjectCard{
+		ProjectID: column.ProjectID,
+		ColumnID:  column.ID,
+		IssueID:   opts.IssueID,
+		Note:      opts.Note,
+		CreatorID: opts.CreatorID,
+	}
+	if card.IssueID > 0 {
+		card.Note = ""
+	}
+
+	return card, s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if card.IssueID > 0 {
+			err := tx.Where("project_id = ? AND issue_id = ?", card.ProjectID, card.IssueID).First(&ProjectCard{}).Error
+			if err == nil {
+				return ErrProjectCardAlreadyExist{args: errutil.Args{"projectID": card.ProjectID, "issueID": card.IssueID}}
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.Wrap(err, "check existence")
+			}
+		}
+
+		var count int64
+		err := tx.Model(&ProjectCard{}).Where("column_id = ?", card.ColumnID).Count(&count).Error
+		if err != nil {
+			return errors.Wrap(err, "count cards")
+		}
+
+		card.Position = int(count)
+		err = tx.Create(card).Error
+		if err != nil {
+			return errors.Wrap(err, "create")
+		}
+		return s.touch(tx, card.ProjectID)
+	})
+}
+
+// GetCardByID returns the card with given ID in the project. It returns
+// ErrProjectCardNotExist when not found.
+func (s *ProjectsStore) GetCardByID(ctx context.Context, projectID, id int64) (*ProjectCard, error) {
+	card := new(ProjectCard)
+	err := s.db.WithContext(ctx).Where("id = ? AND project_id = ?", id, projectID).First(card).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProjectCardNotExist{args: errutil.Args{"projectID": projectID, "cardID": id}}
+		}
+		return nil, err
+	}
+	return card, nil
+}
+
+// ListCards returns all cards of the project in the order of positions within
+// their columns.
+func (s *ProjectsStore) ListCards(ctx context.Context, projectID int64) ([]*ProjectCard, error) {
+	var cards []*ProjectCard
+	return cards, s.db.WithContext(ctx).
+		Where("project_id = ?", projectID).
+		Order("position ASC, id ASC").
+		Find(&cards).
+		Error
+}
+
+// UpdateCardNote updates the note of the card.
+func (s *ProjectsStore) UpdateCardNote(ctx context.Context, card *ProjectCard, note string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&ProjectCard{}).
+			Where("id = ?", card.ID).
+			Updates(map[string]any{
+				"note":         note,
+				"updated_unix": tx.NowFunc().Unix(),
+			}).
+			Error
+		if err != nil {
+			return errors.Wrap(err, "update")
+		}
+		return s.touch(tx, card.ProjectID)
+	})
+}
+
+// MoveCard moves the card to the 0-based position of the column, which must be
+// in the same project.
+func (s *ProjectsStore) MoveCard(ctx context.Context, card *ProjectCard, columnID int64, position int) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&ProjectCard{}).
+			Where("id = ?", card.ID).
+			Updates(map[string]any{
+				"column_id":    columnID,
+				"updated_unix": tx.NowFunc().Unix(),
+			}).
+			Error
+		if err != nil {
+			return errors.Wrap(err, "update column")
+		}
+
+		err = reorder(tx, &ProjectCard{}, "column_id", columnID, card.ID, position)
+		if err != nil {
+			return errors.Wrap(err, "reorder")
+		}
+		return s.touch(tx, card.ProjectID)
+	})
+}
+
+// DeleteCard deletes the card.
+func (s *ProjectsStore) DeleteCard(ctx context.Context, card *ProjectCard) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("id = ?", card.ID).Delete(&ProjectCard{}).Error
+		if err != nil {
+			return errors.Wrap(err, "delete")
+		}
+		return s.touch(tx, card.ProjectID)
+	})
+}
+
+// MoveIssueCards moves cards of the issue in all projects to the top of the
+// column with the first automation in the given order that the project has.
+func (s *ProjectsStore) MoveIssueCards(ctx context.Context, issueID int64, automations ...string) error {
+	var cards []*ProjectCard
+	err := s.db.WithContext(ctx).Where("issue_id = ?", issueID).Find(&cards).Error
+	if err != nil {
+		return errors.Wrap(err, "list cards")
+	}
+
+	for _, card := range cards {
+		var columns []*ProjectColumn
+		err = s.db.WithContext(ctx).
+			Where("project_id = ? AND automation IN ?", card.ProjectID, automations).
+			Order("position ASC, id ASC").
+			Find(&columns).
+			Error
+		if err != nil {
+			return errors.Wrap(err, "list columns")
+		}
+
+		var target *ProjectColumn
+		for _, automation := range automations {
+			for _, column := range columns {
+				if column.Automation == automation {
+					target = column
+					break
+				}
+			}
+			if target != nil {
+				break
+			}
+		}
+		if target == nil || target.ID == card.ColumnID {
+			continue
+		}
+
+		err = s.MoveCard(ctx, card, target.ID, 0)
+		if err != nil {
+			return errors.Wrapf(err, "move card %d", card.ID)
+		}
+	}
+	return nil
+}
+
+// deleteProjects deletes projects of the repository, or of the organization
+// when repoID is zero, along with their columns and cards.
+func deleteProjects(e Engine, ownerID, repoID int64) error {
+	cond, arg := "owner_id = ? AND repo_id = 0", ownerID
+	if repoID > 0 {
+		cond, arg = "repo_id = ?", repoID
+	}
+
+	for _, table := range []string{"project_card", "project_column"} {
+		if _, err := e.Exec("DELETE FROM `"+table+"` WHERE project_id IN (SELECT id FROM `project` WHERE "+cond+")", arg); err != nil {
+			return fmt.Errorf("delete from %q: %v", table, err)
+		}
+	}
+	if _, err := e.Exec("DELETE FROM `project` WHERE "+cond, arg); err != nil {
+		return fmt.Errorf("delete projects: %v", err)
+	}
+	return nil
+}
+
+// GetProjectIssueByRef returns the issue or pull request by given reference
+// that the user can add to the project. References are in the form of
+// "#index" or "index" for repository projects, and "owner/repo#index" for
+// organization projects where the repository must belong to the organization.
+// It returns ErrIssueNotExist when the issue does not exist or the user cannot
+// read it.
+func GetProjectIssueByRef(ctx context.Context, user *User, p *Project, ref string) (*Issue, error) {
+	ref = strings.TrimSpace(ref)
+	notExist := ErrIssueNotExist{args: map[string]any{"projectID": p.ID, "ref": ref}}
+
+	repoRef, indexStr := "", ref
+	if i := strings.LastIndex(ref, "#"); i > -1 {
+		repoRef, indexStr = strings.TrimSpace(ref[:i]), ref[i+1:]
+	}
+	index, err := strconv.ParseInt(indexStr, 10, 64)
+	if err != nil {
+		return nil, notExist
+	}
+
+	var repo *Repository
+	if repoRef == "" {
+		if p.IsOrgProject() {
+			return nil, notExist
+		}
+		repo, err = GetRepositoryByID(p.RepoID)
+	} else {
+		repo, err = GetRepositoryByRef(repoRef)
+	}
+	if err != nil {
+		if errutil.IsNotFound(err) || dberrors.IsInvalidRepoReference(err) {
+			return nil, notExist
+		}
+		return nil, err
+	}
+
+	if (p.IsOrgProject() && repo.OwnerID != p.OwnerID) || (!p.IsOrgProject() && repo.ID != p.RepoID) {
+		return nil, notExist
+	}
+	if !Handle.Permissions().Authorize(ctx, user.ID, repo.ID, AccessModeRead,
+		AccessModeOptions{
+			OwnerID: repo.OwnerID,
+			Private: repo.IsPrivate,
+		},
+	) {
+		return nil, notExist
+	}
+
+	issue, err := GetIssueByIndex(repo.ID, index)
+	if err != nil {
+		if IsErrIssueNotExist(err) {
+			return nil, notExist
+		}
+		return nil, err
+	}
+	return issue, nil
+}
+
+// LoadProjectCardIssues loads issues of the cards along with their
+// repositories, labels and assignees, and returns cards that are visible to the
+// user, i.e. notes and cards of issues that the user can read.
+func LoadProjectCardIssues(ctx context.Context, user *User, cards []*ProjectCard) ([]*ProjectCard, error) {
+	var userID int64
+	if user != nil {
+		userID = user.ID
+	}
+
+	repos := make(map[int64]*Repository)
+	canRead := make(map[int64]bool)
+	visible := make([]*ProjectCard, 0, len(cards))
+	for _, card := range cards {
+		if card.IsNote() {
+			visible = append(visible, card)
+			continue
+		}
+
+		issue, err := getRawIssueByID(x, card.IssueID)
+		if err != nil {
+			if IsErrIssueNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("get issue [issue_id: %d]: %v", card.IssueID, err)
+		}
+
+		if _, ok := canRead[issue.RepoID]; !ok {
+			repo, err := GetRepositoryByID(issue.RepoID)
+			if err != nil {
+				return nil, fmt.Errorf("get repository [repo_id: %d]: %v", issue.RepoID, err)
+			}
+			repos[repo.ID] = repo
+			canRead[repo.ID] = Handle.Permissions().Authorize(ctx, userID, repo.ID, AccessModeRead,
+				AccessModeOptions{
+					OwnerID: repo.OwnerID,
+					Private: repo.IsPrivate,
+				},
+			)
+		}
+		if !canRead[issue.RepoID] {
+			continue
+		}
+
+		issue.Repo = repos[issue.RepoID]
+		if issue.Labels, err = GetLabelsByIssueID(issue.ID); err != nil {
+			return nil, fmt.Errorf("get labels [issue_id: %d]: %v", issue.ID, err)
+		}
+		if issue.Assignees, err = GetAssigneesByIssueID(issue.ID); err != nil {
+			return nil, fmt.Errorf("get assignees [issue_id: %d]: %v", issue.ID, err)
+		}
+		card.Issue = issue
+		visible = append(visible, card)
+	}
+	return visible, nil
+}
+
+// ProjectAccessMode returns the access mode of the user to the project.
+// Repository projects follow access to the repository, and organization
+// projects are only accessible to members of the organization, who can all
+// write to them.
+func ProjectAccessMode(ctx context.Context, user *User, p *Project) (AccessMode, error) {
+	if user != nil && user.IsAdmin {
+		return AccessModeOwner, nil
+	}
+	var userID int64
+	if user != nil {
+		userID = user.ID
+	}
+
+	if !p.IsOrgProject() {
+		repo, err := GetRepositoryByID(p.RepoID)
+		if err != nil {
+			return AccessModeNone, fmt.Errorf("get repository: %v", err)
+		}
+		return Handle.Permissions().AccessMode(ctx, userID, repo.ID,
+			AccessModeOptions{
+				OwnerID: repo.OwnerID,
+				Private: repo.IsPrivate,
+			},
+		), nil
+	}
+
+	switch {
+	case userID <= 0:
+		return AccessModeNone, nil
+	case IsOrganizationOwner(p.OwnerID, userID):
+		return AccessModeOwner, nil
+	case IsOrganizationMember(p.OwnerID, userID):
+		return AccessModeWrite, nil
+	}
+	return AccessModeNone, nil
+}
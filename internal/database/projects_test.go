@@ -0,0 +1,272 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+func TestProjects(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	ctx := context.Background()
+	s := &ProjectsStore{
+		db: newTestDB(t, "ProjectsStore"),
+	}
+
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, s *ProjectsStore)
+	}{
+		{"Create", projectsCreate},
+		{"List", projectsList},
+		{"Delete", projectsDelete},
+		{"UpdateColumn", projectsUpdateColumn},
+		{"CreateCard", projectsCreateCard},
+		{"MoveCard", projectsMoveCard},
+		{"MoveIssueCards", projectsMoveIssueCards},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				err := clearTables(t, s.db)
+				require.NoError(t, err)
+			})
+			tc.test(t, ctx, s)
+		})
+		if t.Failed() {
+			break
+		}
+	}
+}
+
+func columnNames(t *testing.T, ctx context.Context, s *ProjectsStore, projectID int64) []string {
+	columns, err := s.ListColumns(ctx, projectID)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(columns))
+	for _, column := range columns {
+		names = append(names, column.Name)
+	}
+	return names
+}
+
+func cardIDs(t *testing.T, ctx context.Context, s *ProjectsStore, projectID, columnID int64) []int64 {
+	cards, err := s.ListCards(ctx, projectID)
+	require.NoError(t, err)
+
+	ids := make([]int64, 0, len(cards))
+	for _, card := range cards {
+		if card.ColumnID == columnID {
+			ids = append(ids, card.ID)
+		}
+	}
+	return ids
+}
+
+func projectsCreate(t *testing.T, ctx context.Context, s *ProjectsStore) {
+	p, err := s.Create(ctx, CreateProjectOptions{
+		OwnerID:        2,
+		RepoID:         1,
+		Name:           "Roadmap",
+		CreatorID:      1,
+		DefaultColumns: true,
+	})
+	require.NoError(t, err)
+	assert.Zero(t, p.OwnerID)
+	assert.NotZero(t, p.CreatedUnix)
+
+	got, err := s.GetByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Roadmap", got.Name)
+	assert.False(t, got.IsOrgProject())
+
+	columns, err := s.ListColumns(ctx, p.ID)
+	require.NoError(t, err)
+	require.Len(t, columns, 3)
+	assert.Equal(t, "Done", columns[2].Name)
+	assert.Equal(t, ProjectColumnAutomationClosed, columns[2].Automation)
+
+	_, err = s.GetByID(ctx, 404)
+	wantErr := ErrProjectNotExist{args: errutil.Args{"projectID": int64(404)}}
+	assert.Equal(t, wantErr, err)
+}
+
+func projectsList(t *testing.T, ctx context.Context, s *ProjectsStore) {
+	repoProject, err := s.Create(ctx, CreateProjectOptions{RepoID: 1, Name: "Repo", CreatorID: 1})
+	require.NoError(t, err)
+	orgProject1, err := s.Create(ctx, CreateProjectOptions{OwnerID: 2, Name: "Org 1", CreatorID: 1})
+	require.NoError(t, err)
+	orgProject2, err := s.Create(ctx, CreateProjectOptions{OwnerID: 2, Name: "Org 2", CreatorID: 1})
+	require.NoError(t, err)
+
+	closed := true
+	err = s.Update(ctx, orgProject1.ID, UpdateProjectOptions{IsClosed: &closed})
+	require.NoError(t, err)
+
+	projects, err := s.List(ctx, ListProjectsOptions{RepoID: 1})
+	require.NoError(t, err)
+	require.Len(t, projects, 1)
+	assert.Equal(t, repoProject.ID, projects[0].ID)
+
+	projects, err = s.List(ctx, ListProjectsOptions{OwnerID: 2})
+	require.NoError(t, err)
+	require.Len(t, projects, 1)
+	assert.Equal(t, orgProject2.ID, projects[0].ID)
+
+	count, err := s.Count(ctx, ListProjectsOptions{OwnerID: 2, IsClosed: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func projectsDelete(t *testing.T, ctx context.Context, s *ProjectsStore) {
+	p, err := s.Create(ctx, CreateProjectOptions{RepoID: 1, Name: "Roadmap", CreatorID: 1, DefaultColumns: true})
+	require.NoError(t, err)
+	column, err := s.CreateColumn(ctx, p.ID, "Backlog", ProjectColumnAutomationNone)
+	require.NoError(t, err)
+	_, err = s.CreateCard(ctx, column, CreateProjectCardOptions{Note: "Plan", CreatorID: 1})
+	require.NoError(t, err)
+
+	err = s.Delete(ctx, p.ID)
+	require.NoError(t, err)
+
+	_, err = s.GetByID(ctx, p.ID)
+	assert.True(t, IsErrProjectNotExist(err))
+	assert.Empty(t, columnNames(t, ctx, s, p.ID))
+	assert.Empty(t, cardIDs(t, ctx, s, p.ID, column.ID))
+}
+
+func projectsUpdateColumn(t *testing.T, ctx context.Context, s *ProjectsStore) {
+	p, err := s.Create(ctx, CreateProjectOptions{RepoID: 1, Name: "Roadmap", CreatorID: 1, DefaultColumns: true})
+	require.NoError(t, err)
+
+	_, err = s.CreateColumn(ctx, p.ID, "Review", "shipped")
+	wantErr := ErrProjectColumnInvalidAutomation{args: errutil.Args{"automation": "shipped"}}
+	assert.Equal(t, wantErr, err)
+
+	column, err := s.CreateColumn(ctx, p.ID, "Review", ProjectColumnAutomationNone)
+	require.NoError(t, err)
+	assert.Equal(t, 3, column.Position)
+
+	name := "Code review"
+	automation := ProjectColumnAutomationReopened
+	position := 1
+	err = s.UpdateColumn(ctx, column, UpdateProjectColumnOptions{
+		Name:       &name,
+		Automation: &automation,
+		Position:   &position,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"To do", "Code review", "In progress", "Done"}, columnNames(t, ctx, s, p.ID))
+
+	column, err = s.GetColumnByID(ctx, p.ID, column.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ProjectColumnAutomationReopened, column.Automation)
+
+	err = s.DeleteColumn(ctx, column)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"To do", "In progress", "Done"}, columnNames(t, ctx, s, p.ID))
+}
+
+func projectsCreateCard(t *testing.T, ctx context.Context, s *ProjectsStore) {
+	p, err := s.Create(ctx, CreateProjectOptions{RepoID: 1, Name: "Roadmap", CreatorID: 1})
+	require.NoError(t, err)
+	column, err := s.CreateColumn(ctx, p.ID, "To do", ProjectColumnAutomationNone)
+	require.NoError(t, err)
+
+	card, err := s.CreateCard(ctx, column, CreateProjectCardOptions{IssueID: 1, Note: "ignored", CreatorID: 1})
+	require.NoError(t, err)
+	assert.Empty(t, card.Note)
+	assert.False(t, card.IsNote())
+
+	_, err = s.CreateCard(ctx, column, CreateProjectCardOptions{IssueID: 1, CreatorID: 1})
+	wantErr := ErrProjectCardAlreadyExist{args: errutil.Args{"projectID": p.ID, "issueID": int64(1)}}
+	assert.Equal(t, wantErr, err)
+
+	note, err := s.CreateCard(ctx, column, CreateProjectCardOptions{Note: "Write docs", CreatorID: 1})
+	require.NoError(t, err)
+	assert.True(t, note.IsNote())
+	assert.Equal(t, 1, note.Position)
+
+	err = s.UpdateCardNote(ctx, note, "Write more docs")
+	require.NoError(t, err)
+	note, err = s.GetCardByID(ctx, p.ID, note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Write more docs", note.Note)
+
+	err = s.DeleteCard(ctx, note)
+	require.NoError(t, err)
+	_, err = s.GetCardByID(ctx, p.ID, note.ID)
+	assert.True(t, IsErrProjectCardNotExist(err))
+}
+
+func projectsMoveCard(t *testing.T, ctx context.Context, s *ProjectsStore) {
+	p, err := s.Create(ctx, CreateProjectOptions{RepoID: 1, Name: "Roadmap", CreatorID: 1})
+	require.NoError(t, err)
+	todo, err := s.CreateColumn(ctx, p.ID, "To do", ProjectColumnAutomationNone)
+	require.NoError(t, err)
+	done, err := s.CreateColumn(ctx, p.ID, "Done", ProjectColumnAutomationNone)
+	require.NoError(t, err)
+
+	var cards []*ProjectCard
+	for _, note := range []string{"A", "B", "C"} {
+		card, err := s.CreateCard(ctx, todo, CreateProjectCardOptions{Note: note, CreatorID: 1})
+		require.NoError(t, err)
+		cards = append(cards, card)
+	}
+
+	// Reorder within the same column
+	err = s.MoveCard(ctx, cards[2], todo.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{cards[2].ID, cards[0].ID, cards[1].ID}, cardIDs(t, ctx, s, p.ID, todo.ID))
+
+	// Move to another column with an out-of-range position
+	err = s.MoveCard(ctx, cards[0], done.ID, 10)
+	require.NoError(t, err)
+	err = s.MoveCard(ctx, cards[1], done.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{cards[2].ID}, cardIDs(t, ctx, s, p.ID, todo.ID))
+	assert.Equal(t, []int64{cards[1].ID, cards[0].ID}, cardIDs(t, ctx, s, p.ID, done.ID))
+}
+
+func projectsMoveIssueCards(t *testing.T, ctx context.Context, s *ProjectsStore) {
+	p1, err := s.Create(ctx, CreateProjectOptions{RepoID: 1, Name: "Roadmap", CreatorID: 1, DefaultColumns: true})
+	require.NoError(t, err)
+	p1Columns, err := s.ListColumns(ctx, p1.ID)
+	require.NoError(t, err)
+	merged, err := s.CreateColumn(ctx, p1.ID, "Shipped", ProjectColumnAutomationMerged)
+	require.NoError(t, err)
+
+	p2, err := s.Create(ctx, CreateProjectOptions{OwnerID: 2, Name: "Org", CreatorID: 1})
+	require.NoError(t, err)
+	p2Column, err := s.CreateColumn(ctx, p2.ID, "Triage", ProjectColumnAutomationNone)
+	require.NoError(t, err)
+
+	card1, err := s.CreateCard(ctx, p1Columns[0], CreateProjectCardOptions{IssueID: 1, CreatorID: 1})
+	require.NoError(t, err)
+	card2, err := s.CreateCard(ctx, p2Column, CreateProjectCardOptions{IssueID: 1, CreatorID: 1})
+	require.NoError(t, err)
+
+	// Closed issues are moved to "Done"
+	err = s.MoveIssueCards(ctx, 1, ProjectColumnAutomationClosed)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{card1.ID}, cardIDs(t, ctx, s, p1.ID, p1Columns[2].ID))
+
+	// Merged pull requests prefer the column for merged ones
+	err = s.MoveIssueCards(ctx, 1, ProjectColumnAutomationMerged, ProjectColumnAutomationClosed)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{card1.ID}, cardIDs(t, ctx, s, p1.ID, merged.ID))
+
+	// Projects without a matching column are left untouched
+	assert.Equal(t, []int64{card2.ID}, cardIDs(t, ctx, s, p2.ID, p2Column.ID))
+}
@@ -192,11 +192,19 @@ const (
 	MERGE_STYLE_REBASE  MergeStyle = "rebase_before_merging"
 )
 
-// Merge merges pull request to base repository.
+// Merge merges pull request to base repository. It returns ErrIssueBlocked
+// when the pull request still depends on open issues.
 // FIXME: add repoWorkingPull make sure two merges does not happen at same time.
 func (pr *PullRequest) Merge(doer *User, baseGitRepo *git.Repository, mergeStyle MergeStyle, commitDescription string) (err error) {
 	ctx := context.TODO()
 
+	numDependencies, err := Handle.IssueDependencies().CountOpenDependencies(ctx, pr.IssueID)
+	if err != nil {
+		return fmt.Errorf("count open dependencies: %v", err)
+	} else if numDependencies > 0 {
+		return ErrIssueBlocked{IssueID: pr.IssueID, NumDependencies: numDependencies}
+	}
+
 	defer func() {
 		go HookQueue.Add(pr.BaseRepo.ID)
 		go AddTestPullRequestTask(doer, pr.BaseRepo.ID, pr.BaseBranch, false)
@@ -339,6 +347,10 @@ func (pr *PullRequest) Merge(doer *User, baseGitRepo *git.Repository, mergeStyle
 		log.Error("Failed to create action for merge pull request, pull_request_id: %d, error: %v", pr.ID, err)
 	}
 
+	if err = Handle.Projects().MoveIssueCards(ctx, pr.IssueID, ProjectColumnAutomationMerged, ProjectColumnAutomationClosed); err != nil {
+		log.Error("Failed to move project cards [issue_id: %d]: %v", pr.IssueID, err)
+	}
+
 	// Reload pull request information.
 	if err = pr.LoadAttributes(); err != nil {
 		log.Error("LoadAttributes: %v", err)
@@ -445,7 +457,7 @@ func (pr *PullRequest) testPatch() (err error) {
 }
 
 // NewPullRequest creates new pull request with labels for repository.
-func NewPullRequest(repo *Repository, pull *Issue, labelIDs []int64, uuids []string, pr *PullRequest, patch []byte) (err error) {
+func NewPullRequest(repo *Repository, pull *Issue, labelIDs, assigneeIDs []int64, uuids []string, pr *PullRequest, patch []byte) (err error) {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
@@ -456,6 +468,7 @@ func NewPullRequest(repo *Repository, pull *Issue, labelIDs []int64, uuids []str
 		Repo:        repo,
 		Issue:       pull,
 		LableIDs:    labelIDs,
+		AssigneeIDs: assigneeIDs,
 		Attachments: uuids,
 		IsPull:      true,
 	}); err != nil {
@@ -546,6 +559,57 @@ func GetUnmergedPullRequestsByHeadInfo(repoID int64, branch string) ([]*PullRequ
 		Join("INNER", "issue", "issue.id = pull_request.issue_id").Find(&prs)
 }
 
+// createHeadEventComments creates a timeline event with given options on each
+// of the pull requests.
+func createHeadEventComments(doer *User, prs []*PullRequest, opts CreateCommentOptions) error {
+	for _, pr := range prs {
+		issue, err := GetIssueByID(pr.IssueID)
+		if err != nil {
+			return fmt.Errorf("GetIssueByID [%d]: %v", pr.IssueID, err)
+		}
+
+		opts.Doer = doer
+		opts.Repo = issue.Repo
+		opts.Issue = issue
+		if _, err = CreateComment(&opts); err != nil {
+			return fmt.Errorf("CreateComment [issue_id: %d]: %v", issue.ID, err)
+		}
+	}
+	return nil
+}
+
+// CreateForcePushComments creates timeline events on open pull requests whose
+// head is the force-pushed branch of the repository.
+func CreateForcePushComments(doer *User, repoID int64, branch, oldCommitID, newCommitID string) error {
+	prs, err := GetUnmergedPullRequestsByHeadInfo(repoID, branch)
+	if err != nil {
+		return fmt.Errorf("GetUnmergedPullRequestsByHeadInfo: %v", err)
+	}
+
+	return createHeadEventComments(doer, prs, CreateCommentOptions{
+		Type:         COMMENT_TYPE_FORCE_PUSH,
+		OldCommitSHA: oldCommitID,
+		CommitSHA:    newCommitID,
+	})
+}
+
+// CreateDeleteBranchComments creates timeline events on open or merged pull
+// requests whose head is the deleted branch of the repository.
+func CreateDeleteBranchComments(doer *User, repoID int64, branch string) error {
+	prs := make([]*PullRequest, 0, 2)
+	err := x.Where("head_repo_id = ? AND head_branch = ?", repoID, branch).
+		And("has_merged = ? OR issue.is_closed = ?", true, false).
+		Join("INNER", "issue", "issue.id = pull_request.issue_id").Find(&prs)
+	if err != nil {
+		return fmt.Errorf("find pull requests: %v", err)
+	}
+
+	return createHeadEventComments(doer, prs, CreateCommentOptions{
+		Type:    COMMENT_TYPE_DELETE_BRANCH,
+		Content: branch,
+	})
+}
+
 // GetUnmergedPullRequestsByBaseInfo returns all pull requests that are open and has not been merged
 // by given base information (repo and branch).
 func GetUnmergedPullRequestsByBaseInfo(repoID int64, branch string) ([]*PullRequest, error) {
@@ -0,0 +1,133 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// QuotaType is the type of storage that is limited by storage quotas.
+type QuotaType string
+
+const (
+	QuotaTypeRepository QuotaType = "repository"
+	QuotaTypeLFS        QuotaType = "lfs"
+	QuotaTypeAttachment QuotaType = "attachment"
+)
+
+// StorageUsage is the storage usage (in bytes) of a user or an organization.
+type StorageUsage struct {
+	// Repository is the total size of repositories owned by the user.
+	Repository int64
+	// LFS is the total size of LFS objects stored in repositories owned by the
+	// user.
+	LFS int64
+	// Attachment is the total size of attachments uploaded by the user.
+	Attachment int64
+}
+
+// Of returns the storage usage of the given type.
+func (u *StorageUsage) Of(typ QuotaType) int64 {
+	switch typ {
+	case QuotaTypeRepository:
+		return u.Repository
+	case QuotaTypeLFS:
+		return u.LFS
+	case QuotaTypeAttachment:
+		return u.Attachment
+	}
+	return 0
+}
+
+// QuotasStore is the storage layer for storage quotas.
+type QuotasStore struct {
+	db *gorm.DB
+}
+
+func newQuotasStore(db *gorm.DB) *QuotasStore {
+	return &QuotasStore{db: db}
+}
+
+// GetUsage returns the current storage usage of the given user or
+// organization.
+func (s *QuotasStore) GetUsage(ctx context.Context, userID int64) (*StorageUsage, error) {
+	usage := new(StorageUsage)
+	db := s.db.WithContext(ctx)
+
+	err := db.Model(&Repository{}).
+		Select("COALESCE(SUM(size), 0)").
+		Where("owner_id = ?", userID).
+		Scan(&usage.Repository).
+		Error
+	if err != nil {
+		return nil, errors.Wrap(err, "sum repository size")
+	}
+
+	err = db.Model(&LFSObject{}).
+		Select("COALESCE(SUM(lfs_object.size), 0)").
+		Joins("JOIN repository ON repository.id = lfs_object.repo_id").
+		Where("repository.owner_id = ?", userID).
+		Scan(&usage.LFS).
+		Error
+	if err != nil {
+		return nil, errors.Wrap(err, "sum LFS object size")
+	}
+
+	err = db.Model(&Attachment{}).
+		Select("COALESCE(SUM(size), 0)").
+		Where("uploader_id = ?", userID).
+		Scan(&usage.Attachment).
+		Error
+	if err != nil {
+		return nil, errors.Wrap(err, "sum attachment size")
+	}
+	return usage, nil
+}
+
+// ErrQuotaExceeded is returned when an operation would exceed the storage
+// quota of a user or an organization.
+type ErrQuotaExceeded struct {
+	Type  QuotaType
+	Usage int64
+	Limit int64
+}
+
+// IsErrQuotaExceeded returns true if the underlying error has the type
+// ErrQuotaExceeded.
+func IsErrQuotaExceeded(err error) bool {
+	return errors.As(err, &ErrQuotaExceeded{})
+}
+
+func (err ErrQuotaExceeded) Error() string {
+	return fmt.Sprintf("%s storage quota exceeded: usage %d bytes, limit %d bytes", err.Type, err.Usage, err.Limit)
+}
+
+// Check returns ErrQuotaExceeded if adding the given size (in bytes) of the
+// given type of storage would exceed the storage quota of the user or
+// organization.
+func (s *QuotasStore) Check(ctx context.Context, user *User, typ QuotaType, size int64) error {
+	limit := user.StorageQuota(typ)
+	if limit < 0 {
+		return nil
+	}
+
+	usage, err := s.GetUsage(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	if usage.Of(typ)+size > limit {
+		return ErrQuotaExceeded{
+			Type:  typ,
+			Usage: usage.Of(typ),
+			Limit: limit,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/lfsutil"
+)
+
+func TestQuotas(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	ctx := context.Background()
+	s := &QuotasStore{
+		db: newTestDB(t, "QuotasStore"),
+	}
+
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, s *QuotasStore)
+	}{
+		{"GetUsage", quotasGetUsage},
+		{"Check", quotasCheck},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				err := clearTables(t, s.db)
+				require.NoError(t, err)
+			})
+			tc.test(t, ctx, s)
+		})
+		if t.Failed() {
+			break
+		}
+	}
+}
+
+// createQuotaFixtures creates storage usage of 1 MB repository, 2 MB LFS
+// objects and 3 MB attachments for the user with given ID.
+func createQuotaFixtures(t *testing.T, ctx context.Context, s *QuotasStore, userID int64) {
+	const mb = 1024 * 1024
+
+	repo := &Repository{
+		OwnerID:   userID,
+		LowerName: "repo1",
+		Name:      "repo1",
+		Size:      1 * mb,
+	}
+	err := s.db.WithContext(ctx).Create(repo).Error
+	require.NoError(t, err)
+
+	lfsStore := newLFSStore(s.db)
+	err = lfsStore.CreateObject(ctx, repo.ID, lfsutil.OID("ef797c8118f02dfb649607dd5d3f8c7623048c9c063d532cc95c5ed7a898a64f"), 2*mb, lfsutil.StorageLocal)
+	require.NoError(t, err)
+
+	err = s.db.WithContext(ctx).Create(
+		&Attachment{
+			UUID:       "6fa9b2d8-2b1c-4a4e-a3b4-3c1b0e3b0b1a",
+			UploaderID: userID,
+			Name:       "attachment1",
+			Size:       3 * mb,
+		},
+	).Error
+	require.NoError(t, err)
+}
+
+func quotasGetUsage(t *testing.T, ctx context.Context, s *QuotasStore) {
+	usage, err := s.GetUsage(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, &StorageUsage{}, usage)
+
+	createQuotaFixtures(t, ctx, s, 1)
+
+	usage, err = s.GetUsage(ctx, 1)
+	require.NoError(t, err)
+	want := &StorageUsage{
+		Repository: 1 * 1024 * 1024,
+		LFS:        2 * 1024 * 1024,
+		Attachment: 3 * 1024 * 1024,
+	}
+	assert.Equal(t, want, usage)
+
+	// Other users should not be affected
+	usage, err = s.GetUsage(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, &StorageUsage{}, usage)
+}
+
+func quotasCheck(t *testing.T, ctx context.Context, s *QuotasStore) {
+	createQuotaFixtures(t, ctx, s, 1)
+
+	conf.SetMockQuota(t,
+		conf.QuotaOpts{
+			Enabled:           true,
+			MaxRepoSize:       2,
+			MaxLFSSize:        -1,
+			MaxAttachmentSize: 3,
+		},
+	)
+
+	user := &User{
+		ID:                1,
+		MaxRepoSize:       -1,
+		MaxLFSSize:        2,
+		MaxAttachmentSize: -1,
+	}
+
+	// Within the global default limit
+	err := s.Check(ctx, user, QuotaTypeRepository, 1024*1024)
+	assert.NoError(t, err)
+
+	// Exceeds the global default limit
+	err = s.Check(ctx, user, QuotaTypeRepository, 1024*1024+1)
+	want := ErrQuotaExceeded{
+		Type:  QuotaTypeRepository,
+		Usage: 1024 * 1024,
+		Limit: 2 * 1024 * 1024,
+	}
+	assert.Equal(t, want, err)
+
+	// Exceeds the per-user limit while the global default is unlimited
+	err = s.Check(ctx, user, QuotaTypeLFS, 1)
+	assert.True(t, IsErrQuotaExceeded(err))
+
+	// Already reached the global default limit
+	err = s.Check(ctx, user, QuotaTypeAttachment, 1)
+	assert.True(t, IsErrQuotaExceeded(err))
+
+	// Per-user limit takes precedence over the global default
+	user.MaxAttachmentSize = 1024
+	err = s.Check(ctx, user, QuotaTypeAttachment, 1)
+	assert.NoError(t, err)
+
+	// Quotas are not enforced when disabled
+	conf.Quota.Enabled = false
+	err = s.Check(ctx, user, QuotaTypeLFS, 1024*1024*1024)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,308 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+// ReactionTypes is the fixed set of reactions in the order of display.
+var ReactionTypes = []string{"+1", "-1", "laugh", "hooray", "confused", "heart", "rocket", "eyes"}
+
+var reactionEmojis = map[string]string{
+	"+1":       "👍",
+	"-1":       "👎",
+	"laugh":    "😄",
+	"hooray":   "🎉",
+	"confused": "😕",
+	"heart":    "❤️",
+	"rocket":   "🚀",
+	"eyes":     "👀",
+}
+
+// IsValidReactionType returns true if the given type is one of ReactionTypes.
+func IsValidReactionType(typ string) bool {
+	_, ok := reactionEmojis[typ]
+	return ok
+}
+
+// ReactionEmoji returns the emoji of the given reaction type.
+func ReactionEmoji(typ string) string {
+	return reactionEmojis[typ]
+}
+
+// Reaction is an emoji reaction of a user to an issue or a comment. Reactions
+// to comments also have the ID of the issue that comments belong to.
+type Reaction struct {
+	ID          int64  `gorm:"primaryKey"`
+	UserID      int64  `gorm:"uniqueIndex:reaction_user_target_unique;not null"`
+	IssueID     int64  `gorm:"uniqueIndex:reaction_user_target_unique;index;not null"`
+	CommentID   int64  `gorm:"uniqueIndex:reaction_user_target_unique;not null"` // Zero for reactions to the issue itself
+	Type        string `gorm:"uniqueIndex:reaction_user_target_unique;type:VARCHAR(16);not null"`
+	CreatedUnix int64
+}
+
+// BeforeCreate implements the GORM create hook.
+func (r *Reaction) BeforeCreate(tx *gorm.DB) error {
+	if r.CreatedUnix == 0 {
+		r.CreatedUnix = tx.NowFunc().Unix()
+	}
+	return nil
+}
+
+// ReactionSummary is the aggregation of reactions of the same type to an issue
+// or a comment.
+type ReactionSummary struct {
+	Type    string  `json:"type"`
+	Count   int64   `json:"count"`
+	UserIDs []int64 `json:"-"`
+}
+
+// Emoji returns the emoji of the reaction type.
+func (s *ReactionSummary) Emoji() string {
+	return ReactionEmoji(s.Type)
+}
+
+// HasUser returns true if the user is one of who reacted.
+func (s *ReactionSummary) HasUser(userID int64) bool {
+	for _, id := range s.UserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ReactionsStore is the storage layer for reactions.
+type ReactionsStore struct {
+	db *gorm.DB
+}
+
+func newReactionsStore(db *gorm.DB) *ReactionsStore {
+	return &ReactionsStore{db: db}
+}
+
+type ErrReactionInvalidType struct {
+	args errutil.Args
+}
+
+func IsErrReactionInvalidType(err error) bool {
+	return errors.As(err, &ErrReactionInvalidType{})
+}
+
+func (err ErrReactionInvalidType) Error() string {
+	return fmt.Sprintf("invalid reaction type: %v", err.args)
+}
+
+var _ errutil.NotFound = (*ErrReactionNotExist)(nil)
+
+type ErrReactionNotExist struct {
+	args errutil.Args
+}
+
+func IsErrReactionNotExist(err error) bool {
+	return errors.As(err, &ErrReactionNotExist{})
+}
+
+func (err ErrReactionNotExist) Error() string {
+	return fmt.Sprintf("reaction does not exist: %v", err.args)
+}
+
+func (ErrReactionNotExist) NotFound() bool {
+	return true
+}
+
+// Add adds a reaction of the user to the issue, or to the comment of the issue
+// when commentID is not zero. It is a noop if the reaction already exists. It
+// returns ErrReactionInvalidType when the type is not one of ReactionTypes.
+func (s *ReactionsStore) Add(ctx context.Context, userID, issueID, commentID int64, typ string) error {
+	if !IsValidReactionType(typ) {
+		return ErrReactionInvalidType{args: errutil.Args{"type": typ}}
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND issue_id = ? AND comment_id = ? AND type = ?", userID, issueID, commentID, typ).First(&Reaction{}).Error
+		if err == nil {
+			return nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Wrap(err, "check existence")
+		}
+
+		err = tx.Create(
+			&Reaction{
+				UserID:    userID,
+				IssueID:   issueID,
+				CommentID: commentID,
+				Type:      typ,
+			},
+		).Error
+		if err != nil {
+			return errors.Wrap(err, "create")
+		}
+
+		if commentID > 0 {
+			return nil
+		}
+		return tx.Exec("UPDATE issue SET num_reactions = num_reactions + 1 WHERE id = ?", issueID).Error
+	})
+}
+
+// Remove removes a reaction of the user from the issue, or from the comment of
+// the issue when commentID is not zero. It returns ErrReactionNotExist when
+// not found.
+func (s *ReactionsStore) Remove(ctx context.Context, userID, issueID, commentID int64, typ string) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND issue_id = ? AND comment_id = ? AND type = ?", userID, issueID, commentID, typ).Delete(&Reaction{})
+		if result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected == 0 {
+			return ErrReactionNotExist{args: errutil.Args{"userID": userID, "issueID": issueID, "commentID": commentID, "type": typ}}
+		}
+
+		if commentID > 0 {
+			return nil
+		}
+		return tx.Exec("UPDATE issue SET num_reactions = num_reactions - 1 WHERE id = ?", issueID).Error
+	})
+}
+
+// Toggle removes the reaction of the user if it exists, otherwise adds it. It
+// returns true if the reaction is added.
+func (s *ReactionsStore) Toggle(ctx context.Context, userID, issueID, commentID int64, typ string) (added bool, err error) {
+	err = s.Remove(ctx, userID, issueID, commentID, typ)
+	if err == nil {
+		return false, nil
+	} else if !IsErrReactionNotExist(err) {
+		return false, err
+	}
+	return true, s.Add(ctx, userID, issueID, commentID, typ)
+}
+
+// summarizeReactions aggregates reactions by their types in the order of
+// ReactionTypes, types without any reaction are omitted.
+func summarizeReactions(reactions []*Reaction) []*ReactionSummary {
+	byType := make(map[string]*ReactionSummary)
+	for _, r := range reactions {
+		s := byType[r.Type]
+		if s == nil {
+			s = &ReactionSummary{Type: r.Type}
+			byType[r.Type] = s
+		}
+		s.Count++
+		s.UserIDs = append(s.UserIDs, r.UserID)
+	}
+
+	summaries := make([]*ReactionSummary, 0, len(byType))
+	for _, typ := range ReactionTypes {
+		if s := byType[typ]; s != nil {
+			summaries = append(summaries, s)
+		}
+	}
+	return summaries
+}
+
+// Summarize returns aggregated reactions to the issue, or to the comment of the
+// issue when commentID is not zero.
+func (s *ReactionsStore) Summarize(ctx context.Context, issueID, commentID int64) ([]*ReactionSummary, error) {
+	var reactions []*Reaction
+	err := s.db.WithContext(ctx).
+		Where("issue_id = ? AND comment_id = ?", issueID, commentID).
+		Order("id ASC").
+		Find(&reactions).
+		Error
+	if err != nil {
+		return nil, err
+	}
+	return summarizeReactions(reactions), nil
+}
+
+// SummarizeByIssueID returns aggregated reactions to the issue and all of its
+// comments, keyed by comment IDs with zero for the issue itself.
+func (s *ReactionsStore) SummarizeByIssueID(ctx context.Context, issueID int64) (map[int64][]*ReactionSummary, error) {
+	var reactions []*Reaction
+	err := s.db.WithContext(ctx).
+		Where("issue_id = ?", issueID).
+		Order("id ASC").
+		Find(&reactions).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	byComment := make(map[int64][]*Reaction)
+	for _, r := range reactions {
+		byComment[r.CommentID] = append(byComment[r.CommentID], r)
+	}
+
+	summaries := make(map[int64][]*ReactionSummary, len(byComment))
+	for commentID, reactions := range byComment {
+		summaries[commentID] = summarizeReactions(reactions)
+	}
+	return summaries, nil
+}
+
+// SummarizeByIssueIDs returns aggregated reactions to each of the issues,
+// excluding reactions to their comments, keyed by issue IDs.
+func (s *ReactionsStore) SummarizeByIssueIDs(ctx context.Context, issueIDs []int64) (map[int64][]*ReactionSummary, error) {
+	if len(issueIDs) == 0 {
+		return map[int64][]*ReactionSummary{}, nil
+	}
+
+	var reactions []*Reaction
+	err := s.db.WithContext(ctx).
+		Where("issue_id IN ? AND comment_id = 0", issueIDs).
+		Order("id ASC").
+		Find(&reactions).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	byIssue := make(map[int64][]*Reaction)
+	for _, r := range reactions {
+		byIssue[r.IssueID] = append(byIssue[r.IssueID], r)
+	}
+
+	summaries := make(map[int64][]*ReactionSummary, len(byIssue))
+	for issueID, reactions := range byIssue {
+		summaries[issueID] = summarizeReactions(reactions)
+	}
+	return summaries, nil
+}
+
+// SummarizeByCommentIDs returns aggregated reactions to each of the comments,
+// keyed by comment IDs.
+func (s *ReactionsStore) SummarizeByCommentIDs(ctx context.Context, commentIDs []int64) (map[int64][]*ReactionSummary, error) {
+	if len(commentIDs) == 0 {
+		return map[int64][]*ReactionSummary{}, nil
+	}
+
+	var reactions []*Reaction
+	err := s.db.WithContext(ctx).
+		Where("comment_id IN ?", commentIDs).
+		Order("id ASC").
+		Find(&reactions).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	byComment := make(map[int64][]*Reaction)
+	for _, r := range reactions {
+		byComment[r.CommentID] = append(byComment[r.CommentID], r)
+	}
+
+	summaries := make(map[int64][]*ReactionSummary, len(byComment))
+	for commentID, reactions := range byComment {
+		summaries[commentID] = summarizeReactions(reactions)
+	}
+	return summaries, nil
+}
@@ -0,0 +1,202 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+func TestReactions(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	ctx := context.Background()
+	s := &ReactionsStore{
+		db: newTestDB(t, "ReactionsStore"),
+	}
+
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, s *ReactionsStore)
+	}{
+		{"Add", reactionsAdd},
+		{"Remove", reactionsRemove},
+		{"Toggle", reactionsToggle},
+		{"SummarizeByIssueID", reactionsSummarizeByIssueID},
+		{"SummarizeByIssueIDs", reactionsSummarizeByIssueIDs},
+		{"SummarizeByCommentIDs", reactionsSummarizeByCommentIDs},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				err := clearTables(t, s.db)
+				require.NoError(t, err)
+			})
+			tc.test(t, ctx, s)
+		})
+		if t.Failed() {
+			break
+		}
+	}
+}
+
+func createTestIssue(t *testing.T, s *ReactionsStore) *Issue {
+	issue := &Issue{RepoID: 1, Index: 1, Title: "test"}
+	err := s.db.Create(issue).Error
+	require.NoError(t, err)
+	return issue
+}
+
+func reactionsAdd(t *testing.T, ctx context.Context, s *ReactionsStore) {
+	issue := createTestIssue(t, s)
+
+	err := s.Add(ctx, 1, issue.ID, 0, "smile")
+	wantErr := ErrReactionInvalidType{args: errutil.Args{"type": "smile"}}
+	assert.Equal(t, wantErr, err)
+
+	err = s.Add(ctx, 1, issue.ID, 0, "+1")
+	require.NoError(t, err)
+	err = s.Add(ctx, 2, issue.ID, 0, "+1")
+	require.NoError(t, err)
+	err = s.Add(ctx, 1, issue.ID, 0, "heart")
+	require.NoError(t, err)
+
+	// Adding the same reaction again is a noop
+	err = s.Add(ctx, 1, issue.ID, 0, "+1")
+	require.NoError(t, err)
+
+	// Reactions to comments do not count towards the issue
+	err = s.Add(ctx, 1, issue.ID, 1, "+1")
+	require.NoError(t, err)
+
+	err = s.db.First(issue, issue.ID).Error
+	require.NoError(t, err)
+	assert.Equal(t, 3, issue.NumReactions)
+
+	got, err := s.Summarize(ctx, issue.ID, 0)
+	require.NoError(t, err)
+	want := []*ReactionSummary{
+		{Type: "+1", Count: 2, UserIDs: []int64{1, 2}},
+		{Type: "heart", Count: 1, UserIDs: []int64{1}},
+	}
+	assert.Equal(t, want, got)
+}
+
+func reactionsRemove(t *testing.T, ctx context.Context, s *ReactionsStore) {
+	issue := createTestIssue(t, s)
+
+	err := s.Remove(ctx, 1, issue.ID, 0, "+1")
+	wantErr := ErrReactionNotExist{args: errutil.Args{"userID": int64(1), "issueID": issue.ID, "commentID": int64(0), "type": "+1"}}
+	assert.Equal(t, wantErr, err)
+
+	err = s.Add(ctx, 1, issue.ID, 0, "+1")
+	require.NoError(t, err)
+	err = s.Remove(ctx, 1, issue.ID, 0, "+1")
+	require.NoError(t, err)
+
+	err = s.db.First(issue, issue.ID).Error
+	require.NoError(t, err)
+	assert.Equal(t, 0, issue.NumReactions)
+
+	got, err := s.Summarize(ctx, issue.ID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func reactionsToggle(t *testing.T, ctx context.Context, s *ReactionsStore) {
+	issue := createTestIssue(t, s)
+
+	added, err := s.Toggle(ctx, 1, issue.ID, 0, "rocket")
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = s.Toggle(ctx, 1, issue.ID, 0, "rocket")
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	got, err := s.Summarize(ctx, issue.ID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func reactionsSummarizeByIssueID(t *testing.T, ctx context.Context, s *ReactionsStore) {
+	issue := createTestIssue(t, s)
+
+	err := s.Add(ctx, 1, issue.ID, 0, "eyes")
+	require.NoError(t, err)
+	err = s.Add(ctx, 1, issue.ID, 2, "laugh")
+	require.NoError(t, err)
+	err = s.Add(ctx, 2, issue.ID, 2, "-1")
+	require.NoError(t, err)
+
+	got, err := s.SummarizeByIssueID(ctx, issue.ID)
+	require.NoError(t, err)
+	want := map[int64][]*ReactionSummary{
+		0: {
+			{Type: "eyes", Count: 1, UserIDs: []int64{1}},
+		},
+		2: {
+			{Type: "-1", Count: 1, UserIDs: []int64{2}},
+			{Type: "laugh", Count: 1, UserIDs: []int64{1}},
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func reactionsSummarizeByIssueIDs(t *testing.T, ctx context.Context, s *ReactionsStore) {
+	issue1 := createTestIssue(t, s)
+	issue2 := &Issue{RepoID: 1, Index: 2, Title: "test"}
+	err := s.db.Create(issue2).Error
+	require.NoError(t, err)
+
+	err = s.Add(ctx, 1, issue1.ID, 0, "eyes")
+	require.NoError(t, err)
+	err = s.Add(ctx, 2, issue2.ID, 0, "+1")
+	require.NoError(t, err)
+	err = s.Add(ctx, 1, issue2.ID, 3, "laugh")
+	require.NoError(t, err)
+
+	got, err := s.SummarizeByIssueIDs(ctx, []int64{issue1.ID, issue2.ID})
+	require.NoError(t, err)
+	want := map[int64][]*ReactionSummary{
+		issue1.ID: {
+			{Type: "eyes", Count: 1, UserIDs: []int64{1}},
+		},
+		issue2.ID: {
+			{Type: "+1", Count: 1, UserIDs: []int64{2}},
+		},
+	}
+	assert.Equal(t, want, got)
+}
+
+func reactionsSummarizeByCommentIDs(t *testing.T, ctx context.Context, s *ReactionsStore) {
+	issue := createTestIssue(t, s)
+
+	err := s.Add(ctx, 1, issue.ID, 0, "eyes")
+	require.NoError(t, err)
+	err = s.Add(ctx, 1, issue.ID, 2, "laugh")
+	require.NoError(t, err)
+	err = s.Add(ctx, 2, issue.ID, 3, "-1")
+	require.NoError(t, err)
+
+	got, err := s.SummarizeByCommentIDs(ctx, []int64{2, 3, 4})
+	require.NoError(t, err)
+	want := map[int64][]*ReactionSummary{
+		2: {
+			{Type: "laugh", Count: 1, UserIDs: []int64{1}},
+		},
+		3: {
+			{Type: "-1", Count: 1, UserIDs: []int64{2}},
+		},
+	}
+	assert.Equal(t, want, got)
+}
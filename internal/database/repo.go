@@ -194,6 +194,7 @@ type Repository struct {
 	ExternalWikiURL       string
 	EnableIssues          bool `xorm:"NOT NULL DEFAULT true" gorm:"not null;default:TRUE"`
 	AllowPublicIssues     bool
+	AllowClosingBlocked   bool `xorm:"NOT NULL DEFAULT false" gorm:"not null;default:FALSE"`
 	EnableExternalTracker bool
 	ExternalTrackerURL    string
 	ExternalTrackerFormat string
@@ -550,9 +551,10 @@ func (repo *Repository) GetWriters() (_ []*User, err error) {
 	return repo.getUsersWithAccesMode(x, AccessModeWrite)
 }
 
-// GetMilestoneByID returns the milestone belongs to repository by given ID.
+// GetMilestoneByID returns the milestone available to repository by given ID,
+// which is either of the repository or of its owner organization.
 func (repo *Repository) GetMilestoneByID(milestoneID int64) (*Milestone, error) {
-	return GetMilestoneByRepoID(repo.ID, milestoneID)
+	return GetAvailableMilestoneByRepoID(repo.ID, milestoneID)
 }
 
 // IssueStats returns number of open and closed repository issues by given filter mode.
@@ -1372,6 +1374,11 @@ func TransferOwnership(doer *User, newOwnerName string, repo *Repository) error
 	}
 
 	owner := repo.Owner
+	if owner.IsOrganization() {
+		if err = owner.removeOrgIssueMetas(sess, repo.ID); err != nil {
+			return fmt.Errorf("remove organization issue metas: %v", err)
+		}
+	}
 
 	// Note: we have to set value here to make sure recalculate accesses is based on
 	// new owner.
@@ -1667,6 +1674,10 @@ func DeleteRepository(ownerID, repoID int64) error {
 				return err
 			}
 		}
+
+		if err = org.removeOrgIssueMetas(sess, repoID); err != nil {
+			return fmt.Errorf("remove organization issue metas: %v", err)
+		}
 	}
 
 	if err = deleteBeans(sess,
@@ -1686,10 +1697,22 @@ func DeleteRepository(ownerID, repoID int64) error {
 		&Webhook{RepoID: repoID},
 		&HookTask{RepoID: repoID},
 		&LFSObject{RepoID: repoID},
+		&RepoMigration{RepoID: repoID},
+		&RepoMigrationIssue{RepoID: repoID},
+		&RepoMaintenance{RepoID: repoID},
+		&IssueRedirect{RepoID: repoID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err = deleteProjects(sess, 0, repoID); err != nil {
+		return err
+	}
+
+	if err = deleteIssueAssigneesByRepoID(sess, repoID); err != nil {
+		return fmt.Errorf("delete issue assignees: %v", err)
+	}
+
 	// Delete comments and attachments.
 	issues := make([]*Issue, 0, 25)
 	attachmentPaths := make([]string, 0, len(issues))
@@ -1701,6 +1724,40 @@ func DeleteRepository(ownerID, repoID int64) error {
 			return err
 		}
 
+		if _, err = sess.Exec("DELETE FROM `issue_dependency` WHERE issue_id = ? OR dependency_id = ?", issues[i].ID, issues[i].ID); err != nil {
+			return fmt.Errorf("delete issue dependencies: %v", err)
+		}
+
+		if _, err = sess.Exec("DELETE FROM `issue_redirect` WHERE issue_id = ?", issues[i].ID); err != nil {
+			return fmt.Errorf("delete issue redirects: %v", err)
+		}
+
+		if _, err = sess.Exec("DELETE FROM `reaction` WHERE issue_id = ?", issues[i].ID); err != nil {
+			return fmt.Errorf("delete reactions: %v", err)
+		}
+
+		if _, err = sess.Exec("DELETE FROM `project_card` WHERE issue_id = ?", issues[i].ID); err != nil {
+			return fmt.Errorf("delete project cards: %v", err)
+		}
+
+		if _, err = sess.Exec("DELETE FROM `content_revision` WHERE issue_id = ?", issues[i].ID); err != nil {
+			return fmt.Errorf("delete content revisions: %v", err)
+		}
+
+		if _, err = sess.Exec("DELETE FROM `notification` WHERE issue_id = ?", issues[i].ID); err != nil {
+			return fmt.Errorf("delete notifications: %v", err)
+		}
+		if _, err = sess.Exec("DELETE FROM `email_digest_item` WHERE issue_id = ?", issues[i].ID); err != nil {
+			return fmt.Errorf("delete email digest items: %v", err)
+		}
+
+		if _, err = sess.Exec("DELETE FROM `tracked_time` WHERE issue_id = ?", issues[i].ID); err != nil {
+			return fmt.Errorf("delete tracked times: %v", err)
+		}
+		if _, err = sess.Exec("DELETE FROM `stopwatch` WHERE issue_id = ?", issues[i].ID); err != nil {
+			return fmt.Errorf("delete stopwatches: %v", err)
+		}
+
 		attachments := make([]*Attachment, 0, 5)
 		if err = sess.Where("issue_id=?", issues[i].ID).Find(&attachments); err != nil {
 			return err
@@ -2061,6 +2118,10 @@ const (
 	_GIT_FSCK           = "git_fsck"
 	_CHECK_REPO_STATS   = "check_repos_stats"
 	_CLEAN_OLD_ARCHIVES = "clean_old_archives"
+	_REPO_MAINTENANCE   = "repo_maintenance"
+	_REMIND_DUE_ISSUES  = "remind_due_issues"
+	_INCOMING_EMAILS    = "incoming_emails"
+	_EMAIL_DIGESTS      = "email_digests"
 )
 
 // GitFsck calls 'git fsck' to check repository health.
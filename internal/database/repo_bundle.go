@@ -0,0 +1,985 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gogs/git-module"
+	"github.com/pkg/errors"
+	gouuid "github.com/satori/go.uuid"
+	log "unknwon.dev/clog/v2"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/lfsutil"
+	"gogs.io/gogs/internal/osutil"
+	"gogs.io/gogs/internal/process"
+)
+
+// A repository bundle is a zip archive with the following layout:
+//
+//	metadata.json       The metadata of the bundle and settings of the repository
+//	repository/         The bare Git repository
+//	wiki/               The bare Git repository of the wiki, if any
+//	data/<table>.json   The database records in JSON lines
+//	attachments/<uuid>  The files of attachments
+//	lfs/<oid>           The files of LFS objects
+//
+// Server-side hooks are never included in a bundle, they are recreated upon
+// import. The Git config of a bundle is never trusted, the config is generated
+// upon import with only a few allowed keys copied from the bundle.
+const (
+	currentRepoBundleVersion = 1
+
+	repoBundleMetadataFile   = "metadata.json"
+	repoBundleRepositoryDir  = "repository"
+	repoBundleWikiDir        = "wiki"
+	repoBundleDataDir        = "data"
+	repoBundleAttachmentsDir = "attachments"
+	repoBundleLFSDir         = "lfs"
+)
+
+// repoBundleMetadata is the metadata of a repository bundle.
+type repoBundleMetadata struct {
+	Version     int
+	GogsVersion string
+	Exported    time.Time
+	Repository  repoBundleRepository
+}
+
+// repoBundleRepository contains the settings of the repository in a bundle.
+type repoBundleRepository struct {
+	Name                  string
+	Description           string
+	Website               string
+	DefaultBranch         string
+	IsPrivate             bool
+	IsUnlisted            bool
+	IsBare                bool
+	EnableWiki            bool
+	AllowPublicWiki       bool
+	EnableIssues          bool
+	AllowPublicIssues     bool
+	AllowClosingBlocked   bool
+	EnablePulls           bool
+	PullsIgnoreWhitespace bool
+	PullsAllowRebase      bool
+}
+
+// repoBundleUser is a user referenced by records in a bundle, which is mapped
+// to a local user by the email address upon import.
+type repoBundleUser struct {
+	ID    int64
+	Name  string
+	Email string
+}
+
+// isRepoBundleHooksDir returns true if the relative path within a Git
+// repository belongs to server-side hooks, which must never be exported or
+// imported.
+func isRepoBundleHooksDir(rel string) bool {
+	first := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	return first == "hooks" || first == "custom_hooks"
+}
+
+// isRepoBundleGitFile returns true if the relative path within a Git
+// repository is allowed to be imported from a bundle. Files that Git trusts to
+// change its behavior, e.g. "config" and "objects/info/alternates", are never
+// imported because they could run commands or read arbitrary paths on the
+// server.
+func isRepoBundleGitFile(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	switch {
+	case rel == "HEAD", rel == "packed-refs", rel == "description":
+		return true
+	case strings.HasPrefix(rel, "refs/"):
+		return true
+	case strings.HasPrefix(rel, "objects/info/"):
+		return rel == "objects/info/packs"
+	case strings.HasPrefix(rel, "objects/"):
+		return true
+	}
+	return false
+}
+
+// repoBundleConfigKeys is the list of Git config keys that are copied from the
+// bundle to the imported repository.
+var repoBundleConfigKeys = []string{
+	"core.repositoryformatversion",
+	"extensions.objectformat",
+	"core.bigfilethreshold",
+	"core.compression",
+}
+
+// ExportRepository writes the bundle of the repository to w, which contains
+// Git data, wiki, issues, pull requests, labels, milestones, releases,
+// attachments and LFS objects.
+func ExportRepository(ctx context.Context, repo *Repository, w io.Writer) (err error) {
+	zw := zip.NewWriter(w)
+	defer func() {
+		if closeErr := zw.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	metadata := repoBundleMetadata{
+		Version:     currentRepoBundleVersion,
+		GogsVersion: conf.App.Version,
+		Exported:    time.Now().UTC(),
+		Repository: repoBundleRepository{
+			Name:                  repo.Name,
+			Description:           repo.Description,
+			Website:               repo.Website,
+			DefaultBranch:         repo.DefaultBranch,
+			IsPrivate:             repo.IsPrivate,
+			IsUnlisted:            repo.IsUnlisted,
+			IsBare:                repo.IsBare,
+			EnableWiki:            repo.EnableWiki,
+			AllowPublicWiki:       repo.AllowPublicWiki,
+			EnableIssues:          repo.EnableIssues,
+			AllowPublicIssues:     repo.AllowPublicIssues,
+			AllowClosingBlocked:   repo.AllowClosingBlocked,
+			EnablePulls:           repo.EnablePulls,
+			PullsIgnoreWhitespace: repo.PullsIgnoreWhitespace,
+			PullsAllowRebase:      repo.PullsAllowRebase,
+		},
+	}
+	f, err := zw.Create(repoBundleMetadataFile)
+	if err != nil {
+		return errors.Wrap(err, "create metadata")
+	}
+	if err = json.NewEncoder(f).Encode(metadata); err != nil {
+		return errors.Wrap(err, "encode metadata")
+	}
+
+	if err = addRepoBundleDir(zw, repoBundleRepositoryDir, repo.RepoPath()); err != nil {
+		return errors.Wrap(err, "add repository")
+	}
+	if repo.HasWiki() {
+		if err = addRepoBundleDir(zw, repoBundleWikiDir, repo.WikiPath()); err != nil {
+			return errors.Wrap(err, "add wiki")
+		}
+	}
+
+	// Database records
+	issuesCond := "issue_id IN (SELECT id FROM issue WHERE repo_id = ?)"
+
+	var labels []*Label
+	if err = x.Where("repo_id = ?", repo.ID).Asc("id").Find(&labels); err != nil {
+		return errors.Wrap(err, "find labels")
+	}
+	var milestones []*Milestone
+	if err = x.Where("repo_id = ?", repo.ID).Asc("id").Find(&milestones); err != nil {
+		return errors.Wrap(err, "find milestones")
+	}
+	var issues []*Issue
+	if err = x.Where("repo_id = ?", repo.ID).Asc("id").Find(&issues); err != nil {
+		return errors.Wrap(err, "find issues")
+	}
+	var issueLabels []*IssueLabel
+	if err = x.Where(issuesCond, repo.ID).Asc("id").Find(&issueLabels); err != nil {
+		return errors.Wrap(err, "find issue labels")
+	}
+	var issueAssignees []*IssueAssignee
+	if err = x.Where(issuesCond, repo.ID).Asc("id").Find(&issueAssignees); err != nil {
+		return errors.Wrap(err, "find issue assignees")
+	}
+	var comments []*Comment
+	if err = x.Where(issuesCond, repo.ID).Asc("id").Find(&comments); err != nil {
+		return errors.Wrap(err, "find comments")
+	}
+	var reactions []*Reaction
+	if err = x.Where(issuesCond, repo.ID).Asc("id").Find(&reactions); err != nil {
+		return errors.Wrap(err, "find reactions")
+	}
+	var pulls []*PullRequest
+	if err = x.Where(issuesCond, repo.ID).Asc("id").Find(&pulls); err != nil {
+		return errors.Wrap(err, "find pull requests")
+	}
+	var releases []*Release
+	if err = x.Where("repo_id = ?", repo.ID).Asc("id").Find(&releases); err != nil {
+		return errors.Wrap(err, "find releases")
+	}
+	var attachments []*Attachment
+	err = x.Where(issuesCond+" OR release_id IN (SELECT id FROM `release` WHERE repo_id = ?)", repo.ID, repo.ID).
+		Asc("id").
+		Find(&attachments)
+	if err != nil {
+		return errors.Wrap(err, "find attachments")
+	}
+	var lfsObjects []*LFSObject
+	err = Handle.db.WithContext(ctx).Where("repo_id = ?", repo.ID).Order("oid ASC").Find(&lfsObjects).Error
+	if err != nil {
+		return errors.Wrap(err, "find LFS objects")
+	}
+
+	userIDs := make(map[int64]bool)
+	for _, issue := range issues {
+		userIDs[issue.PosterID] = true
+	}
+	for _, a := range issueAssignees {
+		userIDs[a.AssigneeID] = true
+	}
+	for _, c := range comments {
+		userIDs[c.PosterID] = true
+		if c.AssigneeID > 0 {
+			userIDs[c.AssigneeID] = true
+		}
+	}
+	for _, r := range reactions {
+		userIDs[r.UserID] = true
+	}
+	for _, pull := range pulls {
+		userIDs[pull.MergerID] = true
+	}
+	for _, r := range releases {
+		userIDs[r.PublisherID] = true
+	}
+	for _, a := range attachments {
+		userIDs[a.UploaderID] = true
+	}
+	ids := make([]int64, 0, len(userIDs))
+	for id := range userIDs {
+		if id > 0 {
+			ids = append(ids, id)
+		}
+	}
+	var users []*User
+	if len(ids) > 0 {
+		if err = x.In("id", ids).Asc("id").Find(&users); err != nil {
+			return errors.Wrap(err, "find users")
+		}
+	}
+	bundleUsers := make([]*repoBundleUser, 0, len(users))
+	for _, u := range users {
+		bundleUsers = append(bundleUsers, &repoBundleUser{
+			ID:    u.ID,
+			Name:  u.Name,
+			Email: u.Email,
+		})
+	}
+
+	for _, table := range []struct {
+		name  string
+		write func(name string) error
+	}{
+		{"users", func(name string) error { return writeRepoBundleRecords(zw, name, bundleUsers) }},
+		{"labels", func(name string) error { return writeRepoBundleRecords(zw, name, labels) }},
+		{"milestones", func(name string) error { return writeRepoBundleRecords(zw, name, milestones) }},
+		{"issues", func(name string) error { return writeRepoBundleRecords(zw, name, issues) }},
+		{"issue_labels", func(name string) error { return writeRepoBundleRecords(zw, name, issueLabels) }},
+		{"issue_assignees", func(name string) error { return writeRepoBundleRecords(zw, name, issueAssignees) }},
+		{"comments", func(name string) error { return writeRepoBundleRecords(zw, name, comments) }},
+		{"reactions", func(name string) error { return writeRepoBundleRecords(zw, name, reactions) }},
+		{"pull_requests", func(name string) error { return writeRepoBundleRecords(zw, name, pulls) }},
+		{"releases", func(name string) error { return writeRepoBundleRecords(zw, name, releases) }},
+		{"attachments", func(name string) error { return writeRepoBundleRecords(zw, name, attachments) }},
+		{"lfs_objects", func(name string) error { return writeRepoBundleRecords(zw, name, lfsObjects) }},
+	} {
+		if err = table.write(table.name); err != nil {
+			return errors.Wrapf(err, "write %s", table.name)
+		}
+	}
+
+	// Files
+	for _, a := range attachments {
+		err = addRepoBundleFile(zw, path.Join(repoBundleAttachmentsDir, a.UUID), a.LocalPath())
+		if err != nil {
+			return errors.Wrapf(err, "add attachment %q", a.UUID)
+		}
+	}
+	for _, obj := range lfsObjects {
+		if obj.Storage != lfsutil.StorageLocal {
+			return errors.Errorf("unsupported storage %q of LFS object %q", obj.Storage, obj.OID)
+		}
+		storage := &lfsutil.LocalStorage{Root: conf.LFS.ObjectsPath}
+		fw, err := zw.Create(path.Join(repoBundleLFSDir, string(obj.OID)))
+		if err != nil {
+			return errors.Wrapf(err, "create LFS object %q", obj.OID)
+		}
+		if err = storage.Download(obj.OID, fw); err != nil {
+			return errors.Wrapf(err, "add LFS object %q", obj.OID)
+		}
+	}
+	return nil
+}
+
+// addRepoBundleDir adds all files in the Git repository of dir to the zip
+// archive under the prefix, except server-side hooks.
+func addRepoBundleDir(zw *zip.Writer, prefix, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		} else if rel == "." {
+			return nil
+		} else if isRepoBundleHooksDir(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		} else if !d.Type().IsRegular() {
+			return nil
+		}
+		return addRepoBundleFile(zw, path.Join(prefix, filepath.ToSlash(rel)), p)
+	})
+}
+
+// addRepoBundleFile adds the file on the local path to the zip archive with
+// the name.
+func addRepoBundleFile(zw *zip.Writer, name, localPath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// writeRepoBundleRecords writes records as JSON lines to the data file of the
+// table.
+func writeRepoBundleRecords[T any](zw *zip.Writer, table string, records []*T) error {
+	w, err := zw.Create(path.Join(repoBundleDataDir, table+".json"))
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	for _, record := range records {
+		if err = enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readRepoBundleRecords reads records in JSON lines from the data file of the
+// table. It returns nil if the data file does not exist.
+func readRepoBundleRecords[T any](zr *zip.Reader, table string) ([]*T, error) {
+	f, err := zr.Open(path.Join(repoBundleDataDir, table+".json"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var records []*T
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		record := new(T)
+		err = dec.Decode(record)
+		if err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// repoBundleDirSize returns the total uncompressed size of files under the
+// prefix in the zip archive.
+func repoBundleDirSize(zr *zip.Reader, prefix string) int64 {
+	var size int64
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, prefix+"/") {
+			size += int64(f.UncompressedSize64)
+		}
+	}
+	return size
+}
+
+// extractRepoBundleDir extracts Git files under the prefix in the zip archive
+// to the dir, see isRepoBundleGitFile for which files are extracted. It returns
+// false if no such file is found under the prefix.
+func extractRepoBundleDir(zr *zip.Reader, prefix, dir string) (bool, error) {
+	found := false
+	for _, f := range zr.File {
+		if !strings.HasPrefix(f.Name, prefix+"/") || f.FileInfo().IsDir() {
+			continue
+		}
+
+		rel := path.Clean(strings.TrimPrefix(f.Name, prefix+"/"))
+		if rel == "." || path.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, "../") {
+			return false, errors.Errorf("invalid file path %q", f.Name)
+		} else if !isRepoBundleGitFile(rel) {
+			continue
+		}
+		found = true
+
+		dst := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := extractRepoBundleFile(f, dst); err != nil {
+			return false, errors.Wrapf(err, "extract %q", f.Name)
+		}
+	}
+	return found, nil
+}
+
+func extractRepoBundleFile(f *zip.File, dst string) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	if err = os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	w, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// ImportRepoOptions contains options for importing a repository bundle.
+type ImportRepoOptions struct {
+	// Name is the name of the new repository. The original name of the bundle is
+	// used when empty.
+	Name string
+}
+
+// ImportRepository creates a new repository for the owner from the bundle
+// read by zr. Users referenced in the bundle are mapped to local users by
+// email addresses, and to the doer if no match.
+func ImportRepository(ctx context.Context, doer, owner *User, zr *zip.Reader, opts ImportRepoOptions) (_ *Repository, err error) {
+	f, err := zr.Open(repoBundleMetadataFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "open metadata")
+	}
+	var metadata repoBundleMetadata
+	err = json.NewDecoder(f).Decode(&metadata)
+	_ = f.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "decode metadata")
+	} else if metadata.Version != currentRepoBundleVersion {
+		return nil, errors.Errorf("unsupported bundle version %d", metadata.Version)
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = metadata.Repository.Name
+	}
+
+	err = Handle.Quotas().Check(ctx, owner, QuotaTypeRepository, repoBundleDirSize(zr, repoBundleRepositoryDir)+repoBundleDirSize(zr, repoBundleWikiDir))
+	if err != nil {
+		return nil, err
+	}
+	err = Handle.Quotas().Check(ctx, owner, QuotaTypeLFS, repoBundleDirSize(zr, repoBundleLFSDir))
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := CreateRepository(doer, owner, CreateRepoOptionsLegacy{
+		Name:        name,
+		Description: metadata.Repository.Description,
+		IsPrivate:   metadata.Repository.IsPrivate,
+		IsUnlisted:  metadata.Repository.IsUnlisted,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	importer := &repoBundleImporter{
+		zr:    zr,
+		doer:  doer,
+		owner: owner,
+		repo:  repo,
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		for _, p := range importer.files {
+			_ = os.Remove(p)
+		}
+		if deleteErr := DeleteRepository(owner.ID, repo.ID); deleteErr != nil {
+			log.Error("Failed to delete repository %d for import failure: %v", repo.ID, deleteErr)
+		}
+	}()
+
+	if err = importer.importGit(); err != nil {
+		return nil, errors.Wrap(err, "import Git data")
+	}
+	if err = importer.importLFSObjects(ctx); err != nil {
+		return nil, errors.Wrap(err, "import LFS objects")
+	}
+	if err = importer.importRecords(ctx, metadata.Repository); err != nil {
+		return nil, errors.Wrap(err, "import records")
+	}
+
+	if err = repo.UpdateSize(); err != nil {
+		log.Error("UpdateSize [repo_id: %d]: %v", repo.ID, err)
+	}
+	return repo, nil
+}
+
+// repoBundleImporter imports data of a repository bundle.
+type repoBundleImporter struct {
+	zr    *zip.Reader
+	doer  *User
+	owner *User
+	repo  *Repository
+
+	// files is the list of files created outside the repository, which need to
+	// be cleaned up when the import fails.
+	files []string
+}
+
+func (im *repoBundleImporter) importGit() error {
+	repoPath := im.repo.RepoPath()
+	RemoveAllWithNotice("Repository path erase before import", repoPath)
+	found, err := extractRepoBundleDir(im.zr, repoBundleRepositoryDir, repoPath)
+	if err != nil {
+		return errors.Wrap(err, "extract repository")
+	} else if !found {
+		return errors.New("no repository found in the bundle")
+	}
+
+	paths := []string{repoPath}
+	wikiPath := im.repo.WikiPath()
+	found, err = extractRepoBundleDir(im.zr, repoBundleWikiDir, wikiPath)
+	if err != nil {
+		return errors.Wrap(err, "extract wiki")
+	} else if found {
+		paths = append(paths, wikiPath)
+	}
+
+	prefixes := []string{repoBundleRepositoryDir, repoBundleWikiDir}
+	for i, p := range paths {
+		if err = initRepoBundleGit(im.zr, prefixes[i], p); err != nil {
+			return errors.Wrap(err, "initialize repository")
+		} else if err = os.MkdirAll(filepath.Join(p, "hooks"), os.ModePerm); err != nil {
+			return errors.Wrap(err, "create hooks directory")
+		} else if err = createDelegateHooks(p); err != nil {
+			return errors.Wrap(err, "create delegate hooks")
+		}
+	}
+	return nil
+}
+
+// initRepoBundleGit (re)initializes the extracted Git repository in dir to
+// generate a fresh config, then copies allowed keys from the config of the
+// bundle under the prefix, see repoBundleConfigKeys.
+func initRepoBundleGit(zr *zip.Reader, prefix, dir string) error {
+	err := git.Init(dir, git.InitOptions{Bare: true})
+	if err != nil {
+		return errors.Wrap(err, "init")
+	}
+
+	f, err := zr.Open(path.Join(prefix, "config"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return errors.Wrap(err, "open config")
+	}
+	defer func() { _ = f.Close() }()
+
+	tmp, err := os.CreateTemp("", "gogs-bundle-config-")
+	if err != nil {
+		return errors.Wrap(err, "create temporary file")
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	_, err = io.Copy(tmp, f)
+	_ = tmp.Close()
+	if err != nil {
+		return errors.Wrap(err, "copy config")
+	}
+
+	configPath := filepath.Join(dir, "config")
+	for _, key := range repoBundleConfigKeys {
+		// The key is not set when the command fails
+		stdout, _, err := process.Exec("initRepoBundleGit(get "+key+")", "git", "config", "--file", tmp.Name(), "--get", key)
+		value := strings.TrimSpace(stdout)
+		if err != nil || value == "" {
+			continue
+		}
+
+		_, stderr, err := process.Exec("initRepoBundleGit(set "+key+")", "git", "config", "--file", configPath, key, value)
+		if err != nil {
+			return errors.Wrapf(err, "set %q: %s", key, stderr)
+		}
+	}
+	return nil
+}
+
+func (im *repoBundleImporter) importLFSObjects(ctx context.Context) error {
+	objects, err := readRepoBundleRecords[LFSObject](im.zr, "lfs_objects")
+	if err != nil {
+		return errors.Wrap(err, "read records")
+	}
+
+	storage := &lfsutil.LocalStorage{Root: conf.LFS.ObjectsPath}
+	for _, obj := range objects {
+		if !lfsutil.ValidOID(obj.OID) {
+			return errors.Errorf("invalid oid %q", obj.OID)
+		}
+
+		// LFS objects are content-addressed and may be shared with other
+		// repositories, thus never removed even if the import fails.
+		if !osutil.IsFile(filepath.Join(conf.LFS.ObjectsPath, string(obj.OID[0]), string(obj.OID[1]), string(obj.OID))) {
+			f, err := im.zr.Open(path.Join(repoBundleLFSDir, string(obj.OID)))
+			if err != nil {
+				return errors.Wrapf(err, "open %q", obj.OID)
+			}
+			if _, err = storage.Upload(obj.OID, f); err != nil {
+				return errors.Wrapf(err, "upload %q", obj.OID)
+			}
+		}
+
+		err = Handle.LFS().CreateObject(ctx, im.repo.ID, obj.OID, obj.Size, storage.Storage())
+		if err != nil {
+			return errors.Wrapf(err, "create object %q", obj.OID)
+		}
+	}
+	return nil
+}
+
+// userMapper returns a function that maps IDs of users in the bundle to local
+// users by email addresses.
+func (im *repoBundleImporter) userMapper(ctx context.Context) (func(id int64) int64, error) {
+	users, err := readRepoBundleRecords[repoBundleUser](im.zr, "users")
+	if err != nil {
+		return nil, errors.Wrap(err, "read users")
+	}
+
+	userIDs := make(map[int64]int64, len(users))
+	for _, u := range users {
+		if u.Email == "" {
+			continue
+		}
+
+		local, err := Handle.Users().GetByEmail(ctx, u.Email)
+		if err != nil {
+			if IsErrUserNotExist(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "get user by email %q", u.Email)
+		}
+		userIDs[u.ID] = local.ID
+	}
+	return func(id int64) int64 {
+		if id <= 0 {
+			return 0
+		} else if local, ok := userIDs[id]; ok {
+			return local
+		}
+		return im.doer.ID
+	}, nil
+}
+
+func (im *repoBundleImporter) importRecords(ctx context.Context, settings repoBundleRepository) (err error) {
+	userID, err := im.userMapper(ctx)
+	if err != nil {
+		return err
+	}
+
+	labels, err := readRepoBundleRecords[Label](im.zr, "labels")
+	if err != nil {
+		return errors.Wrap(err, "read labels")
+	}
+	milestones, err := readRepoBundleRecords[Milestone](im.zr, "milestones")
+	if err != nil {
+		return errors.Wrap(err, "read milestones")
+	}
+	issues, err := readRepoBundleRecords[Issue](im.zr, "issues")
+	if err != nil {
+		return errors.Wrap(err, "read issues")
+	}
+	issueLabels, err := readRepoBundleRecords[IssueLabel](im.zr, "issue_labels")
+	if err != nil {
+		return errors.Wrap(err, "read issue labels")
+	}
+	issueAssignees, err := readRepoBundleRecords[IssueAssignee](im.zr, "issue_assignees")
+	if err != nil {
+		return errors.Wrap(err, "read issue assignees")
+	}
+	assigneeIDs := make(map[int64][]int64, len(issueAssignees))
+	for _, a := range issueAssignees {
+		assigneeIDs[a.IssueID] = append(assigneeIDs[a.IssueID], userID(a.AssigneeID))
+	}
+	comments, err := readRepoBundleRecords[Comment](im.zr, "comments")
+	if err != nil {
+		return errors.Wrap(err, "read comments")
+	}
+	reactions, err := readRepoBundleRecords[Reaction](im.zr, "reactions")
+	if err != nil {
+		return errors.Wrap(err, "read reactions")
+	}
+	pulls, err := readRepoBundleRecords[PullRequest](im.zr, "pull_requests")
+	if err != nil {
+		return errors.Wrap(err, "read pull requests")
+	}
+	releases, err := readRepoBundleRecords[Release](im.zr, "releases")
+	if err != nil {
+		return errors.Wrap(err, "read releases")
+	}
+	attachments, err := readRepoBundleRecords[Attachment](im.zr, "attachments")
+	if err != nil {
+		return errors.Wrap(err, "read attachments")
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	repo := im.repo
+	labelIDs := make(map[int64]int64, len(labels))
+	for _, l := range labels {
+		oldID := l.ID
+		l.ID = 0
+		l.RepoID = repo.ID
+		if _, err = sess.Insert(l); err != nil {
+			return errors.Wrap(err, "insert label")
+		}
+		labelIDs[oldID] = l.ID
+	}
+
+	milestoneIDs := make(map[int64]int64, len(milestones))
+	for _, m := range milestones {
+		oldID := m.ID
+		m.ID = 0
+		m.RepoID = repo.ID
+		// The hook of insertion sets the deadline from the unexported field.
+		m.Deadline = time.Unix(m.DeadlineUnix, 0)
+		if _, err = sess.Insert(m); err != nil {
+			return errors.Wrap(err, "insert milestone")
+		}
+		milestoneIDs[oldID] = m.ID
+
+		repo.NumMilestones++
+		if m.IsClosed {
+			repo.NumClosedMilestones++
+		}
+	}
+
+	issueIDs := make(map[int64]int64, len(issues))
+	for _, issue := range issues {
+		oldID := issue.ID
+		created, updated := issue.CreatedUnix, issue.UpdatedUnix
+		issue.ID = 0
+		issue.RepoID = repo.ID
+		issue.PosterID = userID(issue.PosterID)
+		issue.MilestoneID = milestoneIDs[issue.MilestoneID]
+		if _, err = sess.Insert(issue); err != nil {
+			return errors.Wrap(err, "insert issue")
+		}
+		issueIDs[oldID] = issue.ID
+
+		// Reset timestamps back to the original ones because Insert method
+		// updates their values.
+		if _, err = sess.Exec("UPDATE `issue` SET created_unix = ?, updated_unix = ? WHERE id = ?", created, updated, issue.ID); err != nil {
+			return errors.Wrap(err, "reset issue timestamps")
+		}
+		if err = newIssueUsers(sess, repo, issue); err != nil {
+			return errors.Wrap(err, "new issue users")
+		}
+		if len(assigneeIDs[oldID]) > 0 {
+			if _, _, err = changeIssueAssignees(sess, issue, assigneeIDs[oldID]); err != nil {
+				return errors.Wrap(err, "change issue assignees")
+			}
+		}
+		if issue.IsClosed {
+			if err = updateIssueUsersByStatus(sess, issue.ID, true); err != nil {
+				return errors.Wrap(err, "update issue users")
+			}
+		}
+
+		if issue.IsPull {
+			repo.NumPulls++
+			if issue.IsClosed {
+				repo.NumClosedPulls++
+			}
+		} else {
+			repo.NumIssues++
+			if issue.IsClosed {
+				repo.NumClosedIssues++
+			}
+		}
+	}
+
+	for _, il := range issueLabels {
+		il.ID = 0
+		il.IssueID = issueIDs[il.IssueID]
+		il.LabelID = labelIDs[il.LabelID]
+		if il.IssueID == 0 || il.LabelID == 0 {
+			continue
+		}
+		if _, err = sess.Insert(il); err != nil {
+			return errors.Wrap(err, "insert issue label")
+		}
+	}
+
+	commentIDs := make(map[int64]int64, len(comments))
+	for _, c := range comments {
+		oldID := c.ID
+		created, updated := c.CreatedUnix, c.UpdatedUnix
+		c.ID = 0
+		c.IssueID = issueIDs[c.IssueID]
+		c.PosterID = userID(c.PosterID)
+		c.LabelID = labelIDs[c.LabelID]
+		c.AssigneeID = userID(c.AssigneeID)
+		c.OldMilestoneID = milestoneIDs[c.OldMilestoneID]
+		c.MilestoneID = milestoneIDs[c.MilestoneID]
+		if _, err = sess.Insert(c); err != nil {
+			return errors.Wrap(err, "insert comment")
+		}
+		commentIDs[oldID] = c.ID
+
+		if _, err = sess.Exec("UPDATE `comment` SET created_unix = ?, updated_unix = ? WHERE id = ?", created, updated, c.ID); err != nil {
+			return errors.Wrap(err, "reset comment timestamps")
+		}
+	}
+
+	// Reactions of different users may collapse into the same one when they are
+	// mapped to the same local user.
+	seenReactions := make(map[Reaction]bool, len(reactions))
+	for _, r := range reactions {
+		r.ID = 0
+		r.UserID = userID(r.UserID)
+		r.IssueID = issueIDs[r.IssueID]
+		if r.CommentID > 0 {
+			r.CommentID = commentIDs[r.CommentID]
+			if r.CommentID == 0 {
+				continue
+			}
+		}
+		key := Reaction{UserID: r.UserID, IssueID: r.IssueID, CommentID: r.CommentID, Type: r.Type}
+		if r.IssueID == 0 || seenReactions[key] {
+			continue
+		}
+		seenReactions[key] = true
+
+		if _, err = sess.Insert(r); err != nil {
+			return errors.Wrap(err, "insert reaction")
+		}
+	}
+	_, err = sess.Exec("UPDATE `issue` SET num_reactions = (SELECT COUNT(*) FROM `reaction` WHERE reaction.issue_id = issue.id AND reaction.comment_id = 0) WHERE repo_id = ?", repo.ID)
+	if err != nil {
+		return errors.Wrap(err, "update issue reaction counts")
+	}
+
+	for _, pull := range pulls {
+		// Pull requests from forks lose their head repositories, the same as
+		// the forks have been deleted.
+		if pull.HeadRepoID == pull.BaseRepoID {
+			pull.HeadRepoID = repo.ID
+			pull.HeadUserName = im.owner.Name
+		} else {
+			pull.HeadRepoID = 0
+		}
+		pull.ID = 0
+		pull.IssueID = issueIDs[pull.IssueID]
+		pull.BaseRepoID = repo.ID
+		pull.MergerID = userID(pull.MergerID)
+		if _, err = sess.Insert(pull); err != nil {
+			return errors.Wrap(err, "insert pull request")
+		}
+	}
+
+	releaseIDs := make(map[int64]int64, len(releases))
+	for _, r := range releases {
+		oldID := r.ID
+		r.ID = 0
+		r.RepoID = repo.ID
+		r.PublisherID = userID(r.PublisherID)
+		if _, err = sess.Insert(r); err != nil {
+			return errors.Wrap(err, "insert release")
+		}
+		releaseIDs[oldID] = r.ID
+	}
+
+	for _, a := range attachments {
+		created := a.CreatedUnix
+		uuid := a.UUID
+		a.ID = 0
+		// Attachments get new UUIDs in case the bundle is imported to the same
+		// instance.
+		a.UUID = gouuid.NewV4().String()
+		a.IssueID = issueIDs[a.IssueID]
+		a.CommentID = commentIDs[a.CommentID]
+		a.ReleaseID = releaseIDs[a.ReleaseID]
+		a.UploaderID = userID(a.UploaderID)
+
+		f, err := im.zr.Open(path.Join(repoBundleAttachmentsDir, path.Base(uuid)))
+		if err != nil {
+			return errors.Wrapf(err, "open attachment %q", uuid)
+		}
+		localPath := a.LocalPath()
+		im.files = append(im.files, localPath)
+		err = copyToFile(f, localPath)
+		_ = f.Close()
+		if err != nil {
+			return errors.Wrapf(err, "copy attachment %q", uuid)
+		}
+
+		if _, err = sess.Insert(a); err != nil {
+			return errors.Wrap(err, "insert attachment")
+		}
+		if _, err = sess.Exec("UPDATE `attachment` SET created_unix = ? WHERE id = ?", created, a.ID); err != nil {
+			return errors.Wrap(err, "reset attachment timestamp")
+		}
+	}
+
+	repo.Website = settings.Website
+	repo.DefaultBranch = settings.DefaultBranch
+	repo.IsBare = settings.IsBare
+	repo.EnableWiki = settings.EnableWiki
+	repo.AllowPublicWiki = settings.AllowPublicWiki
+	repo.EnableIssues = settings.EnableIssues
+	repo.AllowPublicIssues = settings.AllowPublicIssues
+	repo.AllowClosingBlocked = settings.AllowClosingBlocked
+	repo.EnablePulls = settings.EnablePulls
+	repo.PullsIgnoreWhitespace = settings.PullsIgnoreWhitespace
+	repo.PullsAllowRebase = settings.PullsAllowRebase
+	if err = updateRepository(sess, repo, false); err != nil {
+		return errors.Wrap(err, "update repository")
+	}
+	return sess.Commit()
+}
+
+// copyToFile copies content of r to a new file on the local path.
+func copyToFile(r io.Reader, localPath string) error {
+	if err := os.MkdirAll(filepath.Dir(localPath), os.ModePerm); err != nil {
+		return err
+	}
+	w, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+
+	_, err = io.Copy(w, r)
+	return err
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/process"
+)
+
+func newTestZip(t *testing.T, files map[string]string) *zip.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	return zr
+}
+
+func TestRepoBundleRecords(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	labels := []*Label{
+		{ID: 1, RepoID: 1, Name: "bug", Color: "#ee0701", NumIssues: 2},
+		{ID: 2, RepoID: 1, Name: "feature", Color: "#84b6eb"},
+	}
+	err := writeRepoBundleRecords(zw, "labels", labels)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	got, err := readRepoBundleRecords[Label](zr, "labels")
+	require.NoError(t, err)
+	assert.Equal(t, labels, got)
+
+	// Missing data files are treated as no records
+	got, err = readRepoBundleRecords[Label](zr, "milestones")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestExtractRepoBundleDir(t *testing.T) {
+	t.Run("skip hooks", func(t *testing.T) {
+		zr := newTestZip(t, map[string]string{
+			"repository/HEAD":                     "ref: refs/heads/main\n",
+			"repository/refs/heads/main":          "0000000000000000000000000000000000000000\n",
+			"repository/hooks/pre-receive":        "#!/bin/sh\nexit 1\n",
+			"repository/custom_hooks/post-update": "#!/bin/sh\nexit 1\n",
+			"wiki/HEAD":                           "ref: refs/heads/master\n",
+		})
+		assert.Equal(t, int64(96), repoBundleDirSize(zr, "repository"))
+
+		dir := t.TempDir()
+		found, err := extractRepoBundleDir(zr, "repository", dir)
+		require.NoError(t, err)
+		assert.True(t, found)
+
+		p, err := os.ReadFile(filepath.Join(dir, "HEAD"))
+		require.NoError(t, err)
+		assert.Equal(t, "ref: refs/heads/main\n", string(p))
+		assert.FileExists(t, filepath.Join(dir, "refs", "heads", "main"))
+		assert.NoDirExists(t, filepath.Join(dir, "hooks"))
+		assert.NoDirExists(t, filepath.Join(dir, "custom_hooks"))
+
+		found, err = extractRepoBundleDir(zr, "lfs", t.TempDir())
+		require.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("path traversal", func(t *testing.T) {
+		zr := newTestZip(t, map[string]string{
+			"repository/../../evil": "evil",
+		})
+		_, err := extractRepoBundleDir(zr, "repository", t.TempDir())
+		assert.EqualError(t, err, `invalid file path "repository/../../evil"`)
+	})
+}
+
+func TestRepoBundleImporter_importGit(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	conf.SetMockRepository(t, conf.RepositoryOpts{Root: t.TempDir()})
+
+	evil := filepath.Join(t.TempDir(), "evil")
+	zr := newTestZip(t, map[string]string{
+		"repository/HEAD":                    "ref: refs/heads/main\n",
+		"repository/refs/heads/main":         "0000000000000000000000000000000000000000\n",
+		"repository/config":                  "[core]\n\tbare = true\n\thooksPath = " + evil + "\n\tfsmonitor = " + evil + "\n\tbigFileThreshold = 1m\n",
+		"repository/objects/info/alternates": evil + "\n",
+		"repository/objects/info/packs":      "\n",
+		"repository/info/attributes":         "* filter=evil\n",
+	})
+	im := &repoBundleImporter{
+		zr: zr,
+		repo: &Repository{
+			Name:  "bundle",
+			Owner: &User{Name: "alice"},
+		},
+	}
+	err := im.importGit()
+	require.NoError(t, err)
+
+	repoPath := im.repo.RepoPath()
+	assert.FileExists(t, filepath.Join(repoPath, "objects", "info", "packs"))
+	assert.NoFileExists(t, filepath.Join(repoPath, "objects", "info", "alternates"))
+	assert.NoFileExists(t, filepath.Join(repoPath, "info", "attributes"))
+	assert.FileExists(t, filepath.Join(repoPath, "hooks", "pre-receive"))
+
+	gitConfig := func(key string) string {
+		stdout, _, _ := process.Exec("test", "git", "config", "--file", filepath.Join(repoPath, "config"), "--get", key)
+		return strings.TrimSpace(stdout)
+	}
+	assert.Empty(t, gitConfig("core.hooksPath"))
+	assert.Empty(t, gitConfig("core.fsmonitor"))
+	assert.Equal(t, "true", gitConfig("core.bare"))
+	assert.Equal(t, "1m", gitConfig("core.bigFileThreshold"))
+}
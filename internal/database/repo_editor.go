@@ -39,6 +39,7 @@ const (
 	ENV_REPO_OWNER_SALT_MD5    = "GOGS_REPO_OWNER_SALT_MD5"
 	ENV_REPO_ID                = "GOGS_REPO_ID"
 	ENV_REPO_NAME              = "GOGS_REPO_NAME"
+	ENV_REPO_PATH              = "GOGS_REPO_PATH"
 	ENV_REPO_CUSTOM_HOOKS_PATH = "GOGS_REPO_CUSTOM_HOOKS_PATH"
 )
 
@@ -61,6 +62,7 @@ func ComposeHookEnvs(opts ComposeHookEnvsOptions) []string {
 		ENV_REPO_OWNER_SALT_MD5 + "=" + cryptoutil.MD5(opts.OwnerSalt),
 		ENV_REPO_ID + "=" + com.ToStr(opts.RepoID),
 		ENV_REPO_NAME + "=" + opts.RepoName,
+		ENV_REPO_PATH + "=" + opts.RepoPath,
 		ENV_REPO_CUSTOM_HOOKS_PATH + "=" + filepath.Join(opts.RepoPath, "custom_hooks"),
 	}
 	return envs
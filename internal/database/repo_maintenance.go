@@ -0,0 +1,330 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogs/git-module"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	log "unknwon.dev/clog/v2"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/process"
+)
+
+// RepoMaintenance is the maintenance status of a repository.
+type RepoMaintenance struct {
+	RepoID int64 `gorm:"primaryKey;autoIncrement:false"`
+	// The number of pushes since the last maintenance.
+	Pushes int64 `gorm:"not null;default:0"`
+	// The number of loose objects and packs after the last maintenance.
+	LooseObjects int64 `gorm:"not null;default:0"`
+	Packs        int64 `gorm:"not null;default:0"`
+	// The comma-separated list of tasks performed in the last maintenance.
+	Tasks string
+	// The error occurred in the last maintenance, if any.
+	Error string `gorm:"type:TEXT"`
+	// The duration (in milliseconds) of the last maintenance.
+	Duration int64 `gorm:"not null;default:0"`
+
+	Maintained     time.Time `gorm:"-" json:"-"`
+	MaintainedUnix int64
+	LastGC         time.Time `gorm:"-" json:"-"`
+	LastGCUnix     int64
+}
+
+// AfterFind implements the GORM query hook.
+func (m *RepoMaintenance) AfterFind(*gorm.DB) error {
+	if m.MaintainedUnix > 0 {
+		m.Maintained = time.Unix(m.MaintainedUnix, 0).Local()
+	}
+	if m.LastGCUnix > 0 {
+		m.LastGC = time.Unix(m.LastGCUnix, 0).Local()
+	}
+	return nil
+}
+
+// RepoMaintenanceStore is the storage layer for repository maintenance.
+type RepoMaintenanceStore struct {
+	db *gorm.DB
+}
+
+func newRepoMaintenanceStore(db *gorm.DB) *RepoMaintenanceStore {
+	return &RepoMaintenanceStore{db: db}
+}
+
+// IncrementPushes increments the number of pushes since the last maintenance of
+// the given repository.
+func (s *RepoMaintenanceStore) IncrementPushes(ctx context.Context, repoID int64) error {
+	// Use upsert because concurrent first pushes of the same repository would
+	// both try to create the status.
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "repo_id"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"pushes": gorm.Expr("repo_maintenance.pushes + 1"),
+			}),
+		}).
+		Create(
+			&RepoMaintenance{
+				RepoID: repoID,
+				Pushes: 1,
+			},
+		).Error
+}
+
+// GetByRepoID returns the maintenance status of the given repository. It
+// returns a zero status when the repository has never been pushed to nor
+// maintained.
+func (s *RepoMaintenanceStore) GetByRepoID(ctx context.Context, repoID int64) (*RepoMaintenance, error) {
+	status := new(RepoMaintenance)
+	err := s.db.WithContext(ctx).Where("repo_id = ?", repoID).First(status).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &RepoMaintenance{RepoID: repoID}, nil
+		}
+		return nil, err
+	}
+	return status, nil
+}
+
+// ListByRepoIDs returns the maintenance statuses of given repositories, keyed by
+// repository ID. Repositories that have never been pushed to nor maintained are
+// not included.
+func (s *RepoMaintenanceStore) ListByRepoIDs(ctx context.Context, repoIDs ...int64) (map[int64]*RepoMaintenance, error) {
+	if len(repoIDs) == 0 {
+		return map[int64]*RepoMaintenance{}, nil
+	}
+
+	var statuses []*RepoMaintenance
+	err := s.db.WithContext(ctx).Where("repo_id IN (?)", repoIDs).Find(&statuses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[int64]*RepoMaintenance, len(statuses))
+	for _, status := range statuses {
+		m[status.RepoID] = status
+	}
+	return m, nil
+}
+
+// RecordRepoMaintenanceOptions contains options for recording the result of a
+// repository maintenance.
+type RecordRepoMaintenanceOptions struct {
+	// The number of pushes that were observed before the maintenance.
+	Pushes       int64
+	LooseObjects int64
+	Packs        int64
+	Tasks        []RepoMaintenanceTask
+	Error        error
+	Duration     time.Duration
+}
+
+// Record records the result of a maintenance of the given repository. Pushes
+// happened during the maintenance are kept for the next maintenance.
+func (s *RepoMaintenanceStore) Record(ctx context.Context, repoID int64, opts RecordRepoMaintenanceOptions) error {
+	tasks := make([]string, 0, len(opts.Tasks))
+	gced := false
+	for _, task := range opts.Tasks {
+		tasks = append(tasks, string(task))
+		if task == RepoMaintenanceTaskGC {
+			gced = true
+		}
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("repo_id = ?", repoID).First(&RepoMaintenance{}).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			err = tx.Create(&RepoMaintenance{RepoID: repoID}).Error
+		}
+		if err != nil {
+			return errors.Wrap(err, "ensure status")
+		}
+
+		now := tx.NowFunc().Unix()
+		updates := map[string]any{
+			"pushes":          gorm.Expr("pushes - ?", opts.Pushes),
+			"loose_objects":   opts.LooseObjects,
+			"packs":           opts.Packs,
+			"tasks":           strings.Join(tasks, ","),
+			"error":           "",
+			"duration":        opts.Duration.Milliseconds(),
+			"maintained_unix": now,
+		}
+		if opts.Error != nil {
+			updates["error"] = opts.Error.Error()
+		} else if gced {
+			updates["last_gc_unix"] = now
+		}
+		return tx.Model(&RepoMaintenance{}).Where("repo_id = ?", repoID).Updates(updates).Error
+	})
+}
+
+// RepoMaintenanceTask is a task of repository maintenance.
+type RepoMaintenanceTask string
+
+const (
+	// RepoMaintenanceTaskRepack packs loose objects into a new pack without
+	// touching existing packs.
+	RepoMaintenanceTaskRepack RepoMaintenanceTask = "repack"
+	// RepoMaintenanceTaskCommitGraph writes the commit-graph file incrementally.
+	RepoMaintenanceTaskCommitGraph RepoMaintenanceTask = "commit-graph"
+	// RepoMaintenanceTaskGC runs a full garbage collection, which also
+	// consolidates all packs and writes the commit-graph file.
+	RepoMaintenanceTaskGC RepoMaintenanceTask = "gc"
+)
+
+// repoMaintenanceTasks returns the list of tasks needed for a repository with
+// the given maintenance status and object counts. It returns nil if the
+// repository does not need any maintenance.
+func repoMaintenanceTasks(status *RepoMaintenance, count *git.CountObject, now time.Time) []RepoMaintenanceTask {
+	opts := conf.Cron.RepoMaintenance
+
+	if count.Packs > opts.PacksThreshold ||
+		(status.Pushes > 0 && now.Sub(time.Unix(status.LastGCUnix, 0)) > opts.GCInterval) {
+		return []RepoMaintenanceTask{RepoMaintenanceTaskGC}
+	}
+
+	var tasks []RepoMaintenanceTask
+	if count.Count > opts.LooseObjectsThreshold {
+		tasks = append(tasks, RepoMaintenanceTaskRepack)
+	}
+	if status.Pushes > 0 {
+		tasks = append(tasks, RepoMaintenanceTaskCommitGraph)
+	}
+	return tasks
+}
+
+var repoMaintenanceTaskArgs = map[RepoMaintenanceTask][]string{
+	RepoMaintenanceTaskRepack:      {"repack", "-d", "-l"},
+	RepoMaintenanceTaskCommitGraph: {"commit-graph", "write", "--reachable", "--split"},
+}
+
+// runRepoMaintenanceTask runs the maintenance task in the given repository.
+func runRepoMaintenanceTask(repoPath string, task RepoMaintenanceTask) error {
+	args := repoMaintenanceTaskArgs[task]
+	if task == RepoMaintenanceTaskGC {
+		args = append([]string{"gc"}, conf.Git.GCArgs...)
+	}
+
+	_, stderr, err := process.ExecDir(
+		conf.Cron.RepoMaintenance.Timeout,
+		repoPath, fmt.Sprintf("Repository maintenance (%s)", task),
+		"git", args...)
+	if err != nil {
+		return fmt.Errorf("%s: %v - %s", task, err, stderr)
+	}
+	return nil
+}
+
+// maintainRepository performs maintenance tasks needed for the repository.
+func maintainRepository(ctx context.Context, repoID int64, repoPath string) {
+	store := Handle.RepoMaintenance()
+	status, err := store.GetByRepoID(ctx, repoID)
+	if err != nil {
+		log.Error("Failed to get maintenance status [repo_id: %d]: %v", repoID, err)
+		return
+	}
+
+	count, err := git.CountObjects(repoPath)
+	if err != nil {
+		log.Error("Failed to count objects [repo_id: %d]: %v", repoID, err)
+		return
+	}
+
+	tasks := repoMaintenanceTasks(status, count, time.Now())
+	if len(tasks) == 0 {
+		return
+	}
+
+	start := time.Now()
+	var runErr error
+	for _, task := range tasks {
+		runErr = runRepoMaintenanceTask(repoPath, task)
+		if runErr != nil {
+			break
+		}
+	}
+
+	opts := RecordRepoMaintenanceOptions{
+		Pushes:   status.Pushes,
+		Tasks:    tasks,
+		Error:    runErr,
+		Duration: time.Since(start),
+	}
+	if count, err = git.CountObjects(repoPath); err == nil {
+		opts.LooseObjects = count.Count
+		opts.Packs = count.Packs
+	}
+	err = store.Record(ctx, repoID, opts)
+	if err != nil {
+		log.Error("Failed to record maintenance status [repo_id: %d]: %v", repoID, err)
+	}
+
+	if runErr != nil {
+		desc := fmt.Sprintf("Failed to perform maintenance on repository '%s': %v", repoPath, runErr)
+		log.Warn(desc)
+		if err = Handle.Notices().Create(ctx, NoticeTypeRepository, desc); err != nil {
+			log.Error("CreateRepositoryNotice: %v", err)
+		}
+	}
+}
+
+// PerformRepoMaintenance performs maintenance tasks on repositories that need
+// them, based on the number of pushes and objects of each repository.
+func PerformRepoMaintenance() {
+	if taskStatusTable.IsRunning(_REPO_MAINTENANCE) {
+		return
+	}
+	taskStatusTable.Start(_REPO_MAINTENANCE)
+	defer taskStatusTable.Stop(_REPO_MAINTENANCE)
+
+	log.Trace("Doing: PerformRepoMaintenance")
+
+	type repoInfo struct {
+		id   int64
+		path string
+	}
+	var repos []repoInfo
+	if err := x.Where("id>0").Iterate(new(Repository),
+		func(idx int, bean any) error {
+			repo := bean.(*Repository)
+			repos = append(repos, repoInfo{
+				id:   repo.ID,
+				path: repo.RepoPath(),
+			})
+			return nil
+		}); err != nil {
+		log.Error("PerformRepoMaintenance: %v", err)
+		return
+	}
+
+	concurrency := conf.Cron.RepoMaintenance.MaxConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, repo := range repos {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(repo repoInfo) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			maintainRepository(context.Background(), repo.id, repo.path)
+		}(repo)
+	}
+	wg.Wait()
+}
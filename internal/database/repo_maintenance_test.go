@@ -0,0 +1,186 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogs/git-module"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+func TestRepoMaintenance(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	ctx := context.Background()
+	s := &RepoMaintenanceStore{
+		db: newTestDB(t, "RepoMaintenanceStore"),
+	}
+
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, s *RepoMaintenanceStore)
+	}{
+		{"IncrementPushes", repoMaintenanceIncrementPushes},
+		{"ListByRepoIDs", repoMaintenanceListByRepoIDs},
+		{"Record", repoMaintenanceRecord},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				err := clearTables(t, s.db)
+				require.NoError(t, err)
+			})
+			tc.test(t, ctx, s)
+		})
+		if t.Failed() {
+			break
+		}
+	}
+}
+
+func repoMaintenanceIncrementPushes(t *testing.T, ctx context.Context, s *RepoMaintenanceStore) {
+	status, err := s.GetByRepoID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, &RepoMaintenance{RepoID: 1}, status)
+
+	for i := 0; i < 3; i++ {
+		err = s.IncrementPushes(ctx, 1)
+		require.NoError(t, err)
+	}
+
+	status, err = s.GetByRepoID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), status.Pushes)
+}
+
+func repoMaintenanceListByRepoIDs(t *testing.T, ctx context.Context, s *RepoMaintenanceStore) {
+	err := s.IncrementPushes(ctx, 1)
+	require.NoError(t, err)
+	err = s.IncrementPushes(ctx, 2)
+	require.NoError(t, err)
+
+	statuses, err := s.ListByRepoIDs(ctx, 1, 3)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, int64(1), statuses[1].Pushes)
+
+	statuses, err = s.ListByRepoIDs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, statuses)
+}
+
+func repoMaintenanceRecord(t *testing.T, ctx context.Context, s *RepoMaintenanceStore) {
+	for i := 0; i < 3; i++ {
+		err := s.IncrementPushes(ctx, 1)
+		require.NoError(t, err)
+	}
+
+	// Pushes happened during the maintenance should be kept
+	err := s.Record(ctx, 1,
+		RecordRepoMaintenanceOptions{
+			Pushes:       2,
+			LooseObjects: 10,
+			Packs:        1,
+			Tasks:        []RepoMaintenanceTask{RepoMaintenanceTaskGC},
+			Duration:     1500 * time.Millisecond,
+		},
+	)
+	require.NoError(t, err)
+
+	status, err := s.GetByRepoID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), status.Pushes)
+	assert.Equal(t, int64(10), status.LooseObjects)
+	assert.Equal(t, int64(1), status.Packs)
+	assert.Equal(t, "gc", status.Tasks)
+	assert.Equal(t, int64(1500), status.Duration)
+	assert.Equal(t, s.db.NowFunc().Format(time.RFC3339), status.Maintained.UTC().Format(time.RFC3339))
+	assert.Equal(t, status.MaintainedUnix, status.LastGCUnix)
+
+	// Failed maintenance of a repository that has never been pushed to
+	err = s.Record(ctx, 2,
+		RecordRepoMaintenanceOptions{
+			Tasks: []RepoMaintenanceTask{RepoMaintenanceTaskGC},
+			Error: errors.New("gc: exit status 128"),
+		},
+	)
+	require.NoError(t, err)
+
+	status, err = s.GetByRepoID(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "gc: exit status 128", status.Error)
+	assert.Zero(t, status.LastGCUnix)
+}
+
+func Test_repoMaintenanceTasks(t *testing.T) {
+	before := conf.Cron.RepoMaintenance
+	t.Cleanup(func() {
+		conf.Cron.RepoMaintenance = before
+	})
+	conf.Cron.RepoMaintenance.LooseObjectsThreshold = 100
+	conf.Cron.RepoMaintenance.PacksThreshold = 10
+	conf.Cron.RepoMaintenance.GCInterval = 24 * time.Hour
+
+	now := time.Now()
+	recentGC := now.Add(-time.Hour).Unix()
+	tests := []struct {
+		name   string
+		status *RepoMaintenance
+		count  *git.CountObject
+		want   []RepoMaintenanceTask
+	}{
+		{
+			name:   "idle",
+			status: &RepoMaintenance{LastGCUnix: recentGC},
+			count:  &git.CountObject{Count: 100, Packs: 10},
+			want:   nil,
+		},
+		{
+			name:   "idle without any GC",
+			status: &RepoMaintenance{},
+			count:  &git.CountObject{Count: 1, Packs: 1},
+			want:   nil,
+		},
+		{
+			name:   "pushed",
+			status: &RepoMaintenance{Pushes: 1, LastGCUnix: recentGC},
+			count:  &git.CountObject{Count: 1, Packs: 1},
+			want:   []RepoMaintenanceTask{RepoMaintenanceTaskCommitGraph},
+		},
+		{
+			name:   "too many loose objects",
+			status: &RepoMaintenance{Pushes: 1, LastGCUnix: recentGC},
+			count:  &git.CountObject{Count: 101, Packs: 1},
+			want:   []RepoMaintenanceTask{RepoMaintenanceTaskRepack, RepoMaintenanceTaskCommitGraph},
+		},
+		{
+			name:   "too many packs",
+			status: &RepoMaintenance{LastGCUnix: recentGC},
+			count:  &git.CountObject{Count: 1, Packs: 11},
+			want:   []RepoMaintenanceTask{RepoMaintenanceTaskGC},
+		},
+		{
+			name:   "pushed since GC interval",
+			status: &RepoMaintenance{Pushes: 1, LastGCUnix: now.Add(-25 * time.Hour).Unix()},
+			count:  &git.CountObject{Count: 1, Packs: 1},
+			want:   []RepoMaintenanceTask{RepoMaintenanceTaskGC},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := repoMaintenanceTasks(test.status, test.count, now)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
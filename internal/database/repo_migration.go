@@ -0,0 +1,928 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogs/git-module"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	log "unknwon.dev/clog/v2"
+	"xorm.io/xorm"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/errutil"
+	"gogs.io/gogs/internal/migrate"
+)
+
+// RepoMigration is the migration of repository data (e.g. issues and pull
+// requests) from another code hosting service.
+type RepoMigration struct {
+	RepoID int64 `gorm:"primaryKey;autoIncrement:false"`
+	// The user who started the migration, who becomes the author of data that
+	// cannot be mapped to a local user.
+	DoerID  int64  `gorm:"not null"`
+	Service string `gorm:"not null"`
+	// The clone address without credentials.
+	CloneAddr string `gorm:"not null"`
+	// The access token to the API, it is cleared once the migration is done or
+	// failed.
+	AuthToken string
+	// The comma-separated list of stages to perform.
+	Stages string `gorm:"not null"`
+
+	// The checkpoint of the migration.
+	Stage      string
+	Page       int   `gorm:"not null;default:0"`
+	LastNumber int64 `gorm:"not null;default:0"`
+	LastIndex  int64 `gorm:"not null;default:0"`
+	// The error occurred in the last run of the migration, if any.
+	Error string `gorm:"type:TEXT"`
+
+	Created     time.Time `xorm:"-" gorm:"-" json:"-"`
+	CreatedUnix int64
+	Updated     time.Time `xorm:"-" gorm:"-" json:"-"`
+	UpdatedUnix int64
+}
+
+// BeforeCreate implements the GORM create hook.
+func (m *RepoMigration) BeforeCreate(tx *gorm.DB) error {
+	if m.CreatedUnix == 0 {
+		m.CreatedUnix = tx.NowFunc().Unix()
+		m.UpdatedUnix = m.CreatedUnix
+	}
+	return nil
+}
+
+// AfterFind implements the GORM query hook.
+func (m *RepoMigration) AfterFind(*gorm.DB) error {
+	m.Created = time.Unix(m.CreatedUnix, 0).Local()
+	m.Updated = time.Unix(m.UpdatedUnix, 0).Local()
+	return nil
+}
+
+// IsDone returns true if the migration has completed.
+func (m *RepoMigration) IsDone() bool {
+	return m.Stage == string(migrate.StageDone)
+}
+
+// IsRunning returns true if the migration is running in this process.
+func (m *RepoMigration) IsRunning() bool {
+	_, ok := runningRepoMigrations.Load(m.RepoID)
+	return ok
+}
+
+// Checkpoint returns the checkpoint of the migration.
+func (m *RepoMigration) Checkpoint() migrate.Checkpoint {
+	return migrate.Checkpoint{
+		Stage:  migrate.Stage(m.Stage),
+		Page:   m.Page,
+		Number: m.LastNumber,
+		Index:  m.LastIndex,
+	}
+}
+
+// RepoMigrationIssue is an issue or pull request that has been migrated, for
+// rewriting references to its number on the code hosting service.
+type RepoMigrationIssue struct {
+	RepoID int64 `gorm:"primaryKey;autoIncrement:false"`
+	// Issues and pull requests are numbered separately on some services.
+	IsPull bool  `gorm:"primaryKey"`
+	Number int64 `gorm:"primaryKey;autoIncrement:false"`
+	// The index of the issue or pull request in the repository.
+	Index int64 `gorm:"not null"`
+	// Whether references in the content and comments of the issue or pull
+	// request have been rewritten.
+	IsRewritten bool `gorm:"not null;default:FALSE"`
+}
+
+// RepoMigrationsStore is the storage layer for repository migrations.
+type RepoMigrationsStore struct {
+	db *gorm.DB
+}
+
+func newRepoMigrationsStore(db *gorm.DB) *RepoMigrationsStore {
+	return &RepoMigrationsStore{db: db}
+}
+
+// CreateRepoMigrationOptions contains options for creating a repository
+// migration.
+type CreateRepoMigrationOptions struct {
+	DoerID    int64
+	Service   migrate.Service
+	CloneAddr string
+	AuthToken string
+	Stages    []migrate.Stage
+}
+
+// Create creates a new migration for the repository.
+func (s *RepoMigrationsStore) Create(ctx context.Context, repoID int64, opts CreateRepoMigrationOptions) (*RepoMigration, error) {
+	stages := make([]string, 0, len(opts.Stages))
+	for _, stage := range opts.Stages {
+		stages = append(stages, string(stage))
+	}
+
+	m := &RepoMigration{
+		RepoID:    repoID,
+		DoerID:    opts.DoerID,
+		Service:   string(opts.Service),
+		CloneAddr: opts.CloneAddr,
+		AuthToken: opts.AuthToken,
+		Stages:    strings.Join(stages, ","),
+	}
+	return m, s.db.WithContext(ctx).Create(m).Error
+}
+
+var _ errutil.NotFound = (*ErrRepoMigrationNotExist)(nil)
+
+type ErrRepoMigrationNotExist struct {
+	args errutil.Args
+}
+
+func IsErrRepoMigrationNotExist(err error) bool {
+	return errors.As(err, &ErrRepoMigrationNotExist{})
+}
+
+func (err ErrRepoMigrationNotExist) Error() string {
+	return fmt.Sprintf("repository migration does not exist: %v", err.args)
+}
+
+func (ErrRepoMigrationNotExist) NotFound() bool {
+	return true
+}
+
+// GetByRepoID returns the migration of the given repository. It returns
+// ErrRepoMigrationNotExist when not found.
+func (s *RepoMigrationsStore) GetByRepoID(ctx context.Context, repoID int64) (*RepoMigration, error) {
+	m := new(RepoMigration)
+	err := s.db.WithContext(ctx).Where("repo_id = ?", repoID).First(m).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRepoMigrationNotExist{args: errutil.Args{"repoID": repoID}}
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// ListInterrupted returns migrations that are neither done nor failed, i.e.
+// interrupted by a shutdown.
+func (s *RepoMigrationsStore) ListInterrupted(ctx context.Context) ([]*RepoMigration, error) {
+	var ms []*RepoMigration
+	return ms, s.db.WithContext(ctx).
+		Where("stage != ? AND (error IS NULL OR error = ?)", migrate.StageDone, "").
+		Order("repo_id ASC").
+		Find(&ms).Error
+}
+
+// UpdateCheckpoint updates the checkpoint of the migration, the access token is
+// cleared once the migration is done.
+func (s *RepoMigrationsStore) UpdateCheckpoint(ctx context.Context, repoID int64, cp migrate.Checkpoint) error {
+	updates := map[string]any{
+		"stage":        string(cp.Stage),
+		"page":         cp.Page,
+		"last_number":  cp.Number,
+		"last_index":   cp.Index,
+		"updated_unix": s.db.NowFunc().Unix(),
+	}
+	if cp.Stage == migrate.StageDone {
+		updates["auth_token"] = ""
+	}
+	return s.db.WithContext(ctx).Model(&RepoMigration{}).Where("repo_id = ?", repoID).Updates(updates).Error
+}
+
+// SetError sets the error occurred in the last run of the migration, an empty
+// message clears the error. The access token is cleared along with a non-empty
+// message, a new one needs to be set to resume the migration.
+func (s *RepoMigrationsStore) SetError(ctx context.Context, repoID int64, message string) error {
+	updates := map[string]any{
+		"error":        message,
+		"updated_unix": s.db.NowFunc().Unix(),
+	}
+	if message != "" {
+		updates["auth_token"] = ""
+	}
+	return s.db.WithContext(ctx).Model(&RepoMigration{}).Where("repo_id = ?", repoID).Updates(updates).Error
+}
+
+// SetAuthToken sets the access token to the API of the migration, e.g. for
+// resuming a failed migration.
+func (s *RepoMigrationsStore) SetAuthToken(ctx context.Context, repoID int64, token string) error {
+	return s.db.WithContext(ctx).
+		Model(&RepoMigration{}).
+		Where("repo_id = ?", repoID).
+		Updates(map[string]any{
+			"auth_token":   token,
+			"updated_unix": s.db.NowFunc().Unix(),
+		}).Error
+}
+
+// runningRepoMigrations is the set of IDs of repositories whose migration is
+// running in this process.
+var runningRepoMigrations sync.Map
+
+// RunRepoMigration runs the migration of the repository from its last
+// checkpoint. It returns immediately if the migration is already running.
+func RunRepoMigration(repoID int64) {
+	if _, running := runningRepoMigrations.LoadOrStore(repoID, struct{}{}); running {
+		return
+	}
+	defer runningRepoMigrations.Delete(repoID)
+
+	ctx := context.Background()
+	store := Handle.RepoMigrations()
+	err := runRepoMigration(ctx, store, repoID)
+	if err == nil {
+		return
+	}
+
+	if err := store.SetError(ctx, repoID, err.Error()); err != nil {
+		log.Error("Failed to set migration error [repo_id: %d]: %v", repoID, err)
+	}
+	desc := fmt.Sprintf("Failed to migrate data of repository [repo_id: %d]: %v", repoID, err)
+	log.Warn(desc)
+	if err = Handle.Notices().Create(ctx, NoticeTypeRepository, desc); err != nil {
+		log.Error("CreateRepositoryNotice: %v", err)
+	}
+}
+
+func runRepoMigration(ctx context.Context, store *RepoMigrationsStore, repoID int64) error {
+	m, err := store.GetByRepoID(ctx, repoID)
+	if err != nil {
+		return errors.Wrap(err, "get migration")
+	}
+
+	err = store.SetError(ctx, repoID, "")
+	if err != nil {
+		return errors.Wrap(err, "clear error")
+	}
+
+	repo, err := GetRepositoryByID(repoID)
+	if err != nil {
+		return errors.Wrap(err, "get repository")
+	}
+	doer, err := Handle.Users().GetByID(ctx, m.DoerID)
+	if err != nil {
+		return errors.Wrap(err, "get doer")
+	}
+
+	d, err := migrate.NewDownloader(
+		migrate.DownloaderOptions{
+			Service:   migrate.Service(m.Service),
+			CloneAddr: m.CloneAddr,
+			AuthToken: m.AuthToken,
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "new downloader")
+	}
+
+	log.Trace("Migrating data of repository [repo_id: %d] from stage %q", repoID, m.Stage)
+	return migrate.Migrate(ctx, d, newRepoMigrationUploader(doer, repo, m.Service),
+		migrate.Options{
+			Stages:     migrate.ParseStages(strings.Split(m.Stages, ",")),
+			Checkpoint: m.Checkpoint(),
+			OnProgress: func(cp migrate.Checkpoint) error {
+				return store.UpdateCheckpoint(ctx, repoID, cp)
+			},
+		},
+	)
+}
+
+// StartRepoMigration creates the migration of the repository and runs it in
+// background.
+func StartRepoMigration(ctx context.Context, repoID int64, opts CreateRepoMigrationOptions) error {
+	_, err := Handle.RepoMigrations().Create(ctx, repoID, opts)
+	if err != nil {
+		return errors.Wrap(err, "create migration")
+	}
+
+	go RunRepoMigration(repoID)
+	return nil
+}
+
+// ResumeRepoMigrations resumes migrations that were interrupted by a shutdown
+// in background.
+func ResumeRepoMigrations() {
+	ms, err := Handle.RepoMigrations().ListInterrupted(context.Background())
+	if err != nil {
+		log.Error("Failed to list interrupted repository migrations: %v", err)
+		return
+	}
+
+	for _, m := range ms {
+		log.Info("Resuming interrupted migration of repository [repo_id: %d]", m.RepoID)
+		go RunRepoMigration(m.RepoID)
+	}
+}
+
+const (
+	// migratedRefsPrefix is the prefix of references that pull request heads
+	// cloned from the original repository are moved to during the migration,
+	// because pull requests are renumbered.
+	migratedRefsPrefix = "refs/migration/"
+)
+
+var _ migrate.Uploader = (*repoMigrationUploader)(nil)
+
+// repoMigrationUploader saves migrated data to a local repository.
+type repoMigrationUploader struct {
+	doer    *User
+	repo    *Repository
+	service string
+
+	// Lazily loaded lookup tables of IDs by names.
+	labels     map[string]int64
+	milestones map[string]int64
+	// The cache of local users by email addresses, nil value means no match.
+	users map[string]*User
+}
+
+func newRepoMigrationUploader(doer *User, repo *Repository, service string) *repoMigrationUploader {
+	return &repoMigrationUploader{
+		doer:    doer,
+		repo:    repo,
+		service: service,
+		users:   make(map[string]*User),
+	}
+}
+
+// Prepare moves pull request heads cloned from the original repository out of
+// the way, they are recreated with new numbers when migrating pull requests.
+func (u *repoMigrationUploader) Prepare(context.Context) error {
+	repoPath := u.repo.RepoPath()
+	stdout, err := git.NewCommand("for-each-ref", "--format=%(objectname) %(refname)", "refs/pull/", "refs/merge-requests/").RunInDir(repoPath)
+	if err != nil {
+		return errors.Wrap(err, "list pull request refs")
+	}
+
+	var input strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(string(stdout)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		sha, ref := fields[0], fields[1]
+		_, _ = fmt.Fprintf(&input, "create %s %s\ndelete %s %s\n", migratedRefsPrefix+strings.TrimPrefix(ref, "refs/"), sha, ref, sha)
+	}
+	if input.Len() == 0 {
+		return nil
+	}
+
+	err = git.NewCommand("update-ref", "--stdin").RunInDirWithOptions(repoPath, git.RunInDirOptions{
+		Stdin: strings.NewReader(input.String()),
+	})
+	return errors.Wrap(err, "move pull request refs")
+}
+
+// Finish rewrites references to issues and pull requests, which are renumbered,
+// and deletes pull request heads cloned from the original repository.
+func (u *repoMigrationUploader) Finish(context.Context) error {
+	err := rewriteMigratedReferences(x, u.repo.ID, migrate.Service(u.service))
+	if err != nil {
+		return errors.Wrap(err, "rewrite references")
+	}
+
+	repoPath := u.repo.RepoPath()
+	stdout, err := git.NewCommand("for-each-ref", "--format=delete %(refname)", migratedRefsPrefix).RunInDir(repoPath)
+	if err != nil {
+		return errors.Wrap(err, "list migrated refs")
+	} else if len(strings.TrimSpace(string(stdout))) == 0 {
+		return nil
+	}
+
+	err = git.NewCommand("update-ref", "--stdin").RunInDirWithOptions(repoPath, git.RunInDirOptions{
+		Stdin: strings.NewReader(string(stdout)),
+	})
+	return errors.Wrap(err, "delete migrated refs")
+}
+
+// poster returns the local user of the given user by email address, or the doer
+// when no local user matches. It returns true if the local user matches.
+func (u *repoMigrationUploader) poster(ctx context.Context, user migrate.User) (*User, bool) {
+	email := strings.ToLower(user.Email)
+	if email == "" {
+		return u.doer, false
+	}
+
+	local, ok := u.users[email]
+	if !ok {
+		var err error
+		local, err = Handle.Users().GetByEmail(ctx, email)
+		if err != nil {
+			if !IsErrUserNotExist(err) {
+				log.Error("Failed to get user by email %q: %v", email, err)
+			}
+			local = nil
+		}
+		u.users[email] = local
+	}
+	if local == nil {
+		return u.doer, false
+	}
+	return local, true
+}
+
+// content returns the content posted by the user, with a note of the original
+// author prepended if the user cannot be mapped to a local user.
+func (u *repoMigrationUploader) content(ctx context.Context, user migrate.User, content string) (*User, string) {
+	poster, matched := u.poster(ctx, user)
+	if matched || user.Name == "" {
+		return poster, content
+	}
+	return poster, fmt.Sprintf("_Originally posted by %s on %s_\n\n%s", user.Name, u.service, content)
+}
+
+func (u *repoMigrationUploader) CreateLabels(_ context.Context, labels []*migrate.Label) error {
+	existing, err := GetLabelsByRepoID(u.repo.ID)
+	if err != nil {
+		return errors.Wrap(err, "get existing labels")
+	}
+	names := make(map[string]bool, len(existing))
+	for _, l := range existing {
+		names[l.Name] = true
+	}
+
+	toCreate := make([]*Label, 0, len(labels))
+	for _, l := range labels {
+		if names[l.Name] {
+			continue
+		}
+		names[l.Name] = true
+		toCreate = append(toCreate, &Label{
+			RepoID: u.repo.ID,
+			Name:   l.Name,
+			Color:  l.Color,
+		})
+	}
+	if len(toCreate) == 0 {
+		return nil
+	}
+	return NewLabels(toCreate...)
+}
+
+func (u *repoMigrationUploader) CreateMilestones(_ context.Context, milestones []*migrate.Milestone) error {
+	existing, err := GetMilestonesByRepoID(u.repo.ID)
+	if err != nil {
+		return errors.Wrap(err, "get existing milestones")
+	}
+	names := make(map[string]bool, len(existing))
+	for _, m := range existing {
+		names[m.Name] = true
+	}
+
+	for _, m := range milestones {
+		if names[m.Title] {
+			continue
+		}
+		names[m.Title] = true
+
+		err = u.createMilestone(m)
+		if err != nil {
+			return errors.Wrapf(err, "create milestone %q", m.Title)
+		}
+	}
+	return nil
+}
+
+func (u *repoMigrationUploader) createMilestone(m *migrate.Milestone) error {
+	deadline := m.Deadline
+	if deadline.IsZero() {
+		// The same as the one used for milestones without deadline
+		deadline = time.Date(9999, 12, 31, 0, 0, 0, 0, time.Local)
+	}
+	milestone := &Milestone{
+		RepoID:   u.repo.ID,
+		Name:     m.Title,
+		Content:  m.Description,
+		IsClosed: m.IsClosed,
+		Deadline: deadline,
+	}
+	if m.IsClosed && !m.Closed.IsZero() {
+		milestone.ClosedDateUnix = m.Closed.Unix()
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Insert(milestone); err != nil {
+		return err
+	}
+	if _, err := sess.Exec("UPDATE `repository` SET num_milestones = num_milestones + 1 WHERE id = ?", u.repo.ID); err != nil {
+		return err
+	}
+	if m.IsClosed {
+		if _, err := sess.Exec("UPDATE `repository` SET num_closed_milestones = num_closed_milestones + 1 WHERE id = ?", u.repo.ID); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// loadLookups loads lookup tables of labels and milestones if not yet.
+func (u *repoMigrationUploader) loadLookups() error {
+	if u.labels != nil {
+		return nil
+	}
+
+	labels, err := GetLabelsByRepoID(u.repo.ID)
+	if err != nil {
+		return errors.Wrap(err, "get labels")
+	}
+	milestones, err := GetMilestonesByRepoID(u.repo.ID)
+	if err != nil {
+		return errors.Wrap(err, "get milestones")
+	}
+
+	u.labels = make(map[string]int64, len(labels))
+	for _, l := range labels {
+		u.labels[l.Name] = l.ID
+	}
+	u.milestones = make(map[string]int64, len(milestones))
+	for _, m := range milestones {
+		u.milestones[m.Name] = m.ID
+	}
+	return nil
+}
+
+func (u *repoMigrationUploader) HasIssue(_ context.Context, index int64) (bool, error) {
+	return x.Exist(&Issue{
+		RepoID: u.repo.ID,
+		Index:  index,
+	})
+}
+
+func (u *repoMigrationUploader) CreateIssue(ctx context.Context, index int64, issue *migrate.Issue, comments []*migrate.Comment) error {
+	return u.createIssue(ctx, index, issue, comments, nil)
+}
+
+func (u *repoMigrationUploader) CreatePullRequest(ctx context.Context, index int64, pr *migrate.PullRequest, comments []*migrate.Comment) error {
+	return u.createIssue(ctx, index, &pr.Issue, comments, pr)
+}
+
+// createIssue creates the issue and its comments, and the pull request if pr is
+// not nil, in a single transaction.
+func (u *repoMigrationUploader) createIssue(ctx context.Context, index int64, issue *migrate.Issue, comments []*migrate.Comment, pr *migrate.PullRequest) error {
+	err := u.loadLookups()
+	if err != nil {
+		return err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	// Reload to get the latest numbers of issues and pull requests
+	repo, err := getRepositoryByID(sess, u.repo.ID)
+	if err != nil {
+		return errors.Wrap(err, "get repository")
+	} else if next := repo.NextIssueIndex(); next != index {
+		return errors.Errorf("the next index is %d but want %d", next, index)
+	}
+
+	poster, content := u.content(ctx, issue.Poster, issue.Content)
+	labelIDs := make([]int64, 0, len(issue.Labels))
+	for _, name := range issue.Labels {
+		if id, ok := u.labels[name]; ok {
+			labelIDs = append(labelIDs, id)
+		}
+	}
+
+	// Pull requests are migrated as records, which are never open.
+	isClosed := issue.IsClosed || pr != nil
+	newIssue := &Issue{
+		RepoID:      repo.ID,
+		Repo:        repo,
+		Title:       issue.Title,
+		PosterID:    poster.ID,
+		Poster:      poster,
+		MilestoneID: u.milestones[issue.Milestone],
+		Content:     content,
+		IsClosed:    isClosed,
+		IsPull:      pr != nil,
+	}
+	err = newIssueForMigration(sess, repo, newIssue, labelIDs)
+	if err != nil {
+		return errors.Wrap(err, "new issue")
+	}
+
+	// Reset timestamps back to the original ones because Insert method updates
+	// their values.
+	updated := issue.Updated
+	if updated.IsZero() {
+		updated = issue.Created
+	}
+	if _, err = sess.Exec("UPDATE `issue` SET created_unix = ?, updated_unix = ? WHERE id = ?", issue.Created.Unix(), updated.Unix(), newIssue.ID); err != nil {
+		return errors.Wrap(err, "reset issue timestamps")
+	}
+
+	for _, c := range comments {
+		err = u.createComment(ctx, sess, newIssue, c)
+		if err != nil {
+			return errors.Wrap(err, "create comment")
+		}
+	}
+	if _, err = sess.Exec("UPDATE `issue` SET num_comments = ? WHERE id = ?", len(comments), newIssue.ID); err != nil {
+		return errors.Wrap(err, "update number of comments")
+	}
+
+	if pr != nil {
+		err = u.createPullRequest(ctx, sess, repo, newIssue, pr)
+		if err != nil {
+			return errors.Wrap(err, "create pull request")
+		}
+	}
+
+	_, err = sess.Insert(&RepoMigrationIssue{
+		RepoID: repo.ID,
+		IsPull: pr != nil,
+		Number: issue.Number,
+		Index:  newIssue.Index,
+	})
+	if err != nil {
+		return errors.Wrap(err, "record number")
+	}
+
+	return sess.Commit()
+}
+
+// newIssueForMigration inserts the issue and updates related counters, but
+// does not notify anyone about it.
+func newIssueForMigration(e *xorm.Session, repo *Repository, issue *Issue, labelIDs []int64) error {
+	err := newIssue(e, NewIssueOptions{
+		Repo:     repo,
+		Issue:    issue,
+		LableIDs: labelIDs,
+		IsPull:   issue.IsPull,
+	})
+	if err != nil {
+		return err
+	}
+	if !issue.IsClosed {
+		return nil
+	}
+
+	if err = updateIssueUsersByStatus(e, issue.ID, true); err != nil {
+		return errors.Wrap(err, "update issue users")
+	}
+	if issue.IsPull {
+		_, err = e.Exec("UPDATE `repository` SET num_closed_pulls = num_closed_pulls + 1 WHERE id = ?", repo.ID)
+	} else {
+		_, err = e.Exec("UPDATE `repository` SET num_closed_issues = num_closed_issues + 1 WHERE id = ?", repo.ID)
+	}
+	return err
+}
+
+func (u *repoMigrationUploader) createComment(ctx context.Context, e *xorm.Session, issue *Issue, c *migrate.Comment) error {
+	poster, content := u.content(ctx, c.Poster, c.Content)
+	comment := &Comment{
+		Type:     COMMENT_TYPE_COMMENT,
+		PosterID: poster.ID,
+		IssueID:  issue.ID,
+		Content:  content,
+	}
+	if _, err := e.Insert(comment); err != nil {
+		return err
+	}
+
+	updated := c.Updated
+	if updated.IsZero() {
+		updated = c.Created
+	}
+	_, err := e.Exec("UPDATE `comment` SET created_unix = ?, updated_unix = ? WHERE id = ?", c.Created.Unix(), updated.Unix(), comment.ID)
+	return err
+}
+
+func (u *repoMigrationUploader) createPullRequest(ctx context.Context, e *xorm.Session, repo *Repository, issue *Issue, pr *migrate.PullRequest) error {
+	repoPath := repo.RepoPath()
+	headRef := fmt.Sprintf("refs/pull/%d/head", issue.Index)
+	if pr.HeadSHA != "" {
+		// The head may not exist, e.g. the fork has been deleted, then the pull
+		// request is migrated without its commits.
+		_, err := git.NewCommand("update-ref", headRef, pr.HeadSHA).RunInDir(repoPath)
+		if err != nil {
+			log.Trace("Failed to create head of pull request #%d [repo_id: %d]: %v", issue.Index, repo.ID, err)
+		}
+	}
+
+	var mergeBase string
+	if git.RepoHasReference(repoPath, headRef) {
+		base := git.RefsHeads + pr.BaseBranch
+		if pr.HasMerged && pr.MergedCommitID != "" {
+			base = pr.MergedCommitID + "^"
+		}
+		mergeBase, _ = git.MergeBase(repoPath, base, headRef)
+	}
+
+	pull := &PullRequest{
+		Type:           PULL_REQUEST_GOGS,
+		Status:         PULL_REQUEST_STATUS_MERGEABLE,
+		IssueID:        issue.ID,
+		Index:          issue.Index,
+		HeadRepoID:     repo.ID,
+		BaseRepoID:     repo.ID,
+		HeadUserName:   repo.MustOwner().Name,
+		HeadBranch:     pr.HeadBranch,
+		BaseBranch:     pr.BaseBranch,
+		MergeBase:      mergeBase,
+		HasMerged:      pr.HasMerged,
+		MergedCommitID: pr.MergedCommitID,
+	}
+	if pr.HasMerged {
+		// The merger is left unknown unless it can be mapped to a local user.
+		if merger, ok := u.poster(ctx, pr.MergedBy); ok {
+			pull.MergerID = merger.ID
+		}
+		pull.MergedUnix = pr.Merged.Unix()
+	}
+	_, err := e.Insert(pull)
+	return err
+}
+
+// rewriteMigratedReferences rewrites references to migrated issues and pull
+// requests with their numbers on the code hosting service to their indexes in
+// the repository. Each issue or pull request is rewritten once with its
+// comments in a transaction, so that it is safe to be retried.
+func rewriteMigratedReferences(e *xorm.Engine, repoID int64, service migrate.Service) error {
+	var migrated []*RepoMigrationIssue
+	err := e.Where("repo_id = ?", repoID).Find(&migrated)
+	if err != nil {
+		return errors.Wrap(err, "list migrated issues")
+	}
+
+	indexes := map[bool]map[int64]int64{
+		false: make(map[int64]int64),
+		true:  make(map[int64]int64),
+	}
+	for _, m := range migrated {
+		indexes[m.IsPull][m.Number] = m.Index
+	}
+	rewrite := func(content string) string {
+		return migrate.RewriteReferences(service, content, func(number int64, isPull bool) (int64, bool) {
+			index, ok := indexes[isPull][number]
+			return index, ok
+		})
+	}
+
+	for _, m := range migrated {
+		if m.IsRewritten {
+			continue
+		}
+
+		err = rewriteMigratedIssueReferences(e, m, rewrite)
+		if err != nil {
+			return errors.Wrapf(err, "rewrite #%d", m.Index)
+		}
+	}
+
+	_, err = e.Delete(&RepoMigrationIssue{RepoID: repoID})
+	return errors.Wrap(err, "delete migrated issues")
+}
+
+func rewriteMigratedIssueReferences(e *xorm.Engine, m *RepoMigrationIssue, rewrite func(string) string) error {
+	sess := e.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	issue := new(Issue)
+	has, err := sess.Where("repo_id = ? AND `index` = ?", m.RepoID, m.Index).Get(issue)
+	if err != nil {
+		return errors.Wrap(err, "get issue")
+	} else if has {
+		// Update contents directly to keep the original timestamps.
+		if content := rewrite(issue.Content); content != issue.Content {
+			if _, err = sess.Exec("UPDATE `issue` SET content = ? WHERE id = ?", content, issue.ID); err != nil {
+				return errors.Wrap(err, "update issue")
+			}
+		}
+
+		var comments []*Comment
+		if err = sess.Where("issue_id = ? AND type = ?", issue.ID, COMMENT_TYPE_COMMENT).Find(&comments); err != nil {
+			return errors.Wrap(err, "list comments")
+		}
+		for _, c := range comments {
+			if content := rewrite(c.Content); content != c.Content {
+				if _, err = sess.Exec("UPDATE `comment` SET content = ? WHERE id = ?", content, c.ID); err != nil {
+					return errors.Wrap(err, "update comment")
+				}
+			}
+		}
+	}
+
+	_, err = sess.Exec("UPDATE `repo_migration_issue` SET is_rewritten = ? WHERE repo_id = ? AND is_pull = ? AND number = ?", true, m.RepoID, m.IsPull, m.Number)
+	if err != nil {
+		return errors.Wrap(err, "mark as rewritten")
+	}
+	return sess.Commit()
+}
+
+func (u *repoMigrationUploader) CreateRelease(ctx context.Context, release *migrate.Release, open func(*migrate.ReleaseAsset) (io.ReadCloser, error)) error {
+	exists, err := IsReleaseExist(u.repo.ID, release.TagName)
+	if err != nil {
+		return errors.Wrap(err, "check existence")
+	} else if exists {
+		return nil
+	}
+
+	gitRepo, err := git.Open(u.repo.RepoPath())
+	if err != nil {
+		return errors.Wrap(err, "open repository")
+	}
+
+	publisher, note := u.content(ctx, release.Publisher, release.Note)
+	title := release.Title
+	if title == "" {
+		title = release.TagName
+	}
+	r := &Release{
+		RepoID:       u.repo.ID,
+		PublisherID:  publisher.ID,
+		TagName:      release.TagName,
+		LowerTagName: strings.ToLower(release.TagName),
+		Target:       release.Target,
+		Title:        title,
+		Note:         note,
+		// The tag of a release may have been deleted, keep the release as a
+		// draft rather than creating a tag at a wrong commit.
+		IsDraft:      release.IsDraft || !gitRepo.HasTag(release.TagName),
+		IsPrerelease: release.IsPrerelease,
+		CreatedUnix:  release.Created.Unix(),
+	}
+	if err = createTag(gitRepo, r); err != nil {
+		return errors.Wrap(err, "get tag")
+	}
+
+	// Assets are saved before the release to not end up with a partially
+	// migrated release.
+	uuids := make([]string, 0, len(release.Assets))
+	for _, asset := range release.Assets {
+		attach, err := u.createAsset(ctx, asset, open)
+		if err != nil {
+			log.Warn("Skipped asset %q of release %q [repo_id: %d]: %v", asset.Name, release.TagName, u.repo.ID, err)
+			continue
+		}
+		uuids = append(uuids, attach.UUID)
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Insert(r); err != nil {
+		return errors.Wrap(err, "insert release")
+	}
+	if len(uuids) > 0 {
+		if _, err = sess.In("uuid", uuids).Cols("release_id").Update(&Attachment{ReleaseID: r.ID}); err != nil {
+			return errors.Wrap(err, "link attachments")
+		}
+	}
+	return sess.Commit()
+}
+
+// createAsset downloads the release asset as an attachment, assets exceed the
+// size limit of release attachments or the attachment quota of the doer are
+// rejected.
+func (u *repoMigrationUploader) createAsset(ctx context.Context, asset *migrate.ReleaseAsset, open func(*migrate.ReleaseAsset) (io.ReadCloser, error)) (*Attachment, error) {
+	maxSize := conf.Release.Attachment.MaxSize * 1024 * 1024
+	if asset.Size > maxSize {
+		return nil, errors.Errorf("size %d exceeds the limit %d", asset.Size, maxSize)
+	}
+	err := Handle.Quotas().Check(ctx, u.doer, QuotaTypeAttachment, asset.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := open(asset)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+
+	attach, err := newAttachment(u.doer.ID, asset.Name, io.LimitReader(rc, maxSize+1))
+	if err != nil {
+		return nil, err
+	} else if attach.Size > maxSize {
+		if err = DeleteAttachment(attach, true); err != nil {
+			log.Error("Failed to delete attachment [uuid: %s]: %v", attach.UUID, err)
+		}
+		return nil, errors.Errorf("size exceeds the limit %d", maxSize)
+	}
+	return attach, nil
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/errutil"
+	"gogs.io/gogs/internal/migrate"
+)
+
+func TestRepoMigrations(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	ctx := context.Background()
+	s := &RepoMigrationsStore{
+		db: newTestDB(t, "RepoMigrationsStore"),
+	}
+
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, s *RepoMigrationsStore)
+	}{
+		{"Create", repoMigrationsCreate},
+		{"ListInterrupted", repoMigrationsListInterrupted},
+		{"UpdateCheckpoint", repoMigrationsUpdateCheckpoint},
+		{"SetError", repoMigrationsSetError},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				err := clearTables(t, s.db)
+				require.NoError(t, err)
+			})
+			tc.test(t, ctx, s)
+		})
+		if t.Failed() {
+			break
+		}
+	}
+}
+
+func repoMigrationsCreate(t *testing.T, ctx context.Context, s *RepoMigrationsStore) {
+	_, err := s.GetByRepoID(ctx, 1)
+	wantErr := ErrRepoMigrationNotExist{args: errutil.Args{"repoID": int64(1)}}
+	assert.Equal(t, wantErr, err)
+
+	_, err = s.Create(ctx, 1,
+		CreateRepoMigrationOptions{
+			DoerID:    2,
+			Service:   migrate.ServiceGitHub,
+			CloneAddr: "https://github.com/gogs/gogs.git",
+			AuthToken: "abc",
+			Stages:    []migrate.Stage{migrate.StageIssues, migrate.StageReleases},
+		},
+	)
+	require.NoError(t, err)
+
+	m, err := s.GetByRepoID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), m.DoerID)
+	assert.Equal(t, "github", m.Service)
+	assert.Equal(t, "issues,releases", m.Stages)
+	assert.Equal(t, migrate.Checkpoint{}, m.Checkpoint())
+	assert.False(t, m.IsDone())
+}
+
+func repoMigrationsListInterrupted(t *testing.T, ctx context.Context, s *RepoMigrationsStore) {
+	for repoID := int64(1); repoID <= 3; repoID++ {
+		_, err := s.Create(ctx, repoID, CreateRepoMigrationOptions{Service: migrate.ServiceGitea})
+		require.NoError(t, err)
+	}
+	err := s.UpdateCheckpoint(ctx, 2, migrate.Checkpoint{Stage: migrate.StageDone})
+	require.NoError(t, err)
+	err = s.SetError(ctx, 3, "unexpected status 401")
+	require.NoError(t, err)
+
+	ms, err := s.ListInterrupted(ctx)
+	require.NoError(t, err)
+	require.Len(t, ms, 1)
+	assert.Equal(t, int64(1), ms[0].RepoID)
+
+	// Clearing the error makes it resumable again
+	err = s.SetError(ctx, 3, "")
+	require.NoError(t, err)
+
+	ms, err = s.ListInterrupted(ctx)
+	require.NoError(t, err)
+	assert.Len(t, ms, 2)
+}
+
+func repoMigrationsUpdateCheckpoint(t *testing.T, ctx context.Context, s *RepoMigrationsStore) {
+	_, err := s.Create(ctx, 1, CreateRepoMigrationOptions{Service: migrate.ServiceGitLab, AuthToken: "abc"})
+	require.NoError(t, err)
+
+	cp := migrate.Checkpoint{Stage: migrate.StagePullRequests, Page: 3, Number: 140, Index: 120}
+	err = s.UpdateCheckpoint(ctx, 1, cp)
+	require.NoError(t, err)
+
+	m, err := s.GetByRepoID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, cp, m.Checkpoint())
+	assert.Equal(t, "abc", m.AuthToken)
+
+	// The access token is cleared once done
+	err = s.UpdateCheckpoint(ctx, 1, migrate.Checkpoint{Stage: migrate.StageDone, Page: 1, Index: 150})
+	require.NoError(t, err)
+
+	m, err = s.GetByRepoID(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, m.IsDone())
+	assert.Empty(t, m.AuthToken)
+}
+
+func repoMigrationsSetError(t *testing.T, ctx context.Context, s *RepoMigrationsStore) {
+	_, err := s.Create(ctx, 1, CreateRepoMigrationOptions{Service: migrate.ServiceGitHub, AuthToken: "abc"})
+	require.NoError(t, err)
+
+	// The access token is cleared along with the error
+	err = s.SetError(ctx, 1, "connection reset by peer")
+	require.NoError(t, err)
+
+	m, err := s.GetByRepoID(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "connection reset by peer", m.Error)
+	assert.Empty(t, m.AuthToken)
+
+	err = s.SetAuthToken(ctx, 1, "def")
+	require.NoError(t, err)
+	err = s.SetError(ctx, 1, "")
+	require.NoError(t, err)
+
+	m, err = s.GetByRepoID(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, m.Error)
+	assert.Equal(t, "def", m.AuthToken)
+}
+
+func TestRewriteMigratedReferences(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	e := newTestLegacyEngine(t, new(Issue), new(Comment), new(RepoMigrationIssue))
+
+	// Issue #3 and pull request #5 on the code hosting service are migrated as
+	// #1 and #2, and #1 on the code hosting service was deleted.
+	issue := &Issue{RepoID: 1, Index: 1, Content: "Duplicate of #1, fixed by #5"}
+	pull := &Issue{RepoID: 1, Index: 2, Content: "Fixes #3", IsPull: true}
+	_, err := e.Insert(issue, pull)
+	require.NoError(t, err)
+	comment := &Comment{Type: COMMENT_TYPE_COMMENT, IssueID: pull.ID, Content: "See #5 and #3"}
+	_, err = e.Insert(comment)
+	require.NoError(t, err)
+	_, err = e.Insert(
+		&RepoMigrationIssue{RepoID: 1, Number: 3, Index: 1},
+		&RepoMigrationIssue{RepoID: 1, IsPull: true, Number: 5, Index: 2},
+	)
+	require.NoError(t, err)
+
+	err = rewriteMigratedReferences(e, 1, migrate.ServiceGitHub)
+	require.NoError(t, err)
+
+	for id, want := range map[int64]string{
+		issue.ID: "Duplicate of #1, fixed by #2",
+		pull.ID:  "Fixes #1",
+	} {
+		got := new(Issue)
+		_, err = e.ID(id).Get(got)
+		require.NoError(t, err)
+		assert.Equal(t, want, got.Content)
+	}
+
+	gotComment := new(Comment)
+	_, err = e.ID(comment.ID).Get(gotComment)
+	require.NoError(t, err)
+	assert.Equal(t, "See #2 and #1", gotComment.Content)
+
+	// Numbers are deleted once all references are rewritten
+	count, err := e.Count(new(RepoMigrationIssue))
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,390 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+// TrackedTime is a period of time that a user spent on an issue.
+type TrackedTime struct {
+	ID          int64 `gorm:"primaryKey"`
+	IssueID     int64 `gorm:"index;not null"`
+	UserID      int64 `gorm:"index;not null"`
+	Time        int64 `gorm:"not null"` // In seconds
+	CreatedUnix int64 `gorm:"index"`
+
+	User  *User  `gorm:"-" json:"-"`
+	Issue *Issue `gorm:"-" json:"-"`
+}
+
+// BeforeCreate implements the GORM create hook.
+func (t *TrackedTime) BeforeCreate(tx *gorm.DB) error {
+	if t.CreatedUnix == 0 {
+		t.CreatedUnix = tx.NowFunc().Unix()
+	}
+	return nil
+}
+
+// Created returns the time when the period of time is tracked.
+func (t *TrackedTime) Created() time.Time {
+	return time.Unix(t.CreatedUnix, 0).Local()
+}
+
+// IsDeletableBy returns true if the tracked time can be deleted by the user,
+// who must be either the one tracked the time, an admin of the repository or a
+// site admin.
+func (t *TrackedTime) IsDeletableBy(user *User, isRepoAdmin bool) bool {
+	return t.UserID == user.ID || isRepoAdmin || user.IsAdmin
+}
+
+// Stopwatch is a running timer of a user on an issue.
+type Stopwatch struct {
+	ID          int64 `gorm:"primaryKey"`
+	IssueID     int64 `gorm:"uniqueIndex:stopwatch_issue_user_unique;not null"`
+	UserID      int64 `gorm:"uniqueIndex:stopwatch_issue_user_unique;index;not null"`
+	CreatedUnix int64
+}
+
+// BeforeCreate implements the GORM create hook.
+func (w *Stopwatch) BeforeCreate(tx *gorm.DB) error {
+	if w.CreatedUnix == 0 {
+		w.CreatedUnix = tx.NowFunc().Unix()
+	}
+	return nil
+}
+
+// Created returns the time when the stopwatch is started.
+func (w *Stopwatch) Created() time.Time {
+	return time.Unix(w.CreatedUnix, 0).Local()
+}
+
+// TrackedTimesStore is the storage layer for tracked times and stopwatches.
+type TrackedTimesStore struct {
+	db *gorm.DB
+}
+
+func newTrackedTimesStore(db *gorm.DB) *TrackedTimesStore {
+	return &TrackedTimesStore{db: db}
+}
+
+var _ errutil.NotFound = (*ErrTrackedTimeNotExist)(nil)
+
+type ErrTrackedTimeNotExist struct {
+	args errutil.Args
+}
+
+func IsErrTrackedTimeNotExist(err error) bool {
+	return errors.As(err, &ErrTrackedTimeNotExist{})
+}
+
+func (err ErrTrackedTimeNotExist) Error() string {
+	return fmt.Sprintf("tracked time does not exist: %v", err.args)
+}
+
+func (ErrTrackedTimeNotExist) NotFound() bool {
+	return true
+}
+
+var _ errutil.NotFound = (*ErrStopwatchNotExist)(nil)
+
+type ErrStopwatchNotExist struct {
+	args errutil.Args
+}
+
+func IsErrStopwatchNotExist(err error) bool {
+	return errors.As(err, &ErrStopwatchNotExist{})
+}
+
+func (err ErrStopwatchNotExist) Error() string {
+	return fmt.Sprintf("stopwatch does not exist: %v", err.args)
+}
+
+func (ErrStopwatchNotExist) NotFound() bool {
+	return true
+}
+
+type ErrStopwatchAlreadyStarted struct {
+	args errutil.Args
+}
+
+func IsErrStopwatchAlreadyStarted(err error) bool {
+	return errors.As(err, &ErrStopwatchAlreadyStarted{})
+}
+
+func (err ErrStopwatchAlreadyStarted) Error() string {
+	return fmt.Sprintf("stopwatch has already been started: %v", err.args)
+}
+
+// ErrTrackedTimeInvalid is returned when the period of time is not positive.
+type ErrTrackedTimeInvalid struct {
+	args errutil.Args
+}
+
+func IsErrTrackedTimeInvalid(err error) bool {
+	return errors.As(err, &ErrTrackedTimeInvalid{})
+}
+
+func (err ErrTrackedTimeInvalid) Error() string {
+	return fmt.Sprintf("tracked time must be positive: %v", err.args)
+}
+
+// AddTrackedTimeOptions contains optional options for adding a tracked time.
+type AddTrackedTimeOptions struct {
+	// The time when the period of time is tracked, defaults to now.
+	Created time.Time
+}
+
+// Add adds a period of time in seconds that the user spent on the issue. It
+// returns ErrTrackedTimeInvalid when the period of time is not positive.
+func (s *TrackedTimesStore) Add(ctx context.Context, userID, issueID, seconds int64, opts AddTrackedTimeOptions) (*TrackedTime, error) {
+	if seconds <= 0 {
+		return nil, ErrTrackedTimeInvalid{args: errutil.Args{"time": seconds}}
+	}
+
+	t := &TrackedTime{
+		IssueID: issueID,
+		UserID:  userID,
+		Time:    seconds,
+	}
+	if !opts.Created.IsZero() {
+		t.CreatedUnix = opts.Created.Unix()
+	}
+	return t, s.db.WithContext(ctx).Create(t).Error
+}
+
+// GetByID returns the tracked time with given ID. It returns
+// ErrTrackedTimeNotExist when not found.
+func (s *TrackedTimesStore) GetByID(ctx context.Context, id int64) (*TrackedTime, error) {
+	t := new(TrackedTime)
+	err := s.db.WithContext(ctx).Where("id = ?", id).First(t).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTrackedTimeNotExist{args: errutil.Args{"id": id}}
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// DeleteByID deletes the tracked time with given ID.
+func (s *TrackedTimesStore) DeleteByID(ctx context.Context, id int64) error {
+	return s.db.WithContext(ctx).Where("id = ?", id).Delete(&TrackedTime{}).Error
+}
+
+// ListTrackedTimesOptions contains options for listing tracked times. Zero
+// values are ignored.
+type ListTrackedTimesOptions struct {
+	RepoID      int64
+	IssueID     int64
+	MilestoneID int64
+	UserID      int64
+	// The inclusive lower bound of the time when the period of time is tracked.
+	Since time.Time
+	// The exclusive upper bound of the time when the period of time is tracked.
+	Before time.Time
+}
+
+func (s *TrackedTimesStore) filter(ctx context.Context, opts ListTrackedTimesOptions) *gorm.DB {
+	tx := s.db.WithContext(ctx).Model(&TrackedTime{})
+	if opts.RepoID > 0 {
+		tx = tx.Where("issue_id IN (?)", s.db.Table("issue").Select("id").Where("repo_id = ?", opts.RepoID))
+	}
+	if opts.IssueID > 0 {
+		tx = tx.Where("issue_id = ?", opts.IssueID)
+	}
+	if opts.MilestoneID > 0 {
+		tx = tx.Where("issue_id IN (?)", s.db.Table("issue").Select("id").Where("milestone_id = ?", opts.MilestoneID))
+	}
+	if opts.UserID > 0 {
+		tx = tx.Where("user_id = ?", opts.UserID)
+	}
+	if !opts.Since.IsZero() {
+		tx = tx.Where("created_unix >= ?", opts.Since.Unix())
+	}
+	if !opts.Before.IsZero() {
+		tx = tx.Where("created_unix < ?", opts.Before.Unix())
+	}
+	return tx
+}
+
+// List returns tracked times that match given options in the order of time
+// when they are tracked.
+func (s *TrackedTimesStore) List(ctx context.Context, opts ListTrackedTimesOptions) ([]*TrackedTime, error) {
+	var times []*TrackedTime
+	return times, s.filter(ctx, opts).Order("created_unix ASC, id ASC").Find(&times).Error
+}
+
+// Sum returns the total tracked time in seconds that match given options.
+func (s *TrackedTimesStore) Sum(ctx context.Context, opts ListTrackedTimesOptions) (int64, error) {
+	var sum int64
+	return sum, s.filter(ctx, opts).Select("COALESCE(SUM(time), 0)").Scan(&sum).Error
+}
+
+// SumByMilestoneIDs returns the total tracked time in seconds of issues in each
+// of given milestones, keyed by milestone IDs.
+func (s *TrackedTimesStore) SumByMilestoneIDs(ctx context.Context, milestoneIDs []int64) (map[int64]int64, error) {
+	sums := make(map[int64]int64, len(milestoneIDs))
+	if len(milestoneIDs) == 0 {
+		return sums, nil
+	}
+
+	var rows []struct {
+		MilestoneID int64
+		Sum         int64
+	}
+	err := s.db.WithContext(ctx).
+		Table("tracked_time").
+		Select("issue.milestone_id AS milestone_id, SUM(tracked_time.time) AS sum").
+		Joins("JOIN issue ON issue.id = tracked_time.issue_id").
+		Where("issue.milestone_id IN (?)", milestoneIDs).
+		Group("issue.milestone_id").
+		Scan(&rows).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rows {
+		sums[r.MilestoneID] = r.Sum
+	}
+	return sums, nil
+}
+
+// LoadAttributes loads users and issues of given tracked times. Users that no
+// longer exist are replaced by the ghost user.
+func (s *TrackedTimesStore) LoadAttributes(ctx context.Context, times []*TrackedTime) error {
+	if len(times) == 0 {
+		return nil
+	}
+
+	userIDs := make([]int64, 0, len(times))
+	issueIDs := make([]int64, 0, len(times))
+	for _, t := range times {
+		userIDs = append(userIDs, t.UserID)
+		issueIDs = append(issueIDs, t.IssueID)
+	}
+
+	var users []*User
+	err := s.db.WithContext(ctx).Where("id IN (?)", userIDs).Find(&users).Error
+	if err != nil {
+		return errors.Wrap(err, "list users")
+	}
+	usersByID := make(map[int64]*User, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+
+	var issues []*Issue
+	err = s.db.WithContext(ctx).Where("id IN (?)", issueIDs).Find(&issues).Error
+	if err != nil {
+		return errors.Wrap(err, "list issues")
+	}
+	issuesByID := make(map[int64]*Issue, len(issues))
+	for _, issue := range issues {
+		issuesByID[issue.ID] = issue
+	}
+
+	for _, t := range times {
+		t.User = usersByID[t.UserID]
+		if t.User == nil {
+			t.User = NewGhostUser()
+		}
+		t.Issue = issuesByID[t.IssueID]
+		if t.Issue == nil {
+			return ErrIssueNotExist{args: map[string]any{"issueID": t.IssueID}}
+		}
+	}
+	return nil
+}
+
+// GetStopwatch returns the running stopwatch of the user on the issue. It
+// returns ErrStopwatchNotExist when not found.
+func (s *TrackedTimesStore) GetStopwatch(ctx context.Context, userID, issueID int64) (*Stopwatch, error) {
+	w := new(Stopwatch)
+	err := s.db.WithContext(ctx).Where("user_id = ? AND issue_id = ?", userID, issueID).First(w).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStopwatchNotExist{args: errutil.Args{"userID": userID, "issueID": issueID}}
+		}
+		return nil, err
+	}
+	return w, nil
+}
+
+// StartStopwatch starts a stopwatch of the user on the issue. It returns
+// ErrStopwatchAlreadyStarted when there is already a running one.
+func (s *TrackedTimesStore) StartStopwatch(ctx context.Context, userID, issueID int64) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_id = ? AND issue_id = ?", userID, issueID).First(&Stopwatch{}).Error
+		if err == nil {
+			return ErrStopwatchAlreadyStarted{args: errutil.Args{"userID": userID, "issueID": issueID}}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Wrap(err, "check existence")
+		}
+
+		return tx.Create(
+			&Stopwatch{
+				IssueID: issueID,
+				UserID:  userID,
+			},
+		).Error
+	})
+}
+
+// StopStopwatch stops the running stopwatch of the user on the issue and
+// records the elapsed time as a tracked time, which is nil if the elapsed time
+// is less than a second. It returns ErrStopwatchNotExist when there is no
+// running stopwatch.
+func (s *TrackedTimesStore) StopStopwatch(ctx context.Context, userID, issueID int64) (*TrackedTime, error) {
+	var t *TrackedTime
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		w := new(Stopwatch)
+		err := tx.Where("user_id = ? AND issue_id = ?", userID, issueID).First(w).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrStopwatchNotExist{args: errutil.Args{"userID": userID, "issueID": issueID}}
+			}
+			return errors.Wrap(err, "get stopwatch")
+		}
+
+		err = tx.Delete(w).Error
+		if err != nil {
+			return errors.Wrap(err, "delete stopwatch")
+		}
+
+		seconds := tx.NowFunc().Unix() - w.CreatedUnix
+		if seconds <= 0 {
+			return nil
+		}
+
+		t = &TrackedTime{
+			IssueID: issueID,
+			UserID:  userID,
+			Time:    seconds,
+		}
+		return tx.Create(t).Error
+	})
+	return t, err
+}
+
+// CancelStopwatch discards the running stopwatch of the user on the issue. It
+// returns ErrStopwatchNotExist when there is no running stopwatch.
+func (s *TrackedTimesStore) CancelStopwatch(ctx context.Context, userID, issueID int64) error {
+	result := s.db.WithContext(ctx).Where("user_id = ? AND issue_id = ?", userID, issueID).Delete(&Stopwatch{})
+	if result.Error != nil {
+		return result.Error
+	} else if result.RowsAffected == 0 {
+		return ErrStopwatchNotExist{args: errutil.Args{"userID": userID, "issueID": issueID}}
+	}
+	return nil
+}
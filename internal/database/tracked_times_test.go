@@ -0,0 +1,211 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/errutil"
+)
+
+func TestTrackedTimes(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	t.Parallel()
+
+	ctx := context.Background()
+	s := &TrackedTimesStore{
+		db: newTestDB(t, "TrackedTimesStore"),
+	}
+
+	for _, tc := range []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, s *TrackedTimesStore)
+	}{
+		{"Add", trackedTimesAdd},
+		{"List", trackedTimesList},
+		{"SumByMilestoneIDs", trackedTimesSumByMilestoneIDs},
+		{"Stopwatch", trackedTimesStopwatch},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Cleanup(func() {
+				err := clearTables(t, s.db)
+				require.NoError(t, err)
+			})
+			tc.test(t, ctx, s)
+		})
+		if t.Failed() {
+			break
+		}
+	}
+}
+
+func TestTrackedTime_IsDeletableBy(t *testing.T) {
+	tt := &TrackedTime{UserID: 1}
+	tests := []struct {
+		name        string
+		user        *User
+		isRepoAdmin bool
+		want        bool
+	}{
+		{name: "tracker", user: &User{ID: 1}, want: true},
+		{name: "repository admin", user: &User{ID: 2}, isRepoAdmin: true, want: true},
+		{name: "site admin", user: &User{ID: 2, IsAdmin: true}, want: true},
+		{name: "other user", user: &User{ID: 2}, want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, tt.IsDeletableBy(test.user, test.isRepoAdmin))
+		})
+	}
+}
+
+func trackedTimesAdd(t *testing.T, ctx context.Context, s *TrackedTimesStore) {
+	_, err := s.Add(ctx, 1, 1, 0, AddTrackedTimeOptions{})
+	wantErr := ErrTrackedTimeInvalid{args: errutil.Args{"time": int64(0)}}
+	assert.Equal(t, wantErr, err)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tt, err := s.Add(ctx, 1, 1, 3600, AddTrackedTimeOptions{Created: created})
+	require.NoError(t, err)
+	assert.Equal(t, created.Unix(), tt.CreatedUnix)
+
+	got, err := s.GetByID(ctx, tt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3600), got.Time)
+
+	err = s.DeleteByID(ctx, tt.ID)
+	require.NoError(t, err)
+	_, err = s.GetByID(ctx, tt.ID)
+	wantErr2 := ErrTrackedTimeNotExist{args: errutil.Args{"id": tt.ID}}
+	assert.Equal(t, wantErr2, err)
+}
+
+func trackedTimesList(t *testing.T, ctx context.Context, s *TrackedTimesStore) {
+	issue1 := &Issue{RepoID: 1, Index: 1, Title: "issue1", MilestoneID: 1}
+	issue2 := &Issue{RepoID: 1, Index: 2, Title: "issue2"}
+	issue3 := &Issue{RepoID: 2, Index: 1, Title: "issue3"}
+	for _, issue := range []*Issue{issue1, issue2, issue3} {
+		err := s.db.Create(issue).Error
+		require.NoError(t, err)
+	}
+
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+	for _, tc := range []struct {
+		userID  int64
+		issueID int64
+		seconds int64
+		created time.Time
+	}{
+		{1, issue1.ID, 60, day(1)},
+		{2, issue1.ID, 120, day(2)},
+		{1, issue2.ID, 180, day(3)},
+		{1, issue3.ID, 240, day(4)},
+	} {
+		_, err := s.Add(ctx, tc.userID, tc.issueID, tc.seconds, AddTrackedTimeOptions{Created: tc.created})
+		require.NoError(t, err)
+	}
+
+	sumOf := func(times []*TrackedTime) int64 {
+		var sum int64
+		for _, t := range times {
+			sum += t.Time
+		}
+		return sum
+	}
+	for _, tc := range []struct {
+		name    string
+		opts    ListTrackedTimesOptions
+		wantLen int
+		wantSum int64
+	}{
+		{"repository", ListTrackedTimesOptions{RepoID: 1}, 3, 360},
+		{"issue", ListTrackedTimesOptions{IssueID: issue1.ID}, 2, 180},
+		{"milestone", ListTrackedTimesOptions{MilestoneID: 1}, 2, 180},
+		{"user", ListTrackedTimesOptions{RepoID: 1, UserID: 1}, 2, 240},
+		{"date range", ListTrackedTimesOptions{Since: day(2), Before: day(4)}, 2, 300},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			times, err := s.List(ctx, tc.opts)
+			require.NoError(t, err)
+			assert.Len(t, times, tc.wantLen)
+			assert.Equal(t, tc.wantSum, sumOf(times))
+
+			sum, err := s.Sum(ctx, tc.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantSum, sum)
+		})
+	}
+
+	times, err := s.List(ctx, ListTrackedTimesOptions{IssueID: issue1.ID})
+	require.NoError(t, err)
+	err = s.LoadAttributes(ctx, times)
+	require.NoError(t, err)
+	assert.Equal(t, "issue1", times[0].Issue.Title)
+	assert.Equal(t, NewGhostUser().Name, times[0].User.Name)
+}
+
+func trackedTimesSumByMilestoneIDs(t *testing.T, ctx context.Context, s *TrackedTimesStore) {
+	issue1 := &Issue{RepoID: 1, Index: 1, Title: "issue1", MilestoneID: 1}
+	issue2 := &Issue{RepoID: 1, Index: 2, Title: "issue2", MilestoneID: 2}
+	for _, issue := range []*Issue{issue1, issue2} {
+		err := s.db.Create(issue).Error
+		require.NoError(t, err)
+	}
+
+	_, err := s.Add(ctx, 1, issue1.ID, 60, AddTrackedTimeOptions{})
+	require.NoError(t, err)
+	_, err = s.Add(ctx, 2, issue1.ID, 60, AddTrackedTimeOptions{})
+	require.NoError(t, err)
+	_, err = s.Add(ctx, 1, issue2.ID, 30, AddTrackedTimeOptions{})
+	require.NoError(t, err)
+
+	got, err := s.SumByMilestoneIDs(ctx, []int64{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]int64{1: 120, 2: 30}, got)
+}
+
+func trackedTimesStopwatch(t *testing.T, ctx context.Context, s *TrackedTimesStore) {
+	_, err := s.StopStopwatch(ctx, 1, 1)
+	wantErr := ErrStopwatchNotExist{args: errutil.Args{"userID": int64(1), "issueID": int64(1)}}
+	assert.Equal(t, wantErr, err)
+
+	err = s.StartStopwatch(ctx, 1, 1)
+	require.NoError(t, err)
+	err = s.StartStopwatch(ctx, 1, 1)
+	assert.Equal(t, ErrStopwatchAlreadyStarted{args: errutil.Args{"userID": int64(1), "issueID": int64(1)}}, err)
+
+	// Pretend the stopwatch has been running for an hour
+	err = s.db.Model(&Stopwatch{}).Where("user_id = ? AND issue_id = ?", 1, 1).
+		Update("created_unix", s.db.NowFunc().Add(-time.Hour).Unix()).Error
+	require.NoError(t, err)
+
+	tt, err := s.StopStopwatch(ctx, 1, 1)
+	require.NoError(t, err)
+	require.NotNil(t, tt)
+	assert.InDelta(t, 3600, tt.Time, 5)
+
+	_, err = s.GetStopwatch(ctx, 1, 1)
+	assert.Equal(t, wantErr, err)
+
+	err = s.StartStopwatch(ctx, 1, 1)
+	require.NoError(t, err)
+	err = s.CancelStopwatch(ctx, 1, 1)
+	require.NoError(t, err)
+	err = s.CancelStopwatch(ctx, 1, 1)
+	assert.Equal(t, wantErr, err)
+
+	sum, err := s.Sum(ctx, ListTrackedTimesOptions{IssueID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, tt.Time, sum)
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/gogs/git-module"
 	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
 )
 
 // CommitToPushCommit transforms a git.Commit to PushCommit type.
@@ -87,6 +88,12 @@ func PushUpdate(opts PushUpdateOptions) (err error) {
 		return fmt.Errorf("UpdateSize: %v", err)
 	}
 
+	// Maintenance is best-effort, it should never prevent actions and webhooks
+	// from being triggered.
+	if err = Handle.RepoMaintenance().IncrementPushes(ctx, repo.ID); err != nil {
+		log.Error("Failed to increment pushes for maintenance [repo_id: %d]: %v", repo.ID, err)
+	}
+
 	// Push tags
 	if strings.HasPrefix(opts.FullRefspec, git.RefsTags) {
 		err := Handle.Actions().PushTag(ctx,
@@ -141,5 +148,30 @@ func PushUpdate(opts PushUpdateOptions) (err error) {
 	if err != nil {
 		return errors.Wrap(err, "create action for commit push")
 	}
+
+	// Record force-pushes and deletions of head branches of pull requests
+	if isNewRef || !strings.HasPrefix(opts.FullRefspec, git.RefsHeads) {
+		return nil
+	}
+
+	pusher, err := Handle.Users().GetByID(ctx, opts.PusherID)
+	if err != nil {
+		return errors.Wrap(err, "get pusher")
+	}
+
+	branch := strings.TrimPrefix(opts.FullRefspec, git.RefsHeads)
+	if isDelRef {
+		err = CreateDeleteBranchComments(pusher, repo.ID, branch)
+		return errors.Wrap(err, "create delete branch comments")
+	}
+
+	mergeBase, err := gitRepo.MergeBase(opts.OldCommitID, opts.NewCommitID)
+	if err != nil && err != git.ErrNoMergeBase {
+		return errors.Wrap(err, "get merge base")
+	}
+	if mergeBase != opts.OldCommitID {
+		err = CreateForcePushComments(pusher, repo.ID, branch, opts.OldCommitID, opts.NewCommitID)
+		return errors.Wrap(err, "create force push comments")
+	}
 	return nil
 }
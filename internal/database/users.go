@@ -320,20 +320,23 @@ func (s *UsersStore) Create(ctx context.Context, username, email string, opts Cr
 	}
 
 	user := &User{
-		LowerName:       strings.ToLower(username),
-		Name:            username,
-		FullName:        opts.FullName,
-		Email:           email,
-		Password:        opts.Password,
-		LoginSource:     opts.LoginSource,
-		LoginName:       opts.LoginName,
-		Location:        opts.Location,
-		Website:         opts.Website,
-		MaxRepoCreation: -1,
-		IsActive:        opts.Activated,
-		IsAdmin:         opts.Admin,
-		Avatar:          cryptoutil.MD5(email), // Gravatar URL uses the MD5 hash of the email, see https://en.gravatar.com/site/implement/hash/
-		AvatarEmail:     email,
+		LowerName:         strings.ToLower(username),
+		Name:              username,
+		FullName:          opts.FullName,
+		Email:             email,
+		Password:          opts.Password,
+		LoginSource:       opts.LoginSource,
+		LoginName:         opts.LoginName,
+		Location:          opts.Location,
+		Website:           opts.Website,
+		MaxRepoCreation:   -1,
+		MaxRepoSize:       -1,
+		MaxLFSSize:        -1,
+		MaxAttachmentSize: -1,
+		IsActive:          opts.Activated,
+		IsAdmin:           opts.Admin,
+		Avatar:            cryptoutil.MD5(email), // Gravatar URL uses the MD5 hash of the email, see https://en.gravatar.com/site/implement/hash/
+		AvatarEmail:       email,
 	}
 
 	user.Rands, err = userutil.RandomSalt()
@@ -508,16 +511,37 @@ func (s *UsersStore) DeleteByID(ctx context.Context, userID int64, skipRewriteAu
 			return errors.Wrap(err, `decrease "user.num_following"`)
 		}
 
+		/*
+			Equivalent SQL for PostgreSQL:
+
+			UPDATE issue
+			SET num_reactions = num_reactions - (
+				SELECT COUNT(*) FROM reaction WHERE reaction.issue_id = issue.id AND user_id = @userID AND comment_id = 0
+			)
+			WHERE id IN (
+				SELECT issue_id FROM reaction WHERE user_id = @userID AND comment_id = 0
+			)
+		*/
+		err = tx.Table("issue").
+			Where("id IN (?)", tx.
+				Select("issue_id").
+				Table("reaction").
+				Where("user_id = ? AND comment_id = ?", userID, 0),
+			).
+			UpdateColumn("num_reactions", gorm.Expr(
+				"num_reactions - (SELECT COUNT(*) FROM reaction WHERE reaction.issue_id = issue.id AND user_id = ? AND comment_id = ?)",
+				userID, 0,
+			)).
+			Error
+		if err != nil {
+			return errors.Wrap(err, `decrease "issue.num_reactions"`)
+		}
+
 		if !skipRewriteAuthorizedKeys {
 			// We need to rewrite "authorized_keys" file if the user owns any public keys.
 			needsRewriteAuthorizedKeys = tx.Where("owner_id = ?", userID).First(&PublicKey{}).Error != gorm.ErrRecordNotFound
 		}
 
-		err = tx.Model(&Issue{}).Where("assignee_id = ?", userID).Update("assignee_id", 0).Error
-		if err != nil {
-			return errors.Wrap(err, "clear assignees")
-		}
-
 		for _, t := range []struct {
 			table any
 			where string
@@ -531,7 +555,13 @@ func (s *UsersStore) DeleteByID(ctx context.Context, userID int64, skipRewriteAu
 			{&Collaboration{}, "user_id = @userID"},
 			{&Access{}, "user_id = @userID"},
 			{&Action{}, "user_id = @userID"},
+			{&IssueAssignee{}, "assignee_id = @userID"},
 			{&IssueUser{}, "uid = @userID"},
+			{&Notification{}, "user_id = @userID"},
+			{&NotificationPreference{}, "user_id = @userID"},
+			{&EmailDigestItem{}, "user_id = @userID"},
+			{&Reaction{}, "user_id = @userID"},
+			{&Stopwatch{}, "user_id = @userID"},
 			{&EmailAddress{}, "uid = @userID"},
 			{&User{}, "id = @userID"},
 		} {
@@ -789,6 +819,16 @@ func (s *UsersStore) GetMailableEmailsByUsernames(ctx context.Context, usernames
 		Find(&emails).Error
 }
 
+// GetMailableByUsernames returns a list of users with given list of usernames
+// who are able to receive email notifications. Non-existing usernames are
+// ignored.
+func (s *UsersStore) GetMailableByUsernames(ctx context.Context, usernames []string) ([]*User, error) {
+	users := make([]*User, 0, len(usernames))
+	return users, s.db.WithContext(ctx).
+		Where("lower_name IN (?) AND is_active = ?", usernames, true).
+		Find(&users).Error
+}
+
 // IsUsernameUsed returns true if the given username has been used other than
 // the excluded user (a non-positive ID effectively meaning check against all
 // users).
@@ -904,6 +944,9 @@ type UpdateUserOptions struct {
 	Description *string
 
 	MaxRepoCreation    *int
+	MaxRepoSize        *int64
+	MaxLFSSize         *int64
+	MaxAttachmentSize  *int64
 	LastRepoVisibility *bool
 
 	IsActivated      *bool
@@ -974,6 +1017,24 @@ func (s *UsersStore) Update(ctx context.Context, userID int64, opts UpdateUserOp
 		}
 		updates["max_repo_creation"] = *opts.MaxRepoCreation
 	}
+	if opts.MaxRepoSize != nil {
+		if *opts.MaxRepoSize < -1 {
+			*opts.MaxRepoSize = -1
+		}
+		updates["max_repo_size"] = *opts.MaxRepoSize
+	}
+	if opts.MaxLFSSize != nil {
+		if *opts.MaxLFSSize < -1 {
+			*opts.MaxLFSSize = -1
+		}
+		updates["max_lfs_size"] = *opts.MaxLFSSize
+	}
+	if opts.MaxAttachmentSize != nil {
+		if *opts.MaxAttachmentSize < -1 {
+			*opts.MaxAttachmentSize = -1
+		}
+		updates["max_attachment_size"] = *opts.MaxAttachmentSize
+	}
 	if opts.LastRepoVisibility != nil {
 		updates["last_repo_visibility"] = *opts.LastRepoVisibility
 	}
@@ -1246,6 +1307,10 @@ type User struct {
 	LastRepoVisibility bool
 	// Maximum repository creation limit, -1 means use global default
 	MaxRepoCreation int `xorm:"NOT NULL DEFAULT -1" gorm:"not null;default:-1"`
+	// Maximum total size (in MB) of storage of each kind, -1 means use global default
+	MaxRepoSize       int64 `xorm:"NOT NULL DEFAULT -1" gorm:"not null;default:-1"`
+	MaxLFSSize        int64 `xorm:"max_lfs_size NOT NULL DEFAULT -1" gorm:"column:max_lfs_size;not null;default:-1"`
+	MaxAttachmentSize int64 `xorm:"NOT NULL DEFAULT -1" gorm:"not null;default:-1"`
 
 	// Permissions
 	IsActive         bool // Activate primary email
@@ -1321,6 +1386,34 @@ func (u *User) maxNumRepos() int {
 	return u.MaxRepoCreation
 }
 
+// StorageQuota returns the maximum size (in bytes) of the given type of storage
+// that the user can have, -1 means unlimited.
+func (u *User) StorageQuota(typ QuotaType) int64 {
+	if !conf.Quota.Enabled {
+		return -1
+	}
+
+	var limit, defaultLimit int64
+	switch typ {
+	case QuotaTypeRepository:
+		limit, defaultLimit = u.MaxRepoSize, conf.Quota.MaxRepoSize
+	case QuotaTypeLFS:
+		limit, defaultLimit = u.MaxLFSSize, conf.Quota.MaxLFSSize
+	case QuotaTypeAttachment:
+		limit, defaultLimit = u.MaxAttachmentSize, conf.Quota.MaxAttachmentSize
+	default:
+		return -1
+	}
+
+	if limit <= -1 {
+		limit = defaultLimit
+	}
+	if limit <= -1 {
+		return -1
+	}
+	return limit * 1024 * 1024
+}
+
 // canCreateRepo returns true if the user can create a repository.
 func (u *User) canCreateRepo() bool {
 	return u.maxNumRepos() <= -1 || u.NumRepos < u.maxNumRepos()
@@ -104,6 +104,7 @@ func TestUsers(t *testing.T) {
 		{"GetByUsername", usersGetByUsername},
 		{"GetByKeyID", usersGetByKeyID},
 		{"GetMailableEmailsByUsernames", usersGetMailableEmailsByUsernames},
+		{"GetMailableByUsernames", usersGetMailableByUsernames},
 		{"IsUsernameUsed", usersIsUsernameUsed},
 		{"List", usersList},
 		{"ListFollowers", usersListFollowers},
@@ -537,18 +538,6 @@ func usersDeleteByID(t *testing.T, ctx context.Context, s *UsersStore) {
 	err = newPublicKeysStore(s.db).RewriteAuthorizedKeys()
 	require.NoError(t, err)
 
-	// Mock issue assignee
-	// TODO: Use Issues.Assign to replace SQL hack when the method is available.
-	issue := &Issue{
-		RepoID:     repo2.ID,
-		Index:      1,
-		PosterID:   cindy.ID,
-		Title:      "test-issue",
-		AssigneeID: testUser.ID,
-	}
-	err = s.db.Create(issue).Error
-	require.NoError(t, err)
-
 	// Mock random entries in related tables
 	for _, table := range []any{
 		&AccessToken{UserID: testUser.ID},
@@ -556,7 +545,13 @@ func usersDeleteByID(t *testing.T, ctx context.Context, s *UsersStore) {
 		&Access{UserID: testUser.ID},
 		&Action{UserID: testUser.ID},
 		&IssueUser{UserID: testUser.ID},
+		&IssueAssignee{AssigneeID: testUser.ID},
+		&Reaction{UserID: testUser.ID},
+		&Stopwatch{UserID: testUser.ID},
 		&EmailAddress{UserID: testUser.ID},
+		&Notification{UserID: testUser.ID, IssueID: 1, Status: NotificationStatusUnread, Reason: NotificationReasonMention},
+		DefaultNotificationPreference(testUser.ID),
+		&EmailDigestItem{UserID: testUser.ID, Delivery: EmailDeliveryDaily, IssueID: 1, DoerID: 2, Category: NotificationCategoryWatching},
 	} {
 		err = s.db.Create(table).Error
 		require.NoError(t, err, "table for %T", table)
@@ -596,11 +591,6 @@ func usersDeleteByID(t *testing.T, ctx context.Context, s *UsersStore) {
 	assert.Contains(t, string(authorizedKeys), fmt.Sprintf("key-%d", publicKey.ID))
 	assert.Contains(t, string(authorizedKeys), publicKey.Content)
 
-	// TODO: Use Issues.GetByID to replace SQL hack when the method is available.
-	err = s.db.First(issue, issue.ID).Error
-	require.NoError(t, err)
-	assert.Equal(t, testUser.ID, issue.AssigneeID)
-
 	relatedTables := []any{
 		&Watch{UserID: testUser.ID},
 		&Star{UserID: testUser.ID},
@@ -611,7 +601,13 @@ func usersDeleteByID(t *testing.T, ctx context.Context, s *UsersStore) {
 		&Access{UserID: testUser.ID},
 		&Action{UserID: testUser.ID},
 		&IssueUser{UserID: testUser.ID},
+		&IssueAssignee{AssigneeID: testUser.ID},
+		&Reaction{UserID: testUser.ID},
+		&Stopwatch{UserID: testUser.ID},
 		&EmailAddress{UserID: testUser.ID},
+		&Notification{UserID: testUser.ID},
+		&NotificationPreference{UserID: testUser.ID},
+		&EmailDigestItem{UserID: testUser.ID},
 	}
 	for _, table := range relatedTables {
 		var count int64
@@ -644,11 +640,6 @@ func usersDeleteByID(t *testing.T, ctx context.Context, s *UsersStore) {
 	require.NoError(t, err)
 	assert.Empty(t, authorizedKeys)
 
-	// TODO: Use Issues.GetByID to replace SQL hack when the method is available.
-	err = s.db.First(issue, issue.ID).Error
-	require.NoError(t, err)
-	assert.Equal(t, int64(0), issue.AssigneeID)
-
 	for _, table := range []any{
 		&Watch{UserID: testUser.ID},
 		&Star{UserID: testUser.ID},
@@ -659,7 +650,13 @@ func usersDeleteByID(t *testing.T, ctx context.Context, s *UsersStore) {
 		&Access{UserID: testUser.ID},
 		&Action{UserID: testUser.ID},
 		&IssueUser{UserID: testUser.ID},
+		&IssueAssignee{AssigneeID: testUser.ID},
+		&Reaction{UserID: testUser.ID},
+		&Stopwatch{UserID: testUser.ID},
 		&EmailAddress{UserID: testUser.ID},
+		&Notification{UserID: testUser.ID},
+		&NotificationPreference{UserID: testUser.ID},
+		&EmailDigestItem{UserID: testUser.ID},
 	} {
 		var count int64
 		err = s.db.Model(table).Where(table).Count(&count).Error
@@ -847,6 +844,20 @@ func usersGetMailableEmailsByUsernames(t *testing.T, ctx context.Context, s *Use
 	assert.Equal(t, want, got)
 }
 
+func usersGetMailableByUsernames(t *testing.T, ctx context.Context, s *UsersStore) {
+	alice, err := s.Create(ctx, "alice", "alice@exmaple.com", CreateUserOptions{})
+	require.NoError(t, err)
+	bob, err := s.Create(ctx, "bob", "bob@exmaple.com", CreateUserOptions{Activated: true})
+	require.NoError(t, err)
+	_, err = s.Create(ctx, "cindy", "cindy@exmaple.com", CreateUserOptions{Activated: true})
+	require.NoError(t, err)
+
+	got, err := s.GetMailableByUsernames(ctx, []string{alice.Name, bob.Name, "ignore-non-exist"})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, bob.ID, got[0].ID)
+}
+
 func usersIsUsernameUsed(t *testing.T, ctx context.Context, s *UsersStore) {
 	alice, err := s.Create(ctx, "alice", "alice@example.com", CreateUserOptions{})
 	require.NoError(t, err)
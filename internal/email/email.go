@@ -28,6 +28,8 @@ const (
 
 	MAIL_ISSUE_COMMENT = "issue/comment"
 	MAIL_ISSUE_MENTION = "issue/mention"
+	MAIL_ISSUE_DUE     = "issue/due"
+	MAIL_ISSUE_DIGEST  = "issue/digest"
 
 	MAIL_NOTIFY_COLLABORATOR = "notify/collaborator"
 )
@@ -86,6 +88,9 @@ type User interface {
 	ID() int64
 	DisplayName() string
 	Email() string
+	// Rands returns the rands of the user, which is used to sign tokens of
+	// incoming emails.
+	Rands() string
 	GenerateEmailActivateCode(string) string
 }
 
@@ -96,11 +101,18 @@ type Repository interface {
 }
 
 type Issue interface {
+	ID() int64
 	MailSubject() string
 	Content() string
 	HTMLURL() string
 }
 
+type DueIssue interface {
+	Issue
+	Deadline() time.Time
+	IsOverdue() bool
+}
+
 func SendUserMail(_ *macaron.Context, u User, tpl, code, subject, info string) {
 	data := map[string]any{
 		"Username":          u.DisplayName(),
@@ -194,34 +206,137 @@ func composeTplData(subject, body, link string) map[string]any {
 	return data
 }
 
-func composeIssueMessage(issue Issue, repo Repository, doer User, tplName string, tos []string, info string) *Message {
+func composeIssueMessages(issue Issue, repo Repository, doer User, tplName string, tos []User, info string) []*Message {
 	subject := issue.MailSubject()
 	body := string(markup.Markdown([]byte(issue.Content()), repo.HTMLURL(), repo.ComposeMetas()))
-	data := composeTplData(subject, body, issue.HTMLURL())
-	data["Doer"] = doer
-	content, err := render(tplName, data)
-	if err != nil {
-		log.Error("HTMLString (%s): %v", tplName, err)
-	}
 	from := gomail.NewMessage().FormatAddress(conf.Email.FromEmail, doer.DisplayName())
-	msg := NewMessageFrom(tos, from, subject, content)
-	msg.Info = fmt.Sprintf("Subject: %s, %s", subject, info)
-	return msg
+	renderContent := func(data map[string]any) string {
+		data["Doer"] = doer
+		content, err := render(tplName, data)
+		if err != nil {
+			log.Error("HTMLString (%s): %v", tplName, err)
+		}
+		return content
+	}
+
+	if !conf.IncomingEmail.Enabled {
+		emails := make([]string, len(tos))
+		for i := range tos {
+			emails[i] = tos[i].Email()
+		}
+		msg := NewMessageFrom(emails, from, subject, renderContent(composeTplData(subject, body, issue.HTMLURL())))
+		msg.Info = fmt.Sprintf("Subject: %s, %s", subject, info)
+		return []*Message{msg}
+	}
+
+	// Each recipient gets their own addresses for replying to the issue and
+	// unsubscribing from it.
+	msgs := make([]*Message, 0, len(tos))
+	for _, to := range tos {
+		replyTo := ReplyToAddress(Token{Action: TokenActionReply, UserID: to.ID(), IssueID: issue.ID(), Rands: to.Rands()})
+		unsubscribe := Token{Action: TokenActionUnsubscribe, UserID: to.ID(), IssueID: issue.ID(), Rands: to.Rands()}
+		unsubscribeLink := conf.Server.ExternalURL + "user/unsubscribe/" + unsubscribe.String()
+
+		data := composeTplData(subject, body, issue.HTMLURL())
+		data["ReplyByEmail"] = true
+		data["UnsubscribeLink"] = unsubscribeLink
+		msg := NewMessageFrom([]string{to.Email()}, from, subject, renderContent(data))
+		msg.SetHeader("Reply-To", replyTo)
+		msg.SetHeader("List-Unsubscribe", fmt.Sprintf("<mailto:%s>, <%s>", ReplyToAddress(unsubscribe), unsubscribeLink))
+		msg.Info = fmt.Sprintf("UID: %d, Subject: %s, %s", to.ID(), subject, info)
+		msgs = append(msgs, msg)
+	}
+	return msgs
 }
 
 // SendIssueCommentMail composes and sends issue comment emails to target receivers.
-func SendIssueCommentMail(issue Issue, repo Repository, doer User, tos []string) {
+func SendIssueCommentMail(issue Issue, repo Repository, doer User, tos []User) {
 	if len(tos) == 0 {
 		return
 	}
 
-	Send(composeIssueMessage(issue, repo, doer, MAIL_ISSUE_COMMENT, tos, "issue comment"))
+	for _, msg := range composeIssueMessages(issue, repo, doer, MAIL_ISSUE_COMMENT, tos, "issue comment") {
+		Send(msg)
+	}
 }
 
 // SendIssueMentionMail composes and sends issue mention emails to target receivers.
-func SendIssueMentionMail(issue Issue, repo Repository, doer User, tos []string) {
+func SendIssueMentionMail(issue Issue, repo Repository, doer User, tos []User) {
 	if len(tos) == 0 {
 		return
 	}
-	Send(composeIssueMessage(issue, repo, doer, MAIL_ISSUE_MENTION, tos, "issue mention"))
+
+	for _, msg := range composeIssueMessages(issue, repo, doer, MAIL_ISSUE_MENTION, tos, "issue mention") {
+		Send(msg)
+	}
+}
+
+// SendIssueDueMail sends a reminder of issues that are assigned to the user and
+// are due soon or overdue.
+func SendIssueDueMail(u User, issues []DueIssue) {
+	if len(issues) == 0 {
+		return
+	}
+
+	subject := fmt.Sprintf("%d issue(s) assigned to you are due soon or overdue", len(issues))
+	data := map[string]any{
+		"Subject": subject,
+		"Issues":  issues,
+		"Link":    conf.Server.ExternalURL,
+	}
+	body, err := render(MAIL_ISSUE_DUE, data)
+	if err != nil {
+		log.Error("HTMLString: %v", err)
+		return
+	}
+
+	msg := NewMessage([]string{u.Email()}, subject, body)
+	msg.Info = fmt.Sprintf("UID: %d, issue due reminder", u.ID())
+
+	Send(msg)
+}
+
+// DigestActivity is an activity on an issue in an email digest.
+type DigestActivity struct {
+	Doer    string
+	Body    string // The rendered HTML content of the activity
+	HTMLURL string
+	Created time.Time
+}
+
+// DigestIssue is an issue along with its activities in an email digest.
+type DigestIssue struct {
+	Subject    string
+	HTMLURL    string
+	Activities []*DigestActivity
+}
+
+// SendIssueDigestMail sends a digest of activities on issues to the user, with
+// period being either "hourly" or "daily".
+func SendIssueDigestMail(u User, period string, issues []*DigestIssue) {
+	if len(issues) == 0 {
+		return
+	}
+
+	count := 0
+	for _, issue := range issues {
+		count += len(issue.Activities)
+	}
+	subject := fmt.Sprintf("Your %s digest of %d notification(s)", period, count)
+	data := map[string]any{
+		"Subject":      subject,
+		"Period":       period,
+		"Issues":       issues,
+		"SettingsLink": conf.Server.ExternalURL + "user/settings/notifications",
+	}
+	body, err := render(MAIL_ISSUE_DIGEST, data)
+	if err != nil {
+		log.Error("HTMLString: %v", err)
+		return
+	}
+
+	msg := NewMessage([]string{u.Email()}, subject, body)
+	msg.Info = fmt.Sprintf("UID: %d, %s issue digest", u.ID(), period)
+
+	Send(msg)
 }
@@ -0,0 +1,185 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/jaytaylor/html2text"
+	"github.com/pkg/errors"
+	"golang.org/x/net/html/charset"
+)
+
+// IncomingMessage is an email received in the mailbox for incoming emails.
+type IncomingMessage struct {
+	From       string   // The address of the sender
+	Recipients []string // The addresses of all recipients
+	Subject    string
+	Body       string // The plain text body, including any quoted text
+	// Whether the email is sent automatically, e.g. an out-of-office reply,
+	// which should be ignored to prevent mail loops.
+	IsAutoReply bool
+}
+
+var wordDecoder = &mime.WordDecoder{
+	CharsetReader: charset.NewReaderLabel,
+}
+
+// ParseIncomingMessage parses an email in the RFC 5322 format.
+func ParseIncomingMessage(r io.Reader) (*IncomingMessage, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read message")
+	}
+
+	in := new(IncomingMessage)
+	parser := &mail.AddressParser{WordDecoder: wordDecoder}
+	if from, err := parser.ParseList(msg.Header.Get("From")); err == nil && len(from) > 0 {
+		in.From = from[0].Address
+	}
+	for _, key := range []string{"To", "Cc", "Delivered-To", "X-Original-To", "Envelope-To"} {
+		for _, value := range msg.Header[key] {
+			addresses, err := parser.ParseList(value)
+			if err != nil {
+				continue
+			}
+			for _, address := range addresses {
+				in.Recipients = append(in.Recipients, address.Address)
+			}
+		}
+	}
+
+	in.Subject, err = wordDecoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		in.Subject = msg.Header.Get("Subject")
+	}
+
+	autoSubmitted := msg.Header.Get("Auto-Submitted")
+	in.IsAutoReply = (autoSubmitted != "" && !strings.EqualFold(autoSubmitted, "no")) ||
+		msg.Header.Get("X-Autoreply") != "" ||
+		strings.EqualFold(msg.Header.Get("Precedence"), "auto_reply")
+
+	text, html, err := readTextBody(textproto.MIMEHeader(msg.Header), msg.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read body")
+	}
+	if text == "" && html != "" {
+		text, err = html2text.FromString(html)
+		if err != nil {
+			return nil, errors.Wrap(err, "convert HTML to text")
+		}
+	}
+	in.Body = strings.ReplaceAll(text, "\r\n", "\n")
+	return in, nil
+}
+
+// maxTextBodySize is the maximum size of a decoded text body to read, the rest
+// of the body is ignored.
+const maxTextBodySize = 1 << 20
+
+// readTextBody returns the first plain text and the first HTML content found in
+// the body, which is decoded to UTF-8. Attachments are ignored.
+func readTextBody(header textproto.MIMEHeader, r io.Reader) (text, html string, _ error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		for text == "" || html == "" {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return "", "", err
+			}
+
+			disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+			if disposition == "attachment" {
+				continue
+			}
+
+			partText, partHTML, err := readTextBody(part.Header, part)
+			if err != nil {
+				return "", "", err
+			}
+			if text == "" {
+				text = partText
+			}
+			if html == "" {
+				html = partHTML
+			}
+		}
+		return text, html, nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", "", nil
+	}
+
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "quoted-printable":
+		r = quotedprintable.NewReader(r)
+	case "base64":
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	}
+	if params["charset"] != "" {
+		r, err = charset.NewReaderLabel(params["charset"], r)
+		if err != nil {
+			return "", "", errors.Wrap(err, "decode charset")
+		}
+	}
+
+	content, err := io.ReadAll(io.LimitReader(r, maxTextBodySize))
+	if err != nil {
+		return "", "", err
+	}
+	if mediaType == "text/html" {
+		return "", string(content), nil
+	}
+	return string(content), "", nil
+}
+
+var (
+	// The line that introduces the quoted email, e.g. "On Mon, Jan 2, 2006 at
+	// 3:04 PM, Gogs <noreply@gogs.localhost> wrote:", which may be wrapped.
+	replyHeaderPattern = regexp.MustCompile(`(?i)^\s*On\s.+\swrote:\s*$`)
+	// The separator of the original email used by Outlook and some others.
+	originalMessagePattern = regexp.MustCompile(`(?i)^\s*(-{3,}\s*Original Message\s*-{3,}|_{20,})\s*$`)
+)
+
+// StripReply returns the new content of a reply, with the quoted email and the
+// signature stripped.
+func StripReply(body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	end := len(lines)
+	for i, line := range lines {
+		if line == "-- " || line == "--" ||
+			originalMessagePattern.MatchString(line) ||
+			replyHeaderPattern.MatchString(line) ||
+			(i+1 < len(lines) && replyHeaderPattern.MatchString(line+" "+lines[i+1])) {
+			end = i
+			break
+		}
+	}
+
+	kept := make([]string, 0, end)
+	for _, line := range lines[:end] {
+		if strings.HasPrefix(line, ">") {
+			continue
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
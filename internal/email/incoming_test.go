@@ -0,0 +1,211 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIncomingMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want *IncomingMessage
+	}{
+		{
+			name: "plain text",
+			raw: `From: Alice <alice@example.com>
+To: "Gogs" <gogs+token@example.com>
+Cc: bob@example.com
+Subject: =?UTF-8?B?UmU6IFtnb2dzXSBIZWxsbyDwn5GL?=
+Content-Type: text/plain; charset=utf-8
+
+Sounds good.
+`,
+			want: &IncomingMessage{
+				From:       "alice@example.com",
+				Recipients: []string{"gogs+token@example.com", "bob@example.com"},
+				Subject:    "Re: [gogs] Hello 👋",
+				Body:       "Sounds good.\n",
+			},
+		},
+		{
+			name: "multipart",
+			raw: `From: alice@example.com
+Delivered-To: gogs+token@example.com
+Subject: Re: Hello
+Content-Type: multipart/alternative; boundary="b"
+
+--b
+Content-Type: text/plain; charset=iso-8859-1
+Content-Transfer-Encoding: quoted-printable
+
+Caf=E9 at 10?
+--b
+Content-Type: text/html; charset=utf-8
+
+<p>Café at 10?</p>
+--b--
+`,
+			want: &IncomingMessage{
+				From:       "alice@example.com",
+				Recipients: []string{"gogs+token@example.com"},
+				Subject:    "Re: Hello",
+				Body:       "Café at 10?",
+			},
+		},
+		{
+			name: "HTML only",
+			raw: `From: alice@example.com
+To: gogs+token@example.com
+Subject: Re: Hello
+Content-Type: text/html; charset=utf-8
+Content-Transfer-Encoding: base64
+
+PHA+U291bmRzIDxiPmdvb2Q8L2I+LjwvcD4=
+`,
+			want: &IncomingMessage{
+				From:       "alice@example.com",
+				Recipients: []string{"gogs+token@example.com"},
+				Subject:    "Re: Hello",
+				Body:       "Sounds *good*.",
+			},
+		},
+		{
+			name: "automatic reply",
+			raw: `From: alice@example.com
+To: gogs+token@example.com
+Subject: Out of office
+Auto-Submitted: auto-replied
+
+I am on vacation.
+`,
+			want: &IncomingMessage{
+				From:        "alice@example.com",
+				Recipients:  []string{"gogs+token@example.com"},
+				Subject:     "Out of office",
+				Body:        "I am on vacation.\n",
+				IsAutoReply: true,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseIncomingMessage(strings.NewReader(test.raw))
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+
+	t.Run("oversized body", func(t *testing.T) {
+		raw := "From: alice@example.com\nContent-Type: text/plain\n\n" + strings.Repeat("a", maxTextBodySize+1)
+		got, err := ParseIncomingMessage(strings.NewReader(raw))
+		require.NoError(t, err)
+		assert.Len(t, got.Body, maxTextBodySize)
+	})
+}
+
+func TestStripReply(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "quoted email",
+			body: `Sounds good, let's do it.
+
+On Mon, Jan 2, 2006 at 3:04 PM, Gogs <noreply@gogs.localhost> wrote:
+> Should we cut a release?
+`,
+			want: "Sounds good, let's do it.",
+		},
+		{
+			name: "wrapped reply header",
+			body: `Sounds good.
+
+On Mon, Jan 2, 2006 at 3:04 PM, Gogs
+<noreply@gogs.localhost> wrote:
+
+> Should we cut a release?
+`,
+			want: "Sounds good.",
+		},
+		{
+			name: "signature",
+			body: "Sounds good.\r\n\r\n-- \r\nAlice\r\n",
+			want: "Sounds good.",
+		},
+		{
+			name: "Outlook",
+			body: `Sounds good.
+
+-----Original Message-----
+From: Gogs
+`,
+			want: "Sounds good.",
+		},
+		{
+			name: "inline quotes",
+			body: `> Should we cut a release?
+Yes.
+> When?
+Tomorrow.
+`,
+			want: "Yes.\nTomorrow.",
+		},
+		{
+			name: "only quotes",
+			body: "> Should we cut a release?\n",
+			want: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, StripReply(test.body))
+		})
+	}
+}
+
+func TestMaildir(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"new", "cur", "tmp"} {
+		require.NoError(t, os.Mkdir(filepath.Join(root, dir), 0o755))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, "new", "1.eml"), []byte("first"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "new", "2.eml"), []byte("second"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "cur", "0.eml:2,S"), []byte("read"), 0o644))
+
+	m := &Maildir{Path: root}
+	var got []string
+	err := m.Fetch(func(r io.Reader) error {
+		p, err := io.ReadAll(r)
+		got = append(got, string(p))
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, got)
+
+	// Emails are marked as read once processed
+	entries, err := os.ReadDir(filepath.Join(root, "new"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.FileExists(t, filepath.Join(root, "cur", "1.eml:2,S"))
+
+	got = nil
+	err = m.Fetch(func(r io.Reader) error {
+		got = append(got, "unexpected")
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
@@ -0,0 +1,238 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+// Mailbox is the mailbox for incoming emails.
+type Mailbox interface {
+	// Fetch calls fn with each unread email in the mailbox, which is marked as
+	// read once fn returns without an error. It stops at the first error.
+	Fetch(fn func(r io.Reader) error) error
+}
+
+// NewMailbox returns the configured mailbox for incoming emails.
+func NewMailbox() (Mailbox, error) {
+	switch conf.IncomingEmail.Mailbox {
+	case "maildir":
+		return &Maildir{Path: conf.IncomingEmail.MaildirPath}, nil
+	case "imap":
+		return &IMAPMailbox{
+			Host:       conf.IncomingEmail.IMAPHost,
+			User:       conf.IncomingEmail.IMAPUser,
+			Password:   conf.IncomingEmail.IMAPPassword,
+			Mailbox:    conf.IncomingEmail.IMAPMailbox,
+			SkipVerify: conf.IncomingEmail.IMAPSkipVerify,
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported mailbox type %q", conf.IncomingEmail.Mailbox)
+	}
+}
+
+// Maildir is a mailbox in the maildir format, where emails are delivered to
+// the "new" directory and moved to the "cur" directory once read.
+type Maildir struct {
+	Path string
+}
+
+func (m *Maildir) Fetch(fn func(r io.Reader) error) error {
+	newDir := filepath.Join(m.Path, "new")
+	entries, err := os.ReadDir(newDir)
+	if err != nil {
+		return errors.Wrap(err, "read directory")
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		err = func() error {
+			f, err := os.Open(filepath.Join(newDir, entry.Name()))
+			if err != nil {
+				return errors.Wrap(err, "open")
+			}
+			defer func() { _ = f.Close() }()
+			return fn(f)
+		}()
+		if err != nil {
+			return errors.Wrapf(err, "process %q", entry.Name())
+		}
+
+		// The "2,S" info marks the email as seen
+		err = os.Rename(filepath.Join(newDir, entry.Name()), filepath.Join(m.Path, "cur", entry.Name()+":2,S"))
+		if err != nil {
+			return errors.Wrap(err, "mark as read")
+		}
+	}
+	return nil
+}
+
+// IMAPMailbox is a mailbox on an IMAP server that is connected via implicit
+// TLS.
+type IMAPMailbox struct {
+	Host       string
+	User       string
+	Password   string
+	Mailbox    string
+	SkipVerify bool
+}
+
+func (m *IMAPMailbox) Fetch(fn func(r io.Reader) error) error {
+	host, _, err := net.SplitHostPort(m.Host)
+	if err != nil {
+		return errors.Wrap(err, "split host and port")
+	}
+	conn, err := tls.DialWithDialer(
+		&net.Dialer{Timeout: time.Minute},
+		"tcp",
+		m.Host,
+		&tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: m.SkipVerify,
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "dial")
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Minute))
+
+	c := &imapClient{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+	if _, err = c.readLine(); err != nil {
+		return errors.Wrap(err, "read greeting")
+	}
+	if _, _, err = c.command("LOGIN %s %s", imapQuote(m.User), imapQuote(m.Password)); err != nil {
+		return errors.Wrap(err, "login")
+	}
+	defer func() { _, _, _ = c.command("LOGOUT") }()
+	if _, _, err = c.command("SELECT %s", imapQuote(m.Mailbox)); err != nil {
+		return errors.Wrap(err, "select mailbox")
+	}
+
+	lines, _, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return errors.Wrap(err, "search unread emails")
+	}
+	var uids []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "* SEARCH") {
+			uids = append(uids, strings.Fields(strings.TrimPrefix(line, "* SEARCH"))...)
+		}
+	}
+
+	for _, uid := range uids {
+		// BODY.PEEK does not mark the email as read before it is processed
+		_, literals, err := c.command("UID FETCH %s BODY.PEEK[]", uid)
+		if err != nil {
+			return errors.Wrapf(err, "fetch email %s", uid)
+		} else if len(literals) == 0 {
+			continue
+		}
+
+		if err = fn(bytes.NewReader(literals[0])); err != nil {
+			return errors.Wrapf(err, "process email %s", uid)
+		}
+
+		if _, _, err = c.command(`UID STORE %s +FLAGS.SILENT (\Seen)`, uid); err != nil {
+			return errors.Wrapf(err, "mark email %s as read", uid)
+		}
+	}
+	return nil
+}
+
+// imapQuote returns the string as an IMAP quoted string.
+func imapQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// imapClient is a minimal IMAP client that only supports the commands needed
+// for fetching unread emails.
+type imapClient struct {
+	conn   io.Writer
+	reader *bufio.Reader
+	tag    int
+}
+
+func (c *imapClient) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+var imapLiteralPattern = regexp.MustCompile(`\{(\d+)\}$`)
+
+// command sends the command and returns the untagged responses along with all
+// literals in them.
+func (c *imapClient) command(format string, args ...any) (lines []string, literals [][]byte, _ error) {
+	c.tag++
+	tag := fmt.Sprintf("A%d", c.tag)
+	_, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if strings.HasPrefix(line, tag+" ") {
+			status := strings.TrimPrefix(line, tag+" ")
+			if !strings.HasPrefix(status, "OK") {
+				return nil, nil, errors.New(status)
+			}
+			return lines, literals, nil
+		}
+
+		// A literal is followed by the rest of the response
+		for {
+			m := imapLiteralPattern.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			size, err := strconv.Atoi(m[1])
+			if err != nil {
+				return nil, nil, err
+			}
+			literal := make([]byte, size)
+			if _, err = io.ReadFull(c.reader, literal); err != nil {
+				return nil, nil, err
+			}
+			literals = append(literals, literal)
+
+			rest, err := c.readLine()
+			if err != nil {
+				return nil, nil, err
+			}
+			line += rest
+		}
+		lines = append(lines, line)
+	}
+}
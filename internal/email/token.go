@@ -0,0 +1,113 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+// TokenAction is the action to be performed by an incoming email.
+type TokenAction string
+
+const (
+	TokenActionReply       TokenAction = "reply"
+	TokenActionUnsubscribe TokenAction = "unsubscribe"
+)
+
+// Token identifies the user and the issue of an incoming email, along with the
+// action to be performed.
+type Token struct {
+	Action  TokenAction
+	UserID  int64
+	IssueID int64
+	// Rands is the rands of the user, which is mixed into the signature but not
+	// included in the signed form, so that the token is revoked once the rands
+	// is regenerated, e.g. when the password is changed.
+	Rands string
+
+	// The signature in the signed form, which is set by ParseToken.
+	sig string
+}
+
+func (t Token) payload() string {
+	return fmt.Sprintf("%s-%d-%d", t.Action, t.UserID, t.IssueID)
+}
+
+func (t Token) signature() string {
+	h := hmac.New(sha256.New, []byte(conf.Security.SecretKey))
+	_, _ = fmt.Fprintf(h, "%s-%s", t.payload(), t.Rands)
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// String returns the signed form of the token, which only contains characters
+// that are safe to be used in the local part of an email address.
+func (t Token) String() string {
+	return t.payload() + "-" + t.signature()
+}
+
+// ParseToken parses the signed form of a token, which must be verified by
+// Token.Verify with the rands of the user that the token claims to belong to.
+func ParseToken(s string) (*Token, error) {
+	fields := strings.Split(strings.ToLower(s), "-")
+	if len(fields) != 4 {
+		return nil, errors.New("malformed token")
+	}
+
+	t := &Token{Action: TokenAction(fields[0])}
+	switch t.Action {
+	case TokenActionReply, TokenActionUnsubscribe:
+	default:
+		return nil, errors.Errorf("unknown action %q", t.Action)
+	}
+
+	var err error
+	t.UserID, err = strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse user ID")
+	}
+	t.IssueID, err = strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse issue ID")
+	}
+
+	t.sig = fields[3]
+	return t, nil
+}
+
+// Verify returns true if the signature of the parsed token is valid for the
+// given rands of the user.
+func (t *Token) Verify(rands string) bool {
+	t.Rands = rands
+	return hmac.Equal([]byte(t.sig), []byte(t.signature()))
+}
+
+// ReplyToAddress returns the address for incoming emails with the token.
+func ReplyToAddress(t Token) string {
+	return strings.Replace(conf.IncomingEmail.ReplyToAddress, "%{token}", t.String(), 1)
+}
+
+// TokenFromAddress returns the signed form of the token in the address for
+// incoming emails, or an empty string if the address does not match.
+func TokenFromAddress(address string) string {
+	prefix, suffix, _ := strings.Cut(conf.IncomingEmail.ReplyToAddress, "%{token}")
+	address = strings.ToLower(address)
+	prefix = strings.ToLower(prefix)
+	suffix = strings.ToLower(suffix)
+	if len(address) <= len(prefix)+len(suffix) ||
+		!strings.HasPrefix(address, prefix) ||
+		!strings.HasSuffix(address, suffix) {
+		return ""
+	}
+	return address[len(prefix) : len(address)-len(suffix)]
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package email
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+func setMockIncomingEmail(t *testing.T, replyToAddress string) {
+	beforeSecretKey := conf.Security.SecretKey
+	beforeReplyToAddress := conf.IncomingEmail.ReplyToAddress
+	conf.Security.SecretKey = "secret"
+	conf.IncomingEmail.ReplyToAddress = replyToAddress
+	t.Cleanup(func() {
+		conf.Security.SecretKey = beforeSecretKey
+		conf.IncomingEmail.ReplyToAddress = beforeReplyToAddress
+	})
+}
+
+func TestToken(t *testing.T) {
+	setMockIncomingEmail(t, "gogs+%{token}@example.com")
+
+	token := Token{Action: TokenActionReply, UserID: 1, IssueID: 2, Rands: "rands"}
+	assert.NotContains(t, token.String(), token.Rands)
+
+	got, err := ParseToken(token.String())
+	require.NoError(t, err)
+	assert.True(t, got.Verify("rands"))
+	assert.Equal(t, TokenActionReply, got.Action)
+	assert.Equal(t, int64(1), got.UserID)
+	assert.Equal(t, int64(2), got.IssueID)
+
+	// Mail servers may change the case of addresses
+	got, err = ParseToken(strings.ToUpper(token.String()))
+	require.NoError(t, err)
+	assert.True(t, got.Verify("rands"))
+
+	// The token is revoked once the rands is regenerated
+	got, err = ParseToken(token.String())
+	require.NoError(t, err)
+	assert.False(t, got.Verify("regenerated"))
+
+	t.Run("tampered", func(t *testing.T) {
+		for _, s := range []string{
+			strings.Replace(token.String(), "reply-1-2", "reply-3-2", 1),
+			strings.Replace(token.String(), "reply", "unsubscribe", 1),
+		} {
+			got, err := ParseToken(s)
+			require.NoError(t, err)
+			assert.False(t, got.Verify("rands"), s)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{
+			name:    "malformed",
+			token:   "reply-1-2",
+			wantErr: "malformed token",
+		},
+		{
+			name:    "unknown action",
+			token:   "close-1-2-" + token.signature(),
+			wantErr: `unknown action "close"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseToken(test.token)
+			assert.EqualError(t, err, test.wantErr)
+		})
+	}
+}
+
+func TestTokenFromAddress(t *testing.T) {
+	setMockIncomingEmail(t, "gogs+%{token}@example.com")
+
+	token := Token{Action: TokenActionUnsubscribe, UserID: 1, IssueID: 2}
+	address := ReplyToAddress(token)
+	assert.Equal(t, "gogs+"+token.String()+"@example.com", address)
+	assert.Equal(t, token.String(), TokenFromAddress(address))
+	assert.Equal(t, token.String(), TokenFromAddress(strings.ToUpper(address)))
+
+	assert.Empty(t, TokenFromAddress("gogs+@example.com"))
+	assert.Empty(t, TokenFromAddress("gogs@example.com"))
+	assert.Empty(t, TokenFromAddress("alice@example.com"))
+}
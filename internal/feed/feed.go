@@ -0,0 +1,212 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// Type is the format of a feed.
+type Type string
+
+const (
+	TypeAtom Type = "atom"
+	TypeRSS  Type = "rss"
+)
+
+// ContentType returns the MIME type of the feed format.
+func (t Type) ContentType() string {
+	switch t {
+	case TypeAtom:
+		return "application/atom+xml; charset=utf-8"
+	case TypeRSS:
+		return "application/rss+xml; charset=utf-8"
+	default:
+		return "application/xml; charset=utf-8"
+	}
+}
+
+// Author is the author of a feed item.
+type Author struct {
+	Name  string
+	Email string // Optional
+}
+
+// Item is an entry of a feed.
+type Item struct {
+	ID      string // A globally unique identifier that never changes
+	Title   string
+	Link    string
+	Content string // HTML
+	Author  Author
+	Created time.Time
+}
+
+// Feed is a list of items about the same subject, which can be encoded in
+// either Atom or RSS format.
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Updated     time.Time // Defaults to the time of the latest item
+	Items       []*Item
+}
+
+func (f *Feed) updated() time.Time {
+	updated := f.Updated
+	for _, item := range f.Items {
+		if item.Created.After(updated) {
+			updated = item.Created
+		}
+	}
+	return updated
+}
+
+// Encode returns the feed in the given format.
+func (f *Feed) Encode(typ Type) ([]byte, error) {
+	var v any
+	switch typ {
+	case TypeAtom:
+		v = f.atom()
+	case TypeRSS:
+		v = f.rss()
+	default:
+		return nil, fmt.Errorf("unsupported feed type %q", typ)
+	}
+
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+}
+
+type atomContent struct {
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	Title     string       `xml:"title"`
+	Link      atomLink     `xml:"link"`
+	ID        string       `xml:"id"`
+	Updated   string       `xml:"updated"`
+	Published string       `xml:"published"`
+	Author    atomAuthor   `xml:"author"`
+	Content   *atomContent `xml:"content,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName  xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string       `xml:"title"`
+	ID       string       `xml:"id"`
+	Updated  string       `xml:"updated"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	Link     atomLink     `xml:"link"`
+	Entries  []*atomEntry `xml:"entry"`
+}
+
+func (f *Feed) atom() *atomFeed {
+	v := &atomFeed{
+		Title:    f.Title,
+		ID:       f.Link,
+		Updated:  f.updated().UTC().Format(time.RFC3339),
+		Subtitle: f.Description,
+		Link:     atomLink{Href: f.Link, Rel: "alternate"},
+		Entries:  make([]*atomEntry, 0, len(f.Items)),
+	}
+	for _, item := range f.Items {
+		created := item.Created.UTC().Format(time.RFC3339)
+		entry := &atomEntry{
+			Title:     item.Title,
+			Link:      atomLink{Href: item.Link, Rel: "alternate"},
+			ID:        item.ID,
+			Updated:   created,
+			Published: created,
+			Author: atomAuthor{
+				Name:  item.Author.Name,
+				Email: item.Author.Email,
+			},
+		}
+		if item.Content != "" {
+			entry.Content = &atomContent{Type: "html", Content: item.Content}
+		}
+		v.Entries = append(v.Entries, entry)
+	}
+	return v
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	GUID        string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description,omitempty"`
+	Author      string  `xml:"author,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssChannel struct {
+	Title         string     `xml:"title"`
+	Link          string     `xml:"link"`
+	Description   string     `xml:"description"`
+	LastBuildDate string     `xml:"lastBuildDate"`
+	Items         []*rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name    `xml:"rss"`
+	Version string      `xml:"version,attr"`
+	Channel *rssChannel `xml:"channel"`
+}
+
+func (f *Feed) rss() *rssFeed {
+	channel := &rssChannel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Description:   f.Description,
+		LastBuildDate: f.updated().UTC().Format(time.RFC1123Z),
+		Items:         make([]*rssItem, 0, len(f.Items)),
+	}
+	// The description is required by RSS
+	if channel.Description == "" {
+		channel.Description = f.Title
+	}
+
+	for _, item := range f.Items {
+		v := &rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Content,
+			GUID:        rssGUID{GUID: item.ID},
+			PubDate:     item.Created.UTC().Format(time.RFC1123Z),
+		}
+		// The author of an RSS item must be an email address
+		if item.Author.Email != "" {
+			v.Author = fmt.Sprintf("%s (%s)", item.Author.Email, item.Author.Name)
+		}
+		channel.Items = append(channel.Items, v)
+	}
+	return &rssFeed{
+		Version: "2.0",
+		Channel: channel,
+	}
+}
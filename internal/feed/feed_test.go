@@ -0,0 +1,108 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package feed
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeed_Encode(t *testing.T) {
+	f := &Feed{
+		Title: "alice",
+		Link:  "https://gogs.example.com/alice",
+		Items: []*Item{
+			{
+				ID:      "tag:gogs.example.com,2005:action-1",
+				Title:   "alice created repository alice/foo",
+				Link:    "https://gogs.example.com/alice/foo",
+				Content: `<a href="https://gogs.example.com/alice/foo">alice/foo</a>`,
+				Author:  Author{Name: "alice"},
+				Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+			{
+				ID:      "https://gogs.example.com/alice/foo/commit/abc",
+				Title:   "Fix bug",
+				Link:    "https://gogs.example.com/alice/foo/commit/abc",
+				Author:  Author{Name: "Alice", Email: "alice@example.com"},
+				Created: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	t.Run("atom", func(t *testing.T) {
+		got, err := f.Encode(TypeAtom)
+		require.NoError(t, err)
+
+		want := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>alice</title>
+  <id>https://gogs.example.com/alice</id>
+  <updated>2024-01-02T03:04:05Z</updated>
+  <link href="https://gogs.example.com/alice" rel="alternate"></link>
+  <entry>
+    <title>alice created repository alice/foo</title>
+    <link href="https://gogs.example.com/alice/foo" rel="alternate"></link>
+    <id>tag:gogs.example.com,2005:action-1</id>
+    <updated>2024-01-02T03:04:05Z</updated>
+    <published>2024-01-02T03:04:05Z</published>
+    <author>
+      <name>alice</name>
+    </author>
+    <content type="html">&lt;a href=&#34;https://gogs.example.com/alice/foo&#34;&gt;alice/foo&lt;/a&gt;</content>
+  </entry>
+  <entry>
+    <title>Fix bug</title>
+    <link href="https://gogs.example.com/alice/foo/commit/abc" rel="alternate"></link>
+    <id>https://gogs.example.com/alice/foo/commit/abc</id>
+    <updated>2024-01-01T00:00:00Z</updated>
+    <published>2024-01-01T00:00:00Z</published>
+    <author>
+      <name>Alice</name>
+      <email>alice@example.com</email>
+    </author>
+  </entry>
+</feed>`
+		assert.Equal(t, want, string(got))
+	})
+
+	t.Run("rss", func(t *testing.T) {
+		got, err := f.Encode(TypeRSS)
+		require.NoError(t, err)
+
+		want := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>alice</title>
+    <link>https://gogs.example.com/alice</link>
+    <description>alice</description>
+    <lastBuildDate>Tue, 02 Jan 2024 03:04:05 +0000</lastBuildDate>
+    <item>
+      <title>alice created repository alice/foo</title>
+      <link>https://gogs.example.com/alice/foo</link>
+      <description>&lt;a href=&#34;https://gogs.example.com/alice/foo&#34;&gt;alice/foo&lt;/a&gt;</description>
+      <guid isPermaLink="false">tag:gogs.example.com,2005:action-1</guid>
+      <pubDate>Tue, 02 Jan 2024 03:04:05 +0000</pubDate>
+    </item>
+    <item>
+      <title>Fix bug</title>
+      <link>https://gogs.example.com/alice/foo/commit/abc</link>
+      <author>alice@example.com (Alice)</author>
+      <guid isPermaLink="false">https://gogs.example.com/alice/foo/commit/abc</guid>
+      <pubDate>Mon, 01 Jan 2024 00:00:00 +0000</pubDate>
+    </item>
+  </channel>
+</rss>`
+		assert.Equal(t, want, string(got))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := f.Encode("json")
+		assert.Error(t, err)
+	})
+}
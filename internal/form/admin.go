@@ -23,19 +23,22 @@ func (f *AdminCrateUser) Validate(ctx *macaron.Context, errs binding.Errors) bin
 }
 
 type AdminEditUser struct {
-	LoginType        string `binding:"Required"`
-	LoginName        string
-	FullName         string `binding:"MaxSize(100)"`
-	Email            string `binding:"Required;Email;MaxSize(254)"`
-	Password         string `binding:"MaxSize(255)"`
-	Website          string `binding:"MaxSize(50)"`
-	Location         string `binding:"MaxSize(50)"`
-	MaxRepoCreation  int
-	Active           bool
-	Admin            bool
-	AllowGitHook     bool
-	AllowImportLocal bool
-	ProhibitLogin    bool
+	LoginType         string `binding:"Required"`
+	LoginName         string
+	FullName          string `binding:"MaxSize(100)"`
+	Email             string `binding:"Required;Email;MaxSize(254)"`
+	Password          string `binding:"MaxSize(255)"`
+	Website           string `binding:"MaxSize(50)"`
+	Location          string `binding:"MaxSize(50)"`
+	MaxRepoCreation   int
+	MaxRepoSize       int64
+	MaxLFSSize        int64 `form:"max_lfs_size"`
+	MaxAttachmentSize int64
+	Active            bool
+	Admin             bool
+	AllowGitHook      bool
+	AllowImportLocal  bool
+	ProhibitLogin     bool
 }
 
 func (f *AdminEditUser) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
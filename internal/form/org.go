@@ -18,12 +18,15 @@ func (f *CreateOrg) Validate(ctx *macaron.Context, errs binding.Errors) binding.
 }
 
 type UpdateOrgSetting struct {
-	Name            string `binding:"Required;AlphaDashDot;MaxSize(35)" locale:"org.org_name_holder"`
-	FullName        string `binding:"MaxSize(100)"`
-	Description     string `binding:"MaxSize(255)"`
-	Website         string `binding:"Url;MaxSize(100)"`
-	Location        string `binding:"MaxSize(50)"`
-	MaxRepoCreation int
+	Name              string `binding:"Required;AlphaDashDot;MaxSize(35)" locale:"org.org_name_holder"`
+	FullName          string `binding:"MaxSize(100)"`
+	Description       string `binding:"MaxSize(255)"`
+	Website           string `binding:"Url;MaxSize(100)"`
+	Location          string `binding:"MaxSize(50)"`
+	MaxRepoCreation   int
+	MaxRepoSize       int64
+	MaxLFSSize        int64 `form:"max_lfs_size"`
+	MaxAttachmentSize int64
 }
 
 func (f *UpdateOrgSetting) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
@@ -14,6 +14,7 @@ import (
 
 	"gogs.io/gogs/internal/conf"
 	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/migrate"
 	"gogs.io/gogs/internal/netutil"
 )
 
@@ -50,6 +51,16 @@ type MigrateRepo struct {
 	Private      bool   `json:"private"`
 	Unlisted     bool   `json:"unlisted"`
 	Description  string `json:"description" binding:"MaxSize(512)"`
+
+	// Options of migrating repository data other than Git data from the code
+	// hosting service.
+	Service      string `json:"service"`
+	AuthToken    string `json:"auth_token"`
+	Labels       bool   `json:"labels"`
+	Milestones   bool   `json:"milestones"`
+	Issues       bool   `json:"issues"`
+	PullRequests bool   `json:"pull_requests"`
+	Releases     bool   `json:"releases"`
 }
 
 func (f *MigrateRepo) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
@@ -93,6 +104,57 @@ func (f MigrateRepo) ParseRemoteAddr(user *database.User) (string, error) {
 	return remoteAddr, nil
 }
 
+// IsValidService returns true if no service is specified, or the service is
+// supported and the clone address is an HTTP/HTTPS URL.
+func (f MigrateRepo) IsValidService() bool {
+	if f.Service == "" {
+		return true
+	}
+	remoteAddr := strings.TrimSpace(f.CloneAddr)
+	return migrate.Service(f.Service).IsValid() &&
+		(strings.HasPrefix(remoteAddr, "http://") || strings.HasPrefix(remoteAddr, "https://"))
+}
+
+// RepoMigrationOptions returns options for migrating repository data other than
+// Git data from the service. It returns false if there is nothing to migrate,
+// including for mirrors.
+func (f MigrateRepo) RepoMigrationOptions(doerID int64) (database.CreateRepoMigrationOptions, bool) {
+	if f.Service == "" || f.Mirror {
+		return database.CreateRepoMigrationOptions{}, false
+	}
+
+	var names []string
+	for name, selected := range map[migrate.Stage]bool{
+		migrate.StageLabels:       f.Labels,
+		migrate.StageMilestones:   f.Milestones,
+		migrate.StageIssues:       f.Issues,
+		migrate.StagePullRequests: f.PullRequests,
+		migrate.StageReleases:     f.Releases,
+	} {
+		if selected {
+			names = append(names, string(name))
+		}
+	}
+	stages := migrate.ParseStages(names)
+	if len(stages) == 0 {
+		return database.CreateRepoMigrationOptions{}, false
+	}
+
+	// Credentials must not be persisted along with the clone address
+	cloneAddr := strings.TrimSpace(f.CloneAddr)
+	if u, err := url.Parse(cloneAddr); err == nil {
+		u.User = nil
+		cloneAddr = u.String()
+	}
+	return database.CreateRepoMigrationOptions{
+		DoerID:    doerID,
+		Service:   migrate.Service(f.Service),
+		CloneAddr: cloneAddr,
+		AuthToken: f.AuthToken,
+		Stages:    stages,
+	}, true
+}
+
 type RepoSetting struct {
 	RepoName      string `binding:"Required;AlphaDashDot;MaxSize(100)"`
 	Description   string `binding:"MaxSize(512)"`
@@ -104,6 +166,9 @@ type RepoSetting struct {
 	Unlisted      bool
 	EnablePrune   bool
 
+	// Data migration settings
+	AuthToken string
+
 	// Advanced settings
 	EnableWiki            bool
 	AllowPublicWiki       bool
@@ -111,6 +176,7 @@ type RepoSetting struct {
 	ExternalWikiURL       string
 	EnableIssues          bool
 	AllowPublicIssues     bool
+	AllowClosingBlocked   bool
 	EnableExternalTracker bool
 	ExternalTrackerURL    string
 	TrackerURLFormat      string
@@ -231,7 +297,7 @@ type NewIssue struct {
 	Title       string `binding:"Required;MaxSize(255)"`
 	LabelIDs    string `form:"label_ids"`
 	MilestoneID int64
-	AssigneeID  int64
+	AssigneeIDs string `form:"assignee_ids"`
 	Content     string
 	Files       []string
 }
@@ -267,6 +333,35 @@ func (f *CreateMilestone) Validate(ctx *macaron.Context, errs binding.Errors) bi
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+type CreateProject struct {
+	Title          string `binding:"Required;MaxSize(255)"`
+	Content        string
+	DefaultColumns bool
+}
+
+func (f *CreateProject) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+type ProjectColumn struct {
+	Title      string `binding:"Required;MaxSize(255)"`
+	Automation string
+}
+
+func (f *ProjectColumn) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+type ProjectCard struct {
+	ColumnID int64
+	Issue    string
+	Note     string
+}
+
+func (f *ProjectCard) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // .____          ___.          .__
 // |    |   _____ \_ |__   ____ |  |
 // |    |   \__  \ | __ \_/ __ \|  |
@@ -138,6 +138,17 @@ func (f *ChangePassword) Validate(ctx *macaron.Context, errs binding.Errors) bin
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+type NotificationPreference struct {
+	OwnActivity   string `binding:"Required;In(immediate,hourly,daily,none)"`
+	Mention       string `binding:"Required;In(immediate,hourly,daily,none)"`
+	Participating string `binding:"Required;In(immediate,hourly,daily,none)"`
+	Watching      string `binding:"Required;In(immediate,hourly,daily,none)"`
+}
+
+func (f *NotificationPreference) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 type AddSSHKey struct {
 	Title   string `binding:"Required;MaxSize(50)"`
 	Content string `binding:"Required"`
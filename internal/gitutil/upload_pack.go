@@ -0,0 +1,34 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gitutil
+
+import (
+	"fmt"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+// UploadPackConfigs returns the list of "-c" options to be passed to git before
+// the "upload-pack" subcommand, based on the "[git]" settings. These options
+// enable partial clone (e.g. "git clone --filter=blob:none") and the lazy
+// fetching of missing objects afterwards, as well as the pack-objects cache.
+func UploadPackConfigs() []string {
+	var configs []string
+	if conf.Git.UploadPackAllowFilter {
+		// Lazy fetching of missing objects requests objects that are not ref tips,
+		// only allow those reachable from refs so that unreachable objects (e.g.
+		// force-pushed away) are never served.
+		configs = append(configs, "-c", "uploadpack.allowFilter=true", "-c", "uploadpack.allowReachableSHA1InWant=true")
+	}
+	if conf.Git.UploadPackAllowAnySHA1InWant {
+		configs = append(configs, "-c", "uploadpack.allowAnySHA1InWant=true")
+	}
+	if conf.Git.PackObjectsCache.Enabled {
+		// NOTE: The hook is only respected when set via protected configuration,
+		// which includes the command line.
+		configs = append(configs, "-c", fmt.Sprintf(`uploadpack.packObjectsHook="%s" hook --config='%s' pack-objects`, conf.AppPath(), conf.CustomConf))
+	}
+	return configs
+}
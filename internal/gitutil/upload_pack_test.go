@@ -0,0 +1,67 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package gitutil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+func TestUploadPackConfigs(t *testing.T) {
+	before := conf.Git
+	defer func() {
+		conf.Git = before
+	}()
+
+	tests := []struct {
+		name               string
+		allowFilter        bool
+		allowAnySHA1InWant bool
+		packObjectsCache   bool
+		want               []string
+	}{
+		{
+			name: "nothing enabled",
+			want: nil,
+		},
+		{
+			name:        "only filter",
+			allowFilter: true,
+			want: []string{
+				"-c", "uploadpack.allowFilter=true",
+				"-c", "uploadpack.allowReachableSHA1InWant=true",
+			},
+		},
+		{
+			name:               "filter and any SHA1 in want",
+			allowFilter:        true,
+			allowAnySHA1InWant: true,
+			want: []string{
+				"-c", "uploadpack.allowFilter=true",
+				"-c", "uploadpack.allowReachableSHA1InWant=true",
+				"-c", "uploadpack.allowAnySHA1InWant=true",
+			},
+		},
+		{
+			name:             "only pack-objects cache",
+			packObjectsCache: true,
+			want: []string{
+				"-c", fmt.Sprintf(`uploadpack.packObjectsHook="%s" hook --config='%s' pack-objects`, conf.AppPath(), conf.CustomConf),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf.Git.UploadPackAllowFilter = test.allowFilter
+			conf.Git.UploadPackAllowAnySHA1InWant = test.allowAnySHA1InWant
+			conf.Git.PackObjectsCache.Enabled = test.packObjectsCache
+			assert.Equal(t, test.want, UploadPackConfigs())
+		})
+	}
+}
@@ -28,6 +28,9 @@ type Storager interface {
 	// responsibility the close the writer when needed. ErrObjectNotExist is
 	// returned if the given oid does not exist.
 	Download(oid OID, w io.Writer) error
+	// Delete deletes the content of given oid. It is not an error if the given
+	// oid does not exist.
+	Delete(oid OID) error
 }
 
 // Storage is the storage type of an LFS object.
@@ -107,3 +110,16 @@ func (s *LocalStorage) Download(oid OID, w io.Writer) error {
 	}
 	return nil
 }
+
+func (s *LocalStorage) Delete(oid OID) error {
+	fpath := s.storagePath(oid)
+	if fpath == "" {
+		return nil
+	}
+
+	err := os.Remove(fpath)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "remove file")
+	}
+	return nil
+}
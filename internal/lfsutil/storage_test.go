@@ -132,3 +132,22 @@ func TestLocalStorage_Download(t *testing.T) {
 		})
 	}
 }
+
+func TestLocalStorage_Delete(t *testing.T) {
+	oid := OID("ef797c8118f02dfb649607dd5d3f8c7623048c9c063d532cc95c5ed7a898a64f")
+	s := &LocalStorage{
+		Root: t.TempDir(),
+	}
+
+	_, err := s.Upload(oid, io.NopCloser(strings.NewReader("Hello world!")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.FileExists(t, s.storagePath(oid))
+
+	assert.Nil(t, s.Delete(oid))
+	assert.NoFileExists(t, s.storagePath(oid))
+
+	// Deleting a non-existent object is not an error
+	assert.Nil(t, s.Delete(oid))
+}
@@ -36,6 +36,11 @@ const (
 var (
 	// MentionPattern matches string that mentions someone, e.g. @Unknwon
 	MentionPattern = lazyregexp.New(`(\s|^|\W)@[0-9a-zA-Z-_\.]+`)
+	// TeamMentionPattern matches string that mentions a team of an organization,
+	// e.g. @gogs/core
+	TeamMentionPattern = lazyregexp.New(`(\s|^|\W)@[0-9a-zA-Z-_\.]+/[0-9a-zA-Z-_\.]+`)
+	// anyMentionPattern matches string that mentions either someone or a team.
+	anyMentionPattern = lazyregexp.New(`(\s|^|\W)@[0-9a-zA-Z-_\.]+(/[0-9a-zA-Z-_\.]+)?`)
 
 	// CommitPattern matches link to certain commit with or without trailing hash,
 	// e.g. https://try.gogs.io/gogs/gogs/commit/d8a994ef243349f321568f9e36d5c3f444b99cae#diff-2
@@ -59,7 +64,13 @@ var (
 
 // FindAllMentions matches mention patterns in given content
 // and returns a list of found user names without @ prefix.
+// Mentions of teams are not included.
 func FindAllMentions(content string) []string {
+	// Strip team mentions but keep the leading character as the boundary
+	content = TeamMentionPattern.ReplaceAllStringFunc(content, func(m string) string {
+		return m[:strings.Index(m, "@")]
+	})
+
 	mentions := MentionPattern.FindAllString(content, -1)
 	for i := range mentions {
 		mentions[i] = mentions[i][strings.Index(mentions[i], "@")+1:] // Strip @ character
@@ -67,6 +78,16 @@ func FindAllMentions(content string) []string {
 	return mentions
 }
 
+// FindAllTeamMentions matches team mention patterns in given content and
+// returns a list of found teams in the form of "<org>/<team>" without @ prefix.
+func FindAllTeamMentions(content string) []string {
+	mentions := TeamMentionPattern.FindAllString(content, -1)
+	for i := range mentions {
+		mentions[i] = mentions[i][strings.Index(mentions[i], "@")+1:] // Strip @ character
+	}
+	return mentions
+}
+
 // cutoutVerbosePrefix cutouts URL prefix including sub-path to
 // return a clean unified string of request URL path.
 func cutoutVerbosePrefix(prefix string) string {
@@ -152,11 +173,15 @@ func RenderSha1CurrentPattern(rawBytes []byte, urlPrefix string) []byte {
 
 // RenderSpecialLink renders mentions, indexes and SHA1 strings to corresponding links.
 func RenderSpecialLink(rawBytes []byte, urlPrefix string, metas map[string]string) []byte {
-	ms := MentionPattern.FindAll(rawBytes, -1)
-	for _, m := range ms {
-		m = m[bytes.Index(m, []byte("@")):]
-		rawBytes = bytes.ReplaceAll(rawBytes, m, []byte(fmt.Sprintf(`<a href="%s/%s">%s</a>`, conf.Server.Subpath, m[1:], m)))
-	}
+	rawBytes = anyMentionPattern.ReplaceAllFunc(rawBytes, func(m []byte) []byte {
+		i := bytes.Index(m, []byte("@"))
+		prefix, mention := m[:i], m[i:]
+		// Mentions of teams link to the team page of the organization
+		if org, team, ok := bytes.Cut(mention[1:], []byte("/")); ok {
+			return []byte(fmt.Sprintf(`%s<a href="%s/org/%s/teams/%s">%s</a>`, prefix, conf.Server.Subpath, org, team, mention))
+		}
+		return []byte(fmt.Sprintf(`%s<a href="%s/%s">%s</a>`, prefix, conf.Server.Subpath, mention[1:], mention))
+	})
 
 	rawBytes = RenderIssueIndexPattern(rawBytes, urlPrefix, metas)
 	rawBytes = RenderCrossReferenceIssueIndexPattern(rawBytes, urlPrefix, metas)
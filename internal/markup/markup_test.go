@@ -44,6 +44,7 @@ func Test_FindAllMentions(t *testing.T) {
 		{input: "@unknwon what do you think?", expMatches: []string{"unknwon"}},
 		{input: "Hi @unknwon, sounds good to me", expMatches: []string{"unknwon"}},
 		{input: "cc/ @unknwon @eddycjy", expMatches: []string{"unknwon", "eddycjy"}},
+		{input: "cc/ @gogs/core @unknwon", expMatches: []string{"unknwon"}},
 	}
 	for _, test := range tests {
 		t.Run("", func(t *testing.T) {
@@ -52,6 +53,47 @@ func Test_FindAllMentions(t *testing.T) {
 	}
 }
 
+func Test_FindAllTeamMentions(t *testing.T) {
+	tests := []struct {
+		input      string
+		expMatches []string
+	}{
+		{input: "@unknwon, what do you think?", expMatches: nil},
+		{input: "@gogs/core, what do you think?", expMatches: []string{"gogs/core"}},
+		{input: "cc/ @gogs/core @unknwon (@gogs/docs)", expMatches: []string{"gogs/core", "gogs/docs"}},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, test.expMatches, FindAllTeamMentions(test.input))
+		})
+	}
+}
+
+func Test_RenderSpecialLink_Mentions(t *testing.T) {
+	tests := []struct {
+		input  string
+		expVal string
+	}{
+		{
+			input:  "@unknwon, what do you think?",
+			expVal: `<a href="/unknwon">@unknwon</a>, what do you think?`,
+		},
+		{
+			input:  "cc/ @gogs/core and @unknwon",
+			expVal: `cc/ <a href="/org/gogs/teams/core">@gogs/core</a> and <a href="/unknwon">@unknwon</a>`,
+		},
+		{
+			input:  "@uk and @unknwon",
+			expVal: `<a href="/uk">@uk</a> and <a href="/unknwon">@unknwon</a>`,
+		},
+	}
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, test.expVal, string(RenderSpecialLink([]byte(test.input), "", nil)))
+		})
+	}
+}
+
 func Test_RenderIssueIndexPattern(t *testing.T) {
 	urlPrefix := "/prefix"
 	t.Run("render to internal issue tracker", func(t *testing.T) {
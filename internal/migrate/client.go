@@ -0,0 +1,235 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/netutil"
+)
+
+// client is an HTTP client for JSON APIs of code hosting services.
+type client struct {
+	baseURL string
+	header  http.Header
+	http    *http.Client
+}
+
+func newClient(baseURL string, header http.Header) *client {
+	c := &client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		header:  header,
+	}
+	c.http = &http.Client{
+		Timeout:       5 * time.Minute,
+		CheckRedirect: c.checkRedirect,
+	}
+	return c
+}
+
+// checkRedirect blocks redirects to local network addresses, and strips the
+// credentials when redirected to a host other than the API.
+func (c *client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if netutil.IsBlockedLocalHostname(req.URL.Hostname(), conf.Security.LocalNetworkAllowlist) {
+		return errors.Errorf("redirected to %q which is a blocked local network address", req.URL.Host)
+	}
+
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return errors.Wrap(err, "parse base URL")
+	}
+	if req.URL.Host != base.Host {
+		req.Header.Del("Authorization")
+		req.Header.Del("PRIVATE-TOKEN")
+	}
+	return nil
+}
+
+// do sends a GET request to the rawURL. It waits and retries when the rate
+// limit of the API is exceeded.
+func (c *client) do(ctx context.Context, rawURL string, header http.Header) (*http.Response, error) {
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "new request")
+		}
+		for k, vs := range c.header {
+			req.Header[k] = vs
+		}
+		for k, vs := range header {
+			req.Header[k] = vs
+		}
+
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+
+		if wait := rateLimitWait(resp); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+				continue
+			}
+		}
+		return nil, errors.Errorf("GET %s: unexpected status %d: %s", rawURL, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+}
+
+// rateLimitWait returns the duration to wait until the rate limit is reset, or
+// 0 if the response is not about an exceeded rate limit.
+func rateLimitWait(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0
+	}
+
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		seconds, err := strconv.Atoi(v)
+		if err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+
+	for _, prefix := range []string{"X-RateLimit", "RateLimit"} {
+		if resp.Header.Get(prefix+"-Remaining") != "0" {
+			continue
+		}
+		reset, err := strconv.ParseInt(resp.Header.Get(prefix+"-Reset"), 10, 64)
+		if err != nil {
+			continue
+		}
+		wait := time.Until(time.Unix(reset, 0)) + time.Second
+		if wait < time.Second {
+			wait = time.Second
+		}
+		return wait
+	}
+	return 0
+}
+
+// getJSON sends a GET request to the path of the API and decodes the response
+// body into v.
+func (c *client) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	rawURL := c.baseURL + path
+	if len(query) > 0 {
+		rawURL += "?" + query.Encode()
+	}
+
+	resp, err := c.do(ctx, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	err = json.NewDecoder(resp.Body).Decode(v)
+	if err != nil {
+		return errors.Wrapf(err, "decode response of %s", path)
+	}
+	return nil
+}
+
+// getPage returns items on the given page of the API, the pageParam and
+// limitParam are names of query parameters for the page number and the number
+// of items per page respectively. It returns true if it is the last page.
+func getPage[T any](ctx context.Context, c *client, path string, query url.Values, pageParam, limitParam string, page, perPage int) ([]T, bool, error) {
+	q := make(url.Values, len(query)+2)
+	for k, vs := range query {
+		q[k] = vs
+	}
+	q.Set(pageParam, strconv.Itoa(page))
+	q.Set(limitParam, strconv.Itoa(perPage))
+
+	var items []T
+	err := c.getJSON(ctx, path, q, &items)
+	if err != nil {
+		return nil, false, err
+	}
+	return items, len(items) < perPage, nil
+}
+
+// getAll returns items on all pages of the API, see getPage for the meaning of
+// parameters.
+func getAll[T any](ctx context.Context, c *client, path string, query url.Values, pageParam, limitParam string) ([]T, error) {
+	const perPage = 50
+
+	var all []T
+	for page := 1; ; page++ {
+		items, isEnd, err := getPage[T](ctx, c, path, query, pageParam, limitParam, page, perPage)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if isEnd {
+			return all, nil
+		}
+	}
+}
+
+// open sends a GET request to the rawURL and returns the response body. The
+// rawURL may point to anywhere (e.g. links of release assets), thus local
+// network addresses are blocked and the credentials are only sent to the same
+// host of the API.
+func (c *client) open(ctx context.Context, rawURL string, header http.Header) (io.ReadCloser, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse URL")
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse base URL")
+	}
+	if u.Host != base.Host {
+		if netutil.IsBlockedLocalHostname(u.Hostname(), conf.Security.LocalNetworkAllowlist) {
+			return nil, errors.Errorf("%q is a blocked local network address", u.Host)
+		}
+
+		c = &client{
+			baseURL: c.baseURL,
+			http:    c.http,
+		}
+	}
+
+	resp, err := c.do(ctx, rawURL, header)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// timeOf returns the time t points to, or zero time if t is nil.
+func timeOf(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// normalizeColor returns the color in the form of "#rrggbb".
+func normalizeColor(color string) string {
+	return "#" + strings.TrimPrefix(strings.ToLower(color), "#")
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gogs.io/gogs/internal/conf"
+)
+
+func TestClient_open_Redirect(t *testing.T) {
+	var gotToken string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotToken = r.Header.Get("PRIVATE-TOKEN")
+		_, _ = io.WriteString(w, "asset")
+	}))
+	defer target.Close()
+	// Use a hostname different from the API so the request is to another host
+	targetURL := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, targetURL+"/asset", http.StatusFound)
+	}))
+	defer api.Close()
+
+	header := make(http.Header)
+	header.Set("PRIVATE-TOKEN", "abc")
+	c := newClient(api.URL, header)
+
+	t.Run("blocked local network address", func(t *testing.T) {
+		_, err := c.open(context.Background(), api.URL+"/asset", nil)
+		assert.ErrorContains(t, err, "blocked local network address")
+	})
+
+	t.Run("credentials are not sent to another host", func(t *testing.T) {
+		before := conf.Security.LocalNetworkAllowlist
+		conf.Security.LocalNetworkAllowlist = []string{"localhost"}
+		t.Cleanup(func() { conf.Security.LocalNetworkAllowlist = before })
+
+		rc, err := c.open(context.Background(), api.URL+"/asset", nil)
+		require.NoError(t, err)
+		defer func() { _ = rc.Close() }()
+
+		p, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		assert.Equal(t, "asset", string(p))
+		assert.Empty(t, gotToken)
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Service is a code hosting service.
+type Service string
+
+const (
+	ServiceGitHub Service = "github"
+	ServiceGitLab Service = "gitlab"
+	ServiceGitea  Service = "gitea"
+)
+
+// IsValid returns true if the service is supported.
+func (s Service) IsValid() bool {
+	switch s {
+	case ServiceGitHub, ServiceGitLab, ServiceGitea:
+		return true
+	}
+	return false
+}
+
+// DownloaderOptions contains options for creating a downloader.
+type DownloaderOptions struct {
+	Service Service
+	// CloneAddr is the HTTP(S) clone address of the repository, e.g.
+	// "https://github.com/gogs/gogs.git". The address of the API and the path of
+	// the repository are derived from it.
+	CloneAddr string
+	// AuthToken is the access token to authenticate with the API, it is
+	// required for private repositories.
+	AuthToken string
+}
+
+// NewDownloader returns a new downloader of the service for the repository.
+func NewDownloader(opts DownloaderOptions) (Downloader, error) {
+	u, err := url.Parse(opts.CloneAddr)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse clone address")
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.Errorf("unsupported scheme %q of clone address", u.Scheme)
+	}
+
+	repoPath := strings.Trim(strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), ".git"), "/")
+	if strings.Count(repoPath, "/") < 1 {
+		return nil, errors.Errorf("invalid repository path %q of clone address", repoPath)
+	}
+	host := u.Scheme + "://" + u.Host
+
+	switch opts.Service {
+	case ServiceGitHub:
+		baseURL := host + "/api/v3"
+		if u.Host == "github.com" {
+			baseURL = "https://api.github.com"
+		}
+		return newGitHubDownloader(baseURL, repoPath, opts.AuthToken), nil
+	case ServiceGitLab:
+		return newGitLabDownloader(host+"/api/v4", repoPath, opts.AuthToken), nil
+	case ServiceGitea:
+		return newGiteaDownloader(host+"/api/v1", repoPath, opts.AuthToken), nil
+	}
+	return nil, errors.Errorf("unsupported service %q", opts.Service)
+}
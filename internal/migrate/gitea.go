@@ -0,0 +1,261 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var _ Downloader = (*giteaDownloader)(nil)
+
+// giteaDownloader is a downloader for Gitea, see https://docs.gitea.com/api.
+type giteaDownloader struct {
+	client *client
+	// repoPath is the API path prefix of the repository, e.g. "/repos/gitea/tea".
+	repoPath string
+}
+
+func newGiteaDownloader(baseURL, repoPath, token string) *giteaDownloader {
+	header := make(http.Header)
+	if token != "" {
+		header.Set("Authorization", "token "+token)
+	}
+	return &giteaDownloader{
+		client:   newClient(baseURL, header),
+		repoPath: "/repos/" + repoPath,
+	}
+}
+
+// giteaUser is a user returned by the API, which already contains the email
+// address when visible to the token.
+type giteaUser struct {
+	Login string `json:"login"`
+	Email string `json:"email"`
+}
+
+func (u giteaUser) user() User {
+	return User{
+		Name:  u.Login,
+		Email: u.Email,
+	}
+}
+
+func (d *giteaDownloader) GetLabels(ctx context.Context) ([]*Label, error) {
+	ls, err := getAll[struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}](ctx, d.client, d.repoPath+"/labels", nil, "page", "limit")
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]*Label, 0, len(ls))
+	for _, l := range ls {
+		labels = append(labels, &Label{
+			Name:  l.Name,
+			Color: normalizeColor(l.Color),
+		})
+	}
+	return labels, nil
+}
+
+func (d *giteaDownloader) GetMilestones(ctx context.Context) ([]*Milestone, error) {
+	ms, err := getAll[struct {
+		Title       string     `json:"title"`
+		Description string     `json:"description"`
+		State       string     `json:"state"`
+		DueOn       *time.Time `json:"due_on"`
+		ClosedAt    *time.Time `json:"closed_at"`
+	}](ctx, d.client, d.repoPath+"/milestones", url.Values{"state": {"all"}}, "page", "limit")
+	if err != nil {
+		return nil, err
+	}
+
+	milestones := make([]*Milestone, 0, len(ms))
+	for _, m := range ms {
+		milestones = append(milestones, &Milestone{
+			Title:       m.Title,
+			Description: m.Description,
+			Deadline:    timeOf(m.DueOn),
+			IsClosed:    m.State == "closed",
+			Closed:      timeOf(m.ClosedAt),
+		})
+	}
+	return milestones, nil
+}
+
+type giteaIssue struct {
+	Number int64     `json:"number"`
+	Title  string    `json:"title"`
+	Body   string    `json:"body"`
+	User   giteaUser `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Milestone *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+	State     string     `json:"state"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+}
+
+func (i giteaIssue) issue(isPull bool) Issue {
+	issue := Issue{
+		Number:   i.Number,
+		IsPull:   isPull,
+		Title:    i.Title,
+		Content:  i.Body,
+		Poster:   i.User.user(),
+		IsClosed: i.State == "closed",
+		Created:  i.CreatedAt,
+		Updated:  i.UpdatedAt,
+		Closed:   timeOf(i.ClosedAt),
+	}
+	for _, l := range i.Labels {
+		issue.Labels = append(issue.Labels, l.Name)
+	}
+	if i.Milestone != nil {
+		issue.Milestone = i.Milestone.Title
+	}
+	return issue
+}
+
+func (d *giteaDownloader) GetIssues(ctx context.Context, page, perPage int) ([]*Issue, bool, error) {
+	is, isEnd, err := getPage[giteaIssue](ctx, d.client, d.repoPath+"/issues", url.Values{"state": {"all"}, "type": {"issues"}}, "page", "limit", page, perPage)
+	if err != nil {
+		return nil, false, err
+	}
+
+	issues := make([]*Issue, 0, len(is))
+	for _, i := range is {
+		issue := i.issue(false)
+		issues = append(issues, &issue)
+	}
+	return issues, isEnd, nil
+}
+
+func (d *giteaDownloader) GetPullRequests(ctx context.Context, page, perPage int) ([]*PullRequest, bool, error) {
+	ps, isEnd, err := getPage[struct {
+		giteaIssue
+		Merged         bool       `json:"merged"`
+		MergedAt       *time.Time `json:"merged_at"`
+		MergedBy       giteaUser  `json:"merged_by"`
+		MergeCommitSHA string     `json:"merge_commit_sha"`
+		Head           struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	}](ctx, d.client, d.repoPath+"/pulls", url.Values{"state": {"all"}, "sort": {"oldest"}}, "page", "limit", page, perPage)
+	if err != nil {
+		return nil, false, err
+	}
+
+	prs := make([]*PullRequest, 0, len(ps))
+	for _, p := range ps {
+		pr := &PullRequest{
+			Issue:      p.giteaIssue.issue(true),
+			HeadBranch: p.Head.Ref,
+			HeadSHA:    p.Head.SHA,
+			BaseBranch: p.Base.Ref,
+		}
+		if p.Merged {
+			pr.HasMerged = true
+			pr.Merged = timeOf(p.MergedAt)
+			pr.MergedBy = p.MergedBy.user()
+			pr.MergedCommitID = p.MergeCommitSHA
+		}
+		prs = append(prs, pr)
+	}
+	return prs, isEnd, nil
+}
+
+func (d *giteaDownloader) GetComments(ctx context.Context, issue *Issue) ([]*Comment, error) {
+	// The API returns all comments at once without pagination.
+	var cs []struct {
+		User      giteaUser `json:"user"`
+		Body      string    `json:"body"`
+		CreatedAt time.Time `json:"created_at"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	err := d.client.getJSON(ctx, fmt.Sprintf("%s/issues/%d/comments", d.repoPath, issue.Number), nil, &cs)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]*Comment, 0, len(cs))
+	for _, c := range cs {
+		comments = append(comments, &Comment{
+			Poster:  c.User.user(),
+			Content: c.Body,
+			Created: c.CreatedAt,
+			Updated: c.UpdatedAt,
+		})
+	}
+	return comments, nil
+}
+
+func (d *giteaDownloader) GetReleases(ctx context.Context) ([]*Release, error) {
+	rs, err := getAll[struct {
+		TagName         string    `json:"tag_name"`
+		TargetCommitish string    `json:"target_commitish"`
+		Name            string    `json:"name"`
+		Body            string    `json:"body"`
+		Draft           bool      `json:"draft"`
+		Prerelease      bool      `json:"prerelease"`
+		Author          giteaUser `json:"author"`
+		CreatedAt       time.Time `json:"created_at"`
+		Assets          []struct {
+			Name               string `json:"name"`
+			Size               int64  `json:"size"`
+			BrowserDownloadURL string `json:"browser_download_url"`
+		} `json:"assets"`
+	}](ctx, d.client, d.repoPath+"/releases", nil, "page", "limit")
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]*Release, 0, len(rs))
+	for _, r := range rs {
+		release := &Release{
+			TagName:      r.TagName,
+			Target:       r.TargetCommitish,
+			Title:        r.Name,
+			Note:         r.Body,
+			IsDraft:      r.Draft,
+			IsPrerelease: r.Prerelease,
+			Publisher:    r.Author.user(),
+			Created:      r.CreatedAt,
+		}
+		for _, a := range r.Assets {
+			release.Assets = append(release.Assets, &ReleaseAsset{
+				Name:        a.Name,
+				Size:        a.Size,
+				DownloadURL: a.BrowserDownloadURL,
+			})
+		}
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
+
+func (d *giteaDownloader) OpenAsset(ctx context.Context, asset *ReleaseAsset) (io.ReadCloser, error) {
+	rc, err := d.client.open(ctx, asset.DownloadURL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open asset %q", asset.Name)
+	}
+	return rc, nil
+}
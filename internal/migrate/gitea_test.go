@@ -0,0 +1,55 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGiteaDownloader(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	alice := map[string]any{"login": "alice", "email": "alice@example.com"}
+	fixtures := map[string]any{
+		"/repos/gitea/tea/issues": []map[string]any{
+			{"number": 1, "title": "issue 1", "user": alice, "state": "open", "created_at": created, "updated_at": created},
+			{"number": 2, "title": "issue 2", "user": alice, "state": "open", "created_at": created, "updated_at": created},
+			{"number": 3, "title": "issue 3", "user": alice, "state": "open", "created_at": created, "updated_at": created},
+		},
+		"/repos/gitea/tea/pulls": []map[string]any{
+			{"number": 4, "title": "pull 4", "user": alice, "state": "closed", "merged": true, "merged_at": created, "merged_by": alice, "merge_commit_sha": "abc", "head": map[string]any{"ref": "feature", "sha": "def"}, "base": map[string]any{"ref": "main"}, "created_at": created, "updated_at": created},
+		},
+		"/repos/gitea/tea/issues/4/comments": []map[string]any{
+			{"user": alice, "body": "LGTM", "created_at": created, "updated_at": created},
+		},
+	}
+	srv := newFixtureServer(t, http.Header{"Authorization": {"token abc"}}, fixtures)
+
+	d := newGiteaDownloader(srv.URL, "gitea/tea", "abc")
+	ctx := context.Background()
+
+	issues, isEnd, err := d.GetIssues(ctx, 2, 2)
+	require.NoError(t, err)
+	assert.True(t, isEnd)
+	require.Len(t, issues, 1)
+	assert.Equal(t, int64(3), issues[0].Number)
+	assert.Equal(t, User{Name: "alice", Email: "alice@example.com"}, issues[0].Poster)
+
+	prs, _, err := d.GetPullRequests(ctx, 1, 2)
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.True(t, prs[0].HasMerged)
+	assert.Equal(t, User{Name: "alice", Email: "alice@example.com"}, prs[0].MergedBy)
+	assert.Equal(t, "feature", prs[0].HeadBranch)
+
+	comments, err := d.GetComments(ctx, &prs[0].Issue)
+	require.NoError(t, err)
+	assert.Equal(t, []*Comment{{Poster: User{Name: "alice", Email: "alice@example.com"}, Content: "LGTM", Created: created, Updated: created}}, comments)
+}
@@ -0,0 +1,291 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var _ Downloader = (*gitHubDownloader)(nil)
+
+// gitHubDownloader is a downloader for GitHub and GitHub Enterprise, see
+// https://docs.github.com/en/rest.
+type gitHubDownloader struct {
+	client *client
+	// repoPath is the API path prefix of the repository, e.g. "/repos/gogs/gogs".
+	repoPath string
+	// emails is the cache of public email addresses of users.
+	emails map[string]string
+}
+
+func newGitHubDownloader(baseURL, repoPath, token string) *gitHubDownloader {
+	header := http.Header{
+		"Accept": []string{"application/vnd.github+json"},
+	}
+	if token != "" {
+		header.Set("Authorization", "token "+token)
+	}
+	return &gitHubDownloader{
+		client:   newClient(baseURL, header),
+		repoPath: "/repos/" + repoPath,
+		emails:   make(map[string]string),
+	}
+}
+
+type gitHubUser struct {
+	Login string `json:"login"`
+}
+
+// user returns the user with the login, the public email address is looked up
+// when the user is first seen.
+func (d *gitHubDownloader) user(ctx context.Context, u gitHubUser) User {
+	if u.Login == "" {
+		return User{}
+	}
+
+	email, ok := d.emails[u.Login]
+	if !ok {
+		var profile struct {
+			Email string `json:"email"`
+		}
+		// The email address is only a nice-to-have for mapping users.
+		_ = d.client.getJSON(ctx, "/users/"+url.PathEscape(u.Login), nil, &profile)
+		email = profile.Email
+		d.emails[u.Login] = email
+	}
+	return User{
+		Name:  u.Login,
+		Email: email,
+	}
+}
+
+func (d *gitHubDownloader) GetLabels(ctx context.Context) ([]*Label, error) {
+	ls, err := getAll[struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}](ctx, d.client, d.repoPath+"/labels", nil, "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]*Label, 0, len(ls))
+	for _, l := range ls {
+		labels = append(labels, &Label{
+			Name:  l.Name,
+			Color: normalizeColor(l.Color),
+		})
+	}
+	return labels, nil
+}
+
+func (d *gitHubDownloader) GetMilestones(ctx context.Context) ([]*Milestone, error) {
+	ms, err := getAll[struct {
+		Title       string     `json:"title"`
+		Description string     `json:"description"`
+		State       string     `json:"state"`
+		DueOn       *time.Time `json:"due_on"`
+		ClosedAt    *time.Time `json:"closed_at"`
+	}](ctx, d.client, d.repoPath+"/milestones", url.Values{"state": {"all"}}, "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+
+	milestones := make([]*Milestone, 0, len(ms))
+	for _, m := range ms {
+		milestones = append(milestones, &Milestone{
+			Title:       m.Title,
+			Description: m.Description,
+			Deadline:    timeOf(m.DueOn),
+			IsClosed:    m.State == "closed",
+			Closed:      timeOf(m.ClosedAt),
+		})
+	}
+	return milestones, nil
+}
+
+type gitHubIssue struct {
+	Number int64      `json:"number"`
+	Title  string     `json:"title"`
+	Body   string     `json:"body"`
+	User   gitHubUser `json:"user"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Milestone *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+	State       string     `json:"state"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ClosedAt    *time.Time `json:"closed_at"`
+	PullRequest *struct{}  `json:"pull_request"`
+}
+
+func (d *gitHubDownloader) issue(ctx context.Context, i gitHubIssue, isPull bool) Issue {
+	issue := Issue{
+		Number:   i.Number,
+		IsPull:   isPull,
+		Title:    i.Title,
+		Content:  i.Body,
+		Poster:   d.user(ctx, i.User),
+		IsClosed: i.State == "closed",
+		Created:  i.CreatedAt,
+		Updated:  i.UpdatedAt,
+		Closed:   timeOf(i.ClosedAt),
+	}
+	for _, l := range i.Labels {
+		issue.Labels = append(issue.Labels, l.Name)
+	}
+	if i.Milestone != nil {
+		issue.Milestone = i.Milestone.Title
+	}
+	return issue
+}
+
+var issueListQuery = url.Values{
+	"state":     {"all"},
+	"sort":      {"created"},
+	"direction": {"asc"},
+}
+
+func (d *gitHubDownloader) GetIssues(ctx context.Context, page, perPage int) ([]*Issue, bool, error) {
+	is, isEnd, err := getPage[gitHubIssue](ctx, d.client, d.repoPath+"/issues", issueListQuery, "page", "per_page", page, perPage)
+	if err != nil {
+		return nil, false, err
+	}
+
+	issues := make([]*Issue, 0, len(is))
+	for _, i := range is {
+		// The API also returns pull requests as issues
+		if i.PullRequest != nil {
+			continue
+		}
+
+		issue := d.issue(ctx, i, false)
+		issues = append(issues, &issue)
+	}
+	return issues, isEnd, nil
+}
+
+func (d *gitHubDownloader) GetPullRequests(ctx context.Context, page, perPage int) ([]*PullRequest, bool, error) {
+	ps, isEnd, err := getPage[struct {
+		gitHubIssue
+		MergedAt       *time.Time `json:"merged_at"`
+		MergeCommitSHA string     `json:"merge_commit_sha"`
+		Head           struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	}](ctx, d.client, d.repoPath+"/pulls", issueListQuery, "page", "per_page", page, perPage)
+	if err != nil {
+		return nil, false, err
+	}
+
+	prs := make([]*PullRequest, 0, len(ps))
+	for _, p := range ps {
+		pr := &PullRequest{
+			Issue:      d.issue(ctx, p.gitHubIssue, true),
+			HeadBranch: p.Head.Ref,
+			HeadSHA:    p.Head.SHA,
+			BaseBranch: p.Base.Ref,
+		}
+		// The list API does not return the user who merged the pull request.
+		if p.MergedAt != nil {
+			pr.HasMerged = true
+			pr.Merged = *p.MergedAt
+			pr.MergedCommitID = p.MergeCommitSHA
+		}
+		prs = append(prs, pr)
+	}
+	return prs, isEnd, nil
+}
+
+func (d *gitHubDownloader) GetComments(ctx context.Context, issue *Issue) ([]*Comment, error) {
+	cs, err := getAll[struct {
+		User      gitHubUser `json:"user"`
+		Body      string     `json:"body"`
+		CreatedAt time.Time  `json:"created_at"`
+		UpdatedAt time.Time  `json:"updated_at"`
+	}](ctx, d.client, fmt.Sprintf("%s/issues/%d/comments", d.repoPath, issue.Number), nil, "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]*Comment, 0, len(cs))
+	for _, c := range cs {
+		comments = append(comments, &Comment{
+			Poster:  d.user(ctx, c.User),
+			Content: c.Body,
+			Created: c.CreatedAt,
+			Updated: c.UpdatedAt,
+		})
+	}
+	return comments, nil
+}
+
+func (d *gitHubDownloader) GetReleases(ctx context.Context) ([]*Release, error) {
+	rs, err := getAll[struct {
+		TagName         string     `json:"tag_name"`
+		TargetCommitish string     `json:"target_commitish"`
+		Name            string     `json:"name"`
+		Body            string     `json:"body"`
+		Draft           bool       `json:"draft"`
+		Prerelease      bool       `json:"prerelease"`
+		Author          gitHubUser `json:"author"`
+		CreatedAt       time.Time  `json:"created_at"`
+		Assets          []struct {
+			Name string `json:"name"`
+			Size int64  `json:"size"`
+			// The API URL works for both public and private repositories.
+			URL string `json:"url"`
+		} `json:"assets"`
+	}](ctx, d.client, d.repoPath+"/releases", nil, "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]*Release, 0, len(rs))
+	for _, r := range rs {
+		release := &Release{
+			TagName:      r.TagName,
+			Target:       r.TargetCommitish,
+			Title:        r.Name,
+			Note:         r.Body,
+			IsDraft:      r.Draft,
+			IsPrerelease: r.Prerelease,
+			Publisher:    d.user(ctx, r.Author),
+			Created:      r.CreatedAt,
+		}
+		for _, a := range r.Assets {
+			release.Assets = append(release.Assets, &ReleaseAsset{
+				Name:        a.Name,
+				Size:        a.Size,
+				DownloadURL: a.URL,
+			})
+		}
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
+
+func (d *gitHubDownloader) OpenAsset(ctx context.Context, asset *ReleaseAsset) (io.ReadCloser, error) {
+	rc, err := d.client.open(ctx, asset.DownloadURL, http.Header{
+		"Accept": []string{"application/octet-stream"},
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "open asset %q", asset.Name)
+	}
+	return rc, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubDownloader(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	fixtures := map[string]any{
+		"/repos/gogs/gogs/labels": []map[string]any{
+			{"name": "bug", "color": "EE0701"},
+		},
+		"/repos/gogs/gogs/milestones": []map[string]any{
+			{"title": "v1.0", "state": "closed", "due_on": created, "closed_at": created},
+		},
+		"/repos/gogs/gogs/issues": []map[string]any{
+			{"number": 1, "title": "issue 1", "user": map[string]any{"login": "alice"}, "labels": []map[string]any{{"name": "bug"}}, "milestone": map[string]any{"title": "v1.0"}, "state": "open", "created_at": created, "updated_at": created},
+			{"number": 2, "title": "pull 2", "user": map[string]any{"login": "bob"}, "state": "closed", "created_at": created, "updated_at": created, "pull_request": map[string]any{}},
+			{"number": 3, "title": "issue 3", "user": map[string]any{"login": "bob"}, "state": "closed", "created_at": created, "updated_at": created, "closed_at": created},
+		},
+		"/repos/gogs/gogs/pulls": []map[string]any{
+			{"number": 2, "title": "pull 2", "user": map[string]any{"login": "bob"}, "state": "closed", "created_at": created, "updated_at": created, "merged_at": created, "merge_commit_sha": "abc", "head": map[string]any{"ref": "feature", "sha": "def"}, "base": map[string]any{"ref": "main"}},
+		},
+		"/repos/gogs/gogs/issues/1/comments": []map[string]any{
+			{"user": map[string]any{"login": "bob"}, "body": "LGTM", "created_at": created, "updated_at": created},
+		},
+		"/users/alice": map[string]any{"email": "alice@example.com"},
+		"/users/bob":   map[string]any{"email": nil},
+	}
+	srv := newFixtureServer(t, http.Header{"Authorization": {"token abc"}}, fixtures)
+	fixtures["/repos/gogs/gogs/releases/assets/1"] = "binary"
+	fixtures["/repos/gogs/gogs/releases"] = []map[string]any{
+		{"tag_name": "v1.0", "target_commitish": "main", "name": "First", "body": "Note", "author": map[string]any{"login": "alice"}, "created_at": created, "assets": []map[string]any{{"name": "gogs.zip", "size": 6, "url": srv.URL + "/repos/gogs/gogs/releases/assets/1"}}},
+	}
+
+	d := newGitHubDownloader(srv.URL, "gogs/gogs", "abc")
+	ctx := context.Background()
+
+	labels, err := d.GetLabels(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*Label{{Name: "bug", Color: "#ee0701"}}, labels)
+
+	milestones, err := d.GetMilestones(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*Milestone{{Title: "v1.0", Deadline: created, IsClosed: true, Closed: created}}, milestones)
+
+	// Pull requests are skipped from issues, but still count for the page size
+	issues, isEnd, err := d.GetIssues(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.False(t, isEnd)
+	want := &Issue{
+		Number:    1,
+		Title:     "issue 1",
+		Poster:    User{Name: "alice", Email: "alice@example.com"},
+		Labels:    []string{"bug"},
+		Milestone: "v1.0",
+		Created:   created,
+		Updated:   created,
+	}
+	assert.Equal(t, []*Issue{want}, issues)
+
+	issues, isEnd, err = d.GetIssues(ctx, 2, 2)
+	require.NoError(t, err)
+	assert.True(t, isEnd)
+	require.Len(t, issues, 1)
+	assert.Equal(t, int64(3), issues[0].Number)
+	assert.True(t, issues[0].IsClosed)
+	assert.Equal(t, User{Name: "bob"}, issues[0].Poster)
+
+	prs, isEnd, err := d.GetPullRequests(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.True(t, isEnd)
+	require.Len(t, prs, 1)
+	assert.True(t, prs[0].IsPull)
+	assert.Equal(t, "feature", prs[0].HeadBranch)
+	assert.Equal(t, "def", prs[0].HeadSHA)
+	assert.Equal(t, "main", prs[0].BaseBranch)
+	assert.True(t, prs[0].HasMerged)
+	assert.Equal(t, "abc", prs[0].MergedCommitID)
+
+	comments, err := d.GetComments(ctx, want)
+	require.NoError(t, err)
+	assert.Equal(t, []*Comment{{Poster: User{Name: "bob"}, Content: "LGTM", Created: created, Updated: created}}, comments)
+
+	releases, err := d.GetReleases(ctx)
+	require.NoError(t, err)
+	require.Len(t, releases, 1)
+	assert.Equal(t, "v1.0", releases[0].TagName)
+	assert.Equal(t, User{Name: "alice", Email: "alice@example.com"}, releases[0].Publisher)
+	require.Len(t, releases[0].Assets, 1)
+
+	rc, err := d.OpenAsset(ctx, releases[0].Assets[0])
+	require.NoError(t, err)
+	defer func() { _ = rc.Close() }()
+	p, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, "binary", string(p))
+}
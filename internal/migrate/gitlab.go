@@ -0,0 +1,303 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var _ Downloader = (*gitLabDownloader)(nil)
+
+// gitLabDownloader is a downloader for GitLab, see
+// https://docs.gitlab.com/ee/api/rest/.
+type gitLabDownloader struct {
+	client *client
+	// projectPath is the API path prefix of the project, e.g.
+	// "/projects/gitlab-org%2Fgitlab".
+	projectPath string
+	// emails is the cache of public email addresses of users by their IDs.
+	emails map[int64]string
+}
+
+func newGitLabDownloader(baseURL, repoPath, token string) *gitLabDownloader {
+	header := make(http.Header)
+	if token != "" {
+		header.Set("PRIVATE-TOKEN", token)
+	}
+	return &gitLabDownloader{
+		client:      newClient(baseURL, header),
+		projectPath: "/projects/" + url.PathEscape(repoPath),
+		emails:      make(map[int64]string),
+	}
+}
+
+type gitLabUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// user returns the user with the username, the public email address is looked
+// up when the user is first seen.
+func (d *gitLabDownloader) user(ctx context.Context, u gitLabUser) User {
+	if u.Username == "" {
+		return User{}
+	}
+
+	email, ok := d.emails[u.ID]
+	if !ok {
+		var profile struct {
+			PublicEmail string `json:"public_email"`
+		}
+		// The email address is only a nice-to-have for mapping users.
+		_ = d.client.getJSON(ctx, fmt.Sprintf("/users/%d", u.ID), nil, &profile)
+		email = profile.PublicEmail
+		d.emails[u.ID] = email
+	}
+	return User{
+		Name:  u.Username,
+		Email: email,
+	}
+}
+
+func (d *gitLabDownloader) GetLabels(ctx context.Context) ([]*Label, error) {
+	ls, err := getAll[struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}](ctx, d.client, d.projectPath+"/labels", nil, "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]*Label, 0, len(ls))
+	for _, l := range ls {
+		labels = append(labels, &Label{
+			Name:  l.Name,
+			Color: normalizeColor(l.Color),
+		})
+	}
+	return labels, nil
+}
+
+func (d *gitLabDownloader) GetMilestones(ctx context.Context) ([]*Milestone, error) {
+	ms, err := getAll[struct {
+		Title       string    `json:"title"`
+		Description string    `json:"description"`
+		State       string    `json:"state"`
+		DueDate     string    `json:"due_date"`
+		UpdatedAt   time.Time `json:"updated_at"`
+	}](ctx, d.client, d.projectPath+"/milestones", nil, "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+
+	milestones := make([]*Milestone, 0, len(ms))
+	for _, m := range ms {
+		milestone := &Milestone{
+			Title:       m.Title,
+			Description: m.Description,
+			IsClosed:    m.State == "closed",
+		}
+		if m.DueDate != "" {
+			milestone.Deadline, _ = time.Parse("2006-01-02", m.DueDate)
+		}
+		// The API does not provide the time of closing.
+		if milestone.IsClosed {
+			milestone.Closed = m.UpdatedAt
+		}
+		milestones = append(milestones, milestone)
+	}
+	return milestones, nil
+}
+
+type gitLabIssue struct {
+	IID         int64      `json:"iid"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Author      gitLabUser `json:"author"`
+	Labels      []string   `json:"labels"`
+	Milestone   *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+	State     string     `json:"state"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+}
+
+func (d *gitLabDownloader) issue(ctx context.Context, i gitLabIssue, isPull bool) Issue {
+	issue := Issue{
+		Number:   i.IID,
+		IsPull:   isPull,
+		Title:    i.Title,
+		Content:  i.Description,
+		Poster:   d.user(ctx, i.Author),
+		Labels:   i.Labels,
+		IsClosed: i.State != "opened",
+		Created:  i.CreatedAt,
+		Updated:  i.UpdatedAt,
+		Closed:   timeOf(i.ClosedAt),
+	}
+	if i.Milestone != nil {
+		issue.Milestone = i.Milestone.Title
+	}
+	return issue
+}
+
+var gitLabListQuery = url.Values{
+	"state":    {"all"},
+	"order_by": {"created_at"},
+	"sort":     {"asc"},
+}
+
+func (d *gitLabDownloader) GetIssues(ctx context.Context, page, perPage int) ([]*Issue, bool, error) {
+	is, isEnd, err := getPage[gitLabIssue](ctx, d.client, d.projectPath+"/issues", gitLabListQuery, "page", "per_page", page, perPage)
+	if err != nil {
+		return nil, false, err
+	}
+
+	issues := make([]*Issue, 0, len(is))
+	for _, i := range is {
+		issue := d.issue(ctx, i, false)
+		issues = append(issues, &issue)
+	}
+	return issues, isEnd, nil
+}
+
+func (d *gitLabDownloader) GetPullRequests(ctx context.Context, page, perPage int) ([]*PullRequest, bool, error) {
+	ms, isEnd, err := getPage[struct {
+		gitLabIssue
+		SourceBranch    string     `json:"source_branch"`
+		TargetBranch    string     `json:"target_branch"`
+		SHA             string     `json:"sha"`
+		MergedAt        *time.Time `json:"merged_at"`
+		MergedBy        gitLabUser `json:"merged_by"`
+		MergeCommitSHA  string     `json:"merge_commit_sha"`
+		SquashCommitSHA string     `json:"squash_commit_sha"`
+	}](ctx, d.client, d.projectPath+"/merge_requests", gitLabListQuery, "page", "per_page", page, perPage)
+	if err != nil {
+		return nil, false, err
+	}
+
+	prs := make([]*PullRequest, 0, len(ms))
+	for _, m := range ms {
+		pr := &PullRequest{
+			Issue:      d.issue(ctx, m.gitLabIssue, true),
+			HeadBranch: m.SourceBranch,
+			HeadSHA:    m.SHA,
+			BaseBranch: m.TargetBranch,
+		}
+		if m.State == "merged" {
+			pr.HasMerged = true
+			pr.Merged = timeOf(m.MergedAt)
+			pr.MergedBy = d.user(ctx, m.MergedBy)
+			pr.MergedCommitID = m.MergeCommitSHA
+			if pr.MergedCommitID == "" {
+				pr.MergedCommitID = m.SquashCommitSHA
+			}
+			if pr.Closed.IsZero() {
+				pr.Closed = pr.Merged
+			}
+		}
+		prs = append(prs, pr)
+	}
+	return prs, isEnd, nil
+}
+
+func (d *gitLabDownloader) GetComments(ctx context.Context, issue *Issue) ([]*Comment, error) {
+	kind := "issues"
+	if issue.IsPull {
+		kind = "merge_requests"
+	}
+	ns, err := getAll[struct {
+		Author    gitLabUser `json:"author"`
+		Body      string     `json:"body"`
+		System    bool       `json:"system"`
+		CreatedAt time.Time  `json:"created_at"`
+		UpdatedAt time.Time  `json:"updated_at"`
+	}](ctx, d.client, fmt.Sprintf("%s/%s/%d/notes", d.projectPath, kind, issue.Number), url.Values{"order_by": {"created_at"}, "sort": {"asc"}}, "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]*Comment, 0, len(ns))
+	for _, n := range ns {
+		// System notes are events like label changes rather than comments.
+		if n.System {
+			continue
+		}
+
+		comments = append(comments, &Comment{
+			Poster:  d.user(ctx, n.Author),
+			Content: n.Body,
+			Created: n.CreatedAt,
+			Updated: n.UpdatedAt,
+		})
+	}
+	return comments, nil
+}
+
+func (d *gitLabDownloader) GetReleases(ctx context.Context) ([]*Release, error) {
+	rs, err := getAll[struct {
+		TagName     string     `json:"tag_name"`
+		Name        string     `json:"name"`
+		Description string     `json:"description"`
+		Author      gitLabUser `json:"author"`
+		CreatedAt   time.Time  `json:"created_at"`
+		Commit      struct {
+			ID string `json:"id"`
+		} `json:"commit"`
+		UpcomingRelease bool `json:"upcoming_release"`
+		Assets          struct {
+			Links []struct {
+				Name           string `json:"name"`
+				URL            string `json:"url"`
+				DirectAssetURL string `json:"direct_asset_url"`
+			} `json:"links"`
+		} `json:"assets"`
+	}](ctx, d.client, d.projectPath+"/releases", nil, "page", "per_page")
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]*Release, 0, len(rs))
+	for _, r := range rs {
+		release := &Release{
+			TagName:      r.TagName,
+			Target:       r.Commit.ID,
+			Title:        r.Name,
+			Note:         r.Description,
+			IsPrerelease: r.UpcomingRelease,
+			Publisher:    d.user(ctx, r.Author),
+			Created:      r.CreatedAt,
+		}
+		for _, l := range r.Assets.Links {
+			downloadURL := l.DirectAssetURL
+			if downloadURL == "" {
+				downloadURL = l.URL
+			}
+			release.Assets = append(release.Assets, &ReleaseAsset{
+				Name:        l.Name,
+				DownloadURL: downloadURL,
+			})
+		}
+		releases = append(releases, release)
+	}
+	return releases, nil
+}
+
+func (d *gitLabDownloader) OpenAsset(ctx context.Context, asset *ReleaseAsset) (io.ReadCloser, error) {
+	rc, err := d.client.open(ctx, asset.DownloadURL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "open asset %q", asset.Name)
+	}
+	return rc, nil
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabDownloader(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	alice := map[string]any{"id": 1, "username": "alice"}
+	fixtures := map[string]any{
+		"/projects/gitlab-org%2Fgitlab/labels": []map[string]any{
+			{"name": "bug", "color": "#FF0000"},
+		},
+		"/projects/gitlab-org%2Fgitlab/milestones": []map[string]any{
+			{"title": "v1.0", "state": "active", "due_date": "2024-02-01", "updated_at": created},
+		},
+		"/projects/gitlab-org%2Fgitlab/issues": []map[string]any{
+			{"iid": 1, "title": "issue 1", "description": "desc", "author": alice, "labels": []string{"bug"}, "state": "opened", "created_at": created, "updated_at": created},
+		},
+		"/projects/gitlab-org%2Fgitlab/merge_requests": []map[string]any{
+			{"iid": 1, "title": "mr 1", "author": alice, "state": "merged", "created_at": created, "updated_at": created, "merged_at": created, "merged_by": alice, "squash_commit_sha": "abc", "source_branch": "feature", "target_branch": "main", "sha": "def"},
+		},
+		"/projects/gitlab-org%2Fgitlab/merge_requests/1/notes": []map[string]any{
+			{"author": alice, "body": "added label", "system": true, "created_at": created, "updated_at": created},
+			{"author": alice, "body": "LGTM", "created_at": created, "updated_at": created},
+		},
+		"/users/1": map[string]any{"public_email": "alice@example.com"},
+	}
+	srv := newFixtureServer(t, http.Header{"Private-Token": {"abc"}}, fixtures)
+
+	d := newGitLabDownloader(srv.URL, "gitlab-org/gitlab", "abc")
+	ctx := context.Background()
+
+	labels, err := d.GetLabels(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*Label{{Name: "bug", Color: "#ff0000"}}, labels)
+
+	milestones, err := d.GetMilestones(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*Milestone{{Title: "v1.0", Deadline: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}}, milestones)
+
+	issues, isEnd, err := d.GetIssues(ctx, 1, 50)
+	require.NoError(t, err)
+	assert.True(t, isEnd)
+	assert.Equal(t,
+		[]*Issue{{
+			Number:  1,
+			Title:   "issue 1",
+			Content: "desc",
+			Poster:  User{Name: "alice", Email: "alice@example.com"},
+			Labels:  []string{"bug"},
+			Created: created,
+			Updated: created,
+		}},
+		issues,
+	)
+
+	prs, _, err := d.GetPullRequests(ctx, 1, 50)
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.True(t, prs[0].IsClosed)
+	assert.True(t, prs[0].HasMerged)
+	assert.Equal(t, "abc", prs[0].MergedCommitID)
+	assert.Equal(t, User{Name: "alice", Email: "alice@example.com"}, prs[0].MergedBy)
+	assert.Equal(t, created, prs[0].Closed)
+
+	// System notes are skipped
+	comments, err := d.GetComments(ctx, &prs[0].Issue)
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, "LGTM", comments[0].Content)
+}
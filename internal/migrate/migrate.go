@@ -0,0 +1,438 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package migrate implements migrations of repository data (labels,
+// milestones, issues, pull requests and releases) from other code hosting
+// services through their APIs.
+//
+// A migration is performed in stages, data of each stage is fetched page by
+// page by a Downloader and saved by an Uploader. Progress is reported as a
+// Checkpoint after every page and every issue or pull request, a migration can
+// be resumed from the last checkpoint after being interrupted.
+package migrate
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"gogs.io/gogs/internal/lazyregexp"
+)
+
+// Stage is a stage of a migration.
+type Stage string
+
+const (
+	StageLabels       Stage = "labels"
+	StageMilestones   Stage = "milestones"
+	StageIssues       Stage = "issues"
+	StagePullRequests Stage = "pull_requests"
+	StageReleases     Stage = "releases"
+	StageDone         Stage = "done"
+)
+
+// Stages is the list of all migration stages (except StageDone) in the order of
+// being performed.
+var Stages = []Stage{
+	StageLabels,
+	StageMilestones,
+	StageIssues,
+	StagePullRequests,
+	StageReleases,
+}
+
+// ParseStages returns stages with given names in the order of being performed.
+// Unknown names are ignored.
+func ParseStages(names []string) []Stage {
+	selected := make(map[Stage]bool, len(names))
+	for _, name := range names {
+		selected[Stage(name)] = true
+	}
+
+	stages := make([]Stage, 0, len(names))
+	for _, stage := range Stages {
+		if selected[stage] {
+			stages = append(stages, stage)
+		}
+	}
+	return stages
+}
+
+// Checkpoint is the progress of a migration.
+type Checkpoint struct {
+	// Stage is the current stage, it is empty if the migration has not yet
+	// started.
+	Stage Stage
+	// Page is the page to fetch in the current stage.
+	Page int
+	// Number is the number of the last migrated issue or pull request in the
+	// current stage. Items are resumed after it rather than by the page alone,
+	// because items move to previous pages when some are deleted on the code
+	// hosting service.
+	Number int64
+	// Index is the number of issues and pull requests have been enumerated, which
+	// is also the index of the last issue or pull request in the destination
+	// repository.
+	Index int64
+}
+
+// Downloader fetches repository data from a code hosting service.
+type Downloader interface {
+	GetLabels(ctx context.Context) ([]*Label, error)
+	GetMilestones(ctx context.Context) ([]*Milestone, error)
+	// GetIssues returns issues (excluding pull requests) on the given page in
+	// the ascending order of their numbers. It returns true if it is the last
+	// page.
+	GetIssues(ctx context.Context, page, perPage int) ([]*Issue, bool, error)
+	// GetPullRequests returns pull requests on the given page in the ascending
+	// order of their numbers. It returns true if it is the last page.
+	GetPullRequests(ctx context.Context, page, perPage int) ([]*PullRequest, bool, error)
+	// GetComments returns all comments of the issue or pull request in the
+	// ascending order of their creation time.
+	GetComments(ctx context.Context, issue *Issue) ([]*Comment, error)
+	GetReleases(ctx context.Context) ([]*Release, error)
+	// OpenAsset opens the content of the release asset for reading.
+	OpenAsset(ctx context.Context, asset *ReleaseAsset) (io.ReadCloser, error)
+}
+
+// Uploader saves repository data to the destination repository. All methods
+// must be idempotent, so that interrupted migrations can be resumed.
+type Uploader interface {
+	// Prepare is called once before the migration starts.
+	Prepare(ctx context.Context) error
+	// CreateLabels creates labels that do not exist yet.
+	CreateLabels(ctx context.Context, labels []*Label) error
+	// CreateMilestones creates milestones that do not exist yet.
+	CreateMilestones(ctx context.Context, milestones []*Milestone) error
+	// HasIssue returns true if the issue or pull request with given index exists.
+	HasIssue(ctx context.Context, index int64) (bool, error)
+	// CreateIssue creates the issue with its comments with given index.
+	CreateIssue(ctx context.Context, index int64, issue *Issue, comments []*Comment) error
+	// CreatePullRequest creates the pull request with its comments with given
+	// index.
+	CreatePullRequest(ctx context.Context, index int64, pr *PullRequest, comments []*Comment) error
+	// CreateRelease creates the release if it does not exist yet, assets are
+	// read via the open function.
+	CreateRelease(ctx context.Context, release *Release, open func(*ReleaseAsset) (io.ReadCloser, error)) error
+	// Finish is called after all stages are done, before the migration is
+	// marked as done.
+	Finish(ctx context.Context) error
+}
+
+// Options contains options for a migration.
+type Options struct {
+	// Stages is the list of stages to perform.
+	Stages []Stage
+	// Checkpoint is the progress to resume from.
+	Checkpoint Checkpoint
+	// PerPage is the number of items to fetch per page, default is 50.
+	PerPage int
+	// OnProgress is called with the latest checkpoint whenever progress has
+	// been made.
+	OnProgress func(Checkpoint) error
+}
+
+// Migrate migrates repository data from the downloader to the uploader.
+func Migrate(ctx context.Context, d Downloader, u Uploader, opts Options) error {
+	if opts.PerPage <= 0 {
+		opts.PerPage = 50
+	}
+	if opts.OnProgress == nil {
+		opts.OnProgress = func(Checkpoint) error { return nil }
+	}
+
+	m := &migration{
+		d:    d,
+		u:    u,
+		opts: opts,
+		cp:   opts.Checkpoint,
+	}
+	return m.run(ctx)
+}
+
+type migration struct {
+	d    Downloader
+	u    Uploader
+	opts Options
+	cp   Checkpoint
+}
+
+func (m *migration) save() error {
+	return errors.Wrap(m.opts.OnProgress(m.cp), "save progress")
+}
+
+// next moves the checkpoint to the stage after the current one. The uploader
+// is finished before moving to StageDone, so that it is finished again when
+// interrupted.
+func (m *migration) next(ctx context.Context) error {
+	next := StageDone
+	if m.cp.Stage == "" {
+		if len(m.opts.Stages) > 0 {
+			next = m.opts.Stages[0]
+		}
+	} else {
+		for i, stage := range m.opts.Stages {
+			if stage == m.cp.Stage && i+1 < len(m.opts.Stages) {
+				next = m.opts.Stages[i+1]
+				break
+			}
+		}
+	}
+
+	if next == StageDone {
+		err := m.u.Finish(ctx)
+		if err != nil {
+			return errors.Wrap(err, "finish")
+		}
+	}
+
+	m.cp.Stage = next
+	m.cp.Page = 1
+	m.cp.Number = 0
+	return m.save()
+}
+
+func (m *migration) run(ctx context.Context) error {
+	if m.cp.Stage == "" {
+		err := m.u.Prepare(ctx)
+		if err != nil {
+			return errors.Wrap(err, "prepare")
+		}
+		err = m.next(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	if m.cp.Page < 1 {
+		m.cp.Page = 1
+	}
+
+	for m.cp.Stage != StageDone {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var err error
+		switch m.cp.Stage {
+		case StageLabels:
+			err = m.migrateLabels(ctx)
+		case StageMilestones:
+			err = m.migrateMilestones(ctx)
+		case StageIssues:
+			err = m.migrateIssues(ctx)
+		case StagePullRequests:
+			err = m.migratePullRequests(ctx)
+		case StageReleases:
+			err = m.migrateReleases(ctx)
+		default:
+			return errors.Errorf("unknown stage %q", m.cp.Stage)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "migrate %s", m.cp.Stage)
+		}
+	}
+
+	return nil
+}
+
+func (m *migration) migrateLabels(ctx context.Context) error {
+	labels, err := m.d.GetLabels(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get labels")
+	}
+	err = m.u.CreateLabels(ctx, labels)
+	if err != nil {
+		return errors.Wrap(err, "create labels")
+	}
+	return m.next(ctx)
+}
+
+func (m *migration) migrateMilestones(ctx context.Context) error {
+	milestones, err := m.d.GetMilestones(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get milestones")
+	}
+	err = m.u.CreateMilestones(ctx, milestones)
+	if err != nil {
+		return errors.Wrap(err, "create milestones")
+	}
+	return m.next(ctx)
+}
+
+// rewind returns true if the previous page should be fetched when resuming a
+// stage from the page, because items after the last migrated one may have
+// moved to previous pages.
+func (m *migration) rewind(resuming bool, first int64, n int) bool {
+	if !resuming || m.cp.Page <= 1 || (n > 0 && first <= m.cp.Number) {
+		return false
+	}
+	m.cp.Page--
+	return true
+}
+
+// migrateItem creates the issue or pull request with given number by the
+// create function with the next index, unless it has already been created by
+// a previous run of the migration.
+func (m *migration) migrateItem(ctx context.Context, number int64, create func(index int64) error) error {
+	index := m.cp.Index + 1
+	exists, err := m.u.HasIssue(ctx, index)
+	if err != nil {
+		return errors.Wrapf(err, "check existence of #%d", index)
+	}
+	if !exists {
+		err = create(index)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.cp.Index = index
+	m.cp.Number = number
+	return m.save()
+}
+
+func (m *migration) migrateIssues(ctx context.Context) error {
+	resuming := m.cp.Number > 0
+	for {
+		issues, isEnd, err := m.d.GetIssues(ctx, m.cp.Page, m.opts.PerPage)
+		if err != nil {
+			return errors.Wrapf(err, "get issues on page %d", m.cp.Page)
+		}
+
+		var first int64
+		if len(issues) > 0 {
+			first = issues[0].Number
+		}
+		if m.rewind(resuming, first, len(issues)) {
+			continue
+		}
+		resuming = false
+
+		for _, issue := range issues {
+			if issue.Number <= m.cp.Number {
+				continue
+			}
+
+			err = m.migrateItem(ctx, issue.Number, func(index int64) error {
+				comments, err := m.d.GetComments(ctx, issue)
+				if err != nil {
+					return errors.Wrapf(err, "get comments of issue #%d", issue.Number)
+				}
+				return errors.Wrapf(m.u.CreateIssue(ctx, index, issue, comments), "create issue #%d", issue.Number)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if isEnd {
+			return m.next(ctx)
+		}
+		m.cp.Page++
+		if err = m.save(); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *migration) migratePullRequests(ctx context.Context) error {
+	resuming := m.cp.Number > 0
+	for {
+		prs, isEnd, err := m.d.GetPullRequests(ctx, m.cp.Page, m.opts.PerPage)
+		if err != nil {
+			return errors.Wrapf(err, "get pull requests on page %d", m.cp.Page)
+		}
+
+		var first int64
+		if len(prs) > 0 {
+			first = prs[0].Number
+		}
+		if m.rewind(resuming, first, len(prs)) {
+			continue
+		}
+		resuming = false
+
+		for _, pr := range prs {
+			if pr.Number <= m.cp.Number {
+				continue
+			}
+
+			err = m.migrateItem(ctx, pr.Number, func(index int64) error {
+				comments, err := m.d.GetComments(ctx, &pr.Issue)
+				if err != nil {
+					return errors.Wrapf(err, "get comments of pull request #%d", pr.Number)
+				}
+				return errors.Wrapf(m.u.CreatePullRequest(ctx, index, pr, comments), "create pull request #%d", pr.Number)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if isEnd {
+			return m.next(ctx)
+		}
+		m.cp.Page++
+		if err = m.save(); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *migration) migrateReleases(ctx context.Context) error {
+	releases, err := m.d.GetReleases(ctx)
+	if err != nil {
+		return errors.Wrap(err, "get releases")
+	}
+
+	open := func(asset *ReleaseAsset) (io.ReadCloser, error) {
+		return m.d.OpenAsset(ctx, asset)
+	}
+	for _, release := range releases {
+		err = m.u.CreateRelease(ctx, release, open)
+		if err != nil {
+			return errors.Wrapf(err, "create release %q", release.TagName)
+		}
+	}
+	return m.next(ctx)
+}
+
+// referencePattern matches references to issues and pull requests, e.g. "#1",
+// and merge requests of GitLab, e.g. "!1".
+var referencePattern = lazyregexp.New(`(\s|^|\(|\[)([#!])([0-9]+)\b`)
+
+// RewriteReferences rewrites references to issues and pull requests on the code
+// hosting service in the content to their indexes in the destination
+// repository. The index function returns the index of the issue or pull request
+// with the number, and false if it has not been migrated, whose references are
+// kept as is.
+func RewriteReferences(service Service, content string, index func(number int64, isPull bool) (int64, bool)) string {
+	return referencePattern.ReplaceAllStringFunc(content, func(ref string) string {
+		m := referencePattern.FindStringSubmatch(ref)
+		number, err := strconv.ParseInt(m[3], 10, 64)
+		if err != nil {
+			return ref
+		}
+
+		var idx int64
+		var ok bool
+		switch {
+		case service == ServiceGitLab:
+			// Issues and merge requests are numbered separately.
+			idx, ok = index(number, m[2] == "!")
+		case m[2] == "#":
+			// Issues and pull requests share the same numbers.
+			idx, ok = index(number, false)
+			if !ok {
+				idx, ok = index(number, true)
+			}
+		}
+		if !ok {
+			return ref
+		}
+		return m[1] + "#" + strconv.FormatInt(idx, 10)
+	})
+}
@@ -0,0 +1,437 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFixtureServer returns a local server that responds the fixture of the
+// request path in JSON. Fixtures of slices are paginated by either "per_page"
+// or "limit" query parameter. Requests must have the given header.
+func newFixtureServer(t *testing.T, header http.Header, fixtures map[string]any) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k := range header {
+			if r.Header.Get(k) != header.Get(k) {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		path := r.URL.EscapedPath()
+		fixture, ok := fixtures[path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		if s, ok := fixture.(string); ok {
+			_, _ = io.WriteString(w, s)
+			return
+		}
+
+		v := reflect.ValueOf(fixture)
+		if v.Kind() == reflect.Slice {
+			perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+			if perPage == 0 {
+				perPage, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if perPage > 0 && page > 0 {
+				start := (page - 1) * perPage
+				if start > v.Len() {
+					start = v.Len()
+				}
+				end := start + perPage
+				if end > v.Len() {
+					end = v.Len()
+				}
+				fixture = v.Slice(start, end).Interface()
+			}
+		}
+		_ = json.NewEncoder(w).Encode(fixture)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewDownloader(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     DownloaderOptions
+		wantType string
+		wantBase string
+		wantPath string
+		wantErr  string
+	}{
+		{
+			name:     "github.com",
+			opts:     DownloaderOptions{Service: ServiceGitHub, CloneAddr: "https://github.com/gogs/gogs.git"},
+			wantType: "*migrate.gitHubDownloader",
+			wantBase: "https://api.github.com",
+			wantPath: "/repos/gogs/gogs",
+		},
+		{
+			name:     "GitHub Enterprise",
+			opts:     DownloaderOptions{Service: ServiceGitHub, CloneAddr: "https://github.example.com/gogs/gogs"},
+			wantType: "*migrate.gitHubDownloader",
+			wantBase: "https://github.example.com/api/v3",
+			wantPath: "/repos/gogs/gogs",
+		},
+		{
+			name:     "GitLab subgroup",
+			opts:     DownloaderOptions{Service: ServiceGitLab, CloneAddr: "https://gitlab.com/gitlab-org/sub/gitlab.git"},
+			wantType: "*migrate.gitLabDownloader",
+			wantBase: "https://gitlab.com/api/v4",
+			wantPath: "/projects/gitlab-org%2Fsub%2Fgitlab",
+		},
+		{
+			name:     "Gitea",
+			opts:     DownloaderOptions{Service: ServiceGitea, CloneAddr: "http://gitea.example.com/gitea/tea.git/"},
+			wantType: "*migrate.giteaDownloader",
+			wantBase: "http://gitea.example.com/api/v1",
+			wantPath: "/repos/gitea/tea",
+		},
+		{
+			name:    "unsupported scheme",
+			opts:    DownloaderOptions{Service: ServiceGitHub, CloneAddr: "git://github.com/gogs/gogs.git"},
+			wantErr: `unsupported scheme "git" of clone address`,
+		},
+		{
+			name:    "invalid path",
+			opts:    DownloaderOptions{Service: ServiceGitHub, CloneAddr: "https://github.com/gogs"},
+			wantErr: `invalid repository path "gogs" of clone address`,
+		},
+		{
+			name:    "unsupported service",
+			opts:    DownloaderOptions{Service: "bitbucket", CloneAddr: "https://bitbucket.org/gogs/gogs.git"},
+			wantErr: `unsupported service "bitbucket"`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := NewDownloader(test.opts)
+			if test.wantErr != "" {
+				assert.EqualError(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantType, fmt.Sprintf("%T", d))
+
+			var base, path string
+			switch d := d.(type) {
+			case *gitHubDownloader:
+				base, path = d.client.baseURL, d.repoPath
+			case *gitLabDownloader:
+				base, path = d.client.baseURL, d.projectPath
+			case *giteaDownloader:
+				base, path = d.client.baseURL, d.repoPath
+			}
+			assert.Equal(t, test.wantBase, base)
+			assert.Equal(t, test.wantPath, path)
+		})
+	}
+}
+
+func TestParseStages(t *testing.T) {
+	got := ParseStages([]string{"releases", "unknown", "issues", "labels"})
+	assert.Equal(t, []Stage{StageLabels, StageIssues, StageReleases}, got)
+}
+
+// memoryDownloader is a downloader of in-memory data.
+type memoryDownloader struct {
+	labels   []*Label
+	issues   []*Issue
+	prs      []*PullRequest
+	releases []*Release
+	comments map[int64][]*Comment
+	assets   map[string]string
+
+	// failIssuesPage is the page of issues to fail once.
+	failIssuesPage int
+}
+
+func (d *memoryDownloader) GetLabels(context.Context) ([]*Label, error) {
+	return d.labels, nil
+}
+
+func (*memoryDownloader) GetMilestones(context.Context) ([]*Milestone, error) {
+	return nil, nil
+}
+
+func paginate[T any](items []T, page, perPage int) ([]T, bool) {
+	start := (page - 1) * perPage
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + perPage
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], end-start < perPage
+}
+
+func (d *memoryDownloader) GetIssues(_ context.Context, page, perPage int) ([]*Issue, bool, error) {
+	if page == d.failIssuesPage {
+		d.failIssuesPage = 0
+		return nil, false, errors.New("connection reset by peer")
+	}
+	issues, isEnd := paginate(d.issues, page, perPage)
+	return issues, isEnd, nil
+}
+
+func (d *memoryDownloader) GetPullRequests(_ context.Context, page, perPage int) ([]*PullRequest, bool, error) {
+	prs, isEnd := paginate(d.prs, page, perPage)
+	return prs, isEnd, nil
+}
+
+func (d *memoryDownloader) GetComments(_ context.Context, issue *Issue) ([]*Comment, error) {
+	return d.comments[issue.Number], nil
+}
+
+func (d *memoryDownloader) GetReleases(context.Context) ([]*Release, error) {
+	return d.releases, nil
+}
+
+func (d *memoryDownloader) OpenAsset(_ context.Context, asset *ReleaseAsset) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(d.assets[asset.DownloadURL])), nil
+}
+
+// memoryUploader is an uploader that records uploaded data in memory.
+type memoryUploader struct {
+	prepared int
+	finished int
+	labels   []string
+	// issues is the titles of issues and pull requests by their indexes.
+	issues   map[int64]string
+	comments map[int64]int
+	assets   map[string]string
+}
+
+func newMemoryUploader() *memoryUploader {
+	return &memoryUploader{
+		issues:   make(map[int64]string),
+		comments: make(map[int64]int),
+		assets:   make(map[string]string),
+	}
+}
+
+func (u *memoryUploader) Prepare(context.Context) error {
+	u.prepared++
+	return nil
+}
+
+func (u *memoryUploader) CreateLabels(_ context.Context, labels []*Label) error {
+	for _, l := range labels {
+		u.labels = append(u.labels, l.Name)
+	}
+	return nil
+}
+
+func (*memoryUploader) CreateMilestones(context.Context, []*Milestone) error {
+	return nil
+}
+
+func (u *memoryUploader) HasIssue(_ context.Context, index int64) (bool, error) {
+	_, ok := u.issues[index]
+	return ok, nil
+}
+
+func (u *memoryUploader) CreateIssue(_ context.Context, index int64, issue *Issue, comments []*Comment) error {
+	u.issues[index] = issue.Title
+	u.comments[index] = len(comments)
+	return nil
+}
+
+func (u *memoryUploader) CreatePullRequest(_ context.Context, index int64, pr *PullRequest, comments []*Comment) error {
+	u.issues[index] = pr.Title
+	u.comments[index] = len(comments)
+	return nil
+}
+
+func (u *memoryUploader) CreateRelease(_ context.Context, release *Release, open func(*ReleaseAsset) (io.ReadCloser, error)) error {
+	for _, asset := range release.Assets {
+		rc, err := open(asset)
+		if err != nil {
+			return err
+		}
+		p, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+		u.assets[release.TagName+"/"+asset.Name] = string(p)
+	}
+	return nil
+}
+
+func (u *memoryUploader) Finish(context.Context) error {
+	u.finished++
+	return nil
+}
+
+func TestMigrate(t *testing.T) {
+	d := &memoryDownloader{
+		labels: []*Label{{Name: "bug"}},
+		issues: []*Issue{
+			{Number: 1, Title: "issue 1"},
+			{Number: 3, Title: "issue 3"},
+			{Number: 4, Title: "issue 4"},
+		},
+		prs: []*PullRequest{
+			{Issue: Issue{Number: 2, IsPull: true, Title: "pull 2"}},
+			{Issue: Issue{Number: 5, IsPull: true, Title: "pull 5"}},
+		},
+		releases: []*Release{
+			{TagName: "v1.0", Assets: []*ReleaseAsset{{Name: "a.txt", DownloadURL: "a"}}},
+		},
+		comments: map[int64][]*Comment{
+			3: {{Content: "1"}, {Content: "2"}},
+			5: {{Content: "1"}},
+		},
+		assets:         map[string]string{"a": "content"},
+		failIssuesPage: 2,
+	}
+	u := newMemoryUploader()
+
+	var checkpoints []Checkpoint
+	opts := Options{
+		Stages:  []Stage{StageLabels, StageIssues, StagePullRequests, StageReleases},
+		PerPage: 2,
+		OnProgress: func(cp Checkpoint) error {
+			checkpoints = append(checkpoints, cp)
+			return nil
+		},
+	}
+
+	// The first run is interrupted on the second page of issues
+	err := Migrate(context.Background(), d, u, opts)
+	assert.EqualError(t, err, "migrate issues: get issues on page 2: connection reset by peer")
+	assert.Equal(t,
+		[]Checkpoint{
+			{Stage: StageLabels, Page: 1},
+			{Stage: StageIssues, Page: 1},
+			{Stage: StageIssues, Page: 1, Number: 1, Index: 1},
+			{Stage: StageIssues, Page: 1, Number: 3, Index: 2},
+			{Stage: StageIssues, Page: 2, Number: 3, Index: 2},
+		},
+		checkpoints,
+	)
+
+	// Pretend the next issue was created but the progress was not saved
+	u.issues[3] = "issue 4"
+
+	opts.Checkpoint = checkpoints[len(checkpoints)-1]
+	err = Migrate(context.Background(), d, u, opts)
+	require.NoError(t, err)
+	assert.Equal(t, Checkpoint{Stage: StageDone, Page: 1, Index: 5}, checkpoints[len(checkpoints)-1])
+
+	assert.Equal(t, 1, u.prepared)
+	assert.Equal(t, 1, u.finished)
+	assert.Equal(t, []string{"bug"}, u.labels)
+	assert.Equal(t,
+		map[int64]string{
+			1: "issue 1",
+			2: "issue 3",
+			3: "issue 4",
+			4: "pull 2",
+			5: "pull 5",
+		},
+		u.issues,
+	)
+	assert.Equal(t, 2, u.comments[2])
+	assert.Equal(t, 1, u.comments[5])
+	assert.Equal(t, map[string]string{"v1.0/a.txt": "content"}, u.assets)
+}
+
+func TestMigrate_ResumeAfterDeletion(t *testing.T) {
+	// Issue 1 was deleted after the first page had been migrated, which moves
+	// issue 3 to the first page.
+	d := &memoryDownloader{
+		issues: []*Issue{
+			{Number: 2, Title: "issue 2"},
+			{Number: 3, Title: "issue 3"},
+			{Number: 4, Title: "issue 4"},
+		},
+	}
+	u := newMemoryUploader()
+	u.issues[1] = "issue 1"
+	u.issues[2] = "issue 2"
+
+	var last Checkpoint
+	err := Migrate(context.Background(), d, u,
+		Options{
+			Stages:     []Stage{StageIssues},
+			Checkpoint: Checkpoint{Stage: StageIssues, Page: 2, Number: 2, Index: 2},
+			PerPage:    2,
+			OnProgress: func(cp Checkpoint) error {
+				last = cp
+				return nil
+			},
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, Checkpoint{Stage: StageDone, Page: 1, Index: 4}, last)
+	assert.Equal(t,
+		map[int64]string{
+			1: "issue 1",
+			2: "issue 2",
+			3: "issue 3",
+			4: "issue 4",
+		},
+		u.issues,
+	)
+}
+
+func TestRewriteReferences(t *testing.T) {
+	issues := map[int64]int64{1: 1, 3: 2}
+	pulls := map[int64]int64{2: 3, 4: 4}
+	index := func(number int64, isPull bool) (int64, bool) {
+		if isPull {
+			idx, ok := pulls[number]
+			return idx, ok
+		}
+		idx, ok := issues[number]
+		return idx, ok
+	}
+
+	tests := []struct {
+		name    string
+		service Service
+		content string
+		want    string
+	}{
+		{
+			name:    "shared numbers",
+			service: ServiceGitHub,
+			content: "#3 is fixed by #2 (see #1), not [#9] or a#1 or !2.\n#4",
+			want:    "#2 is fixed by #3 (see #1), not [#9] or a#1 or !2.\n#4",
+		},
+		{
+			name:    "separate numbers",
+			service: ServiceGitLab,
+			content: "#3 is fixed by !2, not #2 or !3",
+			want:    "#2 is fixed by #3, not #2 or !3",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, RewriteReferences(test.service, test.content, index))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"time"
+)
+
+// User is a user on the code hosting service.
+type User struct {
+	Name string
+	// Email is the public email address of the user, it may be empty.
+	Email string
+}
+
+// Label is a label of issues and pull requests.
+type Label struct {
+	Name string
+	// Color is in the form of "#rrggbb".
+	Color string
+}
+
+// Milestone is a milestone of issues and pull requests.
+type Milestone struct {
+	Title       string
+	Description string
+	// Deadline is zero if the milestone does not have one.
+	Deadline time.Time
+	IsClosed bool
+	Closed   time.Time
+}
+
+// Issue is an issue or the issue part of a pull request.
+type Issue struct {
+	// Number is the number of the issue on the code hosting service.
+	Number  int64
+	IsPull  bool
+	Title   string
+	Content string
+	Poster  User
+	// Labels is the list of label names.
+	Labels []string
+	// Milestone is the title of the milestone, it may be empty.
+	Milestone string
+	IsClosed  bool
+	Created   time.Time
+	Updated   time.Time
+	Closed    time.Time
+}
+
+// PullRequest is a pull request.
+type PullRequest struct {
+	Issue
+	HeadBranch string
+	// HeadSHA is the latest commit of the head branch.
+	HeadSHA    string
+	BaseBranch string
+	HasMerged  bool
+	Merged     time.Time
+	// MergedBy is the user who merged the pull request, it is empty when
+	// unknown.
+	MergedBy User
+	// MergedCommitID is the commit that the pull request was merged into the
+	// base branch.
+	MergedCommitID string
+}
+
+// Comment is a comment of an issue or a pull request.
+type Comment struct {
+	Poster  User
+	Content string
+	Created time.Time
+	Updated time.Time
+}
+
+// Release is a release of the repository.
+type Release struct {
+	TagName string
+	// Target is the branch or commit that the tag was created from.
+	Target       string
+	Title        string
+	Note         string
+	IsDraft      bool
+	IsPrerelease bool
+	Publisher    User
+	Created      time.Time
+	Assets       []*ReleaseAsset
+}
+
+// ReleaseAsset is a file attached to a release.
+type ReleaseAsset struct {
+	Name string
+	// Size is the size of the asset in bytes, it is 0 when unknown.
+	Size        int64
+	DownloadURL string
+}
@@ -5,8 +5,10 @@
 package osutil
 
 import (
+	"io/fs"
 	"os"
 	"os/user"
+	"path/filepath"
 )
 
 // IsFile returns true if given path exists as a file (i.e. not a directory).
@@ -34,6 +36,27 @@ func IsExist(path string) bool {
 	return err == nil || os.IsExist(err)
 }
 
+// DirSize returns the total size (in bytes) of all regular files in the given
+// directory and its subdirectories.
+func DirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		} else if !d.Type().IsRegular() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += fi.Size()
+		return nil
+	})
+	return size, err
+}
+
 // CurrentUsername returns the username of the current user.
 func CurrentUsername() string {
 	username := os.Getenv("USER")
@@ -6,9 +6,11 @@ package osutil
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsFile(t *testing.T) {
@@ -80,6 +82,23 @@ func TestIsExist(t *testing.T) {
 	}
 }
 
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("Hello"), 0600)
+	require.NoError(t, err)
+	err = os.MkdirAll(filepath.Join(dir, "sub"), 0700)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("world!"), 0600)
+	require.NoError(t, err)
+
+	size, err := DirSize(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), size)
+
+	_, err = DirSize(filepath.Join(dir, "not_found"))
+	assert.True(t, os.IsNotExist(err))
+}
+
 func TestCurrentUsername(t *testing.T) {
 	if oldUser, ok := os.LookupEnv("USER"); ok {
 		defer func() { _ = os.Setenv("USER", oldUser) }()
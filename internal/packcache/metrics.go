@@ -0,0 +1,69 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packcache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	log "unknwon.dev/clog/v2"
+)
+
+// collector is a Prometheus collector that reads statistics of a pack-objects
+// cache when being scraped. Statistics are read from the disk because the
+// cache is used by "git upload-pack" processes rather than the web server.
+type collector struct {
+	cache *Cache
+
+	hits    *prometheus.Desc
+	misses  *prometheus.Desc
+	entries *prometheus.Desc
+	size    *prometheus.Desc
+}
+
+// NewCollector returns a new Prometheus collector for the given cache.
+func NewCollector(cache *Cache) prometheus.Collector {
+	return &collector{
+		cache: cache,
+		hits: prometheus.NewDesc(
+			"gogs_pack_objects_cache_hits_total",
+			"Total number of fetch requests served from the pack-objects cache.",
+			nil, nil,
+		),
+		misses: prometheus.NewDesc(
+			"gogs_pack_objects_cache_misses_total",
+			"Total number of fetch requests not found in the pack-objects cache.",
+			nil, nil,
+		),
+		entries: prometheus.NewDesc(
+			"gogs_pack_objects_cache_entries",
+			"Number of packs in the pack-objects cache.",
+			nil, nil,
+		),
+		size: prometheus.NewDesc(
+			"gogs_pack_objects_cache_size_bytes",
+			"Total size of packs in the pack-objects cache.",
+			nil, nil,
+		),
+	}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.entries
+	ch <- c.size
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.cache.Stats()
+	if err != nil {
+		log.Error("Failed to get pack-objects cache stats: %v", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries))
+	ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(stats.Size))
+}
@@ -0,0 +1,415 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+// Package packcache implements a cache of packs generated by "git pack-objects"
+// on behalf of "git upload-pack", so that identical fetch requests (e.g. many
+// CI jobs cloning the same commit at once) are served from disk instead of
+// recomputing the same pack again and again.
+//
+// The cache is used through the "uploadpack.packObjectsHook" Git config, see
+// https://git-scm.com/docs/git-config#Documentation/git-config.txt-uploadpackpackObjectsHook.
+package packcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	packExt = ".pack"
+	tempExt = ".tmp"
+	lockExt = ".lock"
+
+	// statsFile is the name of the file that stores counters of statistics.
+	statsFile = "stats"
+
+	// staleTempAge is the age after which a temporary or lock file is considered
+	// to be left behind.
+	staleTempAge = time.Hour
+)
+
+// Indexes of counters in the stats file.
+const (
+	statHits = iota
+	statMisses
+	numStats
+)
+
+// Cache is a pack-objects cache stored on the local file system.
+type Cache struct {
+	root    string
+	maxSize int64
+	maxAge  time.Duration
+}
+
+// New returns a new pack-objects cache stored in the given root directory.
+// Packs are evicted when they are older than the maxAge, or oldest ones first
+// when the total size (in bytes) of cached packs exceeds the maxSize.
+func New(root string, maxSize int64, maxAge time.Duration) *Cache {
+	return &Cache{
+		root:    root,
+		maxSize: maxSize,
+		maxAge:  maxAge,
+	}
+}
+
+// key returns the cache key of a request, which is identified by the
+// repository path, the arguments to "git pack-objects", its input and the state
+// of refs that the pack depends on.
+func key(repoPath string, args []string, input, refs []byte) string {
+	h := sha256.New()
+	_, _ = io.WriteString(h, repoPath)
+	_, _ = h.Write([]byte{0})
+	_, _ = io.WriteString(h, strings.Join(args, "\x00"))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(input)
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(refs)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// refsState returns the state of refs in the repository that the pack of the
+// "git pack-objects" command specified by args depends on besides its input.
+// Packs with "--include-tag" contain tags pointing to packed objects, which may
+// be created or deleted without changing the input.
+func refsState(repoPath string, args []string) ([]byte, error) {
+	includeTag := false
+	for _, arg := range args {
+		if arg == "--include-tag" {
+			includeTag = true
+			break
+		}
+	}
+	if !includeTag {
+		return nil, nil
+	}
+
+	cmd := exec.Command("git", "for-each-ref", "--format=%(objectname) %(refname)", "refs/tags/")
+	cmd.Dir = repoPath
+	return cmd.Output()
+}
+
+// path returns the path of the cached pack with given key.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.root, key[:2], key+packExt)
+}
+
+// Run serves the request of the "git pack-objects" command specified by args
+// (including the leading "git pack-objects") in the given repository. The pack
+// is read from the cache when an identical request has been served before and
+// not yet evicted, otherwise the command is executed and its output is saved to
+// the cache for subsequent requests. The stdin is the input of the command and
+// the pack is written to the stdout.
+func (c *Cache) Run(repoPath string, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("no command to run")
+	}
+
+	input, err := io.ReadAll(stdin)
+	if err != nil {
+		return errors.Wrap(err, "read input")
+	}
+
+	refs, err := refsState(repoPath, args)
+	if err != nil {
+		return errors.Wrap(err, "get state of refs")
+	}
+
+	path := c.path(key(repoPath, args, input, refs))
+	hit, err := c.serve(path, stdout)
+	if err != nil {
+		return errors.Wrap(err, "serve from cache")
+	} else if hit {
+		c.record(statHits)
+		return nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return errors.Wrap(err, "create directory")
+	}
+
+	// Only one of identical requests computes the pack at a time, the others
+	// wait and then serve the pack from the cache.
+	lock, err := os.OpenFile(strings.TrimSuffix(path, packExt)+lockExt, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "open lock file")
+	}
+	defer func() { _ = lock.Close() }()
+	if err = lockFile(lock); err != nil {
+		return errors.Wrap(err, "lock")
+	}
+	defer func() { _ = unlockFile(lock) }()
+
+	hit, err = c.serve(path, stdout)
+	if err != nil {
+		return errors.Wrap(err, "serve from cache")
+	} else if hit {
+		c.record(statHits)
+		return nil
+	}
+	c.record(statMisses)
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*"+tempExt)
+	if err != nil {
+		return errors.Wrap(err, "create temporary file")
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	w := &cacheWriter{
+		file:  tmp,
+		limit: c.maxSize,
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = repoPath
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = io.MultiWriter(stdout, w)
+	cmd.Stderr = stderr
+	err = cmd.Run()
+	if err != nil {
+		return errors.Wrap(err, "run command")
+	}
+
+	// The pack has been served successfully, failing to save it only means a
+	// cache miss for the next time.
+	if w.err != nil {
+		return nil
+	}
+	if tmp.Close() == nil && os.Rename(tmp.Name(), path) == nil {
+		_ = c.Evict()
+	}
+	return nil
+}
+
+// serve writes the cached pack to the w if it exists and not yet expired. It
+// returns true if the pack is served.
+func (c *Cache) serve(path string, w io.Writer) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false, err
+	} else if time.Since(fi.ModTime()) > c.maxAge {
+		return false, nil
+	}
+
+	_, err = io.Copy(w, f)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// cacheWriter writes to the underlying file until an error occurred or the
+// limit is reached, but always reports a successful write to not interrupt the
+// other writers of an io.MultiWriter.
+type cacheWriter struct {
+	file    *os.File
+	limit   int64
+	written int64
+	err     error
+}
+
+var errPackTooLarge = errors.New("pack is too large")
+
+func (w *cacheWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return len(p), nil
+	}
+
+	if w.written+int64(len(p)) > w.limit {
+		w.err = errPackTooLarge
+		return len(p), nil
+	}
+
+	n, err := w.file.Write(p)
+	w.written += int64(n)
+	w.err = err
+	return len(p), nil
+}
+
+// readCounters reads counters from the stats file, a new or truncated file has
+// zero counters.
+func readCounters(f *os.File) ([numStats]int64, error) {
+	var counters [numStats]int64
+	err := binary.Read(io.NewSectionReader(f, 0, numStats*8), binary.LittleEndian, &counters)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return [numStats]int64{}, nil
+	}
+	return counters, err
+}
+
+// record increments the counter of the given stat. Counters are shared by
+// multiple processes, they are stored in the fixed-size stats file and updated
+// while holding the lock of the file.
+func (c *Cache) record(stat int) {
+	err := os.MkdirAll(c.root, os.ModePerm)
+	if err != nil {
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(c.root, statsFile), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	if lockFile(f) != nil {
+		return
+	}
+	defer func() { _ = unlockFile(f) }()
+
+	counters, err := readCounters(f)
+	if err != nil {
+		return
+	}
+	counters[stat]++
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, counters)
+	_, _ = f.WriteAt(buf.Bytes(), 0)
+}
+
+// Stats contains statistics of a pack-objects cache.
+type Stats struct {
+	// Hits is the number of requests served from the cache.
+	Hits int64
+	// Misses is the number of requests not found in the cache.
+	Misses int64
+	// Entries is the number of cached packs.
+	Entries int64
+	// Size is the total size (in bytes) of cached packs.
+	Size int64
+}
+
+// Stats returns the statistics of the cache.
+func (c *Cache) Stats() (*Stats, error) {
+	stats := new(Stats)
+	f, err := os.Open(filepath.Join(c.root, statsFile))
+	if err == nil {
+		defer func() { _ = f.Close() }()
+
+		// Read counters from a consistent snapshot
+		if err = lockFile(f); err != nil {
+			return nil, err
+		}
+		counters, err := readCounters(f)
+		_ = unlockFile(f)
+		if err != nil {
+			return nil, err
+		}
+		stats.Hits = counters[statHits]
+		stats.Misses = counters[statMisses]
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	packs, err := c.packs()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range packs {
+		stats.Entries++
+		stats.Size += p.size
+	}
+	return stats, nil
+}
+
+type pack struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// packs returns the list of cached packs, it also removes stale temporary and
+// lock files along the way.
+func (c *Cache) packs() ([]pack, error) {
+	var packs []pack
+	err := filepath.WalkDir(c.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		switch filepath.Ext(path) {
+		case packExt:
+			packs = append(packs, pack{
+				path:    path,
+				size:    fi.Size(),
+				modTime: fi.ModTime(),
+			})
+		case tempExt, lockExt:
+			if time.Since(fi.ModTime()) > staleTempAge {
+				_ = os.Remove(path)
+			}
+		}
+		return nil
+	})
+	return packs, err
+}
+
+// Evict removes expired packs, and then oldest packs until the total size of
+// cached packs is within the limit.
+func (c *Cache) Evict() error {
+	packs, err := c.packs()
+	if err != nil {
+		return errors.Wrap(err, "list packs")
+	}
+
+	sort.Slice(packs, func(i, j int) bool {
+		return packs[i].modTime.Before(packs[j].modTime)
+	})
+
+	var total int64
+	for _, p := range packs {
+		total += p.size
+	}
+
+	for _, p := range packs {
+		if total <= c.maxSize && time.Since(p.modTime) <= c.maxAge {
+			break
+		}
+
+		err = os.Remove(p.path)
+		if err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "remove %q", p.path)
+		}
+		total -= p.size
+	}
+	return nil
+}
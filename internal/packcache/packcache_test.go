@@ -0,0 +1,177 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package packcache
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRepo(t *testing.T) string {
+	repoPath := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"-c", "user.name=gogs", "-c", "user.email=gogs@example.com", "commit", "--quiet", "--allow-empty", "-m", "Initial commit"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	return repoPath
+}
+
+func TestCache_Run(t *testing.T) {
+	repoPath := newTestRepo(t)
+	cache := New(t.TempDir(), 1024*1024, time.Minute)
+
+	args := []string{"git", "pack-objects", "--revs", "--stdout"}
+	run := func(input string) []byte {
+		var stdout bytes.Buffer
+		err := cache.Run(repoPath, args, strings.NewReader(input), &stdout, os.Stderr)
+		require.NoError(t, err)
+		return stdout.Bytes()
+	}
+
+	want := run("HEAD\n")
+	require.True(t, bytes.HasPrefix(want, []byte("PACK")))
+
+	stats, err := cache.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{Misses: 1, Entries: 1, Size: int64(len(want))}, stats)
+
+	// An identical request should be served from the cache
+	got := run("HEAD\n")
+	assert.Equal(t, want, got)
+
+	stats, err = cache.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{Hits: 1, Misses: 1, Entries: 1, Size: int64(len(want))}, stats)
+
+	// A different request should not be served from the cache
+	_ = run("HEAD\n^HEAD\n")
+
+	stats, err = cache.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Equal(t, int64(2), stats.Entries)
+
+	// Counters do not grow with requests
+	fi, err := os.Stat(filepath.Join(cache.root, statsFile))
+	require.NoError(t, err)
+	assert.Equal(t, int64(numStats*8), fi.Size())
+}
+
+func TestCache_Run_IncludeTag(t *testing.T) {
+	repoPath := newTestRepo(t)
+	cache := New(t.TempDir(), 1024*1024, time.Minute)
+
+	args := []string{"git", "pack-objects", "--revs", "--stdout", "--include-tag"}
+	run := func() []byte {
+		var stdout bytes.Buffer
+		err := cache.Run(repoPath, args, strings.NewReader("HEAD\n"), &stdout, os.Stderr)
+		require.NoError(t, err)
+		return stdout.Bytes()
+	}
+
+	before := run()
+
+	// Creating a tag changes the pack without changing the input
+	cmd := exec.Command("git", "-c", "user.name=gogs", "-c", "user.email=gogs@example.com", "tag", "--annotate", "--message", "v1.0", "v1.0")
+	cmd.Dir = repoPath
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	after := run()
+	assert.NotEqual(t, before, after)
+
+	stats, err := cache.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+}
+
+func TestCache_Run_Concurrent(t *testing.T) {
+	repoPath := t.TempDir()
+	cache := New(t.TempDir(), 1024*1024, time.Minute)
+
+	// The command counts its runs and takes a while to let requests pile up
+	counter := filepath.Join(t.TempDir(), "counter")
+	args := []string{"sh", "-c", `echo >> "$0" && sleep 0.2 && cat`, counter}
+
+	const numRequests = 10
+	outputs := make([]bytes.Buffer, numRequests)
+	var wg sync.WaitGroup
+	for i := range outputs {
+		wg.Add(1)
+		go func(stdout *bytes.Buffer) {
+			defer wg.Done()
+			err := cache.Run(repoPath, args, strings.NewReader("PACK"), stdout, os.Stderr)
+			assert.NoError(t, err)
+		}(&outputs[i])
+	}
+	wg.Wait()
+
+	p, err := os.ReadFile(counter)
+	require.NoError(t, err)
+	assert.Equal(t, "\n", string(p))
+	for _, stdout := range outputs {
+		assert.Equal(t, "PACK", stdout.String())
+	}
+
+	stats, err := cache.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{Hits: numRequests - 1, Misses: 1, Entries: 1, Size: 4}, stats)
+}
+
+func TestCache_Run_TooLarge(t *testing.T) {
+	repoPath := newTestRepo(t)
+	cache := New(t.TempDir(), 1, time.Minute)
+
+	var stdout bytes.Buffer
+	err := cache.Run(repoPath, []string{"git", "pack-objects", "--revs", "--stdout"}, strings.NewReader("HEAD\n"), &stdout, os.Stderr)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(stdout.Bytes(), []byte("PACK")))
+
+	// The pack is served but not cached
+	stats, err := cache.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, &Stats{Misses: 1}, stats)
+}
+
+func TestCache_Evict(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	createPack := func(name string, size int, modTime time.Time) string {
+		path := filepath.Join(root, name[:2], name+packExt)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), os.ModePerm))
+		require.NoError(t, os.WriteFile(path, make([]byte, size), 0o644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+		return path
+	}
+
+	expired := createPack("aa01", 1, now.Add(-2*time.Minute))
+	oldest := createPack("bb01", 4, now.Add(-30*time.Second))
+	older := createPack("cc01", 4, now.Add(-20*time.Second))
+	newest := createPack("dd01", 4, now.Add(-10*time.Second))
+
+	err := New(root, 8, time.Minute).Evict()
+	require.NoError(t, err)
+
+	assert.NoFileExists(t, expired)
+	assert.NoFileExists(t, oldest)
+	assert.FileExists(t, older)
+	assert.FileExists(t, newest)
+}
@@ -204,6 +204,7 @@ func Config(c *context.Context) {
 	c.Data["Cache"] = conf.Cache
 	c.Data["Attachment"] = conf.Attachment
 	c.Data["Release"] = conf.Release
+	c.Data["Quota"] = conf.Quota
 	c.Data["Picture"] = conf.Picture
 	c.Data["HTTP"] = conf.HTTP
 	c.Data["Mirror"] = conf.Mirror
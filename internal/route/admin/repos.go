@@ -64,6 +64,16 @@ func Repos(c *context.Context) {
 	}
 	c.Data["Repos"] = repos
 
+	repoIDs := make([]int64, 0, len(repos))
+	for _, repo := range repos {
+		repoIDs = append(repoIDs, repo.ID)
+	}
+	c.Data["MaintenanceStatuses"], err = database.Handle.RepoMaintenance().ListByRepoIDs(c.Req.Context(), repoIDs...)
+	if err != nil {
+		c.Error(err, "list maintenance statuses")
+		return
+	}
+
 	c.Success(REPOS)
 }
 
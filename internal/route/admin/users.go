@@ -141,6 +141,12 @@ func prepareUserInfo(c *context.Context) *database.User {
 	}
 	c.Data["Sources"] = sources
 
+	c.Data["StorageUsage"], err = database.Handle.Quotas().GetUsage(c.Req.Context(), u.ID)
+	if err != nil {
+		c.Error(err, "get storage usage")
+		return nil
+	}
+
 	return u
 }
 
@@ -175,16 +181,19 @@ func EditUserPost(c *context.Context, f form.AdminEditUser) {
 	}
 
 	opts := database.UpdateUserOptions{
-		LoginName:        &f.LoginName,
-		FullName:         &f.FullName,
-		Website:          &f.Website,
-		Location:         &f.Location,
-		MaxRepoCreation:  &f.MaxRepoCreation,
-		IsActivated:      &f.Active,
-		IsAdmin:          &f.Admin,
-		AllowGitHook:     &f.AllowGitHook,
-		AllowImportLocal: &f.AllowImportLocal,
-		ProhibitLogin:    &f.ProhibitLogin,
+		LoginName:         &f.LoginName,
+		FullName:          &f.FullName,
+		Website:           &f.Website,
+		Location:          &f.Location,
+		MaxRepoCreation:   &f.MaxRepoCreation,
+		MaxRepoSize:       &f.MaxRepoSize,
+		MaxLFSSize:        &f.MaxLFSSize,
+		MaxAttachmentSize: &f.MaxAttachmentSize,
+		IsActivated:       &f.Active,
+		IsAdmin:           &f.Admin,
+		AllowGitHook:      &f.AllowGitHook,
+		AllowImportLocal:  &f.AllowImportLocal,
+		ProhibitLogin:     &f.ProhibitLogin,
 	}
 
 	fields := strings.Split(f.LoginType, "-")
@@ -5,9 +5,16 @@
 package admin
 
 import (
+	"archive/zip"
+	"fmt"
+	"net/http"
+
 	api "github.com/gogs/go-gogs-client"
+	"github.com/pkg/errors"
+	log "unknwon.dev/clog/v2"
 
 	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
 	"gogs.io/gogs/internal/route/api/v1/repo"
 	"gogs.io/gogs/internal/route/api/v1/user"
 )
@@ -20,3 +27,65 @@ func CreateRepo(c *context.APIContext, form api.CreateRepoOption) {
 
 	repo.CreateUserRepo(c, owner, form)
 }
+
+// ExportRepo streams the bundle of the repository, which can be imported by
+// ImportRepo on another instance.
+func ExportRepo(c *context.APIContext) {
+	owner := user.GetUserByParams(c)
+	if c.Written() {
+		return
+	}
+
+	r, err := database.Handle.Repositories().GetByName(c.Req.Context(), owner.ID, c.Params(":reponame"))
+	if err != nil {
+		c.NotFoundOrError(err, "get repository by name")
+		return
+	}
+	r.Owner = owner
+
+	c.Resp.Header().Set("Content-Type", "application/zip")
+	c.Resp.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.zip"`, owner.Name, r.Name))
+	c.Resp.WriteHeader(http.StatusOK)
+	if err = database.ExportRepository(c.Req.Context(), r, c.Resp); err != nil {
+		// The status has been sent, nothing more can be told to the client.
+		log.Error("Failed to export repository %d: %v", r.ID, err)
+	}
+}
+
+// ImportRepo creates a new repository for the user or organization from the
+// bundle uploaded as the "bundle" field of the multipart form.
+func ImportRepo(c *context.APIContext) {
+	owner := user.GetUserByParams(c)
+	if c.Written() {
+		return
+	}
+
+	file, header, err := c.Req.FormFile("bundle")
+	if err != nil {
+		c.ErrorStatus(http.StatusUnprocessableEntity, errors.Wrap(err, "get bundle"))
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	zr, err := zip.NewReader(file, header.Size)
+	if err != nil {
+		c.ErrorStatus(http.StatusUnprocessableEntity, errors.Wrap(err, "open bundle"))
+		return
+	}
+
+	r, err := database.ImportRepository(c.Req.Context(), c.User, owner, zr, database.ImportRepoOptions{
+		Name: c.Req.FormValue("name"),
+	})
+	if err != nil {
+		if database.IsErrRepoAlreadyExist(err) ||
+			database.IsErrNameNotAllowed(err) ||
+			database.IsErrQuotaExceeded(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "import repository")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, r.APIFormatLegacy(&api.Permission{Admin: true, Push: true, Pull: true}))
+}
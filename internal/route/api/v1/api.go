@@ -234,6 +234,16 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/issues", repo.ListUserIssues)
 		}, reqToken())
 
+		m.Group("/notifications", func() {
+			m.Combo("").
+				Get(user.ListNotifications).
+				Put(user.MarkNotificationsRead)
+			m.Get("/new", user.CountNewNotifications)
+			m.Combo("/threads/:id").
+				Get(user.GetNotificationThread).
+				Patch(user.UpdateNotificationThread)
+		}, reqToken())
+
 		// Repositories
 		m.Get("/users/:username/repos", reqToken(), repo.ListUserRepositories)
 		m.Get("/orgs/:org/repos", reqToken(), repo.ListOrgRepositories)
@@ -287,6 +297,9 @@ func RegisterRoutes(m *macaron.Macaron) {
 						m.Get("/:sha", repo.RepoGitBlob)
 					})
 				})
+				m.Combo("/notifications").
+					Get(user.ListRepoNotifications).
+					Put(user.MarkRepoNotificationsRead)
 				m.Get("/forks", repo.ListForks)
 				m.Get("/tags", repo.ListTags)
 				m.Group("/branches", func() {
@@ -311,15 +324,20 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Group("/issues", func() {
 					m.Combo("").
 						Get(repo.ListIssues).
-						Post(bind(api.CreateIssueOption{}), repo.CreateIssue)
+						Post(bind(repo.CreateIssueRequest{}), repo.CreateIssue)
+					m.Post("/import", reqRepoWriter(), repo.ImportIssues)
 					m.Group("/comments", func() {
 						m.Get("", repo.ListRepoIssueComments)
 						m.Patch("/:id", bind(api.EditIssueCommentOption{}), repo.EditIssueComment)
+						m.Combo("/:id/reactions").
+							Get(repo.ListReactions).
+							Post(bind(repo.ReactionRequest{}), repo.AddReaction).
+							Delete(bind(repo.ReactionRequest{}), repo.RemoveReaction)
 					})
 					m.Group("/:index", func() {
 						m.Combo("").
 							Get(repo.GetIssue).
-							Patch(bind(api.EditIssueOption{}), repo.EditIssue)
+							Patch(bind(repo.EditIssueRequest{}), repo.EditIssue)
 
 						m.Group("/comments", func() {
 							m.Combo("").
@@ -330,6 +348,13 @@ func RegisterRoutes(m *macaron.Macaron) {
 								Delete(repo.DeleteIssueComment)
 						})
 
+						m.Combo("/reactions").
+							Get(repo.ListReactions).
+							Post(bind(repo.ReactionRequest{}), repo.AddReaction).
+							Delete(bind(repo.ReactionRequest{}), repo.RemoveReaction)
+
+						m.Get("/timeline", repo.ListIssueTimeline)
+
 						m.Get("/labels", repo.ListIssueLabels)
 						m.Group("/labels", func() {
 							m.Combo("").
@@ -338,8 +363,35 @@ func RegisterRoutes(m *macaron.Macaron) {
 								Delete(repo.ClearIssueLabels)
 							m.Delete("/:id", repo.DeleteIssueLabel)
 						}, reqRepoWriter())
+
+						m.Get("/dependencies", repo.ListIssueDependencies)
+						m.Group("/dependencies", func() {
+							m.Post("", bind(repo.AddIssueDependencyRequest{}), repo.AddIssueDependency)
+							m.Delete("/:id", repo.DeleteIssueDependency)
+						}, reqRepoWriter())
+
+						m.Combo("/lock", reqRepoWriter()).
+							Put(bind(repo.LockIssueRequest{}), repo.LockIssue).
+							Delete(repo.UnlockIssue)
+						m.Combo("/pin", reqRepoWriter()).
+							Put(repo.PinIssue).
+							Delete(repo.UnpinIssue)
+						m.Post("/transfer", reqRepoWriter(), bind(repo.TransferIssueRequest{}), repo.TransferIssue)
+
+						m.Group("/times", func() {
+							m.Combo("").
+								Get(repo.ListIssueTrackedTimes).
+								Post(bind(repo.AddTrackedTimeRequest{}), repo.AddIssueTrackedTime)
+							m.Delete("/:id", repo.DeleteIssueTrackedTime)
+						}, reqRepoWriter())
+						m.Group("/stopwatch", func() {
+							m.Post("/start", repo.StartIssueStopwatch)
+							m.Post("/stop", repo.StopIssueStopwatch)
+							m.Delete("", repo.CancelIssueStopwatch)
+						}, reqRepoWriter())
 					})
 				}, mustEnableIssues)
+				m.Get("/times", reqRepoWriter(), repo.ListRepoTrackedTimes)
 
 				m.Group("/labels", func() {
 					m.Get("", repo.ListLabels)
@@ -363,6 +415,10 @@ func RegisterRoutes(m *macaron.Macaron) {
 						Delete(repo.DeleteMilestone)
 				}, reqRepoWriter())
 
+				m.Combo("/projects").
+					Get(repo.ListRepoProjects).
+					Post(reqRepoWriter(), bind(repo.CreateProjectRequest{}), repo.CreateRepoProject)
+
 				m.Patch("/issue-tracker", reqRepoWriter(), bind(api.EditIssueTrackerOption{}), repo.IssueTracker)
 				m.Patch("/wiki", reqRepoWriter(), bind(api.EditWikiOption{}), repo.Wiki)
 				m.Post("/mirror-sync", reqRepoWriter(), repo.MirrorSync)
@@ -371,6 +427,32 @@ func RegisterRoutes(m *macaron.Macaron) {
 		}, reqToken())
 
 		m.Get("/issues", reqToken(), repo.ListUserIssues)
+		m.Get("/issues/search", reqToken(), repo.SearchIssues)
+
+		// Projects
+		m.Group("/projects/:id", func() {
+			m.Combo("").
+				Get(repo.GetProject).
+				Patch(bind(repo.EditProjectRequest{}), repo.EditProject).
+				Delete(repo.DeleteProject)
+			m.Combo("/columns").
+				Get(repo.ListProjectColumns).
+				Post(bind(repo.ProjectColumnRequest{}), repo.CreateProjectColumn)
+			m.Group("/columns/:column_id", func() {
+				m.Combo("").
+					Patch(bind(repo.ProjectColumnRequest{}), repo.EditProjectColumn).
+					Delete(repo.DeleteProjectColumn)
+				m.Post("/cards", bind(repo.CreateProjectCardRequest{}), repo.CreateProjectCard)
+			})
+			m.Get("/cards", repo.ListProjectCards)
+			m.Group("/cards/:card_id", func() {
+				m.Combo("").
+					Get(repo.GetProjectCard).
+					Patch(bind(repo.EditProjectCardRequest{}), repo.EditProjectCard).
+					Delete(repo.DeleteProjectCard)
+				m.Post("/move", bind(repo.MoveProjectCardRequest{}), repo.MoveProjectCard)
+			})
+		}, reqToken())
 
 		// Organizations
 		m.Combo("/user/orgs", reqToken()).
@@ -383,6 +465,28 @@ func RegisterRoutes(m *macaron.Macaron) {
 				Get(org.Get).
 				Patch(bind(api.EditOrgOption{}), org.Edit)
 			m.Get("/teams", org.ListTeams)
+			m.Combo("/projects", reqToken()).
+				Get(repo.ListOrgProjects).
+				Post(bind(repo.CreateProjectRequest{}), repo.CreateOrgProject)
+			m.Group("/labels", func() {
+				m.Combo("").
+					Get(org.ListOrgLabels).
+					Post(bind(api.CreateLabelOption{}), org.CreateOrgLabel)
+				m.Combo("/:id").
+					Get(org.GetOrgLabel).
+					Patch(bind(api.EditLabelOption{}), org.EditOrgLabel).
+					Delete(org.DeleteOrgLabel)
+			}, reqToken())
+			m.Group("/milestones", func() {
+				m.Combo("").
+					Get(org.ListOrgMilestones).
+					Post(bind(api.CreateMilestoneOption{}), org.CreateOrgMilestone)
+				m.Combo("/:id").
+					Get(org.GetOrgMilestone).
+					Patch(bind(api.EditMilestoneOption{}), org.EditOrgMilestone).
+					Delete(org.DeleteOrgMilestone)
+				m.Get("/:id/issues", org.ListOrgMilestoneIssues)
+			}, reqToken())
 		}, orgAssignment(true))
 
 		m.Group("/admin", func() {
@@ -396,9 +500,12 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/keys", bind(api.CreateKeyOption{}), admin.CreatePublicKey)
 					m.Post("/orgs", bind(api.CreateOrgOption{}), admin.CreateOrg)
 					m.Post("/repos", bind(api.CreateRepoOption{}), admin.CreateRepo)
+					m.Post("/repos/import", admin.ImportRepo)
 				})
 			})
 
+			m.Get("/repos/:username/:reponame/export", admin.ExportRepo)
+
 			m.Group("/orgs/:orgname", func() {
 				m.Group("/teams", func() {
 					m.Post("", orgAssignment(true), bind(api.CreateTeamOption{}), admin.CreateTeam)
@@ -7,12 +7,15 @@ package convert
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/unknwon/com"
 
 	"github.com/gogs/git-module"
 	api "github.com/gogs/go-gogs-client"
 
+	"gogs.io/gogs/internal/conf"
 	"gogs.io/gogs/internal/database"
 )
 
@@ -138,3 +141,261 @@ func ToTeam(team *database.Team) *api.Team {
 		Permission:  team.Authorize.String(),
 	}
 }
+
+// Issue is the API representation of an issue, which extends api.Issue with
+// all assignees, reactions, the due date, and the lock and pin states of the
+// issue.
+type Issue struct {
+	*api.Issue
+	Assignees  []*api.User                 `json:"assignees"`
+	Reactions  []*database.ReactionSummary `json:"reactions"`
+	DueDate    *time.Time                  `json:"due_date"`
+	IsLocked   bool                        `json:"is_locked"`
+	LockReason string                      `json:"lock_reason,omitempty"`
+	IsPinned   bool                        `json:"is_pinned"`
+}
+
+// ToIssue converts the issue with its reactions, use ToIssues for a list of
+// issues to load reactions in one query.
+func ToIssue(ctx context.Context, issue *database.Issue) (*Issue, error) {
+	reactions, err := database.Handle.Reactions().Summarize(ctx, issue.ID, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "summarize reactions")
+	}
+	return toIssue(issue, reactions), nil
+}
+
+// ToIssues converts the list of issues with their reactions.
+func ToIssues(ctx context.Context, issues []*database.Issue) ([]*Issue, error) {
+	issueIDs := make([]int64, len(issues))
+	for i := range issues {
+		issueIDs[i] = issues[i].ID
+	}
+	reactions, err := database.Handle.Reactions().SummarizeByIssueIDs(ctx, issueIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "summarize reactions")
+	}
+
+	apiIssues := make([]*Issue, len(issues))
+	for i := range issues {
+		apiIssues[i] = toIssue(issues[i], reactions[issues[i].ID])
+	}
+	return apiIssues, nil
+}
+
+func toIssue(issue *database.Issue, reactions []*database.ReactionSummary) *Issue {
+	if reactions == nil {
+		reactions = []*database.ReactionSummary{}
+	}
+
+	assignees := make([]*api.User, len(issue.Assignees))
+	for i := range issue.Assignees {
+		assignees[i] = issue.Assignees[i].APIFormat()
+	}
+	apiIssue := &Issue{
+		Issue:      issue.APIFormat(),
+		Assignees:  assignees,
+		Reactions:  reactions,
+		IsLocked:   issue.IsLocked,
+		LockReason: issue.LockReason,
+		IsPinned:   issue.IsPinned(),
+	}
+	if issue.HasDeadline() {
+		deadline := time.Unix(issue.DeadlineUnix, 0)
+		apiIssue.DueDate = &deadline
+	}
+	return apiIssue
+}
+
+// Comment is the API representation of a comment, which extends api.Comment
+// with reactions of the comment.
+type Comment struct {
+	*api.Comment
+	Reactions []*database.ReactionSummary `json:"reactions"`
+}
+
+// ToComment converts the comment with its reactions, use ToComments for a list
+// of comments to load reactions in one query.
+func ToComment(ctx context.Context, comment *database.Comment) (*Comment, error) {
+	reactions, err := database.Handle.Reactions().Summarize(ctx, comment.IssueID, comment.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "summarize reactions")
+	}
+	return toComment(comment, reactions), nil
+}
+
+// ToComments converts the list of comments with their reactions.
+func ToComments(ctx context.Context, comments []*database.Comment) ([]*Comment, error) {
+	commentIDs := make([]int64, len(comments))
+	for i := range comments {
+		commentIDs[i] = comments[i].ID
+	}
+	reactions, err := database.Handle.Reactions().SummarizeByCommentIDs(ctx, commentIDs)
+	if err != nil {
+		return nil, errors.Wrap(err, "summarize reactions")
+	}
+
+	apiComments := make([]*Comment, len(comments))
+	for i := range comments {
+		apiComments[i] = toComment(comments[i], reactions[comments[i].ID])
+	}
+	return apiComments, nil
+}
+
+func toComment(comment *database.Comment, reactions []*database.ReactionSummary) *Comment {
+	if reactions == nil {
+		reactions = []*database.ReactionSummary{}
+	}
+	return &Comment{
+		Comment:   comment.APIFormat(),
+		Reactions: reactions,
+	}
+}
+
+// Project is the API representation of a project board of a repository or an
+// organization.
+type Project struct {
+	ID          int64         `json:"id"`
+	OwnerID     int64         `json:"owner_id,omitempty"`
+	RepoID      int64         `json:"repo_id,omitempty"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	State       api.StateType `json:"state"`
+	Created     time.Time     `json:"created_at"`
+	Updated     time.Time     `json:"updated_at"`
+}
+
+func ToProject(p *database.Project) *Project {
+	state := api.STATE_OPEN
+	if p.IsClosed {
+		state = api.STATE_CLOSED
+	}
+	return &Project{
+		ID:          p.ID,
+		OwnerID:     p.OwnerID,
+		RepoID:      p.RepoID,
+		Name:        p.Name,
+		Description: p.Description,
+		State:       state,
+		Created:     p.Created(),
+		Updated:     p.Updated(),
+	}
+}
+
+type ProjectColumn struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Position   int    `json:"position"`
+	Automation string `json:"automation"`
+}
+
+func ToProjectColumn(column *database.ProjectColumn) *ProjectColumn {
+	return &ProjectColumn{
+		ID:         column.ID,
+		Name:       column.Name,
+		Position:   column.Position,
+		Automation: column.Automation,
+	}
+}
+
+// ProjectCardIssue is the reference to the issue or pull request of a project
+// card.
+type ProjectCardIssue struct {
+	ID         int64         `json:"id"`
+	Number     int64         `json:"number"`
+	Repository string        `json:"repository"`
+	Title      string        `json:"title"`
+	State      api.StateType `json:"state"`
+	IsPull     bool          `json:"is_pull"`
+	HTMLURL    string        `json:"html_url"`
+}
+
+// ProjectCard is the API representation of a project card, which either
+// references an issue or pull request, or has a note.
+type ProjectCard struct {
+	ID       int64             `json:"id"`
+	ColumnID int64             `json:"column_id"`
+	Position int               `json:"position"`
+	Note     string            `json:"note,omitempty"`
+	Issue    *ProjectCardIssue `json:"issue,omitempty"`
+	Created  time.Time         `json:"created_at"`
+	Updated  time.Time         `json:"updated_at"`
+}
+
+// ToProjectCard converts the card, whose issue must have been loaded with
+// database.LoadProjectCardIssues unless it is a note.
+func ToProjectCard(card *database.ProjectCard) *ProjectCard {
+	apiCard := &ProjectCard{
+		ID:       card.ID,
+		ColumnID: card.ColumnID,
+		Position: card.Position,
+		Note:     card.Note,
+		Created:  time.Unix(card.CreatedUnix, 0),
+		Updated:  time.Unix(card.UpdatedUnix, 0),
+	}
+	if issue := card.Issue; issue != nil {
+		apiCard.Issue = &ProjectCardIssue{
+			ID:         issue.ID,
+			Number:     issue.Index,
+			Repository: issue.Repo.FullName(),
+			Title:      issue.Title,
+			State:      issue.State(),
+			IsPull:     issue.IsPull,
+			HTMLURL:    issue.HTMLURL(),
+		}
+	}
+	return apiCard
+}
+
+// NotificationSubject is the issue or pull request of a notification thread.
+type NotificationSubject struct {
+	Title   string        `json:"title"`
+	URL     string        `json:"url"`
+	HTMLURL string        `json:"html_url"`
+	Type    string        `json:"type"`
+	State   api.StateType `json:"state"`
+	// The link to the latest comment, empty if the latest activity is on the
+	// issue itself.
+	LatestCommentHTMLURL string `json:"latest_comment_html_url,omitempty"`
+}
+
+// NotificationThread is the API representation of a notification.
+type NotificationThread struct {
+	ID         int64                `json:"id"`
+	Repository *api.Repository      `json:"repository"`
+	Subject    *NotificationSubject `json:"subject"`
+	Reason     string               `json:"reason"`
+	Status     string               `json:"status"`
+	Unread     bool                 `json:"unread"`
+	Pinned     bool                 `json:"pinned"`
+	Updated    time.Time            `json:"updated_at"`
+}
+
+// ToNotificationThread converts the notification, whose issue must have been
+// loaded with database.LoadNotificationIssues.
+func ToNotificationThread(n *database.Notification) *NotificationThread {
+	issue := n.Issue
+	subject := &NotificationSubject{
+		Title:   issue.Title,
+		URL:     fmt.Sprintf("%sapi/v1/repos/%s/issues/%d", conf.Server.ExternalURL, issue.Repo.FullName(), issue.Index),
+		HTMLURL: issue.HTMLURL(),
+		Type:    "Issue",
+		State:   issue.State(),
+	}
+	if issue.IsPull {
+		subject.Type = "Pull"
+	}
+	if n.CommentID > 0 {
+		subject.LatestCommentHTMLURL = fmt.Sprintf("%s#issuecomment-%d", subject.HTMLURL, n.CommentID)
+	}
+	return &NotificationThread{
+		ID:         n.ID,
+		Repository: issue.Repo.APIFormatLegacy(nil),
+		Subject:    subject,
+		Reason:     n.Reason.String(),
+		Status:     n.Status.String(),
+		Unread:     n.IsUnread(),
+		Pinned:     n.IsPinned(),
+		Updated:    n.Updated(),
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"net/http"
+
+	api "github.com/gogs/go-gogs-client"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+)
+
+// orgLabelsAssignment makes sure the context organization exists and the
+// context user is a member of it, and also an owner of it when write access is
+// required, as labels and milestones of the organization are shared by all its
+// repositories.
+func orgLabelsAssignment(c *context.APIContext, needsWrite bool) bool {
+	org := c.Org.Organization
+	if !org.IsOrganization() ||
+		(!c.User.IsAdmin && !database.IsOrganizationMember(org.ID, c.User.ID)) {
+		c.NotFound()
+		return false
+	} else if needsWrite && !c.User.IsAdmin && !org.IsOwnedBy(c.User.ID) {
+		c.Status(http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// GET /orgs/:orgname/labels
+func ListOrgLabels(c *context.APIContext) {
+	if !orgLabelsAssignment(c, false) {
+		return
+	}
+
+	labels, err := database.GetLabelsByOrgID(c.Org.Organization.ID)
+	if err != nil {
+		c.Error(err, "get labels by organization ID")
+		return
+	}
+
+	apiLabels := make([]*api.Label, len(labels))
+	for i := range labels {
+		apiLabels[i] = labels[i].APIFormat()
+	}
+	c.JSONSuccess(&apiLabels)
+}
+
+// GET /orgs/:orgname/labels/:id
+func GetOrgLabel(c *context.APIContext) {
+	if !orgLabelsAssignment(c, false) {
+		return
+	}
+
+	label, err := database.GetLabelOfOrgByID(c.Org.Organization.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get label of organization by ID")
+		return
+	}
+	c.JSONSuccess(label.APIFormat())
+}
+
+// POST /orgs/:orgname/labels
+func CreateOrgLabel(c *context.APIContext, form api.CreateLabelOption) {
+	if !orgLabelsAssignment(c, true) {
+		return
+	}
+
+	label := &database.Label{
+		Name:  form.Name,
+		Color: form.Color,
+		OrgID: c.Org.Organization.ID,
+	}
+	if err := database.NewLabels(label); err != nil {
+		c.Error(err, "new labels")
+		return
+	}
+	c.JSON(http.StatusCreated, label.APIFormat())
+}
+
+// PATCH /orgs/:orgname/labels/:id
+func EditOrgLabel(c *context.APIContext, form api.EditLabelOption) {
+	if !orgLabelsAssignment(c, true) {
+		return
+	}
+
+	label, err := database.GetLabelOfOrgByID(c.Org.Organization.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get label of organization by ID")
+		return
+	}
+
+	if form.Name != nil {
+		label.Name = *form.Name
+	}
+	if form.Color != nil {
+		label.Color = *form.Color
+	}
+	if err := database.UpdateLabel(label); err != nil {
+		c.Error(err, "update label")
+		return
+	}
+	c.JSONSuccess(label.APIFormat())
+}
+
+// DELETE /orgs/:orgname/labels/:id
+func DeleteOrgLabel(c *context.APIContext) {
+	if !orgLabelsAssignment(c, true) {
+		return
+	}
+
+	if err := database.DeleteOrgLabel(c.Org.Organization.ID, c.ParamsInt64(":id")); err != nil {
+		c.Error(err, "delete organization label")
+		return
+	}
+	c.NoContent()
+}
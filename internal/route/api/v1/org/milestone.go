@@ -0,0 +1,169 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"net/http"
+	"time"
+
+	api "github.com/gogs/go-gogs-client"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/route/api/v1/repo"
+)
+
+// GET /orgs/:orgname/milestones
+func ListOrgMilestones(c *context.APIContext) {
+	if !orgLabelsAssignment(c, false) {
+		return
+	}
+
+	milestones, err := database.GetMilestonesByOrgID(c.Org.Organization.ID)
+	if err != nil {
+		c.Error(err, "get milestones by organization ID")
+		return
+	}
+
+	apiMilestones := make([]*api.Milestone, len(milestones))
+	for i := range milestones {
+		apiMilestones[i] = milestones[i].APIFormat()
+	}
+	c.JSONSuccess(&apiMilestones)
+}
+
+// getOrgMilestone returns the milestone by the ":id" parameter of the context
+// organization, or responds with 404.
+func getOrgMilestone(c *context.APIContext) *database.Milestone {
+	milestone, err := database.GetMilestoneByOrgID(c.Org.Organization.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get milestone by organization ID")
+		return nil
+	}
+	return milestone
+}
+
+// GET /orgs/:orgname/milestones/:id
+func GetOrgMilestone(c *context.APIContext) {
+	if !orgLabelsAssignment(c, false) {
+		return
+	}
+
+	milestone := getOrgMilestone(c)
+	if c.Written() {
+		return
+	}
+	c.JSONSuccess(milestone.APIFormat())
+}
+
+// GET /orgs/:orgname/milestones/:id/issues
+func ListOrgMilestoneIssues(c *context.APIContext) {
+	if !orgLabelsAssignment(c, false) {
+		return
+	}
+
+	milestone := getOrgMilestone(c)
+	if c.Written() {
+		return
+	}
+
+	org := c.Org.Organization
+	repos, _, err := org.GetUserRepositories(c.User.ID, 1, org.NumRepos)
+	if err != nil {
+		c.Error(err, "get user repositories")
+		return
+	}
+	repoIDs := make([]int64, 0, len(repos))
+	for _, r := range repos {
+		repoIDs = append(repoIDs, r.ID)
+	}
+	repoIDs, err = database.FilterRepositoryWithIssues(repoIDs)
+	if err != nil {
+		c.Error(err, "filter repositories with issues")
+		return
+	}
+	// An empty list of repositories must not list issues of all repositories
+	if repoIDs == nil {
+		repoIDs = []int64{}
+	}
+
+	repo.ListIssuesByOptions(c, &database.IssuesOptions{
+		RepoIDs:     repoIDs,
+		MilestoneID: milestone.ID,
+		Page:        c.QueryInt("page"),
+		IsClosed:    api.StateType(c.Query("state")) == api.STATE_CLOSED,
+	})
+}
+
+// POST /orgs/:orgname/milestones
+func CreateOrgMilestone(c *context.APIContext, form api.CreateMilestoneOption) {
+	if !orgLabelsAssignment(c, true) {
+		return
+	}
+
+	if form.Deadline == nil {
+		defaultDeadline, _ := time.ParseInLocation("2006-01-02", "9999-12-31", time.Local)
+		form.Deadline = &defaultDeadline
+	}
+
+	milestone := &database.Milestone{
+		OrgID:    c.Org.Organization.ID,
+		Name:     form.Title,
+		Content:  form.Description,
+		Deadline: *form.Deadline,
+	}
+	if err := database.NewMilestone(milestone); err != nil {
+		c.Error(err, "new milestone")
+		return
+	}
+	c.JSON(http.StatusCreated, milestone.APIFormat())
+}
+
+// PATCH /orgs/:orgname/milestones/:id
+func EditOrgMilestone(c *context.APIContext, form api.EditMilestoneOption) {
+	if !orgLabelsAssignment(c, true) {
+		return
+	}
+
+	milestone := getOrgMilestone(c)
+	if c.Written() {
+		return
+	}
+
+	if len(form.Title) > 0 {
+		milestone.Name = form.Title
+	}
+	if form.Description != nil {
+		milestone.Content = *form.Description
+	}
+	if form.Deadline != nil && !form.Deadline.IsZero() {
+		milestone.Deadline = *form.Deadline
+	}
+
+	var err error
+	if form.State != nil {
+		if err = milestone.ChangeStatus(api.STATE_CLOSED == api.StateType(*form.State)); err != nil {
+			c.Error(err, "change status")
+			return
+		}
+	} else if err = database.UpdateMilestone(milestone); err != nil {
+		c.Error(err, "update milestone")
+		return
+	}
+	c.JSONSuccess(milestone.APIFormat())
+}
+
+// DELETE /orgs/:orgname/milestones/:id
+func DeleteOrgMilestone(c *context.APIContext) {
+	if !orgLabelsAssignment(c, true) {
+		return
+	}
+
+	if err := database.DeleteMilestoneOfOrgByID(c.Org.Organization.ID, c.ParamsInt64(":id")); err != nil {
+		c.Error(err, "delete milestone of organization by ID")
+		return
+	}
+	c.NoContent()
+}
@@ -8,15 +8,28 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	api "github.com/gogs/go-gogs-client"
 
 	"gogs.io/gogs/internal/conf"
 	"gogs.io/gogs/internal/context"
 	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/route/api/v1/convert"
 )
 
-func listIssues(c *context.APIContext, opts *database.IssuesOptions) {
+// ListIssuesByOptions responds with issues matching the options, which are narrowed down
+// by the search query in the "q" parameter if present.
+func ListIssuesByOptions(c *context.APIContext, opts *database.IssuesOptions) {
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		var err error
+		opts.Query, err = database.ParseIssueQuery(q, c.User)
+		if err != nil {
+			c.Error(err, "parse issue query")
+			return
+		}
+	}
+
 	issues, err := database.Issues(opts)
 	if err != nil {
 		c.Error(err, "list issues")
@@ -30,13 +43,16 @@ func listIssues(c *context.APIContext, opts *database.IssuesOptions) {
 	}
 
 	// FIXME: use IssueList to improve performance.
-	apiIssues := make([]*api.Issue, len(issues))
 	for i := range issues {
 		if err = issues[i].LoadAttributes(); err != nil {
 			c.Error(err, "load attributes")
 			return
 		}
-		apiIssues[i] = issues[i].APIFormat()
+	}
+	apiIssues, err := convert.ToIssues(c.Req.Context(), issues)
+	if err != nil {
+		c.Error(err, "convert issues")
+		return
 	}
 
 	c.SetLinkHeader(int(count), conf.UI.IssuePagingNum)
@@ -50,7 +66,7 @@ func ListUserIssues(c *context.APIContext) {
 		IsClosed:   api.StateType(c.Query("state")) == api.STATE_CLOSED,
 	}
 
-	listIssues(c, &opts)
+	ListIssuesByOptions(c, &opts)
 }
 
 func ListIssues(c *context.APIContext) {
@@ -60,7 +76,24 @@ func ListIssues(c *context.APIContext) {
 		IsClosed: api.StateType(c.Query("state")) == api.STATE_CLOSED,
 	}
 
-	listIssues(c, &opts)
+	ListIssuesByOptions(c, &opts)
+}
+
+// GET /issues/search
+func SearchIssues(c *context.APIContext) {
+	repoIDs, err := database.GetAccessibleRepoIDs(c.User.ID)
+	if err != nil {
+		c.Error(err, "get accessible repository IDs")
+		return
+	}
+
+	opts := database.IssuesOptions{
+		RepoIDs:  repoIDs,
+		Page:     c.QueryInt("page"),
+		IsClosed: api.StateType(c.Query("state")) == api.STATE_CLOSED,
+	}
+
+	ListIssuesByOptions(c, &opts)
 }
 
 func GetIssue(c *context.APIContext) {
@@ -69,10 +102,46 @@ func GetIssue(c *context.APIContext) {
 		c.NotFoundOrError(err, "get issue by index")
 		return
 	}
-	c.JSONSuccess(issue.APIFormat())
+
+	apiIssue, err := convert.ToIssue(c.Req.Context(), issue)
+	if err != nil {
+		c.Error(err, "convert issue")
+		return
+	}
+	c.JSONSuccess(apiIssue)
 }
 
-func CreateIssue(c *context.APIContext, form api.CreateIssueOption) {
+// getAssigneeIDsByNames returns IDs of users by given usernames. It responds
+// with 422 when any of the users does not exist.
+func getAssigneeIDsByNames(c *context.APIContext, names []string) []int64 {
+	assigneeIDs := make([]int64, 0, len(names))
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+
+		assignee, err := database.Handle.Users().GetByUsername(c.Req.Context(), name)
+		if err != nil {
+			if database.IsErrUserNotExist(err) {
+				c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("assignee does not exist: [name: %s]", name))
+			} else {
+				c.Error(err, "get user by name")
+			}
+			return nil
+		}
+		assigneeIDs = append(assigneeIDs, assignee.ID)
+	}
+	return assigneeIDs
+}
+
+// CreateIssueRequest is the API message for creating an issue, which accepts
+// multiple assignees in addition to api.CreateIssueOption.
+type CreateIssueRequest struct {
+	api.CreateIssueOption
+	Assignees []string `json:"assignees"`
+}
+
+func CreateIssue(c *context.APIContext, form CreateIssueRequest) {
 	issue := &database.Issue{
 		RepoID:   c.Repo.Repository.ID,
 		Title:    form.Title,
@@ -81,25 +150,18 @@ func CreateIssue(c *context.APIContext, form api.CreateIssueOption) {
 		Content:  form.Body,
 	}
 
+	var assigneeIDs []int64
 	if c.Repo.IsWriter() {
-		if len(form.Assignee) > 0 {
-			assignee, err := database.Handle.Users().GetByUsername(c.Req.Context(), form.Assignee)
-			if err != nil {
-				if database.IsErrUserNotExist(err) {
-					c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("assignee does not exist: [name: %s]", form.Assignee))
-				} else {
-					c.Error(err, "get user by name")
-				}
-				return
-			}
-			issue.AssigneeID = assignee.ID
+		assigneeIDs = getAssigneeIDsByNames(c, append(form.Assignees, form.Assignee))
+		if c.Written() {
+			return
 		}
 		issue.MilestoneID = form.Milestone
 	} else {
 		form.Labels = nil
 	}
 
-	if err := database.NewIssue(c.Repo.Repository, issue, form.Labels, nil); err != nil {
+	if err := database.NewIssue(c.Repo.Repository, issue, form.Labels, assigneeIDs, nil); err != nil {
 		c.Error(err, "new issue")
 		return
 	}
@@ -118,10 +180,28 @@ func CreateIssue(c *context.APIContext, form api.CreateIssueOption) {
 		c.Error(err, "get issue by ID")
 		return
 	}
-	c.JSON(http.StatusCreated, issue.APIFormat())
+
+	apiIssue, err := convert.ToIssue(c.Req.Context(), issue)
+	if err != nil {
+		c.Error(err, "convert issue")
+		return
+	}
+	c.JSON(http.StatusCreated, apiIssue)
+}
+
+// EditIssueRequest is the API message for editing an issue, which accepts
+// multiple assignees and the due date in addition to api.EditIssueOption. The
+// legacy "assignee" field replaces all assignees with the single user when
+// "assignees" is absent.
+type EditIssueRequest struct {
+	api.EditIssueOption
+	Assignees *[]string `json:"assignees"`
+	// The due date in the form of "2006-01-02", an empty string removes the due
+	// date.
+	DueDate *string `json:"due_date"`
 }
 
-func EditIssue(c *context.APIContext, form api.EditIssueOption) {
+func EditIssue(c *context.APIContext, form EditIssueRequest) {
 	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
 	if err != nil {
 		c.NotFoundOrError(err, "get issue by index")
@@ -133,32 +213,60 @@ func EditIssue(c *context.APIContext, form api.EditIssueOption) {
 		return
 	}
 
-	if len(form.Title) > 0 {
-		issue.Title = form.Title
+	// Validate everything before applying any change to not leave a partial
+	// update behind.
+	isClosed := form.State != nil && api.StateType(*form.State) == api.STATE_CLOSED
+	if isClosed && !issue.IsClosed {
+		if err = issue.CheckClosable(c.Repo.Repository); err != nil {
+			if database.IsErrIssueBlocked(err) {
+				c.ErrorStatus(http.StatusUnprocessableEntity, err)
+			} else {
+				c.Error(err, "check closable")
+			}
+			return
+		}
 	}
-	if form.Body != nil {
-		issue.Content = *form.Body
+
+	var deadline time.Time
+	if c.Repo.IsWriter() && form.DueDate != nil && *form.DueDate != "" {
+		deadline, err = time.ParseInLocation("2006-01-02", *form.DueDate, time.Local)
+		if err != nil {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+			return
+		}
+		deadline = time.Date(deadline.Year(), deadline.Month(), deadline.Day(), 23, 59, 59, 0, deadline.Location())
 	}
 
-	if c.Repo.IsWriter() && form.Assignee != nil &&
-		(issue.Assignee == nil || issue.Assignee.LowerName != strings.ToLower(*form.Assignee)) {
-		if *form.Assignee == "" {
-			issue.AssigneeID = 0
+	changeAssignees := c.Repo.IsWriter() && (form.Assignees != nil || form.Assignee != nil)
+	var assigneeIDs []int64
+	if changeAssignees {
+		var names []string
+		if form.Assignees != nil {
+			names = *form.Assignees
 		} else {
-			assignee, err := database.Handle.Users().GetByUsername(c.Req.Context(), *form.Assignee)
-			if err != nil {
-				if database.IsErrUserNotExist(err) {
-					c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("assignee does not exist: [name: %s]", *form.Assignee))
-				} else {
-					c.Error(err, "get user by name")
-				}
-				return
-			}
-			issue.AssigneeID = assignee.ID
+			names = []string{*form.Assignee}
 		}
 
-		if err = database.UpdateIssueUserByAssignee(issue); err != nil {
-			c.Error(err, "update issue user by assignee")
+		assigneeIDs = getAssigneeIDsByNames(c, names)
+		if c.Written() {
+			return
+		}
+	}
+
+	if len(form.Title) > 0 && form.Title != issue.Title {
+		if err = issue.ChangeTitle(c.User, form.Title); err != nil {
+			c.Error(err, "change title")
+			return
+		}
+	}
+	oldContent := issue.Content
+	if form.Body != nil {
+		issue.Content = *form.Body
+	}
+
+	if changeAssignees {
+		if err = issue.ChangeAssignees(c.User, assigneeIDs); err != nil {
+			c.Error(err, "change assignees")
 			return
 		}
 	}
@@ -172,12 +280,19 @@ func EditIssue(c *context.APIContext, form api.EditIssueOption) {
 		}
 	}
 
-	if err = database.UpdateIssue(issue); err != nil {
+	if c.Repo.IsWriter() && form.DueDate != nil {
+		if err = issue.ChangeDeadline(deadline); err != nil {
+			c.Error(err, "change deadline")
+			return
+		}
+	}
+
+	if err = database.UpdateIssue(c.User, issue, oldContent); err != nil {
 		c.Error(err, "update issue")
 		return
 	}
 	if form.State != nil {
-		if err = issue.ChangeStatus(c.User, c.Repo.Repository, api.STATE_CLOSED == api.StateType(*form.State)); err != nil {
+		if err = issue.ChangeStatus(c.User, c.Repo.Repository, isClosed); err != nil {
 			c.Error(err, "change status")
 			return
 		}
@@ -189,5 +304,11 @@ func EditIssue(c *context.APIContext, form api.EditIssueOption) {
 		c.Error(err, "get issue by ID")
 		return
 	}
-	c.JSON(http.StatusCreated, issue.APIFormat())
+
+	apiIssue, err := convert.ToIssue(c.Req.Context(), issue)
+	if err != nil {
+		c.Error(err, "convert issue")
+		return
+	}
+	c.JSON(http.StatusCreated, apiIssue)
 }
@@ -8,9 +8,11 @@ import (
 	"time"
 
 	api "github.com/gogs/go-gogs-client"
+	"github.com/pkg/errors"
 
 	"gogs.io/gogs/internal/context"
 	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/route/api/v1/convert"
 )
 
 func ListIssueComments(c *context.APIContext) {
@@ -37,9 +39,10 @@ func ListIssueComments(c *context.APIContext) {
 		return
 	}
 
-	apiComments := make([]*api.Comment, len(comments))
-	for i := range comments {
-		apiComments[i] = comments[i].APIFormat()
+	apiComments, err := convert.ToComments(c.Req.Context(), comments)
+	if err != nil {
+		c.Error(err, "convert comments")
+		return
 	}
 	c.JSONSuccess(&apiComments)
 }
@@ -61,9 +64,10 @@ func ListRepoIssueComments(c *context.APIContext) {
 		return
 	}
 
-	apiComments := make([]*api.Comment, len(comments))
-	for i := range comments {
-		apiComments[i] = comments[i].APIFormat()
+	apiComments, err := convert.ToComments(c.Req.Context(), comments)
+	if err != nil {
+		c.Error(err, "convert comments")
+		return
 	}
 	c.JSONSuccess(&apiComments)
 }
@@ -75,13 +79,23 @@ func CreateIssueComment(c *context.APIContext, form api.CreateIssueCommentOption
 		return
 	}
 
+	if issue.IsLocked && !c.Repo.IsWriter() {
+		c.ErrorStatus(http.StatusForbidden, errors.New("issue is locked"))
+		return
+	}
+
 	comment, err := database.CreateIssueComment(c.User, c.Repo.Repository, issue, form.Body, nil)
 	if err != nil {
 		c.Error(err, "create issue comment")
 		return
 	}
 
-	c.JSON(http.StatusCreated, comment.APIFormat())
+	apiComment, err := convert.ToComment(c.Req.Context(), comment)
+	if err != nil {
+		c.Error(err, "convert comment")
+		return
+	}
+	c.JSON(http.StatusCreated, apiComment)
 }
 
 func EditIssueComment(c *context.APIContext, form api.EditIssueCommentOption) {
@@ -105,7 +119,13 @@ func EditIssueComment(c *context.APIContext, form api.EditIssueCommentOption) {
 		c.Error(err, "update comment")
 		return
 	}
-	c.JSONSuccess(comment.APIFormat())
+
+	apiComment, err := convert.ToComment(c.Req.Context(), comment)
+	if err != nil {
+		c.Error(err, "convert comment")
+		return
+	}
+	c.JSONSuccess(apiComment)
 }
 
 func DeleteIssueComment(c *context.APIContext) {
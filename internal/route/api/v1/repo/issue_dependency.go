@@ -0,0 +1,109 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	api "github.com/gogs/go-gogs-client"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+)
+
+type issueDependency struct {
+	Repository string     `json:"repository"`
+	Issue      *api.Issue `json:"issue"`
+}
+
+func toIssueDependencies(issues []*database.Issue) []*issueDependency {
+	dependencies := make([]*issueDependency, len(issues))
+	for i := range issues {
+		dependencies[i] = &issueDependency{
+			Repository: issues[i].Repo.FullName(),
+			Issue:      issues[i].APIFormat(),
+		}
+	}
+	return dependencies
+}
+
+// GET /repos/:username/:reponame/issues/:index/dependencies
+func ListIssueDependencies(c *context.APIContext) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	blockedBy, blocks, err := database.ListVisibleIssueDependencies(c.Req.Context(), c.User, issue.ID)
+	if err != nil {
+		c.Error(err, "list visible issue dependencies")
+		return
+	}
+
+	c.JSONSuccess(map[string]any{
+		"blocked_by": toIssueDependencies(blockedBy),
+		"blocks":     toIssueDependencies(blocks),
+	})
+}
+
+// AddIssueDependencyRequest is the API message for making an issue blocked by
+// another issue.
+type AddIssueDependencyRequest struct {
+	// The full name of the repository that the blocking issue belongs to,
+	// defaults to the current repository.
+	Repository string `json:"repository"`
+	Index      int64  `json:"index" binding:"Required"`
+}
+
+// POST /repos/:username/:reponame/issues/:index/dependencies
+func AddIssueDependency(c *context.APIContext, r AddIssueDependencyRequest) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	ref := fmt.Sprintf("%s#%d", r.Repository, r.Index)
+	dependency, err := database.GetVisibleIssueByRef(c.Req.Context(), c.User, c.Repo.Repository, ref)
+	if err != nil {
+		if database.IsErrIssueNotExist(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "get visible issue by reference")
+		}
+		return
+	}
+
+	err = database.Handle.IssueDependencies().Add(c.Req.Context(), issue.ID, dependency.ID)
+	if err != nil {
+		if database.IsErrIssueDependencyAlreadyExist(err) || database.IsErrIssueDependencyInvalid(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "add issue dependency")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, toIssueDependencies([]*database.Issue{dependency})[0])
+}
+
+// DELETE /repos/:username/:reponame/issues/:index/dependencies/:id
+func DeleteIssueDependency(c *context.APIContext) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	err = database.Handle.IssueDependencies().Remove(c.Req.Context(), issue.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "remove issue dependency")
+		return
+	}
+
+	c.NoContent()
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+	"strings"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+)
+
+// POST /repos/:username/:reponame/issues/import
+//
+// The request body contains the records in the format given by the "format"
+// parameter, which defaults to CSV for the "text/csv" content type and JSON
+// otherwise. No issue is created when "dry_run" is true.
+func ImportIssues(c *context.APIContext) {
+	format := c.QueryTrim("format")
+	if format == "" {
+		format = database.IssueExchangeFormatJSON
+		if strings.Contains(c.Req.Header.Get("Content-Type"), "csv") {
+			format = database.IssueExchangeFormatCSV
+		}
+	}
+
+	defer func() { _ = c.Req.Request.Body.Close() }()
+	records, err := database.ReadIssueRecords(c.Req.Request.Body, format)
+	if err != nil {
+		c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	report, err := database.ImportIssues(c.User, c.Repo.Repository, records, c.QueryBool("dry_run"))
+	if err != nil {
+		c.Error(err, "import issues")
+		return
+	} else if len(report.Errors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	if report.DryRun {
+		c.JSONSuccess(report)
+	} else {
+		c.JSON(http.StatusCreated, report)
+	}
+}
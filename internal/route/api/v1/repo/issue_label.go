@@ -34,7 +34,7 @@ func AddIssueLabels(c *context.APIContext, form api.IssueLabelsOption) {
 		return
 	}
 
-	labels, err := database.GetLabelsInRepoByIDs(c.Repo.Repository.ID, form.Labels)
+	labels, err := database.GetAvailableLabelsInRepoByIDs(c.Repo.Repository.ID, form.Labels)
 	if err != nil {
 		c.Error(err, "get labels in repository by IDs")
 		return
@@ -65,7 +65,7 @@ func DeleteIssueLabel(c *context.APIContext) {
 		return
 	}
 
-	label, err := database.GetLabelOfRepoByID(c.Repo.Repository.ID, c.ParamsInt64(":id"))
+	label, err := database.GetAvailableLabelOfRepoByID(c.Repo.Repository.ID, c.ParamsInt64(":id"))
 	if err != nil {
 		if database.IsErrLabelNotExist(err) {
 			c.ErrorStatus(http.StatusUnprocessableEntity, err)
@@ -75,8 +75,8 @@ func DeleteIssueLabel(c *context.APIContext) {
 		return
 	}
 
-	if err := database.DeleteIssueLabel(issue, label); err != nil {
-		c.Error(err, "delete issue label")
+	if err := issue.RemoveLabel(c.User, label); err != nil {
+		c.Error(err, "remove label")
 		return
 	}
 
@@ -90,13 +90,13 @@ func ReplaceIssueLabels(c *context.APIContext, form api.IssueLabelsOption) {
 		return
 	}
 
-	labels, err := database.GetLabelsInRepoByIDs(c.Repo.Repository.ID, form.Labels)
+	labels, err := database.GetAvailableLabelsInRepoByIDs(c.Repo.Repository.ID, form.Labels)
 	if err != nil {
 		c.Error(err, "get labels in repository by IDs")
 		return
 	}
 
-	if err := issue.ReplaceLabels(labels); err != nil {
+	if err := issue.ReplaceLabels(c.User, labels); err != nil {
 		c.Error(err, "replace labels")
 		return
 	}
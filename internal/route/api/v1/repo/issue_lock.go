@@ -0,0 +1,88 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+)
+
+// LockIssueRequest is the API message for locking an issue.
+type LockIssueRequest struct {
+	// One of "off_topic", "too_heated", "resolved" and "spam", can be empty.
+	Reason string `json:"reason"`
+}
+
+// PUT /repos/:username/:reponame/issues/:index/lock
+func LockIssue(c *context.APIContext, r LockIssueRequest) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	if !database.IsValidIssueLockReason(r.Reason) {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("invalid lock reason: %q", r.Reason))
+		return
+	}
+
+	if err = issue.ChangeLock(c.User, true, r.Reason); err != nil {
+		c.Error(err, "lock issue")
+		return
+	}
+	c.NoContent()
+}
+
+// DELETE /repos/:username/:reponame/issues/:index/lock
+func UnlockIssue(c *context.APIContext) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	if err = issue.ChangeLock(c.User, false, ""); err != nil {
+		c.Error(err, "unlock issue")
+		return
+	}
+	c.NoContent()
+}
+
+// PUT /repos/:username/:reponame/issues/:index/pin
+func PinIssue(c *context.APIContext) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	if err = issue.ChangePin(c.User, true); err != nil {
+		if database.IsErrPinnedIssuesLimit(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "pin issue")
+		}
+		return
+	}
+	c.NoContent()
+}
+
+// DELETE /repos/:username/:reponame/issues/:index/pin
+func UnpinIssue(c *context.APIContext) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	if err = issue.ChangePin(c.User, false); err != nil {
+		c.Error(err, "unpin issue")
+		return
+	}
+	c.NoContent()
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+)
+
+// ReactionRequest is the API message for adding or removing a reaction.
+type ReactionRequest struct {
+	// One of "+1", "-1", "laugh", "hooray", "confused", "heart", "rocket" and
+	// "eyes".
+	Type string `json:"type" binding:"Required"`
+}
+
+// getReactionTarget returns the issue ID and the comment ID that reactions
+// apply to, the comment ID is zero for the issue itself.
+func getReactionTarget(c *context.APIContext) (issueID, commentID int64) {
+	if c.Params(":id") == "" {
+		issue, err := database.GetRawIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+		if err != nil {
+			c.NotFoundOrError(err, "get issue by index")
+			return 0, 0
+		}
+		return issue.ID, 0
+	}
+
+	comment, err := database.GetCommentByID(c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get comment by ID")
+		return 0, 0
+	} else if comment.Issue.RepoID != c.Repo.Repository.ID || comment.Type != database.COMMENT_TYPE_COMMENT {
+		c.NotFound()
+		return 0, 0
+	}
+	return comment.IssueID, comment.ID
+}
+
+// GET /repos/:username/:reponame/issues/:index/reactions
+// GET /repos/:username/:reponame/issues/comments/:id/reactions
+func ListReactions(c *context.APIContext) {
+	issueID, commentID := getReactionTarget(c)
+	if c.Written() {
+		return
+	}
+
+	reactions, err := database.Handle.Reactions().Summarize(c.Req.Context(), issueID, commentID)
+	if err != nil {
+		c.Error(err, "summarize reactions")
+		return
+	}
+	c.JSONSuccess(reactions)
+}
+
+// POST /repos/:username/:reponame/issues/:index/reactions
+// POST /repos/:username/:reponame/issues/comments/:id/reactions
+func AddReaction(c *context.APIContext, r ReactionRequest) {
+	issueID, commentID := getReactionTarget(c)
+	if c.Written() {
+		return
+	}
+
+	err := database.Handle.Reactions().Add(c.Req.Context(), c.User.ID, issueID, commentID, r.Type)
+	if err != nil {
+		if database.IsErrReactionInvalidType(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "add reaction")
+		}
+		return
+	}
+
+	reactions, err := database.Handle.Reactions().Summarize(c.Req.Context(), issueID, commentID)
+	if err != nil {
+		c.Error(err, "summarize reactions")
+		return
+	}
+	c.JSON(http.StatusCreated, reactions)
+}
+
+// DELETE /repos/:username/:reponame/issues/:index/reactions
+// DELETE /repos/:username/:reponame/issues/comments/:id/reactions
+func RemoveReaction(c *context.APIContext, r ReactionRequest) {
+	issueID, commentID := getReactionTarget(c)
+	if c.Written() {
+		return
+	}
+
+	err := database.Handle.Reactions().Remove(c.Req.Context(), c.User.ID, issueID, commentID, r.Type)
+	if err != nil {
+		c.NotFoundOrError(err, "remove reaction")
+		return
+	}
+	c.NoContent()
+}
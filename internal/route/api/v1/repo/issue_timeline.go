@@ -0,0 +1,108 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"time"
+
+	api "github.com/gogs/go-gogs-client"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+)
+
+var timelineEventTypes = map[database.CommentType]string{
+	database.COMMENT_TYPE_COMMENT:         "comment",
+	database.COMMENT_TYPE_REOPEN:          "reopened",
+	database.COMMENT_TYPE_CLOSE:           "closed",
+	database.COMMENT_TYPE_ISSUE_REF:       "issue_referenced",
+	database.COMMENT_TYPE_COMMIT_REF:      "commit_referenced",
+	database.COMMENT_TYPE_COMMENT_REF:     "comment_referenced",
+	database.COMMENT_TYPE_PULL_REF:        "pull_referenced",
+	database.COMMENT_TYPE_START_TRACKING:  "timer_started",
+	database.COMMENT_TYPE_STOP_TRACKING:   "timer_stopped",
+	database.COMMENT_TYPE_ADD_TIME_MANUAL: "time_added",
+	database.COMMENT_TYPE_CANCEL_TRACKING: "timer_cancelled",
+	database.COMMENT_TYPE_ADD_LABEL:       "labeled",
+	database.COMMENT_TYPE_REMOVE_LABEL:    "unlabeled",
+	database.COMMENT_TYPE_ASSIGN:          "assigned",
+	database.COMMENT_TYPE_UNASSIGN:        "unassigned",
+	database.COMMENT_TYPE_MILESTONE:       "milestone_changed",
+	database.COMMENT_TYPE_CHANGE_TITLE:    "renamed",
+	database.COMMENT_TYPE_FORCE_PUSH:      "head_force_pushed",
+	database.COMMENT_TYPE_DELETE_BRANCH:   "head_branch_deleted",
+	database.COMMENT_TYPE_LOCK:            "locked",
+	database.COMMENT_TYPE_UNLOCK:          "unlocked",
+	database.COMMENT_TYPE_PIN:             "pinned",
+	database.COMMENT_TYPE_UNPIN:           "unpinned",
+	database.COMMENT_TYPE_TRANSFER:        "transferred",
+}
+
+type timelineEvent struct {
+	ID      int64     `json:"id"`
+	Type    string    `json:"type"`
+	Actor   *api.User `json:"actor"`
+	Created time.Time `json:"created_at"`
+	// The body of comments, the tracked time in seconds of time tracking events,
+	// the branch name of "head_branch_deleted" events, the reason of "locked"
+	// events, or the former reference of "transferred" events.
+	Body         string         `json:"body,omitempty"`
+	Label        *api.Label     `json:"label,omitempty"`
+	Assignee     *api.User      `json:"assignee,omitempty"`
+	OldMilestone *api.Milestone `json:"old_milestone,omitempty"`
+	Milestone    *api.Milestone `json:"milestone,omitempty"`
+	OldTitle     string         `json:"old_title,omitempty"`
+	NewTitle     string         `json:"new_title,omitempty"`
+	OldCommitID  string         `json:"old_commit_id,omitempty"`
+	CommitID     string         `json:"commit_id,omitempty"`
+}
+
+func toTimelineEvent(c *database.Comment) *timelineEvent {
+	event := &timelineEvent{
+		ID:          c.ID,
+		Type:        timelineEventTypes[c.Type],
+		Actor:       c.Poster.APIFormat(),
+		Created:     c.Created,
+		Body:        c.Content,
+		OldTitle:    c.OldTitle,
+		NewTitle:    c.NewTitle,
+		OldCommitID: c.OldCommitSHA,
+		CommitID:    c.CommitSHA,
+	}
+	if c.Label != nil {
+		event.Label = c.Label.APIFormat()
+	}
+	if c.Assignee != nil {
+		event.Assignee = c.Assignee.APIFormat()
+	}
+	if c.OldMilestone != nil {
+		event.OldMilestone = c.OldMilestone.APIFormat()
+	}
+	if c.Milestone != nil {
+		event.Milestone = c.Milestone.APIFormat()
+	}
+	return event
+}
+
+// GET /repos/:username/:reponame/issues/:index/timeline
+func ListIssueTimeline(c *context.APIContext) {
+	issue, err := database.GetRawIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	comments, err := database.GetCommentsByIssueID(issue.ID)
+	if err != nil {
+		c.Error(err, "get comments by issue ID")
+		return
+	}
+
+	events := make([]*timelineEvent, len(comments))
+	for i := range comments {
+		events[i] = toTimelineEvent(comments[i])
+	}
+	c.JSONSuccess(events)
+}
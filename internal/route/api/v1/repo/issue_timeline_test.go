@@ -0,0 +1,113 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gogs.io/gogs/internal/database"
+)
+
+func TestTimelineEventTypes(t *testing.T) {
+	seen := make(map[string]database.CommentType)
+	for typ := database.COMMENT_TYPE_COMMENT; typ <= database.COMMENT_TYPE_TRANSFER; typ++ {
+		name := timelineEventTypes[typ]
+		if !assert.NotEmpty(t, name, "comment type %d", typ) {
+			continue
+		}
+
+		other, ok := seen[name]
+		assert.False(t, ok, "comment types %d and %d are both %q", other, typ, name)
+		seen[name] = typ
+	}
+}
+
+func TestToTimelineEvent(t *testing.T) {
+	poster := &database.User{ID: 1, Name: "alice"}
+	tests := []struct {
+		name    string
+		comment *database.Comment
+		want    *timelineEvent
+	}{
+		{
+			name: "locked",
+			comment: &database.Comment{
+				ID:      1,
+				Type:    database.COMMENT_TYPE_LOCK,
+				Content: "spam",
+			},
+			want: &timelineEvent{
+				ID:   1,
+				Type: "locked",
+				Body: "spam",
+			},
+		},
+		{
+			name: "renamed",
+			comment: &database.Comment{
+				ID:       2,
+				Type:     database.COMMENT_TYPE_CHANGE_TITLE,
+				OldTitle: "old",
+				NewTitle: "new",
+			},
+			want: &timelineEvent{
+				ID:       2,
+				Type:     "renamed",
+				OldTitle: "old",
+				NewTitle: "new",
+			},
+		},
+		{
+			name: "head_force_pushed",
+			comment: &database.Comment{
+				ID:           3,
+				Type:         database.COMMENT_TYPE_FORCE_PUSH,
+				OldCommitSHA: "1111111",
+				CommitSHA:    "2222222",
+			},
+			want: &timelineEvent{
+				ID:          3,
+				Type:        "head_force_pushed",
+				OldCommitID: "1111111",
+				CommitID:    "2222222",
+			},
+		},
+		{
+			name: "labeled",
+			comment: &database.Comment{
+				ID:    4,
+				Type:  database.COMMENT_TYPE_ADD_LABEL,
+				Label: &database.Label{ID: 1, Name: "bug", Color: "#ee0701"},
+			},
+			want: &timelineEvent{
+				ID:    4,
+				Type:  "labeled",
+				Label: (&database.Label{ID: 1, Name: "bug", Color: "#ee0701"}).APIFormat(),
+			},
+		},
+		{
+			name: "milestone_changed",
+			comment: &database.Comment{
+				ID:        5,
+				Type:      database.COMMENT_TYPE_MILESTONE,
+				Milestone: &database.Milestone{ID: 1, Name: "v1.0"},
+			},
+			want: &timelineEvent{
+				ID:        5,
+				Type:      "milestone_changed",
+				Milestone: (&database.Milestone{ID: 1, Name: "v1.0"}).APIFormat(),
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.comment.Poster = poster
+			test.want.Actor = poster.APIFormat()
+			assert.Equal(t, test.want, toTimelineEvent(test.comment))
+		})
+	}
+}
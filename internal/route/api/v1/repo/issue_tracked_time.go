@@ -0,0 +1,257 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+	"time"
+
+	log "unknwon.dev/clog/v2"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+)
+
+type trackedTime struct {
+	ID         int64     `json:"id"`
+	Created    time.Time `json:"created"`
+	Time       int64     `json:"time"` // In seconds
+	UserName   string    `json:"user_name"`
+	IssueIndex int64     `json:"issue_index"`
+}
+
+func toTrackedTime(t *database.TrackedTime) *trackedTime {
+	return &trackedTime{
+		ID:         t.ID,
+		Created:    t.Created(),
+		Time:       t.Time,
+		UserName:   t.User.Name,
+		IssueIndex: t.Issue.Index,
+	}
+}
+
+func listTrackedTimes(c *context.APIContext, opts database.ListTrackedTimesOptions) {
+	for _, bound := range []struct {
+		name string
+		dest *time.Time
+	}{
+		{"since", &opts.Since},
+		{"before", &opts.Before},
+	} {
+		if c.Query(bound.name) == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, c.Query(bound.name))
+		if err != nil {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+			return
+		}
+		*bound.dest = t
+	}
+
+	if username := c.Query("user"); username != "" {
+		u, err := database.Handle.Users().GetByUsername(c.Req.Context(), username)
+		if err != nil {
+			if database.IsErrUserNotExist(err) {
+				c.ErrorStatus(http.StatusUnprocessableEntity, err)
+			} else {
+				c.Error(err, "get user by name")
+			}
+			return
+		}
+		opts.UserID = u.ID
+	}
+
+	times, err := database.Handle.TrackedTimes().List(c.Req.Context(), opts)
+	if err != nil {
+		c.Error(err, "list tracked times")
+		return
+	}
+	err = database.Handle.TrackedTimes().LoadAttributes(c.Req.Context(), times)
+	if err != nil {
+		c.Error(err, "load attributes")
+		return
+	}
+
+	apiTimes := make([]*trackedTime, len(times))
+	for i := range times {
+		apiTimes[i] = toTrackedTime(times[i])
+	}
+	c.JSONSuccess(apiTimes)
+}
+
+// GET /repos/:username/:reponame/times
+func ListRepoTrackedTimes(c *context.APIContext) {
+	listTrackedTimes(c,
+		database.ListTrackedTimesOptions{
+			RepoID:      c.Repo.Repository.ID,
+			MilestoneID: c.QueryInt64("milestone"),
+		},
+	)
+}
+
+// GET /repos/:username/:reponame/issues/:index/times
+func ListIssueTrackedTimes(c *context.APIContext) {
+	issue, err := database.GetRawIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	listTrackedTimes(c,
+		database.ListTrackedTimesOptions{
+			IssueID: issue.ID,
+		},
+	)
+}
+
+// AddTrackedTimeRequest is the API message for adding a tracked time.
+type AddTrackedTimeRequest struct {
+	// The tracked time in seconds.
+	Time int64 `json:"time" binding:"Required"`
+	// The time when the period of time is tracked, defaults to now.
+	Created *time.Time `json:"created"`
+}
+
+// POST /repos/:username/:reponame/issues/:index/times
+func AddIssueTrackedTime(c *context.APIContext, r AddTrackedTimeRequest) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	opts := database.AddTrackedTimeOptions{}
+	if r.Created != nil {
+		opts.Created = *r.Created
+	}
+	t, err := database.Handle.TrackedTimes().Add(c.Req.Context(), c.User.ID, issue.ID, r.Time, opts)
+	if err != nil {
+		if database.IsErrTrackedTimeInvalid(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "add tracked time")
+		}
+		return
+	}
+
+	err = database.CreateTrackingComment(c.User, c.Repo.Repository, issue, database.COMMENT_TYPE_ADD_TIME_MANUAL, t.Time)
+	if err != nil {
+		log.Error("Failed to create time tracking comment: %v", err)
+	}
+
+	t.User = c.User
+	t.Issue = issue
+	c.JSON(http.StatusCreated, toTrackedTime(t))
+}
+
+// DELETE /repos/:username/:reponame/issues/:index/times/:id
+func DeleteIssueTrackedTime(c *context.APIContext) {
+	issue, err := database.GetRawIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	t, err := database.Handle.TrackedTimes().GetByID(c.Req.Context(), c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get tracked time by ID")
+		return
+	} else if t.IssueID != issue.ID {
+		c.NotFound()
+		return
+	} else if !t.IsDeletableBy(c.User, c.Repo.IsAdmin()) {
+		c.Status(http.StatusForbidden)
+		return
+	}
+
+	err = database.Handle.TrackedTimes().DeleteByID(c.Req.Context(), t.ID)
+	if err != nil {
+		c.Error(err, "delete tracked time by ID")
+		return
+	}
+	c.NoContent()
+}
+
+// POST /repos/:username/:reponame/issues/:index/stopwatch/start
+func StartIssueStopwatch(c *context.APIContext) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	err = database.Handle.TrackedTimes().StartStopwatch(c.Req.Context(), c.User.ID, issue.ID)
+	if err != nil {
+		if database.IsErrStopwatchAlreadyStarted(err) {
+			c.ErrorStatus(http.StatusConflict, err)
+		} else {
+			c.Error(err, "start stopwatch")
+		}
+		return
+	}
+
+	err = database.CreateTrackingComment(c.User, c.Repo.Repository, issue, database.COMMENT_TYPE_START_TRACKING, 0)
+	if err != nil {
+		log.Error("Failed to create time tracking comment: %v", err)
+	}
+	c.NoContent()
+}
+
+// POST /repos/:username/:reponame/issues/:index/stopwatch/stop
+func StopIssueStopwatch(c *context.APIContext) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	t, err := database.Handle.TrackedTimes().StopStopwatch(c.Req.Context(), c.User.ID, issue.ID)
+	if err != nil {
+		c.NotFoundOrError(err, "stop stopwatch")
+		return
+	}
+
+	var seconds int64
+	if t != nil {
+		seconds = t.Time
+	}
+	err = database.CreateTrackingComment(c.User, c.Repo.Repository, issue, database.COMMENT_TYPE_STOP_TRACKING, seconds)
+	if err != nil {
+		log.Error("Failed to create time tracking comment: %v", err)
+	}
+
+	// Less than a second has elapsed, nothing is tracked.
+	if t == nil {
+		c.NoContent()
+		return
+	}
+
+	t.User = c.User
+	t.Issue = issue
+	c.JSON(http.StatusCreated, toTrackedTime(t))
+}
+
+// DELETE /repos/:username/:reponame/issues/:index/stopwatch
+func CancelIssueStopwatch(c *context.APIContext) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	}
+
+	err = database.Handle.TrackedTimes().CancelStopwatch(c.Req.Context(), c.User.ID, issue.ID)
+	if err != nil {
+		c.NotFoundOrError(err, "cancel stopwatch")
+		return
+	}
+
+	err = database.CreateTrackingComment(c.User, c.Repo.Repository, issue, database.COMMENT_TYPE_CANCEL_TRACKING, 0)
+	if err != nil {
+		log.Error("Failed to create time tracking comment: %v", err)
+	}
+	c.NoContent()
+}
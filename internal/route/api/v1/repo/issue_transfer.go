@@ -0,0 +1,58 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/route/api/v1/convert"
+)
+
+// TransferIssueRequest is the API message for transferring an issue.
+type TransferIssueRequest struct {
+	// The full name of the new repository, e.g. "owner/repo".
+	Repo string `json:"repo" binding:"Required"`
+}
+
+// POST /repos/:username/:reponame/issues/:index/transfer
+func TransferIssue(c *context.APIContext, r TransferIssueRequest) {
+	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, c.ParamsInt64(":index"))
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by index")
+		return
+	} else if issue.IsPull {
+		c.ErrorStatus(http.StatusUnprocessableEntity, errors.New("pull requests cannot be transferred"))
+		return
+	}
+
+	newRepo, err := database.GetIssueTransferTarget(c.Req.Context(), c.User, r.Repo)
+	if err != nil {
+		if database.IsErrRepoNotExist(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "get issue transfer target")
+		}
+		return
+	} else if newRepo.ID == issue.RepoID {
+		c.ErrorStatus(http.StatusUnprocessableEntity, errors.New("issue is already in the repository"))
+		return
+	}
+
+	if err = issue.Transfer(c.User, newRepo); err != nil {
+		c.Error(err, "transfer issue")
+		return
+	}
+
+	apiIssue, err := convert.ToIssue(c.Req.Context(), issue)
+	if err != nil {
+		c.Error(err, "convert issue")
+		return
+	}
+	c.JSONSuccess(apiIssue)
+}
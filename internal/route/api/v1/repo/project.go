@@ -0,0 +1,541 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	api "github.com/gogs/go-gogs-client"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/route/api/v1/convert"
+)
+
+// CreateProjectRequest is the API message for creating a project.
+type CreateProjectRequest struct {
+	Name        string `json:"name" binding:"Required;MaxSize(255)"`
+	Description string `json:"description"`
+	// Whether to create the columns "To do", "In progress" and "Done".
+	DefaultColumns bool `json:"default_columns"`
+}
+
+// EditProjectRequest is the API message for editing a project.
+type EditProjectRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	// Either "open" or "closed".
+	State *string `json:"state"`
+}
+
+// ProjectColumnRequest is the API message for creating or editing a project
+// column.
+type ProjectColumnRequest struct {
+	Name *string `json:"name"`
+	// One of "", "closed", "reopened" and "merged".
+	Automation *string `json:"automation"`
+	// Zero-based position of the column on the board.
+	Position *int `json:"position"`
+}
+
+// CreateProjectCardRequest is the API message for creating a project card.
+type CreateProjectCardRequest struct {
+	// Reference to an issue or pull request, e.g. "#1" or "owner/repo#1".
+	Issue string `json:"issue"`
+	// Note of the card when it does not reference an issue or pull request.
+	Note string `json:"note"`
+}
+
+// EditProjectCardRequest is the API message for editing the note of a project
+// card.
+type EditProjectCardRequest struct {
+	Note string `json:"note" binding:"Required"`
+}
+
+// MoveProjectCardRequest is the API message for moving a project card.
+type MoveProjectCardRequest struct {
+	ColumnID int64 `json:"column_id" binding:"Required"`
+	// Zero-based position of the card in the column.
+	Position int `json:"position"`
+}
+
+func listProjects(c *context.APIContext, opts database.ListProjectsOptions) {
+	opts.IsClosed = c.Query("state") == string(api.STATE_CLOSED)
+	projects, err := database.Handle.Projects().List(c.Req.Context(), opts)
+	if err != nil {
+		c.Error(err, "list projects")
+		return
+	}
+
+	apiProjects := make([]*convert.Project, len(projects))
+	for i := range projects {
+		apiProjects[i] = convert.ToProject(projects[i])
+	}
+	c.JSONSuccess(&apiProjects)
+}
+
+func createProject(c *context.APIContext, opts database.CreateProjectOptions, r CreateProjectRequest) {
+	opts.Name = r.Name
+	opts.Description = r.Description
+	opts.CreatorID = c.User.ID
+	opts.DefaultColumns = r.DefaultColumns
+	p, err := database.Handle.Projects().Create(c.Req.Context(), opts)
+	if err != nil {
+		c.Error(err, "create project")
+		return
+	}
+	c.JSON(http.StatusCreated, convert.ToProject(p))
+}
+
+// GET /repos/:username/:reponame/projects
+func ListRepoProjects(c *context.APIContext) {
+	listProjects(c, database.ListProjectsOptions{RepoID: c.Repo.Repository.ID})
+}
+
+// POST /repos/:username/:reponame/projects
+func CreateRepoProject(c *context.APIContext, r CreateProjectRequest) {
+	createProject(c, database.CreateProjectOptions{RepoID: c.Repo.Repository.ID}, r)
+}
+
+// orgProjectsAssignment makes sure the context organization exists and the
+// context user is a member of it, as only members can see its projects.
+func orgProjectsAssignment(c *context.APIContext) bool {
+	org := c.Org.Organization
+	if !org.IsOrganization() ||
+		(!c.User.IsAdmin && !database.IsOrganizationMember(org.ID, c.User.ID)) {
+		c.NotFound()
+		return false
+	}
+	return true
+}
+
+// GET /orgs/:orgname/projects
+func ListOrgProjects(c *context.APIContext) {
+	if !orgProjectsAssignment(c) {
+		return
+	}
+	listProjects(c, database.ListProjectsOptions{OwnerID: c.Org.Organization.ID})
+}
+
+// POST /orgs/:orgname/projects
+func CreateOrgProject(c *context.APIContext, r CreateProjectRequest) {
+	if !orgProjectsAssignment(c) {
+		return
+	}
+	createProject(c, database.CreateProjectOptions{OwnerID: c.Org.Organization.ID}, r)
+}
+
+// getProject returns the project by the ":id" parameter, or responds with 404
+// when the context user cannot read it, and with 403 when write access is
+// required but the context user does not have it.
+func getProject(c *context.APIContext, needsWrite bool) *database.Project {
+	p, err := database.Handle.Projects().GetByID(c.Req.Context(), c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get project by ID")
+		return nil
+	}
+
+	mode, err := database.ProjectAccessMode(c.Req.Context(), c.User, p)
+	if err != nil {
+		c.Error(err, "get project access mode")
+		return nil
+	}
+	if mode < database.AccessModeRead {
+		c.NotFound()
+		return nil
+	} else if needsWrite && mode < database.AccessModeWrite {
+		c.Status(http.StatusForbidden)
+		return nil
+	}
+	return p
+}
+
+// checkProjectName responds with 422 when the name of a project or a column is
+// empty or too long.
+func checkProjectName(c *context.APIContext, name string) bool {
+	if strings.TrimSpace(name) == "" {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("name cannot be empty"))
+		return false
+	} else if utf8.RuneCountInString(name) > 255 {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("name cannot be longer than 255 characters"))
+		return false
+	}
+	return true
+}
+
+// GET /projects/:id
+func GetProject(c *context.APIContext) {
+	p := getProject(c, false)
+	if c.Written() {
+		return
+	}
+	c.JSONSuccess(convert.ToProject(p))
+}
+
+// PATCH /projects/:id
+func EditProject(c *context.APIContext, r EditProjectRequest) {
+	p := getProject(c, true)
+	if c.Written() {
+		return
+	}
+
+	opts := database.UpdateProjectOptions{
+		Name:        r.Name,
+		Description: r.Description,
+	}
+	if r.State != nil {
+		switch api.StateType(*r.State) {
+		case api.STATE_OPEN, api.STATE_CLOSED:
+			isClosed := api.StateType(*r.State) == api.STATE_CLOSED
+			opts.IsClosed = &isClosed
+		default:
+			c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("invalid state: %q", *r.State))
+			return
+		}
+	}
+	if r.Name != nil && !checkProjectName(c, *r.Name) {
+		return
+	}
+
+	if err := database.Handle.Projects().Update(c.Req.Context(), p.ID, opts); err != nil {
+		c.Error(err, "update project")
+		return
+	}
+
+	p, err := database.Handle.Projects().GetByID(c.Req.Context(), p.ID)
+	if err != nil {
+		c.Error(err, "get project by ID")
+		return
+	}
+	c.JSONSuccess(convert.ToProject(p))
+}
+
+// DELETE /projects/:id
+func DeleteProject(c *context.APIContext) {
+	p := getProject(c, true)
+	if c.Written() {
+		return
+	}
+
+	if err := database.Handle.Projects().Delete(c.Req.Context(), p.ID); err != nil {
+		c.Error(err, "delete project")
+		return
+	}
+	c.NoContent()
+}
+
+// GET /projects/:id/columns
+func ListProjectColumns(c *context.APIContext) {
+	p := getProject(c, false)
+	if c.Written() {
+		return
+	}
+
+	columns, err := database.Handle.Projects().ListColumns(c.Req.Context(), p.ID)
+	if err != nil {
+		c.Error(err, "list columns")
+		return
+	}
+
+	apiColumns := make([]*convert.ProjectColumn, len(columns))
+	for i := range columns {
+		apiColumns[i] = convert.ToProjectColumn(columns[i])
+	}
+	c.JSONSuccess(&apiColumns)
+}
+
+// POST /projects/:id/columns
+func CreateProjectColumn(c *context.APIContext, r ProjectColumnRequest) {
+	p := getProject(c, true)
+	if c.Written() {
+		return
+	}
+
+	if r.Name == nil {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("name is required"))
+		return
+	} else if !checkProjectName(c, *r.Name) {
+		return
+	}
+	var automation string
+	if r.Automation != nil {
+		automation = *r.Automation
+	}
+
+	column, err := database.Handle.Projects().CreateColumn(c.Req.Context(), p.ID, *r.Name, automation)
+	if err != nil {
+		if database.IsErrProjectColumnInvalidAutomation(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "create column")
+		}
+		return
+	}
+
+	if r.Position != nil {
+		err = database.Handle.Projects().UpdateColumn(c.Req.Context(), column, database.UpdateProjectColumnOptions{
+			Position: r.Position,
+		})
+		if err != nil {
+			c.Error(err, "update column")
+			return
+		}
+		column, err = database.Handle.Projects().GetColumnByID(c.Req.Context(), p.ID, column.ID)
+		if err != nil {
+			c.Error(err, "get column by ID")
+			return
+		}
+	}
+	c.JSON(http.StatusCreated, convert.ToProjectColumn(column))
+}
+
+// PATCH /projects/:id/columns/:column_id
+func EditProjectColumn(c *context.APIContext, r ProjectColumnRequest) {
+	p := getProject(c, true)
+	if c.Written() {
+		return
+	}
+	column, err := database.Handle.Projects().GetColumnByID(c.Req.Context(), p.ID, c.ParamsInt64(":column_id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get column by ID")
+		return
+	}
+
+	if r.Name != nil && !checkProjectName(c, *r.Name) {
+		return
+	}
+
+	err = database.Handle.Projects().UpdateColumn(c.Req.Context(), column, database.UpdateProjectColumnOptions{
+		Name:       r.Name,
+		Automation: r.Automation,
+		Position:   r.Position,
+	})
+	if err != nil {
+		if database.IsErrProjectColumnInvalidAutomation(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "update column")
+		}
+		return
+	}
+
+	column, err = database.Handle.Projects().GetColumnByID(c.Req.Context(), p.ID, column.ID)
+	if err != nil {
+		c.Error(err, "get column by ID")
+		return
+	}
+	c.JSONSuccess(convert.ToProjectColumn(column))
+}
+
+// DELETE /projects/:id/columns/:column_id
+func DeleteProjectColumn(c *context.APIContext) {
+	p := getProject(c, true)
+	if c.Written() {
+		return
+	}
+	column, err := database.Handle.Projects().GetColumnByID(c.Req.Context(), p.ID, c.ParamsInt64(":column_id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get column by ID")
+		return
+	}
+
+	if err = database.Handle.Projects().DeleteColumn(c.Req.Context(), column); err != nil {
+		c.Error(err, "delete column")
+		return
+	}
+	c.NoContent()
+}
+
+// GET /projects/:id/cards
+func ListProjectCards(c *context.APIContext) {
+	p := getProject(c, false)
+	if c.Written() {
+		return
+	}
+
+	cards, err := database.Handle.Projects().ListCards(c.Req.Context(), p.ID)
+	if err != nil {
+		c.Error(err, "list cards")
+		return
+	}
+	cards, err = database.LoadProjectCardIssues(c.Req.Context(), c.User, cards)
+	if err != nil {
+		c.Error(err, "load card issues")
+		return
+	}
+
+	columnID := c.QueryInt64("column_id")
+	apiCards := make([]*convert.ProjectCard, 0, len(cards))
+	for _, card := range cards {
+		if columnID > 0 && card.ColumnID != columnID {
+			continue
+		}
+		apiCards = append(apiCards, convert.ToProjectCard(card))
+	}
+	c.JSONSuccess(&apiCards)
+}
+
+// POST /projects/:id/columns/:column_id/cards
+func CreateProjectCard(c *context.APIContext, r CreateProjectCardRequest) {
+	p := getProject(c, true)
+	if c.Written() {
+		return
+	}
+	column, err := database.Handle.Projects().GetColumnByID(c.Req.Context(), p.ID, c.ParamsInt64(":column_id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get column by ID")
+		return
+	}
+
+	opts := database.CreateProjectCardOptions{
+		Note:      strings.TrimSpace(r.Note),
+		CreatorID: c.User.ID,
+	}
+	if strings.TrimSpace(r.Issue) != "" {
+		issue, err := database.GetProjectIssueByRef(c.Req.Context(), c.User, p, r.Issue)
+		if err != nil {
+			if database.IsErrIssueNotExist(err) {
+				c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("issue %q does not exist", r.Issue))
+			} else {
+				c.Error(err, "get project issue by reference")
+			}
+			return
+		}
+		opts.IssueID = issue.ID
+	} else if opts.Note == "" {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("either issue or note is required"))
+		return
+	}
+
+	card, err := database.Handle.Projects().CreateCard(c.Req.Context(), column, opts)
+	if err != nil {
+		if database.IsErrProjectCardAlreadyExist(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "create card")
+		}
+		return
+	}
+	respondProjectCard(c, http.StatusCreated, card)
+}
+
+// getProjectCard returns the card by the ":card_id" parameter in the project,
+// or responds with 404.
+func getProjectCard(c *context.APIContext, p *database.Project) *database.ProjectCard {
+	card, err := database.Handle.Projects().GetCardByID(c.Req.Context(), p.ID, c.ParamsInt64(":card_id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get card by ID")
+		return nil
+	}
+	return card
+}
+
+// respondProjectCard responds with the card along with its issue, or with 404
+// when the context user cannot read the issue.
+func respondProjectCard(c *context.APIContext, status int, card *database.ProjectCard) {
+	cards, err := database.LoadProjectCardIssues(c.Req.Context(), c.User, []*database.ProjectCard{card})
+	if err != nil {
+		c.Error(err, "load card issues")
+		return
+	} else if len(cards) == 0 {
+		c.NotFound()
+		return
+	}
+	c.JSON(status, convert.ToProjectCard(cards[0]))
+}
+
+// GET /projects/:id/cards/:card_id
+func GetProjectCard(c *context.APIContext) {
+	p := getProject(c, false)
+	if c.Written() {
+		return
+	}
+	card := getProjectCard(c, p)
+	if c.Written() {
+		return
+	}
+	respondProjectCard(c, http.StatusOK, card)
+}
+
+// PATCH /projects/:id/cards/:card_id
+func EditProjectCard(c *context.APIContext, r EditProjectCardRequest) {
+	p := getProject(c, true)
+	if c.Written() {
+		return
+	}
+	card := getProjectCard(c, p)
+	if c.Written() {
+		return
+	}
+
+	note := strings.TrimSpace(r.Note)
+	if !card.IsNote() {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("only notes can be edited"))
+		return
+	} else if note == "" {
+		c.ErrorStatus(http.StatusUnprocessableEntity, fmt.Errorf("note cannot be empty"))
+		return
+	}
+
+	if err := database.Handle.Projects().UpdateCardNote(c.Req.Context(), card, note); err != nil {
+		c.Error(err, "update card note")
+		return
+	}
+	respondProjectCard(c, http.StatusOK, card)
+}
+
+// POST /projects/:id/cards/:card_id/move
+func MoveProjectCard(c *context.APIContext, r MoveProjectCardRequest) {
+	p := getProject(c, true)
+	if c.Written() {
+		return
+	}
+	card := getProjectCard(c, p)
+	if c.Written() {
+		return
+	}
+	column, err := database.Handle.Projects().GetColumnByID(c.Req.Context(), p.ID, r.ColumnID)
+	if err != nil {
+		if database.IsErrProjectColumnNotExist(err) {
+			c.ErrorStatus(http.StatusUnprocessableEntity, err)
+		} else {
+			c.Error(err, "get column by ID")
+		}
+		return
+	}
+
+	if err = database.Handle.Projects().MoveCard(c.Req.Context(), card, column.ID, r.Position); err != nil {
+		c.Error(err, "move card")
+		return
+	}
+
+	card, err = database.Handle.Projects().GetCardByID(c.Req.Context(), p.ID, card.ID)
+	if err != nil {
+		c.Error(err, "get card by ID")
+		return
+	}
+	respondProjectCard(c, http.StatusOK, card)
+}
+
+// DELETE /projects/:id/cards/:card_id
+func DeleteProjectCard(c *context.APIContext) {
+	p := getProject(c, true)
+	if c.Written() {
+		return
+	}
+	card := getProjectCard(c, p)
+	if c.Written() {
+		return
+	}
+
+	if err := database.Handle.Projects().DeleteCard(c.Req.Context(), card); err != nil {
+		c.Error(err, "delete card")
+		return
+	}
+	c.NoContent()
+}
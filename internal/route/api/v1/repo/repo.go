@@ -237,6 +237,11 @@ func Migrate(c *context.APIContext, f form.MigrateRepo) {
 		}
 	}
 
+	if !f.IsValidService() {
+		c.ErrorStatus(http.StatusUnprocessableEntity, errors.New("Unsupported service or the clone address is not an HTTP/HTTPS URL."))
+		return
+	}
+
 	remoteAddr, err := f.ParseRemoteAddr(c.User)
 	if err != nil {
 		if database.IsErrInvalidCloneAddr(err) {
@@ -282,6 +287,11 @@ func Migrate(c *context.APIContext, f form.MigrateRepo) {
 	}
 
 	log.Trace("Repository migrated: %s/%s", ctxUser.Name, f.RepoName)
+	if opts, ok := f.RepoMigrationOptions(c.User.ID); ok {
+		if err = database.StartRepoMigration(c.Req.Context(), repo.ID, opts); err != nil {
+			log.Error("Failed to start migration of repository data [repo_id: %d]: %v", repo.ID, err)
+		}
+	}
 	c.JSON(201, repo.APIFormatLegacy(&api.Permission{Admin: true, Push: true, Pull: true}))
 }
 
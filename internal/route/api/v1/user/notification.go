@@ -0,0 +1,156 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/route/api/v1/convert"
+)
+
+// parseNotificationTime parses the time in the RFC 3339 format from the query
+// parameter, and returns 0 when the parameter is empty.
+func parseNotificationTime(c *context.APIContext, name string) (int64, bool) {
+	if c.Query(name) == "" {
+		return 0, true
+	}
+	t, err := time.Parse(time.RFC3339, c.Query(name))
+	if err != nil {
+		c.ErrorStatus(http.StatusUnprocessableEntity, errors.Wrapf(err, "parse %q", name))
+		return 0, false
+	}
+	return t.Unix(), true
+}
+
+func listNotifications(c *context.APIContext, repoID int64) {
+	sinceUnix, ok := parseNotificationTime(c, "since")
+	if !ok {
+		return
+	}
+
+	opts := database.ListNotificationsOptions{
+		RepoID:    repoID,
+		SinceUnix: sinceUnix,
+		Page:      c.QueryInt("page"),
+		PageSize:  convert.ToCorrectPageSize(c.QueryInt("limit")),
+	}
+	// Only unread and pinned notifications are included by default
+	if !c.QueryBool("all") {
+		opts.Statuses = []database.NotificationStatus{database.NotificationStatusUnread, database.NotificationStatusPinned}
+	}
+	notifications, err := database.Handle.Notifications().List(c.Req.Context(), c.User.ID, opts)
+	if err != nil {
+		c.Error(err, "list notifications")
+		return
+	}
+	notifications, err = database.LoadNotificationIssues(c.Req.Context(), c.User, notifications)
+	if err != nil {
+		c.Error(err, "load notification issues")
+		return
+	}
+
+	threads := make([]*convert.NotificationThread, len(notifications))
+	for i := range notifications {
+		threads[i] = convert.ToNotificationThread(notifications[i])
+	}
+	c.JSONSuccess(&threads)
+}
+
+func markNotificationsRead(c *context.APIContext, repoID int64) {
+	lastReadUnix, ok := parseNotificationTime(c, "last_read_at")
+	if !ok {
+		return
+	}
+
+	err := database.Handle.Notifications().MarkAllRead(c.Req.Context(), c.User.ID, repoID, lastReadUnix)
+	if err != nil {
+		c.Error(err, "mark all notifications read")
+		return
+	}
+	c.NoContent()
+}
+
+func ListNotifications(c *context.APIContext) {
+	listNotifications(c, 0)
+}
+
+func MarkNotificationsRead(c *context.APIContext) {
+	markNotificationsRead(c, 0)
+}
+
+func ListRepoNotifications(c *context.APIContext) {
+	listNotifications(c, c.Repo.Repository.ID)
+}
+
+func MarkRepoNotificationsRead(c *context.APIContext) {
+	markNotificationsRead(c, c.Repo.Repository.ID)
+}
+
+func CountNewNotifications(c *context.APIContext) {
+	count, err := database.Handle.Notifications().CountUnread(c.Req.Context(), c.User.ID)
+	if err != nil {
+		c.Error(err, "count unread notifications")
+		return
+	}
+	c.JSONSuccess(map[string]int64{"new": count})
+}
+
+// getNotificationThread returns the notification thread with the ID in the
+// URL, whose issue is still accessible by the user.
+func getNotificationThread(c *context.APIContext) *database.Notification {
+	n, err := database.Handle.Notifications().GetByID(c.Req.Context(), c.User.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get notification by ID")
+		return nil
+	}
+
+	notifications, err := database.LoadNotificationIssues(c.Req.Context(), c.User, []*database.Notification{n})
+	if err != nil {
+		c.Error(err, "load notification issues")
+		return nil
+	} else if len(notifications) == 0 {
+		c.NotFound()
+		return nil
+	}
+	return notifications[0]
+}
+
+func GetNotificationThread(c *context.APIContext) {
+	n := getNotificationThread(c)
+	if c.Written() {
+		return
+	}
+	c.JSONSuccess(convert.ToNotificationThread(n))
+}
+
+func UpdateNotificationThread(c *context.APIContext) {
+	n := getNotificationThread(c)
+	if c.Written() {
+		return
+	}
+
+	status := database.NotificationStatusRead
+	if c.Query("to-status") != "" {
+		var ok bool
+		status, ok = database.ParseNotificationStatus(c.Query("to-status"))
+		if !ok {
+			c.ErrorStatus(http.StatusUnprocessableEntity, errors.Errorf("unknown status %q", c.Query("to-status")))
+			return
+		}
+	}
+
+	err := database.Handle.Notifications().SetStatus(c.Req.Context(), c.User.ID, n.ID, status)
+	if err != nil {
+		c.Error(err, "set notification status")
+		return
+	}
+	n.Status = status
+	c.JSONSuccess(convert.ToNotificationThread(n))
+}
@@ -85,6 +85,7 @@ func GlobalInit(customConf string) error {
 		database.InitSyncMirrors()
 		database.InitDeliverHooks()
 		database.InitTestPullRequests()
+		database.ResumeRepoMigrations()
 	}
 	if conf.HasMinWinSvc {
 		log.Info("Builtin Windows Service is supported")
@@ -76,7 +76,7 @@ func (h *basicHandler) serveDownload(c *macaron.Context, repo *database.Reposito
 }
 
 // PUT /{owner}/{repo}.git/info/lfs/object/basic/{oid}
-func (h *basicHandler) serveUpload(c *macaron.Context, repo *database.Repository, oid lfsutil.OID) {
+func (h *basicHandler) serveUpload(c *macaron.Context, owner *database.User, repo *database.Repository, oid lfsutil.OID) {
 	// NOTE: LFS client will retry upload the same object if there was a partial failure,
 	// therefore we would like to skip ones that already exist.
 	_, err := h.store.GetLFSObjectByOID(c.Req.Context(), repo.ID, oid)
@@ -92,6 +92,16 @@ func (h *basicHandler) serveUpload(c *macaron.Context, repo *database.Repository
 		return
 	}
 
+	// The content length is unknown (i.e. -1) for chunked uploads, the quota is
+	// checked against the actual size again once the object is uploaded.
+	size := c.Req.ContentLength
+	if size < 0 {
+		size = 0
+	}
+	if !h.checkStorageQuota(c, owner, size) {
+		return
+	}
+
 	s := h.DefaultStorager()
 	written, err := s.Upload(oid, c.Req.Request.Body)
 	if err != nil {
@@ -106,6 +116,14 @@ func (h *basicHandler) serveUpload(c *macaron.Context, repo *database.Repository
 		return
 	}
 
+	if written != size && !h.checkStorageQuota(c, owner, written) {
+		err = s.Delete(oid)
+		if err != nil {
+			log.Error("Failed to delete object [storage: %s, oid: %s]: %v", s.Storage(), oid, err)
+		}
+		return
+	}
+
 	err = h.store.CreateLFSObject(c.Req.Context(), repo.ID, oid, written, s.Storage())
 	if err != nil {
 		// NOTE: It is OK to leave the file when the whole operation failed
@@ -120,6 +138,26 @@ func (h *basicHandler) serveUpload(c *macaron.Context, repo *database.Repository
 	log.Trace("[LFS] Object created %q", oid)
 }
 
+// checkStorageQuota checks whether adding the size of LFS objects would exceed
+// the storage quota of the owner. It writes the error response and returns
+// false if the check is not passed.
+func (h *basicHandler) checkStorageQuota(c *macaron.Context, owner *database.User, size int64) bool {
+	err := h.store.CheckStorageQuota(c.Req.Context(), owner, database.QuotaTypeLFS, size)
+	if err == nil {
+		return true
+	}
+
+	if database.IsErrQuotaExceeded(err) {
+		responseJSON(c.Resp, http.StatusInsufficientStorage, responseError{
+			Message: "Storage quota exceeded",
+		})
+	} else {
+		internalServerError(c.Resp)
+		log.Error("Failed to check storage quota [owner_id: %d]: %v", owner.ID, err)
+	}
+	return false
+}
+
 // POST /{owner}/{repo}.git/info/lfs/object/basic/verify
 func (h *basicHandler) serveVerify(c *macaron.Context, repo *database.Repository) {
 	var request basicVerifyRequest
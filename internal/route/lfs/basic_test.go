@@ -6,6 +6,7 @@ package lfs
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -41,6 +42,11 @@ func (s *mockStorage) Download(_ lfsutil.OID, w io.Writer) error {
 	return err
 }
 
+func (s *mockStorage) Delete(lfsutil.OID) error {
+	s.buf.Reset()
+	return nil
+}
+
 func TestBasicHandler_serveDownload(t *testing.T) {
 	s := &mockStorage{}
 	basic := &basicHandler{
@@ -150,6 +156,7 @@ func TestBasicHandler_serveUpload(t *testing.T) {
 	m := macaron.New()
 	m.Use(macaron.Renderer())
 	m.Use(func(c *macaron.Context) {
+		c.Map(&database.User{Name: "owner"})
 		c.Map(&database.Repository{Name: "repo"})
 		c.Map(lfsutil.OID("ef797c8118f02dfb649607dd5d3f8c7623048c9c063d532cc95c5ed7a898a64f"))
 	})
@@ -157,9 +164,11 @@ func TestBasicHandler_serveUpload(t *testing.T) {
 
 	tests := []struct {
 		name          string
+		chunked       bool
 		mockStore     func() *MockStore
 		expStatusCode int
 		expBody       string
+		expDeleted    bool
 	}{
 		{
 			name: "object already exists",
@@ -179,13 +188,46 @@ func TestBasicHandler_serveUpload(t *testing.T) {
 			},
 			expStatusCode: http.StatusOK,
 		},
+		{
+			name: "storage quota exceeded",
+			mockStore: func() *MockStore {
+				mockStore := NewMockStore()
+				mockStore.GetLFSObjectByOIDFunc.SetDefaultReturn(nil, database.ErrLFSObjectNotExist{})
+				mockStore.CheckStorageQuotaFunc.SetDefaultReturn(database.ErrQuotaExceeded{Type: database.QuotaTypeLFS})
+				return mockStore
+			},
+			expStatusCode: http.StatusInsufficientStorage,
+			expBody:       `{"message":"Storage quota exceeded"}` + "\n",
+		},
+		{
+			name:    "storage quota exceeded by chunked upload",
+			chunked: true,
+			mockStore: func() *MockStore {
+				mockStore := NewMockStore()
+				mockStore.GetLFSObjectByOIDFunc.SetDefaultReturn(nil, database.ErrLFSObjectNotExist{})
+				mockStore.CheckStorageQuotaFunc.SetDefaultHook(func(_ context.Context, _ *database.User, _ database.QuotaType, size int64) error {
+					if size > 0 {
+						return database.ErrQuotaExceeded{Type: database.QuotaTypeLFS}
+					}
+					return nil
+				})
+				return mockStore
+			},
+			expStatusCode: http.StatusInsufficientStorage,
+			expBody:       `{"message":"Storage quota exceeded"}` + "\n",
+			expDeleted:    true,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			basic.store = test.mockStore()
+			s.buf.Reset()
 
 			r, err := http.NewRequest("PUT", "/", strings.NewReader("Hello world!"))
 			require.NoError(t, err)
+			if test.chunked {
+				r.ContentLength = -1
+			}
 
 			rr := httptest.NewRecorder()
 			m.ServeHTTP(rr, r)
@@ -196,6 +238,10 @@ func TestBasicHandler_serveUpload(t *testing.T) {
 			body, err := io.ReadAll(resp.Body)
 			require.NoError(t, err)
 			assert.Equal(t, test.expBody, string(body))
+
+			if test.expDeleted {
+				assert.Zero(t, s.buf.Len())
+			}
 		})
 	}
 }
@@ -24,6 +24,9 @@ type MockStore struct {
 	// object controlling the behavior of the method
 	// AuthorizeRepositoryAccess.
 	AuthorizeRepositoryAccessFunc *StoreAuthorizeRepositoryAccessFunc
+	// CheckStorageQuotaFunc is an instance of a mock function object
+	// controlling the behavior of the method CheckStorageQuota.
+	CheckStorageQuotaFunc *StoreCheckStorageQuotaFunc
 	// CreateLFSObjectFunc is an instance of a mock function object
 	// controlling the behavior of the method CreateLFSObject.
 	CreateLFSObjectFunc *StoreCreateLFSObjectFunc
@@ -70,6 +73,11 @@ func NewMockStore() *MockStore {
 				return
 			},
 		},
+		CheckStorageQuotaFunc: &StoreCheckStorageQuotaFunc{
+			defaultHook: func(context.Context, *database.User, database.QuotaType, int64) (r0 error) {
+				return
+			},
+		},
 		CreateLFSObjectFunc: &StoreCreateLFSObjectFunc{
 			defaultHook: func(context.Context, int64, lfsutil.OID, int64, lfsutil.Storage) (r0 error) {
 				return
@@ -137,6 +145,11 @@ func NewStrictMockStore() *MockStore {
 				panic("unexpected invocation of MockStore.AuthorizeRepositoryAccess")
 			},
 		},
+		CheckStorageQuotaFunc: &StoreCheckStorageQuotaFunc{
+			defaultHook: func(context.Context, *database.User, database.QuotaType, int64) error {
+				panic("unexpected invocation of MockStore.CheckStorageQuota")
+			},
+		},
 		CreateLFSObjectFunc: &StoreCreateLFSObjectFunc{
 			defaultHook: func(context.Context, int64, lfsutil.OID, int64, lfsutil.Storage) error {
 				panic("unexpected invocation of MockStore.CreateLFSObject")
@@ -200,6 +213,9 @@ func NewMockStoreFrom(i Store) *MockStore {
 		AuthorizeRepositoryAccessFunc: &StoreAuthorizeRepositoryAccessFunc{
 			defaultHook: i.AuthorizeRepositoryAccess,
 		},
+		CheckStorageQuotaFunc: &StoreCheckStorageQuotaFunc{
+			defaultHook: i.CheckStorageQuota,
+		},
 		CreateLFSObjectFunc: &StoreCreateLFSObjectFunc{
 			defaultHook: i.CreateLFSObject,
 		},
@@ -463,6 +479,117 @@ func (c StoreAuthorizeRepositoryAccessFuncCall) Results() []interface{} {
 	return []interface{}{c.Result0}
 }
 
+// StoreCheckStorageQuotaFunc describes the behavior when the
+// CheckStorageQuota method of the parent MockStore instance is invoked.
+type StoreCheckStorageQuotaFunc struct {
+	defaultHook func(context.Context, *database.User, database.QuotaType, int64) error
+	hooks       []func(context.Context, *database.User, database.QuotaType, int64) error
+	history     []StoreCheckStorageQuotaFuncCall
+	mutex       sync.Mutex
+}
+
+// CheckStorageQuota delegates to the next hook function in the queue and
+// stores the parameter and result values of this invocation.
+func (m *MockStore) CheckStorageQuota(v0 context.Context, v1 *database.User, v2 database.QuotaType, v3 int64) error {
+	r0 := m.CheckStorageQuotaFunc.nextHook()(v0, v1, v2, v3)
+	m.CheckStorageQuotaFunc.appendCall(StoreCheckStorageQuotaFuncCall{v0, v1, v2, v3, r0})
+	return r0
+}
+
+// SetDefaultHook sets function that is called when the CheckStorageQuota
+// method of the parent MockStore instance is invoked and the hook queue is
+// empty.
+func (f *StoreCheckStorageQuotaFunc) SetDefaultHook(hook func(context.Context, *database.User, database.QuotaType, int64) error) {
+	f.defaultHook = hook
+}
+
+// PushHook adds a function to the end of hook queue. Each invocation of the
+// CheckStorageQuota method of the parent MockStore instance invokes the
+// hook at the front of the queue and discards it. After the queue is empty,
+// the default hook function is invoked for any future action.
+func (f *StoreCheckStorageQuotaFunc) PushHook(hook func(context.Context, *database.User, database.QuotaType, int64) error) {
+	f.mutex.Lock()
+	f.hooks = append(f.hooks, hook)
+	f.mutex.Unlock()
+}
+
+// SetDefaultReturn calls SetDefaultHook with a function that returns the
+// given values.
+func (f *StoreCheckStorageQuotaFunc) SetDefaultReturn(r0 error) {
+	f.SetDefaultHook(func(context.Context, *database.User, database.QuotaType, int64) error {
+		return r0
+	})
+}
+
+// PushReturn calls PushHook with a function that returns the given values.
+func (f *StoreCheckStorageQuotaFunc) PushReturn(r0 error) {
+	f.PushHook(func(context.Context, *database.User, database.QuotaType, int64) error {
+		return r0
+	})
+}
+
+func (f *StoreCheckStorageQuotaFunc) nextHook() func(context.Context, *database.User, database.QuotaType, int64) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if len(f.hooks) == 0 {
+		return f.defaultHook
+	}
+
+	hook := f.hooks[0]
+	f.hooks = f.hooks[1:]
+	return hook
+}
+
+func (f *StoreCheckStorageQuotaFunc) appendCall(r0 StoreCheckStorageQuotaFuncCall) {
+	f.mutex.Lock()
+	f.history = append(f.history, r0)
+	f.mutex.Unlock()
+}
+
+// History returns a sequence of StoreCheckStorageQuotaFuncCall objects
+// describing the invocations of this function.
+func (f *StoreCheckStorageQuotaFunc) History() []StoreCheckStorageQuotaFuncCall {
+	f.mutex.Lock()
+	history := make([]StoreCheckStorageQuotaFuncCall, len(f.history))
+	copy(history, f.history)
+	f.mutex.Unlock()
+
+	return history
+}
+
+// StoreCheckStorageQuotaFuncCall is an object that describes an invocation
+// of method CheckStorageQuota on an instance of MockStore.
+type StoreCheckStorageQuotaFuncCall struct {
+	// Arg0 is the value of the 1st argument passed to this method
+	// invocation.
+	Arg0 context.Context
+	// Arg1 is the value of the 2nd argument passed to this method
+	// invocation.
+	Arg1 *database.User
+	// Arg2 is the value of the 3rd argument passed to this method
+	// invocation.
+	Arg2 database.QuotaType
+	// Arg3 is the value of the 4th argument passed to this method
+	// invocation.
+	Arg3 int64
+	// Result0 is the value of the 1st result returned from this method
+	// invocation.
+	Result0 error
+}
+
+// Args returns an interface slice containing the arguments of this
+// invocation.
+func (c StoreCheckStorageQuotaFuncCall) Args() []interface{} {
+	return []interface{}{c.Arg0, c.Arg1, c.Arg2, c.Arg3}
+}
+
+// Results returns an interface slice containing the results of this
+// invocation.
+func (c StoreCheckStorageQuotaFuncCall) Results() []interface{} {
+	return []interface{}{c.Result0}
+}
+
 // StoreCreateLFSObjectFunc describes the behavior when the CreateLFSObject
 // method of the parent MockStore instance is invoked.
 type StoreCreateLFSObjectFunc struct {
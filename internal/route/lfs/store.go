@@ -27,6 +27,11 @@ type Store interface {
 	// list could have fewer elements if some oids were not found.
 	GetLFSObjectsByOIDs(ctx context.Context, repoID int64, oids ...lfsutil.OID) ([]*database.LFSObject, error)
 
+	// CheckStorageQuota returns database.ErrQuotaExceeded if adding the given
+	// size (in bytes) of the given type of storage would exceed the storage quota
+	// of the user or organization.
+	CheckStorageQuota(ctx context.Context, user *database.User, typ database.QuotaType, size int64) error
+
 	// AuthorizeRepositoryAccess returns true if the user has as good as desired
 	// access mode to the repository.
 	AuthorizeRepositoryAccess(ctx context.Context, userID, repoID int64, desired database.AccessMode, opts database.AccessModeOptions) bool
@@ -92,6 +97,10 @@ func (*store) GetLFSObjectsByOIDs(ctx context.Context, repoID int64, oids ...lfs
 	return database.Handle.LFS().GetObjectsByOIDs(ctx, repoID, oids...)
 }
 
+func (*store) CheckStorageQuota(ctx context.Context, user *database.User, typ database.QuotaType, size int64) error {
+	return database.Handle.Quotas().Check(ctx, user, typ, size)
+}
+
 func (*store) AuthorizeRepositoryAccess(ctx context.Context, userID, repoID int64, desired database.AccessMode, opts database.AccessModeOptions) bool {
 	return database.Handle.Permissions().Authorize(ctx, userID, repoID, desired, opts)
 }
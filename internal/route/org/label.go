@@ -0,0 +1,80 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/form"
+)
+
+const (
+	LABELS = "org/issue/labels"
+)
+
+func Labels(c *context.Context) {
+	c.Data["Title"] = c.Tr("repo.labels")
+	c.Data["PageIsOrgLabels"] = true
+	c.Data["RequireMinicolors"] = true
+
+	labels, err := database.GetLabelsByOrgID(c.Org.Organization.ID)
+	if err != nil {
+		c.Error(err, "get labels by organization ID")
+		return
+	}
+	for _, l := range labels {
+		l.CalOpenIssues()
+	}
+	c.Data["Labels"] = labels
+	c.Data["NumLabels"] = len(labels)
+	c.Success(LABELS)
+}
+
+func NewLabel(c *context.Context, f form.CreateLabel) {
+	if c.HasError() {
+		c.Flash.Error(c.Data["ErrorMsg"].(string))
+		c.Redirect(c.Org.OrgLink + "/labels")
+		return
+	}
+
+	l := &database.Label{
+		OrgID: c.Org.Organization.ID,
+		Name:  f.Title,
+		Color: f.Color,
+	}
+	if err := database.NewLabels(l); err != nil {
+		c.Error(err, "new labels")
+		return
+	}
+	c.Redirect(c.Org.OrgLink + "/labels")
+}
+
+func UpdateLabel(c *context.Context, f form.CreateLabel) {
+	l, err := database.GetLabelOfOrgByID(c.Org.Organization.ID, f.ID)
+	if err != nil {
+		c.NotFoundOrError(err, "get label of organization by ID")
+		return
+	}
+
+	l.Name = f.Title
+	l.Color = f.Color
+	if err := database.UpdateLabel(l); err != nil {
+		c.Error(err, "update label")
+		return
+	}
+	c.Redirect(c.Org.OrgLink + "/labels")
+}
+
+func DeleteLabel(c *context.Context) {
+	if err := database.DeleteOrgLabel(c.Org.Organization.ID, c.QueryInt64("id")); err != nil {
+		c.Flash.Error("DeleteOrgLabel: " + err.Error())
+	} else {
+		c.Flash.Success(c.Tr("repo.issues.label_deletion_success"))
+	}
+
+	c.JSONSuccess(map[string]any{
+		"redirect": c.Org.OrgLink + "/labels",
+	})
+}
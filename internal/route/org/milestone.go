@@ -0,0 +1,305 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	"time"
+
+	"github.com/unknwon/paginater"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/form"
+	"gogs.io/gogs/internal/markup"
+)
+
+const (
+	MILESTONES     = "org/issue/milestones"
+	MILESTONE_NEW  = "org/issue/milestone_new"
+	MILESTONE_VIEW = "org/issue/milestone"
+)
+
+func Milestones(c *context.Context) {
+	c.Data["Title"] = c.Tr("repo.milestones")
+	c.Data["PageIsOrgMilestones"] = true
+
+	org := c.Org.Organization
+	isShowClosed := c.Query("state") == "closed"
+	openCount, closedCount := database.OrgMilestoneStats(org.ID)
+	c.Data["OpenCount"] = openCount
+	c.Data["ClosedCount"] = closedCount
+
+	page := c.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	var total int
+	if !isShowClosed {
+		total = int(openCount)
+	} else {
+		total = int(closedCount)
+	}
+	c.Data["Page"] = paginater.New(total, conf.UI.IssuePagingNum, page, 5)
+
+	miles, err := database.GetOrgMilestones(org.ID, page, isShowClosed)
+	if err != nil {
+		c.Error(err, "get organization milestones")
+		return
+	}
+	for _, m := range miles {
+		m.NumOpenIssues = int(m.CountIssues(false, false))
+		m.NumClosedIssues = int(m.CountIssues(true, false))
+		if m.NumOpenIssues+m.NumClosedIssues > 0 {
+			m.Completeness = m.NumClosedIssues * 100 / (m.NumOpenIssues + m.NumClosedIssues)
+		}
+		m.RenderedContent = string(markup.Markdown(m.Content, c.Org.OrgLink, nil))
+	}
+	c.Data["Milestones"] = miles
+
+	if isShowClosed {
+		c.Data["State"] = "closed"
+	} else {
+		c.Data["State"] = "open"
+	}
+
+	c.Data["IsShowClosed"] = isShowClosed
+	c.Success(MILESTONES)
+}
+
+// ViewMilestone shows issues of the organization milestone across all
+// repositories of the organization that the user has access to.
+func ViewMilestone(c *context.Context) {
+	org := c.Org.Organization
+	m, err := database.GetMilestoneByOrgID(org.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get milestone by organization ID")
+		return
+	}
+	m.RenderedContent = string(markup.Markdown(m.Content, c.Org.OrgLink, nil))
+	c.Data["Title"] = m.Name
+	c.Data["PageIsOrgMilestones"] = true
+	c.Data["Milestone"] = m
+
+	repos, _, err := org.GetUserRepositories(c.User.ID, 1, org.NumRepos)
+	if err != nil {
+		c.Error(err, "get user repositories")
+		return
+	}
+	repoIDs := make([]int64, 0, len(repos))
+	for _, repo := range repos {
+		repoIDs = append(repoIDs, repo.ID)
+	}
+	repoIDs, err = database.FilterRepositoryWithIssues(repoIDs)
+	if err != nil {
+		c.Error(err, "filter repositories with issues")
+		return
+	}
+	// An empty list of repositories must not list issues of all repositories
+	if repoIDs == nil {
+		repoIDs = []int64{}
+	}
+
+	opts := &database.IssuesOptions{
+		RepoIDs:     repoIDs,
+		MilestoneID: m.ID,
+	}
+	openCount, err := database.IssuesCount(opts)
+	if err != nil {
+		c.Error(err, "count open issues")
+		return
+	}
+	opts.IsClosed = true
+	closedCount, err := database.IssuesCount(opts)
+	if err != nil {
+		c.Error(err, "count closed issues")
+		return
+	}
+	c.Data["OpenCount"] = openCount
+	c.Data["ClosedCount"] = closedCount
+
+	isShowClosed := c.Query("state") == "closed"
+	page := c.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+
+	var total int
+	if !isShowClosed {
+		total = int(openCount)
+	} else {
+		total = int(closedCount)
+	}
+	c.Data["Page"] = paginater.New(total, conf.UI.IssuePagingNum, page, 5)
+
+	opts.IsClosed = isShowClosed
+	opts.Page = page
+	issues, err := database.Issues(opts)
+	if err != nil {
+		c.Error(err, "list issues")
+		return
+	}
+	for _, issue := range issues {
+		if err = issue.Repo.GetOwner(); err != nil {
+			c.Error(err, "get owner")
+			return
+		}
+	}
+	c.Data["Issues"] = issues
+
+	if isShowClosed {
+		c.Data["State"] = "closed"
+	} else {
+		c.Data["State"] = "open"
+	}
+
+	c.Data["IsShowClosed"] = isShowClosed
+	c.Success(MILESTONE_VIEW)
+}
+
+func NewMilestone(c *context.Context) {
+	c.Data["Title"] = c.Tr("repo.milestones.new")
+	c.Data["PageIsOrgMilestones"] = true
+	c.Data["RequireDatetimepicker"] = true
+	c.Data["DateLang"] = conf.I18n.DateLang(c.Locale.Language())
+	c.Success(MILESTONE_NEW)
+}
+
+func NewMilestonePost(c *context.Context, f form.CreateMilestone) {
+	c.Data["Title"] = c.Tr("repo.milestones.new")
+	c.Data["PageIsOrgMilestones"] = true
+	c.Data["RequireDatetimepicker"] = true
+	c.Data["DateLang"] = conf.I18n.DateLang(c.Locale.Language())
+
+	if c.HasError() {
+		c.Success(MILESTONE_NEW)
+		return
+	}
+
+	if f.Deadline == "" {
+		f.Deadline = "9999-12-31"
+	}
+	deadline, err := time.ParseInLocation("2006-01-02", f.Deadline, time.Local)
+	if err != nil {
+		c.Data["Err_Deadline"] = true
+		c.RenderWithErr(c.Tr("repo.milestones.invalid_due_date_format"), MILESTONE_NEW, &f)
+		return
+	}
+
+	if err = database.NewMilestone(&database.Milestone{
+		OrgID:    c.Org.Organization.ID,
+		Name:     f.Title,
+		Content:  f.Content,
+		Deadline: deadline,
+	}); err != nil {
+		c.Error(err, "new milestone")
+		return
+	}
+
+	c.Flash.Success(c.Tr("repo.milestones.create_success", f.Title))
+	c.Redirect(c.Org.OrgLink + "/milestones")
+}
+
+func EditMilestone(c *context.Context) {
+	c.Data["Title"] = c.Tr("repo.milestones.edit")
+	c.Data["PageIsOrgMilestones"] = true
+	c.Data["PageIsEditMilestone"] = true
+	c.Data["RequireDatetimepicker"] = true
+	c.Data["DateLang"] = conf.I18n.DateLang(c.Locale.Language())
+
+	m, err := database.GetMilestoneByOrgID(c.Org.Organization.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get milestone by organization ID")
+		return
+	}
+	c.Data["title"] = m.Name
+	c.Data["content"] = m.Content
+	if len(m.DeadlineString) > 0 {
+		c.Data["deadline"] = m.DeadlineString
+	}
+	c.Success(MILESTONE_NEW)
+}
+
+func EditMilestonePost(c *context.Context, f form.CreateMilestone) {
+	c.Data["Title"] = c.Tr("repo.milestones.edit")
+	c.Data["PageIsOrgMilestones"] = true
+	c.Data["PageIsEditMilestone"] = true
+	c.Data["RequireDatetimepicker"] = true
+	c.Data["DateLang"] = conf.I18n.DateLang(c.Locale.Language())
+
+	if c.HasError() {
+		c.Success(MILESTONE_NEW)
+		return
+	}
+
+	if f.Deadline == "" {
+		f.Deadline = "9999-12-31"
+	}
+	deadline, err := time.ParseInLocation("2006-01-02", f.Deadline, time.Local)
+	if err != nil {
+		c.Data["Err_Deadline"] = true
+		c.RenderWithErr(c.Tr("repo.milestones.invalid_due_date_format"), MILESTONE_NEW, &f)
+		return
+	}
+
+	m, err := database.GetMilestoneByOrgID(c.Org.Organization.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get milestone by organization ID")
+		return
+	}
+	m.Name = f.Title
+	m.Content = f.Content
+	m.Deadline = deadline
+	if err = database.UpdateMilestone(m); err != nil {
+		c.Error(err, "update milestone")
+		return
+	}
+
+	c.Flash.Success(c.Tr("repo.milestones.edit_success", m.Name))
+	c.Redirect(c.Org.OrgLink + "/milestones")
+}
+
+func ChangeMilestoneStatus(c *context.Context) {
+	m, err := database.GetMilestoneByOrgID(c.Org.Organization.ID, c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get milestone by organization ID")
+		return
+	}
+
+	state := "open"
+	switch c.Params(":action") {
+	case "open":
+		if m.IsClosed {
+			if err = database.ChangeMilestoneStatus(m, false); err != nil {
+				c.Error(err, "change milestone status to open")
+				return
+			}
+		}
+	case "close":
+		if !m.IsClosed {
+			m.ClosedDate = time.Now()
+			if err = database.ChangeMilestoneStatus(m, true); err != nil {
+				c.Error(err, "change milestone status to closed")
+				return
+			}
+		}
+		state = "closed"
+	}
+
+	c.Redirect(c.Org.OrgLink + "/milestones?state=" + state)
+}
+
+func DeleteMilestone(c *context.Context) {
+	if err := database.DeleteMilestoneOfOrgByID(c.Org.Organization.ID, c.QueryInt64("id")); err != nil {
+		c.Flash.Error("DeleteMilestoneOfOrgByID: " + err.Error())
+	} else {
+		c.Flash.Success(c.Tr("repo.milestones.deletion_success"))
+	}
+
+	c.JSONSuccess(map[string]any{
+		"redirect": c.Org.OrgLink + "/milestones",
+	})
+}
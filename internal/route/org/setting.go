@@ -23,12 +23,35 @@ const (
 func Settings(c *context.Context) {
 	c.Title("org.settings")
 	c.Data["PageIsSettingsOptions"] = true
+	if !prepareStorageUsage(c) {
+		return
+	}
 	c.Success(SETTINGS_OPTIONS)
 }
 
+// prepareStorageUsage sets the storage usage of the organization for site
+// admins to compare with storage quotas. It returns false if an error occurred
+// and a response has been written.
+func prepareStorageUsage(c *context.Context) bool {
+	if !c.User.IsAdmin {
+		return true
+	}
+
+	usage, err := database.Handle.Quotas().GetUsage(c.Req.Context(), c.Org.Organization.ID)
+	if err != nil {
+		c.Error(err, "get storage usage")
+		return false
+	}
+	c.Data["StorageUsage"] = usage
+	return true
+}
+
 func SettingsPost(c *context.Context, f form.UpdateOrgSetting) {
 	c.Title("org.settings")
 	c.Data["PageIsSettingsOptions"] = true
+	if !prepareStorageUsage(c) {
+		return
+	}
 
 	if c.HasError() {
 		c.Success(SETTINGS_OPTIONS)
@@ -70,6 +93,9 @@ func SettingsPost(c *context.Context, f form.UpdateOrgSetting) {
 	}
 	if c.User.IsAdmin {
 		opts.MaxRepoCreation = &f.MaxRepoCreation
+		opts.MaxRepoSize = &f.MaxRepoSize
+		opts.MaxLFSSize = &f.MaxLFSSize
+		opts.MaxAttachmentSize = &f.MaxAttachmentSize
 	}
 	err := database.Handle.Users().Update(c.Req.Context(), c.Org.Organization.ID, opts)
 	if err != nil {
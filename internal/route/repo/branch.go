@@ -142,6 +142,10 @@ func DeleteBranchPost(c *context.Context) {
 		return
 	}
 
+	if err := database.CreateDeleteBranchComments(c.User, c.Repo.Repository.ID, branchName); err != nil {
+		log.Error("Failed to create delete branch comments for %q: %v", branchName, err)
+	}
+
 	if err := database.PrepareWebhooks(c.Repo.Repository, database.HOOK_EVENT_DELETE, &api.DeletePayload{
 		Ref:        branchName,
 		RefType:    "branch",
@@ -6,6 +6,7 @@ package repo
 
 import (
 	gocontext "context"
+	"net/url"
 	"path"
 	"time"
 
@@ -79,6 +80,9 @@ func renderCommits(c *context.Context, filename string) {
 }
 
 func Commits(c *context.Context) {
+	if c.Repo.IsViewBranch {
+		c.SetFeedLinks(c.Repo.RepoLink+"/commits", url.Values{"branch": {c.Repo.BranchName}})
+	}
 	renderCommits(c, "")
 }
 
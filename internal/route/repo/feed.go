@@ -0,0 +1,106 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"html"
+
+	"github.com/gogs/git-module"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/feed"
+	"gogs.io/gogs/internal/markup"
+	"gogs.io/gogs/internal/template"
+)
+
+// CommitsFeed serves the feed of the latest commits on the branch given by the
+// "branch" query parameter, which defaults to the default branch.
+func CommitsFeed(c *context.Context) {
+	branch := c.Query("branch")
+	if branch == "" {
+		branch = c.Repo.Repository.DefaultBranch
+	}
+	if !c.Repo.GitRepo.HasBranch(branch) {
+		c.NotFound()
+		return
+	}
+
+	commit, err := c.Repo.GitRepo.BranchCommit(branch)
+	if err != nil {
+		c.Error(err, "get branch commit")
+		return
+	}
+	commits, err := commit.CommitsByPage(1, conf.UI.User.CommitsPagingNum, git.CommitsByPageOptions{})
+	if err != nil {
+		c.Error(err, "paging commits")
+		return
+	}
+
+	repoURL := c.Repo.Repository.HTMLURL()
+	f := &feed.Feed{
+		Title:       c.Tr("feed.commits", c.Repo.Repository.FullName(), branch),
+		Link:        repoURL + "/commits/" + template.EscapePound(branch),
+		Description: c.Repo.Repository.Description,
+		Items:       make([]*feed.Item, 0, len(commits)),
+	}
+	for _, commit := range commits {
+		link := repoURL + "/commit/" + commit.ID.String()
+		f.Items = append(f.Items, &feed.Item{
+			ID:      link,
+			Title:   commit.Summary(),
+			Link:    link,
+			Content: "<pre>" + html.EscapeString(commit.Message) + "</pre>",
+			Author: feed.Author{
+				Name:  commit.Author.Name,
+				Email: commit.Author.Email,
+			},
+			Created: commit.Author.When,
+		})
+	}
+	c.Feed(f)
+}
+
+// ReleasesFeed serves the feed of the latest published releases.
+func ReleasesFeed(c *context.Context) {
+	releases, err := database.GetPublishedReleasesByRepoID(c.Repo.Repository.ID)
+	if err != nil {
+		c.Error(err, "get published releases by repository ID")
+		return
+	}
+	if len(releases) > conf.UI.User.NewsFeedPagingNum {
+		releases = releases[:conf.UI.User.NewsFeedPagingNum]
+	}
+
+	repoURL := c.Repo.Repository.HTMLURL()
+	f := &feed.Feed{
+		Title:       c.Tr("feed.releases", c.Repo.Repository.FullName()),
+		Link:        repoURL + "/releases",
+		Description: c.Repo.Repository.Description,
+		Items:       make([]*feed.Item, 0, len(releases)),
+	}
+	for _, r := range releases {
+		if err = r.LoadAttributes(); err != nil {
+			c.Error(err, "load attributes")
+			return
+		}
+
+		title := r.Title
+		if title == "" {
+			title = r.TagName
+		}
+		f.Items = append(f.Items, &feed.Item{
+			ID:      fmt.Sprintf("%s/releases/%d", repoURL, r.ID),
+			Title:   title,
+			Link:    repoURL + "/src/" + template.EscapePound(r.TagName),
+			Content: string(markup.Markdown(r.Note, repoURL, c.Repo.Repository.ComposeMetas())),
+			Author:  feed.Author{Name: r.Publisher.Name},
+			Created: r.Created,
+		})
+	}
+	c.Feed(f)
+}
@@ -24,6 +24,7 @@ import (
 	"gogs.io/gogs/internal/conf"
 	"gogs.io/gogs/internal/context"
 	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/gitutil"
 	"gogs.io/gogs/internal/lazyregexp"
 	"gogs.io/gogs/internal/pathutil"
 	"gogs.io/gogs/internal/tool"
@@ -164,7 +165,7 @@ Please create and use personal access token on user settings page`)
 		if isPull {
 			mode = database.AccessModeRead
 		}
-		if !database.Handle.Permissions().Authorize(c.Req.Context(), authUser.ID, repo.ID, mode,
+		if !store.AuthorizeRepositoryAccess(c.Req.Context(), authUser.ID, repo.ID, mode,
 			database.AccessModeOptions{
 				OwnerID: repo.OwnerID,
 				Private: repo.IsPrivate,
@@ -256,7 +257,7 @@ func serviceRPC(h serviceHandler, service string) {
 	}
 
 	var stderr bytes.Buffer
-	cmd := exec.Command("git", service, "--stateless-rpc", h.dir)
+	cmd := exec.Command("git", serviceArgs(service, "--stateless-rpc", h.dir)...)
 	if service == "receive-pack" {
 		cmd.Env = append(os.Environ(), database.ComposeHookEnvs(database.ComposeHookEnvsOptions{
 			AuthUser:  h.authUser,
@@ -286,6 +287,15 @@ func serviceReceivePack(h serviceHandler) {
 	serviceRPC(h, "receive-pack")
 }
 
+// serviceArgs returns the arguments to run the given Git service with, taking
+// care of the configuration options that only apply to "upload-pack".
+func serviceArgs(service string, args ...string) []string {
+	if service != "upload-pack" {
+		return append([]string{service}, args...)
+	}
+	return append(append(gitutil.UploadPackConfigs(), service), args...)
+}
+
 func getServiceType(r *http.Request) string {
 	serviceType := r.FormValue("service")
 	if !strings.HasPrefix(serviceType, "git-") {
@@ -326,7 +336,7 @@ func getInfoRefs(h serviceHandler) {
 		return
 	}
 
-	refs := gitCommand(h.dir, service, "--stateless-rpc", "--advertise-refs", ".")
+	refs := gitCommand(h.dir, serviceArgs(service, "--stateless-rpc", "--advertise-refs", ".")...)
 	h.w.Header().Set("Content-Type", fmt.Sprintf("application/x-git-%s-advertisement", service))
 	h.w.WriteHeader(http.StatusOK)
 	_, _ = h.w.Write(packetWrite("# service=git-" + service + "\n"))
@@ -0,0 +1,158 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/macaron.v1"
+
+	"gogs.io/gogs/internal/auth"
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/errutil"
+)
+
+func Test_serviceArgs(t *testing.T) {
+	before := conf.Git
+	defer func() {
+		conf.Git = before
+	}()
+	conf.Git.UploadPackAllowFilter = true
+	conf.Git.UploadPackAllowAnySHA1InWant = true
+
+	assert.Equal(t,
+		[]string{
+			"-c", "uploadpack.allowFilter=true",
+			"-c", "uploadpack.allowReachableSHA1InWant=true",
+			"-c", "uploadpack.allowAnySHA1InWant=true",
+			"upload-pack", "--stateless-rpc", ".",
+		},
+		serviceArgs("upload-pack", "--stateless-rpc", "."),
+	)
+	assert.Equal(t,
+		[]string{"receive-pack", "--stateless-rpc", "."},
+		serviceArgs("receive-pack", "--stateless-rpc", "."),
+	)
+}
+
+func TestHTTP_PartialClone(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping testing with Git operations in short mode")
+	}
+
+	before := conf.Git
+	defer func() {
+		conf.Git = before
+	}()
+	conf.Git.UploadPackAllowFilter = true
+
+	root := t.TempDir()
+	conf.SetMockRepository(t, conf.RepositoryOpts{Root: root})
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=alice", "GIT_AUTHOR_EMAIL=alice@example.com",
+			"GIT_COMMITTER_NAME=alice", "GIT_COMMITTER_EMAIL=alice@example.com",
+			"GIT_TERMINAL_PROMPT=0",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return string(out)
+	}
+
+	// Prepare a private bare repository with a single commit.
+	bare := filepath.Join(root, "alice", "repo.git")
+	git(root, "init", "--bare", bare)
+	work := filepath.Join(root, "work")
+	git(root, "clone", bare, work)
+	require.NoError(t, os.WriteFile(filepath.Join(work, "README.md"), []byte("# Partial clone"), 0644))
+	git(work, "add", "README.md")
+	git(work, "commit", "-m", "Initial commit")
+	git(work, "push", "origin", "HEAD")
+
+	alice := &database.User{ID: 1, Name: "alice"}
+	mockStore := NewMockStore()
+	mockStore.GetUserByUsernameFunc.SetDefaultReturn(alice, nil)
+	mockStore.GetRepositoryByNameFunc.SetDefaultReturn(
+		&database.Repository{
+			ID:        1,
+			OwnerID:   alice.ID,
+			Name:      "repo",
+			IsPrivate: true,
+		},
+		nil,
+	)
+	mockStore.AuthenticateUserFunc.SetDefaultHook(func(_ context.Context, login, password string, _ int64) (*database.User, error) {
+		if login == alice.Name && password == "password" {
+			return alice, nil
+		}
+		return nil, auth.ErrBadCredentials{Args: errutil.Args{"login": login}}
+	})
+	mockStore.AuthorizeRepositoryAccessFunc.SetDefaultHook(func(_ context.Context, userID, _ int64, _ database.AccessMode, opts database.AccessModeOptions) bool {
+		return userID == opts.OwnerID
+	})
+
+	m := macaron.New()
+	m.Use(macaron.Renderer())
+	m.Route("/:username/:reponame/*", "GET,POST", HTTPContexter(mockStore), HTTP)
+	server := httptest.NewServer(m)
+	defer server.Close()
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/alice/repo.git/info/refs?service=git-upload-pack")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, err = http.Post(server.URL+"/alice/repo.git/git-upload-pack", "application/x-git-upload-pack-request", strings.NewReader("0000"))
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("authorized", func(t *testing.T) {
+		u, err := url.Parse(server.URL + "/alice/repo.git")
+		require.NoError(t, err)
+		u.User = url.UserPassword("alice", "password")
+
+		clone := filepath.Join(root, "clone")
+		git(root, "clone", "--no-checkout", "--filter=blob:none", u.String(), clone)
+		missing := git(clone, "rev-list", "--objects", "--missing=print", "HEAD")
+		assert.Contains(t, missing, "?", "blobs should not be fetched during clone")
+
+		// Missing blobs are fetched lazily from the server upon checkout, which
+		// goes through the same authentication.
+		git(clone, "checkout", "HEAD", "--", "README.md")
+		got, err := os.ReadFile(filepath.Join(clone, "README.md"))
+		require.NoError(t, err)
+		assert.Equal(t, "# Partial clone", string(got))
+
+		// Commits that are no longer reachable from any ref are never served.
+		git(work, "checkout", "-b", "secret")
+		require.NoError(t, os.WriteFile(filepath.Join(work, "SECRET"), []byte("password"), 0644))
+		git(work, "add", "SECRET")
+		git(work, "commit", "-m", "Add secret")
+		git(work, "push", "origin", "secret")
+		secret := strings.TrimSpace(git(work, "rev-parse", "HEAD"))
+		git(work, "push", "origin", ":secret")
+
+		cmd := exec.Command("git", "fetch", "origin", secret)
+		cmd.Dir = clone
+		out, err := cmd.CombinedOutput()
+		assert.Error(t, err, string(out))
+	})
+}
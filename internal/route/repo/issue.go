@@ -39,8 +39,9 @@ const (
 )
 
 var (
-	ErrFileTypeForbidden = errors.New("File type is not allowed")
-	ErrTooManyFiles      = errors.New("Maximum number of files to upload exceeded")
+	ErrFileTypeForbidden    = errors.New("File type is not allowed")
+	ErrTooManyFiles         = errors.New("Maximum number of files to upload exceeded")
+	ErrStorageQuotaExceeded = errors.New("Storage quota exceeded")
 
 	IssueTemplateCandidates = []string{
 		"ISSUE_TEMPLATE.md",
@@ -75,13 +76,17 @@ func MustAllowPulls(c *context.Context) {
 }
 
 func RetrieveLabels(c *context.Context) {
-	labels, err := database.GetLabelsByRepoID(c.Repo.Repository.ID)
+	labels, err := database.GetAvailableLabelsByRepoID(c.Repo.Repository.ID)
 	if err != nil {
-		c.Error(err, "get labels by repository ID")
+		c.Error(err, "get available labels by repository ID")
 		return
 	}
 	for _, l := range labels {
-		l.CalOpenIssues()
+		if l.IsOrgLabel() {
+			l.CalOpenIssuesInRepo(c.Repo.Repository.ID)
+		} else {
+			l.CalOpenIssues()
+		}
 	}
 	c.Data["Labels"] = labels
 	c.Data["NumLabels"] = len(labels)
@@ -159,28 +164,60 @@ func issues(c *context.Context, isPullList bool) {
 		page = 1
 	}
 
-	var total int
-	if !isShowClosed {
-		total = int(issueStats.OpenCount)
-	} else {
-		total = int(issueStats.ClosedCount)
-	}
-	pager := paginater.New(total, conf.UI.IssuePagingNum, page, 5)
-	c.Data["Page"] = pager
-
-	issues, err := database.Issues(&database.IssuesOptions{
+	opts := &database.IssuesOptions{
 		UserID:      uid,
 		AssigneeID:  assigneeID,
 		RepoID:      repo.ID,
 		PosterID:    posterID,
 		MilestoneID: milestoneID,
-		Page:        pager.Current(),
-		IsClosed:    isShowClosed,
 		IsMention:   filterMode == database.FILTER_MODE_MENTION,
 		IsPull:      isPullList,
 		Labels:      selectLabels,
 		SortType:    sortType,
-	})
+	}
+
+	keyword := strings.TrimSpace(c.Query("q"))
+	if keyword != "" {
+		query, err := database.ParseIssueQuery(keyword, c.User)
+		if err != nil {
+			c.Error(err, "parse issue query")
+			return
+		}
+
+		// The state qualifier selects the tab, so both tabs count matched issues.
+		if query.IsClosed != nil {
+			isShowClosed = *query.IsClosed
+			query.IsClosed = nil
+		}
+		if query.SortType != "" {
+			sortType = query.SortType
+		}
+		opts.Query = query
+
+		opts.IsClosed = false
+		if issueStats.OpenCount, err = database.IssuesCount(opts); err != nil {
+			c.Error(err, "count open issues")
+			return
+		}
+		opts.IsClosed = true
+		if issueStats.ClosedCount, err = database.IssuesCount(opts); err != nil {
+			c.Error(err, "count closed issues")
+			return
+		}
+	}
+
+	var total int
+	if !isShowClosed {
+		total = int(issueStats.OpenCount)
+	} else {
+		total = int(issueStats.ClosedCount)
+	}
+	pager := paginater.New(total, conf.UI.IssuePagingNum, page, 5)
+	c.Data["Page"] = pager
+
+	opts.Page = pager.Current()
+	opts.IsClosed = isShowClosed
+	issues, err := database.Issues(opts)
 	if err != nil {
 		c.Error(err, "list issues")
 		return
@@ -210,10 +247,18 @@ func issues(c *context.Context, isPullList bool) {
 	}
 	c.Data["Issues"] = issues
 
+	if pager.Current() == 1 && keyword == "" {
+		c.Data["PinnedIssues"], err = database.GetPinnedIssues(repo.ID, isPullList)
+		if err != nil {
+			c.Error(err, "get pinned issues")
+			return
+		}
+	}
+
 	// Get milestones.
-	c.Data["Milestones"], err = database.GetMilestonesByRepoID(repo.ID)
+	c.Data["Milestones"], err = database.GetAvailableMilestonesByRepoID(repo.ID)
 	if err != nil {
-		c.Error(err, "get milestone by repository ID")
+		c.Error(err, "get available milestones by repository ID")
 		return
 	}
 
@@ -232,9 +277,13 @@ func issues(c *context.Context, isPullList bool) {
 	c.Data["SelectLabels"] = com.StrTo(selectLabels).MustInt64()
 	c.Data["ViewType"] = viewType
 	c.Data["SortType"] = sortType
+	c.Data["Keyword"] = keyword
 	c.Data["MilestoneID"] = milestoneID
 	c.Data["AssigneeID"] = assigneeID
 	c.Data["IsShowClosed"] = isShowClosed
+	if !isPullList {
+		c.Data["ExportLink"] = c.Repo.RepoLink + "/issues/export?" + c.Req.URL.RawQuery
+	}
 	if isShowClosed {
 		c.Data["State"] = "closed"
 	} else {
@@ -252,6 +301,69 @@ func Pulls(c *context.Context) {
 	issues(c, true)
 }
 
+// ExportIssues exports all issues matching filters of the issue list in the
+// format given by the "format" parameter.
+func ExportIssues(c *context.Context) {
+	format := c.QueryTrim("format")
+	if format != database.IssueExchangeFormatCSV && format != database.IssueExchangeFormatJSON {
+		c.NotFound()
+		return
+	}
+
+	var uid int64 = -1
+	if c.IsLogged {
+		uid = c.User.ID
+	}
+	opts := &database.IssuesOptions{
+		UserID:      uid,
+		AssigneeID:  c.QueryInt64("assignee"),
+		RepoID:      c.Repo.Repository.ID,
+		MilestoneID: c.QueryInt64("milestone"),
+		IsClosed:    c.Query("state") == "closed",
+		Labels:      c.Query("labels"),
+		SortType:    c.Query("sort"),
+	}
+	if c.IsLogged {
+		switch c.Query("type") {
+		case "assigned":
+			opts.AssigneeID = c.User.ID
+		case "created_by":
+			opts.PosterID = c.User.ID
+		case "mentioned":
+			opts.IsMention = true
+		}
+	}
+
+	if keyword := strings.TrimSpace(c.Query("q")); keyword != "" {
+		query, err := database.ParseIssueQuery(keyword, c.User)
+		if err != nil {
+			c.Error(err, "parse issue query")
+			return
+		}
+		if query.IsClosed != nil {
+			opts.IsClosed = *query.IsClosed
+			query.IsClosed = nil
+		}
+		opts.Query = query
+	}
+
+	records, err := database.ListIssueRecords(opts)
+	if err != nil {
+		c.Error(err, "list issue records")
+		return
+	}
+
+	if format == database.IssueExchangeFormatCSV {
+		c.Resp.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Resp.Header().Set("Content-Type", "application/json; charset=utf-8")
+	}
+	c.Resp.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s-issues.%s"`, c.Repo.Owner.Name, c.Repo.Repository.Name, format))
+	if err = database.WriteIssueRecords(c.Resp, format, records); err != nil {
+		log.Error("Failed to write issue records: %v", err)
+	}
+}
+
 func renderAttachmentSettings(c *context.Context) {
 	c.Data["RequireDropzone"] = true
 	c.Data["IsAttachmentEnabled"] = conf.Attachment.Enabled
@@ -262,12 +374,12 @@ func renderAttachmentSettings(c *context.Context) {
 
 func RetrieveRepoMilestonesAndAssignees(c *context.Context, repo *database.Repository) {
 	var err error
-	c.Data["OpenMilestones"], err = database.GetMilestones(repo.ID, -1, false)
+	c.Data["OpenMilestones"], err = database.GetAvailableMilestones(repo.ID, false)
 	if err != nil {
 		c.Error(err, "get open milestones")
 		return
 	}
-	c.Data["ClosedMilestones"], err = database.GetMilestones(repo.ID, -1, true)
+	c.Data["ClosedMilestones"], err = database.GetAvailableMilestones(repo.ID, true)
 	if err != nil {
 		c.Error(err, "get closed milestones")
 		return
@@ -285,9 +397,9 @@ func RetrieveRepoMetas(c *context.Context, repo *database.Repository) []*databas
 		return nil
 	}
 
-	labels, err := database.GetLabelsByRepoID(repo.ID)
+	labels, err := database.GetAvailableLabelsByRepoID(repo.ID)
 	if err != nil {
-		c.Error(err, "get labels by repository ID")
+		c.Error(err, "get available labels by repository ID")
 		return nil
 	}
 	c.Data["Labels"] = labels
@@ -296,6 +408,7 @@ func RetrieveRepoMetas(c *context.Context, repo *database.Repository) []*databas
 	if c.Written() {
 		return nil
 	}
+	c.Data["SelectedAssigneeIDs"] = map[int64]bool{}
 
 	return labels
 }
@@ -348,7 +461,7 @@ func NewIssue(c *context.Context) {
 	c.Success(ISSUE_NEW)
 }
 
-func ValidateRepoMetas(c *context.Context, f form.NewIssue) ([]int64, int64, int64) {
+func ValidateRepoMetas(c *context.Context, f form.NewIssue) ([]int64, int64, []int64) {
 	var (
 		repo = c.Repo.Repository
 		err  error
@@ -356,11 +469,11 @@ func ValidateRepoMetas(c *context.Context, f form.NewIssue) ([]int64, int64, int
 
 	labels := RetrieveRepoMetas(c, c.Repo.Repository)
 	if c.Written() {
-		return nil, 0, 0
+		return nil, 0, nil
 	}
 
 	if !c.Repo.IsWriter() {
-		return nil, 0, 0
+		return nil, 0, nil
 	}
 
 	// Check labels.
@@ -383,23 +496,29 @@ func ValidateRepoMetas(c *context.Context, f form.NewIssue) ([]int64, int64, int
 		c.Data["Milestone"], err = repo.GetMilestoneByID(milestoneID)
 		if err != nil {
 			c.Error(err, "get milestone by ID")
-			return nil, 0, 0
+			return nil, 0, nil
 		}
 		c.Data["milestone_id"] = milestoneID
 	}
 
-	// Check assignee.
-	assigneeID := f.AssigneeID
-	if assigneeID > 0 {
-		c.Data["Assignee"], err = repo.GetAssigneeByID(assigneeID)
+	// Check assignees.
+	assigneeIDs := make([]int64, 0, 1)
+	for _, assigneeID := range tool.StringsToInt64s(strings.Split(f.AssigneeIDs, ",")) {
+		if assigneeID <= 0 {
+			continue
+		}
+
+		assignee, err := repo.GetAssigneeByID(assigneeID)
 		if err != nil {
 			c.Error(err, "get assignee by ID")
-			return nil, 0, 0
+			return nil, 0, nil
 		}
-		c.Data["assignee_id"] = assigneeID
+		assigneeIDs = append(assigneeIDs, assignee.ID)
 	}
+	c.Data["SelectedAssigneeIDs"] = tool.Int64sToMap(assigneeIDs)
+	c.Data["assignee_ids"] = f.AssigneeIDs
 
-	return labelIDs, milestoneID, assigneeID
+	return labelIDs, milestoneID, assigneeIDs
 }
 
 func NewIssuePost(c *context.Context, f form.NewIssue) {
@@ -409,7 +528,7 @@ func NewIssuePost(c *context.Context, f form.NewIssue) {
 	c.Data["RequireSimpleMDE"] = true
 	renderAttachmentSettings(c)
 
-	labelIDs, milestoneID, assigneeID := ValidateRepoMetas(c, f)
+	labelIDs, milestoneID, assigneeIDs := ValidateRepoMetas(c, f)
 	if c.Written() {
 		return
 	}
@@ -430,10 +549,9 @@ func NewIssuePost(c *context.Context, f form.NewIssue) {
 		PosterID:    c.User.ID,
 		Poster:      c.User,
 		MilestoneID: milestoneID,
-		AssigneeID:  assigneeID,
 		Content:     f.Content,
 	}
-	if err := database.NewIssue(c.Repo.Repository, issue, labelIDs, attachments); err != nil {
+	if err := database.NewIssue(c.Repo.Repository, issue, labelIDs, assigneeIDs, attachments); err != nil {
 		c.Error(err, "new issue")
 		return
 	}
@@ -471,7 +589,19 @@ func uploadAttachment(c *context.Context, allowedTypes []string) {
 		return
 	}
 
-	attach, err := database.NewAttachment(header.Filename, buf, file)
+	if c.IsLogged {
+		err = database.Handle.Quotas().Check(c.Req.Context(), c.User, database.QuotaTypeAttachment, header.Size)
+		if err != nil {
+			if database.IsErrQuotaExceeded(err) {
+				c.PlainText(http.StatusRequestEntityTooLarge, ErrStorageQuotaExceeded.Error())
+			} else {
+				c.Error(err, "check attachment quota")
+			}
+			return
+		}
+	}
+
+	attach, err := database.NewAttachment(c.UserID(), header.Filename, buf, file)
 	if err != nil {
 		c.Error(err, "new attachment")
 		return
@@ -505,7 +635,11 @@ func viewIssue(c *context.Context, isPullList bool) {
 
 	issue, err := database.GetIssueByIndex(c.Repo.Repository.ID, index)
 	if err != nil {
-		c.NotFoundOrError(err, "get issue by index")
+		if database.IsErrIssueNotExist(err) {
+			redirectTransferredIssue(c, index)
+			return
+		}
+		c.Error(err, "get issue by index")
 		return
 	}
 	c.Data["Title"] = issue.Title
@@ -557,9 +691,9 @@ func viewIssue(c *context.Context, isPullList bool) {
 	for i := range issue.Labels {
 		labelIDMark[issue.Labels[i].ID] = true
 	}
-	labels, err := database.GetLabelsByRepoID(repo.ID)
+	labels, err := database.GetAvailableLabelsByRepoID(repo.ID)
 	if err != nil {
-		c.Error(err, "get labels by repository ID")
+		c.Error(err, "get available labels by repository ID")
 		return
 	}
 	hasSelected := false
@@ -586,6 +720,10 @@ func viewIssue(c *context.Context, isPullList bool) {
 			c.Error(err, "mark read by")
 			return
 		}
+		if err = database.Handle.Notifications().MarkIssueRead(c.Req.Context(), c.User.ID, issue.ID); err != nil {
+			c.Error(err, "mark notification read")
+			return
+		}
 	}
 
 	var (
@@ -665,6 +803,58 @@ func viewIssue(c *context.Context, isPullList bool) {
 		})
 	}
 
+	blockedBy, blocks, err := database.ListVisibleIssueDependencies(c.Req.Context(), c.User, issue.ID)
+	if err != nil {
+		c.Error(err, "list visible issue dependencies")
+		return
+	}
+	c.Data["BlockedBy"] = blockedBy
+	c.Data["Blocks"] = blocks
+
+	reactions, err := database.Handle.Reactions().SummarizeByIssueID(c.Req.Context(), issue.ID)
+	if err != nil {
+		c.Error(err, "summarize reactions")
+		return
+	}
+	issue.Reactions = reactions[0]
+	for _, comment := range issue.Comments {
+		comment.Reactions = reactions[comment.ID]
+	}
+	c.Data["ReactionTypes"] = database.ReactionTypes
+
+	edited, err := database.Handle.ContentRevisions().ListEdited(c.Req.Context(), issue.ID)
+	if err != nil {
+		c.Error(err, "list edited contents")
+		return
+	}
+	issue.IsEdited = edited[0]
+	for _, comment := range issue.Comments {
+		comment.IsEdited = edited[comment.ID]
+	}
+
+	c.Data["TotalTrackedTime"], err = database.Handle.TrackedTimes().Sum(c.Req.Context(), database.ListTrackedTimesOptions{IssueID: issue.ID})
+	if err != nil {
+		c.Error(err, "sum tracked times")
+		return
+	}
+	if c.Repo.IsWriter() {
+		stopwatch, err := database.Handle.TrackedTimes().GetStopwatch(c.Req.Context(), c.User.ID, issue.ID)
+		if err != nil && !database.IsErrStopwatchNotExist(err) {
+			c.Error(err, "get stopwatch")
+			return
+		}
+		c.Data["Stopwatch"] = stopwatch
+		c.Data["IssueLockReasons"] = database.IssueLockReasons
+	}
+
+	if issue.IsPull && !issue.IsClosed {
+		c.Data["NumOpenDependencies"], err = database.Handle.IssueDependencies().CountOpenDependencies(c.Req.Context(), issue.ID)
+		if err != nil {
+			c.Error(err, "count open dependencies")
+			return
+		}
+	}
+
 	c.Data["Participants"] = participants
 	c.Data["NumParticipants"] = len(participants)
 	c.Data["Issue"] = issue
@@ -673,6 +863,23 @@ func viewIssue(c *context.Context, isPullList bool) {
 	c.Success(ISSUE_VIEW)
 }
 
+// redirectTransferredIssue redirects to the issue that used to have the index
+// in the current repository before being transferred, if it is visible to the
+// user.
+func redirectTransferredIssue(c *context.Context, index int64) {
+	issue, err := database.GetIssueByRedirect(c.Repo.Repository.ID, index)
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by redirect")
+		return
+	}
+
+	if !database.CanReadIssues(c.Req.Context(), c.User, issue.Repo) {
+		c.NotFound()
+		return
+	}
+	c.Redirect(issue.Repo.Link() + "/issues/" + com.ToStr(issue.Index))
+}
+
 func ViewIssue(c *context.Context) {
 	viewIssue(c, false)
 }
@@ -697,6 +904,44 @@ func getActionIssue(c *context.Context) *database.Issue {
 	return issue
 }
 
+// MentionTeams returns teams matching the keyword that can be mentioned in
+// issues and comments, which are teams of the organization that owns the
+// repository and are only visible to members of the organization.
+func MentionTeams(c *context.Context) {
+	type team struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	teams := make([]team, 0)
+
+	owner := c.Repo.Owner
+	if !owner.IsOrganization() || (!c.User.IsAdmin && !owner.IsOrgMember(c.User.ID)) {
+		c.JSONSuccess(teams)
+		return
+	}
+
+	orgTeams, err := database.GetTeamsByOrgID(owner.ID)
+	if err != nil {
+		c.Error(err, "get teams by organization ID")
+		return
+	}
+
+	keyword := strings.ToLower(strings.TrimPrefix(c.QueryTrim("q"), "@"))
+	for _, t := range orgTeams {
+		name := owner.Name + "/" + t.Name
+		if keyword != "" &&
+			!strings.HasPrefix(strings.ToLower(name), keyword) &&
+			!strings.HasPrefix(t.LowerName, keyword) {
+			continue
+		}
+		teams = append(teams, team{
+			Name:        name,
+			Description: t.Description,
+		})
+	}
+	c.JSONSuccess(teams)
+}
+
 func UpdateIssueTitle(c *context.Context) {
 	issue := getActionIssue(c)
 	if c.Written() {
@@ -759,9 +1004,9 @@ func UpdateIssueLabel(c *context.Context) {
 		}
 	} else {
 		isAttach := c.Query("action") == "attach"
-		label, err := database.GetLabelOfRepoByID(c.Repo.Repository.ID, c.QueryInt64("id"))
+		label, err := database.GetAvailableLabelOfRepoByID(c.Repo.Repository.ID, c.QueryInt64("id"))
 		if err != nil {
-			c.NotFoundOrError(err, "get label by ID")
+			c.NotFoundOrError(err, "get available label by ID")
 			return
 		}
 
@@ -816,16 +1061,41 @@ func UpdateIssueAssignee(c *context.Context) {
 		return
 	}
 
-	assigneeID := c.QueryInt64("id")
-	if issue.AssigneeID == assigneeID {
-		c.JSONSuccess(map[string]any{
-			"ok": true,
-		})
-		return
+	var assigneeIDs []int64
+	switch c.Query("action") {
+	case "clear":
+	case "attach":
+		assignee, err := c.Repo.Repository.GetAssigneeByID(c.QueryInt64("id"))
+		if err != nil {
+			c.NotFoundOrError(err, "get assignee by ID")
+			return
+		}
+
+		if issue.IsAssignee(assignee.ID) {
+			c.JSONSuccess(map[string]any{
+				"ok": true,
+			})
+			return
+		}
+		assigneeIDs = append(issue.AssigneeIDs(), assignee.ID)
+	default:
+		assigneeID := c.QueryInt64("id")
+		if !issue.IsAssignee(assigneeID) {
+			c.JSONSuccess(map[string]any{
+				"ok": true,
+			})
+			return
+		}
+
+		for _, id := range issue.AssigneeIDs() {
+			if id != assigneeID {
+				assigneeIDs = append(assigneeIDs, id)
+			}
+		}
 	}
 
-	if err := issue.ChangeAssignee(c.User, assigneeID); err != nil {
-		c.Error(err, "change assignee")
+	if err := issue.ChangeAssignees(c.User, assigneeIDs); err != nil {
+		c.Error(err, "change assignees")
 		return
 	}
 
@@ -845,6 +1115,12 @@ func NewComment(c *context.Context, f form.CreateComment) {
 		attachments = f.Files
 	}
 
+	if issue.IsLocked && !c.Repo.IsWriter() {
+		c.Flash.Error(c.Tr("repo.issues.lock.comment_forbidden"))
+		c.RawRedirect(c.Repo.MakeURL(fmt.Sprintf("issues/%d", issue.Index)))
+		return
+	}
+
 	if c.HasError() {
 		c.Flash.Error(c.Data["ErrorMsg"].(string))
 		c.RawRedirect(c.Repo.MakeURL(fmt.Sprintf("issues/%d", issue.Index)))
@@ -887,7 +1163,11 @@ func NewComment(c *context.Context, f form.CreateComment) {
 				c.Flash.Info(c.Tr("repo.pulls.open_unmerged_pull_exists", pr.Index))
 			} else {
 				if err = issue.ChangeStatus(c.User, c.Repo.Repository, f.Status == "close"); err != nil {
-					log.Error("ChangeStatus: %v", err)
+					if database.IsErrIssueBlocked(err) {
+						c.Flash.Error(c.Tr("repo.issues.dependency.close_blocked"))
+					} else {
+						log.Error("ChangeStatus: %v", err)
+					}
 				} else {
 					log.Trace("Issue [%d] status changed to closed: %v", issue.ID, issue.IsClosed)
 				}
@@ -958,6 +1238,170 @@ func UpdateCommentContent(c *context.Context) {
 	})
 }
 
+func AddIssueDependency(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	dependency, err := database.GetVisibleIssueByRef(c.Req.Context(), c.User, c.Repo.Repository, c.Query("ref"))
+	if err != nil {
+		if database.IsErrIssueNotExist(err) {
+			c.Flash.Error(c.Tr("repo.issues.dependency.issue_not_exist"))
+			c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+			return
+		}
+		c.Error(err, "get visible issue by reference")
+		return
+	}
+
+	err = database.Handle.IssueDependencies().Add(c.Req.Context(), issue.ID, dependency.ID)
+	if err != nil {
+		switch {
+		case database.IsErrIssueDependencyAlreadyExist(err):
+			c.Flash.Error(c.Tr("repo.issues.dependency.already_exist"))
+		case database.IsErrIssueDependencyInvalid(err):
+			c.Flash.Error(c.Tr("repo.issues.dependency.cycle"))
+		default:
+			c.Error(err, "add issue dependency")
+			return
+		}
+	}
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
+func RemoveIssueDependency(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	err := database.Handle.IssueDependencies().Remove(c.Req.Context(), issue.ID, c.QueryInt64("dependency_id"))
+	if err != nil && !database.IsErrIssueDependencyNotExist(err) {
+		c.Error(err, "remove issue dependency")
+		return
+	}
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
+func UpdateIssueDeadline(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	var deadline time.Time
+	if c.QueryTrim("deadline") != "" {
+		var err error
+		deadline, err = time.ParseInLocation("2006-01-02", c.QueryTrim("deadline"), time.Local)
+		if err != nil {
+			c.Flash.Error(c.Tr("repo.issues.due_date.invalid"))
+			c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+			return
+		}
+		deadline = time.Date(deadline.Year(), deadline.Month(), deadline.Day(), 23, 59, 59, 0, deadline.Location())
+	}
+
+	if err := issue.ChangeDeadline(deadline); err != nil {
+		c.Error(err, "change deadline")
+		return
+	}
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
+func LockIssue(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	reason := c.Query("reason")
+	if !database.IsValidIssueLockReason(reason) {
+		c.Flash.Error(c.Tr("repo.issues.lock.invalid_reason"))
+		c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+		return
+	}
+
+	if err := issue.ChangeLock(c.User, true, reason); err != nil {
+		c.Error(err, "lock issue")
+		return
+	}
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
+func TransferIssue(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	} else if issue.IsPull {
+		c.NotFound()
+		return
+	}
+
+	newRepo, err := database.GetIssueTransferTarget(c.Req.Context(), c.User, c.Query("repo"))
+	if err != nil {
+		if database.IsErrRepoNotExist(err) {
+			c.Flash.Error(c.Tr("repo.issues.transfer.repo_not_exist"))
+			c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+			return
+		}
+		c.Error(err, "get issue transfer target")
+		return
+	} else if newRepo.ID == issue.RepoID {
+		c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+		return
+	}
+
+	if err = issue.Transfer(c.User, newRepo); err != nil {
+		c.Error(err, "transfer issue")
+		return
+	}
+	c.Flash.Success(c.Tr("repo.issues.transfer.success", newRepo.FullName()))
+	c.Redirect(newRepo.Link() + "/issues/" + com.ToStr(issue.Index))
+}
+
+func UnlockIssue(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	if err := issue.ChangeLock(c.User, false, ""); err != nil {
+		c.Error(err, "unlock issue")
+		return
+	}
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
+func PinIssue(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	if err := issue.ChangePin(c.User, true); err != nil {
+		if !database.IsErrPinnedIssuesLimit(err) {
+			c.Error(err, "pin issue")
+			return
+		}
+		c.Flash.Error(c.Tr("repo.issues.pin.limit", database.MaxPinnedIssues))
+	}
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
+func UnpinIssue(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	if err := issue.ChangePin(c.User, false); err != nil {
+		c.Error(err, "unpin issue")
+		return
+	}
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
 func DeleteComment(c *context.Context) {
 	comment, err := database.GetCommentByID(c.ParamsInt64(":id"))
 	if err != nil {
@@ -981,6 +1425,53 @@ func DeleteComment(c *context.Context) {
 	c.Status(http.StatusOK)
 }
 
+func ToggleIssueReaction(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	_, err := database.Handle.Reactions().Toggle(c.Req.Context(), c.User.ID, issue.ID, 0, c.Query("type"))
+	if err != nil {
+		if database.IsErrReactionInvalidType(err) {
+			c.Status(http.StatusUnprocessableEntity)
+		} else {
+			c.Error(err, "toggle reaction")
+		}
+		return
+	}
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
+func ToggleCommentReaction(c *context.Context) {
+	comment, err := database.GetCommentByID(c.ParamsInt64(":id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get comment by ID")
+		return
+	}
+
+	// Prevent guests accessing pull requests
+	issue := comment.Issue
+	if issue.RepoID != c.Repo.Repository.ID || (!c.Repo.HasAccess() && issue.IsPull) {
+		c.NotFound()
+		return
+	} else if comment.Type != database.COMMENT_TYPE_COMMENT {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	_, err = database.Handle.Reactions().Toggle(c.Req.Context(), c.User.ID, issue.ID, comment.ID, c.Query("type"))
+	if err != nil {
+		if database.IsErrReactionInvalidType(err) {
+			c.Status(http.StatusUnprocessableEntity)
+		} else {
+			c.Error(err, "toggle reaction")
+		}
+		return
+	}
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index) + "#" + comment.HashTag())
+}
+
 func Labels(c *context.Context) {
 	c.Data["Title"] = c.Tr("repo.labels")
 	c.Data["PageIsIssueList"] = true
@@ -1040,9 +1531,9 @@ func NewLabel(c *context.Context, f form.CreateLabel) {
 }
 
 func UpdateLabel(c *context.Context, f form.CreateLabel) {
-	l, err := database.GetLabelByID(f.ID)
+	l, err := database.GetLabelOfRepoByID(c.Repo.Repository.ID, f.ID)
 	if err != nil {
-		c.NotFoundOrError(err, "get label by ID")
+		c.NotFoundOrError(err, "get label of repository by ID")
 		return
 	}
 
@@ -1103,8 +1594,57 @@ func Milestones(c *context.Context) {
 		}
 		m.RenderedContent = string(markup.Markdown(m.Content, c.Repo.RepoLink, c.Repo.Repository.ComposeMetas()))
 	}
+
+	milestoneIDs := make([]int64, len(miles))
+	for i := range miles {
+		milestoneIDs[i] = miles[i].ID
+	}
+	trackedTimes, err := database.Handle.TrackedTimes().SumByMilestoneIDs(c.Req.Context(), milestoneIDs)
+	if err != nil {
+		c.Error(err, "sum tracked times by milestone IDs")
+		return
+	}
+	for _, m := range miles {
+		m.TrackedTime = trackedTimes[m.ID]
+	}
 	c.Data["Milestones"] = miles
 
+	// Milestones of the owner organization are available to the repository as
+	// well, which only count issues of the repository.
+	if page == 1 && c.Repo.Owner.IsOrganization() {
+		orgMiles, err := database.GetOrgMilestones(c.Repo.Owner.ID, -1, isShowClosed)
+		if err != nil {
+			c.Error(err, "get organization milestones")
+			return
+		}
+		for _, m := range orgMiles {
+			opts := &database.IssuesOptions{
+				RepoID:      c.Repo.Repository.ID,
+				MilestoneID: m.ID,
+			}
+			numOpenIssues, err := database.IssuesCount(opts)
+			if err != nil {
+				c.Error(err, "count open issues")
+				return
+			}
+			opts.IsClosed = true
+			numClosedIssues, err := database.IssuesCount(opts)
+			if err != nil {
+				c.Error(err, "count closed issues")
+				return
+			}
+
+			m.NumOpenIssues = int(numOpenIssues)
+			m.NumClosedIssues = int(numClosedIssues)
+			m.Completeness = 0
+			if m.NumOpenIssues+m.NumClosedIssues > 0 {
+				m.Completeness = m.NumClosedIssues * 100 / (m.NumOpenIssues + m.NumClosedIssues)
+			}
+			m.RenderedContent = string(markup.Markdown(m.Content, c.Repo.RepoLink, c.Repo.Repository.ComposeMetas()))
+		}
+		c.Data["OrgMilestones"] = orgMiles
+	}
+
 	if isShowClosed {
 		c.Data["State"] = "closed"
 	} else {
@@ -0,0 +1,153 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"github.com/unknwon/com"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+)
+
+const (
+	ISSUE_HISTORY = "repo/issue/history"
+)
+
+// contentRevision is a revision along with its changes compared to the
+// previous one.
+type contentRevision struct {
+	*database.ContentRevision
+	IsOriginal bool
+	Diff       template.HTML
+}
+
+// diffContents returns the HTML of the inline diff between two contents.
+func diffContents(before, after string) template.HTML {
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffCleanupSemantic(dmp.DiffMain(before, after, true))
+
+	var buf bytes.Buffer
+	for _, diff := range diffs {
+		text := html.EscapeString(diff.Text)
+		switch diff.Type {
+		case diffmatchpatch.DiffInsert:
+			buf.WriteString(`<ins class="added-code">` + text + `</ins>`)
+		case diffmatchpatch.DiffDelete:
+			buf.WriteString(`<del class="removed-code">` + text + `</del>`)
+		default:
+			buf.WriteString(text)
+		}
+	}
+	return template.HTML(buf.String())
+}
+
+// getHistoryTarget returns the issue and the ID of its comment whose history
+// is requested by the "comment_id" query parameter, which is zero for the body
+// of the issue.
+func getHistoryTarget(c *context.Context) (*database.Issue, int64) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return nil, 0
+	}
+
+	commentID := c.QueryInt64("comment_id")
+	if commentID > 0 {
+		comment, err := database.GetCommentByID(commentID)
+		if err != nil {
+			c.NotFoundOrError(err, "get comment by ID")
+			return nil, 0
+		} else if comment.IssueID != issue.ID {
+			c.NotFound()
+			return nil, 0
+		}
+	}
+	return issue, commentID
+}
+
+// ContentHistory shows all revisions of the body of an issue or of a comment,
+// along with the changes made by each edit.
+func ContentHistory(c *context.Context) {
+	issue, commentID := getHistoryTarget(c)
+	if c.Written() {
+		return
+	}
+	c.Data["Title"] = c.Tr("repo.issues.history.title", issue.Title, issue.Index)
+	c.Data["Issue"] = issue
+	c.Data["CommentID"] = commentID
+	c.Data["PageIsIssueList"] = !issue.IsPull
+	c.Data["PageIsPullList"] = issue.IsPull
+
+	revisions, err := database.Handle.ContentRevisions().List(c.Req.Context(), issue.ID, commentID)
+	if err != nil {
+		c.Error(err, "list content revisions")
+		return
+	}
+
+	editors := make(map[int64]*database.User)
+	history := make([]*contentRevision, len(revisions))
+	for i, r := range revisions {
+		if editors[r.EditorID] == nil {
+			editors[r.EditorID], err = database.Handle.Users().GetByID(c.Req.Context(), r.EditorID)
+			if err != nil {
+				if !database.IsErrUserNotExist(err) {
+					c.Error(err, "get user by ID")
+					return
+				}
+				editors[r.EditorID] = database.NewGhostUser()
+			}
+		}
+		r.Editor = editors[r.EditorID]
+
+		revision := &contentRevision{
+			ContentRevision: r,
+			IsOriginal:      i == 0,
+		}
+		if i == 0 {
+			revision.Diff = template.HTML(html.EscapeString(r.Content))
+		} else {
+			revision.Diff = diffContents(revisions[i-1].Content, r.Content)
+		}
+		// Show the latest revision first
+		history[len(revisions)-1-i] = revision
+	}
+	c.Data["Revisions"] = history
+	c.Data["CanDeleteRevisions"] = c.Repo.IsAdmin()
+
+	c.Success(ISSUE_HISTORY)
+}
+
+// DeleteContentRevision deletes a revision of the body of an issue or of a
+// comment, which is used to remove sensitive information from the history.
+func DeleteContentRevision(c *context.Context) {
+	issue, commentID := getHistoryTarget(c)
+	if c.Written() {
+		return
+	}
+
+	r, err := database.Handle.ContentRevisions().GetByID(c.Req.Context(), issue.ID, commentID, c.QueryInt64("id"))
+	if err != nil {
+		c.NotFoundOrError(err, "get content revision by ID")
+		return
+	}
+
+	if err = database.Handle.ContentRevisions().Delete(c.Req.Context(), r.ID); err != nil {
+		c.Flash.Error("Delete content revision: " + err.Error())
+	} else {
+		c.Flash.Success(c.Tr("repo.issues.history.deletion_success"))
+	}
+
+	redirect := c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index) + "/history"
+	if commentID > 0 {
+		redirect += "?comment_id=" + com.ToStr(commentID)
+	}
+	c.JSONSuccess(map[string]any{
+		"redirect": redirect,
+	})
+}
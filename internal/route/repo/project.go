@@ -0,0 +1,466 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/unknwon/com"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/form"
+)
+
+const (
+	PROJECTS     = "repo/projects/list"
+	PROJECT_NEW  = "repo/projects/new"
+	PROJECT_VIEW = "repo/projects/view"
+)
+
+// projectsContext prepares pages of projects of the context repository, or of
+// the context organization outside of repositories. It returns options to list
+// the projects and the link to them.
+func projectsContext(c *context.Context) (database.ListProjectsOptions, string) {
+	var (
+		opts database.ListProjectsOptions
+		link string
+	)
+	if c.Repo.Repository != nil {
+		opts.RepoID = c.Repo.Repository.ID
+		link = c.Repo.RepoLink + "/projects"
+		c.Data["CanWriteProjects"] = c.Repo.IsWriter()
+	} else {
+		opts.OwnerID = c.Org.Organization.ID
+		link = c.Org.OrgLink + "/projects"
+		c.Data["CanWriteProjects"] = c.Org.IsMember
+	}
+	c.Data["PageIsProjects"] = true
+	c.Data["ProjectsLink"] = link
+	return opts, link
+}
+
+// getProject returns the project by given ID that belongs to the context
+// repository or organization along with the link to its board, or responds
+// with 404.
+func getProject(c *context.Context, id int64) (*database.Project, string) {
+	opts, link := projectsContext(c)
+	p, err := database.Handle.Projects().GetByID(c.Req.Context(), id)
+	if err != nil {
+		c.NotFoundOrError(err, "get project by ID")
+		return nil, ""
+	}
+
+	if (opts.RepoID > 0 && p.RepoID != opts.RepoID) ||
+		(opts.RepoID == 0 && (p.RepoID != 0 || p.OwnerID != opts.OwnerID)) {
+		c.NotFound()
+		return nil, ""
+	}
+	c.Data["Project"] = p
+	return p, link + "/" + com.ToStr(p.ID)
+}
+
+func Projects(c *context.Context) {
+	c.Data["Title"] = c.Tr("repo.projects")
+	opts, _ := projectsContext(c)
+
+	var err error
+	opts.IsClosed = false
+	if c.Data["OpenCount"], err = database.Handle.Projects().Count(c.Req.Context(), opts); err != nil {
+		c.Error(err, "count open projects")
+		return
+	}
+	opts.IsClosed = true
+	if c.Data["ClosedCount"], err = database.Handle.Projects().Count(c.Req.Context(), opts); err != nil {
+		c.Error(err, "count closed projects")
+		return
+	}
+
+	opts.IsClosed = c.Query("state") == "closed"
+	c.Data["Projects"], err = database.Handle.Projects().List(c.Req.Context(), opts)
+	if err != nil {
+		c.Error(err, "list projects")
+		return
+	}
+
+	c.Data["IsShowClosed"] = opts.IsClosed
+	if opts.IsClosed {
+		c.Data["State"] = "closed"
+	} else {
+		c.Data["State"] = "open"
+	}
+	c.Success(PROJECTS)
+}
+
+func NewProject(c *context.Context) {
+	c.Data["Title"] = c.Tr("repo.projects.new")
+	projectsContext(c)
+	c.Data["default_columns"] = true
+	c.Success(PROJECT_NEW)
+}
+
+func NewProjectPost(c *context.Context, f form.CreateProject) {
+	c.Data["Title"] = c.Tr("repo.projects.new")
+	opts, link := projectsContext(c)
+
+	if c.HasError() {
+		c.Success(PROJECT_NEW)
+		return
+	}
+
+	p, err := database.Handle.Projects().Create(c.Req.Context(), database.CreateProjectOptions{
+		OwnerID:        opts.OwnerID,
+		RepoID:         opts.RepoID,
+		Name:           f.Title,
+		Description:    f.Content,
+		CreatorID:      c.User.ID,
+		DefaultColumns: f.DefaultColumns,
+	})
+	if err != nil {
+		c.Error(err, "create project")
+		return
+	}
+
+	c.Flash.Success(c.Tr("repo.projects.create_success", f.Title))
+	c.Redirect(link + "/" + com.ToStr(p.ID))
+}
+
+func EditProject(c *context.Context) {
+	c.Data["Title"] = c.Tr("repo.projects.edit")
+	c.Data["PageIsEditProject"] = true
+
+	p, _ := getProject(c, c.ParamsInt64(":id"))
+	if c.Written() {
+		return
+	}
+	c.Data["title"] = p.Name
+	c.Data["content"] = p.Description
+	c.Success(PROJECT_NEW)
+}
+
+func EditProjectPost(c *context.Context, f form.CreateProject) {
+	c.Data["Title"] = c.Tr("repo.projects.edit")
+	c.Data["PageIsEditProject"] = true
+
+	p, link := getProject(c, c.ParamsInt64(":id"))
+	if c.Written() {
+		return
+	}
+
+	if c.HasError() {
+		c.Success(PROJECT_NEW)
+		return
+	}
+
+	err := database.Handle.Projects().Update(c.Req.Context(), p.ID, database.UpdateProjectOptions{
+		Name:        &f.Title,
+		Description: &f.Content,
+	})
+	if err != nil {
+		c.Error(err, "update project")
+		return
+	}
+
+	c.Flash.Success(c.Tr("repo.projects.edit_success", f.Title))
+	c.Redirect(link)
+}
+
+func ChangeProjectStatus(c *context.Context) {
+	p, _ := getProject(c, c.ParamsInt64(":id"))
+	if c.Written() {
+		return
+	}
+
+	var isClosed bool
+	switch c.Params(":action") {
+	case "open":
+		isClosed = false
+	case "close":
+		isClosed = true
+	default:
+		c.NotFound()
+		return
+	}
+
+	err := database.Handle.Projects().Update(c.Req.Context(), p.ID, database.UpdateProjectOptions{
+		IsClosed: &isClosed,
+	})
+	if err != nil {
+		c.Error(err, "update project")
+		return
+	}
+
+	link := c.Data["ProjectsLink"].(string)
+	if isClosed {
+		c.Redirect(link + "?state=closed")
+	} else {
+		c.Redirect(link + "?state=open")
+	}
+}
+
+func DeleteProject(c *context.Context) {
+	p, _ := getProject(c, c.QueryInt64("id"))
+	if c.Written() {
+		return
+	}
+
+	if err := database.Handle.Projects().Delete(c.Req.Context(), p.ID); err != nil {
+		c.Flash.Error("Delete project: " + err.Error())
+	} else {
+		c.Flash.Success(c.Tr("repo.projects.deletion_success"))
+	}
+
+	c.JSONSuccess(map[string]any{
+		"redirect": c.Data["ProjectsLink"],
+	})
+}
+
+func ViewProject(c *context.Context) {
+	p, _ := getProject(c, c.ParamsInt64(":id"))
+	if c.Written() {
+		return
+	}
+	c.Data["Title"] = p.Name
+
+	columns, err := database.Handle.Projects().ListColumns(c.Req.Context(), p.ID)
+	if err != nil {
+		c.Error(err, "list columns")
+		return
+	}
+	cards, err := database.Handle.Projects().ListCards(c.Req.Context(), p.ID)
+	if err != nil {
+		c.Error(err, "list cards")
+		return
+	}
+	cards, err = database.LoadProjectCardIssues(c.Req.Context(), c.User, cards)
+	if err != nil {
+		c.Error(err, "load card issues")
+		return
+	}
+
+	columnsByID := make(map[int64]*database.ProjectColumn, len(columns))
+	for _, column := range columns {
+		columnsByID[column.ID] = column
+	}
+	for _, card := range cards {
+		if column := columnsByID[card.ColumnID]; column != nil {
+			column.Cards = append(column.Cards, card)
+		}
+	}
+
+	c.Data["Columns"] = columns
+	c.Data["Automations"] = []string{
+		database.ProjectColumnAutomationClosed,
+		database.ProjectColumnAutomationReopened,
+		database.ProjectColumnAutomationMerged,
+	}
+	c.Success(PROJECT_VIEW)
+}
+
+func NewProjectColumnPost(c *context.Context, f form.ProjectColumn) {
+	p, link := getProject(c, c.ParamsInt64(":id"))
+	if c.Written() {
+		return
+	}
+
+	if c.HasError() {
+		c.Flash.Error(c.Data["ErrorMsg"].(string))
+		c.Redirect(link)
+		return
+	}
+
+	_, err := database.Handle.Projects().CreateColumn(c.Req.Context(), p.ID, f.Title, f.Automation)
+	if err != nil {
+		if database.IsErrProjectColumnInvalidAutomation(err) {
+			c.Flash.Error(c.Tr("repo.projects.columns.invalid_automation"))
+		} else {
+			c.Error(err, "create column")
+			return
+		}
+	}
+	c.Redirect(link)
+}
+
+// getProjectColumn returns the column by given ID in the project, or responds
+// with 404.
+func getProjectColumn(c *context.Context, p *database.Project, id int64) *database.ProjectColumn {
+	column, err := database.Handle.Projects().GetColumnByID(c.Req.Context(), p.ID, id)
+	if err != nil {
+		c.NotFoundOrError(err, "get column by ID")
+		return nil
+	}
+	return column
+}
+
+func EditProjectColumnPost(c *context.Context, f form.ProjectColumn) {
+	p, link := getProject(c, c.ParamsInt64(":id"))
+	if c.Written() {
+		return
+	}
+	column := getProjectColumn(c, p, c.ParamsInt64(":column_id"))
+	if c.Written() {
+		return
+	}
+
+	if c.HasError() {
+		c.Flash.Error(c.Data["ErrorMsg"].(string))
+		c.Redirect(link)
+		return
+	}
+
+	err := database.Handle.Projects().UpdateColumn(c.Req.Context(), column, database.UpdateProjectColumnOptions{
+		Name:       &f.Title,
+		Automation: &f.Automation,
+	})
+	if err != nil {
+		if database.IsErrProjectColumnInvalidAutomation(err) {
+			c.Flash.Error(c.Tr("repo.projects.columns.invalid_automation"))
+		} else {
+			c.Error(err, "update column")
+			return
+		}
+	}
+	c.Redirect(link)
+}
+
+func DeleteProjectColumn(c *context.Context) {
+	p, link := getProject(c, c.ParamsInt64(":id"))
+	if c.Written() {
+		return
+	}
+	column := getProjectColumn(c, p, c.ParamsInt64(":column_id"))
+	if c.Written() {
+		return
+	}
+
+	if err := database.Handle.Projects().DeleteColumn(c.Req.Context(), column); err != nil {
+		c.Error(err, "delete column")
+		return
+	}
+	c.Redirect(link)
+}
+
+func NewProjectCardPost(c *context.Context, f form.ProjectCard) {
+	p, link := getProject(c, c.ParamsInt64(":id"))
+	if c.Written() {
+		return
+	}
+	column := getProjectColumn(c, p, f.ColumnID)
+	if c.Written() {
+		return
+	}
+
+	opts := database.CreateProjectCardOptions{
+		Note:      strings.TrimSpace(f.Note),
+		CreatorID: c.User.ID,
+	}
+	if strings.TrimSpace(f.Issue) != "" {
+		issue, err := database.GetProjectIssueByRef(c.Req.Context(), c.User, p, f.Issue)
+		if err != nil {
+			if database.IsErrIssueNotExist(err) {
+				c.Flash.Error(c.Tr("repo.projects.cards.issue_not_exist", f.Issue))
+				c.Redirect(link)
+			} else {
+				c.Error(err, "get project issue by reference")
+			}
+			return
+		}
+		opts.IssueID = issue.ID
+	} else if opts.Note == "" {
+		c.Flash.Error(c.Tr("repo.projects.cards.empty"))
+		c.Redirect(link)
+		return
+	}
+
+	_, err := database.Handle.Projects().CreateCard(c.Req.Context(), column, opts)
+	if err != nil {
+		if database.IsErrProjectCardAlreadyExist(err) {
+			c.Flash.Error(c.Tr("repo.projects.cards.already_exist", f.Issue))
+		} else {
+			c.Error(err, "create card")
+			return
+		}
+	}
+	c.Redirect(link)
+}
+
+// getProjectCard returns the card by given ID in the project, or responds with
+// 404.
+func getProjectCard(c *context.Context, p *database.Project, id int64) *database.ProjectCard {
+	card, err := database.Handle.Projects().GetCardByID(c.Req.Context(), p.ID, id)
+	if err != nil {
+		c.NotFoundOrError(err, "get card by ID")
+		return nil
+	}
+	return card
+}
+
+func EditProjectCardPost(c *context.Context, f form.ProjectCard) {
+	p, link := getProject(c, c.ParamsInt64(":id"))
+	if c.Written() {
+		return
+	}
+	card := getProjectCard(c, p, c.ParamsInt64(":card_id"))
+	if c.Written() {
+		return
+	}
+
+	note := strings.TrimSpace(f.Note)
+	if !card.IsNote() {
+		c.NotFound()
+		return
+	} else if note == "" {
+		c.Flash.Error(c.Tr("repo.projects.cards.empty"))
+		c.Redirect(link)
+		return
+	}
+
+	if err := database.Handle.Projects().UpdateCardNote(c.Req.Context(), card, note); err != nil {
+		c.Error(err, "update card note")
+		return
+	}
+	c.Redirect(link)
+}
+
+func DeleteProjectCard(c *context.Context) {
+	p, link := getProject(c, c.ParamsInt64(":id"))
+	if c.Written() {
+		return
+	}
+	card := getProjectCard(c, p, c.ParamsInt64(":card_id"))
+	if c.Written() {
+		return
+	}
+
+	if err := database.Handle.Projects().DeleteCard(c.Req.Context(), card); err != nil {
+		c.Error(err, "delete card")
+		return
+	}
+	c.Redirect(link)
+}
+
+// MoveProjectCard moves a card to the position of the column, which is used by
+// drag-and-drop on the board.
+func MoveProjectCard(c *context.Context) {
+	p, _ := getProject(c, c.ParamsInt64(":id"))
+	if c.Written() {
+		return
+	}
+	card := getProjectCard(c, p, c.ParamsInt64(":card_id"))
+	if c.Written() {
+		return
+	}
+	column := getProjectColumn(c, p, c.QueryInt64("column_id"))
+	if c.Written() {
+		return
+	}
+
+	if err := database.Handle.Projects().MoveCard(c.Req.Context(), card, column.ID, c.QueryInt("position")); err != nil {
+		c.Error(err, "move card")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -426,6 +426,11 @@ func MergePullRequest(c *context.Context) {
 	pr.Issue = issue
 	pr.Issue.Repo = c.Repo.Repository
 	if err = pr.Merge(c.User, c.Repo.GitRepo, database.MergeStyle(c.Query("merge_style")), c.Query("commit_description")); err != nil {
+		if database.IsErrIssueBlocked(err) {
+			c.Flash.Error(c.Tr("repo.pulls.blocked_by_dependencies"))
+			c.Redirect(c.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+			return
+		}
 		c.Error(err, "merge")
 		return
 	}
@@ -694,7 +699,7 @@ func CompareAndPullRequestPost(c *context.Context, f form.NewIssue) {
 		return
 	}
 
-	labelIDs, milestoneID, assigneeID := ValidateRepoMetas(c, f)
+	labelIDs, milestoneID, assigneeIDs := ValidateRepoMetas(c, f)
 	if c.Written() {
 		return
 	}
@@ -730,7 +735,6 @@ func CompareAndPullRequestPost(c *context.Context, f form.NewIssue) {
 		PosterID:    c.User.ID,
 		Poster:      c.User,
 		MilestoneID: milestoneID,
-		AssigneeID:  assigneeID,
 		IsPull:      true,
 		Content:     f.Content,
 	}
@@ -747,7 +751,7 @@ func CompareAndPullRequestPost(c *context.Context, f form.NewIssue) {
 	}
 	// FIXME: check error in the case two people send pull request at almost same time, give nice error prompt
 	// instead of 500.
-	if err := database.NewPullRequest(repo, pullIssue, labelIDs, attachments, pullRequest, patch); err != nil {
+	if err := database.NewPullRequest(repo, pullIssue, labelIDs, assigneeIDs, attachments, pullRequest, patch); err != nil {
 		c.Error(err, "new pull request")
 		return
 	} else if err := pullRequest.PushToBaseRepo(); err != nil {
@@ -50,6 +50,7 @@ func Releases(c *context.Context) {
 	c.Data["Title"] = c.Tr("repo.release.releases")
 	c.Data["PageIsViewFiles"] = true
 	c.Data["PageIsReleaseList"] = true
+	c.SetFeedLinks(c.Repo.RepoLink+"/releases", nil)
 
 	tagsPage, err := gitutil.Module.ListTagsAfter(c.Repo.GitRepo.Path(), c.Query("after"), 10)
 	if err != nil {
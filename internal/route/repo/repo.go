@@ -173,6 +173,12 @@ func MigratePost(c *context.Context, f form.MigrateRepo) {
 		return
 	}
 
+	if !f.IsValidService() {
+		c.Data["Err_Service"] = true
+		c.RenderWithErr(c.Tr("repo.migrate.invalid_service"), MIGRATE, &f)
+		return
+	}
+
 	remoteAddr, err := f.ParseRemoteAddr(c.User)
 	if err != nil {
 		if database.IsErrInvalidCloneAddr(err) {
@@ -206,6 +212,11 @@ func MigratePost(c *context.Context, f form.MigrateRepo) {
 	})
 	if err == nil {
 		log.Trace("Repository migrated [%d]: %s/%s", repo.ID, ctxUser.Name, f.RepoName)
+		if opts, ok := f.RepoMigrationOptions(c.User.ID); ok {
+			if err = database.StartRepoMigration(c.Req.Context(), repo.ID, opts); err != nil {
+				log.Error("Failed to start migration of repository data [repo_id: %d]: %v", repo.ID, err)
+			}
+		}
 		c.Redirect(conf.Server.Subpath + "/" + ctxUser.Name + "/" + f.RepoName)
 		return
 	}
@@ -40,9 +40,28 @@ func Settings(c *context.Context) {
 	c.Title("repo.settings")
 	c.PageIs("SettingsOptions")
 	c.RequireAutosize()
+
+	if !prepareRepoMigration(c) {
+		return
+	}
 	c.Success(SETTINGS_OPTIONS)
 }
 
+// prepareRepoMigration sets the migration of repository data to the context
+// data if any. It returns false if an error has been responded.
+func prepareRepoMigration(c *context.Context) bool {
+	m, err := database.Handle.RepoMigrations().GetByRepoID(c.Req.Context(), c.Repo.Repository.ID)
+	if err != nil {
+		if database.IsErrRepoMigrationNotExist(err) {
+			return true
+		}
+		c.Error(err, "get repository migration")
+		return false
+	}
+	c.Data["RepoMigration"] = m
+	return true
+}
+
 func SettingsPost(c *context.Context, f form.RepoSetting) {
 	c.Title("repo.settings")
 	c.PageIs("SettingsOptions")
@@ -142,6 +161,27 @@ func SettingsPost(c *context.Context, f form.RepoSetting) {
 		c.Flash.Info(c.Tr("repo.settings.mirror_sync_in_progress"))
 		c.Redirect(repo.Link() + "/settings")
 
+	case "resume-migration":
+		m, err := database.Handle.RepoMigrations().GetByRepoID(c.Req.Context(), repo.ID)
+		if err != nil {
+			c.NotFoundOrError(err, "get repository migration")
+			return
+		}
+
+		if !m.IsDone() && !m.IsRunning() {
+			// The access token is cleared when the migration failed.
+			if f.AuthToken != "" {
+				err = database.Handle.RepoMigrations().SetAuthToken(c.Req.Context(), repo.ID, f.AuthToken)
+				if err != nil {
+					c.Error(err, "set migration access token")
+					return
+				}
+			}
+			go database.RunRepoMigration(repo.ID)
+		}
+		c.Flash.Info(c.Tr("repo.settings.migration_in_progress"))
+		c.Redirect(repo.Link() + "/settings")
+
 	case "advanced":
 		repo.EnableWiki = f.EnableWiki
 		repo.AllowPublicWiki = f.AllowPublicWiki
@@ -149,6 +189,7 @@ func SettingsPost(c *context.Context, f form.RepoSetting) {
 		repo.ExternalWikiURL = f.ExternalWikiURL
 		repo.EnableIssues = f.EnableIssues
 		repo.AllowPublicIssues = f.AllowPublicIssues
+		repo.AllowClosingBlocked = f.AllowClosingBlocked
 		repo.EnableExternalTracker = f.EnableExternalTracker
 		repo.ExternalTrackerURL = f.ExternalTrackerURL
 		repo.ExternalTrackerFormat = f.TrackerURLFormat
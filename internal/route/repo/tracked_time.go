@@ -0,0 +1,249 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/unknwon/com"
+	log "unknwon.dev/clog/v2"
+
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/strutil"
+	"gogs.io/gogs/internal/tool"
+)
+
+const (
+	TRACKED_TIMES = "repo/issue/times"
+)
+
+// createTrackingComment creates a time tracking comment on the issue. The
+// failure is only logged because the time has been tracked anyway.
+func createTrackingComment(c *context.Context, issue *database.Issue, typ database.CommentType, seconds int64) {
+	err := database.CreateTrackingComment(c.User, c.Repo.Repository, issue, typ, seconds)
+	if err != nil {
+		log.Error("Failed to create time tracking comment: %v", err)
+	}
+}
+
+func StartIssueStopwatch(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	err := database.Handle.TrackedTimes().StartStopwatch(c.Req.Context(), c.User.ID, issue.ID)
+	if err != nil {
+		if database.IsErrStopwatchAlreadyStarted(err) {
+			c.Flash.Error(c.Tr("repo.issues.tracking.already_started"))
+			c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+			return
+		}
+		c.Error(err, "start stopwatch")
+		return
+	}
+
+	createTrackingComment(c, issue, database.COMMENT_TYPE_START_TRACKING, 0)
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
+func StopIssueStopwatch(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	t, err := database.Handle.TrackedTimes().StopStopwatch(c.Req.Context(), c.User.ID, issue.ID)
+	if err != nil {
+		if database.IsErrStopwatchNotExist(err) {
+			c.Flash.Error(c.Tr("repo.issues.tracking.not_started"))
+			c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+			return
+		}
+		c.Error(err, "stop stopwatch")
+		return
+	}
+
+	var seconds int64
+	if t != nil {
+		seconds = t.Time
+	}
+	createTrackingComment(c, issue, database.COMMENT_TYPE_STOP_TRACKING, seconds)
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
+func CancelIssueStopwatch(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	err := database.Handle.TrackedTimes().CancelStopwatch(c.Req.Context(), c.User.ID, issue.ID)
+	if err != nil {
+		if database.IsErrStopwatchNotExist(err) {
+			c.Flash.Error(c.Tr("repo.issues.tracking.not_started"))
+			c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+			return
+		}
+		c.Error(err, "cancel stopwatch")
+		return
+	}
+
+	createTrackingComment(c, issue, database.COMMENT_TYPE_CANCEL_TRACKING, 0)
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
+func AddIssueTrackedTime(c *context.Context) {
+	issue := getActionIssue(c)
+	if c.Written() {
+		return
+	}
+
+	// Accepts Go duration strings with optional spaces, e.g. "1h 30m".
+	d, err := time.ParseDuration(strings.ReplaceAll(c.QueryTrim("duration"), " ", ""))
+	if err != nil || d < time.Second {
+		c.Flash.Error(c.Tr("repo.issues.tracking.invalid_duration"))
+		c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+		return
+	}
+
+	seconds := int64(d / time.Second)
+	_, err = database.Handle.TrackedTimes().Add(c.Req.Context(), c.User.ID, issue.ID, seconds, database.AddTrackedTimeOptions{})
+	if err != nil {
+		c.Error(err, "add tracked time")
+		return
+	}
+
+	createTrackingComment(c, issue, database.COMMENT_TYPE_ADD_TIME_MANUAL, seconds)
+	c.Redirect(c.Repo.RepoLink + "/issues/" + com.ToStr(issue.Index))
+}
+
+// parseTrackedTimesOptions parses filters of tracked times from the query, it
+// returns false if any of filters is invalid. Dates are in the form of
+// "2006-01-02" and both ends are inclusive.
+func parseTrackedTimesOptions(c *context.Context) (database.ListTrackedTimesOptions, bool) {
+	opts := database.ListTrackedTimesOptions{
+		RepoID:      c.Repo.Repository.ID,
+		MilestoneID: c.QueryInt64("milestone"),
+	}
+
+	if since := c.QueryTrim("since"); since != "" {
+		t, err := time.ParseInLocation("2006-01-02", since, time.Local)
+		if err != nil {
+			return opts, false
+		}
+		opts.Since = t
+	}
+	if until := c.QueryTrim("until"); until != "" {
+		t, err := time.ParseInLocation("2006-01-02", until, time.Local)
+		if err != nil {
+			return opts, false
+		}
+		opts.Before = t.AddDate(0, 0, 1)
+	}
+
+	if username := c.QueryTrim("user"); username != "" {
+		u, err := database.Handle.Users().GetByUsername(c.Req.Context(), username)
+		if err != nil {
+			if !database.IsErrUserNotExist(err) {
+				c.Error(err, "get user by name")
+			}
+			return opts, false
+		}
+		opts.UserID = u.ID
+	}
+	return opts, true
+}
+
+func TrackedTimes(c *context.Context) {
+	c.Data["Title"] = c.Tr("repo.issues.tracking.title")
+	c.Data["PageIsIssueList"] = true
+	c.Data["PageIsTrackedTimes"] = true
+	c.Data["Since"] = c.QueryTrim("since")
+	c.Data["Until"] = c.QueryTrim("until")
+	c.Data["Username"] = c.QueryTrim("user")
+	c.Data["MilestoneID"] = c.QueryInt64("milestone")
+	c.Data["ExportLink"] = c.Repo.RepoLink + "/times/export?" + c.Req.URL.RawQuery
+
+	milestones, err := database.GetAvailableMilestonesByRepoID(c.Repo.Repository.ID)
+	if err != nil {
+		c.Error(err, "get available milestones by repository ID")
+		return
+	}
+	c.Data["Milestones"] = milestones
+
+	opts, ok := parseTrackedTimesOptions(c)
+	if c.Written() {
+		return
+	} else if !ok {
+		c.Flash.Error(c.Tr("repo.issues.tracking.invalid_filters"), true)
+		c.Data["TotalTrackedTime"] = int64(0)
+		c.Success(TRACKED_TIMES)
+		return
+	}
+
+	times, err := database.Handle.TrackedTimes().List(c.Req.Context(), opts)
+	if err != nil {
+		c.Error(err, "list tracked times")
+		return
+	}
+	err = database.Handle.TrackedTimes().LoadAttributes(c.Req.Context(), times)
+	if err != nil {
+		c.Error(err, "load attributes")
+		return
+	}
+
+	var total int64
+	for _, t := range times {
+		total += t.Time
+	}
+	c.Data["TrackedTimes"] = times
+	c.Data["TotalTrackedTime"] = total
+	c.Success(TRACKED_TIMES)
+}
+
+func ExportTrackedTimes(c *context.Context) {
+	opts, ok := parseTrackedTimesOptions(c)
+	if c.Written() {
+		return
+	} else if !ok {
+		// The page of tracked times reports invalid filters
+		c.Redirect(c.Repo.RepoLink + "/times?" + c.Req.URL.RawQuery)
+		return
+	}
+
+	times, err := database.Handle.TrackedTimes().List(c.Req.Context(), opts)
+	if err != nil {
+		c.Error(err, "list tracked times")
+		return
+	}
+	err = database.Handle.TrackedTimes().LoadAttributes(c.Req.Context(), times)
+	if err != nil {
+		c.Error(err, "load attributes")
+		return
+	}
+
+	c.Resp.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	c.Resp.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s-times.csv"`, c.Repo.Owner.Name, c.Repo.Repository.Name))
+
+	w := csv.NewWriter(c.Resp)
+	_ = w.Write([]string{"Date", "User", "Issue", "Title", "Seconds", "Duration"})
+	for _, t := range times {
+		_ = w.Write([]string{
+			t.Created().Format(time.RFC3339),
+			strutil.EscapeCSVCell(t.User.Name),
+			"#" + com.ToStr(t.Issue.Index),
+			strutil.EscapeCSVCell(t.Issue.Title),
+			strconv.FormatInt(t.Time, 10),
+			tool.FormatDuration(t.Time),
+		})
+	}
+	w.Flush()
+}
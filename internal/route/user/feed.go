@@ -0,0 +1,193 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/jaytaylor/html2text"
+	log "unknwon.dev/clog/v2"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/feed"
+	"gogs.io/gogs/internal/template"
+	"gogs.io/gogs/internal/tool"
+)
+
+// ActivityFeed serves the feed of the activity of the user or the organization,
+// which includes private activity only when the viewer has access to it.
+func ActivityFeed(c *context.Context, puser *context.ParamsUser) {
+	var (
+		actions []*database.Action
+		err     error
+	)
+	if puser.IsOrganization() {
+		actions, err = database.Handle.Actions().ListByOrganization(c.Req.Context(), puser.ID, c.UserID(), 0)
+	} else {
+		actions, err = database.Handle.Actions().ListByUser(c.Req.Context(), puser.ID, c.UserID(), 0, true)
+	}
+	if err != nil {
+		c.Error(err, "list actions")
+		return
+	}
+
+	f := &feed.Feed{
+		Title:       c.Tr("feed.activity", puser.DisplayName()),
+		Link:        conf.Server.ExternalURL + puser.Name,
+		Description: puser.Description,
+		Updated:     puser.Updated,
+		Items:       make([]*feed.Item, 0, len(actions)),
+	}
+	for _, act := range actions {
+		f.Items = append(f.Items, actionFeedItem(c, act))
+	}
+	c.Feed(f)
+}
+
+// actionFeedItem returns the feed item of the action, which is described the
+// same way as on the dashboard.
+func actionFeedItem(c *context.Context, act *database.Action) *feed.Item {
+	repoURL := conf.Server.ExternalURL + act.GetRepoPath()
+	link := repoURL
+
+	var (
+		key    string
+		args   []any
+		detail string
+	)
+	switch act.OpType {
+	case database.ActionCreateRepo:
+		key, args = "action.create_repo", []any{repoURL, act.ShortRepoPath()}
+	case database.ActionRenameRepo:
+		key, args = "action.rename_repo", []any{act.GetContent(), repoURL, act.ShortRepoPath()}
+	case database.ActionTransferRepo:
+		key, args = "action.transfer_repo", []any{act.GetContent(), repoURL, act.ShortRepoPath()}
+	case database.ActionForkRepo:
+		key, args = "action.fork_repo", []any{repoURL, act.ShortRepoPath()}
+
+	case database.ActionCommitRepo, database.ActionMirrorSyncPush:
+		key = "action.commit_repo"
+		if act.OpType == database.ActionMirrorSyncPush {
+			key = "action.mirror_sync_push"
+		}
+		args = []any{repoURL, template.EscapePound(act.GetBranch()), act.GetBranch(), act.ShortRepoPath()}
+		link = repoURL + "/src/" + template.EscapePound(act.GetBranch())
+
+		push := template.ActionContent2Commits(act)
+		var buf strings.Builder
+		buf.WriteString("<ul>")
+		for _, commit := range push.Commits {
+			_, _ = fmt.Fprintf(&buf, `<li><a href="%s/commit/%s">%s</a> %s</li>`,
+				repoURL, commit.Sha1, tool.ShortSHA1(commit.Sha1), html.EscapeString(commit.Message))
+		}
+		if push.Len > 1 && push.CompareURL != "" {
+			link = conf.Server.ExternalURL + push.CompareURL
+			_, _ = fmt.Fprintf(&buf, `<li><a href="%s">%s</a></li>`, link, c.Tr("action.compare_commits", push.Len))
+		}
+		buf.WriteString("</ul>")
+		detail = buf.String()
+
+	case database.ActionCreateIssue, database.ActionCreatePullRequest,
+		database.ActionCommentIssue, database.ActionMergePullRequest,
+		database.ActionCloseIssue, database.ActionReopenIssue,
+		database.ActionClosePullRequest, database.ActionReopenPullRequest:
+		infos := act.GetIssueInfos()
+		index := infos[0]
+
+		var keyName string
+		switch act.OpType {
+		case database.ActionCreateIssue:
+			keyName = "create_issue"
+		case database.ActionCreatePullRequest:
+			keyName = "create_pull_request"
+		case database.ActionCommentIssue:
+			keyName = "comment_issue"
+		case database.ActionMergePullRequest:
+			keyName = "merge_pull_request"
+		case database.ActionCloseIssue:
+			keyName = "close_issue"
+		case database.ActionReopenIssue:
+			keyName = "reopen_issue"
+		case database.ActionClosePullRequest:
+			keyName = "close_pull_request"
+		case database.ActionReopenPullRequest:
+			keyName = "reopen_pull_request"
+		}
+		key, args = "action."+keyName, []any{repoURL, index, act.ShortRepoPath()}
+
+		if strings.Contains(keyName, "pull_request") {
+			link = repoURL + "/pulls/" + index
+		} else {
+			link = repoURL + "/issues/" + index
+		}
+
+		switch act.OpType {
+		case database.ActionCreateIssue, database.ActionCreatePullRequest, database.ActionMergePullRequest:
+			if len(infos) > 1 {
+				detail = "<p>" + html.EscapeString(infos[1]) + "</p>"
+			}
+		case database.ActionCommentIssue:
+			detail = "<p>" + html.EscapeString(act.GetIssueTitle()) + "</p>"
+			if len(infos) > 1 {
+				detail += "<blockquote>" + html.EscapeString(infos[1]) + "</blockquote>"
+			}
+		default:
+			detail = "<p>" + html.EscapeString(act.GetIssueTitle()) + "</p>"
+		}
+
+	case database.ActionCreateBranch:
+		key, args = "action.create_branch", []any{repoURL, template.EscapePound(act.GetBranch()), act.GetBranch(), act.ShortRepoPath()}
+		link = repoURL + "/src/" + template.EscapePound(act.GetBranch())
+	case database.ActionDeleteBranch:
+		key, args = "action.delete_branch", []any{repoURL, act.GetBranch(), act.ShortRepoPath()}
+	case database.ActionPushTag:
+		key, args = "action.push_tag", []any{repoURL, act.GetBranch(), act.ShortRepoPath()}
+		link = repoURL + "/src/" + act.GetBranch()
+	case database.ActionDeleteTag:
+		key, args = "action.delete_tag", []any{repoURL, act.GetBranch(), act.ShortRepoPath()}
+	case database.ActionMirrorSyncCreate:
+		key, args = "action.mirror_sync_create", []any{repoURL, act.GetBranch(), act.ShortRepoPath()}
+		link = repoURL + "/src/" + act.GetBranch()
+	case database.ActionMirrorSyncDelete:
+		key, args = "action.mirror_sync_delete", []any{repoURL, act.GetBranch(), act.ShortRepoPath()}
+	case database.ActionTransferIssue:
+		infos := act.GetIssueInfos()
+		key, args = "action.transfer_issue", []any{repoURL, infos[0], act.ShortRepoPath()}
+		if len(infos) > 1 {
+			key, args = "action.transfer_issue_to", append(args, infos[1])
+		}
+		link = repoURL + "/issues/" + infos[0]
+	}
+
+	// Branch names and old repository names are not escaped in locale strings
+	for i := range args {
+		if s, ok := args[i].(string); ok {
+			args[i] = html.EscapeString(s)
+		}
+	}
+	summary := html.EscapeString(act.ShortRepoPath())
+	if key != "" {
+		summary = c.Tr(key, args...)
+	}
+	title, err := html2text.FromString(summary, html2text.Options{OmitLinks: true})
+	if err != nil {
+		log.Error("Failed to convert summary of action [%d] to text: %v", act.ID, err)
+		title = act.ShortRepoPath()
+	}
+
+	actUserURL := conf.Server.ExternalURL + act.ActUserName
+	return &feed.Item{
+		ID:      fmt.Sprintf("%saction/%d", conf.Server.ExternalURL, act.ID),
+		Title:   act.ShortActUserName() + " " + title,
+		Link:    link,
+		Content: fmt.Sprintf(`<p><a href="%s">%s</a> %s</p>`, actUserURL, html.EscapeString(act.ShortActUserName()), summary) + detail,
+		Author:  feed.Author{Name: act.ActUserName},
+		Created: act.Created,
+	}
+}
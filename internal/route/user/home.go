@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/unknwon/com"
 	"github.com/unknwon/paginater"
@@ -314,6 +315,41 @@ func Issues(c *context.Context) {
 		issueOptions.PosterID = ctxUser.ID
 	}
 
+	keyword := strings.TrimSpace(c.Query("q"))
+	if keyword != "" {
+		issueOptions.Query, err = database.ParseIssueQuery(keyword, c.User)
+		if err != nil {
+			c.Error(err, "parse issue query")
+			return
+		}
+
+		// The state qualifier selects the tab, so both tabs count matched issues.
+		if issueOptions.Query.IsClosed != nil {
+			isShowClosed = *issueOptions.Query.IsClosed
+			issueOptions.Query.IsClosed = nil
+		}
+		if issueOptions.Query.SortType != "" {
+			sortType = issueOptions.Query.SortType
+		}
+
+		// Search in all repositories the user has access to, not only the owned ones.
+		if !ctxUser.IsOrganization() && filterMode == database.FILTER_MODE_YOUR_REPOS {
+			issueOptions.RepoIDs, err = database.GetAccessibleRepoIDs(ctxUser.ID)
+			if err != nil {
+				c.Error(err, "get accessible repository IDs")
+				return
+			}
+			if !isPullList {
+				issueOptions.RepoIDs, err = database.FilterRepositoryWithIssues(issueOptions.RepoIDs)
+				if err != nil {
+					c.Error(err, "filter repositories with issues")
+					return
+				}
+			}
+		}
+		issueOptions.IsClosed = isShowClosed
+	}
+
 	issues, err := database.Issues(issueOptions)
 	if err != nil {
 		c.Error(err, "list issues")
@@ -347,6 +383,19 @@ func Issues(c *context.Context) {
 	}
 
 	issueStats := database.GetUserIssueStats(repoID, ctxUser.ID, userRepoIDs, filterMode, isPullList)
+	if issueOptions.Query != nil {
+		countOptions := *issueOptions
+		countOptions.IsClosed = false
+		if issueStats.OpenCount, err = database.IssuesCount(&countOptions); err != nil {
+			c.Error(err, "count open issues")
+			return
+		}
+		countOptions.IsClosed = true
+		if issueStats.ClosedCount, err = database.IssuesCount(&countOptions); err != nil {
+			c.Error(err, "count closed issues")
+			return
+		}
+	}
 
 	var total int
 	if !isShowClosed {
@@ -361,6 +410,7 @@ func Issues(c *context.Context) {
 	c.Data["IssueStats"] = issueStats
 	c.Data["ViewType"] = string(filterMode)
 	c.Data["SortType"] = sortType
+	c.Data["Keyword"] = keyword
 	c.Data["RepoID"] = repoID
 	c.Data["IsShowClosed"] = isShowClosed
 
@@ -397,6 +447,7 @@ func showOrgProfile(c *context.Context) {
 
 	org := c.Org.Organization
 	c.Data["Title"] = org.FullName
+	c.SetFeedLinks(org.HomeURLPath(), nil)
 
 	page := c.QueryInt("page")
 	if page <= 0 {
@@ -0,0 +1,99 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/unknwon/paginater"
+
+	"gogs.io/gogs/internal/conf"
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+)
+
+const (
+	NOTIFICATIONS = "user/notification/list"
+)
+
+func Notifications(c *context.Context) {
+	c.Title("notifications")
+	c.PageIs("Notifications")
+
+	status, ok := database.ParseNotificationStatus(c.Query("status"))
+	if !ok {
+		status = database.NotificationStatusUnread
+	}
+	c.Data["Status"] = status.String()
+
+	page := c.QueryInt("page")
+	if page < 1 {
+		page = 1
+	}
+	opts := database.ListNotificationsOptions{
+		Statuses: []database.NotificationStatus{status},
+		Page:     page,
+		PageSize: conf.UI.IssuePagingNum,
+	}
+	total, err := database.Handle.Notifications().Count(c.Req.Context(), c.User.ID, opts)
+	if err != nil {
+		c.Error(err, "count notifications")
+		return
+	}
+	c.Data["Page"] = paginater.New(int(total), opts.PageSize, page, 5)
+
+	notifications, err := database.Handle.Notifications().List(c.Req.Context(), c.User.ID, opts)
+	if err != nil {
+		c.Error(err, "list notifications")
+		return
+	}
+	notifications, err = database.LoadNotificationIssues(c.Req.Context(), c.User, notifications)
+	if err != nil {
+		c.Error(err, "load notification issues")
+		return
+	}
+	c.Data["Notifications"] = notifications
+
+	pinnedCount, err := database.Handle.Notifications().Count(c.Req.Context(), c.User.ID,
+		database.ListNotificationsOptions{Statuses: []database.NotificationStatus{database.NotificationStatusPinned}})
+	if err != nil {
+		c.Error(err, "count pinned notifications")
+		return
+	}
+	c.Data["PinnedCount"] = pinnedCount
+
+	c.Success(NOTIFICATIONS)
+}
+
+func NotificationStatusPost(c *context.Context) {
+	status, ok := database.ParseNotificationStatus(c.Query("status"))
+	if !ok {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+
+	err := database.Handle.Notifications().SetStatus(c.Req.Context(), c.User.ID, c.QueryInt64("id"), status)
+	if err != nil {
+		c.NotFoundOrError(err, "set notification status")
+		return
+	}
+
+	// Go back to the tab where the status was changed
+	tab, ok := database.ParseNotificationStatus(c.Query("tab"))
+	if !ok {
+		tab = database.NotificationStatusUnread
+	}
+	c.RedirectSubpath("/notifications?status=" + tab.String() + "&page=" + strconv.Itoa(c.QueryInt("page")))
+}
+
+func NotificationsMarkAllReadPost(c *context.Context) {
+	if err := database.Handle.Notifications().MarkAllRead(c.Req.Context(), c.User.ID, 0, 0); err != nil {
+		c.Error(err, "mark all notifications read")
+		return
+	}
+	c.Flash.Success(c.Tr("notification.mark_all_read_success"))
+	c.RedirectSubpath("/notifications")
+}
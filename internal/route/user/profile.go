@@ -41,6 +41,7 @@ func Profile(c *context.Context, puser *context.ParamsUser) {
 	c.Title(puser.DisplayName())
 	c.PageIs("UserProfile")
 	c.Data["Owner"] = puser
+	c.SetFeedLinks(puser.HomeURLPath(), nil)
 
 	orgs, err := database.GetOrgsByUserID(puser.ID, c.IsLogged && (c.User.IsAdmin || c.User.ID == puser.ID))
 	if err != nil {
@@ -47,6 +47,7 @@ const (
 	SETTINGS_AVATAR                    = "user/settings/avatar"
 	SETTINGS_PASSWORD                  = "user/settings/password"
 	SETTINGS_EMAILS                    = "user/settings/email"
+	SETTINGS_NOTIFICATIONS             = "user/settings/notifications"
 	SETTINGS_SSH_KEYS                  = "user/settings/sshkeys"
 	SETTINGS_SECURITY                  = "user/settings/security"
 	SETTINGS_TWO_FACTOR_ENABLE         = "user/settings/two_factor_enable"
@@ -322,6 +323,48 @@ func DeleteEmail(c *context.Context) {
 	})
 }
 
+func SettingsNotifications(c *context.Context) {
+	c.Title("settings.notifications")
+	c.PageIs("SettingsNotifications")
+
+	pref, err := database.Handle.NotificationPreferences().Get(c.Req.Context(), c.User.ID)
+	if err != nil {
+		c.Error(err, "get notification preference")
+		return
+	}
+	c.Data["Preference"] = pref
+	c.Data["Categories"] = database.NotificationCategories
+	c.Data["Deliveries"] = database.EmailDeliveries
+	c.Data["EnableEmailNotification"] = conf.User.EnableEmailNotification
+
+	c.Success(SETTINGS_NOTIFICATIONS)
+}
+
+func SettingsNotificationsPost(c *context.Context, f form.NotificationPreference) {
+	if c.HasError() {
+		c.Flash.Error(c.Data["ErrorMsg"].(string))
+		c.RedirectSubpath("/user/settings/notifications")
+		return
+	}
+
+	err := database.Handle.NotificationPreferences().Save(c.Req.Context(),
+		&database.NotificationPreference{
+			UserID:        c.User.ID,
+			OwnActivity:   database.EmailDelivery(f.OwnActivity),
+			Mention:       database.EmailDelivery(f.Mention),
+			Participating: database.EmailDelivery(f.Participating),
+			Watching:      database.EmailDelivery(f.Watching),
+		},
+	)
+	if err != nil {
+		c.Error(err, "save notification preference")
+		return
+	}
+
+	c.Flash.Success(c.Tr("settings.update_notification_settings_success"))
+	c.RedirectSubpath("/user/settings/notifications")
+}
+
 func SettingsSSHKeys(c *context.Context) {
 	c.Title("settings.ssh_keys")
 	c.PageIs("SettingsSSHKeys")
@@ -544,6 +587,14 @@ func SettingsRepos(c *context.Context) {
 	}
 	c.Data["Repos"] = repos
 
+	if conf.Quota.Enabled {
+		c.Data["StorageUsage"], err = database.Handle.Quotas().GetUsage(c.Req.Context(), c.User.ID)
+		if err != nil {
+			c.Errorf(err, "get storage usage")
+			return
+		}
+	}
+
 	c.Success(SETTINGS_REPOSITORIES)
 }
 
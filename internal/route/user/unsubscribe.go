@@ -0,0 +1,68 @@
+// Copyright 2024 The Gogs Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"gogs.io/gogs/internal/context"
+	"gogs.io/gogs/internal/database"
+	"gogs.io/gogs/internal/email"
+)
+
+const (
+	UNSUBSCRIBE = "user/unsubscribe"
+)
+
+// getUnsubscribeIssue returns the unsubscribe token and its issue
+// from the link in a notification email.
+func getUnsubscribeIssue(c *context.Context) (*email.Token, *database.Issue) {
+	token, err := email.ParseToken(c.Params(":token"))
+	if err != nil || token.Action != email.TokenActionUnsubscribe {
+		c.NotFound()
+		return nil, nil
+	}
+
+	user, err := database.Handle.Users().GetByID(c.Req.Context(), token.UserID)
+	if err != nil {
+		c.NotFoundOrError(err, "get user by ID")
+		return nil, nil
+	} else if !token.Verify(user.Rands) {
+		c.NotFound()
+		return nil, nil
+	}
+
+	issue, err := database.GetIssueByID(token.IssueID)
+	if err != nil {
+		c.NotFoundOrError(err, "get issue by ID")
+		return nil, nil
+	}
+
+	c.Title("user.unsubscribe.title")
+	c.Data["Issue"] = issue
+	return token, issue
+}
+
+// Unsubscribe asks for confirmation of unsubscribing from email notifications
+// of an issue, so that links visited by mail scanners have no effect.
+func Unsubscribe(c *context.Context) {
+	getUnsubscribeIssue(c)
+	if c.Written() {
+		return
+	}
+	c.Success(UNSUBSCRIBE)
+}
+
+func UnsubscribePost(c *context.Context) {
+	token, issue := getUnsubscribeIssue(c)
+	if c.Written() {
+		return
+	}
+
+	if err := database.UnsubscribeIssue(token.UserID, issue); err != nil {
+		c.Error(err, "unsubscribe issue")
+		return
+	}
+	c.Data["IsUnsubscribed"] = true
+	c.Success(UNSUBSCRIBE)
+}
@@ -7,6 +7,7 @@ package strutil
 import (
 	"crypto/rand"
 	"math/big"
+	"strings"
 	"unicode"
 )
 
@@ -63,3 +64,21 @@ func Truncate(str string, limit int) string {
 	}
 	return str[:limit]
 }
+
+// EscapeCSVCell prefixes s with a single quote if it starts with a character
+// that makes spreadsheet applications treat the cell as a formula.
+func EscapeCSVCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// UnescapeCSVCell returns s with the single quote prefixed by EscapeCSVCell
+// removed.
+func UnescapeCSVCell(s string) string {
+	if len(s) > 1 && s[0] == '\'' && EscapeCSVCell(s[1:]) != s[1:] {
+		return s[1:]
+	}
+	return s
+}
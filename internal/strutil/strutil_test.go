@@ -135,3 +135,27 @@ func TestTruncate(t *testing.T) {
 		})
 	}
 }
+
+func TestEscapeCSVCell(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "", want: ""},
+		{s: "Crash on start", want: "Crash on start"},
+		{s: "=HYPERLINK(\"http://example.com\")", want: "'=HYPERLINK(\"http://example.com\")"},
+		{s: "+1", want: "'+1"},
+		{s: "-1", want: "'-1"},
+		{s: "@SUM(A1)", want: "'@SUM(A1)"},
+		{s: "\t=1", want: "'\t=1"},
+		{s: "\r=1", want: "'\r=1"},
+		{s: "'quoted", want: "'quoted"},
+	}
+	for _, test := range tests {
+		t.Run(test.s, func(t *testing.T) {
+			got := EscapeCSVCell(test.s)
+			assert.Equal(t, test.want, got)
+			assert.Equal(t, test.s, UnescapeCSVCell(got))
+		})
+	}
+}
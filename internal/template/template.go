@@ -82,6 +82,7 @@ func FuncMap() []template.FuncMap {
 			"TimeSince":        tool.TimeSince,
 			"RawTimeSince":     tool.RawTimeSince,
 			"FileSize":         tool.FileSize,
+			"FormatDuration":   tool.FormatDuration,
 			"Subtract":         tool.Subtract,
 			"Add": func(a, b int) int {
 				return a + b
@@ -118,6 +119,7 @@ func FuncMap() []template.FuncMap {
 			"ThemeColorMetaTag": func() string {
 				return conf.UI.ThemeColorMetaTag
 			},
+			"ReactionEmoji": database.ReactionEmoji,
 			"FilenameIsImage": func(filename string) bool {
 				mimeType := mime.TypeByExtension(filepath.Ext(filename))
 				return strings.HasPrefix(mimeType, "image/")
@@ -245,6 +247,8 @@ func ActionIcon(opType int) string {
 		return "repo-forked"
 	case 20, 21, 22: // Mirror sync
 		return "repo-clone"
+	case 23: // Transfer issue
+		return "issue-opened"
 	default:
 		return "invalid type"
 	}
@@ -305,6 +305,26 @@ func TimeSince(t time.Time, lang string) template.HTML {
 	return template.HTML(fmt.Sprintf(`<span class="time-since" title="%s">%s</span>`, t.Format(conf.Time.FormatLayout), timeSince(t, lang)))
 }
 
+// FormatDuration formats the duration in seconds into a compact string, e.g.
+// "1h 30m 5s". Zero units are omitted.
+func FormatDuration(seconds int64) string {
+	if seconds <= 0 {
+		return "0s"
+	}
+
+	var parts []string
+	if h := seconds / Hour; h > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", h))
+	}
+	if m := seconds % Hour / Minute; m > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", m))
+	}
+	if s := seconds % Minute; s > 0 {
+		parts = append(parts, fmt.Sprintf("%ds", s))
+	}
+	return strings.Join(parts, " ")
+}
+
 // Subtract deals with subtraction of all types of number.
 func Subtract(left, right any) any {
 	var rleft, rright int64
@@ -6,6 +6,7 @@ package userutil
 
 import (
 	"bytes"
+	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/hex"
@@ -139,3 +140,31 @@ func TwoFactorCacheKey(userID int64, passcode string) string {
 func RandomSalt() (string, error) {
 	return strutil.RandomChars(10)
 }
+
+// FeedToken returns the token for the user to subscribe to private feeds, which
+// is revoked once the rands of the user is regenerated, e.g. when the password
+// is changed.
+func FeedToken(userID int64, rands string) string {
+	h := hmac.New(sha256.New, []byte(conf.Security.SecretKey))
+	_, _ = fmt.Fprintf(h, "feed-%d-%s", userID, rands)
+	return fmt.Sprintf("%d-%s", userID, hex.EncodeToString(h.Sum(nil))[:32])
+}
+
+// FeedTokenUserID returns the ID of the user that the feed token claims to
+// belong to, which must be verified by ValidateFeedToken.
+func FeedTokenUserID(token string) (int64, bool) {
+	id, _, ok := strings.Cut(token, "-")
+	if !ok {
+		return 0, false
+	}
+	userID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, false
+	}
+	return userID, true
+}
+
+// ValidateFeedToken returns true if the feed token belongs to the user.
+func ValidateFeedToken(token string, userID int64, rands string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(FeedToken(userID, rands))) == 1
+}
@@ -7,6 +7,7 @@ package userutil
 import (
 	"os"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -199,3 +200,29 @@ func TestRandomSalt(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotEqual(t, salt1, salt2)
 }
+
+func TestFeedToken(t *testing.T) {
+	before := conf.Security.SecretKey
+	conf.Security.SecretKey = "secret"
+	t.Cleanup(func() {
+		conf.Security.SecretKey = before
+	})
+
+	token := FeedToken(1, "rands")
+	assert.True(t, strings.HasPrefix(token, "1-"))
+
+	userID, ok := FeedTokenUserID(token)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), userID)
+	assert.True(t, ValidateFeedToken(token, 1, "rands"))
+
+	// The token is revoked once rands is regenerated
+	assert.False(t, ValidateFeedToken(token, 1, "new-rands"))
+	// The token cannot be used by another user
+	assert.False(t, ValidateFeedToken(token, 2, "rands"))
+
+	for _, token := range []string{"", "1", "abc-def", "0-def"} {
+		_, ok = FeedTokenUserID(token)
+		assert.False(t, ok, token)
+	}
+}
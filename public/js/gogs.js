@@ -120,12 +120,6 @@ function initCommentForm() {
 
   initCommentPreviewTab($(".comment.form"));
 
-  // Labels
-  var $list = $(".ui.labels.list");
-  var $noSelect = $list.find(".no-select");
-  var $labelMenu = $(".select-label .menu");
-  var hasLabelUpdateAction = $labelMenu.data("action") == "update";
-
   function updateIssueMeta(url, action, id) {
     $.post(url, {
       _csrf: csrf,
@@ -134,92 +128,103 @@ function initCommentForm() {
     });
   }
 
-  // Add &nbsp; to each unselected label to keep UI looks good.
-  // This should be added directly to HTML but somehow just get empty <span> on this page.
-  $labelMenu
-    .find(".item:not(.no-select) .octicon:not(.octicon-check)")
-    .each(function() {
-      $(this).html("&nbsp;");
-    });
-  $labelMenu.find(".item:not(.no-select)").click(function() {
-    if ($(this).hasClass("checked")) {
-      $(this).removeClass("checked");
-      $(this)
-        .find(".octicon")
-        .removeClass("octicon-check")
-        .html("&nbsp;");
-      if (hasLabelUpdateAction) {
-        updateIssueMeta(
-          $labelMenu.data("update-url"),
-          "detach",
-          $(this).data("id")
-        );
-      }
-    } else {
-      $(this).addClass("checked");
-      $(this)
-        .find(".octicon")
-        .addClass("octicon-check")
-        .html("");
-      if (hasLabelUpdateAction) {
-        updateIssueMeta(
-          $labelMenu.data("update-url"),
-          "attach",
-          $(this).data("id")
-        );
-      }
-    }
+  function selectMultipleItems(select_id, list_selector) {
+    var $list = $(list_selector);
+    var $noSelect = $list.find(".no-select");
+    var $menu = $(select_id + " .menu");
+    var hasUpdateAction = $menu.data("action") == "update";
 
-    var labelIds = "";
-    $(this)
-      .parent()
-      .find(".item")
+    // Add &nbsp; to each unselected item to keep UI looks good.
+    // This should be added directly to HTML but somehow just get empty <span> on this page.
+    $menu
+      .find(".item:not(.no-select) .octicon:not(.octicon-check)")
       .each(function() {
-        if ($(this).hasClass("checked")) {
-          labelIds += $(this).data("id") + ",";
-          $($(this).data("id-selector")).removeClass("hide");
-        } else {
-          $($(this).data("id-selector")).addClass("hide");
-        }
+        $(this).html("&nbsp;");
       });
-    if (labelIds.length == 0) {
-      $noSelect.removeClass("hide");
-    } else {
-      $noSelect.addClass("hide");
-    }
-    $(
-      $(this)
-        .parent()
-        .data("id")
-    ).val(labelIds);
-    return false;
-  });
-  $labelMenu.find(".no-select.item").click(function() {
-    if (hasLabelUpdateAction) {
-      updateIssueMeta($labelMenu.data("update-url"), "clear", "");
-    }
-
-    $(this)
-      .parent()
-      .find(".item")
-      .each(function() {
+    $menu.find(".item:not(.no-select)").click(function() {
+      if ($(this).hasClass("checked")) {
         $(this).removeClass("checked");
         $(this)
           .find(".octicon")
           .removeClass("octicon-check")
           .html("&nbsp;");
-      });
+        if (hasUpdateAction) {
+          updateIssueMeta(
+            $menu.data("update-url"),
+            "detach",
+            $(this).data("id")
+          );
+        }
+      } else {
+        $(this).addClass("checked");
+        $(this)
+          .find(".octicon")
+          .addClass("octicon-check")
+          .html("");
+        if (hasUpdateAction) {
+          updateIssueMeta(
+            $menu.data("update-url"),
+            "attach",
+            $(this).data("id")
+          );
+        }
+      }
 
-    $list.find(".item").each(function() {
-      $(this).addClass("hide");
+      var selectedIds = "";
+      $(this)
+        .parent()
+        .find(".item")
+        .each(function() {
+          if ($(this).hasClass("checked")) {
+            selectedIds += $(this).data("id") + ",";
+            $($(this).data("id-selector")).removeClass("hide");
+          } else {
+            $($(this).data("id-selector")).addClass("hide");
+          }
+        });
+      if (selectedIds.length == 0) {
+        $noSelect.removeClass("hide");
+      } else {
+        $noSelect.addClass("hide");
+      }
+      $(
+        $(this)
+          .parent()
+          .data("id")
+      ).val(selectedIds);
+      return false;
     });
-    $noSelect.removeClass("hide");
-    $(
+    $menu.find(".no-select.item").click(function() {
+      if (hasUpdateAction) {
+        updateIssueMeta($menu.data("update-url"), "clear", "");
+      }
+
       $(this)
         .parent()
-        .data("id")
-    ).val("");
-  });
+        .find(".item")
+        .each(function() {
+          $(this).removeClass("checked");
+          $(this)
+            .find(".octicon")
+            .removeClass("octicon-check")
+            .html("&nbsp;");
+        });
+
+      $list.find(".item").each(function() {
+        $(this).addClass("hide");
+      });
+      $noSelect.removeClass("hide");
+      $(
+        $(this)
+          .parent()
+          .data("id")
+      ).val("");
+    });
+  }
+
+  // Labels and assignees
+  selectMultipleItems(".select-label", ".ui.labels.list");
+  selectMultipleItems(".select-assignees", ".ui.assignees.list");
 
   function selectItem(select_id, input_id) {
     var $menu = $(select_id + " .menu");
@@ -250,19 +255,6 @@ function initCommentForm() {
                 "</a>"
             );
           break;
-        case "#assignee_id":
-          $list
-            .find(".selected")
-            .html(
-              '<a class="item" href=' +
-                $(this).data("href") +
-                ">" +
-                '<img class="ui avatar image" src=' +
-                $(this).data("avatar") +
-                ">" +
-                $(this).text() +
-                "</a>"
-            );
       }
       $(".ui" + select_id + ".list .no-select").addClass("hide");
       $(input_id).val($(this).data("id"));
@@ -285,9 +277,8 @@ function initCommentForm() {
     });
   }
 
-  // Milestone and assignee
+  // Milestone
   selectItem(".select-milestone", "#milestone_id");
-  selectItem(".select-assignee", "#assignee_id");
 }
 
 function initRepository() {
@@ -364,7 +355,7 @@ function initRepository() {
   }
 
   // Labels
-  if ($(".repository.labels").length > 0) {
+  if ($(".repository.labels, .organization.labels").length > 0) {
     // Create label
     var $newLabelPanel = $(".new-label.segment");
     $(".new-label.button").click(function() {
@@ -404,7 +395,7 @@ function initRepository() {
   // Milestones
   if ($(".repository.milestones").length > 0) {
   }
-  if ($(".repository.new.milestone").length > 0) {
+  if ($(".repository.new.milestone, .organization.new.milestone").length > 0) {
     var $datepicker = $(".milestone.datepicker");
     $datepicker.datetimepicker({
       lang: $datepicker.data("lang"),
@@ -567,6 +558,14 @@ function initRepository() {
       $("#status").val($statusButton.data("status-val"));
       $("#comment-form").submit();
     });
+
+    // Toggle reaction
+    $(".reactions [data-type]").click(function() {
+      var $form = $(this).closest("form");
+      $form.find("input[name=type]").val($(this).data("type"));
+      $form.submit();
+      return false;
+    });
   }
 
   // Diff
@@ -1022,6 +1021,67 @@ function initOrganization() {
   }
 }
 
+function initProjectBoard() {
+  var $board = $(".project.board");
+  if ($board.length == 0) {
+    return;
+  }
+
+  var $dragging = null;
+  $board.find(".project.card[draggable=true]").on("dragstart", function(e) {
+    $dragging = $(this);
+    $dragging.addClass("dragging");
+    e.originalEvent.dataTransfer.effectAllowed = "move";
+    e.originalEvent.dataTransfer.setData("text/plain", $dragging.data("id"));
+  });
+  $board.find(".project.card[draggable=true]").on("dragend", function() {
+    if ($dragging) {
+      $dragging.removeClass("dragging");
+      $dragging = null;
+    }
+  });
+
+  $board.find(".project.cards").on("dragover", function(e) {
+    if (!$dragging) {
+      return;
+    }
+    e.preventDefault();
+
+    // Place the card before the first card whose middle is below the cursor.
+    var $before = null;
+    $(this)
+      .children(".project.card")
+      .not($dragging)
+      .each(function() {
+        var rect = this.getBoundingClientRect();
+        if (e.originalEvent.clientY < rect.top + rect.height / 2) {
+          $before = $(this);
+          return false;
+        }
+      });
+    if ($before) {
+      $dragging.insertBefore($before);
+    } else {
+      $(this).append($dragging);
+    }
+  });
+  $board.find(".project.cards").on("drop", function(e) {
+    if (!$dragging) {
+      return;
+    }
+    e.preventDefault();
+
+    var $card = $dragging;
+    $.post($board.data("url") + "/" + $card.data("id") + "/move", {
+      _csrf: csrf,
+      column_id: $(this).data("column-id"),
+      position: $card.index()
+    }).fail(function() {
+      window.location.reload();
+    });
+  });
+}
+
 function initAdmin() {
   if ($(".admin").length == 0) {
     return;
@@ -1411,6 +1471,122 @@ function initWebhookSettings() {
   });
 }
 
+// initMentionAutocomplete suggests teams to mention when typing "@" in textareas
+// that have the URL to fetch mentionable teams.
+function initMentionAutocomplete() {
+  var $menu = $('<div class="ui vertical menu mention-suggestions"></div>')
+    .hide()
+    .appendTo("body");
+  var mentionPattern = /(^|\s)@([0-9a-zA-Z-_.]+(\/[0-9a-zA-Z-_.]*)?)$/;
+  var $textarea = null;
+  var query = null;
+
+  var select = function($item) {
+    var textarea = $textarea[0];
+    var caret = textarea.selectionStart;
+    var before =
+      textarea.value.substring(0, caret - query.length) +
+      $item.data("name") +
+      " ";
+    textarea.value = before + textarea.value.substring(caret);
+    textarea.selectionStart = textarea.selectionEnd = before.length;
+    $menu.hide();
+    $textarea.focus();
+  };
+
+  $(document).on("keyup", "textarea[data-mention-url]", function(e) {
+    // Keys to navigate suggestions are handled on keydown
+    if ($menu.is(":visible") && [9, 13, 27, 38, 40].indexOf(e.keyCode) > -1) {
+      return;
+    }
+
+    $textarea = $(this);
+    var m = this.value.substring(0, this.selectionStart).match(mentionPattern);
+    if (!m) {
+      $menu.hide();
+      return;
+    }
+    query = m[2];
+
+    var current = query;
+    $.getJSON($textarea.data("mention-url"), { q: query }, function(teams) {
+      // Ignore outdated responses
+      if (current !== query) {
+        return;
+      }
+
+      $menu.empty();
+      if (!teams.length) {
+        $menu.hide();
+        return;
+      }
+      $.each(teams, function(i, team) {
+        var $item = $('<a class="item"></a>')
+          .data("name", team.name)
+          .text("@" + team.name);
+        if (team.description) {
+          $item.append(
+            $('<span class="text grey"></span>').text(team.description)
+          );
+        }
+        $menu.append($item);
+      });
+      $menu
+        .children()
+        .first()
+        .addClass("active");
+
+      var offset = $textarea.offset();
+      $menu
+        .css({
+          top: offset.top + $textarea.outerHeight(),
+          left: offset.left
+        })
+        .show();
+    });
+  });
+
+  $(document).on("keydown", "textarea[data-mention-url]", function(e) {
+    if (!$menu.is(":visible")) {
+      return;
+    }
+
+    var $active = $menu.children(".active");
+    var $next;
+    switch (e.keyCode) {
+      case 38: // Up
+        $next = $active.prev();
+        break;
+      case 40: // Down
+        $next = $active.next();
+        break;
+      case 9: // Tab
+      case 13: // Enter
+        e.preventDefault();
+        select($active);
+        return;
+      case 27: // Escape
+        e.preventDefault();
+        $menu.hide();
+        return;
+      default:
+        return;
+    }
+
+    e.preventDefault();
+    if ($next.length) {
+      $active.removeClass("active");
+      $next.addClass("active");
+    }
+  });
+
+  $menu.on("mousedown", ".item", function(e) {
+    e.preventDefault();
+    select($(this));
+  });
+  hideWhenLostFocus(".mention-suggestions", ".mention-suggestions");
+}
+
 $(document).ready(function() {
   csrf = $("meta[name=_csrf]").attr("content");
   suburl = $("meta[name=_suburl]").attr("content");
@@ -1666,6 +1842,7 @@ $(document).ready(function() {
   buttonsClickOnEnter();
   searchUsers();
   searchRepositories();
+  initMentionAutocomplete();
 
   initCommentForm();
   initRepository();
@@ -1673,6 +1850,7 @@ $(document).ready(function() {
   initEditForm();
   initEditor();
   initOrganization();
+  initProjectBoard();
   initAdmin();
   initCodeView();
 